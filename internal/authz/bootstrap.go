@@ -32,6 +32,7 @@ func BuiltinRoleSeeds() []RoleSeed {
 			Policies: []Policy{
 				{Object: "/admin/products", Action: "*"},
 				{Object: "/admin/products/:id", Action: "*"},
+				{Object: "/admin/products/:id/license-key", Action: "*"},
 				{Object: "/admin/categories", Action: "*"},
 				{Object: "/admin/categories/:id", Action: "*"},
 				{Object: "/admin/posts", Action: "*"},
@@ -42,6 +43,8 @@ func BuiltinRoleSeeds() []RoleSeed {
 				{Object: "/admin/coupons/:id", Action: "*"},
 				{Object: "/admin/promotions", Action: "*"},
 				{Object: "/admin/promotions/:id", Action: "*"},
+				{Object: "/admin/wallet/recharge-bonus-rules", Action: "*"},
+				{Object: "/admin/wallet/recharge-bonus-rules/:id", Action: "*"},
 				{Object: "/admin/card-secrets", Action: "*"},
 				{Object: "/admin/card-secrets/:id", Action: "*"},
 				{Object: "/admin/card-secrets/batch", Action: "POST"},
@@ -51,6 +54,7 @@ func BuiltinRoleSeeds() []RoleSeed {
 				{Object: "/admin/card-secrets/export", Action: "POST"},
 				{Object: "/admin/card-secrets/stats", Action: "GET"},
 				{Object: "/admin/card-secrets/batches", Action: "GET"},
+				{Object: "/admin/card-secrets/defect-report", Action: "GET"},
 				{Object: "/admin/card-secrets/template", Action: "GET"},
 				{Object: "/admin/gift-cards", Action: "*"},
 				{Object: "/admin/gift-cards/:id", Action: "*"},
@@ -60,9 +64,12 @@ func BuiltinRoleSeeds() []RoleSeed {
 				{Object: "/admin/upload", Action: "POST"},
 				{Object: "/admin/media/:id", Action: "PUT"},
 				{Object: "/admin/media/:id", Action: "DELETE"},
+				{Object: "/admin/file-assets", Action: "*"},
+				{Object: "/admin/file-assets/:id", Action: "DELETE"},
 				{Object: "/admin/affiliates/users", Action: "GET"},
 				{Object: "/admin/affiliates/users/:id/status", Action: "PATCH"},
 				{Object: "/admin/affiliates/users/batch-status", Action: "PATCH"},
+				{Object: "/admin/affiliates/users/:id/risk-report", Action: "GET"},
 				// 会员等级管理
 				{Object: "/admin/member-levels", Action: "*"},
 				{Object: "/admin/member-levels/:id", Action: "*"},
@@ -81,6 +88,13 @@ func BuiltinRoleSeeds() []RoleSeed {
 				{Object: "/admin/orders/:id", Action: "GET"},
 				{Object: "/admin/orders/:id", Action: "PATCH"},
 				{Object: "/admin/orders/:id/fulfillment/download", Action: "GET"},
+				{Object: "/admin/orders/:id/delivery-links", Action: "GET"},
+				{Object: "/admin/orders/:id/webhook-fulfillments", Action: "GET"},
+				{Object: "/admin/orders/:id/webhook-fulfillments/retry", Action: "POST"},
+				{Object: "/admin/orders/:id/licenses", Action: "GET"},
+				{Object: "/admin/orders/:id/file-downloads", Action: "GET"},
+				{Object: "/admin/licenses/:id/revoke", Action: "POST"},
+				{Object: "/admin/orders/:id/card-secret-replacements", Action: "*"},
 				{Object: "/admin/orders/:id/refund-to-wallet", Action: "POST"},
 				{Object: "/admin/orders/:id/manual-refund", Action: "POST"},
 				{Object: "/admin/order-refunds", Action: "GET"},
@@ -93,11 +107,13 @@ func BuiltinRoleSeeds() []RoleSeed {
 				{Object: "/admin/users/:id/coupon-usages", Action: "GET"},
 				{Object: "/admin/users/:id/wallet", Action: "GET"},
 				{Object: "/admin/users/:id/wallet/transactions", Action: "GET"},
+				{Object: "/admin/users/:id/wallet/statement", Action: "GET"},
 				{Object: "/admin/users/:id/wallet/adjust", Action: "POST"},
 				{Object: "/admin/users/:id/member-level", Action: "PUT"},
 				{Object: "/admin/users/:id/2fa", Action: "DELETE"}, // 客服协助用户重置丢失 TOTP+恢复码 的 2FA
 				{Object: "/admin/user-login-logs", Action: "GET"},
 				{Object: "/admin/wallet/recharges", Action: "GET"},
+				{Object: "/admin/wallet/transfers", Action: "GET"},
 				{Object: "/admin/payments", Action: "GET"},
 				{Object: "/admin/payments/:id", Action: "GET"},
 				{Object: "/admin/gift-cards", Action: "GET"},
@@ -111,12 +127,17 @@ func BuiltinRoleSeeds() []RoleSeed {
 				{Object: "/admin/site-connections", Action: "*"},
 				{Object: "/admin/site-connections/:id", Action: "*"},
 				{Object: "/admin/site-connections/:id/ping", Action: "POST"},
+				{Object: "/admin/site-connections/:id/dry-run", Action: "POST"},
 				{Object: "/admin/site-connections/:id/status", Action: "PUT"},
 				{Object: "/admin/site-connections/:id/reapply-markup", Action: "POST"},
+				{Object: "/admin/site-connections/:id/balance-check", Action: "POST"},
+				{Object: "/admin/site-connections/:id/balance-snapshots", Action: "GET"},
 				{Object: "/admin/product-mappings", Action: "*"},
 				{Object: "/admin/product-mappings/:id", Action: "*"},
 				{Object: "/admin/product-mappings/:id/sync", Action: "POST"},
 				{Object: "/admin/product-mappings/:id/status", Action: "PUT"},
+				{Object: "/admin/product-mappings/:id/margin-guard", Action: "PUT"},
+				{Object: "/admin/margin-guard-events", Action: "GET"},
 				{Object: "/admin/product-mappings/import", Action: "POST"},
 				{Object: "/admin/product-mappings/batch-import", Action: "POST"},
 				{Object: "/admin/product-mappings/batch-sync", Action: "POST"},
@@ -128,7 +149,14 @@ func BuiltinRoleSeeds() []RoleSeed {
 				{Object: "/admin/procurement-orders/:id/upstream-payload/download", Action: "GET"},
 				{Object: "/admin/procurement-orders/:id/retry", Action: "POST"},
 				{Object: "/admin/procurement-orders/:id/cancel", Action: "POST"},
+				{Object: "/admin/card-replenish-rules", Action: "*"},
+				{Object: "/admin/card-replenish-rules/:id", Action: "*"},
+				{Object: "/admin/card-replenish-rules/:id/run", Action: "POST"},
+				{Object: "/admin/sku-sources", Action: "*"},
+				{Object: "/admin/sku-sources/:id", Action: "*"},
 				{Object: "/admin/reconciliation/run", Action: "POST"},
+				{Object: "/admin/reconciliation/payment/import", Action: "POST"},
+				{Object: "/admin/reconciliation/payment/run", Action: "POST"},
 				{Object: "/admin/reconciliation/jobs", Action: "GET"},
 				{Object: "/admin/reconciliation/jobs/:id", Action: "GET"},
 				{Object: "/admin/reconciliation/items/:id/resolve", Action: "PUT"},
@@ -137,6 +165,13 @@ func BuiltinRoleSeeds() []RoleSeed {
 				{Object: "/admin/api-credentials/:id/approve", Action: "POST"},
 				{Object: "/admin/api-credentials/:id/reject", Action: "POST"},
 				{Object: "/admin/api-credentials/:id/status", Action: "PUT"},
+				{Object: "/admin/api-credentials/:id/price-list", Action: "PUT"},
+				{Object: "/admin/api-credential-usages", Action: "GET"},
+				{Object: "/admin/users/:id/price-list", Action: "PUT"},
+				{Object: "/admin/reseller-price-lists", Action: "*"},
+				{Object: "/admin/reseller-price-lists/margins", Action: "GET"},
+				{Object: "/admin/reseller-price-lists/:id", Action: "*"},
+				{Object: "/admin/reseller-price-lists/:id/items", Action: "*"},
 				{Object: "/admin/upstream-products", Action: "GET"},
 			},
 			Immutable: true,
@@ -157,12 +192,29 @@ func BuiltinRoleSeeds() []RoleSeed {
 				{Object: "/admin/order-refunds", Action: "GET"},
 				{Object: "/admin/order-refunds/:id", Action: "GET"},
 				{Object: "/admin/affiliates/commissions", Action: "GET"},
+				{Object: "/admin/affiliates/commissions/:id/approve", Action: "POST"},
+				{Object: "/admin/affiliates/commissions/:id/reject", Action: "POST"},
+				{Object: "/admin/affiliates/users/:id/risk-report", Action: "GET"},
 				{Object: "/admin/affiliates/withdraws", Action: "GET"},
 				{Object: "/admin/affiliates/withdraws/:id/reject", Action: "POST"},
 				{Object: "/admin/affiliates/withdraws/:id/pay", Action: "POST"},
+				{Object: "/admin/affiliates/commission-rules", Action: "*"},
+				{Object: "/admin/affiliates/commission-rules/:id", Action: "DELETE"},
 				{Object: "/admin/gift-cards", Action: "GET"},
 				{Object: "/admin/gift-cards/export", Action: "POST"},
 				{Object: "/admin/wallet/recharges", Action: "GET"},
+				{Object: "/admin/wallet/transfers", Action: "GET"},
+				{Object: "/admin/wallet/withdraws", Action: "GET"},
+				{Object: "/admin/wallet/withdraws/:id/reject", Action: "POST"},
+				{Object: "/admin/wallet/withdraws/:id/pay", Action: "POST"},
+				{Object: "/admin/wallet/withdraws/export", Action: "POST"},
+				{Object: "/admin/wallet/recharges/:id/refund", Action: "POST"},
+				{Object: "/admin/wallet/recharge-bonus-rules", Action: "GET"},
+				{Object: "/admin/wallet/ledger-audits", Action: "GET"},
+				{Object: "/admin/wallet/ledger-audits", Action: "POST"},
+				{Object: "/admin/wallet/ledger-audits/:id", Action: "GET"},
+				{Object: "/admin/wallet/ledger-discrepancies", Action: "GET"},
+				{Object: "/admin/wallet/ledger-discrepancies/:id/resolve", Action: "PUT"},
 			},
 			Immutable: true,
 		},
@@ -198,6 +198,8 @@ type UploadConfig struct {
 	AllowedExtensions []string `mapstructure:"allowed_extensions"`
 	MaxWidth          int      `mapstructure:"max_width"`
 	MaxHeight         int      `mapstructure:"max_height"`
+	PrivateDir        string   `mapstructure:"private_dir"`      // 私有文件（文件交付）存储目录，不对外公开
+	PrivateMaxSize    int64    `mapstructure:"private_max_size"` // 私有文件最大上传大小（字节）
 }
 
 // CORSConfig 跨域配置
@@ -220,6 +222,7 @@ var (
 		"Cache-Control",
 		"X-Requested-With",
 		"X-CSRF-Token",
+		"Idempotency-Key",
 	}
 )
 
@@ -339,6 +342,8 @@ func Load() *Config {
 	})
 	viper.SetDefault("upload.max_width", 4096)
 	viper.SetDefault("upload.max_height", 4096)
+	viper.SetDefault("upload.private_dir", "./storage/private")
+	viper.SetDefault("upload.private_max_size", 524288000)
 	viper.SetDefault("cors.allowed_origins", DefaultCORSAllowedOrigins())
 	viper.SetDefault("cors.allowed_methods", DefaultCORSAllowedMethods())
 	viper.SetDefault("cors.allowed_headers", DefaultCORSAllowedHeaders())
@@ -25,10 +25,64 @@ const (
 	FulfillmentTypeAuto        = "auto"
 	FulfillmentTypeManual      = "manual"
 	FulfillmentTypeUpstream    = "upstream"
+	FulfillmentTypeWebhook     = "webhook"
+	FulfillmentTypeLicense     = "license"
+	FulfillmentTypeFile        = "file"
 	FulfillmentStatusPending   = "pending"
 	FulfillmentStatusDelivered = "delivered"
 )
 
+// 交付内容展示方式常量
+const (
+	DeliveryModeInline     = "inline"
+	DeliveryModeSecureLink = "secure_link"
+)
+
+// Webhook 交付任务状态常量
+const (
+	WebhookFulfillmentStatusPending   = "pending"   // 等待推送或重试中
+	WebhookFulfillmentStatusSucceeded = "succeeded" // 推送成功并已交付
+	WebhookFulfillmentStatusFallback  = "fallback"  // 多次失败已转人工交付
+)
+
+// Webhook 交付默认参数
+const (
+	WebhookFulfillmentDefaultTimeoutSeconds = 10
+	WebhookFulfillmentMaxTimeoutSeconds     = 60
+	WebhookFulfillmentDefaultMaxAttempts    = 5
+	WebhookFulfillmentMaxAttemptsLimit      = 20
+)
+
+// 授权码状态常量
+const (
+	LicenseStatusActive  = "active"
+	LicenseStatusRevoked = "revoked"
+)
+
+// 授权码签发默认值
+const (
+	LicenseKeyPrefix             = "DJL1"
+	LicenseDefaultMaxActivations = 1
+	LicenseMaxActivationsLimit   = 1000
+	LicenseMaxValidDays          = 36500
+	LicenseMachineIDMaxLength    = 191
+)
+
+// 文件交付下载默认值
+const (
+	FileDownloadDefaultExpireHours = 72
+	FileDownloadMaxExpireHours     = 8760
+	FileDownloadDefaultMaxCount    = 5
+	FileDownloadMaxCountLimit      = 1000
+	FileDownloadResumeWindowHours  = 24
+)
+
+// 安全交付链接访问动作常量
+const (
+	DeliveryLinkActionView     = "view"
+	DeliveryLinkActionReceived = "received"
+)
+
 // 支付状态常量
 const (
 	PaymentStatusInitiated = "initiated"
@@ -86,12 +140,73 @@ const (
 
 // 钱包交易类型常量
 const (
-	WalletTxnTypeRecharge    = "recharge"
-	WalletTxnTypeOrderPay    = "order_pay"
-	WalletTxnTypeOrderRefund = "order_refund"
-	WalletTxnTypeAdminAdjust = "admin_adjust"
-	WalletTxnTypeAdminRefund = "admin_refund"
-	WalletTxnTypeGiftCard    = "gift_card_redeem"
+	WalletTxnTypeRecharge        = "recharge"
+	WalletTxnTypeOrderPay        = "order_pay"
+	WalletTxnTypeOrderRefund     = "order_refund"
+	WalletTxnTypeAdminAdjust     = "admin_adjust"
+	WalletTxnTypeAdminRefund     = "admin_refund"
+	WalletTxnTypeGiftCard        = "gift_card_redeem"
+	WalletTxnTypeTransferOut     = "transfer_out"
+	WalletTxnTypeTransferIn      = "transfer_in"
+	WalletTxnTypeTransferFee     = "transfer_fee"
+	WalletTxnTypeWithdrawHold    = "withdraw_hold"
+	WalletTxnTypeWithdrawRelease = "withdraw_release"
+	WalletTxnTypeRechargeBonus   = "recharge_bonus"
+	WalletTxnTypeBonusRevoke     = "recharge_bonus_revoke"
+	WalletTxnTypeRechargeRefund  = "recharge_refund"
+)
+
+// 充值赠送方式常量
+const (
+	WalletRechargeBonusTypeFixed   = "fixed"
+	WalletRechargeBonusTypePercent = "percent"
+)
+
+// 充值赠送发放状态常量
+const (
+	WalletRechargeBonusStatusGranted = "granted"
+	WalletRechargeBonusStatusRevoked = "revoked"
+)
+
+// 钱包提现状态常量
+const (
+	WalletWithdrawStatusPendingReview = "pending_review"
+	WalletWithdrawStatusRejected      = "rejected"
+	WalletWithdrawStatusPaid          = "paid"
+)
+
+// 钱包提现审核动作常量
+const (
+	WalletWithdrawActionReject = "reject"
+	WalletWithdrawActionPay    = "pay"
+)
+
+// 钱包账本核对触发方式常量
+const (
+	WalletLedgerAuditSourceScheduled = "scheduled"
+	WalletLedgerAuditSourceManual    = "manual"
+)
+
+// 钱包账本核对状态常量
+const (
+	WalletLedgerAuditStatusRunning   = "running"
+	WalletLedgerAuditStatusCompleted = "completed"
+	WalletLedgerAuditStatusFailed    = "failed"
+)
+
+// 钱包账本差异类型常量
+const (
+	WalletLedgerDiscrepancyChainBreak  = "chain_break"           // 流水变动前余额与上一笔变动后余额不衔接
+	WalletLedgerDiscrepancyTxnAmount   = "txn_amount_mismatch"   // 流水变动前后余额之差与金额不符
+	WalletLedgerDiscrepancyBalance     = "balance_mismatch"      // 最后一笔流水余额与账户余额不符
+	WalletLedgerDiscrepancyOrderPaid   = "order_paid_mismatch"   // 订单余额支付流水净额与订单钱包支付金额不符
+	WalletLedgerDiscrepancyOrderRefund = "order_refund_mismatch" // 订单退回钱包流水与订单已退款金额不符
+)
+
+// 钱包转账来源常量
+const (
+	WalletTransferSourceWeb     = "web"
+	WalletTransferSourceChannel = "channel"
 )
 
 // 钱包交易方向常量
@@ -102,10 +217,11 @@ const (
 
 // 钱包充值状态常量
 const (
-	WalletRechargeStatusPending = "pending"
-	WalletRechargeStatusSuccess = "success"
-	WalletRechargeStatusFailed  = "failed"
-	WalletRechargeStatusExpired = "expired"
+	WalletRechargeStatusPending  = "pending"
+	WalletRechargeStatusSuccess  = "success"
+	WalletRechargeStatusFailed   = "failed"
+	WalletRechargeStatusExpired  = "expired"
+	WalletRechargeStatusRefunded = "refunded"
 )
 
 // 推广返利状态常量
@@ -117,6 +233,7 @@ const (
 // 推广返利佣金状态常量
 const (
 	AffiliateCommissionStatusPendingConfirm = "pending_confirm"
+	AffiliateCommissionStatusPendingReview  = "pending_review" // 命中风控规则，待人工复核
 	AffiliateCommissionStatusAvailable      = "available"
 	AffiliateCommissionStatusRejected       = "rejected"
 	AffiliateCommissionStatusWithdrawn      = "withdrawn"
@@ -124,7 +241,57 @@ const (
 
 // 推广返利佣金类型常量
 const (
-	AffiliateCommissionTypeOrder = "order"
+	AffiliateCommissionTypeOrder      = "order"
+	AffiliateCommissionTypeSecondTier = "second_tier"
+)
+
+// 推广返利佣金层级常量
+const (
+	AffiliateCommissionLevelDirect     = 1
+	AffiliateCommissionLevelSecondTier = 2
+)
+
+// 推广返利比例规则范围常量
+const (
+	AffiliateRuleScopeCategory  = "category"
+	AffiliateRuleScopeProduct   = "product"
+	AffiliateRuleScopeSKU       = "sku"
+	AffiliateRuleScopeAffiliate = "affiliate"
+)
+
+// 推广返利佣金计算基数常量
+const (
+	AffiliateCommissionBasisPaidAmount = "paid_amount" // 按实付金额
+	AffiliateCommissionBasisProfit     = "profit"      // 按利润（实付金额 - 成本价）
+)
+
+// 推广返利风控规则常量
+const (
+	AffiliateRiskFlagSharedIP           = "shared_ip"           // 买家与推广用户共用 IP
+	AffiliateRiskFlagSharedDevice       = "shared_device"       // 买家与推广用户共用设备
+	AffiliateRiskFlagEmailPattern       = "email_pattern"       // 买家与推广用户邮箱规则相似
+	AffiliateRiskFlagTelegramID         = "telegram_id"         // 买家与推广用户 Telegram 身份相同
+	AffiliateRiskFlagAbnormalConversion = "abnormal_conversion" // 点击下单比异常
+	AffiliateRiskFlagRepeatedRefund     = "repeated_refund"     // 同一被推广用户反复退款
+)
+
+// 推广返利佣金复核动作常量
+const (
+	AffiliateCommissionActionApprove = "approve"
+	AffiliateCommissionActionReject  = "reject"
+)
+
+// 推广用户风险等级常量
+const (
+	AffiliateRiskLevelLow    = "low"
+	AffiliateRiskLevelMedium = "medium"
+	AffiliateRiskLevelHigh   = "high"
+)
+
+// 推广关系绑定来源常量
+const (
+	AffiliateReferralSourceRegister = "register"
+	AffiliateReferralSourceOrder    = "order"
 )
 
 // 推广返利提现状态常量
@@ -313,6 +480,9 @@ const (
 	QueueDefault                    = "default"
 	TaskOrderStatusEmail            = "order:status_email"
 	TaskOrderAutoFulfill            = "order:auto_fulfill"
+	TaskOrderWebhookFulfill         = "order:webhook_fulfill"
+	TaskOrderLicenseIssue           = "order:license_issue"
+	TaskOrderFileDeliver            = "order:file_deliver"
 	TaskOrderTimeoutCancel          = "order:timeout_cancel"
 	TaskWalletRechargeExpire        = "wallet_recharge:timeout_expire"
 	TaskNotificationDispatch        = "notification:dispatch"
@@ -320,12 +490,29 @@ const (
 	TaskProcurementSubmit           = "procurement:submit"
 	TaskProcurementPollStatus       = "procurement:poll_status"
 	TaskProcurementSyncAccepted     = "procurement:sync_accepted"
+	TaskCardReplenishRun            = "card_replenish:run"
 	TaskUpstreamSyncProducts        = "upstream:sync_products"
 	TaskUpstreamSyncStock           = "upstream:sync_stock"
+	TaskUpstreamBalanceCheck        = "upstream:balance_check"
+	TaskWalletLedgerAudit           = "wallet:ledger_audit"
 	TaskReconciliationRun           = "reconciliation:run"
 	TaskDownstreamCallback          = "downstream:callback"
+	TaskCatalogWebhookDispatch      = "catalog_webhook:dispatch"
 	TaskBotNotify                   = "bot:notify"
 	TaskTelegramBroadcast           = "telegram:broadcast"
+	TaskIdempotencyPurge            = "idempotency:purge"
+)
+
+// 幂等键记录状态
+const (
+	IdempotencyStatusProcessing = "processing"
+	IdempotencyStatusCompleted  = "completed"
+)
+
+// 分销价目表定价方式
+const (
+	ResellerPriceModeFixed    = "fixed"    // 固定价
+	ResellerPriceModeDiscount = "discount" // 按零售价折扣
 )
 
 // Telegram Bot 群发常量
@@ -361,7 +548,26 @@ const (
 
 // 对接协议类型常量
 const (
-	ConnectionProtocolDujiaoNext = "dujiao-next"
+	ConnectionProtocolDujiaoNext  = "dujiao-next"
+	ConnectionProtocolGenericREST = "generic-rest" // 模板驱动的通用 REST 上游
+)
+
+// 商品目录推送事件常量（B 站推送给已注册 Webhook 的下游站点）
+const (
+	CatalogEventProductChanged = "catalog.product_changed"
+)
+
+// 下游订单回调事件常量（B 站推送到下单时的 callback_url）
+const (
+	CallbackEventOrderStatusChanged = "order.status_changed"
+	CallbackEventOrderFulfilled     = "order.fulfilled"
+)
+
+// 利润保护模式常量
+const (
+	MarginGuardModeOff        = "off"        // 不检查
+	MarginGuardModeDeactivate = "deactivate" // 利润不足时自动停用对应 SKU
+	MarginGuardModeReprice    = "reprice"    // 利润不足时自动调价
 )
 
 // API 凭证状态常量
@@ -372,6 +578,16 @@ const (
 	ApiCredentialStatusDisabled      = "disabled"
 )
 
+// ApiCredentialMaxRateLimitPerMinute 密钥每分钟请求上限的最大可配置值（鉴权前兜底限流按此上限）
+const ApiCredentialMaxRateLimitPerMinute = 600
+
+// API 凭证权限范围（未配置时视为拥有全部权限，兼容历史凭证）
+const (
+	ApiScopeCatalogRead = "catalog:read" // 读取分类、商品与订阅商品变更
+	ApiScopeOrderCreate = "order:create" // 下单与取消订单
+	ApiScopeOrderRead   = "order:read"   // 查询订单
+)
+
 // 下游回调状态常量
 const (
 	CallbackStatusPending = "pending"
@@ -394,6 +610,20 @@ const (
 	ReconciliationJobStatusFailed    = "failed"
 )
 
+// 对账来源常量
+const (
+	ReconciliationSourceUpstream = "upstream" // 采购单 vs 上游站点
+	ReconciliationSourcePayment  = "payment"  // 支付记录 vs 支付网关对账单
+)
+
+// 支付网关对账单格式常量
+const (
+	PaymentStatementFormatAlipay = "alipay" // 支付宝业务明细 CSV
+	PaymentStatementFormatWechat = "wechat" // 微信支付交易账单
+	PaymentStatementFormatStripe = "stripe" // Stripe Balance transactions CSV
+	PaymentStatementFormatPaypal = "paypal" // PayPal Activity CSV
+)
+
 // 缓存默认配置常量
 const (
 	RedisPrefixDefault = "dj"
@@ -462,6 +692,9 @@ const (
 	NotificationBizTypePaymentCallback = "payment_callback"
 	NotificationBizTypeProcurement     = "procurement"
 	NotificationBizTypeReconciliation  = "reconciliation"
+	NotificationBizTypeMarginGuard     = "margin_guard"
+	NotificationBizTypeUpstreamBalance = "upstream_balance"
+	NotificationBizTypeWalletLedger    = "wallet_ledger"
 )
 
 // 对账差异类型常量
@@ -469,12 +702,22 @@ const (
 	MismatchTypeStatus = "status"
 	MismatchTypeAmount = "amount"
 	MismatchTypeBoth   = "both"
+	// 支付对账：本地已支付但对账单中缺失 / 对账单中存在但本地无对应已支付记录
+	MismatchTypeMissing = "missing"
+	MismatchTypeExtra   = "extra"
 )
 
 // 卡密批次来源常量
 const (
-	CardSecretSourceManual = "manual"
-	CardSecretSourceCSV    = "csv"
+	CardSecretSourceManual    = "manual"
+	CardSecretSourceCSV       = "csv"
+	CardSecretSourceReplenish = "replenish"
+)
+
+// SKU 供货来源类型常量
+const (
+	SKUSourceTypeUpstream = "upstream"  // 上游连接
+	SKUSourceTypeCardPool = "card_pool" // 本地卡密库存
 )
 
 // 导出格式常量
@@ -29,6 +29,7 @@ func NewAffiliateProfileResp(p *models.AffiliateProfile) AffiliateProfileResp {
 type AffiliateCommissionResp struct {
 	ID               uint         `json:"id"`
 	CommissionType   string       `json:"commission_type"`
+	Level            int          `json:"level"`
 	CommissionAmount models.Money `json:"commission_amount"`
 	Status           string       `json:"status"`
 	ConfirmAt        *time.Time   `json:"confirm_at,omitempty"`
@@ -41,6 +42,7 @@ func NewAffiliateCommissionResp(c *models.AffiliateCommission) AffiliateCommissi
 	return AffiliateCommissionResp{
 		ID:               c.ID,
 		CommissionType:   c.CommissionType,
+		Level:            c.Level,
 		CommissionAmount: c.CommissionAmount,
 		Status:           c.Status,
 		ConfirmAt:        c.ConfirmAt,
@@ -48,7 +50,8 @@ func NewAffiliateCommissionResp(c *models.AffiliateCommission) AffiliateCommissi
 		CreatedAt:        c.CreatedAt,
 	}
 	// 排除：AffiliateProfileID、OrderItemID、BaseAmount、RatePercent、
-	// WithdrawRequestID、InvalidReason、UpdatedAt、关联 Order/AffiliateProfile/WithdrawRequest
+	// WithdrawRequestID、InvalidReason、RiskFlags、ProcessedBy、ProcessedAt、UpdatedAt、
+	// 关联 Order/AffiliateProfile/WithdrawRequest
 }
 
 // NewAffiliateCommissionRespList 批量转换佣金列表
@@ -62,25 +65,29 @@ func NewAffiliateCommissionRespList(commissions []models.AffiliateCommission) []
 
 // AffiliateWithdrawResp 提现记录响应
 type AffiliateWithdrawResp struct {
-	ID           uint         `json:"id"`
-	Amount       models.Money `json:"amount"`
-	Channel      string       `json:"channel"`
-	Account      string       `json:"account"`
-	Status       string       `json:"status"`
-	RejectReason string       `json:"reject_reason,omitempty"`
-	CreatedAt    time.Time    `json:"created_at"`
+	ID               uint         `json:"id"`
+	Amount           models.Money `json:"amount"`
+	DirectAmount     models.Money `json:"direct_amount"`
+	SecondTierAmount models.Money `json:"second_tier_amount"`
+	Channel          string       `json:"channel"`
+	Account          string       `json:"account"`
+	Status           string       `json:"status"`
+	RejectReason     string       `json:"reject_reason,omitempty"`
+	CreatedAt        time.Time    `json:"created_at"`
 }
 
 // NewAffiliateWithdrawResp 从 models.AffiliateWithdrawRequest 构造响应
 func NewAffiliateWithdrawResp(w *models.AffiliateWithdrawRequest) AffiliateWithdrawResp {
 	return AffiliateWithdrawResp{
-		ID:           w.ID,
-		Amount:       w.Amount,
-		Channel:      w.Channel,
-		Account:      w.Account,
-		Status:       w.Status,
-		RejectReason: w.RejectReason,
-		CreatedAt:    w.CreatedAt,
+		ID:               w.ID,
+		Amount:           w.Amount,
+		DirectAmount:     w.DirectAmount,
+		SecondTierAmount: w.SecondTierAmount,
+		Channel:          w.Channel,
+		Account:          w.Account,
+		Status:           w.Status,
+		RejectReason:     w.RejectReason,
+		CreatedAt:        w.CreatedAt,
 	}
 	// 排除：AffiliateProfileID、ProcessedBy、ProcessedAt、UpdatedAt、关联
 }
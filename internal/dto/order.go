@@ -151,6 +151,7 @@ type OrderItemResp struct {
 	MemberDiscountAmount     models.Money       `json:"member_discount_amount"`
 	PromotionDiscountAmount  models.Money       `json:"promotion_discount_amount"`
 	FulfillmentType          string             `json:"fulfillment_type"`
+	DeliveryMode             string             `json:"delivery_mode"`
 	ManualFormSchemaSnapshot models.JSON        `json:"manual_form_schema_snapshot"`
 	ManualFormSubmission     models.JSON        `json:"manual_form_submission"`
 	// Instructions 交付使用说明（多语言 raw JSON，与 Title 字段契约一致，由前端按 locale 解析）。
@@ -161,8 +162,10 @@ type OrderItemResp struct {
 
 func newOrderItemResp(item *models.OrderItem) OrderItemResp {
 	ft := item.FulfillmentType
-	if ft == "upstream" {
+	if ft == "upstream" || ft == "webhook" {
 		ft = "manual"
+	} else if ft == "license" || ft == "file" {
+		ft = "auto"
 	}
 	return OrderItemResp{
 		Title:                    item.TitleJSON,
@@ -175,6 +178,7 @@ func newOrderItemResp(item *models.OrderItem) OrderItemResp {
 		MemberDiscountAmount:     item.MemberDiscount,
 		PromotionDiscountAmount:  item.PromotionDiscount,
 		FulfillmentType:          ft,
+		DeliveryMode:             item.DeliveryMode,
 		ManualFormSchemaSnapshot: item.ManualFormSchemaSnapshotJSON,
 		ManualFormSubmission:     item.ManualFormSubmissionJSON,
 		Instructions:             item.InstructionsJSON,
@@ -194,8 +198,10 @@ type FulfillmentResp struct {
 
 func newFulfillmentResp(f *models.Fulfillment) FulfillmentResp {
 	typ := f.Type
-	if typ == "upstream" {
+	if typ == "upstream" || typ == "webhook" {
 		typ = "manual"
+	} else if typ == "license" || typ == "file" {
+		typ = "auto"
 	}
 	return FulfillmentResp{
 		Type:             typ,
@@ -1,32 +1,93 @@
 package dto
 
 import (
+	"strings"
 	"time"
 
+	"github.com/dujiao-next/internal/constants"
 	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/service"
+
+	"github.com/shopspring/decimal"
 )
 
 // WalletAccountResp 钱包账户响应
 type WalletAccountResp struct {
-	Balance models.Money `json:"balance"`
+	Balance     models.Money `json:"balance"`
+	LockedBonus models.Money `json:"locked_bonus"` // 不可提现的赠送余额
+	Currency    string       `json:"currency,omitempty"`
 }
 
 // NewWalletAccountResp 从 models.WalletAccount 构造响应
 func NewWalletAccountResp(a *models.WalletAccount) WalletAccountResp {
 	return WalletAccountResp{
-		Balance: a.Balance,
+		Balance:     a.Balance,
+		LockedBonus: a.LockedBonus,
+		Currency:    a.Currency,
+	}
+}
+
+// NewWalletAccountRespList 批量转换钱包账户
+func NewWalletAccountRespList(accounts []models.WalletAccount) []WalletAccountResp {
+	result := make([]WalletAccountResp, 0, len(accounts))
+	for i := range accounts {
+		result = append(result, NewWalletAccountResp(&accounts[i]))
 	}
+	return result
+}
+
+// WalletOverviewResp 钱包概览响应：站点币种账户 + 全部币种账户
+type WalletOverviewResp struct {
+	WalletAccountResp
+	Accounts []WalletAccountResp `json:"accounts"`
+}
+
+// NewWalletOverviewResp 构造钱包概览响应
+func NewWalletOverviewResp(primary *models.WalletAccount, accounts []models.WalletAccount) WalletOverviewResp {
+	return WalletOverviewResp{
+		WalletAccountResp: NewWalletAccountResp(primary),
+		Accounts:          NewWalletAccountRespList(accounts),
+	}
+}
+
+// WalletStatementResp 单一币种钱包对账单响应
+type WalletStatementResp struct {
+	Currency string       `json:"currency"`
+	Balance  models.Money `json:"balance"`
+	TotalIn  models.Money `json:"total_in"`
+	TotalOut models.Money `json:"total_out"`
+	InCount  int64        `json:"in_count"`
+	OutCount int64        `json:"out_count"`
+}
+
+// NewWalletStatementRespList 批量转换钱包对账单
+func NewWalletStatementRespList(items []service.WalletCurrencyStatement) []WalletStatementResp {
+	result := make([]WalletStatementResp, 0, len(items))
+	for _, item := range items {
+		result = append(result, WalletStatementResp{
+			Currency: item.Currency,
+			Balance:  item.Balance,
+			TotalIn:  item.TotalIn,
+			TotalOut: item.TotalOut,
+			InCount:  item.InCount,
+			OutCount: item.OutCount,
+		})
+	}
+	return result
 }
 
 // WalletTransactionResp 钱包流水响应
 type WalletTransactionResp struct {
-	ID           uint         `json:"id"`
-	Type         string       `json:"type"`
-	Direction    string       `json:"direction"`
-	Amount       models.Money `json:"amount"`
-	BalanceAfter models.Money `json:"balance_after"`
-	Remark       string       `json:"remark"`
-	CreatedAt    time.Time    `json:"created_at"`
+	ID           uint             `json:"id"`
+	Type         string           `json:"type"`
+	Direction    string           `json:"direction"`
+	Amount       models.Money     `json:"amount"`
+	BalanceAfter models.Money     `json:"balance_after"`
+	Currency     string           `json:"currency"`
+	ExchangeRate *decimal.Decimal `json:"exchange_rate,omitempty"`
+	SettleAmount *models.Money    `json:"settle_amount,omitempty"`
+	Remark       string           `json:"remark"`
+	CreatedAt    time.Time        `json:"created_at"`
 }
 
 // NewWalletTransactionResp 从 models.WalletTransaction 构造响应
@@ -37,10 +98,13 @@ func NewWalletTransactionResp(t *models.WalletTransaction) WalletTransactionResp
 		Direction:    t.Direction,
 		Amount:       t.Amount,
 		BalanceAfter: t.BalanceAfter,
+		Currency:     t.Currency,
+		ExchangeRate: t.ExchangeRate,
+		SettleAmount: t.SettleAmount,
 		Remark:       t.Remark,
 		CreatedAt:    t.CreatedAt,
 	}
-	// 排除：UserID、Currency、BalanceBefore、Reference、UpdatedAt
+	// 排除：UserID、BalanceBefore、Reference、UpdatedAt
 }
 
 // NewWalletTransactionRespList 批量转换钱包流水
@@ -137,3 +201,146 @@ func NewWalletRechargePaymentPayload(recharge *models.WalletRechargeOrder, payme
 	// 排除 Payment 的：OrderID、ChannelID、Amount、FeeRate、FixedFee、FeeAmount、Currency、
 	// ProviderRef、GatewayOrderNo、ProviderPayload、CreatedAt、UpdatedAt、PaidAt、CallbackAt
 }
+
+// WalletTransferResp 钱包转账响应（方向与对方用户相对于当前查看者）
+type WalletTransferResp struct {
+	TransferNo     string       `json:"transfer_no"`
+	Direction      string       `json:"direction"`
+	CounterpartyID uint         `json:"counterparty_id"`
+	Amount         models.Money `json:"amount"`
+	FeeAmount      models.Money `json:"fee_amount"`
+	Currency       string       `json:"currency"`
+	Remark         string       `json:"remark"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// NewWalletTransferResp 从 models.WalletTransfer 构造响应，转入方不展示手续费
+func NewWalletTransferResp(t *models.WalletTransfer, viewerID uint) WalletTransferResp {
+	resp := WalletTransferResp{
+		TransferNo:     t.TransferNo,
+		Direction:      constants.WalletTxnDirectionOut,
+		CounterpartyID: t.ToUserID,
+		Amount:         t.Amount,
+		FeeAmount:      t.FeeAmount,
+		Currency:       t.Currency,
+		Remark:         t.Remark,
+		CreatedAt:      t.CreatedAt,
+	}
+	if t.ToUserID == viewerID && t.FromUserID != viewerID {
+		resp.Direction = constants.WalletTxnDirectionIn
+		resp.CounterpartyID = t.FromUserID
+		resp.FeeAmount = models.NewMoneyFromDecimal(decimal.Zero)
+	}
+	return resp
+	// 排除：ID、Source、UpdatedAt
+}
+
+// NewWalletTransferRespList 批量转换钱包转账记录
+func NewWalletTransferRespList(transfers []models.WalletTransfer, viewerID uint) []WalletTransferResp {
+	result := make([]WalletTransferResp, 0, len(transfers))
+	for i := range transfers {
+		result = append(result, NewWalletTransferResp(&transfers[i], viewerID))
+	}
+	return result
+}
+
+// WalletTransferRecipientResp 转账收款人确认信息（邮箱脱敏）
+type WalletTransferRecipientResp struct {
+	ID          uint   `json:"id"`
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email"`
+}
+
+// NewWalletTransferRecipientResp 构造收款人确认信息
+func NewWalletTransferRecipientResp(user *models.User) WalletTransferRecipientResp {
+	return WalletTransferRecipientResp{
+		ID:          user.ID,
+		DisplayName: user.DisplayName,
+		Email:       maskTransferRecipientEmail(user.Email),
+	}
+}
+
+func maskTransferRecipientEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return ""
+	}
+	local := []rune(email[:at])
+	return string(local[0]) + "***" + email[at:]
+}
+
+// WalletWithdrawResp 钱包提现申请响应
+type WalletWithdrawResp struct {
+	ID              uint         `json:"id"`
+	WithdrawNo      string       `json:"withdraw_no"`
+	Amount          models.Money `json:"amount"`
+	Currency        string       `json:"currency"`
+	Channel         string       `json:"channel"`
+	Account         string       `json:"account"`
+	AccountName     string       `json:"account_name,omitempty"`
+	Status          string       `json:"status"`
+	RejectReason    string       `json:"reject_reason,omitempty"`
+	PayoutReference string       `json:"payout_reference,omitempty"`
+	ProcessedAt     *time.Time   `json:"processed_at,omitempty"`
+	CreatedAt       time.Time    `json:"created_at"`
+}
+
+// NewWalletWithdrawResp 从 models.WalletWithdrawRequest 构造响应
+func NewWalletWithdrawResp(w *models.WalletWithdrawRequest) WalletWithdrawResp {
+	return WalletWithdrawResp{
+		ID:              w.ID,
+		WithdrawNo:      w.WithdrawNo,
+		Amount:          w.Amount,
+		Currency:        w.Currency,
+		Channel:         w.Channel,
+		Account:         w.Account,
+		AccountName:     w.AccountName,
+		Status:          w.Status,
+		RejectReason:    w.RejectReason,
+		PayoutReference: w.PayoutReference,
+		ProcessedAt:     w.ProcessedAt,
+		CreatedAt:       w.CreatedAt,
+	}
+	// 排除：UserID、ProcessedBy、UpdatedAt、关联
+}
+
+// NewWalletWithdrawRespList 批量转换钱包提现申请
+func NewWalletWithdrawRespList(withdraws []models.WalletWithdrawRequest) []WalletWithdrawResp {
+	result := make([]WalletWithdrawResp, 0, len(withdraws))
+	for i := range withdraws {
+		result = append(result, NewWalletWithdrawResp(&withdraws[i]))
+	}
+	return result
+}
+
+// WalletRechargeBonusRuleResp 充值赠送活动展示响应
+type WalletRechargeBonusRuleResp struct {
+	ID                uint                             `json:"id"`
+	Name              string                           `json:"name"`
+	Currency          string                           `json:"currency,omitempty"`
+	Tiers             []models.WalletRechargeBonusTier `json:"tiers"`
+	FirstRechargeOnly bool                             `json:"first_recharge_only"`
+	Locked            bool                             `json:"locked"`
+	StartsAt          *time.Time                       `json:"starts_at,omitempty"`
+	EndsAt            *time.Time                       `json:"ends_at,omitempty"`
+}
+
+// NewWalletRechargeBonusRuleRespList 批量转换充值赠送活动
+func NewWalletRechargeBonusRuleRespList(rules []models.WalletRechargeBonusRule) []WalletRechargeBonusRuleResp {
+	result := make([]WalletRechargeBonusRuleResp, 0, len(rules))
+	for i := range rules {
+		rule := &rules[i]
+		result = append(result, WalletRechargeBonusRuleResp{
+			ID:                rule.ID,
+			Name:              rule.Name,
+			Currency:          rule.Currency,
+			Tiers:             rule.Tiers,
+			FirstRechargeOnly: rule.FirstRechargeOnly,
+			Locked:            rule.Locked,
+			StartsAt:          rule.StartsAt,
+			EndsAt:            rule.EndsAt,
+		})
+	}
+	// 排除：会员等级与每人上限等内部配置
+	return result
+}
@@ -50,7 +50,7 @@ func TestWalletTransactionRespOmitsSensitiveFields(t *testing.T) {
 	data, _ := json.Marshal(resp)
 	jsonStr := string(data)
 
-	sensitiveFields := []string{"user_id", "balance_before", "reference", "currency", "updated_at"}
+	sensitiveFields := []string{"user_id", "balance_before", "reference", "updated_at"}
 	for _, field := range sensitiveFields {
 		if strings.Contains(jsonStr, `"`+field+`"`) {
 			t.Errorf("sensitive field %q should not appear", field)
@@ -63,6 +63,12 @@ func TestWalletTransactionRespOmitsSensitiveFields(t *testing.T) {
 	if !strings.Contains(jsonStr, `"remark"`) {
 		t.Error("remark should appear")
 	}
+	if !strings.Contains(jsonStr, `"currency":"CNY"`) {
+		t.Error("currency should appear")
+	}
+	if strings.Contains(jsonStr, `"exchange_rate"`) || strings.Contains(jsonStr, `"settle_amount"`) {
+		t.Error("exchange_rate and settle_amount should be omitted for same-currency transactions")
+	}
 }
 
 func TestWalletRechargePaymentPayloadOmitsSensitiveFields(t *testing.T) {
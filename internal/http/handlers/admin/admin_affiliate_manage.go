@@ -61,6 +61,8 @@ func (h *Handler) ListAffiliateCommissions(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	page, pageSize = shared.NormalizePagination(page, pageSize)
 	profileID, _ := shared.ParseQueryUint(c.Query("affiliate_profile_id"), false)
+	level, _ := strconv.Atoi(strings.TrimSpace(c.Query("level")))
+	riskFlagged, _ := strconv.ParseBool(strings.TrimSpace(c.Query("risk_flagged")))
 
 	rows, total, err := h.AffiliateService.ListAdminCommissions(service.AffiliateAdminCommissionListFilter{
 		Page:               page,
@@ -68,6 +70,8 @@ func (h *Handler) ListAffiliateCommissions(c *gin.Context) {
 		AffiliateProfileID: profileID,
 		OrderNo:            strings.TrimSpace(c.Query("order_no")),
 		Status:             strings.TrimSpace(c.Query("status")),
+		Level:              level,
+		RiskFlagged:        riskFlagged,
 		Keyword:            strings.TrimSpace(c.Query("keyword")),
 	})
 	if err != nil {
@@ -233,3 +237,173 @@ func (h *Handler) PayAffiliateWithdraw(c *gin.Context) {
 	}
 	response.Success(c, row)
 }
+
+// AffiliateReviewCommissionRequest 风控佣金复核请求
+type AffiliateReviewCommissionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ApproveAffiliateCommission 复核通过风控佣金（转可提现）
+func (h *Handler) ApproveAffiliateCommission(c *gin.Context) {
+	adminID, ok := shared.GetAdminID(c)
+	if !ok {
+		return
+	}
+	if h.AffiliateService == nil {
+		shared.RespondError(c, response.CodeInternal, "error.save_failed", nil)
+		return
+	}
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
+		return
+	}
+	row, err := h.AffiliateService.ReviewCommission(adminID, id, constants.AffiliateCommissionActionApprove, "")
+	if err != nil {
+		respondAffiliateCommissionReviewError(c, err)
+		return
+	}
+	response.Success(c, row)
+}
+
+// RejectAffiliateCommission 复核驳回风控佣金（作废）
+func (h *Handler) RejectAffiliateCommission(c *gin.Context) {
+	adminID, ok := shared.GetAdminID(c)
+	if !ok {
+		return
+	}
+	if h.AffiliateService == nil {
+		shared.RespondError(c, response.CodeInternal, "error.save_failed", nil)
+		return
+	}
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
+		return
+	}
+
+	var req AffiliateReviewCommissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	row, err := h.AffiliateService.ReviewCommission(adminID, id, constants.AffiliateCommissionActionReject, req.Reason)
+	if err != nil {
+		respondAffiliateCommissionReviewError(c, err)
+		return
+	}
+	response.Success(c, row)
+}
+
+func respondAffiliateCommissionReviewError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		shared.RespondError(c, response.CodeNotFound, "error.bad_request", nil)
+	case errors.Is(err, service.ErrAffiliateCommissionStatusInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
+	default:
+		shared.RespondError(c, response.CodeInternal, "error.save_failed", err)
+	}
+}
+
+// GetAffiliateUserRiskReport 管理端查询推广用户风险报告
+func (h *Handler) GetAffiliateUserRiskReport(c *gin.Context) {
+	if h.AffiliateService == nil {
+		shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", nil)
+		return
+	}
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
+		return
+	}
+	report, err := h.AffiliateService.GetProfileRiskReport(id)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			shared.RespondError(c, response.CodeNotFound, "error.bad_request", nil)
+			return
+		}
+		shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", err)
+		return
+	}
+	response.Success(c, report)
+}
+
+// AffiliateCommissionRuleRequest 返利比例规则保存请求
+type AffiliateCommissionRuleRequest struct {
+	ScopeType   string  `json:"scope_type" binding:"required"`
+	ScopeID     uint    `json:"scope_id" binding:"required"`
+	RatePercent float64 `json:"rate_percent"`
+	Remark      string  `json:"remark"`
+}
+
+// ListAffiliateCommissionRules 管理端返利比例规则列表
+func (h *Handler) ListAffiliateCommissionRules(c *gin.Context) {
+	if h.AffiliateService == nil {
+		shared.RespondError(c, response.CodeInternal, "error.settings_fetch_failed", nil)
+		return
+	}
+	rows, err := h.AffiliateService.ListCommissionRules(strings.TrimSpace(c.Query("scope_type")))
+	if err != nil {
+		if errors.Is(err, service.ErrAffiliateCommissionRuleInvalid) {
+			shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
+			return
+		}
+		shared.RespondError(c, response.CodeInternal, "error.settings_fetch_failed", err)
+		return
+	}
+	response.Success(c, rows)
+}
+
+// SaveAffiliateCommissionRule 管理端新增或更新返利比例规则
+func (h *Handler) SaveAffiliateCommissionRule(c *gin.Context) {
+	if h.AffiliateService == nil {
+		shared.RespondError(c, response.CodeInternal, "error.settings_save_failed", nil)
+		return
+	}
+	var req AffiliateCommissionRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	row, err := h.AffiliateService.SaveCommissionRule(service.AffiliateCommissionRuleInput{
+		ScopeType:   req.ScopeType,
+		ScopeID:     req.ScopeID,
+		RatePercent: req.RatePercent,
+		Remark:      req.Remark,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAffiliateCommissionRuleInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
+		case errors.Is(err, service.ErrNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.bad_request", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.settings_save_failed", err)
+		}
+		return
+	}
+	response.Success(c, row)
+}
+
+// DeleteAffiliateCommissionRule 管理端删除返利比例规则
+func (h *Handler) DeleteAffiliateCommissionRule(c *gin.Context) {
+	if h.AffiliateService == nil {
+		shared.RespondError(c, response.CodeInternal, "error.settings_save_failed", nil)
+		return
+	}
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
+		return
+	}
+	if err := h.AffiliateService.DeleteCommissionRule(id); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			shared.RespondError(c, response.CodeNotFound, "error.bad_request", nil)
+			return
+		}
+		shared.RespondError(c, response.CodeInternal, "error.settings_save_failed", err)
+		return
+	}
+	response.Success(c, gin.H{"deleted": true})
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/dujiao-next/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 )
 
 // GetApiCredentials 获取 API 凭证列表
@@ -165,3 +166,96 @@ func (h *Handler) DeleteApiCredential(c *gin.Context) {
 
 	response.Success(c, gin.H{"deleted": true})
 }
+
+// UpdateApiCredentialSettingsRequest 修改密钥配置请求；限额字段未传时保持不变
+type UpdateApiCredentialSettingsRequest struct {
+	Name               string   `json:"name"`
+	Scopes             []string `json:"scopes"`
+	IPAllowlist        []string `json:"ip_allowlist"`
+	RateLimitPerMinute *int     `json:"rate_limit_per_minute"`
+	DailyOrderLimit    *int     `json:"daily_order_limit"`
+	DailyAmountLimit   *float64 `json:"daily_amount_limit"`
+}
+
+// UpdateApiCredentialSettings 修改密钥名称、权限范围、IP 白名单、限流与每日配额
+func (h *Handler) UpdateApiCredentialSettings(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+
+	var req UpdateApiCredentialSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+
+	input := service.ApiCredentialKeyInput{
+		Name:               req.Name,
+		Scopes:             req.Scopes,
+		IPAllowlist:        req.IPAllowlist,
+		RateLimitPerMinute: req.RateLimitPerMinute,
+		DailyOrderLimit:    req.DailyOrderLimit,
+	}
+	if req.DailyAmountLimit != nil {
+		amount := decimal.NewFromFloat(*req.DailyAmountLimit)
+		input.DailyAmountLimit = &amount
+	}
+
+	cred, err := h.ApiCredentialService.UpdateSettings(id, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrApiCredentialNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.api_credential_not_found", nil)
+		case errors.Is(err, service.ErrApiCredentialScopeInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.api_credential_scope_invalid", nil)
+		case errors.Is(err, service.ErrApiCredentialIPInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.api_credential_ip_invalid", nil)
+		case errors.Is(err, service.ErrApiCredentialSettingsInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.api_credential_settings_invalid", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.api_credential_update_failed", err)
+		}
+		return
+	}
+
+	response.Success(c, cred)
+}
+
+// GetApiCredentialUsages API 密钥每日用量日志
+func (h *Handler) GetApiCredentialUsages(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	page, pageSize = shared.NormalizePagination(page, pageSize)
+	credentialID, err := shared.ParseQueryUint(c.Query("credential_id"), false)
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	userID, err := shared.ParseQueryUint(c.Query("user_id"), false)
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+
+	usages, total, err := h.ApiCredentialService.ListUsage(repository.ApiCredentialUsageListFilter{
+		Page:         page,
+		PageSize:     pageSize,
+		CredentialID: credentialID,
+		UserID:       userID,
+		DateFrom:     c.Query("date_from"),
+		DateTo:       c.Query("date_to"),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrApiCredentialUsageFilter) {
+			shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
+			return
+		}
+		shared.RespondError(c, response.CodeInternal, "error.api_credential_usage_fetch_failed", err)
+		return
+	}
+
+	pagination := response.BuildPagination(page, pageSize, total)
+	response.SuccessWithPage(c, usages, pagination)
+}
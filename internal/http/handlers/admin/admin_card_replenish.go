@@ -0,0 +1,164 @@
+package admin
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/dujiao-next/internal/http/handlers/shared"
+	"github.com/dujiao-next/internal/http/response"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/dujiao-next/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// CardReplenishRuleRequest 卡密自动补货规则请求
+type CardReplenishRuleRequest struct {
+	ProductID         uint    `json:"product_id" binding:"required"`
+	SKUID             uint    `json:"sku_id" binding:"required"`
+	ConnectionID      uint    `json:"connection_id" binding:"required"`
+	UpstreamProductID uint    `json:"upstream_product_id" binding:"required"`
+	UpstreamSKUID     uint    `json:"upstream_sku_id" binding:"required"`
+	MinLevel          int     `json:"min_level" binding:"required"`
+	TargetLevel       int     `json:"target_level" binding:"required"`
+	MaxUnitCost       float64 `json:"max_unit_cost"`
+	DailySpendCap     float64 `json:"daily_spend_cap"`
+	IsActive          *bool   `json:"is_active"`
+}
+
+func (req CardReplenishRuleRequest) toInput() service.CardReplenishRuleInput {
+	return service.CardReplenishRuleInput{
+		ProductID:         req.ProductID,
+		SKUID:             req.SKUID,
+		ConnectionID:      req.ConnectionID,
+		UpstreamProductID: req.UpstreamProductID,
+		UpstreamSKUID:     req.UpstreamSKUID,
+		MinLevel:          req.MinLevel,
+		TargetLevel:       req.TargetLevel,
+		MaxUnitCost:       models.NewMoneyFromDecimal(decimal.NewFromFloat(req.MaxUnitCost)),
+		DailySpendCap:     models.NewMoneyFromDecimal(decimal.NewFromFloat(req.DailySpendCap)),
+		IsActive:          req.IsActive,
+	}
+}
+
+// GetCardReplenishRules 卡密自动补货规则列表
+func (h *Handler) GetCardReplenishRules(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	page, pageSize = shared.NormalizePagination(page, pageSize)
+
+	filter := repository.CardReplenishRuleListFilter{Page: page, PageSize: pageSize}
+	if productID := strings.TrimSpace(c.Query("product_id")); productID != "" {
+		if id, err := shared.ParseQueryUint(productID, false); err == nil {
+			filter.ProductID = id
+		}
+	}
+	if connID := strings.TrimSpace(c.Query("connection_id")); connID != "" {
+		if id, err := shared.ParseQueryUint(connID, false); err == nil {
+			filter.ConnectionID = id
+		}
+	}
+	if raw := strings.TrimSpace(c.Query("is_active")); raw != "" {
+		if active, err := strconv.ParseBool(raw); err == nil {
+			filter.IsActive = &active
+		}
+	}
+
+	rules, total, err := h.CardReplenishService.ListRules(filter)
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.card_replenish_rule_fetch_failed", err)
+		return
+	}
+	pagination := response.BuildPagination(page, pageSize, total)
+	response.SuccessWithPage(c, rules, pagination)
+}
+
+// CreateCardReplenishRule 创建卡密自动补货规则
+func (h *Handler) CreateCardReplenishRule(c *gin.Context) {
+	var req CardReplenishRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	rule, err := h.CardReplenishService.CreateRule(req.toInput())
+	if err != nil {
+		respondCardReplenishRuleError(c, err)
+		return
+	}
+	response.Success(c, rule)
+}
+
+// UpdateCardReplenishRule 更新卡密自动补货规则
+func (h *Handler) UpdateCardReplenishRule(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	var req CardReplenishRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	rule, err := h.CardReplenishService.UpdateRule(id, req.toInput())
+	if err != nil {
+		respondCardReplenishRuleError(c, err)
+		return
+	}
+	response.Success(c, rule)
+}
+
+// DeleteCardReplenishRule 删除卡密自动补货规则
+func (h *Handler) DeleteCardReplenishRule(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	if err := h.CardReplenishService.DeleteRule(id); err != nil {
+		respondCardReplenishRuleError(c, err)
+		return
+	}
+	response.Success(c, nil)
+}
+
+// RunCardReplenishRule 立即执行一次补货检查
+func (h *Handler) RunCardReplenishRule(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	result, err := h.CardReplenishService.RunRule(id)
+	if err != nil {
+		if errors.Is(err, service.ErrCardReplenishRuleNotFound) {
+			shared.RespondError(c, response.CodeNotFound, "error.card_replenish_rule_not_found", nil)
+			return
+		}
+		shared.RespondError(c, response.CodeInternal, "error.card_replenish_run_failed", err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func respondCardReplenishRuleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrCardReplenishRuleNotFound):
+		shared.RespondError(c, response.CodeNotFound, "error.card_replenish_rule_not_found", nil)
+	case errors.Is(err, service.ErrCardReplenishRuleInvalid), errors.Is(err, service.ErrProductSKUInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.card_replenish_rule_invalid", nil)
+	case errors.Is(err, service.ErrCardReplenishRuleExists):
+		shared.RespondError(c, response.CodeBadRequest, "error.card_replenish_rule_exists", nil)
+	case errors.Is(err, service.ErrCardReplenishRuleBusy):
+		shared.RespondError(c, response.CodeBadRequest, "error.card_replenish_rule_busy", nil)
+	case errors.Is(err, service.ErrProductNotFound):
+		shared.RespondError(c, response.CodeNotFound, "error.product_not_found", nil)
+	case errors.Is(err, service.ErrConnectionNotFound):
+		shared.RespondError(c, response.CodeNotFound, "error.connection_not_found", nil)
+	default:
+		shared.RespondError(c, response.CodeInternal, "error.card_replenish_rule_save_failed", err)
+	}
+}
@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"errors"
+
+	"github.com/dujiao-next/internal/http/handlers/shared"
+	"github.com/dujiao-next/internal/http/response"
+	"github.com/dujiao-next/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateProductLicenseKeyRequest 设置商品授权码签名密钥请求
+type UpdateProductLicenseKeyRequest struct {
+	PrivateKey string `json:"private_key"` // Base64 编码的 Ed25519 私钥，留空则重新生成
+}
+
+// GetProductLicenseKey 获取商品授权码签名公钥
+func (h *Handler) GetProductLicenseKey(c *gin.Context) {
+	productID, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
+		return
+	}
+	info, err := h.LicenseService.GetSigningKeyInfo(productID)
+	if err != nil {
+		respondProductLicenseKeyError(c, err)
+		return
+	}
+	response.Success(c, info)
+}
+
+// UpdateProductLicenseKey 导入或重新生成商品授权码签名密钥
+func (h *Handler) UpdateProductLicenseKey(c *gin.Context) {
+	productID, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
+		return
+	}
+	var req UpdateProductLicenseKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	info, err := h.LicenseService.SetSigningKey(productID, req.PrivateKey)
+	if err != nil {
+		respondProductLicenseKeyError(c, err)
+		return
+	}
+	response.Success(c, info)
+}
+
+func respondProductLicenseKeyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound):
+		shared.RespondError(c, response.CodeNotFound, "error.product_not_found", nil)
+	case errors.Is(err, service.ErrLicenseSigningKeyInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.license_signing_key_invalid", nil)
+	default:
+		shared.RespondError(c, response.CodeInternal, "error.license_update_failed", err)
+	}
+}
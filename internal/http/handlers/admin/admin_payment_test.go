@@ -48,6 +48,8 @@ func setupAdminPaymentHandlerTest(t *testing.T) (*Handler, *gorm.DB) {
 		&models.PaymentChannel{},
 		&models.Payment{},
 		&models.WalletRechargeOrder{},
+		&models.WalletRechargeBonusRule{},
+		&models.WalletRechargeBonus{},
 	); err != nil {
 		t.Fatalf("auto migrate failed: %v", err)
 	}
@@ -41,6 +41,7 @@ func (h *Handler) GetAdminProducts(c *gin.Context) {
 	}
 
 	h.applyUpstreamDisplayTypes(products)
+	fillProductWebhookSecretFlags(products)
 
 	pagination := response.BuildPagination(page, pageSize, total)
 	response.SuccessWithPage(c, products, pagination)
@@ -72,6 +73,7 @@ func (h *Handler) GetAdminProduct(c *gin.Context) {
 	*product = temp[0]
 
 	h.applyUpstreamDisplayTypes(temp)
+	fillProductWebhookSecretFlags(temp)
 	*product = temp[0]
 
 	response.Success(c, product)
@@ -86,6 +88,7 @@ type ProductSKURequest struct {
 	PriceAmount      float64                `json:"price_amount" binding:"required"`
 	CostPriceAmount  float64                `json:"cost_price_amount"`
 	ManualStockTotal int                    `json:"manual_stock_total"`
+	FileAssetID      *uint                  `json:"file_asset_id"`
 	IsActive         *bool                  `json:"is_active"`
 	SortOrder        int                    `json:"sort_order"`
 }
@@ -108,7 +111,17 @@ type CreateProductRequest struct {
 	MinPurchaseQuantity *int                   `json:"min_purchase_quantity"`
 	MaxPurchaseQuantity *int                   `json:"max_purchase_quantity"`
 	FulfillmentType     string                 `json:"fulfillment_type"`
+	DeliveryMode        string                 `json:"delivery_mode"`
+	DeliveryRevealLimit *int                   `json:"delivery_reveal_limit"`
 	ManualStockTotal    *int                   `json:"manual_stock_total"`
+	WebhookURL          string                 `json:"webhook_url"`
+	WebhookSecret       string                 `json:"webhook_secret"`
+	WebhookTimeoutSecs  *int                   `json:"webhook_timeout_seconds"`
+	WebhookMaxAttempts  *int                   `json:"webhook_max_attempts"`
+	LicenseValidDays    *int                   `json:"license_valid_days"`
+	LicenseMaxDevices   *int                   `json:"license_max_activations"`
+	DownloadExpireHours *int                   `json:"download_expire_hours"`
+	DownloadMaxCount    *int                   `json:"download_max_count"`
 	SKUs                []ProductSKURequest    `json:"skus"`
 	PaymentChannelIDs   []uint                 `json:"payment_channel_ids"`
 	IsAffiliateEnabled  *bool                  `json:"is_affiliate_enabled"`
@@ -129,6 +142,7 @@ func toProductSKUInputs(items []ProductSKURequest) []service.ProductSKUInput {
 			PriceAmount:      decimal.NewFromFloat(item.PriceAmount),
 			CostPriceAmount:  decimal.NewFromFloat(item.CostPriceAmount),
 			ManualStockTotal: item.ManualStockTotal,
+			FileAssetID:      item.FileAssetID,
 			IsActive:         item.IsActive,
 			SortOrder:        item.SortOrder,
 		})
@@ -136,6 +150,24 @@ func toProductSKUInputs(items []ProductSKURequest) []service.ProductSKUInput {
 	return result
 }
 
+// ensureProductFileAssets 校验 SKU 引用的私有文件存在
+func (h *Handler) ensureProductFileAssets(c *gin.Context, items []ProductSKURequest) bool {
+	for _, item := range items {
+		if item.FileAssetID == nil || *item.FileAssetID == 0 {
+			continue
+		}
+		if err := h.FileDeliveryService.EnsureAssetExists(*item.FileAssetID); err != nil {
+			if errors.Is(err, service.ErrFileAssetNotFound) {
+				shared.RespondError(c, response.CodeBadRequest, "error.file_asset_not_found", nil)
+				return false
+			}
+			shared.RespondError(c, response.CodeInternal, "error.internal", err)
+			return false
+		}
+	}
+	return true
+}
+
 // CreateProduct 创建商品
 func (h *Handler) CreateProduct(c *gin.Context) {
 	var req CreateProductRequest
@@ -144,6 +176,10 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 		return
 	}
 
+	if !h.ensureProductFileAssets(c, req.SKUs) {
+		return
+	}
+
 	product, err := h.ProductService.Create(service.CreateProductInput{
 		CategoryID:           req.CategoryID,
 		Slug:                 req.Slug,
@@ -161,7 +197,17 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 		MinPurchaseQuantity:  req.MinPurchaseQuantity,
 		MaxPurchaseQuantity:  req.MaxPurchaseQuantity,
 		FulfillmentType:      req.FulfillmentType,
+		DeliveryMode:         req.DeliveryMode,
+		DeliveryRevealLimit:  req.DeliveryRevealLimit,
 		ManualStockTotal:     req.ManualStockTotal,
+		WebhookURL:           req.WebhookURL,
+		WebhookSecret:        req.WebhookSecret,
+		WebhookTimeoutSecs:   req.WebhookTimeoutSecs,
+		WebhookMaxAttempts:   req.WebhookMaxAttempts,
+		LicenseValidDays:     req.LicenseValidDays,
+		LicenseMaxDevices:    req.LicenseMaxDevices,
+		DownloadExpireHours:  req.DownloadExpireHours,
+		DownloadMaxCount:     req.DownloadMaxCount,
 		SKUs:                 toProductSKUInputs(req.SKUs),
 		PaymentChannelIDs:    req.PaymentChannelIDs,
 		IsAffiliateEnabled:   req.IsAffiliateEnabled,
@@ -193,6 +239,14 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 			shared.RespondError(c, response.CodeBadRequest, "error.manual_form_schema_invalid", nil)
 			return
 		}
+		if errors.Is(err, service.ErrProductWebhookInvalid) {
+			shared.RespondError(c, response.CodeBadRequest, "error.product_webhook_invalid", nil)
+			return
+		}
+		if errors.Is(err, service.ErrProductFileInvalid) {
+			shared.RespondError(c, response.CodeBadRequest, "error.product_file_invalid", nil)
+			return
+		}
 		if errors.Is(err, service.ErrManualStockInvalid) {
 			shared.RespondError(c, response.CodeBadRequest, "error.manual_stock_invalid", nil)
 			return
@@ -213,6 +267,7 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 		return
 	}
 
+	product.HasWebhookSecret = strings.TrimSpace(product.WebhookSecret) != ""
 	response.Success(c, product)
 }
 
@@ -226,6 +281,10 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	if !h.ensureProductFileAssets(c, req.SKUs) {
+		return
+	}
+
 	product, err := h.ProductService.Update(id, service.CreateProductInput{
 		CategoryID:           req.CategoryID,
 		Slug:                 req.Slug,
@@ -243,7 +302,17 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 		MinPurchaseQuantity:  req.MinPurchaseQuantity,
 		MaxPurchaseQuantity:  req.MaxPurchaseQuantity,
 		FulfillmentType:      req.FulfillmentType,
+		DeliveryMode:         req.DeliveryMode,
+		DeliveryRevealLimit:  req.DeliveryRevealLimit,
 		ManualStockTotal:     req.ManualStockTotal,
+		WebhookURL:           req.WebhookURL,
+		WebhookSecret:        req.WebhookSecret,
+		WebhookTimeoutSecs:   req.WebhookTimeoutSecs,
+		WebhookMaxAttempts:   req.WebhookMaxAttempts,
+		LicenseValidDays:     req.LicenseValidDays,
+		LicenseMaxDevices:    req.LicenseMaxDevices,
+		DownloadExpireHours:  req.DownloadExpireHours,
+		DownloadMaxCount:     req.DownloadMaxCount,
 		SKUs:                 toProductSKUInputs(req.SKUs),
 		PaymentChannelIDs:    req.PaymentChannelIDs,
 		IsAffiliateEnabled:   req.IsAffiliateEnabled,
@@ -279,6 +348,14 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 			shared.RespondError(c, response.CodeBadRequest, "error.manual_form_schema_invalid", nil)
 			return
 		}
+		if errors.Is(err, service.ErrProductWebhookInvalid) {
+			shared.RespondError(c, response.CodeBadRequest, "error.product_webhook_invalid", nil)
+			return
+		}
+		if errors.Is(err, service.ErrProductFileInvalid) {
+			shared.RespondError(c, response.CodeBadRequest, "error.product_file_invalid", nil)
+			return
+		}
 		if errors.Is(err, service.ErrManualStockInvalid) {
 			shared.RespondError(c, response.CodeBadRequest, "error.manual_stock_invalid", nil)
 			return
@@ -299,6 +376,7 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	product.HasWebhookSecret = strings.TrimSpace(product.WebhookSecret) != ""
 	response.Success(c, product)
 }
 
@@ -347,6 +425,13 @@ func (h *Handler) QuickUpdateProduct(c *gin.Context) {
 	response.Success(c, product)
 }
 
+// fillProductWebhookSecretFlags 标记是否已配置 Webhook 签名密钥（密钥本身不返回）
+func fillProductWebhookSecretFlags(products []models.Product) {
+	for i := range products {
+		products[i].HasWebhookSecret = strings.TrimSpace(products[i].WebhookSecret) != ""
+	}
+}
+
 // applyUpstreamDisplayTypes 将 upstream 类型商品的 FulfillmentType 替换为上游的实际交付类型，并填充库存字段
 func (h *Handler) applyUpstreamDisplayTypes(products []models.Product) {
 	var upstreamIDs []uint
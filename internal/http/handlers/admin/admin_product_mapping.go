@@ -3,6 +3,7 @@ package admin
 import (
 	"errors"
 	"strconv"
+	"strings"
 
 	"github.com/dujiao-next/internal/http/handlers/shared"
 	"github.com/dujiao-next/internal/http/response"
@@ -396,3 +397,58 @@ func (h *Handler) BatchImportByCategory(c *gin.Context) {
 
 	response.Success(c, result)
 }
+
+// UpdateProductMappingMarginGuard 更新映射的利润保护配置
+func (h *Handler) UpdateProductMappingMarginGuard(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+
+	var input service.MarginGuardInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+
+	mapping, err := h.ProductMappingService.UpdateMarginGuard(id, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMappingNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.mapping_not_found", nil)
+		case errors.Is(err, service.ErrMappingMarginGuardInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.mapping_margin_guard_invalid", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.mapping_update_failed", err)
+		}
+		return
+	}
+
+	response.Success(c, mapping)
+}
+
+// GetMarginGuardEvents 利润保护触发记录列表
+func (h *Handler) GetMarginGuardEvents(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	page, pageSize = shared.NormalizePagination(page, pageSize)
+
+	connectionID, _ := shared.ParseQueryUint(c.Query("connection_id"), false)
+	productID, _ := shared.ParseQueryUint(c.Query("product_id"), false)
+
+	events, total, err := h.ProductMappingService.ListMarginGuardEvents(repository.MarginGuardEventListFilter{
+		Page:           page,
+		PageSize:       pageSize,
+		ConnectionID:   connectionID,
+		LocalProductID: productID,
+		Action:         strings.TrimSpace(c.Query("action")),
+	})
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.margin_guard_event_fetch_failed", err)
+		return
+	}
+
+	pagination := response.BuildPagination(page, pageSize, total)
+	response.SuccessWithPage(c, events, pagination)
+}
@@ -34,6 +34,90 @@ func (h *Handler) RunReconciliation(c *gin.Context) {
 	response.Success(c, job)
 }
 
+// ImportPaymentStatement 上传网关对账单并与支付记录对账
+func (h *Handler) ImportPaymentStatement(c *gin.Context) {
+	if h.ReconciliationService == nil {
+		shared.RespondErrorWithMsg(c, response.CodeInternal, "service not available", nil)
+		return
+	}
+	channelID, err := shared.ParseQueryUint(c.DefaultPostForm("channel_id", "0"), false)
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	rangeStart, err := shared.ParseTimeNullable(strings.TrimSpace(c.PostForm("time_range_start")))
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	rangeEnd, err := shared.ParseTimeNullable(strings.TrimSpace(c.PostForm("time_range_end")))
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.payment_statement_invalid", nil)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.payment_statement_invalid", nil)
+		return
+	}
+	defer file.Close()
+
+	job, err := h.ReconciliationService.ImportPaymentStatement(service.ImportPaymentStatementInput{
+		Format:         c.PostForm("format"),
+		ChannelID:      channelID,
+		FileName:       fileHeader.Filename,
+		Reader:         file,
+		TimeRangeStart: rangeStart,
+		TimeRangeEnd:   rangeEnd,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPaymentStatementInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.payment_statement_invalid", nil)
+		case errors.Is(err, service.ErrPaymentChannelNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.payment_channel_not_found", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.reconciliation_create_failed", err)
+		}
+		return
+	}
+	response.Success(c, job)
+}
+
+// RunPaymentReconciliation 发起从网关 API 拉取的支付对账任务
+func (h *Handler) RunPaymentReconciliation(c *gin.Context) {
+	if h.ReconciliationService == nil {
+		shared.RespondErrorWithMsg(c, response.CodeInternal, "service not available", nil)
+		return
+	}
+	var input service.RunPaymentReconciliationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+
+	job, err := h.ReconciliationService.CreatePaymentFetchJob(input)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPaymentStatementFetchUnsupported):
+			shared.RespondError(c, response.CodeBadRequest, "error.payment_statement_fetch_unsupported", nil)
+		case errors.Is(err, service.ErrPaymentStatementInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
+		case errors.Is(err, service.ErrPaymentChannelNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.payment_channel_not_found", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.reconciliation_create_failed", err)
+		}
+		return
+	}
+	response.Success(c, job)
+}
+
 // GetReconciliationJobs 对账任务列表
 func (h *Handler) GetReconciliationJobs(c *gin.Context) {
 	if h.ReconciliationService == nil {
@@ -52,6 +136,9 @@ func (h *Handler) GetReconciliationJobs(c *gin.Context) {
 			filter.ConnectionID = id
 		}
 	}
+	if source := strings.TrimSpace(c.Query("source")); source != "" {
+		filter.Source = source
+	}
 	if status := strings.TrimSpace(c.Query("status")); status != "" {
 		filter.Status = status
 	}
@@ -0,0 +1,295 @@
+package admin
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/dujiao-next/internal/http/handlers/shared"
+	"github.com/dujiao-next/internal/http/response"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/dujiao-next/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// ResellerPriceListRequest 创建/更新分销价目表请求
+type ResellerPriceListRequest struct {
+	Name                   string  `json:"name" binding:"required"`
+	Description            string  `json:"description"`
+	DefaultDiscountPercent float64 `json:"default_discount_percent"`
+	IsActive               *bool   `json:"is_active"`
+}
+
+// ReplaceResellerPriceItemsRequest 批量编辑价目表条目请求（整表覆盖）
+type ReplaceResellerPriceItemsRequest struct {
+	Items []ResellerPriceItemRequest `json:"items"`
+}
+
+// ResellerPriceItemRequest 价目表条目
+type ResellerPriceItemRequest struct {
+	ProductID       uint    `json:"product_id" binding:"required"`
+	SKUID           uint    `json:"sku_id"`
+	PriceMode       string  `json:"price_mode" binding:"required"`
+	FixedPrice      float64 `json:"fixed_price"`
+	DiscountPercent float64 `json:"discount_percent"`
+}
+
+// AssignResellerPriceListRequest 分配价目表请求，price_list_id 为 0 表示取消分配
+type AssignResellerPriceListRequest struct {
+	PriceListID uint `json:"price_list_id"`
+}
+
+// GetResellerPriceLists 分销价目表列表
+func (h *Handler) GetResellerPriceLists(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	page, pageSize = shared.NormalizePagination(page, pageSize)
+
+	var isActive *bool
+	if raw := c.Query("is_active"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+			return
+		}
+		isActive = &parsed
+	}
+
+	lists, total, err := h.ResellerPriceListService.List(repository.ResellerPriceListListFilter{
+		Page:     page,
+		PageSize: pageSize,
+		Search:   c.Query("search"),
+		IsActive: isActive,
+	})
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.reseller_price_list_fetch_failed", err)
+		return
+	}
+
+	pagination := response.BuildPagination(page, pageSize, total)
+	response.SuccessWithPage(c, lists, pagination)
+}
+
+// GetResellerPriceList 分销价目表详情
+func (h *Handler) GetResellerPriceList(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	list, err := h.ResellerPriceListService.GetByID(id)
+	if err != nil {
+		respondResellerPriceListError(c, err, "error.reseller_price_list_fetch_failed")
+		return
+	}
+	response.Success(c, list)
+}
+
+// CreateResellerPriceList 创建分销价目表
+func (h *Handler) CreateResellerPriceList(c *gin.Context) {
+	var req ResellerPriceListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	list, err := h.ResellerPriceListService.Create(req.toInput(true))
+	if err != nil {
+		respondResellerPriceListError(c, err, "error.reseller_price_list_save_failed")
+		return
+	}
+	response.Success(c, list)
+}
+
+// UpdateResellerPriceList 更新分销价目表
+func (h *Handler) UpdateResellerPriceList(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	existing, err := h.ResellerPriceListService.GetByID(id)
+	if err != nil {
+		respondResellerPriceListError(c, err, "error.reseller_price_list_fetch_failed")
+		return
+	}
+	var req ResellerPriceListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	list, err := h.ResellerPriceListService.Update(id, req.toInput(existing.IsActive))
+	if err != nil {
+		respondResellerPriceListError(c, err, "error.reseller_price_list_save_failed")
+		return
+	}
+	response.Success(c, list)
+}
+
+// DeleteResellerPriceList 删除分销价目表（同时解除凭证与用户的分配）
+func (h *Handler) DeleteResellerPriceList(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	if err := h.ResellerPriceListService.Delete(id); err != nil {
+		respondResellerPriceListError(c, err, "error.reseller_price_list_delete_failed")
+		return
+	}
+	response.Success(c, gin.H{"deleted": true})
+}
+
+// GetResellerPriceListItems 价目表条目
+func (h *Handler) GetResellerPriceListItems(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	items, err := h.ResellerPriceListService.ListItems(id)
+	if err != nil {
+		respondResellerPriceListError(c, err, "error.reseller_price_list_fetch_failed")
+		return
+	}
+	response.Success(c, items)
+}
+
+// ReplaceResellerPriceListItems 批量编辑价目表条目
+func (h *Handler) ReplaceResellerPriceListItems(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	var req ReplaceResellerPriceItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	inputs := make([]service.ResellerPriceItemInput, 0, len(req.Items))
+	for _, item := range req.Items {
+		inputs = append(inputs, service.ResellerPriceItemInput{
+			ProductID:       item.ProductID,
+			SKUID:           item.SKUID,
+			PriceMode:       item.PriceMode,
+			FixedPrice:      decimal.NewFromFloat(item.FixedPrice),
+			DiscountPercent: decimal.NewFromFloat(item.DiscountPercent),
+		})
+	}
+	items, err := h.ResellerPriceListService.ReplaceItems(id, inputs)
+	if err != nil {
+		respondResellerPriceListError(c, err, "error.reseller_price_list_save_failed")
+		return
+	}
+	response.Success(c, items)
+}
+
+// GetResellerMargins 分销利润报表（按用户与价目表汇总已支付订单）
+func (h *Handler) GetResellerMargins(c *gin.Context) {
+	priceListID, err := shared.ParseQueryUint(c.Query("price_list_id"), false)
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	userID, err := shared.ParseQueryUint(c.Query("user_id"), false)
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	createdFrom, err := shared.ParseTimeNullable(c.Query("created_from"))
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	createdTo, err := shared.ParseTimeNullable(c.Query("created_to"))
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+
+	items, err := h.ResellerPriceListService.MarginReport(repository.ResellerMarginFilter{
+		PriceListID: priceListID,
+		UserID:      userID,
+		CreatedFrom: createdFrom,
+		CreatedTo:   createdTo,
+	})
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.reseller_margin_fetch_failed", err)
+		return
+	}
+	response.Success(c, items)
+}
+
+// AssignApiCredentialPriceList 为 API 凭证分配价目表
+func (h *Handler) AssignApiCredentialPriceList(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	var req AssignResellerPriceListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	if err := h.ResellerPriceListService.AssignCredential(id, req.PriceListID); err != nil {
+		if errors.Is(err, service.ErrApiCredentialNotFound) {
+			shared.RespondError(c, response.CodeNotFound, "error.api_credential_not_found", nil)
+			return
+		}
+		respondResellerPriceListError(c, err, "error.reseller_price_list_assign_failed")
+		return
+	}
+	response.Success(c, gin.H{"updated": true})
+}
+
+// AssignUserPriceList 为用户分配价目表
+func (h *Handler) AssignUserPriceList(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	var req AssignResellerPriceListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	if err := h.ResellerPriceListService.AssignUser(id, req.PriceListID); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			shared.RespondError(c, response.CodeNotFound, "error.user_not_found", nil)
+			return
+		}
+		respondResellerPriceListError(c, err, "error.reseller_price_list_assign_failed")
+		return
+	}
+	response.Success(c, gin.H{"updated": true})
+}
+
+// toInput 转换为服务层输入，未传 is_active 时沿用 defaultActive
+func (req ResellerPriceListRequest) toInput(defaultActive bool) service.ResellerPriceListInput {
+	isActive := defaultActive
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	return service.ResellerPriceListInput{
+		Name:                   req.Name,
+		Description:            req.Description,
+		DefaultDiscountPercent: decimal.NewFromFloat(req.DefaultDiscountPercent),
+		IsActive:               isActive,
+	}
+}
+
+func respondResellerPriceListError(c *gin.Context, err error, fallbackKey string) {
+	switch {
+	case errors.Is(err, service.ErrResellerPriceListNotFound):
+		shared.RespondError(c, response.CodeNotFound, "error.reseller_price_list_not_found", nil)
+	case errors.Is(err, service.ErrResellerPriceListInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.reseller_price_list_invalid", nil)
+	case errors.Is(err, service.ErrResellerPriceItemInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.reseller_price_item_invalid", nil)
+	default:
+		shared.RespondError(c, response.CodeInternal, fallbackKey, err)
+	}
+}
@@ -6,8 +6,10 @@ import (
 
 	"github.com/dujiao-next/internal/http/handlers/shared"
 	"github.com/dujiao-next/internal/http/response"
+	"github.com/dujiao-next/internal/models"
 	"github.com/dujiao-next/internal/repository"
 	"github.com/dujiao-next/internal/service"
+	"github.com/dujiao-next/internal/upstream"
 
 	"github.com/gin-gonic/gin"
 )
@@ -64,6 +66,10 @@ func (h *Handler) CreateSiteConnection(c *gin.Context) {
 
 	conn, err := h.SiteConnectionService.Create(input)
 	if err != nil {
+		if errors.Is(err, service.ErrConnectionTemplateInvalid) {
+			shared.RespondErrorWithMsg(c, response.CodeBadRequest, err.Error(), nil)
+			return
+		}
 		if errors.Is(err, service.ErrConnectionInvalid) {
 			shared.RespondError(c, response.CodeBadRequest, "error.connection_invalid", nil)
 			return
@@ -95,6 +101,14 @@ func (h *Handler) UpdateSiteConnection(c *gin.Context) {
 			shared.RespondError(c, response.CodeNotFound, "error.connection_not_found", nil)
 			return
 		}
+		if errors.Is(err, service.ErrConnectionTemplateInvalid) {
+			shared.RespondErrorWithMsg(c, response.CodeBadRequest, err.Error(), nil)
+			return
+		}
+		if errors.Is(err, service.ErrConnectionInvalid) {
+			shared.RespondError(c, response.CodeBadRequest, "error.connection_invalid", nil)
+			return
+		}
 		shared.RespondError(c, response.CodeInternal, "error.connection_update_failed", err)
 		return
 	}
@@ -143,6 +157,44 @@ func (h *Handler) PingSiteConnection(c *gin.Context) {
 	response.Success(c, result)
 }
 
+// DryRunSiteConnectionRequest 通用 REST 模板试运行请求
+type DryRunSiteConnectionRequest struct {
+	RestTemplate models.JSON `json:"rest_template"` // 可选，为空时使用已保存的模板
+	upstream.RESTDryRunInput
+}
+
+// DryRunSiteConnection 试运行 generic-rest 连接的接口模板
+func (h *Handler) DryRunSiteConnection(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+
+	var req DryRunSiteConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+
+	steps, err := h.SiteConnectionService.DryRunTemplate(id, req.RestTemplate, req.RESTDryRunInput)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrConnectionNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.connection_not_found", nil)
+		case errors.Is(err, service.ErrConnectionTemplateInvalid):
+			shared.RespondErrorWithMsg(c, response.CodeBadRequest, err.Error(), nil)
+		case errors.Is(err, service.ErrConnectionInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.connection_invalid", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.connection_dry_run_failed", err)
+		}
+		return
+	}
+
+	response.Success(c, gin.H{"steps": steps})
+}
+
 // ReapplyConnectionMarkup 对连接的所有映射商品重新应用加价规则
 func (h *Handler) ReapplyConnectionMarkup(c *gin.Context) {
 	id, err := shared.ParseParamUint(c, "id")
@@ -194,3 +246,65 @@ func (h *Handler) UpdateSiteConnectionStatus(c *gin.Context) {
 
 	response.Success(c, gin.H{"updated": true})
 }
+
+// CheckSiteConnectionBalance 立即检查上游余额并记录快照
+func (h *Handler) CheckSiteConnectionBalance(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+
+	snapshot, err := h.UpstreamBalanceService.Check(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrConnectionNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.connection_not_found", nil)
+		case errors.Is(err, service.ErrUpstreamBalanceUnavailable):
+			shared.RespondError(c, response.CodeBadRequest, "error.upstream_balance_unavailable", err)
+		default:
+			shared.RespondErrorWithMsg(c, response.CodeInternal, err.Error(), err)
+		}
+		return
+	}
+
+	response.Success(c, snapshot)
+}
+
+// GetSiteConnectionBalanceSnapshots 上游余额快照列表
+func (h *Handler) GetSiteConnectionBalanceSnapshots(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	page, pageSize = shared.NormalizePagination(page, pageSize)
+
+	createdFrom, err := shared.ParseTimeNullable(c.Query("created_from"))
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	createdTo, err := shared.ParseTimeNullable(c.Query("created_to"))
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+
+	snapshots, total, err := h.UpstreamBalanceService.ListSnapshots(repository.UpstreamBalanceSnapshotListFilter{
+		Page:         page,
+		PageSize:     pageSize,
+		ConnectionID: id,
+		CreatedFrom:  createdFrom,
+		CreatedTo:    createdTo,
+	})
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.upstream_balance_snapshot_fetch_failed", err)
+		return
+	}
+
+	pagination := response.BuildPagination(page, pageSize, total)
+	response.SuccessWithPage(c, snapshots, pagination)
+}
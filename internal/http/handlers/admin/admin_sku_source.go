@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/dujiao-next/internal/http/handlers/shared"
+	"github.com/dujiao-next/internal/http/response"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/dujiao-next/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// SKUSourceRequest SKU 供货来源请求
+type SKUSourceRequest struct {
+	LocalSKUID        uint    `json:"local_sku_id" binding:"required"`
+	Priority          int     `json:"priority"`
+	SourceType        string  `json:"source_type" binding:"required"`
+	ConnectionID      uint    `json:"connection_id"`
+	UpstreamProductID uint    `json:"upstream_product_id"`
+	UpstreamSKUID     uint    `json:"upstream_sku_id"`
+	MaxUnitCost       float64 `json:"max_unit_cost"`
+	IsActive          *bool   `json:"is_active"`
+}
+
+func (req SKUSourceRequest) toInput() service.SKUSourceInput {
+	return service.SKUSourceInput{
+		LocalSKUID:        req.LocalSKUID,
+		Priority:          req.Priority,
+		SourceType:        req.SourceType,
+		ConnectionID:      req.ConnectionID,
+		UpstreamProductID: req.UpstreamProductID,
+		UpstreamSKUID:     req.UpstreamSKUID,
+		MaxUnitCost:       models.NewMoneyFromDecimal(decimal.NewFromFloat(req.MaxUnitCost)),
+		IsActive:          req.IsActive,
+	}
+}
+
+// GetSKUSources SKU 供货来源列表
+func (h *Handler) GetSKUSources(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	page, pageSize = shared.NormalizePagination(page, pageSize)
+
+	filter := repository.SKUSourceListFilter{Page: page, PageSize: pageSize}
+	if raw := strings.TrimSpace(c.Query("product_id")); raw != "" {
+		if id, err := shared.ParseQueryUint(raw, false); err == nil {
+			filter.LocalProductID = id
+		}
+	}
+	if raw := strings.TrimSpace(c.Query("sku_id")); raw != "" {
+		if id, err := shared.ParseQueryUint(raw, false); err == nil {
+			filter.LocalSKUID = id
+		}
+	}
+	if raw := strings.TrimSpace(c.Query("connection_id")); raw != "" {
+		if id, err := shared.ParseQueryUint(raw, false); err == nil {
+			filter.ConnectionID = id
+		}
+	}
+
+	sources, total, err := h.SKUSourceService.List(filter)
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.sku_source_fetch_failed", err)
+		return
+	}
+	pagination := response.BuildPagination(page, pageSize, total)
+	response.SuccessWithPage(c, sources, pagination)
+}
+
+// CreateSKUSource 创建 SKU 供货来源
+func (h *Handler) CreateSKUSource(c *gin.Context) {
+	var req SKUSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	source, err := h.SKUSourceService.Create(req.toInput())
+	if err != nil {
+		respondSKUSourceError(c, err)
+		return
+	}
+	response.Success(c, source)
+}
+
+// UpdateSKUSource 更新 SKU 供货来源
+func (h *Handler) UpdateSKUSource(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	var req SKUSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	source, err := h.SKUSourceService.Update(id, req.toInput())
+	if err != nil {
+		respondSKUSourceError(c, err)
+		return
+	}
+	response.Success(c, source)
+}
+
+// DeleteSKUSource 删除 SKU 供货来源
+func (h *Handler) DeleteSKUSource(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	if err := h.SKUSourceService.Delete(id); err != nil {
+		respondSKUSourceError(c, err)
+		return
+	}
+	response.Success(c, nil)
+}
+
+func respondSKUSourceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrSKUSourceNotFound):
+		shared.RespondError(c, response.CodeNotFound, "error.sku_source_not_found", nil)
+	case errors.Is(err, service.ErrSKUSourceInvalid), errors.Is(err, service.ErrProductSKUInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.sku_source_invalid", nil)
+	case errors.Is(err, service.ErrSKUSourceExists):
+		shared.RespondError(c, response.CodeBadRequest, "error.sku_source_exists", nil)
+	case errors.Is(err, service.ErrProductNotFound):
+		shared.RespondError(c, response.CodeNotFound, "error.product_not_found", nil)
+	case errors.Is(err, service.ErrConnectionNotFound):
+		shared.RespondError(c, response.CodeNotFound, "error.connection_not_found", nil)
+	default:
+		shared.RespondError(c, response.CodeInternal, "error.sku_source_save_failed", err)
+	}
+}
@@ -75,6 +75,7 @@ type AdminUserOAuthIdentityItem struct {
 type AdminUserDetail struct {
 	models.User
 	WalletBalance   models.Money                 `json:"wallet_balance"`
+	WalletAccounts  []models.WalletAccount       `json:"wallet_accounts"`
 	OAuthIdentities []AdminUserOAuthIdentityItem `json:"oauth_identities"`
 }
 
@@ -174,6 +175,11 @@ func (h *Handler) GetAdminUser(c *gin.Context) {
 		shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", err)
 		return
 	}
+	walletAccounts, err := h.WalletService.ListAccounts(user.ID)
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", err)
+		return
+	}
 	identities, err := h.UserOAuthIdentityRepo.ListByUserID(user.ID)
 	if err != nil {
 		shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", err)
@@ -194,6 +200,7 @@ func (h *Handler) GetAdminUser(c *gin.Context) {
 	response.Success(c, AdminUserDetail{
 		User:            *user,
 		WalletBalance:   account.Balance,
+		WalletAccounts:  walletAccounts,
 		OAuthIdentities: oauthItems,
 	})
 }
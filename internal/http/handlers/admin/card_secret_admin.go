@@ -469,3 +469,32 @@ func (h *Handler) GetCardSecretTemplate(c *gin.Context) {
 	c.Header("Content-Disposition", "attachment; filename=\"card-secrets-template.csv\"")
 	c.String(200, content)
 }
+
+// GetCardSecretDefectReport 获取批次问题卡密统计
+func (h *Handler) GetCardSecretDefectReport(c *gin.Context) {
+	productID, err := shared.ParseQueryUint(c.DefaultQuery("product_id", "0"), false)
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.card_secret_invalid", nil)
+		return
+	}
+	skuID, err := shared.ParseQueryUint(c.DefaultQuery("sku_id", "0"), false)
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.card_secret_invalid", nil)
+		return
+	}
+	items, err := h.CardSecretService.GetDefectReport(productID, skuID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrProductSKURequired):
+			shared.RespondError(c, response.CodeBadRequest, "error.card_secret_invalid", nil)
+		case errors.Is(err, service.ErrProductSKUInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.card_secret_invalid", nil)
+		case errors.Is(err, service.ErrCardSecretInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.card_secret_invalid", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.card_secret_batch_fetch_failed", err)
+		}
+		return
+	}
+	response.Success(c, items)
+}
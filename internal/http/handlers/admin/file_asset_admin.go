@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/dujiao-next/internal/http/handlers/shared"
+	"github.com/dujiao-next/internal/http/response"
+	"github.com/dujiao-next/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ====================  私有文件（文件交付）  ====================
+
+// UploadFileAsset 上传文件交付商品使用的私有文件
+func (h *Handler) UploadFileAsset(c *gin.Context) {
+	adminID, ok := shared.GetAdminID(c)
+	if !ok {
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.file_missing", nil)
+		return
+	}
+	asset, err := h.FileDeliveryService.Upload(file, adminID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrFileAssetTooLarge):
+			shared.RespondError(c, response.CodeBadRequest, "error.file_asset_too_large", nil)
+		case errors.Is(err, service.ErrFileAssetInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.file_asset_invalid", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.upload_failed", err)
+		}
+		return
+	}
+	response.Success(c, asset)
+}
+
+// ListFileAssets 私有文件列表
+func (h *Handler) ListFileAssets(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	items, total, err := h.FileDeliveryService.ListAssets(c.Query("search"), page, pageSize)
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.internal", err)
+		return
+	}
+	response.Success(c, gin.H{
+		"items": items,
+		"total": total,
+	})
+}
+
+// DeleteFileAsset 删除私有文件（仍被 SKU 引用时拒绝）
+func (h *Handler) DeleteFileAsset(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.invalid_id", nil)
+		return
+	}
+	if err := h.FileDeliveryService.DeleteAsset(id); err != nil {
+		switch {
+		case errors.Is(err, service.ErrFileAssetNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.file_asset_not_found", nil)
+		case errors.Is(err, service.ErrFileAssetInUse):
+			shared.RespondError(c, response.CodeBadRequest, "error.file_asset_in_use", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.internal", err)
+		}
+		return
+	}
+	response.Success(c, nil)
+}
@@ -55,3 +55,236 @@ func (h *Handler) AdminCreateFulfillment(c *gin.Context) {
 
 	response.Success(c, fulfillment)
 }
+
+// AdminListOrderDeliveryLinks 获取订单安全交付链接及访问记录
+func (h *Handler) AdminListOrderDeliveryLinks(c *gin.Context) {
+	orderID, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.order_item_invalid", nil)
+		return
+	}
+	order, err := h.OrderService.GetOrderForAdmin(orderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.order_not_found", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.order_fetch_failed", err)
+		}
+		return
+	}
+	links, err := h.DeliveryLinkService.ListByOrder(order)
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.delivery_link_fetch_failed", err)
+		return
+	}
+	response.Success(c, links)
+}
+
+// AdminListOrderFileDownloads 获取订单文件下载授权及下载记录
+func (h *Handler) AdminListOrderFileDownloads(c *gin.Context) {
+	orderID, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.order_item_invalid", nil)
+		return
+	}
+	order, err := h.OrderService.GetOrderForAdmin(orderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.order_not_found", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.order_fetch_failed", err)
+		}
+		return
+	}
+	grants, err := h.FileDeliveryService.ListByOrder(order)
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.file_download_failed", err)
+		return
+	}
+	response.Success(c, grants)
+}
+
+// AdminListOrderWebhookFulfillments 获取订单 Webhook 交付任务及尝试记录
+func (h *Handler) AdminListOrderWebhookFulfillments(c *gin.Context) {
+	orderID, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.order_item_invalid", nil)
+		return
+	}
+	order, err := h.OrderService.GetOrderForAdmin(orderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.order_not_found", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.order_fetch_failed", err)
+		}
+		return
+	}
+	jobs, err := h.FulfillmentWebhookService.ListByOrder(order)
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.webhook_fulfillment_fetch_failed", err)
+		return
+	}
+	response.Success(c, jobs)
+}
+
+// AdminRetryOrderWebhookFulfillment 手动重试 Webhook 交付
+func (h *Handler) AdminRetryOrderWebhookFulfillment(c *gin.Context) {
+	adminID, ok := shared.GetAdminID(c)
+	if !ok {
+		return
+	}
+	orderID, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.order_item_invalid", nil)
+		return
+	}
+	job, err := h.FulfillmentWebhookService.Retry(orderID, adminID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.order_not_found", nil)
+		case errors.Is(err, service.ErrFulfillmentWebhookNotApplicable):
+			shared.RespondError(c, response.CodeBadRequest, "error.webhook_fulfillment_not_applicable", nil)
+		case errors.Is(err, service.ErrFulfillmentExists):
+			shared.RespondError(c, response.CodeBadRequest, "error.fulfillment_exists", nil)
+		case errors.Is(err, service.ErrOrderStatusInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.order_status_invalid", nil)
+		case errors.Is(err, service.ErrFulfillmentWebhookInProgress):
+			shared.RespondError(c, response.CodeTooManyRequests, "error.webhook_fulfillment_in_progress", nil)
+		case errors.Is(err, service.ErrFulfillmentWebhookFailed):
+			shared.RespondError(c, response.CodeBadRequest, "error.webhook_fulfillment_failed", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.webhook_fulfillment_fetch_failed", err)
+		}
+		return
+	}
+	response.Success(c, job)
+}
+
+// AdminListOrderLicenses 获取订单签发的授权码及激活记录
+func (h *Handler) AdminListOrderLicenses(c *gin.Context) {
+	orderID, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.order_item_invalid", nil)
+		return
+	}
+	order, err := h.OrderService.GetOrderForAdmin(orderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.order_not_found", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.order_fetch_failed", err)
+		}
+		return
+	}
+	licenses, err := h.LicenseService.ListByOrder(order)
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.license_fetch_failed", err)
+		return
+	}
+	response.Success(c, licenses)
+}
+
+// AdminRevokeLicense 吊销授权码
+func (h *Handler) AdminRevokeLicense(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
+		return
+	}
+	license, err := h.LicenseService.Revoke(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLicenseNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.license_not_found", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.license_update_failed", err)
+		}
+		return
+	}
+	response.Success(c, license)
+}
+
+// AdminReplaceCardSecretsRequest 问题卡密补发请求
+type AdminReplaceCardSecretsRequest struct {
+	OrderItemID   uint   `json:"order_item_id" binding:"required"`
+	CardSecretIDs []uint `json:"card_secret_ids" binding:"required"`
+	Reason        string `json:"reason"`
+}
+
+// AdminListOrderCardSecretReplacements 获取订单已交付卡密及补发记录
+func (h *Handler) AdminListOrderCardSecretReplacements(c *gin.Context) {
+	orderID, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.order_item_invalid", nil)
+		return
+	}
+	order, err := h.OrderService.GetOrderForAdmin(orderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.order_not_found", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.order_fetch_failed", err)
+		}
+		return
+	}
+	detail, err := h.CardReplacementService.ListByOrder(order)
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.card_secret_replacement_fetch_failed", err)
+		return
+	}
+	response.Success(c, detail)
+}
+
+// AdminReplaceOrderCardSecrets 标记问题卡密并从同 SKU 库存补发
+func (h *Handler) AdminReplaceOrderCardSecrets(c *gin.Context) {
+	adminID, ok := shared.GetAdminID(c)
+	if !ok {
+		return
+	}
+	orderID, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.order_item_invalid", nil)
+		return
+	}
+	var req AdminReplaceCardSecretsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	result, err := h.CardReplacementService.ReplaceDefective(service.ReplaceDefectiveCardSecretsInput{
+		OrderID:       orderID,
+		OrderItemID:   req.OrderItemID,
+		CardSecretIDs: req.CardSecretIDs,
+		Reason:        req.Reason,
+		AdminID:       adminID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.order_not_found", nil)
+		case errors.Is(err, service.ErrOrderFetchFailed):
+			shared.RespondError(c, response.CodeInternal, "error.order_fetch_failed", err)
+		case errors.Is(err, service.ErrInvalidOrderItem):
+			shared.RespondError(c, response.CodeBadRequest, "error.order_item_invalid", nil)
+		case errors.Is(err, service.ErrFulfillmentNotAuto), errors.Is(err, service.ErrFulfillmentInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.fulfillment_invalid", nil)
+		case errors.Is(err, service.ErrOrderStatusInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.order_status_invalid", nil)
+		case errors.Is(err, service.ErrCardSecretReplaceInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.card_secret_replace_invalid", nil)
+		case errors.Is(err, service.ErrCardSecretInsufficient):
+			shared.RespondError(c, response.CodeBadRequest, "error.card_secret_insufficient", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.card_secret_replace_failed", err)
+		}
+		return
+	}
+	response.Success(c, result)
+}
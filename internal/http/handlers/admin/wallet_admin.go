@@ -58,9 +58,15 @@ func (h *Handler) GetAdminUserWallet(c *gin.Context) {
 		shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", err)
 		return
 	}
+	accounts, err := h.WalletService.ListAccounts(userID)
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", err)
+		return
+	}
 	response.Success(c, gin.H{
-		"user":    user,
-		"account": account,
+		"user":     user,
+		"account":  account,
+		"accounts": accounts,
 	})
 }
 
@@ -81,6 +87,7 @@ func (h *Handler) GetAdminUserWalletTransactions(c *gin.Context) {
 		UserID:    userID,
 		Type:      strings.TrimSpace(c.Query("type")),
 		Direction: strings.TrimSpace(c.Query("direction")),
+		Currency:  strings.ToUpper(strings.TrimSpace(c.Query("currency"))),
 	}
 	transactions, total, err := h.WalletService.ListTransactions(filter)
 	if err != nil {
@@ -91,6 +98,36 @@ func (h *Handler) GetAdminUserWalletTransactions(c *gin.Context) {
 	response.SuccessWithPage(c, transactions, pagination)
 }
 
+// GetAdminUserWalletStatement 管理端获取用户按币种汇总的钱包对账单
+func (h *Handler) GetAdminUserWalletStatement(c *gin.Context) {
+	userID, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.user_id_invalid", nil)
+		return
+	}
+	createdFrom, err := shared.ParseTimeNullable(strings.TrimSpace(c.Query("created_from")))
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	createdTo, err := shared.ParseTimeNullable(strings.TrimSpace(c.Query("created_to")))
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	statements, err := h.WalletService.Statement(repository.WalletTransactionListFilter{
+		UserID:      userID,
+		Currency:    c.Query("currency"),
+		CreatedFrom: createdFrom,
+		CreatedTo:   createdTo,
+	})
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.wallet_statement_failed", err)
+		return
+	}
+	response.Success(c, statements)
+}
+
 // GetAdminWalletRecharges 管理端分页获取钱包充值记录
 func (h *Handler) GetAdminWalletRecharges(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -291,6 +328,8 @@ func (h *Handler) AdjustAdminUserWallet(c *gin.Context) {
 			shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
 		case errors.Is(err, service.ErrWalletInsufficientBalance):
 			shared.RespondError(c, response.CodeBadRequest, "error.payment_amount_mismatch", nil)
+		case errors.Is(err, service.ErrWalletCurrencyInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.wallet_currency_invalid", nil)
 		default:
 			shared.RespondError(c, response.CodeInternal, "error.user_update_failed", err)
 		}
@@ -302,3 +341,54 @@ func (h *Handler) AdjustAdminUserWallet(c *gin.Context) {
 		"transaction": txn,
 	})
 }
+
+// GetAdminWalletTransfers 管理端分页获取用户间转账记录
+func (h *Handler) GetAdminWalletTransfers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	page, pageSize = shared.NormalizePagination(page, pageSize)
+
+	userID, err := shared.ParseQueryUint(c.Query("user_id"), false)
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	fromUserID, err := shared.ParseQueryUint(c.Query("from_user_id"), false)
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	toUserID, err := shared.ParseQueryUint(c.Query("to_user_id"), false)
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	createdFrom, err := shared.ParseTimeNullable(strings.TrimSpace(c.Query("created_from")))
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	createdTo, err := shared.ParseTimeNullable(strings.TrimSpace(c.Query("created_to")))
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+
+	transfers, total, err := h.WalletTransferService.ListTransfers(repository.WalletTransferListFilter{
+		Page:        page,
+		PageSize:    pageSize,
+		TransferNo:  strings.TrimSpace(c.Query("transfer_no")),
+		UserID:      userID,
+		FromUserID:  fromUserID,
+		ToUserID:    toUserID,
+		Currency:    strings.ToUpper(strings.TrimSpace(c.Query("currency"))),
+		CreatedFrom: createdFrom,
+		CreatedTo:   createdTo,
+	})
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.wallet_transfer_failed", err)
+		return
+	}
+	pagination := response.BuildPagination(page, pageSize, total)
+	response.SuccessWithPage(c, transfers, pagination)
+}
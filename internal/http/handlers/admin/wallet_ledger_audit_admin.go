@@ -0,0 +1,158 @@
+package admin
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/http/handlers/shared"
+	"github.com/dujiao-next/internal/http/response"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/dujiao-next/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminWalletLedgerDiscrepancyResolveRequest 处理钱包账本差异请求
+type AdminWalletLedgerDiscrepancyResolveRequest struct {
+	Remark string `json:"remark"`
+}
+
+// GetAdminWalletLedgerAudits 钱包账本核对记录列表
+func (h *Handler) GetAdminWalletLedgerAudits(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	page, pageSize = shared.NormalizePagination(page, pageSize)
+
+	rows, total, err := h.WalletLedgerAuditService.ListAudits(repository.WalletLedgerAuditListFilter{
+		Page:     page,
+		PageSize: pageSize,
+		Source:   strings.TrimSpace(c.Query("source")),
+		Status:   strings.TrimSpace(c.Query("status")),
+	})
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.wallet_ledger_audit_fetch_failed", err)
+		return
+	}
+	response.SuccessWithPage(c, rows, response.BuildPagination(page, pageSize, total))
+}
+
+// RunAdminWalletLedgerAudit 立即执行一次钱包账本核对
+func (h *Handler) RunAdminWalletLedgerAudit(c *gin.Context) {
+	adminID, ok := shared.GetAdminID(c)
+	if !ok {
+		return
+	}
+	audit, err := h.WalletLedgerAuditService.Run(constants.WalletLedgerAuditSourceManual, &adminID)
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.wallet_ledger_audit_failed", err)
+		return
+	}
+	response.Success(c, audit)
+}
+
+// GetAdminWalletLedgerAudit 钱包账本核对详情（含差异明细）
+func (h *Handler) GetAdminWalletLedgerAudit(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	audit, err := h.WalletLedgerAuditService.GetAudit(id)
+	if err != nil {
+		if errors.Is(err, service.ErrWalletLedgerAuditNotFound) {
+			shared.RespondError(c, response.CodeNotFound, "error.wallet_ledger_audit_not_found", nil)
+			return
+		}
+		shared.RespondError(c, response.CodeInternal, "error.wallet_ledger_audit_fetch_failed", err)
+		return
+	}
+
+	itemPage, _ := strconv.Atoi(c.DefaultQuery("items_page", "1"))
+	itemPageSize, _ := strconv.Atoi(c.DefaultQuery("items_page_size", "20"))
+	itemPage, itemPageSize = shared.NormalizePagination(itemPage, itemPageSize)
+	items, itemsTotal, err := h.WalletLedgerAuditService.ListDiscrepancies(repository.WalletLedgerDiscrepancyListFilter{
+		Page:     itemPage,
+		PageSize: itemPageSize,
+		AuditID:  id,
+	})
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.wallet_ledger_audit_fetch_failed", err)
+		return
+	}
+	response.Success(c, gin.H{
+		"audit":       audit,
+		"items":       items,
+		"items_total": itemsTotal,
+	})
+}
+
+// GetAdminWalletLedgerDiscrepancies 钱包账本差异明细列表
+func (h *Handler) GetAdminWalletLedgerDiscrepancies(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	page, pageSize = shared.NormalizePagination(page, pageSize)
+
+	filter := repository.WalletLedgerDiscrepancyListFilter{
+		Page:     page,
+		PageSize: pageSize,
+		Type:     strings.TrimSpace(c.Query("type")),
+	}
+	var err error
+	if filter.AuditID, err = shared.ParseQueryUint(c.Query("audit_id"), false); err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	if filter.UserID, err = shared.ParseQueryUint(c.Query("user_id"), false); err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	if filter.OrderID, err = shared.ParseQueryUint(c.Query("order_id"), false); err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	if raw := strings.TrimSpace(c.Query("resolved")); raw != "" {
+		resolved, err := strconv.ParseBool(raw)
+		if err != nil {
+			shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+			return
+		}
+		filter.Resolved = &resolved
+	}
+
+	rows, total, err := h.WalletLedgerAuditService.ListDiscrepancies(filter)
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.wallet_ledger_audit_fetch_failed", err)
+		return
+	}
+	response.SuccessWithPage(c, rows, response.BuildPagination(page, pageSize, total))
+}
+
+// ResolveAdminWalletLedgerDiscrepancy 标记钱包账本差异已处理
+func (h *Handler) ResolveAdminWalletLedgerDiscrepancy(c *gin.Context) {
+	adminID, ok := shared.GetAdminID(c)
+	if !ok {
+		return
+	}
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	var req AdminWalletLedgerDiscrepancyResolveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	item, err := h.WalletLedgerAuditService.ResolveDiscrepancy(id, adminID, req.Remark)
+	if err != nil {
+		if errors.Is(err, service.ErrWalletLedgerDiscrepancyNotFound) {
+			shared.RespondError(c, response.CodeNotFound, "error.wallet_ledger_discrepancy_not_found", nil)
+			return
+		}
+		shared.RespondError(c, response.CodeInternal, "error.wallet_ledger_resolve_failed", err)
+		return
+	}
+	response.Success(c, item)
+}
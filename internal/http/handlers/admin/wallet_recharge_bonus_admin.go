@@ -0,0 +1,199 @@
+package admin
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/dujiao-next/internal/http/handlers/shared"
+	"github.com/dujiao-next/internal/http/response"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/dujiao-next/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// WalletRechargeBonusTierRequest 充值赠送档位请求
+type WalletRechargeBonusTierRequest struct {
+	MinAmount float64 `json:"min_amount" binding:"required"`
+	Type      string  `json:"type" binding:"required"` // fixed/percent
+	Value     float64 `json:"value" binding:"required"`
+	MaxBonus  float64 `json:"max_bonus"`
+}
+
+// WalletRechargeBonusRuleRequest 创建/更新充值赠送活动请求
+type WalletRechargeBonusRuleRequest struct {
+	Name              string                           `json:"name" binding:"required"`
+	Currency          string                           `json:"currency"`
+	Tiers             []WalletRechargeBonusTierRequest `json:"tiers" binding:"required"`
+	FirstRechargeOnly bool                             `json:"first_recharge_only"`
+	MemberLevels      []uint                           `json:"member_levels"`
+	PerUserLimit      int                              `json:"per_user_limit"`
+	PerUserBonusCap   float64                          `json:"per_user_bonus_cap"`
+	Locked            bool                             `json:"locked"`
+	StartsAt          string                           `json:"starts_at"`
+	EndsAt            string                           `json:"ends_at"`
+	IsActive          *bool                            `json:"is_active"`
+}
+
+// AdminWalletRechargeRefundRequest 充值退款登记请求
+type AdminWalletRechargeRefundRequest struct {
+	Remark string `json:"remark"`
+}
+
+// GetAdminWalletRechargeBonusRules 获取充值赠送活动列表
+func (h *Handler) GetAdminWalletRechargeBonusRules(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	page, pageSize = shared.NormalizePagination(page, pageSize)
+
+	filter := repository.WalletRechargeBonusRuleListFilter{
+		Page:     page,
+		PageSize: pageSize,
+		Keyword:  strings.TrimSpace(c.Query("keyword")),
+	}
+	if raw := strings.TrimSpace(c.Query("is_active")); raw != "" {
+		active, err := strconv.ParseBool(raw)
+		if err != nil {
+			shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+			return
+		}
+		filter.IsActive = &active
+	}
+
+	rules, total, err := h.WalletService.ListRechargeBonusRules(filter)
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.wallet_recharge_bonus_save_failed", err)
+		return
+	}
+	response.SuccessWithPage(c, rules, response.BuildPagination(page, pageSize, total))
+}
+
+// CreateAdminWalletRechargeBonusRule 创建充值赠送活动
+func (h *Handler) CreateAdminWalletRechargeBonusRule(c *gin.Context) {
+	input, ok := bindWalletRechargeBonusRuleInput(c)
+	if !ok {
+		return
+	}
+	rule, err := h.WalletService.CreateRechargeBonusRule(input)
+	if err != nil {
+		respondWalletRechargeBonusRuleError(c, err)
+		return
+	}
+	response.Success(c, rule)
+}
+
+// UpdateAdminWalletRechargeBonusRule 更新充值赠送活动
+func (h *Handler) UpdateAdminWalletRechargeBonusRule(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	input, ok := bindWalletRechargeBonusRuleInput(c)
+	if !ok {
+		return
+	}
+	rule, err := h.WalletService.UpdateRechargeBonusRule(id, input)
+	if err != nil {
+		respondWalletRechargeBonusRuleError(c, err)
+		return
+	}
+	response.Success(c, rule)
+}
+
+// DeleteAdminWalletRechargeBonusRule 删除充值赠送活动
+func (h *Handler) DeleteAdminWalletRechargeBonusRule(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	if err := h.WalletService.DeleteRechargeBonusRule(id); err != nil {
+		respondWalletRechargeBonusRuleError(c, err)
+		return
+	}
+	response.Success(c, nil)
+}
+
+// RefundAdminWalletRecharge 登记充值退款：扣回充值金额并追回赠送余额
+func (h *Handler) RefundAdminWalletRecharge(c *gin.Context) {
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	var req AdminWalletRechargeRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	recharge, err := h.WalletService.RefundRecharge(id, req.Remark)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrWalletRechargeNotFound):
+			shared.RespondError(c, response.CodeNotFound, "error.payment_not_found", nil)
+		case errors.Is(err, service.ErrWalletRechargeStatusInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.wallet_recharge_refund_status_invalid", nil)
+		case errors.Is(err, service.ErrWalletInsufficientBalance):
+			shared.RespondError(c, response.CodeBadRequest, "error.wallet_insufficient_balance", nil)
+		default:
+			shared.RespondError(c, response.CodeInternal, "error.wallet_recharge_refund_failed", err)
+		}
+		return
+	}
+	response.Success(c, recharge)
+}
+
+func bindWalletRechargeBonusRuleInput(c *gin.Context) (service.WalletRechargeBonusRuleInput, bool) {
+	var req WalletRechargeBonusRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return service.WalletRechargeBonusRuleInput{}, false
+	}
+	startsAt, err := shared.ParseTimeNullable(req.StartsAt)
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return service.WalletRechargeBonusRuleInput{}, false
+	}
+	endsAt, err := shared.ParseTimeNullable(req.EndsAt)
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return service.WalletRechargeBonusRuleInput{}, false
+	}
+	tiers := make([]models.WalletRechargeBonusTier, 0, len(req.Tiers))
+	for _, tier := range req.Tiers {
+		tiers = append(tiers, models.WalletRechargeBonusTier{
+			MinAmount: models.NewMoneyFromDecimal(decimal.NewFromFloat(tier.MinAmount)),
+			Type:      tier.Type,
+			Value:     models.NewMoneyFromDecimal(decimal.NewFromFloat(tier.Value)),
+			MaxBonus:  models.NewMoneyFromDecimal(decimal.NewFromFloat(tier.MaxBonus)),
+		})
+	}
+	return service.WalletRechargeBonusRuleInput{
+		Name:              req.Name,
+		Currency:          req.Currency,
+		Tiers:             tiers,
+		FirstRechargeOnly: req.FirstRechargeOnly,
+		MemberLevels:      req.MemberLevels,
+		PerUserLimit:      req.PerUserLimit,
+		PerUserBonusCap:   models.NewMoneyFromDecimal(decimal.NewFromFloat(req.PerUserBonusCap)),
+		Locked:            req.Locked,
+		StartsAt:          startsAt,
+		EndsAt:            endsAt,
+		IsActive:          req.IsActive,
+	}, true
+}
+
+func respondWalletRechargeBonusRuleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrWalletRechargeBonusRuleNotFound):
+		shared.RespondError(c, response.CodeNotFound, "error.wallet_recharge_bonus_not_found", nil)
+	case errors.Is(err, service.ErrWalletRechargeBonusRuleInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.wallet_recharge_bonus_invalid", nil)
+	default:
+		shared.RespondError(c, response.CodeInternal, "error.wallet_recharge_bonus_save_failed", err)
+	}
+}
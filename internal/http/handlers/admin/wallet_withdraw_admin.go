@@ -0,0 +1,152 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/http/handlers/shared"
+	"github.com/dujiao-next/internal/http/response"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/dujiao-next/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminWalletWithdrawRejectRequest 拒绝钱包提现请求
+type AdminWalletWithdrawRejectRequest struct {
+	Reason string `json:"reason"`
+}
+
+// AdminWalletWithdrawPayRequest 钱包提现打款请求
+type AdminWalletWithdrawPayRequest struct {
+	PayoutReference string `json:"payout_reference"` // 线下打款流水号
+}
+
+// AdminWalletWithdrawExportRequest 钱包提现导出请求
+type AdminWalletWithdrawExportRequest struct {
+	IDs    []uint `json:"ids"`
+	Status string `json:"status"`
+}
+
+// GetAdminWalletWithdraws 管理端钱包提现审核列表
+func (h *Handler) GetAdminWalletWithdraws(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	page, pageSize = shared.NormalizePagination(page, pageSize)
+
+	userID, err := shared.ParseQueryUint(c.Query("user_id"), false)
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	createdFrom, err := shared.ParseTimeNullable(strings.TrimSpace(c.Query("created_from")))
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	createdTo, err := shared.ParseTimeNullable(strings.TrimSpace(c.Query("created_to")))
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+
+	rows, total, err := h.WalletService.ListAdminWithdraws(repository.WalletWithdrawListFilter{
+		Page:        page,
+		PageSize:    pageSize,
+		UserID:      userID,
+		Status:      strings.TrimSpace(c.Query("status")),
+		Keyword:     strings.TrimSpace(c.Query("keyword")),
+		CreatedFrom: createdFrom,
+		CreatedTo:   createdTo,
+	})
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.wallet_withdraw_failed", err)
+		return
+	}
+	response.SuccessWithPage(c, rows, response.BuildPagination(page, pageSize, total))
+}
+
+// RejectAdminWalletWithdraw 拒绝钱包提现申请并退回冻结余额
+func (h *Handler) RejectAdminWalletWithdraw(c *gin.Context) {
+	adminID, ok := shared.GetAdminID(c)
+	if !ok {
+		return
+	}
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
+		return
+	}
+	var req AdminWalletWithdrawRejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	row, err := h.WalletService.ReviewWithdraw(adminID, id, constants.WalletWithdrawActionReject, req.Reason, "")
+	if err != nil {
+		respondAdminWalletWithdrawError(c, err)
+		return
+	}
+	response.Success(c, row)
+}
+
+// PayAdminWalletWithdraw 标记钱包提现已打款
+func (h *Handler) PayAdminWalletWithdraw(c *gin.Context) {
+	adminID, ok := shared.GetAdminID(c)
+	if !ok {
+		return
+	}
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
+		return
+	}
+	var req AdminWalletWithdrawPayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	row, err := h.WalletService.ReviewWithdraw(adminID, id, constants.WalletWithdrawActionPay, "", req.PayoutReference)
+	if err != nil {
+		respondAdminWalletWithdrawError(c, err)
+		return
+	}
+	response.Success(c, row)
+}
+
+// ExportAdminWalletWithdraws 导出钱包提现申请用于批量打款
+func (h *Handler) ExportAdminWalletWithdraws(c *gin.Context) {
+	var req AdminWalletWithdrawExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	content, contentType, err := h.WalletService.ExportWithdraws(repository.WalletWithdrawListFilter{
+		IDs:    req.IDs,
+		Status: strings.TrimSpace(req.Status),
+	})
+	if err != nil {
+		respondAdminWalletWithdrawError(c, err)
+		return
+	}
+	filename := fmt.Sprintf("wallet_withdraws_%s.%s", time.Now().Format("20060102_150405"), constants.ExportFormatCSV)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, contentType, content)
+}
+
+func respondAdminWalletWithdrawError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrWalletWithdrawNotFound):
+		shared.RespondError(c, response.CodeNotFound, "error.wallet_withdraw_not_found", nil)
+	case errors.Is(err, service.ErrWalletWithdrawStatusInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.wallet_withdraw_status_invalid", nil)
+	default:
+		shared.RespondError(c, response.CodeInternal, "error.wallet_withdraw_failed", err)
+	}
+}
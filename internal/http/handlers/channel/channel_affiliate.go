@@ -2,11 +2,13 @@ package channel
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/dujiao-next/internal/constants"
 	"github.com/dujiao-next/internal/http/handlers/shared"
 	"github.com/dujiao-next/internal/http/response"
 	"github.com/dujiao-next/internal/logger"
@@ -24,6 +26,10 @@ type channelAffiliateTrackClickRequest struct {
 	VisitorKey     string `json:"visitor_key,omitempty"`
 	LandingPath    string `json:"landing_path,omitempty"`
 	Referrer       string `json:"referrer,omitempty"`
+	SubID          string `json:"sub_id,omitempty"`
+	UTMSource      string `json:"utm_source,omitempty"`
+	UTMMedium      string `json:"utm_medium,omitempty"`
+	UTMCampaign    string `json:"utm_campaign,omitempty"`
 }
 
 type channelAffiliateApplyWithdrawRequest struct {
@@ -110,6 +116,10 @@ func (h *Handler) TrackAffiliateClick(c *gin.Context) {
 		Referrer:      strings.TrimSpace(req.Referrer),
 		ClientIP:      c.ClientIP(),
 		UserAgent:     c.GetHeader("User-Agent"),
+		SubID:         req.SubID,
+		UTMSource:     req.UTMSource,
+		UTMMedium:     req.UTMMedium,
+		UTMCampaign:   req.UTMCampaign,
 	}); err != nil {
 		logger.Errorw("channel_affiliate_track_click_failed", "channel_user_id", channelUserID, "affiliate_code", req.AffiliateCode, "error", err)
 		respondChannelError(c, http.StatusInternalServerError, response.CodeInternal, "affiliate_track_click_failed", "error.save_failed", err)
@@ -149,20 +159,87 @@ func (h *Handler) GetAffiliateDashboard(c *gin.Context) {
 	}
 
 	respondChannelSuccess(c, gin.H{
-		"opened":               dashboard.Opened,
-		"affiliate_code":       dashboard.AffiliateCode,
-		"promotion_path":       dashboard.PromotionPath,
-		"click_count":          dashboard.ClickCount,
-		"valid_order_count":    dashboard.ValidOrderCount,
-		"conversion_rate":      dashboard.ConversionRate,
-		"pending_commission":   dashboard.PendingCommission,
-		"available_commission": dashboard.AvailableCommission,
-		"withdrawn_commission": dashboard.WithdrawnCommission,
-		"min_withdraw_amount":  setting.MinWithdrawAmount,
-		"withdraw_channels":    setting.WithdrawChannels,
+		"opened":                 dashboard.Opened,
+		"affiliate_code":         dashboard.AffiliateCode,
+		"promotion_path":         dashboard.PromotionPath,
+		"click_count":            dashboard.ClickCount,
+		"valid_order_count":      dashboard.ValidOrderCount,
+		"conversion_rate":        dashboard.ConversionRate,
+		"pending_commission":     dashboard.PendingCommission,
+		"available_commission":   dashboard.AvailableCommission,
+		"withdrawn_commission":   dashboard.WithdrawnCommission,
+		"referred_user_count":    dashboard.ReferredUserCount,
+		"direct_commission":      dashboard.DirectCommission,
+		"second_tier_commission": dashboard.SecondTierCommission,
+		"second_tier_enabled":    setting.SecondTierEnabled,
+		"min_withdraw_amount":    setting.MinWithdrawAmount,
+		"withdraw_channels":      setting.WithdrawChannels,
 	})
 }
 
+// GetAffiliateFunnel GET /api/v1/channel/affiliate/funnel
+func (h *Handler) GetAffiliateFunnel(c *gin.Context) {
+	if h.AffiliateService == nil {
+		respondChannelError(c, http.StatusInternalServerError, response.CodeInternal, "internal_error", "error.internal_error", nil)
+		return
+	}
+
+	userID, channelUserID, ok := h.resolveChannelAffiliateUserID(c)
+	if !ok {
+		return
+	}
+
+	funnel, err := h.AffiliateService.GetUserFunnel(userID, channelAffiliateFunnelQuery(c))
+	if err != nil {
+		respondChannelAffiliateFunnelError(c, userID, channelUserID, err)
+		return
+	}
+	respondChannelSuccess(c, funnel)
+}
+
+// ExportAffiliateFunnel GET /api/v1/channel/affiliate/funnel/export
+func (h *Handler) ExportAffiliateFunnel(c *gin.Context) {
+	if h.AffiliateService == nil {
+		respondChannelError(c, http.StatusInternalServerError, response.CodeInternal, "internal_error", "error.internal_error", nil)
+		return
+	}
+
+	userID, channelUserID, ok := h.resolveChannelAffiliateUserID(c)
+	if !ok {
+		return
+	}
+
+	content, contentType, err := h.AffiliateService.ExportUserFunnelCSV(userID, channelAffiliateFunnelQuery(c))
+	if err != nil {
+		respondChannelAffiliateFunnelError(c, userID, channelUserID, err)
+		return
+	}
+	filename := fmt.Sprintf("affiliate_funnel_%s.%s", time.Now().Format("20060102_150405"), constants.ExportFormatCSV)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, contentType, content)
+}
+
+func channelAffiliateFunnelQuery(c *gin.Context) service.AffiliateFunnelQuery {
+	return service.AffiliateFunnelQuery{
+		From:        strings.TrimSpace(c.Query("from")),
+		To:          strings.TrimSpace(c.Query("to")),
+		SubID:       strings.TrimSpace(c.Query("sub_id")),
+		UTMSource:   strings.TrimSpace(c.Query("utm_source")),
+		UTMMedium:   strings.TrimSpace(c.Query("utm_medium")),
+		UTMCampaign: strings.TrimSpace(c.Query("utm_campaign")),
+	}
+}
+
+func respondChannelAffiliateFunnelError(c *gin.Context, userID uint, channelUserID string, err error) {
+	if errors.Is(err, service.ErrAffiliateFunnelRangeInvalid) {
+		respondChannelError(c, http.StatusBadRequest, response.CodeBadRequest, "validation_error", "error.bad_request", nil)
+		return
+	}
+	logger.Errorw("channel_affiliate_funnel_failed", "user_id", userID, "channel_user_id", channelUserID, "error", err)
+	respondChannelError(c, http.StatusInternalServerError, response.CodeInternal, "affiliate_funnel_failed", "error.user_fetch_failed", err)
+}
+
 // ListAffiliateCommissions GET /api/v1/channel/affiliate/commissions
 func (h *Handler) ListAffiliateCommissions(c *gin.Context) {
 	if h.AffiliateService == nil {
@@ -179,8 +256,9 @@ func (h *Handler) ListAffiliateCommissions(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	page, pageSize = shared.NormalizePagination(page, pageSize)
 	status := strings.TrimSpace(c.Query("status"))
+	level, _ := strconv.Atoi(strings.TrimSpace(c.Query("level")))
 
-	rows, total, err := h.AffiliateService.ListUserCommissions(userID, page, pageSize, status)
+	rows, total, err := h.AffiliateService.ListUserCommissions(userID, page, pageSize, status, level)
 	if err != nil {
 		logger.Errorw("channel_affiliate_commissions_failed", "user_id", userID, "channel_user_id", channelUserID, "error", err)
 		respondChannelError(c, http.StatusInternalServerError, response.CodeInternal, "affiliate_commissions_failed", "error.user_fetch_failed", err)
@@ -196,6 +274,7 @@ func (h *Handler) ListAffiliateCommissions(c *gin.Context) {
 			"order_no":             strings.TrimSpace(row.Order.OrderNo),
 			"order_item_id":        channelAffiliateUintValue(row.OrderItemID),
 			"commission_type":      row.CommissionType,
+			"level":                row.Level,
 			"base_amount":          row.BaseAmount,
 			"rate_percent":         row.RatePercent,
 			"commission_amount":    row.CommissionAmount,
@@ -248,6 +327,8 @@ func (h *Handler) ListAffiliateWithdraws(c *gin.Context) {
 			"id":                   row.ID,
 			"affiliate_profile_id": row.AffiliateProfileID,
 			"amount":               row.Amount,
+			"direct_amount":        row.DirectAmount,
+			"second_tier_amount":   row.SecondTierAmount,
 			"channel":              row.Channel,
 			"account":              row.Account,
 			"status":               row.Status,
@@ -328,6 +409,8 @@ func (h *Handler) ApplyAffiliateWithdraw(c *gin.Context) {
 		"id":                   row.ID,
 		"affiliate_profile_id": row.AffiliateProfileID,
 		"amount":               row.Amount,
+		"direct_amount":        row.DirectAmount,
+		"second_tier_amount":   row.SecondTierAmount,
 		"channel":              row.Channel,
 		"account":              row.Account,
 		"status":               row.Status,
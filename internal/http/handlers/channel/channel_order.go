@@ -470,6 +470,10 @@ func (h *Handler) GetOrderStatus(c *gin.Context) {
 		return
 	}
 
+	if err := h.DeliveryLinkService.MaskOrderPayloads(order); err != nil {
+		respondChannelError(c, 500, response.CodeInternal, "internal_error", "error.order_fetch_failed", err)
+		return
+	}
 	order.MaskUpstreamFulfillmentType()
 	order.StripCostPrice()
 	respondChannelSuccess(c, buildChannelOrderDetailResponse(order, channelLocaleValue(c, c.Query("locale"))))
@@ -510,6 +514,10 @@ func (h *Handler) GetOrderByOrderNo(c *gin.Context) {
 		return
 	}
 
+	if err := h.DeliveryLinkService.MaskOrderPayloads(order); err != nil {
+		respondChannelError(c, 500, response.CodeInternal, "internal_error", "error.order_fetch_failed", err)
+		return
+	}
 	order.MaskUpstreamFulfillmentType()
 	order.StripCostPrice()
 	respondChannelSuccess(c, buildChannelOrderDetailResponse(order, channelLocaleValue(c, c.Query("locale"))))
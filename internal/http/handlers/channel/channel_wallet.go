@@ -47,7 +47,7 @@ func (h *Handler) GetWallet(c *gin.Context) {
 
 	respondChannelSuccess(c, gin.H{
 		"balance":  account.Balance.StringFixed(2),
-		"currency": "CNY",
+		"currency": account.Currency,
 	})
 }
 
@@ -226,3 +226,231 @@ func (h *Handler) CreateWalletRecharge(c *gin.Context) {
 		},
 	})
 }
+
+// TransferWallet POST /api/v1/channel/wallet/transfer
+func (h *Handler) TransferWallet(c *gin.Context) {
+	var req struct {
+		ChannelUserID  string `json:"channel_user_id"`
+		TelegramUserID string `json:"telegram_user_id"`
+		Recipient      string `json:"recipient" binding:"required"` // 收款人邮箱或用户ID
+		Amount         string `json:"amount" binding:"required"`
+		Currency       string `json:"currency"`
+		Remark         string `json:"remark"`
+		Code           string `json:"code"`
+		RecoveryCode   string `json:"recovery_code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondChannelBindError(c, err)
+		return
+	}
+	channelUserID := channelUserIDValue(req.ChannelUserID, req.TelegramUserID)
+	if channelUserID == "" {
+		respondChannelError(c, 400, 400, "validation_error", "error.bad_request", nil)
+		return
+	}
+
+	userID, err := h.provisionTelegramChannelUserID(service.TelegramChannelIdentityInput{ChannelUserID: channelUserID})
+	if err != nil {
+		logger.Errorw("channel_wallet_transfer_resolve_user", "channel_user_id", channelUserID, "error", err)
+		respondChannelIdentityServiceError(c, err)
+		return
+	}
+
+	amount, err := decimal.NewFromString(strings.TrimSpace(req.Amount))
+	if err != nil {
+		respondChannelError(c, 400, 400, "validation_error", "error.bad_request", nil)
+		return
+	}
+
+	result, err := h.WalletTransferService.Transfer(service.WalletTransferInput{
+		FromUserID:   userID,
+		Recipient:    req.Recipient,
+		Amount:       models.NewMoneyFromDecimal(amount),
+		Currency:     req.Currency,
+		Remark:       req.Remark,
+		TOTPCode:     req.Code,
+		RecoveryCode: req.RecoveryCode,
+		Source:       constants.WalletTransferSourceChannel,
+	})
+	if err != nil {
+		logger.Warnw("channel_wallet_transfer_failed", "user_id", userID, "channel_user_id", channelUserID, "error", err)
+		switch {
+		case errors.Is(err, service.ErrWalletTransferDisabled):
+			respondChannelError(c, 403, 403, "transfer_disabled", "error.wallet_transfer_disabled", nil)
+		case errors.Is(err, service.ErrWalletTransferRecipientNotFound):
+			respondChannelError(c, 404, 404, "recipient_not_found", "error.wallet_transfer_recipient_not_found", nil)
+		case errors.Is(err, service.ErrWalletTransferSelf):
+			respondChannelError(c, 400, 400, "transfer_to_self", "error.wallet_transfer_self", nil)
+		case errors.Is(err, service.ErrWalletTransferAmountInvalid):
+			respondChannelError(c, 400, 400, "validation_error", "error.wallet_transfer_amount_invalid", nil)
+		case errors.Is(err, service.ErrWalletTransferDailyLimitExceeded):
+			respondChannelError(c, 400, 400, "transfer_daily_limit", "error.wallet_transfer_daily_limit", nil)
+		case errors.Is(err, service.ErrWalletTransferTOTPRequired):
+			respondChannelError(c, 400, 400, "totp_required", "error.totp_code_required", nil)
+		case errors.Is(err, service.ErrTOTPCodeInvalid):
+			respondChannelError(c, 400, 400, "totp_invalid", "error.totp_code_invalid", nil)
+		case errors.Is(err, service.ErrTOTPRecoveryInvalid):
+			respondChannelError(c, 400, 400, "totp_invalid", "error.recovery_code_invalid", nil)
+		case errors.Is(err, service.ErrTOTPTooManyAttempts):
+			respondChannelError(c, 429, 429, "totp_locked", "error.totp_too_many_attempts", nil)
+		case errors.Is(err, service.ErrWalletInsufficientBalance):
+			respondChannelError(c, 400, 400, "insufficient_balance", "error.wallet_insufficient_balance", nil)
+		case errors.Is(err, service.ErrWalletBonusLocked):
+			respondChannelError(c, 400, 400, "bonus_locked", "error.wallet_bonus_locked", nil)
+		case errors.Is(err, service.ErrWalletCurrencyInvalid):
+			respondChannelError(c, 400, 400, "validation_error", "error.wallet_currency_invalid", nil)
+		default:
+			respondChannelError(c, 500, 500, "transfer_failed", "error.wallet_transfer_failed", err)
+		}
+		return
+	}
+
+	respondChannelSuccess(c, gin.H{
+		"transfer_no":  result.Transfer.TransferNo,
+		"recipient_id": result.Recipient.ID,
+		"amount":       result.Transfer.Amount.StringFixed(2),
+		"fee_amount":   result.Transfer.FeeAmount.StringFixed(2),
+		"currency":     result.Transfer.Currency,
+		"balance":      result.Account.Balance.StringFixed(2),
+	})
+}
+
+// ApplyWalletWithdraw POST /api/v1/channel/wallet/withdraws
+func (h *Handler) ApplyWalletWithdraw(c *gin.Context) {
+	var req struct {
+		ChannelUserID  string `json:"channel_user_id"`
+		TelegramUserID string `json:"telegram_user_id"`
+		Amount         string `json:"amount" binding:"required"`
+		Channel        string `json:"channel" binding:"required"`
+		Account        string `json:"account" binding:"required"`
+		AccountName    string `json:"account_name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondChannelBindError(c, err)
+		return
+	}
+	channelUserID := channelUserIDValue(req.ChannelUserID, req.TelegramUserID)
+	if channelUserID == "" {
+		respondChannelError(c, 400, 400, "validation_error", "error.bad_request", nil)
+		return
+	}
+
+	userID, err := h.provisionTelegramChannelUserID(service.TelegramChannelIdentityInput{ChannelUserID: channelUserID})
+	if err != nil {
+		logger.Errorw("channel_wallet_withdraw_resolve_user", "channel_user_id", channelUserID, "error", err)
+		respondChannelIdentityServiceError(c, err)
+		return
+	}
+
+	amount, err := decimal.NewFromString(strings.TrimSpace(req.Amount))
+	if err != nil {
+		respondChannelError(c, 400, 400, "validation_error", "error.wallet_withdraw_amount_invalid", nil)
+		return
+	}
+
+	row, err := h.WalletService.ApplyWithdraw(userID, service.WalletWithdrawApplyInput{
+		Amount:      amount,
+		Channel:     req.Channel,
+		Account:     req.Account,
+		AccountName: req.AccountName,
+	})
+	if err != nil {
+		logger.Warnw("channel_wallet_withdraw_failed", "user_id", userID, "channel_user_id", channelUserID, "error", err)
+		switch {
+		case errors.Is(err, service.ErrWalletWithdrawDisabled):
+			respondChannelError(c, 403, 403, "withdraw_disabled", "error.wallet_withdraw_disabled", nil)
+		case errors.Is(err, service.ErrWalletWithdrawAmountInvalid):
+			respondChannelError(c, 400, 400, "validation_error", "error.wallet_withdraw_amount_invalid", nil)
+		case errors.Is(err, service.ErrWalletWithdrawChannelInvalid):
+			respondChannelError(c, 400, 400, "validation_error", "error.wallet_withdraw_channel_invalid", nil)
+		case errors.Is(err, service.ErrWalletInsufficientBalance):
+			respondChannelError(c, 400, 400, "insufficient_balance", "error.wallet_insufficient_balance", nil)
+		case errors.Is(err, service.ErrWalletBonusLocked):
+			respondChannelError(c, 400, 400, "bonus_locked", "error.wallet_bonus_locked", nil)
+		default:
+			respondChannelError(c, 500, 500, "withdraw_failed", "error.wallet_withdraw_failed", err)
+		}
+		return
+	}
+
+	account, err := h.WalletService.GetAccountByCurrency(userID, row.Currency)
+	if err != nil {
+		logger.Errorw("channel_wallet_withdraw_get_account", "user_id", userID, "error", err)
+		respondChannelError(c, 500, 500, "internal_error", "error.internal_error", err)
+		return
+	}
+
+	respondChannelSuccess(c, gin.H{
+		"withdraw_no": row.WithdrawNo,
+		"amount":      row.Amount.StringFixed(2),
+		"currency":    row.Currency,
+		"channel":     row.Channel,
+		"status":      row.Status,
+		"balance":     account.Balance.StringFixed(2),
+	})
+}
+
+// GetWalletWithdraws GET /api/v1/channel/wallet/withdraws?telegram_user_id=xxx&page=1&page_size=5
+func (h *Handler) GetWalletWithdraws(c *gin.Context) {
+	channelUserID := channelUserIDFromQuery(c)
+	if channelUserID == "" {
+		respondChannelError(c, 400, 400, "validation_error", "error.bad_request", nil)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "5"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 20 {
+		pageSize = 5
+	}
+
+	userID, err := h.provisionTelegramChannelUserID(service.TelegramChannelIdentityInput{ChannelUserID: channelUserID})
+	if err != nil {
+		logger.Errorw("channel_wallet_withdraws_resolve_user", "channel_user_id", channelUserID, "error", err)
+		respondChannelIdentityServiceError(c, err)
+		return
+	}
+
+	rows, total, err := h.WalletService.ListUserWithdraws(userID, page, pageSize, c.Query("status"))
+	if err != nil {
+		logger.Errorw("channel_wallet_list_withdraws", "user_id", userID, "error", err)
+		respondChannelError(c, 500, 500, "internal_error", "error.internal_error", err)
+		return
+	}
+
+	type withdrawItem struct {
+		WithdrawNo   string `json:"withdraw_no"`
+		Amount       string `json:"amount"`
+		Currency     string `json:"currency"`
+		Channel      string `json:"channel"`
+		Status       string `json:"status"`
+		RejectReason string `json:"reject_reason,omitempty"`
+		CreatedAt    string `json:"created_at"`
+	}
+
+	items := make([]withdrawItem, 0, len(rows))
+	for _, w := range rows {
+		items = append(items, withdrawItem{
+			WithdrawNo:   w.WithdrawNo,
+			Amount:       w.Amount.StringFixed(2),
+			Currency:     w.Currency,
+			Channel:      w.Channel,
+			Status:       w.Status,
+			RejectReason: w.RejectReason,
+			CreatedAt:    w.CreatedAt.Format("2006-01-02 15:04"),
+		})
+	}
+
+	totalPages := (total + int64(pageSize) - 1) / int64(pageSize)
+
+	respondChannelSuccess(c, gin.H{
+		"items":       items,
+		"page":        page,
+		"page_size":   pageSize,
+		"total":       total,
+		"total_pages": totalPages,
+	})
+}
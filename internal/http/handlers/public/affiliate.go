@@ -2,12 +2,16 @@ package public
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/shopspring/decimal"
 
+	"github.com/dujiao-next/internal/constants"
 	"github.com/dujiao-next/internal/dto"
 	"github.com/dujiao-next/internal/http/handlers/shared"
 	"github.com/dujiao-next/internal/http/response"
@@ -20,6 +24,10 @@ type AffiliateTrackClickRequest struct {
 	VisitorKey    string `json:"visitor_key"`
 	LandingPath   string `json:"landing_path"`
 	Referrer      string `json:"referrer"`
+	SubID         string `json:"sub_id"`
+	UTMSource     string `json:"utm_source"`
+	UTMMedium     string `json:"utm_medium"`
+	UTMCampaign   string `json:"utm_campaign"`
 }
 
 // TrackAffiliateClick 记录推广点击
@@ -38,6 +46,10 @@ func (h *Handler) TrackAffiliateClick(c *gin.Context) {
 			Referrer:      req.Referrer,
 			ClientIP:      c.ClientIP(),
 			UserAgent:     c.GetHeader("User-Agent"),
+			SubID:         req.SubID,
+			UTMSource:     req.UTMSource,
+			UTMMedium:     req.UTMMedium,
+			UTMCampaign:   req.UTMCampaign,
 		}); err != nil {
 			shared.RespondError(c, response.CodeInternal, "error.save_failed", err)
 			return
@@ -90,6 +102,64 @@ func (h *Handler) GetAffiliateDashboard(c *gin.Context) {
 	response.Success(c, data)
 }
 
+// GetAffiliateFunnel 查询我的推广转化漏斗（按日期与子渠道）
+func (h *Handler) GetAffiliateFunnel(c *gin.Context) {
+	uid, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+	if h.AffiliateService == nil {
+		shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", nil)
+		return
+	}
+	data, err := h.AffiliateService.GetUserFunnel(uid, buildAffiliateFunnelQuery(c))
+	if err != nil {
+		respondAffiliateFunnelError(c, err)
+		return
+	}
+	response.Success(c, data)
+}
+
+// ExportAffiliateFunnel 导出我的推广转化漏斗 CSV
+func (h *Handler) ExportAffiliateFunnel(c *gin.Context) {
+	uid, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+	if h.AffiliateService == nil {
+		shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", nil)
+		return
+	}
+	content, contentType, err := h.AffiliateService.ExportUserFunnelCSV(uid, buildAffiliateFunnelQuery(c))
+	if err != nil {
+		respondAffiliateFunnelError(c, err)
+		return
+	}
+	filename := fmt.Sprintf("affiliate_funnel_%s.%s", time.Now().Format("20060102_150405"), constants.ExportFormatCSV)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, contentType, content)
+}
+
+func buildAffiliateFunnelQuery(c *gin.Context) service.AffiliateFunnelQuery {
+	return service.AffiliateFunnelQuery{
+		From:        strings.TrimSpace(c.Query("from")),
+		To:          strings.TrimSpace(c.Query("to")),
+		SubID:       strings.TrimSpace(c.Query("sub_id")),
+		UTMSource:   strings.TrimSpace(c.Query("utm_source")),
+		UTMMedium:   strings.TrimSpace(c.Query("utm_medium")),
+		UTMCampaign: strings.TrimSpace(c.Query("utm_campaign")),
+	}
+}
+
+func respondAffiliateFunnelError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrAffiliateFunnelRangeInvalid) {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
+		return
+	}
+	shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", err)
+}
+
 // ListAffiliateCommissions 查询我的推广佣金记录
 func (h *Handler) ListAffiliateCommissions(c *gin.Context) {
 	uid, ok := shared.GetUserID(c)
@@ -104,8 +174,9 @@ func (h *Handler) ListAffiliateCommissions(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	page, pageSize = shared.NormalizePagination(page, pageSize)
 	status := strings.TrimSpace(c.Query("status"))
+	level, _ := strconv.Atoi(strings.TrimSpace(c.Query("level")))
 
-	rows, total, err := h.AffiliateService.ListUserCommissions(uid, page, pageSize, status)
+	rows, total, err := h.AffiliateService.ListUserCommissions(uid, page, pageSize, status, level)
 	if err != nil {
 		shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", err)
 		return
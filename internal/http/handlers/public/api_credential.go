@@ -2,10 +2,13 @@ package public
 
 import (
 	"errors"
+	"strconv"
 
 	"github.com/dujiao-next/internal/constants"
 	"github.com/dujiao-next/internal/http/handlers/shared"
 	"github.com/dujiao-next/internal/http/response"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
 	"github.com/dujiao-next/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -136,3 +139,233 @@ func (h *Handler) UpdateMyApiCredentialStatus(c *gin.Context) {
 
 	response.Success(c, gin.H{"updated": true})
 }
+
+// ApiCredentialKeyRequest 创建/修改密钥请求
+type ApiCredentialKeyRequest struct {
+	Name        string   `json:"name"`
+	Scopes      []string `json:"scopes"`
+	IPAllowlist []string `json:"ip_allowlist"`
+}
+
+// ListMyApiCredentials 获取自己的全部密钥
+func (h *Handler) ListMyApiCredentials(c *gin.Context) {
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	creds, err := h.ApiCredentialService.ListByUserID(userID)
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.api_credential_fetch_failed", err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(creds))
+	for i := range creds {
+		items = append(items, apiCredentialKeyView(&creds[i]))
+	}
+	response.Success(c, gin.H{
+		"items": items,
+		"limit": service.MaxApiCredentialsPerUser,
+	})
+}
+
+// CreateMyApiCredential 新建具名密钥（需已通过 API 对接审核），Secret 仅返回一次
+func (h *Handler) CreateMyApiCredential(c *gin.Context) {
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ApiCredentialKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+
+	cred, secret, err := h.ApiCredentialService.CreateKey(userID, req.toInput())
+	if err != nil {
+		respondApiCredentialKeyError(c, err, "error.api_credential_create_failed")
+		return
+	}
+
+	result := apiCredentialKeyView(cred)
+	result["api_secret"] = secret
+	response.Success(c, result)
+}
+
+// UpdateMyApiCredential 修改自己密钥的名称、权限范围与 IP 白名单
+func (h *Handler) UpdateMyApiCredential(c *gin.Context) {
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+
+	var req ApiCredentialKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+
+	cred, err := h.ApiCredentialService.UpdateKeyByUser(userID, id, req.toInput())
+	if err != nil {
+		respondApiCredentialKeyError(c, err, "error.api_credential_update_failed")
+		return
+	}
+	response.Success(c, apiCredentialKeyView(cred))
+}
+
+// RegenerateMyApiCredentialKey 重新生成指定密钥的 Secret
+func (h *Handler) RegenerateMyApiCredentialKey(c *gin.Context) {
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+
+	newSecret, err := h.ApiCredentialService.RegenerateKeyByUser(userID, id)
+	if err != nil {
+		respondApiCredentialKeyError(c, err, "error.api_credential_regenerate_failed")
+		return
+	}
+	response.Success(c, gin.H{
+		"api_secret": newSecret,
+	})
+}
+
+// UpdateMyApiCredentialKeyStatus 启用/禁用指定密钥
+func (h *Handler) UpdateMyApiCredentialKeyStatus(c *gin.Context) {
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+
+	var req UpdateMyApiCredentialStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+
+	if err := h.ApiCredentialService.SetKeyActiveByUser(userID, id, req.IsActive); err != nil {
+		respondApiCredentialKeyError(c, err, "error.api_credential_update_failed")
+		return
+	}
+	response.Success(c, gin.H{"updated": true})
+}
+
+// DeleteMyApiCredential 删除指定密钥
+func (h *Handler) DeleteMyApiCredential(c *gin.Context) {
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+	id, err := shared.ParseParamUint(c, "id")
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+
+	if err := h.ApiCredentialService.DeleteKeyByUser(userID, id); err != nil {
+		respondApiCredentialKeyError(c, err, "error.api_credential_delete_failed")
+		return
+	}
+	response.Success(c, gin.H{"deleted": true})
+}
+
+// GetMyApiCredentialUsage 自己密钥的每日用量
+func (h *Handler) GetMyApiCredentialUsage(c *gin.Context) {
+	userID, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	page, pageSize = shared.NormalizePagination(page, pageSize)
+	credentialID, err := shared.ParseQueryUint(c.Query("credential_id"), false)
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+
+	usages, total, err := h.ApiCredentialService.ListUsage(repository.ApiCredentialUsageListFilter{
+		Page:         page,
+		PageSize:     pageSize,
+		CredentialID: credentialID,
+		UserID:       userID,
+		DateFrom:     c.Query("date_from"),
+		DateTo:       c.Query("date_to"),
+	})
+	if err != nil {
+		respondApiCredentialKeyError(c, err, "error.api_credential_usage_fetch_failed")
+		return
+	}
+
+	pagination := response.BuildPagination(page, pageSize, total)
+	response.SuccessWithPage(c, usages, pagination)
+}
+
+func (req ApiCredentialKeyRequest) toInput() service.ApiCredentialKeyInput {
+	return service.ApiCredentialKeyInput{
+		Name:        req.Name,
+		Scopes:      req.Scopes,
+		IPAllowlist: req.IPAllowlist,
+	}
+}
+
+// apiCredentialKeyView 密钥展示字段（Secret 仅展示末 4 位）
+func apiCredentialKeyView(cred *models.ApiCredential) gin.H {
+	result := gin.H{
+		"id":                    cred.ID,
+		"name":                  cred.Name,
+		"api_key":               cred.ApiKey,
+		"status":                cred.Status,
+		"is_active":             cred.IsActive,
+		"scopes":                cred.Scopes,
+		"ip_allowlist":          cred.IPAllowlist,
+		"rate_limit_per_minute": cred.RateLimitPerMinute,
+		"daily_order_limit":     cred.DailyOrderLimit,
+		"daily_amount_limit":    cred.DailyAmountLimit,
+		"approved_at":           cred.ApprovedAt,
+		"last_used_at":          cred.LastUsedAt,
+		"created_at":            cred.CreatedAt,
+	}
+	if len(cred.ApiSecret) >= 4 {
+		result["api_secret_tail"] = cred.ApiSecret[len(cred.ApiSecret)-4:]
+	}
+	return result
+}
+
+func respondApiCredentialKeyError(c *gin.Context, err error, fallbackKey string) {
+	switch {
+	case errors.Is(err, service.ErrApiCredentialNotFound):
+		shared.RespondError(c, response.CodeNotFound, "error.api_credential_not_found", nil)
+	case errors.Is(err, service.ErrApiCredentialNotApproved):
+		shared.RespondError(c, response.CodeBadRequest, "error.api_credential_not_approved", nil)
+	case errors.Is(err, service.ErrApiCredentialLimitReached):
+		shared.RespondError(c, response.CodeBadRequest, "error.api_credential_limit_reached", nil)
+	case errors.Is(err, service.ErrApiCredentialScopeInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.api_credential_scope_invalid", nil)
+	case errors.Is(err, service.ErrApiCredentialIPInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.api_credential_ip_invalid", nil)
+	case errors.Is(err, service.ErrApiCredentialSettingsInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.api_credential_settings_invalid", nil)
+	case errors.Is(err, service.ErrApiCredentialUsageFilter):
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
+	default:
+		shared.RespondError(c, response.CodeInternal, fallbackKey, err)
+	}
+}
@@ -50,12 +50,16 @@ func (h *Handler) GetCart(c *gin.Context) {
 			continue
 		}
 		productFT := item.Product.FulfillmentType
-		if productFT == constants.FulfillmentTypeUpstream {
+		if productFT == constants.FulfillmentTypeUpstream || productFT == constants.FulfillmentTypeWebhook {
 			productFT = constants.FulfillmentTypeManual
+		} else if productFT == constants.FulfillmentTypeLicense || productFT == constants.FulfillmentTypeFile {
+			productFT = constants.FulfillmentTypeAuto
 		}
 		cartFT := item.FulfillmentType
-		if cartFT == constants.FulfillmentTypeUpstream {
+		if cartFT == constants.FulfillmentTypeUpstream || cartFT == constants.FulfillmentTypeWebhook {
 			cartFT = constants.FulfillmentTypeManual
+		} else if cartFT == constants.FulfillmentTypeLicense || cartFT == constants.FulfillmentTypeFile {
+			cartFT = constants.FulfillmentTypeAuto
 		}
 		product := dto.CartProductResp{
 			Slug:                item.Product.Slug,
@@ -0,0 +1,62 @@
+package public
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/dujiao-next/internal/http/handlers/shared"
+	"github.com/dujiao-next/internal/http/response"
+	"github.com/dujiao-next/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDeliveryLink 获取安全交付链接状态（不消耗查看次数）
+func (h *Handler) GetDeliveryLink(c *gin.Context) {
+	info, err := h.DeliveryLinkService.Resolve(strings.TrimSpace(c.Param("token")))
+	if err != nil {
+		respondDeliveryLinkError(c, err)
+		return
+	}
+	response.Success(c, info)
+}
+
+// RevealDeliveryLink 查看安全交付链接中的交付内容
+func (h *Handler) RevealDeliveryLink(c *gin.Context) {
+	result, err := h.DeliveryLinkService.Reveal(strings.TrimSpace(c.Param("token")), deliveryLinkAccess(c))
+	if err != nil {
+		respondDeliveryLinkError(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+// ConfirmDeliveryLinkReceived 买家确认已收到交付内容
+func (h *Handler) ConfirmDeliveryLinkReceived(c *gin.Context) {
+	info, err := h.DeliveryLinkService.MarkReceived(strings.TrimSpace(c.Param("token")), deliveryLinkAccess(c))
+	if err != nil {
+		respondDeliveryLinkError(c, err)
+		return
+	}
+	response.Success(c, info)
+}
+
+func deliveryLinkAccess(c *gin.Context) service.DeliveryLinkAccess {
+	return service.DeliveryLinkAccess{
+		ClientIP:  c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+func respondDeliveryLinkError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrDeliveryLinkInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.delivery_link_invalid", nil)
+	case errors.Is(err, service.ErrDeliveryLinkNotFound):
+		shared.RespondError(c, response.CodeNotFound, "error.delivery_link_not_found", nil)
+	case errors.Is(err, service.ErrDeliveryLinkExhausted):
+		shared.RespondError(c, response.CodeForbidden, "error.delivery_link_exhausted", nil)
+	default:
+		shared.RespondError(c, response.CodeInternal, "error.delivery_link_fetch_failed", err)
+	}
+}
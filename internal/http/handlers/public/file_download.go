@@ -0,0 +1,77 @@
+package public
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dujiao-next/internal/http/handlers/shared"
+	"github.com/dujiao-next/internal/http/response"
+	"github.com/dujiao-next/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DownloadFile 通过签名链接下载文件交付商品（支持 Range 断点续传）
+func (h *Handler) DownloadFile(c *gin.Context) {
+	token := strings.TrimSpace(c.Param("token"))
+	if c.Request.Method == http.MethodHead {
+		grant, err := h.FileDeliveryService.Resolve(token)
+		if err != nil {
+			respondFileDownloadError(c, err)
+			return
+		}
+		if grant.FileAsset != nil {
+			if grant.FileAsset.MimeType != "" {
+				c.Header("Content-Type", grant.FileAsset.MimeType)
+			}
+			c.Header("Content-Length", strconv.FormatInt(grant.FileAsset.Size, 10))
+		}
+		c.Header("Accept-Ranges", "bytes")
+		c.Status(http.StatusOK)
+		return
+	}
+
+	// If-Range 不匹配时 ServeContent 会返回完整文件，而计次只按 Range 计算续传字节，
+	// 因此忽略 If-Range，确保实际返回内容与计次一致
+	c.Request.Header.Del("If-Range")
+
+	download, err := h.FileDeliveryService.OpenDownload(token, service.FileDownloadAccess{
+		ClientIP:    c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		RangeHeader: c.GetHeader("Range"),
+	})
+	if err != nil {
+		respondFileDownloadError(c, err)
+		return
+	}
+	defer download.File.Close()
+
+	if download.MimeType != "" {
+		c.Header("Content-Type", download.MimeType)
+	}
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": download.Name})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	c.Header("Content-Disposition", disposition)
+	c.Header("Cache-Control", "private, no-store")
+	http.ServeContent(c.Writer, c.Request, download.Name, download.ModTime, download.File)
+}
+
+func respondFileDownloadError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrFileDownloadInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.file_download_invalid", nil)
+	case errors.Is(err, service.ErrFileDownloadExpired):
+		shared.RespondError(c, response.CodeForbidden, "error.file_download_expired", nil)
+	case errors.Is(err, service.ErrFileDownloadExhausted):
+		shared.RespondError(c, response.CodeForbidden, "error.file_download_exhausted", nil)
+	case errors.Is(err, service.ErrFileAssetNotFound):
+		shared.RespondError(c, response.CodeNotFound, "error.file_asset_not_found", nil)
+	default:
+		shared.RespondError(c, response.CodeInternal, "error.file_download_failed", err)
+	}
+}
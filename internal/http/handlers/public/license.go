@@ -0,0 +1,72 @@
+package public
+
+import (
+	"errors"
+
+	"github.com/dujiao-next/internal/http/handlers/shared"
+	"github.com/dujiao-next/internal/http/response"
+	"github.com/dujiao-next/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LicenseVerifyRequest 授权码校验请求
+type LicenseVerifyRequest struct {
+	LicenseKey string `json:"license_key" binding:"required"`
+	MachineID  string `json:"machine_id"`
+}
+
+// VerifyLicense 校验授权码，传入 machine_id 时登记设备激活
+func (h *Handler) VerifyLicense(c *gin.Context) {
+	var req LicenseVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	result, err := h.LicenseService.Verify(service.LicenseVerifyInput{
+		LicenseKey: req.LicenseKey,
+		MachineID:  req.MachineID,
+		ClientIP:   c.ClientIP(),
+	})
+	if err != nil {
+		respondLicenseError(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+// DeactivateLicense 解除设备激活
+func (h *Handler) DeactivateLicense(c *gin.Context) {
+	var req LicenseVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	result, err := h.LicenseService.Deactivate(service.LicenseVerifyInput{
+		LicenseKey: req.LicenseKey,
+		MachineID:  req.MachineID,
+		ClientIP:   c.ClientIP(),
+	})
+	if err != nil {
+		respondLicenseError(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+func respondLicenseError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrLicenseInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.license_invalid", nil)
+	case errors.Is(err, service.ErrLicenseRevoked):
+		shared.RespondError(c, response.CodeForbidden, "error.license_revoked", nil)
+	case errors.Is(err, service.ErrLicenseExpired):
+		shared.RespondError(c, response.CodeForbidden, "error.license_expired", nil)
+	case errors.Is(err, service.ErrLicenseActivationLimit):
+		shared.RespondError(c, response.CodeForbidden, "error.license_activation_limit", nil)
+	case errors.Is(err, service.ErrLicenseMachineIDInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.license_machine_id_invalid", nil)
+	default:
+		shared.RespondError(c, response.CodeInternal, "error.license_verify_failed", err)
+	}
+}
@@ -436,6 +436,10 @@ func (h *Handler) GetOrderByOrderNo(c *gin.Context) {
 		return
 	}
 
+	if err := h.DeliveryLinkService.MaskOrderPayloads(order); err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.order_fetch_failed", err)
+		return
+	}
 	orderDetail := dto.NewOrderDetailTruncated(order)
 	h.enrichOrderWithAllowedChannels(order, &orderDetail)
 	h.enrichOrderWithRefundRecords(order, &orderDetail)
@@ -502,11 +506,16 @@ func (h *Handler) DownloadFulfillment(c *gin.Context) {
 		shared.RespondError(c, response.CodeNotFound, "error.order_not_found", nil)
 		return
 	}
-	respondFulfillmentDownload(c, order)
+	h.respondFulfillmentDownload(c, order)
 }
 
 // respondFulfillmentDownload 输出订单交付内容下载响应。
-func respondFulfillmentDownload(c *gin.Context, order *models.Order) {
+// 安全链接模式的交付内容仅输出查看链接。
+func (h *Handler) respondFulfillmentDownload(c *gin.Context, order *models.Order) {
+	if err := h.DeliveryLinkService.MaskOrderPayloads(order); err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.order_fetch_failed", err)
+		return
+	}
 	payload := collectFulfillmentPayload(order)
 	if payload == "" {
 		shared.RespondError(c, response.CodeNotFound, "error.fulfillment_not_found", nil)
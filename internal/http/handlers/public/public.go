@@ -454,6 +454,21 @@ func (h *Handler) decorateProductStock(product *models.Product, item *publicProd
 		fulfillmentType = constants.FulfillmentTypeManual
 	}
 
+	// webhook 类型：由外部系统发货，按人工交付展示且不限库存
+	if fulfillmentType == constants.FulfillmentTypeWebhook {
+		item.Product.FulfillmentType = constants.FulfillmentTypeManual
+		item.ManualStockAvailable = constants.ManualStockUnlimited
+		item.StockStatus = constants.ProductStockStatusUnlimited
+		return
+	}
+
+	// license / file 类型：交付时实时签发，按自动交付展示且不限库存
+	if fulfillmentType == constants.FulfillmentTypeLicense || fulfillmentType == constants.FulfillmentTypeFile {
+		item.Product.FulfillmentType = constants.FulfillmentTypeAuto
+		item.StockStatus = constants.ProductStockStatusUnlimited
+		return
+	}
+
 	// upstream 类型：根据 SKU 映射中的上游库存判断
 	if fulfillmentType == constants.FulfillmentTypeUpstream {
 		h.decorateUpstreamStock(product, item)
@@ -989,6 +1004,10 @@ func (h *Handler) GetGuestOrderByOrderNo(c *gin.Context) {
 		shared.RespondError(c, response.CodeInternal, "error.order_fetch_failed", err)
 		return
 	}
+	if err := h.DeliveryLinkService.MaskOrderPayloads(order); err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.order_fetch_failed", err)
+		return
+	}
 	orderDetail := dto.NewOrderDetailTruncated(order)
 	h.enrichOrderWithAllowedChannels(order, &orderDetail)
 	h.enrichOrderWithRefundRecords(order, &orderDetail)
@@ -1018,7 +1037,7 @@ func (h *Handler) DownloadGuestFulfillment(c *gin.Context) {
 		shared.RespondError(c, response.CodeNotFound, "error.guest_order_not_found", nil)
 		return
 	}
-	respondFulfillmentDownload(c, order)
+	h.respondFulfillmentDownload(c, order)
 }
 
 // CreateGuestPaymentRequest 游客发起支付请求
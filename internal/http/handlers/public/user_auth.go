@@ -9,6 +9,7 @@ import (
 	"github.com/dujiao-next/internal/http/handlers/shared"
 	"github.com/dujiao-next/internal/http/response"
 	"github.com/dujiao-next/internal/i18n"
+	"github.com/dujiao-next/internal/logger"
 	"github.com/dujiao-next/internal/models"
 	"github.com/dujiao-next/internal/service"
 
@@ -111,10 +112,12 @@ func (h *Handler) SendUserVerifyCode(c *gin.Context) {
 
 // UserRegisterRequest 注册请求
 type UserRegisterRequest struct {
-	Email             string `json:"email" binding:"required"`
-	Password          string `json:"password" binding:"required"`
-	Code              string `json:"code"`
-	AgreementAccepted bool   `json:"agreement_accepted"`
+	Email               string `json:"email" binding:"required"`
+	Password            string `json:"password" binding:"required"`
+	Code                string `json:"code"`
+	AgreementAccepted   bool   `json:"agreement_accepted"`
+	AffiliateCode       string `json:"affiliate_code"`
+	AffiliateVisitorKey string `json:"affiliate_visitor_key"`
 }
 
 // UserRegister 用户注册
@@ -175,6 +178,13 @@ func (h *Handler) UserRegister(c *gin.Context) {
 		return
 	}
 
+	// 推广关系绑定失败不影响注册结果
+	if h.AffiliateService != nil && user != nil {
+		if err := h.AffiliateService.BindUserReferral(user.ID, req.AffiliateCode, req.AffiliateVisitorKey); err != nil {
+			logger.Warnw("public_user_register_bind_referral_failed", "user_id", user.ID, "error", err)
+		}
+	}
+
 	response.Success(c, gin.H{
 		"user":       dto.NewUserAuthBriefResp(user),
 		"token":      token,
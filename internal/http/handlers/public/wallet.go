@@ -77,7 +77,12 @@ func (h *Handler) GetMyWallet(c *gin.Context) {
 		shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", err)
 		return
 	}
-	response.Success(c, dto.NewWalletAccountResp(account))
+	accounts, err := h.WalletService.ListAccounts(uid)
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", err)
+		return
+	}
+	response.Success(c, dto.NewWalletOverviewResp(account, accounts))
 }
 
 // GetMyWalletTransactions 获取当前用户钱包流水
@@ -94,6 +99,7 @@ func (h *Handler) GetMyWalletTransactions(c *gin.Context) {
 		Page:     page,
 		PageSize: pageSize,
 		UserID:   uid,
+		Currency: strings.ToUpper(strings.TrimSpace(c.Query("currency"))),
 	})
 	if err != nil {
 		shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", err)
@@ -104,6 +110,35 @@ func (h *Handler) GetMyWalletTransactions(c *gin.Context) {
 	response.SuccessWithPage(c, dto.NewWalletTransactionRespList(transactions), pagination)
 }
 
+// GetMyWalletStatement 获取当前用户按币种汇总的钱包对账单
+func (h *Handler) GetMyWalletStatement(c *gin.Context) {
+	uid, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+	createdFrom, err := shared.ParseTimeNullable(strings.TrimSpace(c.Query("created_from")))
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	createdTo, err := shared.ParseTimeNullable(strings.TrimSpace(c.Query("created_to")))
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	statements, err := h.WalletService.Statement(repository.WalletTransactionListFilter{
+		UserID:      uid,
+		Currency:    c.Query("currency"),
+		CreatedFrom: createdFrom,
+		CreatedTo:   createdTo,
+	})
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.wallet_statement_failed", err)
+		return
+	}
+	response.Success(c, dto.NewWalletStatementRespList(statements))
+}
+
 // RechargeWallet 用户充值钱包余额
 func (h *Handler) RechargeWallet(c *gin.Context) {
 	uid, ok := shared.GetUserID(c)
@@ -142,12 +177,14 @@ func (h *Handler) RechargeWallet(c *gin.Context) {
 			shared.RespondError(c, response.CodeBadRequest, "error.bad_request", nil)
 		case errors.Is(err, service.ErrWalletNotSupportedForGuest):
 			shared.RespondError(c, response.CodeBadRequest, "error.payment_invalid", nil)
+		case errors.Is(err, service.ErrWalletCurrencyInvalid):
+			shared.RespondError(c, response.CodeBadRequest, "error.wallet_currency_invalid", nil)
 		default:
 			respondPaymentCreateError(c, err)
 		}
 		return
 	}
-	account, err := h.WalletService.GetAccount(uid)
+	account, err := h.WalletService.GetAccountByCurrency(uid, result.Recharge.Currency)
 	if err != nil {
 		shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", err)
 		return
@@ -181,7 +218,7 @@ func (h *Handler) GetMyWalletRecharge(c *gin.Context) {
 		respondPaymentCaptureError(c, err)
 		return
 	}
-	account, err := h.WalletService.GetAccount(uid)
+	account, err := h.WalletService.GetAccountByCurrency(uid, recharge.Currency)
 	if err != nil {
 		shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", err)
 		return
@@ -252,10 +289,219 @@ func (h *Handler) CaptureMyWalletRechargePayment(c *gin.Context) {
 		shared.RespondError(c, response.CodeInternal, "error.payment_fetch_failed", err)
 		return
 	}
-	account, err := h.WalletService.GetAccount(uid)
+	account, err := h.WalletService.GetAccountByCurrency(uid, updatedRecharge.Currency)
 	if err != nil {
 		shared.RespondError(c, response.CodeInternal, "error.user_fetch_failed", err)
 		return
 	}
 	response.Success(c, dto.NewWalletRechargePaymentPayload(updatedRecharge, updatedPayment, account))
 }
+
+// ListWalletRechargeBonusRules 获取当前生效的充值赠送活动
+func (h *Handler) ListWalletRechargeBonusRules(c *gin.Context) {
+	rules, err := h.WalletService.ListActiveRechargeBonusRules(c.Query("currency"))
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.wallet_recharge_bonus_fetch_failed", err)
+		return
+	}
+	response.Success(c, dto.NewWalletRechargeBonusRuleRespList(rules))
+}
+
+// WalletTransferRequest 用户钱包转账请求
+type WalletTransferRequest struct {
+	Recipient    string `json:"recipient" binding:"required"` // 收款人邮箱或用户ID
+	Amount       string `json:"amount" binding:"required"`
+	Currency     string `json:"currency"`
+	Remark       string `json:"remark"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// LookupWalletTransferRecipient 按邮箱或用户ID查询转账收款人
+func (h *Handler) LookupWalletTransferRecipient(c *gin.Context) {
+	uid, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+	recipient, err := h.WalletTransferService.ResolveRecipient(uid, c.Query("keyword"))
+	if err != nil {
+		respondWalletTransferError(c, err)
+		return
+	}
+	response.Success(c, dto.NewWalletTransferRecipientResp(recipient))
+}
+
+// TransferWallet 用户向其他用户转账
+func (h *Handler) TransferWallet(c *gin.Context) {
+	uid, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+	var req WalletTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	amount, err := decimal.NewFromString(strings.TrimSpace(req.Amount))
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.bad_request", err)
+		return
+	}
+	result, err := h.WalletTransferService.Transfer(service.WalletTransferInput{
+		FromUserID:   uid,
+		Recipient:    req.Recipient,
+		Amount:       models.NewMoneyFromDecimal(amount),
+		Currency:     req.Currency,
+		Remark:       req.Remark,
+		TOTPCode:     req.Code,
+		RecoveryCode: req.RecoveryCode,
+		Source:       constants.WalletTransferSourceWeb,
+	})
+	if err != nil {
+		respondWalletTransferError(c, err)
+		return
+	}
+	response.Success(c, gin.H{
+		"transfer": dto.NewWalletTransferResp(result.Transfer, uid),
+		"account":  dto.NewWalletAccountResp(result.Account),
+	})
+}
+
+// ListMyWalletTransfers 获取当前用户转账记录（含转出与转入）
+func (h *Handler) ListMyWalletTransfers(c *gin.Context) {
+	uid, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	page, pageSize = shared.NormalizePagination(page, pageSize)
+
+	transfers, total, err := h.WalletTransferService.ListTransfers(repository.WalletTransferListFilter{
+		Page:     page,
+		PageSize: pageSize,
+		UserID:   uid,
+	})
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.wallet_transfer_failed", err)
+		return
+	}
+	pagination := response.BuildPagination(page, pageSize, total)
+	response.SuccessWithPage(c, dto.NewWalletTransferRespList(transfers, uid), pagination)
+}
+
+func respondWalletTransferError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrWalletTransferDisabled):
+		shared.RespondError(c, response.CodeForbidden, "error.wallet_transfer_disabled", nil)
+	case errors.Is(err, service.ErrWalletTransferRecipientNotFound):
+		shared.RespondError(c, response.CodeNotFound, "error.wallet_transfer_recipient_not_found", nil)
+	case errors.Is(err, service.ErrWalletTransferSelf):
+		shared.RespondError(c, response.CodeBadRequest, "error.wallet_transfer_self", nil)
+	case errors.Is(err, service.ErrWalletTransferAmountInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.wallet_transfer_amount_invalid", nil)
+	case errors.Is(err, service.ErrWalletTransferDailyLimitExceeded):
+		shared.RespondError(c, response.CodeBadRequest, "error.wallet_transfer_daily_limit", nil)
+	case errors.Is(err, service.ErrWalletTransferTOTPRequired):
+		shared.RespondError(c, response.CodeBadRequest, "error.totp_code_required", nil)
+	case errors.Is(err, service.ErrTOTPCodeInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.totp_code_invalid", nil)
+	case errors.Is(err, service.ErrTOTPRecoveryInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.recovery_code_invalid", nil)
+	case errors.Is(err, service.ErrTOTPTooManyAttempts):
+		shared.RespondError(c, response.CodeTooManyRequests, "error.totp_too_many_attempts", nil)
+	case errors.Is(err, service.ErrWalletInsufficientBalance):
+		shared.RespondError(c, response.CodeBadRequest, "error.wallet_insufficient_balance", nil)
+	case errors.Is(err, service.ErrWalletBonusLocked):
+		shared.RespondError(c, response.CodeBadRequest, "error.wallet_bonus_locked", nil)
+	case errors.Is(err, service.ErrWalletCurrencyInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.wallet_currency_invalid", nil)
+	default:
+		shared.RespondError(c, response.CodeInternal, "error.wallet_transfer_failed", err)
+	}
+}
+
+// WalletWithdrawApplyRequest 用户钱包提现申请请求
+type WalletWithdrawApplyRequest struct {
+	Amount      string `json:"amount" binding:"required"`
+	Currency    string `json:"currency"`
+	Channel     string `json:"channel" binding:"required"`
+	Account     string `json:"account" binding:"required"`
+	AccountName string `json:"account_name"`
+}
+
+// ApplyWalletWithdraw 用户申请钱包余额提现
+func (h *Handler) ApplyWalletWithdraw(c *gin.Context) {
+	uid, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+	var req WalletWithdrawApplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		shared.RespondBindError(c, err)
+		return
+	}
+	amount, err := decimal.NewFromString(strings.TrimSpace(req.Amount))
+	if err != nil {
+		shared.RespondError(c, response.CodeBadRequest, "error.wallet_withdraw_amount_invalid", nil)
+		return
+	}
+	row, err := h.WalletService.ApplyWithdraw(uid, service.WalletWithdrawApplyInput{
+		Amount:      amount,
+		Currency:    req.Currency,
+		Channel:     req.Channel,
+		Account:     req.Account,
+		AccountName: req.AccountName,
+	})
+	if err != nil {
+		respondWalletWithdrawError(c, err)
+		return
+	}
+	account, err := h.WalletService.GetAccountByCurrency(uid, row.Currency)
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.wallet_withdraw_failed", err)
+		return
+	}
+	response.Success(c, gin.H{
+		"withdraw": dto.NewWalletWithdrawResp(row),
+		"account":  dto.NewWalletAccountResp(account),
+	})
+}
+
+// ListMyWalletWithdraws 获取当前用户钱包提现记录
+func (h *Handler) ListMyWalletWithdraws(c *gin.Context) {
+	uid, ok := shared.GetUserID(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	page, pageSize = shared.NormalizePagination(page, pageSize)
+
+	rows, total, err := h.WalletService.ListUserWithdraws(uid, page, pageSize, c.Query("status"))
+	if err != nil {
+		shared.RespondError(c, response.CodeInternal, "error.wallet_withdraw_failed", err)
+		return
+	}
+	pagination := response.BuildPagination(page, pageSize, total)
+	response.SuccessWithPage(c, dto.NewWalletWithdrawRespList(rows), pagination)
+}
+
+func respondWalletWithdrawError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrWalletWithdrawDisabled):
+		shared.RespondError(c, response.CodeForbidden, "error.wallet_withdraw_disabled", nil)
+	case errors.Is(err, service.ErrWalletWithdrawAmountInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.wallet_withdraw_amount_invalid", nil)
+	case errors.Is(err, service.ErrWalletWithdrawChannelInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.wallet_withdraw_channel_invalid", nil)
+	case errors.Is(err, service.ErrWalletInsufficientBalance):
+		shared.RespondError(c, response.CodeBadRequest, "error.wallet_insufficient_balance", nil)
+	case errors.Is(err, service.ErrWalletBonusLocked):
+		shared.RespondError(c, response.CodeBadRequest, "error.wallet_bonus_locked", nil)
+	case errors.Is(err, service.ErrWalletCurrencyInvalid):
+		shared.RespondError(c, response.CodeBadRequest, "error.wallet_currency_invalid", nil)
+	default:
+		shared.RespondError(c, response.CodeInternal, "error.wallet_withdraw_failed", err)
+	}
+}
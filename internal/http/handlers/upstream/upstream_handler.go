@@ -41,6 +41,7 @@ func New(c *provider.Container, downstreamRefRepo repository.DownstreamOrderRefR
 const (
 	upstreamUserIDKey       = "upstream_user_id"
 	upstreamCredentialIDKey = "upstream_credential_id"
+	upstreamCredentialKey   = "upstream_credential"
 )
 
 func getUpstreamUserID(c *gin.Context) uint {
@@ -59,6 +60,12 @@ func getUpstreamCredentialID(c *gin.Context) uint {
 	return 0
 }
 
+func getUpstreamCredential(c *gin.Context) *models.ApiCredential {
+	v, _ := c.Get(upstreamCredentialKey)
+	cred, _ := v.(*models.ApiCredential)
+	return cred
+}
+
 // ---- response helpers ----
 
 func successResponse(c *gin.Context, data interface{}) {
@@ -70,20 +77,27 @@ func successResponse(c *gin.Context, data interface{}) {
 }
 
 func errorResponse(c *gin.Context, status int, code, message string) {
-	c.JSON(status, gin.H{
-		"ok":            false,
-		"error_code":    code,
-		"error_message": message,
+	c.JSON(status, upstreamadapter.ErrorResponse{
+		OK:           false,
+		ErrorCode:    code,
+		ErrorMessage: message,
 	})
 }
 
+// ---- OpenAPI ----
+
+// OpenAPISpec GET /api/v1/upstream/openapi.json（协议文档，无需签名）
+func (h *Handler) OpenAPISpec(c *gin.Context) {
+	c.JSON(http.StatusOK, upstreamadapter.OpenAPIDocument())
+}
+
 // ---- Ping ----
 
 // Ping POST /api/v1/upstream/ping
 func (h *Handler) Ping(c *gin.Context) {
 	userID := getUpstreamUserID(c)
 	if userID == 0 {
-		errorResponse(c, http.StatusUnauthorized, "unauthorized", "invalid credentials")
+		errorResponse(c, http.StatusUnauthorized, upstreamadapter.ErrorCodeUnauthorized, "invalid credentials")
 		return
 	}
 
@@ -109,12 +123,12 @@ func (h *Handler) Ping(c *gin.Context) {
 	currency, _ := h.SettingService.GetSiteCurrency("CNY")
 
 	// 用户会员等级
-	var memberLevel gin.H
+	var memberLevel map[string]interface{}
 	user, err := h.UserRepo.GetByID(userID)
 	if err == nil && user != nil && user.MemberLevelID > 0 && h.MemberLevelService != nil {
 		level, levelErr := h.MemberLevelService.GetByID(user.MemberLevelID)
 		if levelErr == nil && level != nil {
-			memberLevel = gin.H{
+			memberLevel = map[string]interface{}{
 				"id":   level.ID,
 				"name": level.NameJSON,
 				"slug": level.Slug,
@@ -123,41 +137,34 @@ func (h *Handler) Ping(c *gin.Context) {
 		}
 	}
 
-	successResponse(c, gin.H{
-		"ok":               true,
-		"site_name":        siteName,
-		"protocol_version": "1.0",
-		"user_id":          userID,
-		"balance":          balanceStr,
-		"currency":         currency,
-		"member_level":     memberLevel,
+	successResponse(c, upstreamadapter.PingResponse{
+		OK: true,
+		PingResult: upstreamadapter.PingResult{
+			SiteName:        siteName,
+			ProtocolVersion: upstreamadapter.ProtocolVersion,
+			Features:        upstreamadapter.ProtocolFeatures(upstreamadapter.ProtocolVersion),
+			UserID:          userID,
+			Balance:         balanceStr,
+			Currency:        currency,
+			MemberLevel:     memberLevel,
+		},
 	})
 }
 
 // ---- ListCategories ----
 
-// upstreamCategory 上游分类响应格式
-type upstreamCategory struct {
-	ID        uint        `json:"id"`
-	ParentID  uint        `json:"parent_id"`
-	Slug      string      `json:"slug"`
-	Name      models.JSON `json:"name"`
-	Icon      string      `json:"icon"`
-	SortOrder int         `json:"sort_order"`
-}
-
 // ListCategories GET /api/v1/upstream/categories
 func (h *Handler) ListCategories(c *gin.Context) {
 	categories, err := h.CategoryRepo.List()
 	if err != nil {
 		logger.Errorw("upstream_list_categories_failed", "error", err)
-		errorResponse(c, http.StatusInternalServerError, "internal_error", "failed to list categories")
+		errorResponse(c, http.StatusInternalServerError, upstreamadapter.ErrorCodeInternal, "failed to list categories")
 		return
 	}
 
-	items := make([]upstreamCategory, 0, len(categories))
+	items := make([]upstreamadapter.UpstreamCategory, 0, len(categories))
 	for _, cat := range categories {
-		items = append(items, upstreamCategory{
+		items = append(items, upstreamadapter.UpstreamCategory{
 			ID:        cat.ID,
 			ParentID:  cat.ParentID,
 			Slug:      cat.Slug,
@@ -167,48 +174,14 @@ func (h *Handler) ListCategories(c *gin.Context) {
 		})
 	}
 
-	successResponse(c, gin.H{
-		"ok":         true,
-		"categories": items,
+	successResponse(c, upstreamadapter.CategoryListResponse{
+		OK:         true,
+		Categories: items,
 	})
 }
 
 // ---- ListProducts ----
 
-// upstreamProduct 上游商品响应格式
-type upstreamProduct struct {
-	ID               uint               `json:"id"`
-	Slug             string             `json:"slug"`
-	SeoMeta          models.JSON        `json:"seo_meta"`
-	Title            models.JSON        `json:"title"`
-	Description      models.JSON        `json:"description"`
-	Content          models.JSON        `json:"content"`
-	Images           models.StringArray `json:"images"`
-	Tags             models.StringArray `json:"tags"`
-	PriceAmount      string             `json:"price_amount"`
-	OriginalPrice    string             `json:"original_price,omitempty"`
-	MemberPrice      string             `json:"member_price,omitempty"`
-	FulfillmentType  string             `json:"fulfillment_type"`
-	ManualFormSchema models.JSON        `json:"manual_form_schema"`
-	IsActive         bool               `json:"is_active"`
-	CategoryID       uint               `json:"category_id"`
-	SKUs             []upstreamSKU      `json:"skus"`
-	CreatedAt        time.Time          `json:"created_at"`
-	UpdatedAt        time.Time          `json:"updated_at"`
-}
-
-type upstreamSKU struct {
-	ID            uint        `json:"id"`
-	SKUCode       string      `json:"sku_code"`
-	SpecValues    models.JSON `json:"spec_values"`
-	PriceAmount   string      `json:"price_amount"`
-	OriginalPrice string      `json:"original_price,omitempty"`
-	MemberPrice   string      `json:"member_price,omitempty"`
-	StockStatus   string      `json:"stock_status"`
-	StockQuantity int         `json:"stock_quantity"`
-	IsActive      bool        `json:"is_active"`
-}
-
 // ListProducts GET /api/v1/upstream/products
 func (h *Handler) ListProducts(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -235,7 +208,7 @@ func (h *Handler) ListProducts(c *gin.Context) {
 	}
 	if err != nil {
 		logger.Errorw("upstream_list_products_failed", "error", err)
-		errorResponse(c, http.StatusInternalServerError, "internal_error", "failed to list products")
+		errorResponse(c, http.StatusInternalServerError, upstreamadapter.ErrorCodeInternal, "failed to list products")
 		return
 	}
 
@@ -247,30 +220,23 @@ func (h *Handler) ListProducts(c *gin.Context) {
 	// 补充上游对接商品的 SKU 库存
 	h.applyUpstreamStockToProducts(products)
 
-	// 获取下游用户的会员等级
-	userID := getUpstreamUserID(c)
-	var memberLevelID uint
-	if userID > 0 {
-		user, err := h.UserRepo.GetByID(userID)
-		if err == nil && user != nil {
-			memberLevelID = user.MemberLevelID
-		}
-	}
+	// 获取下游用户的会员等级与分销价目表
+	memberLevelID, resellerPricing := h.resolveUpstreamPricing(c)
 
 	// 批量解析映射商品的真实交付类型
 	fulfillmentTypeMap := h.resolveEffectiveFulfillmentTypes(products)
 
-	items := make([]upstreamProduct, 0, len(products))
+	items := make([]upstreamadapter.UpstreamProduct, 0, len(products))
 	for _, p := range products {
-		items = append(items, h.toUpstreamProductWithMemberPrice(p, memberLevelID, fulfillmentTypeMap))
+		items = append(items, h.toUpstreamProductWithMemberPrice(p, memberLevelID, resellerPricing, fulfillmentTypeMap))
 	}
 
-	successResponse(c, gin.H{
-		"ok":        true,
-		"items":     items,
-		"total":     total,
-		"page":      page,
-		"page_size": pageSize,
+	successResponse(c, upstreamadapter.ProductListResponse{
+		OK:       true,
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
 	})
 }
 
@@ -278,23 +244,23 @@ func (h *Handler) ListProducts(c *gin.Context) {
 func (h *Handler) GetProduct(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		errorResponse(c, http.StatusBadRequest, "bad_request", "product id is required")
+		errorResponse(c, http.StatusBadRequest, upstreamadapter.ErrorCodeBadRequest, "product id is required")
 		return
 	}
 
 	product, err := h.ProductService.GetAdminByID(id)
 	if err != nil {
 		if errors.Is(err, service.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "product_not_found", "product not found")
+			errorResponse(c, http.StatusNotFound, upstreamadapter.ErrorCodeProductNotFound, "product not found")
 			return
 		}
 		logger.Errorw("upstream_get_product_failed", "id", id, "error", err)
-		errorResponse(c, http.StatusInternalServerError, "internal_error", "failed to get product")
+		errorResponse(c, http.StatusInternalServerError, upstreamadapter.ErrorCodeInternal, "failed to get product")
 		return
 	}
 
 	if !product.IsActive {
-		errorResponse(c, http.StatusNotFound, "product_unavailable", "product is not active")
+		errorResponse(c, http.StatusNotFound, upstreamadapter.ErrorCodeProductUnavailable, "product is not active")
 		return
 	}
 
@@ -307,22 +273,15 @@ func (h *Handler) GetProduct(c *gin.Context) {
 	// 补充上游对接商品的 SKU 库存
 	h.applyUpstreamStockToProducts(products)
 
-	// 获取下游用户的会员等级
-	userID := getUpstreamUserID(c)
-	var memberLevelID uint
-	if userID > 0 {
-		user, err := h.UserRepo.GetByID(userID)
-		if err == nil && user != nil {
-			memberLevelID = user.MemberLevelID
-		}
-	}
+	// 获取下游用户的会员等级与分销价目表
+	memberLevelID, resellerPricing := h.resolveUpstreamPricing(c)
 
 	// 解析映射商品的真实交付类型
 	fulfillmentTypeMap := h.resolveEffectiveFulfillmentTypes(products)
 
-	successResponse(c, gin.H{
-		"ok":      true,
-		"product": h.toUpstreamProductWithMemberPrice(products[0], memberLevelID, fulfillmentTypeMap),
+	successResponse(c, upstreamadapter.ProductResponse{
+		OK:      true,
+		Product: h.toUpstreamProductWithMemberPrice(products[0], memberLevelID, resellerPricing, fulfillmentTypeMap),
 	})
 }
 
@@ -342,20 +301,20 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 	userID := getUpstreamUserID(c)
 	credentialID := getUpstreamCredentialID(c)
 	if userID == 0 || credentialID == 0 {
-		errorResponse(c, http.StatusUnauthorized, "unauthorized", "invalid credentials")
+		errorResponse(c, http.StatusUnauthorized, upstreamadapter.ErrorCodeUnauthorized, "invalid credentials")
 		return
 	}
 
 	var req createOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "bad_request", "invalid request body: "+err.Error())
+		errorResponse(c, http.StatusBadRequest, upstreamadapter.ErrorCodeBadRequest, "invalid request body: "+err.Error())
 		return
 	}
 
 	// 验证 callback URL（防止 SSRF）
 	if req.CallbackURL != "" {
 		if err := validateCallbackURL(req.CallbackURL); err != nil {
-			errorResponse(c, http.StatusBadRequest, "invalid_callback_url", err.Error())
+			errorResponse(c, http.StatusBadRequest, upstreamadapter.ErrorCodeInvalidCallbackURL, err.Error())
 			return
 		}
 	}
@@ -368,13 +327,13 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 			existingOrder, orderErr := h.OrderService.GetOrderByUser(existingRef.OrderID, userID)
 			if orderErr == nil && existingOrder != nil {
 				currency, _ := h.SettingService.GetSiteCurrency("CNY")
-				successResponse(c, gin.H{
-					"ok":       true,
-					"order_id": existingOrder.ID,
-					"order_no": existingOrder.OrderNo,
-					"status":   existingOrder.Status,
-					"amount":   existingOrder.TotalAmount.StringFixed(2),
-					"currency": currency,
+				successResponse(c, upstreamadapter.CreateUpstreamOrderResp{
+					OK:       true,
+					OrderID:  existingOrder.ID,
+					OrderNo:  existingOrder.OrderNo,
+					Status:   existingOrder.Status,
+					Amount:   existingOrder.TotalAmount.StringFixed(2),
+					Currency: currency,
 				})
 				return
 			}
@@ -384,24 +343,25 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 	// 查找 SKU 获取所属商品 ID
 	sku, err := h.ProductSKURepo.GetByID(req.SKUID)
 	if err != nil || sku == nil {
-		errorResponse(c, http.StatusBadRequest, "sku_unavailable", "sku not found")
+		errorResponse(c, http.StatusBadRequest, upstreamadapter.ErrorCodeSKUUnavailable, "sku not found")
 		return
 	}
 	if !sku.IsActive {
-		errorResponse(c, http.StatusBadRequest, "sku_unavailable", "sku is not active")
+		errorResponse(c, http.StatusBadRequest, upstreamadapter.ErrorCodeSKUUnavailable, "sku is not active")
 		return
 	}
 
 	// 验证商品是否上架
 	product, err := h.ProductRepo.GetByID(fmt.Sprintf("%d", sku.ProductID))
 	if err != nil || product == nil || !product.IsActive {
-		errorResponse(c, http.StatusBadRequest, "product_unavailable", "product is not available")
+		errorResponse(c, http.StatusBadRequest, upstreamadapter.ErrorCodeProductUnavailable, "product is not available")
 		return
 	}
 
 	// 构建手动表单数据
 	var manualFormData map[string]models.JSON
-	if req.ManualFormData != nil && product.FulfillmentType == constants.FulfillmentTypeManual {
+	if req.ManualFormData != nil &&
+		(product.FulfillmentType == constants.FulfillmentTypeManual || product.FulfillmentType == constants.FulfillmentTypeWebhook) {
 		manualFormData = map[string]models.JSON{
 			fmt.Sprintf("%d", sku.ProductID): req.ManualFormData,
 		}
@@ -422,9 +382,34 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 		ManualFormData:  manualFormData,
 		SkipRiskControl: true,
 	}
+	// 按分销价目表定价，钱包扣款金额即订单实付金额
+	if h.ResellerPriceListService != nil {
+		input.PriceListID = h.ResellerPriceListService.ResolveListID(credentialID, userID)
+	}
+
+	// 按定价预览金额，在下单前原子预占密钥当日下单配额
+	cred := getUpstreamCredential(c)
+	preview, err := h.OrderService.PreviewOrder(input)
+	if err != nil {
+		mapOrderErrorToResponse(c, err)
+		return
+	}
+	reservedAmount := preview.TotalAmount.Decimal
+	quotaDate, quotaErr := h.ApiCredentialService.ReserveOrderQuota(cred, reservedAmount)
+	if quotaErr != nil {
+		if errors.Is(quotaErr, service.ErrApiCredentialOrderQuota) || errors.Is(quotaErr, service.ErrApiCredentialAmountQuota) {
+			h.ApiCredentialService.RecordRejected(cred)
+			errorResponse(c, http.StatusTooManyRequests, upstreamadapter.ErrorCodeQuotaExceeded, quotaErr.Error())
+			return
+		}
+		logger.Errorw("upstream_order_quota_reserve_failed", "credential_id", credentialID, "error", quotaErr)
+		errorResponse(c, http.StatusInternalServerError, upstreamadapter.ErrorCodeInternal, "failed to create order")
+		return
+	}
 
 	order, err := h.OrderService.CreateOrder(input)
 	if err != nil {
+		h.ApiCredentialService.ReleaseOrderQuota(cred, quotaDate, reservedAmount)
 		mapOrderErrorToResponse(c, err)
 		return
 	}
@@ -466,18 +451,19 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 			"order_id", order.ID,
 			"error", payErr,
 		)
-		// 支付失败，自动取消订单避免遗留未支付订单
+		// 支付失败，自动取消订单避免遗留未支付订单，并归还预占的配额
+		h.ApiCredentialService.ReleaseOrderQuota(cred, quotaDate, reservedAmount)
 		if _, cancelErr := h.OrderService.CancelOrder(order.ID, userID); cancelErr != nil {
 			logger.Warnw("upstream_cancel_unpaid_order_failed", "order_id", order.ID, "error", cancelErr)
 		}
 		// 钱包余额不足或支付失败，返回 200 + ok:false 让 A 站正确解析错误码
-		c.JSON(http.StatusOK, gin.H{
-			"ok":            false,
-			"order_id":      order.ID,
-			"order_no":      order.OrderNo,
-			"status":        constants.OrderStatusCanceled,
-			"error_code":    "payment_failed",
-			"error_message": fmt.Sprintf("wallet payment failed: %s", payErr.Error()),
+		c.JSON(http.StatusOK, upstreamadapter.CreateUpstreamOrderResp{
+			OK:           false,
+			OrderID:      order.ID,
+			OrderNo:      order.OrderNo,
+			Status:       constants.OrderStatusCanceled,
+			ErrorCode:    upstreamadapter.ErrorCodePaymentFailed,
+			ErrorMessage: fmt.Sprintf("wallet payment failed: %s", payErr.Error()),
 		})
 		return
 	}
@@ -486,18 +472,20 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 	finalStatus := order.Status
 	if payResult != nil && payResult.OrderPaid {
 		finalStatus = constants.OrderStatusPaid
+	} else {
+		h.ApiCredentialService.ReleaseOrderQuota(cred, quotaDate, reservedAmount)
 	}
 
 	// 币种
 	currency, _ := h.SettingService.GetSiteCurrency("CNY")
 
-	successResponse(c, gin.H{
-		"ok":       true,
-		"order_id": order.ID,
-		"order_no": order.OrderNo,
-		"status":   finalStatus,
-		"amount":   order.TotalAmount.StringFixed(2),
-		"currency": currency,
+	successResponse(c, upstreamadapter.CreateUpstreamOrderResp{
+		OK:       true,
+		OrderID:  order.ID,
+		OrderNo:  order.OrderNo,
+		Status:   finalStatus,
+		Amount:   order.TotalAmount.StringFixed(2),
+		Currency: currency,
 	})
 }
 
@@ -507,24 +495,24 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 func (h *Handler) GetOrder(c *gin.Context) {
 	userID := getUpstreamUserID(c)
 	if userID == 0 {
-		errorResponse(c, http.StatusUnauthorized, "unauthorized", "invalid credentials")
+		errorResponse(c, http.StatusUnauthorized, upstreamadapter.ErrorCodeUnauthorized, "invalid credentials")
 		return
 	}
 
 	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		errorResponse(c, http.StatusBadRequest, "bad_request", "invalid order id")
+		errorResponse(c, http.StatusBadRequest, upstreamadapter.ErrorCodeBadRequest, "invalid order id")
 		return
 	}
 
 	order, err := h.OrderService.GetOrderByUser(uint(orderID), userID)
 	if err != nil {
 		if errors.Is(err, service.ErrOrderNotFound) {
-			errorResponse(c, http.StatusNotFound, "order_not_found", "order not found")
+			errorResponse(c, http.StatusNotFound, upstreamadapter.ErrorCodeOrderNotFound, "order not found")
 			return
 		}
 		logger.Errorw("upstream_get_order_failed", "order_id", orderID, "error", err)
-		errorResponse(c, http.StatusInternalServerError, "internal_error", "failed to get order")
+		errorResponse(c, http.StatusInternalServerError, upstreamadapter.ErrorCodeInternal, "failed to get order")
 		return
 	}
 
@@ -549,15 +537,17 @@ func (h *Handler) GetOrder(c *gin.Context) {
 		localRefundRecords = records
 	}
 
-	resp := gin.H{
-		"ok":              true,
-		"order_id":        order.ID,
-		"order_no":        order.OrderNo,
-		"status":          status,
-		"amount":          order.TotalAmount.StringFixed(2),
-		"refunded_amount": order.RefundedAmount.StringFixed(2),
-		"currency":        order.Currency,
-		"refund_records":  localRefundRecords,
+	resp := upstreamadapter.OrderDetailResponse{
+		OK: true,
+		UpstreamOrderDetail: upstreamadapter.UpstreamOrderDetail{
+			OrderID:        order.ID,
+			OrderNo:        order.OrderNo,
+			Status:         status,
+			Amount:         order.TotalAmount.StringFixed(2),
+			RefundedAmount: order.RefundedAmount.StringFixed(2),
+			Currency:       order.Currency,
+			RefundRecords:  localRefundRecords,
+		},
 	}
 
 	// 若已交付，返回交付信息（优先使用订单自身的 fulfillment，否则从子订单获取）
@@ -571,30 +561,30 @@ func (h *Handler) GetOrder(c *gin.Context) {
 		}
 	}
 	if sourceFulfillment != nil && sourceFulfillment.Status == constants.FulfillmentStatusDelivered {
-		resp["fulfillment"] = gin.H{
-			"type":          sourceFulfillment.Type,
-			"status":        sourceFulfillment.Status,
-			"payload":       sourceFulfillment.Payload,
-			"delivery_data": sourceFulfillment.LogisticsJSON,
-			"delivered_at":  sourceFulfillment.DeliveredAt,
+		resp.Fulfillment = &upstreamadapter.UpstreamFulfillment{
+			Type:         sourceFulfillment.Type,
+			Status:       sourceFulfillment.Status,
+			Payload:      sourceFulfillment.Payload,
+			DeliveryData: sourceFulfillment.LogisticsJSON,
+			DeliveredAt:  sourceFulfillment.DeliveredAt,
 		}
 	}
 
 	// 订单项信息
 	if len(order.Items) > 0 {
-		items := make([]gin.H, 0, len(order.Items))
+		items := make([]upstreamadapter.UpstreamOrderItem, 0, len(order.Items))
 		for _, item := range order.Items {
-			items = append(items, gin.H{
-				"product_id":       item.ProductID,
-				"sku_id":           item.SKUID,
-				"title":            item.TitleJSON,
-				"quantity":         item.Quantity,
-				"unit_price":       item.UnitPrice.StringFixed(2),
-				"total_price":      item.TotalPrice.StringFixed(2),
-				"fulfillment_type": item.FulfillmentType,
+			items = append(items, upstreamadapter.UpstreamOrderItem{
+				ProductID:       item.ProductID,
+				SKUID:           item.SKUID,
+				Title:           item.TitleJSON,
+				Quantity:        item.Quantity,
+				UnitPrice:       item.UnitPrice.StringFixed(2),
+				TotalPrice:      item.TotalPrice.StringFixed(2),
+				FulfillmentType: item.FulfillmentType,
 			})
 		}
-		resp["items"] = items
+		resp.Items = items
 	}
 
 	successResponse(c, resp)
@@ -606,39 +596,110 @@ func (h *Handler) GetOrder(c *gin.Context) {
 func (h *Handler) CancelOrder(c *gin.Context) {
 	userID := getUpstreamUserID(c)
 	if userID == 0 {
-		errorResponse(c, http.StatusUnauthorized, "unauthorized", "invalid credentials")
+		errorResponse(c, http.StatusUnauthorized, upstreamadapter.ErrorCodeUnauthorized, "invalid credentials")
 		return
 	}
 
 	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		errorResponse(c, http.StatusBadRequest, "bad_request", "invalid order id")
+		errorResponse(c, http.StatusBadRequest, upstreamadapter.ErrorCodeBadRequest, "invalid order id")
 		return
 	}
 
 	order, err := h.OrderService.CancelOrder(uint(orderID), userID)
 	if err != nil {
 		if errors.Is(err, service.ErrOrderNotFound) {
-			errorResponse(c, http.StatusNotFound, "order_not_found", "order not found")
+			errorResponse(c, http.StatusNotFound, upstreamadapter.ErrorCodeOrderNotFound, "order not found")
 			return
 		}
 		if errors.Is(err, service.ErrOrderCancelNotAllowed) {
-			errorResponse(c, http.StatusConflict, "cancel_not_allowed", "order cannot be canceled in current status")
+			errorResponse(c, http.StatusConflict, upstreamadapter.ErrorCodeCancelNotAllowed, "order cannot be canceled in current status")
 			return
 		}
 		logger.Errorw("upstream_cancel_order_failed", "order_id", orderID, "error", err)
-		errorResponse(c, http.StatusInternalServerError, "internal_error", "failed to cancel order")
+		errorResponse(c, http.StatusInternalServerError, upstreamadapter.ErrorCodeInternal, "failed to cancel order")
 		return
 	}
 
-	successResponse(c, gin.H{
-		"ok":       true,
-		"order_id": order.ID,
-		"order_no": order.OrderNo,
-		"status":   order.Status,
+	successResponse(c, upstreamadapter.CancelOrderResponse{
+		OK:      true,
+		OrderID: order.ID,
+		OrderNo: order.OrderNo,
+		Status:  order.Status,
 	})
 }
 
+// ---- Catalog Webhook ----
+
+// RegisterCatalogWebhook POST /api/v1/upstream/webhooks (注册商品目录变更推送地址)
+func (h *Handler) RegisterCatalogWebhook(c *gin.Context) {
+	credentialID := getUpstreamCredentialID(c)
+	userID := getUpstreamUserID(c)
+	if credentialID == 0 || userID == 0 {
+		errorResponse(c, http.StatusUnauthorized, upstreamadapter.ErrorCodeUnauthorized, "invalid credentials")
+		return
+	}
+
+	var req upstreamadapter.CatalogWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.URL) == "" {
+		errorResponse(c, http.StatusBadRequest, upstreamadapter.ErrorCodeBadRequest, "invalid request body")
+		return
+	}
+	if err := validateCallbackURL(req.URL); err != nil {
+		errorResponse(c, http.StatusBadRequest, upstreamadapter.ErrorCodeInvalidCallbackURL, "invalid webhook url: "+err.Error())
+		return
+	}
+
+	webhook, err := h.CatalogWebhookService.Register(credentialID, userID, req.URL)
+	if err != nil {
+		logger.Errorw("upstream_register_catalog_webhook_failed", "credential_id", credentialID, "error", err)
+		errorResponse(c, http.StatusInternalServerError, upstreamadapter.ErrorCodeInternal, "failed to register webhook")
+		return
+	}
+	successResponse(c, upstreamadapter.CatalogWebhookResponse{OK: true, Webhook: *webhook})
+}
+
+// GetCatalogWebhook GET /api/v1/upstream/webhooks
+func (h *Handler) GetCatalogWebhook(c *gin.Context) {
+	credentialID := getUpstreamCredentialID(c)
+	if credentialID == 0 {
+		errorResponse(c, http.StatusUnauthorized, upstreamadapter.ErrorCodeUnauthorized, "invalid credentials")
+		return
+	}
+
+	webhook, err := h.CatalogWebhookService.Get(credentialID)
+	if err != nil {
+		if errors.Is(err, service.ErrCatalogWebhookNotFound) {
+			errorResponse(c, http.StatusNotFound, upstreamadapter.ErrorCodeWebhookNotFound, "webhook not registered")
+			return
+		}
+		logger.Errorw("upstream_get_catalog_webhook_failed", "credential_id", credentialID, "error", err)
+		errorResponse(c, http.StatusInternalServerError, upstreamadapter.ErrorCodeInternal, "failed to get webhook")
+		return
+	}
+	successResponse(c, upstreamadapter.CatalogWebhookResponse{OK: true, Webhook: *webhook})
+}
+
+// DeleteCatalogWebhook DELETE /api/v1/upstream/webhooks
+func (h *Handler) DeleteCatalogWebhook(c *gin.Context) {
+	credentialID := getUpstreamCredentialID(c)
+	if credentialID == 0 {
+		errorResponse(c, http.StatusUnauthorized, upstreamadapter.ErrorCodeUnauthorized, "invalid credentials")
+		return
+	}
+
+	if err := h.CatalogWebhookService.Unregister(credentialID); err != nil {
+		if errors.Is(err, service.ErrCatalogWebhookNotFound) {
+			errorResponse(c, http.StatusNotFound, upstreamadapter.ErrorCodeWebhookNotFound, "webhook not registered")
+			return
+		}
+		logger.Errorw("upstream_delete_catalog_webhook_failed", "credential_id", credentialID, "error", err)
+		errorResponse(c, http.StatusInternalServerError, upstreamadapter.ErrorCodeInternal, "failed to delete webhook")
+		return
+	}
+	successResponse(c, nil)
+}
+
 // ---- HandleCallback (A 站接收 B 站回调) ----
 
 type callbackPayload struct {
@@ -654,7 +715,8 @@ type callbackPayload struct {
 		DeliveryData models.JSON `json:"delivery_data"`
 		DeliveredAt  *time.Time  `json:"delivered_at"`
 	} `json:"fulfillment,omitempty"`
-	Timestamp int64 `json:"timestamp"`
+	Product   *upstreamadapter.UpstreamProduct `json:"product,omitempty"` // 目录变更推送的商品快照
+	Timestamp int64                            `json:"timestamp"`
 }
 
 // HandleCallback POST /api/v1/upstream/callback (A 站点接收 B 站回调)
@@ -724,6 +786,25 @@ func (h *Handler) HandleCallback(c *gin.Context) {
 		return
 	}
 
+	// 目录变更推送：立即应用商品快照，定时同步仍作兜底
+	if payload.Event == constants.CatalogEventProductChanged {
+		if payload.Product == nil || h.ProductMappingService == nil {
+			c.JSON(http.StatusOK, gin.H{"ok": false, "message": "missing required fields"})
+			return
+		}
+		if err := h.ProductMappingService.ApplyCatalogEvent(conn, payload.Product); err != nil {
+			logger.Errorw("upstream_callback_apply_catalog_event_failed",
+				"connection_id", conn.ID,
+				"upstream_product_id", payload.Product.ID,
+				"error", err,
+			)
+			c.JSON(http.StatusOK, gin.H{"ok": false, "message": "internal error"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"ok": true, "message": "received"})
+		return
+	}
+
 	if payload.DownstreamOrderNo == "" || payload.Status == "" {
 		c.JSON(http.StatusOK, gin.H{"ok": false, "message": "missing required fields"})
 		return
@@ -802,7 +883,7 @@ func mapCallbackStatus(status string) string {
 // ---- helpers ----
 
 // applyUpstreamStockToProducts 为 upstream 类型商品的 SKU 填充上游库存数据
-// 从 SKU 映射中读取 UpstreamStock，写入 ProductSKU 的虚拟字段，供 computeSKUStock 使用
+// 从 SKU 映射中读取 UpstreamStock，写入 ProductSKU 的虚拟字段，供 service.ComputeUpstreamSKUStock 使用
 func (h *Handler) applyUpstreamStockToProducts(products []models.Product) {
 	for i := range products {
 		p := &products[i]
@@ -849,14 +930,36 @@ func (h *Handler) resolveEffectiveFulfillmentTypes(products []models.Product) ma
 	return result
 }
 
-func (h *Handler) toUpstreamProductWithMemberPrice(p models.Product, memberLevelID uint, fulfillmentTypeMap map[uint]string) upstreamProduct {
-	skus := make([]upstreamSKU, 0, len(p.SKUs))
+// resolveUpstreamPricing 解析调用方的会员等级与分销价目表（凭证分配的价目表优先于用户分配的）
+func (h *Handler) resolveUpstreamPricing(c *gin.Context) (uint, *service.ResellerPricing) {
+	userID := getUpstreamUserID(c)
+	var memberLevelID uint
+	if userID > 0 {
+		user, err := h.UserRepo.GetByID(userID)
+		if err == nil && user != nil {
+			memberLevelID = user.MemberLevelID
+		}
+	}
+	if h.ResellerPriceListService == nil {
+		return memberLevelID, nil
+	}
+	listID := h.ResellerPriceListService.ResolveListID(getUpstreamCredentialID(c), userID)
+	pricing, err := h.ResellerPriceListService.LoadPricing(listID)
+	if err != nil {
+		logger.Warnw("upstream_load_reseller_pricing_failed", "price_list_id", listID, "error", err)
+		return memberLevelID, nil
+	}
+	return memberLevelID, pricing
+}
+
+func (h *Handler) toUpstreamProductWithMemberPrice(p models.Product, memberLevelID uint, resellerPricing *service.ResellerPricing, fulfillmentTypeMap map[uint]string) upstreamadapter.UpstreamProduct {
+	skus := make([]upstreamadapter.UpstreamSKU, 0, len(p.SKUs))
 	for _, s := range p.SKUs {
 		if !s.IsActive {
 			continue
 		}
-		stockStatus, stockQuantity := computeSKUStock(p, s)
-		si := upstreamSKU{
+		stockStatus, stockQuantity := service.ComputeUpstreamSKUStock(p, s)
+		si := upstreamadapter.UpstreamSKU{
 			ID:            s.ID,
 			SKUCode:       s.SKUCode,
 			SpecValues:    s.SpecValuesJSON,
@@ -865,7 +968,11 @@ func (h *Handler) toUpstreamProductWithMemberPrice(p models.Product, memberLevel
 			StockQuantity: stockQuantity,
 			IsActive:      s.IsActive,
 		}
-		if memberLevelID > 0 && h.MemberLevelService != nil {
+		if rp, ok := resellerPricing.Resolve(p.ID, s.ID, s.PriceAmount.Decimal); ok {
+			// 分销价代替会员价，与下单定价一致
+			si.OriginalPrice = si.PriceAmount
+			si.PriceAmount = models.NewMoneyFromDecimal(rp).StringFixed(2)
+		} else if memberLevelID > 0 && h.MemberLevelService != nil {
 			mp, _ := h.MemberLevelService.ResolveMemberPrice(memberLevelID, p.ID, s.ID, s.PriceAmount.Decimal)
 			if mp.LessThan(s.PriceAmount.Decimal) {
 				si.OriginalPrice = si.PriceAmount
@@ -881,8 +988,10 @@ func (h *Handler) toUpstreamProductWithMemberPrice(p models.Product, memberLevel
 	if ft, ok := fulfillmentTypeMap[p.ID]; ok {
 		effectiveFulfillmentType = ft
 	}
+	effectiveFulfillmentType = service.UpstreamVisibleFulfillmentType(effectiveFulfillmentType)
 
-	result := upstreamProduct{
+	createdAt := p.CreatedAt
+	result := upstreamadapter.UpstreamProduct{
 		ID:               p.ID,
 		Slug:             p.Slug,
 		SeoMeta:          p.SeoMetaJSON,
@@ -897,11 +1006,14 @@ func (h *Handler) toUpstreamProductWithMemberPrice(p models.Product, memberLevel
 		IsActive:         p.IsActive,
 		CategoryID:       p.CategoryID,
 		SKUs:             skus,
-		CreatedAt:        p.CreatedAt,
+		CreatedAt:        &createdAt,
 		UpdatedAt:        p.UpdatedAt,
 	}
 
-	if memberLevelID > 0 && h.MemberLevelService != nil {
+	if rp, ok := resellerPricing.Resolve(p.ID, 0, p.PriceAmount.Decimal); ok {
+		result.OriginalPrice = result.PriceAmount
+		result.PriceAmount = models.NewMoneyFromDecimal(rp).StringFixed(2)
+	} else if memberLevelID > 0 && h.MemberLevelService != nil {
 		mp, _ := h.MemberLevelService.ResolveMemberPrice(memberLevelID, p.ID, 0, p.PriceAmount.Decimal)
 		if mp.LessThan(p.PriceAmount.Decimal) {
 			result.OriginalPrice = result.PriceAmount
@@ -913,50 +1025,6 @@ func (h *Handler) toUpstreamProductWithMemberPrice(p models.Product, memberLevel
 	return result
 }
 
-// computeSKUStock 计算 SKU 的库存状态和实际可用量
-func computeSKUStock(p models.Product, s models.ProductSKU) (status string, quantity int) {
-	if p.FulfillmentType == constants.FulfillmentTypeManual {
-		// 手动交付：根据 SKU 级别手动库存判断
-		skuTotal := s.ManualStockTotal
-		if skuTotal == constants.ManualStockUnlimited {
-			return constants.ProductStockStatusUnlimited, -1
-		}
-		available := skuTotal - s.ManualStockLocked
-		if available <= 0 {
-			return constants.ProductStockStatusOutOfStock, 0
-		}
-		if available <= 20 {
-			return constants.ProductStockStatusLowStock, available
-		}
-		return constants.ProductStockStatusInStock, available
-	}
-
-	if p.FulfillmentType == constants.FulfillmentTypeUpstream {
-		// 上游对接商品：使用 SKU 映射中的上游库存（通过虚拟字段 UpstreamStock 传入）
-		available := s.UpstreamStock
-		if available < 0 {
-			return constants.ProductStockStatusUnlimited, -1
-		}
-		if available == 0 {
-			return constants.ProductStockStatusOutOfStock, 0
-		}
-		if available <= 20 {
-			return constants.ProductStockStatusLowStock, available
-		}
-		return constants.ProductStockStatusInStock, available
-	}
-
-	// 自动发货：根据卡密库存判断
-	available := int(s.AutoStockAvailable)
-	if available <= 0 {
-		return constants.ProductStockStatusOutOfStock, 0
-	}
-	if available <= 20 {
-		return constants.ProductStockStatusLowStock, available
-	}
-	return constants.ProductStockStatusInStock, available
-}
-
 // mapOrderErrorToResponse 将订单创建错误映射为上游 API 错误响应
 // validateCallbackURL 验证回调 URL 的安全性（防止 SSRF）
 func validateCallbackURL(rawURL string) error {
@@ -989,25 +1057,25 @@ func validateCallbackURL(rawURL string) error {
 func mapOrderErrorToResponse(c *gin.Context, err error) {
 	switch {
 	case errors.Is(err, service.ErrWalletInsufficientBalance):
-		errorResponse(c, http.StatusPaymentRequired, "insufficient_balance", "wallet balance is insufficient")
+		errorResponse(c, http.StatusPaymentRequired, upstreamadapter.ErrorCodeInsufficientFunds, "wallet balance is insufficient")
 	case errors.Is(err, service.ErrCardSecretInsufficient),
 		errors.Is(err, service.ErrManualStockInsufficient):
-		errorResponse(c, http.StatusConflict, "insufficient_stock", "product stock is insufficient")
+		errorResponse(c, http.StatusConflict, upstreamadapter.ErrorCodeInsufficientStock, "product stock is insufficient")
 	case errors.Is(err, service.ErrProductNotAvailable),
 		errors.Is(err, service.ErrProductNotFound):
-		errorResponse(c, http.StatusBadRequest, "product_unavailable", "product is not available")
+		errorResponse(c, http.StatusBadRequest, upstreamadapter.ErrorCodeProductUnavailable, "product is not available")
 	case errors.Is(err, service.ErrProductSKUInvalid),
 		errors.Is(err, service.ErrProductSKURequired):
-		errorResponse(c, http.StatusBadRequest, "sku_unavailable", "sku is invalid or not available")
+		errorResponse(c, http.StatusBadRequest, upstreamadapter.ErrorCodeSKUUnavailable, "sku is invalid or not available")
 	case errors.Is(err, service.ErrInvalidOrderItem):
-		errorResponse(c, http.StatusBadRequest, "bad_request", "invalid order parameters")
+		errorResponse(c, http.StatusBadRequest, upstreamadapter.ErrorCodeBadRequest, "invalid order parameters")
 	case errors.Is(err, service.ErrManualFormRequiredMissing),
 		errors.Is(err, service.ErrManualFormFieldInvalid),
 		errors.Is(err, service.ErrManualFormTypeInvalid),
 		errors.Is(err, service.ErrManualFormOptionInvalid):
-		errorResponse(c, http.StatusBadRequest, "bad_request", "manual form data is invalid: "+err.Error())
+		errorResponse(c, http.StatusBadRequest, upstreamadapter.ErrorCodeBadRequest, "manual form data is invalid: "+err.Error())
 	default:
 		logger.Errorw("upstream_create_order_failed", "error", err)
-		errorResponse(c, http.StatusInternalServerError, "internal_error", "failed to create order")
+		errorResponse(c, http.StatusInternalServerError, upstreamadapter.ErrorCodeInternal, "failed to create order")
 	}
 }
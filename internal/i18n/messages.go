@@ -183,6 +183,49 @@ var messages = map[string]map[string]string{
 		"error.fulfillment_invalid":                      "交付信息不合法",
 		"error.fulfillment_exists":                       "交付记录已存在",
 		"error.fulfillment_create_failed":                "创建交付失败",
+		"error.delivery_link_invalid":                    "交付链接无效",
+		"error.delivery_link_not_found":                  "交付链接不存在",
+		"error.delivery_link_exhausted":                  "交付链接查看次数已用完，请联系客服",
+		"error.delivery_link_fetch_failed":               "获取交付链接失败",
+		"error.product_webhook_invalid":                  "Webhook 交付配置无效，请填写 http(s) 地址与签名密钥",
+		"error.webhook_fulfillment_not_applicable":       "该订单不是 Webhook 交付订单",
+		"error.webhook_fulfillment_failed":               "Webhook 交付推送失败，请查看尝试记录",
+		"error.webhook_fulfillment_in_progress":          "Webhook 交付正在推送中，请稍后查看结果",
+		"error.webhook_fulfillment_fetch_failed":         "获取 Webhook 交付记录失败",
+		"error.license_invalid":                          "授权码无效",
+		"error.license_not_found":                        "授权码不存在",
+		"error.license_revoked":                          "授权码已被吊销",
+		"error.license_expired":                          "授权码已过期",
+		"error.license_activation_limit":                 "授权码激活设备数已达上限",
+		"error.license_machine_id_invalid":               "设备标识无效",
+		"error.license_signing_key_invalid":              "签名私钥格式无效，请提供 Base64 编码的 Ed25519 私钥",
+		"error.license_verify_failed":                    "授权码校验失败",
+		"error.license_fetch_failed":                     "获取授权码失败",
+		"error.license_update_failed":                    "更新授权码失败",
+		"error.card_secret_replace_invalid":              "所选卡密不属于该订单项或不可补发",
+		"error.card_secret_replace_failed":               "问题卡密补发失败",
+		"error.card_secret_replacement_fetch_failed":     "获取卡密补发记录失败",
+		"error.file_asset_not_found":                     "文件不存在",
+		"error.file_asset_invalid":                       "文件无效",
+		"error.file_asset_too_large":                     "文件大小超过限制",
+		"error.file_asset_in_use":                        "文件仍被商品 SKU 引用，无法删除",
+		"error.file_download_invalid":                    "下载链接无效",
+		"error.file_download_expired":                    "下载链接已过期，请联系客服",
+		"error.file_download_exhausted":                  "下载次数已用完，请联系客服",
+		"error.file_download_failed":                     "文件下载失败",
+		"error.product_file_invalid":                     "文件交付商品需为每个启用的 SKU 选择下载文件",
+		"error.card_replenish_rule_not_found":            "补货规则不存在",
+		"error.card_replenish_rule_invalid":              "补货规则无效：需为自动发货商品 SKU 配置上游映射，且目标库存不低于触发下限",
+		"error.card_replenish_rule_exists":               "该 SKU 已存在补货规则",
+		"error.card_replenish_rule_busy":                 "该规则仍有进行中的补货采购单，暂不能删除",
+		"error.card_replenish_rule_fetch_failed":         "获取补货规则失败",
+		"error.card_replenish_rule_save_failed":          "保存补货规则失败",
+		"error.card_replenish_run_failed":                "执行补货检查失败",
+		"error.sku_source_not_found":                     "供货来源不存在",
+		"error.sku_source_invalid":                       "供货来源配置无效",
+		"error.sku_source_exists":                        "该 SKU 已存在相同的供货来源",
+		"error.sku_source_fetch_failed":                  "获取供货来源失败",
+		"error.sku_source_save_failed":                   "保存供货来源失败",
 		"error.payment_invalid":                          "支付请求不合法",
 		"error.payment_not_found":                        "支付记录不存在",
 		"error.payment_create_failed":                    "创建支付失败",
@@ -288,7 +331,10 @@ var messages = map[string]map[string]string{
 		"error.mapping_sync_failed":              "同步商品映射失败",
 		"error.mapping_update_failed":            "更新商品映射失败",
 		"error.mapping_delete_failed":            "删除商品映射失败",
+		"error.mapping_margin_guard_invalid":     "利润保护配置无效",
+		"error.margin_guard_event_fetch_failed":  "获取利润保护记录失败",
 		"error.connection_not_found":             "站点连接不存在",
+		"error.connection_dry_run_failed":        "接口模板试运行失败",
 		"error.upstream_product_not_found":       "上游商品不存在",
 		"error.upstream_products_fetch_failed":   "获取上游商品列表失败",
 		"error.upstream_categories_fetch_failed": "获取上游分类列表失败",
@@ -302,6 +348,74 @@ var messages = map[string]map[string]string{
 		"error.totp_too_many_attempts":           "失败次数过多，请稍后重试",
 		"error.totp_challenge_invalid":           "登录会话已失效，请重新输入密码",
 		"error.totp_cannot_reset_self":           "无法通过此入口重置自己的 2FA，请使用 admin-tool CLI",
+
+		// 支付对账
+		"error.payment_statement_invalid":           "对账单格式不支持或无法解析",
+		"error.payment_statement_fetch_unsupported": "该支付渠道不支持自动拉取对账单",
+
+		// 上游余额
+		"error.upstream_balance_unavailable":           "上游未返回可识别的余额",
+		"error.upstream_balance_snapshot_fetch_failed": "获取上游余额记录失败",
+
+		// 幂等键
+		"error.idempotency_key_invalid":  "Idempotency-Key 无效（不能为空且不超过 128 个字符）",
+		"error.idempotency_key_conflict": "Idempotency-Key 已用于内容不同的请求",
+		"error.idempotency_in_progress":  "相同 Idempotency-Key 的请求正在处理中，请稍后重试",
+
+		// 分销价目表
+		"error.reseller_price_list_not_found":     "分销价目表不存在",
+		"error.reseller_price_list_invalid":       "价目表名称不能为空，默认折扣需在 0-100 之间",
+		"error.reseller_price_item_invalid":       "价目表条目无效（需指定商品，固定价大于 0 或折扣在 0-100 之间，且商品/SKU 不能重复）",
+		"error.reseller_price_list_fetch_failed":  "获取分销价目表失败",
+		"error.reseller_price_list_save_failed":   "保存分销价目表失败",
+		"error.reseller_price_list_delete_failed": "删除分销价目表失败",
+		"error.reseller_price_list_assign_failed": "分配分销价目表失败",
+		"error.reseller_margin_fetch_failed":      "获取分销利润报表失败",
+
+		// API 多密钥与用量
+		"error.api_credential_create_failed":      "创建 API 密钥失败",
+		"error.api_credential_limit_reached":      "API 密钥数量已达上限",
+		"error.api_credential_scope_invalid":      "API 密钥权限范围无效",
+		"error.api_credential_ip_invalid":         "IP 白名单格式无效或条目过多",
+		"error.api_credential_settings_invalid":   "API 密钥配置无效",
+		"error.api_credential_usage_fetch_failed": "获取 API 密钥用量失败",
+
+		// 多币种钱包
+		"error.wallet_currency_invalid": "钱包币种不支持",
+		"error.wallet_statement_failed": "获取钱包对账单失败",
+
+		// 钱包转账
+		"error.wallet_transfer_disabled":            "当前账户不允许转账",
+		"error.wallet_transfer_recipient_not_found": "收款用户不存在或已停用",
+		"error.wallet_transfer_self":                "不能向自己转账",
+		"error.wallet_transfer_amount_invalid":      "转账金额无效或低于最低金额",
+		"error.wallet_transfer_daily_limit":         "已超出每日转账限额",
+		"error.wallet_transfer_failed":              "转账失败",
+		"error.wallet_insufficient_balance":         "钱包余额不足",
+
+		// 钱包提现
+		"error.wallet_withdraw_disabled":        "暂未开放余额提现",
+		"error.wallet_withdraw_amount_invalid":  "提现金额无效或低于最低金额",
+		"error.wallet_withdraw_channel_invalid": "提现渠道或收款账号无效",
+		"error.wallet_withdraw_not_found":       "提现申请不存在",
+		"error.wallet_withdraw_status_invalid":  "提现申请状态不允许该操作",
+		"error.wallet_withdraw_failed":          "提现处理失败",
+
+		// 充值赠送
+		"error.wallet_bonus_locked":                   "赠送余额不可提现或转出",
+		"error.wallet_recharge_bonus_invalid":         "充值赠送活动配置无效",
+		"error.wallet_recharge_bonus_not_found":       "充值赠送活动不存在",
+		"error.wallet_recharge_bonus_save_failed":     "充值赠送活动保存失败",
+		"error.wallet_recharge_bonus_fetch_failed":    "获取充值赠送活动失败",
+		"error.wallet_recharge_refund_status_invalid": "仅已到账的充值单可登记退款",
+		"error.wallet_recharge_refund_failed":         "充值退款失败",
+
+		// 钱包账本核对
+		"error.wallet_ledger_audit_failed":          "钱包账本核对执行失败",
+		"error.wallet_ledger_audit_not_found":       "钱包账本核对记录不存在",
+		"error.wallet_ledger_audit_fetch_failed":    "获取钱包账本核对结果失败",
+		"error.wallet_ledger_discrepancy_not_found": "钱包账本差异不存在",
+		"error.wallet_ledger_resolve_failed":        "钱包账本差异处理失败",
 	},
 	LocaleTW: {
 		"error.jwt_secret_missing":                       "JWT secret 未配置",
@@ -471,6 +585,49 @@ var messages = map[string]map[string]string{
 		"error.fulfillment_invalid":                      "交付資訊不合法",
 		"error.fulfillment_exists":                       "交付記錄已存在",
 		"error.fulfillment_create_failed":                "建立交付失敗",
+		"error.delivery_link_invalid":                    "交付連結無效",
+		"error.delivery_link_not_found":                  "交付連結不存在",
+		"error.delivery_link_exhausted":                  "交付連結查看次數已用完，請聯繫客服",
+		"error.delivery_link_fetch_failed":               "獲取交付連結失敗",
+		"error.product_webhook_invalid":                  "Webhook 交付設定無效，請填寫 http(s) 位址與簽章金鑰",
+		"error.webhook_fulfillment_not_applicable":       "該訂單不是 Webhook 交付訂單",
+		"error.webhook_fulfillment_failed":               "Webhook 交付推送失敗，請查看嘗試紀錄",
+		"error.webhook_fulfillment_in_progress":          "Webhook 交付正在推送中，請稍後查看結果",
+		"error.webhook_fulfillment_fetch_failed":         "獲取 Webhook 交付紀錄失敗",
+		"error.license_invalid":                          "授權碼無效",
+		"error.license_not_found":                        "授權碼不存在",
+		"error.license_revoked":                          "授權碼已被撤銷",
+		"error.license_expired":                          "授權碼已過期",
+		"error.license_activation_limit":                 "授權碼啟用裝置數已達上限",
+		"error.license_machine_id_invalid":               "裝置識別碼無效",
+		"error.license_signing_key_invalid":              "簽章私鑰格式無效，請提供 Base64 編碼的 Ed25519 私鑰",
+		"error.license_verify_failed":                    "授權碼驗證失敗",
+		"error.license_fetch_failed":                     "獲取授權碼失敗",
+		"error.license_update_failed":                    "更新授權碼失敗",
+		"error.card_secret_replace_invalid":              "所選卡密不屬於該訂單項或不可補發",
+		"error.card_secret_replace_failed":               "問題卡密補發失敗",
+		"error.card_secret_replacement_fetch_failed":     "獲取卡密補發記錄失敗",
+		"error.file_asset_not_found":                     "檔案不存在",
+		"error.file_asset_invalid":                       "檔案無效",
+		"error.file_asset_too_large":                     "檔案大小超過限制",
+		"error.file_asset_in_use":                        "檔案仍被商品 SKU 引用，無法刪除",
+		"error.file_download_invalid":                    "下載連結無效",
+		"error.file_download_expired":                    "下載連結已過期，請聯繫客服",
+		"error.file_download_exhausted":                  "下載次數已用完，請聯繫客服",
+		"error.file_download_failed":                     "檔案下載失敗",
+		"error.product_file_invalid":                     "檔案交付商品需為每個啟用的 SKU 選擇下載檔案",
+		"error.card_replenish_rule_not_found":            "補貨規則不存在",
+		"error.card_replenish_rule_invalid":              "補貨規則無效：需為自動發貨商品 SKU 設定上游對應，且目標庫存不低於觸發下限",
+		"error.card_replenish_rule_exists":               "該 SKU 已存在補貨規則",
+		"error.card_replenish_rule_busy":                 "該規則仍有進行中的補貨採購單，暫不能刪除",
+		"error.card_replenish_rule_fetch_failed":         "取得補貨規則失敗",
+		"error.card_replenish_rule_save_failed":          "儲存補貨規則失敗",
+		"error.card_replenish_run_failed":                "執行補貨檢查失敗",
+		"error.sku_source_not_found":                     "供貨來源不存在",
+		"error.sku_source_invalid":                       "供貨來源設定無效",
+		"error.sku_source_exists":                        "該 SKU 已存在相同的供貨來源",
+		"error.sku_source_fetch_failed":                  "取得供貨來源失敗",
+		"error.sku_source_save_failed":                   "儲存供貨來源失敗",
 		"error.payment_invalid":                          "支付請求不合法",
 		"error.payment_not_found":                        "支付記錄不存在",
 		"error.payment_create_failed":                    "建立支付失敗",
@@ -576,7 +733,10 @@ var messages = map[string]map[string]string{
 		"error.mapping_sync_failed":              "同步商品映射失敗",
 		"error.mapping_update_failed":            "更新商品映射失敗",
 		"error.mapping_delete_failed":            "刪除商品映射失敗",
+		"error.mapping_margin_guard_invalid":     "利潤保護設定無效",
+		"error.margin_guard_event_fetch_failed":  "取得利潤保護紀錄失敗",
 		"error.connection_not_found":             "站點連接不存在",
+		"error.connection_dry_run_failed":        "接口模板試運行失敗",
 		"error.upstream_product_not_found":       "上游商品不存在",
 		"error.upstream_products_fetch_failed":   "獲取上游商品列表失敗",
 		"error.upstream_categories_fetch_failed": "獲取上游分類列表失敗",
@@ -590,6 +750,74 @@ var messages = map[string]map[string]string{
 		"error.totp_too_many_attempts":           "失敗次數過多，請稍後重試",
 		"error.totp_challenge_invalid":           "登入工作階段已失效，請重新輸入密碼",
 		"error.totp_cannot_reset_self":           "無法透過此入口重設自己的 2FA，請使用 admin-tool CLI",
+
+		// 支付對帳
+		"error.payment_statement_invalid":           "對帳單格式不支援或無法解析",
+		"error.payment_statement_fetch_unsupported": "該支付渠道不支援自動拉取對帳單",
+
+		// 上游餘額
+		"error.upstream_balance_unavailable":           "上游未返回可識別的餘額",
+		"error.upstream_balance_snapshot_fetch_failed": "取得上游餘額記錄失敗",
+
+		// 冪等鍵
+		"error.idempotency_key_invalid":  "Idempotency-Key 無效（不能為空且不超過 128 個字元）",
+		"error.idempotency_key_conflict": "Idempotency-Key 已用於內容不同的請求",
+		"error.idempotency_in_progress":  "相同 Idempotency-Key 的請求正在處理中，請稍後重試",
+
+		// 分銷價目表
+		"error.reseller_price_list_not_found":     "分銷價目表不存在",
+		"error.reseller_price_list_invalid":       "價目表名稱不能為空，預設折扣需在 0-100 之間",
+		"error.reseller_price_item_invalid":       "價目表條目無效（需指定商品，固定價大於 0 或折扣在 0-100 之間，且商品/SKU 不能重複）",
+		"error.reseller_price_list_fetch_failed":  "取得分銷價目表失敗",
+		"error.reseller_price_list_save_failed":   "儲存分銷價目表失敗",
+		"error.reseller_price_list_delete_failed": "刪除分銷價目表失敗",
+		"error.reseller_price_list_assign_failed": "分配分銷價目表失敗",
+		"error.reseller_margin_fetch_failed":      "取得分銷利潤報表失敗",
+
+		// API 多金鑰與用量
+		"error.api_credential_create_failed":      "建立 API 金鑰失敗",
+		"error.api_credential_limit_reached":      "API 金鑰數量已達上限",
+		"error.api_credential_scope_invalid":      "API 金鑰權限範圍無效",
+		"error.api_credential_ip_invalid":         "IP 白名單格式無效或條目過多",
+		"error.api_credential_settings_invalid":   "API 金鑰設定無效",
+		"error.api_credential_usage_fetch_failed": "取得 API 金鑰用量失敗",
+
+		// 多幣種錢包
+		"error.wallet_currency_invalid": "錢包幣種不支援",
+		"error.wallet_statement_failed": "取得錢包對帳單失敗",
+
+		// 錢包轉帳
+		"error.wallet_transfer_disabled":            "目前帳戶不允許轉帳",
+		"error.wallet_transfer_recipient_not_found": "收款使用者不存在或已停用",
+		"error.wallet_transfer_self":                "不能向自己轉帳",
+		"error.wallet_transfer_amount_invalid":      "轉帳金額無效或低於最低金額",
+		"error.wallet_transfer_daily_limit":         "已超出每日轉帳限額",
+		"error.wallet_transfer_failed":              "轉帳失敗",
+		"error.wallet_insufficient_balance":         "錢包餘額不足",
+
+		// 錢包提現
+		"error.wallet_withdraw_disabled":        "暫未開放餘額提現",
+		"error.wallet_withdraw_amount_invalid":  "提現金額無效或低於最低金額",
+		"error.wallet_withdraw_channel_invalid": "提現管道或收款帳號無效",
+		"error.wallet_withdraw_not_found":       "提現申請不存在",
+		"error.wallet_withdraw_status_invalid":  "提現申請狀態不允許此操作",
+		"error.wallet_withdraw_failed":          "提現處理失敗",
+
+		// 儲值贈送
+		"error.wallet_bonus_locked":                   "贈送餘額不可提現或轉出",
+		"error.wallet_recharge_bonus_invalid":         "儲值贈送活動設定無效",
+		"error.wallet_recharge_bonus_not_found":       "儲值贈送活動不存在",
+		"error.wallet_recharge_bonus_save_failed":     "儲值贈送活動儲存失敗",
+		"error.wallet_recharge_bonus_fetch_failed":    "取得儲值贈送活動失敗",
+		"error.wallet_recharge_refund_status_invalid": "僅已入帳的儲值單可登記退款",
+		"error.wallet_recharge_refund_failed":         "儲值退款失敗",
+
+		// 錢包帳本核對
+		"error.wallet_ledger_audit_failed":          "錢包帳本核對執行失敗",
+		"error.wallet_ledger_audit_not_found":       "錢包帳本核對紀錄不存在",
+		"error.wallet_ledger_audit_fetch_failed":    "取得錢包帳本核對結果失敗",
+		"error.wallet_ledger_discrepancy_not_found": "錢包帳本差異不存在",
+		"error.wallet_ledger_resolve_failed":        "錢包帳本差異處理失敗",
 	},
 	LocaleEN: {
 		"error.jwt_secret_missing":                       "JWT secret is not configured",
@@ -759,6 +987,49 @@ var messages = map[string]map[string]string{
 		"error.fulfillment_invalid":                      "Invalid fulfillment data",
 		"error.fulfillment_exists":                       "Fulfillment already exists",
 		"error.fulfillment_create_failed":                "Failed to create fulfillment",
+		"error.delivery_link_invalid":                    "Invalid delivery link",
+		"error.delivery_link_not_found":                  "Delivery link not found",
+		"error.delivery_link_exhausted":                  "Delivery link has reached its view limit, please contact support",
+		"error.delivery_link_fetch_failed":               "Failed to fetch delivery link",
+		"error.product_webhook_invalid":                  "Invalid webhook fulfillment config: an http(s) URL and signing secret are required",
+		"error.webhook_fulfillment_not_applicable":       "This order is not fulfilled by webhook",
+		"error.webhook_fulfillment_failed":               "Webhook fulfillment request failed, see attempt log",
+		"error.webhook_fulfillment_in_progress":          "Webhook fulfillment is already in progress, check back shortly",
+		"error.webhook_fulfillment_fetch_failed":         "Failed to fetch webhook fulfillment records",
+		"error.license_invalid":                          "Invalid license key",
+		"error.license_not_found":                        "License not found",
+		"error.license_revoked":                          "License has been revoked",
+		"error.license_expired":                          "License has expired",
+		"error.license_activation_limit":                 "License activation limit reached",
+		"error.license_machine_id_invalid":               "Invalid machine ID",
+		"error.license_signing_key_invalid":              "Invalid signing key, provide a Base64-encoded Ed25519 private key",
+		"error.license_verify_failed":                    "Failed to verify license",
+		"error.license_fetch_failed":                     "Failed to fetch licenses",
+		"error.license_update_failed":                    "Failed to update license",
+		"error.card_secret_replace_invalid":              "The selected card secrets do not belong to this order item or cannot be replaced",
+		"error.card_secret_replace_failed":               "Failed to replace defective card secrets",
+		"error.card_secret_replacement_fetch_failed":     "Failed to fetch card secret replacements",
+		"error.file_asset_not_found":                     "File not found",
+		"error.file_asset_invalid":                       "Invalid file",
+		"error.file_asset_too_large":                     "File exceeds the size limit",
+		"error.file_asset_in_use":                        "File is still referenced by a product SKU and cannot be deleted",
+		"error.file_download_invalid":                    "Invalid download link",
+		"error.file_download_expired":                    "Download link has expired, please contact support",
+		"error.file_download_exhausted":                  "Download limit reached, please contact support",
+		"error.file_download_failed":                     "File download failed",
+		"error.product_file_invalid":                     "File delivery products require a download file for every active SKU",
+		"error.card_replenish_rule_not_found":            "Replenishment rule not found",
+		"error.card_replenish_rule_invalid":              "Invalid replenishment rule: an auto-delivery SKU with an upstream mapping is required, and the target level must not be below the minimum level",
+		"error.card_replenish_rule_exists":               "A replenishment rule already exists for this SKU",
+		"error.card_replenish_rule_busy":                 "The rule still has open replenishment procurement orders and cannot be deleted",
+		"error.card_replenish_rule_fetch_failed":         "Failed to fetch replenishment rules",
+		"error.card_replenish_rule_save_failed":          "Failed to save replenishment rule",
+		"error.card_replenish_run_failed":                "Failed to run replenishment check",
+		"error.sku_source_not_found":                     "SKU source not found",
+		"error.sku_source_invalid":                       "Invalid SKU source configuration",
+		"error.sku_source_exists":                        "This SKU already has the same source",
+		"error.sku_source_fetch_failed":                  "Failed to fetch SKU sources",
+		"error.sku_source_save_failed":                   "Failed to save SKU source",
 		"error.payment_invalid":                          "Invalid payment request",
 		"error.payment_not_found":                        "Payment not found",
 		"error.payment_create_failed":                    "Failed to create payment",
@@ -864,7 +1135,10 @@ var messages = map[string]map[string]string{
 		"error.mapping_sync_failed":              "Failed to sync product mapping",
 		"error.mapping_update_failed":            "Failed to update product mapping",
 		"error.mapping_delete_failed":            "Failed to delete product mapping",
+		"error.mapping_margin_guard_invalid":     "Invalid margin guard settings",
+		"error.margin_guard_event_fetch_failed":  "Failed to fetch margin guard events",
 		"error.connection_not_found":             "Site connection not found",
+		"error.connection_dry_run_failed":        "Failed to dry-run REST template",
 		"error.upstream_product_not_found":       "Upstream product not found",
 		"error.upstream_products_fetch_failed":   "Failed to fetch upstream products",
 		"error.upstream_categories_fetch_failed": "Failed to fetch upstream categories",
@@ -878,6 +1152,74 @@ var messages = map[string]map[string]string{
 		"error.totp_too_many_attempts":           "Too many failed attempts, please try again later",
 		"error.totp_challenge_invalid":           "Login session expired, please re-enter your password",
 		"error.totp_cannot_reset_self":           "You cannot reset your own 2FA from this endpoint; use admin-tool CLI",
+
+		// Payment reconciliation
+		"error.payment_statement_invalid":           "Unsupported or unreadable payment statement",
+		"error.payment_statement_fetch_unsupported": "This payment channel does not support fetching statements",
+
+		// Upstream balance
+		"error.upstream_balance_unavailable":           "The upstream did not return a recognizable balance",
+		"error.upstream_balance_snapshot_fetch_failed": "Failed to fetch upstream balance records",
+
+		// Idempotency keys
+		"error.idempotency_key_invalid":  "Invalid Idempotency-Key (must be 1-128 characters)",
+		"error.idempotency_key_conflict": "Idempotency-Key was already used for a different request",
+		"error.idempotency_in_progress":  "A request with the same Idempotency-Key is still in progress; retry later",
+
+		// Reseller price lists
+		"error.reseller_price_list_not_found":     "Reseller price list not found",
+		"error.reseller_price_list_invalid":       "Price list name is required and the default discount must be between 0 and 100",
+		"error.reseller_price_item_invalid":       "Invalid price list item (product required, fixed price above 0 or discount between 0 and 100, no duplicate product/SKU)",
+		"error.reseller_price_list_fetch_failed":  "Failed to fetch reseller price list",
+		"error.reseller_price_list_save_failed":   "Failed to save reseller price list",
+		"error.reseller_price_list_delete_failed": "Failed to delete reseller price list",
+		"error.reseller_price_list_assign_failed": "Failed to assign reseller price list",
+		"error.reseller_margin_fetch_failed":      "Failed to fetch reseller margin report",
+
+		// API keys and usage
+		"error.api_credential_create_failed":      "Failed to create API key",
+		"error.api_credential_limit_reached":      "API key limit reached",
+		"error.api_credential_scope_invalid":      "Invalid API key scope",
+		"error.api_credential_ip_invalid":         "IP allowlist is invalid or has too many entries",
+		"error.api_credential_settings_invalid":   "Invalid API key settings",
+		"error.api_credential_usage_fetch_failed": "Failed to fetch API key usage",
+
+		// Multi-currency wallets
+		"error.wallet_currency_invalid": "Wallet currency is not supported",
+		"error.wallet_statement_failed": "Failed to fetch wallet statement",
+
+		// Wallet transfers
+		"error.wallet_transfer_disabled":            "Transfers are not allowed for this account",
+		"error.wallet_transfer_recipient_not_found": "Recipient does not exist or is disabled",
+		"error.wallet_transfer_self":                "You cannot transfer to yourself",
+		"error.wallet_transfer_amount_invalid":      "Transfer amount is invalid or below the minimum",
+		"error.wallet_transfer_daily_limit":         "Daily transfer limit exceeded",
+		"error.wallet_transfer_failed":              "Transfer failed",
+		"error.wallet_insufficient_balance":         "Insufficient wallet balance",
+
+		// Wallet withdrawals
+		"error.wallet_withdraw_disabled":        "Balance withdrawals are not available",
+		"error.wallet_withdraw_amount_invalid":  "Withdrawal amount is invalid or below the minimum",
+		"error.wallet_withdraw_channel_invalid": "Withdrawal channel or payout account is invalid",
+		"error.wallet_withdraw_not_found":       "Withdrawal request not found",
+		"error.wallet_withdraw_status_invalid":  "Withdrawal request status does not allow this action",
+		"error.wallet_withdraw_failed":          "Failed to process withdrawal",
+
+		// Recharge bonuses
+		"error.wallet_bonus_locked":                   "Bonus balance cannot be withdrawn or transferred",
+		"error.wallet_recharge_bonus_invalid":         "Recharge bonus campaign is invalid",
+		"error.wallet_recharge_bonus_not_found":       "Recharge bonus campaign not found",
+		"error.wallet_recharge_bonus_save_failed":     "Failed to save recharge bonus campaign",
+		"error.wallet_recharge_bonus_fetch_failed":    "Failed to load recharge bonus campaigns",
+		"error.wallet_recharge_refund_status_invalid": "Only credited recharges can be refunded",
+		"error.wallet_recharge_refund_failed":         "Failed to refund recharge",
+
+		// Wallet ledger audits
+		"error.wallet_ledger_audit_failed":          "Wallet ledger audit failed",
+		"error.wallet_ledger_audit_not_found":       "Wallet ledger audit not found",
+		"error.wallet_ledger_audit_fetch_failed":    "Failed to load wallet ledger audit results",
+		"error.wallet_ledger_discrepancy_not_found": "Wallet ledger discrepancy not found",
+		"error.wallet_ledger_resolve_failed":        "Failed to resolve wallet ledger discrepancy",
 	},
 }
 
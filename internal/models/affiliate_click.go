@@ -11,6 +11,10 @@ type AffiliateClick struct {
 	Referrer           string    `gorm:"type:varchar(1024)" json:"referrer"`                         // 来源地址
 	ClientIP           string    `gorm:"type:varchar(64)" json:"client_ip"`                          // 客户端IP
 	UserAgent          string    `gorm:"type:varchar(1024)" json:"user_agent"`                       // 客户端UA
+	SubID              string    `gorm:"type:varchar(64);index" json:"sub_id"`                       // 推广子渠道标识
+	UTMSource          string    `gorm:"type:varchar(100)" json:"utm_source"`                        // UTM 来源
+	UTMMedium          string    `gorm:"type:varchar(100)" json:"utm_medium"`                        // UTM 媒介
+	UTMCampaign        string    `gorm:"type:varchar(100)" json:"utm_campaign"`                      // UTM 活动
 	CreatedAt          time.Time `gorm:"index;not null;default:CURRENT_TIMESTAMP" json:"created_at"` // 创建时间
 
 	AffiliateProfile AffiliateProfile `gorm:"foreignKey:AffiliateProfileID" json:"affiliate_profile,omitempty"` // 推广用户
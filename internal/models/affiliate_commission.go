@@ -13,6 +13,7 @@ type AffiliateCommission struct {
 	OrderID            uint           `gorm:"not null;index;index:idx_affiliate_commission_unique,unique" json:"order_id"`                                   // 订单ID
 	OrderItemID        *uint          `gorm:"index" json:"order_item_id,omitempty"`                                                                          // 订单项ID
 	CommissionType     string         `gorm:"type:varchar(20);not null;default:'order';index:idx_affiliate_commission_unique,unique" json:"commission_type"` // 佣金类型
+	Level              int            `gorm:"not null;default:1;index" json:"level"`                                                                         // 佣金层级（1=直推，2=二级）
 	BaseAmount         Money          `gorm:"type:decimal(20,2);not null;default:0" json:"base_amount"`                                                      // 佣金基数金额
 	RatePercent        Money          `gorm:"type:decimal(10,2);not null;default:0" json:"rate_percent"`                                                     // 佣金比例（百分比）
 	CommissionAmount   Money          `gorm:"type:decimal(20,2);not null;default:0" json:"commission_amount"`                                                // 佣金金额
@@ -21,6 +22,9 @@ type AffiliateCommission struct {
 	AvailableAt        *time.Time     `gorm:"index" json:"available_at,omitempty"`                                                                           // 转可提现时间
 	WithdrawRequestID  *uint          `gorm:"index" json:"withdraw_request_id,omitempty"`                                                                    // 关联提现申请
 	InvalidReason      string         `gorm:"type:varchar(255)" json:"invalid_reason"`                                                                       // 失效原因
+	RiskFlags          string         `gorm:"type:varchar(255)" json:"risk_flags"`                                                                           // 命中的风控规则（逗号分隔）
+	ProcessedBy        *uint          `gorm:"index" json:"processed_by,omitempty"`                                                                           // 复核管理员ID
+	ProcessedAt        *time.Time     `gorm:"index" json:"processed_at,omitempty"`                                                                           // 复核时间
 	CreatedAt          time.Time      `gorm:"index" json:"created_at"`                                                                                       // 创建时间
 	UpdatedAt          time.Time      `gorm:"index" json:"updated_at"`                                                                                       // 更新时间
 	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`                                                                                                // 软删除时间
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// AffiliateCommissionRule 推广返利比例覆盖规则（分类/商品/SKU/推广用户）
+type AffiliateCommissionRule struct {
+	ID          uint      `gorm:"primarykey" json:"id"`                                                                        // 主键
+	ScopeType   string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_affiliate_commission_rule_scope" json:"scope_type"` // 规则范围（category/product/sku/affiliate）
+	ScopeID     uint      `gorm:"not null;uniqueIndex:idx_affiliate_commission_rule_scope" json:"scope_id"`                    // 范围对象ID（分类/商品/SKU/推广用户ID）
+	RatePercent Money     `gorm:"type:decimal(10,2);not null;default:0" json:"rate_percent"`                                   // 返利比例（百分比，0 表示不返利）
+	Remark      string    `gorm:"type:varchar(255)" json:"remark"`                                                             // 备注
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`                                                                     // 创建时间
+	UpdatedAt   time.Time `gorm:"index" json:"updated_at"`                                                                     // 更新时间
+}
+
+// TableName 指定表名
+func (AffiliateCommissionRule) TableName() string {
+	return "affiliate_commission_rules"
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// AffiliateReferral 推广关系（被推广用户 -> 推广用户），用于二级返利
+type AffiliateReferral struct {
+	ID                 uint      `gorm:"primarykey" json:"id"`                          // 主键
+	UserID             uint      `gorm:"not null;uniqueIndex" json:"user_id"`           // 被推广用户ID
+	AffiliateProfileID uint      `gorm:"not null;index" json:"affiliate_profile_id"`    // 推广用户ID
+	Source             string    `gorm:"type:varchar(20);not null;index" json:"source"` // 绑定来源
+	CreatedAt          time.Time `gorm:"index" json:"created_at"`                       // 创建时间
+
+	AffiliateProfile AffiliateProfile `gorm:"foreignKey:AffiliateProfileID" json:"affiliate_profile,omitempty"` // 推广用户
+	User             User             `gorm:"foreignKey:UserID" json:"user,omitempty"`                          // 被推广用户
+}
+
+// TableName 指定表名
+func (AffiliateReferral) TableName() string {
+	return "affiliate_referrals"
+}
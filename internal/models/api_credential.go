@@ -6,20 +6,29 @@ import (
 	"gorm.io/gorm"
 )
 
-// ApiCredential API 凭证表（用户申请 + admin 审核）
+// ApiCredential API 凭证表（用户申请 + admin 审核；审核通过后用户可创建多个具名密钥）
 type ApiCredential struct {
-	ID           uint           `gorm:"primarykey" json:"id"`
-	UserID       uint           `gorm:"uniqueIndex;not null" json:"user_id"`
-	ApiKey       string         `gorm:"type:varchar(64);uniqueIndex" json:"api_key"`
-	ApiSecret    string         `gorm:"type:varchar(256)" json:"-"`
-	Status       string         `gorm:"type:varchar(20);not null;default:'pending_review'" json:"status"`
-	RejectReason string         `gorm:"type:varchar(500)" json:"reject_reason,omitempty"`
-	ApprovedAt   *time.Time     `json:"approved_at,omitempty"`
-	IsActive     bool           `gorm:"not null;default:false" json:"is_active"`
-	LastUsedAt   *time.Time     `json:"last_used_at,omitempty"`
-	CreatedAt    time.Time      `gorm:"index" json:"created_at"`
-	UpdatedAt    time.Time      `gorm:"index" json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uint       `gorm:"primarykey" json:"id"`
+	UserID       uint       `gorm:"index:idx_api_credential_user;not null" json:"user_id"`
+	Name         string     `gorm:"type:varchar(100);not null;default:''" json:"name"` // 密钥名称
+	ApiKey       string     `gorm:"type:varchar(64);uniqueIndex" json:"api_key"`
+	ApiSecret    string     `gorm:"type:varchar(256)" json:"-"`
+	Status       string     `gorm:"type:varchar(20);not null;default:'pending_review'" json:"status"`
+	RejectReason string     `gorm:"type:varchar(500)" json:"reject_reason,omitempty"`
+	ApprovedAt   *time.Time `json:"approved_at,omitempty"`
+	IsActive     bool       `gorm:"not null;default:false" json:"is_active"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+	PriceListID  *uint      `gorm:"index" json:"price_list_id,omitempty"` // 分销价目表，优先于用户的价目表
+
+	Scopes             StringArray `gorm:"type:json" json:"scopes"`                                         // 权限范围，为空表示全部权限
+	IPAllowlist        StringArray `gorm:"type:json" json:"ip_allowlist"`                                   // IP 白名单（IP 或 CIDR），为空表示不限制
+	RateLimitPerMinute int         `gorm:"not null;default:0" json:"rate_limit_per_minute"`                 // 每分钟请求上限，0 表示使用全局默认
+	DailyOrderLimit    int         `gorm:"not null;default:0" json:"daily_order_limit"`                     // 每日下单数上限，0 表示不限
+	DailyAmountLimit   Money       `gorm:"type:decimal(20,2);not null;default:0" json:"daily_amount_limit"` // 每日下单金额上限，0 表示不限
+
+	CreatedAt time.Time      `gorm:"index" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"index" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// 关联
 	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
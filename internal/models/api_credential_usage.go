@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ApiCredentialUsage API 凭证按日用量统计
+type ApiCredentialUsage struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	CredentialID  uint      `gorm:"uniqueIndex:idx_api_credential_usage_day;not null" json:"credential_id"`               // 关联凭证
+	UsageDate     string    `gorm:"type:varchar(10);uniqueIndex:idx_api_credential_usage_day;not null" json:"usage_date"` // 日期（YYYY-MM-DD，服务器时区）
+	UserID        uint      `gorm:"index;not null" json:"user_id"`                                                        // 凭证所属用户
+	RequestCount  int64     `gorm:"not null;default:0" json:"request_count"`                                              // 通过鉴权的请求数
+	RejectedCount int64     `gorm:"not null;default:0" json:"rejected_count"`                                             // 因权限、IP、限流或配额被拒绝的请求数
+	OrderCount    int64     `gorm:"not null;default:0" json:"order_count"`                                                // 成功下单数
+	OrderAmount   Money     `gorm:"type:decimal(20,2);not null;default:0" json:"order_amount"`                            // 成功下单金额
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (ApiCredentialUsage) TableName() string {
+	return "api_credential_usages"
+}
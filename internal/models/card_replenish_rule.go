@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// CardReplenishRule 卡密自动补货规则表（每个本地 SKU 一条）
+type CardReplenishRule struct {
+	ID                uint       `gorm:"primarykey" json:"id"`                                              // 主键
+	ProductID         uint       `gorm:"index;not null" json:"product_id"`                                  // 本地商品ID
+	SKUID             uint       `gorm:"column:sku_id;uniqueIndex;not null" json:"sku_id"`                  // 本地 SKU ID
+	ConnectionID      uint       `gorm:"index;not null" json:"connection_id"`                               // 上游连接ID
+	UpstreamProductID uint       `gorm:"not null" json:"upstream_product_id"`                               // 上游商品ID
+	UpstreamSKUID     uint       `gorm:"column:upstream_sku_id;not null" json:"upstream_sku_id"`            // 上游 SKU ID
+	MinLevel          int        `gorm:"not null;default:0" json:"min_level"`                               // 触发补货的可用库存下限
+	TargetLevel       int        `gorm:"not null;default:0" json:"target_level"`                            // 补货后的目标库存
+	MaxUnitCost       Money      `gorm:"type:decimal(20,2);not null;default:0" json:"max_unit_cost"`        // 上游单价上限（上游货币，0 表示不限）
+	DailySpendCap     Money      `gorm:"type:decimal(20,2);not null;default:0" json:"daily_spend_cap"`      // 每日采购金额上限（上游货币，0 表示不限）
+	IsActive          bool       `gorm:"not null;default:true" json:"is_active"`                            // 是否启用
+	LastCheckedAt     *time.Time `json:"last_checked_at,omitempty"`                                         // 最近检查时间
+	LastOrderedAt     *time.Time `json:"last_ordered_at,omitempty"`                                         // 最近下单时间
+	LastError         string     `gorm:"type:varchar(500);not null;default:''" json:"last_error,omitempty"` // 最近一次跳过或失败原因
+	CreatedAt         time.Time  `gorm:"index" json:"created_at"`                                           // 创建时间
+	UpdatedAt         time.Time  `gorm:"index" json:"updated_at"`                                           // 更新时间
+
+	Product    *Product        `gorm:"foreignKey:ProductID" json:"product,omitempty"`       // 本地商品
+	SKU        *ProductSKU     `gorm:"foreignKey:SKUID" json:"sku,omitempty"`               // 本地 SKU
+	Connection *SiteConnection `gorm:"foreignKey:ConnectionID" json:"connection,omitempty"` // 上游连接
+}
+
+// TableName 指定表名
+func (CardReplenishRule) TableName() string {
+	return "card_replenish_rules"
+}
@@ -10,6 +10,7 @@ const (
 	CardSecretStatusAvailable = "available"
 	CardSecretStatusReserved  = "reserved"
 	CardSecretStatusUsed      = "used"
+	CardSecretStatusDefective = "defective"
 )
 
 // CardSecret 卡密库存表
@@ -19,7 +20,7 @@ type CardSecret struct {
 	SKUID      uint           `gorm:"column:sku_id;not null;default:0;index:idx_card_secret_reserve" json:"sku_id"` // SKU ID
 	BatchID    *uint          `gorm:"index" json:"batch_id,omitempty"`                                              // 批次ID
 	Secret     string         `gorm:"type:text;not null" json:"secret"`                                             // 卡密内容
-	Status     string         `gorm:"not null;index:idx_card_secret_reserve" json:"status"`                         // 状态（available/used）
+	Status     string         `gorm:"not null;index:idx_card_secret_reserve" json:"status"`                         // 状态（available/reserved/used/defective）
 	OrderID    *uint          `gorm:"index" json:"order_id,omitempty"`                                              // 关联订单ID
 	ReservedAt *time.Time     `gorm:"index" json:"reserved_at"`                                                     // 占用时间
 	UsedAt     *time.Time     `gorm:"index" json:"used_at"`                                                         // 使用时间
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// CardSecretReplacement 问题卡密补发记录表（每张问题卡密一条）
+type CardSecretReplacement struct {
+	ID                  uint      `gorm:"primarykey" json:"id"`                                 // 主键
+	OrderID             uint      `gorm:"index;not null" json:"order_id"`                       // 订单ID（持有交付记录的订单）
+	OrderItemID         uint      `gorm:"index;not null" json:"order_item_id"`                  // 订单项ID
+	ProductID           uint      `gorm:"index;not null" json:"product_id"`                     // 商品ID
+	SKUID               uint      `gorm:"column:sku_id;index;not null;default:0" json:"sku_id"` // SKU ID
+	DefectiveSecretID   uint      `gorm:"uniqueIndex;not null" json:"defective_secret_id"`      // 问题卡密ID
+	DefectiveBatchID    *uint     `gorm:"index" json:"defective_batch_id,omitempty"`            // 问题卡密所属批次ID
+	ReplacementSecretID uint      `gorm:"index;not null" json:"replacement_secret_id"`          // 补发卡密ID
+	Reason              string    `gorm:"type:varchar(500)" json:"reason"`                      // 问题原因
+	CreatedBy           *uint     `gorm:"index" json:"created_by,omitempty"`                    // 操作管理员ID
+	CreatedAt           time.Time `gorm:"index" json:"created_at"`                              // 创建时间
+
+	DefectiveSecret   *CardSecret `gorm:"foreignKey:DefectiveSecretID" json:"defective_secret,omitempty"`     // 问题卡密
+	ReplacementSecret *CardSecret `gorm:"foreignKey:ReplacementSecretID" json:"replacement_secret,omitempty"` // 补发卡密
+}
+
+// TableName 指定表名
+func (CardSecretReplacement) TableName() string {
+	return "card_secret_replacements"
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// CatalogWebhook 下游站点注册的商品目录变更 Webhook（每个 API 凭证一个）
+type CatalogWebhook struct {
+	ID              uint       `gorm:"primarykey" json:"id"`                                              // 主键
+	ApiCredentialID uint       `gorm:"uniqueIndex;not null" json:"api_credential_id"`                     // API 凭证ID
+	UserID          uint       `gorm:"index;not null" json:"user_id"`                                     // 凭证所属用户ID（用于计算会员价）
+	URL             string     `gorm:"type:varchar(500);not null" json:"url"`                             // 推送地址
+	IsActive        bool       `gorm:"not null;default:true" json:"is_active"`                            // 是否启用
+	LastDeliveredAt *time.Time `json:"last_delivered_at,omitempty"`                                       // 最近成功推送时间
+	FailCount       int        `gorm:"not null;default:0" json:"fail_count"`                              // 连续失败次数
+	LastError       string     `gorm:"type:varchar(500);not null;default:''" json:"last_error,omitempty"` // 最近一次失败原因
+	CreatedAt       time.Time  `gorm:"index" json:"created_at"`                                           // 创建时间
+	UpdatedAt       time.Time  `gorm:"index" json:"updated_at"`                                           // 更新时间
+}
+
+// TableName 指定表名
+func (CatalogWebhook) TableName() string {
+	return "catalog_webhooks"
+}
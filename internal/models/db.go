@@ -18,6 +18,7 @@ const (
 	manualStockRemainingMigrationSettingKey = "migration/manual_stock_remaining_v1"
 	skuMigrationSettingKey                  = "migration/product_sku_v1"
 	categoryParentMigrationSettingKey       = "migration/category_parent_v1"
+	walletAccountCurrencyMigrationKey       = "migration/wallet_account_currency_v1"
 	manualStockUnlimitedValue               = -1
 )
 
@@ -106,11 +107,19 @@ func AutoMigrate() error {
 		&UserOAuthIdentity{},
 		&AffiliateProfile{},
 		&AffiliateClick{},
+		&AffiliateReferral{},
 		&AffiliateCommission{},
+		&AffiliateCommissionRule{},
 		&AffiliateWithdrawRequest{},
 		&WalletAccount{},
 		&WalletTransaction{},
 		&WalletRechargeOrder{},
+		&WalletTransfer{},
+		&WalletWithdrawRequest{},
+		&WalletRechargeBonusRule{},
+		&WalletRechargeBonus{},
+		&WalletLedgerAudit{},
+		&WalletLedgerDiscrepancy{},
 		&UserLoginLog{},
 		&AuthzAuditLog{},
 		&NotificationLog{},
@@ -124,9 +133,20 @@ func AutoMigrate() error {
 		&Payment{},
 		&CardSecret{},
 		&CardSecretBatch{},
+		&CardSecretReplacement{},
+		&FileAsset{},
+		&FileDownloadGrant{},
+		&FileDownloadLog{},
 		&GiftCard{},
 		&GiftCardBatch{},
 		&Fulfillment{},
+		&DeliveryLink{},
+		&DeliveryLinkLog{},
+		&FulfillmentWebhookJob{},
+		&FulfillmentWebhookAttempt{},
+		&LicenseSigningKey{},
+		&License{},
+		&LicenseActivation{},
 		&Coupon{},
 		&CouponUsage{},
 		&Promotion{},
@@ -138,11 +158,20 @@ func AutoMigrate() error {
 		&Banner{},
 		&Setting{},
 		&ApiCredential{},
+		&ApiCredentialUsage{},
 		&SiteConnection{},
 		&ProductMapping{},
 		&SKUMapping{},
 		&ProcurementOrder{},
+		&CardReplenishRule{},
+		&SKUSource{},
+		&MarginGuardEvent{},
+		&UpstreamBalanceSnapshot{},
+		&IdempotencyRecord{},
+		&ResellerPriceList{},
+		&ResellerPriceListItem{},
 		&DownstreamOrderRef{},
+		&CatalogWebhook{},
 		&ReconciliationJob{},
 		&ReconciliationItem{},
 		&ChannelClient{},
@@ -157,6 +186,12 @@ func AutoMigrate() error {
 	if err := migrateCartSKUUniqueIndex(); err != nil {
 		return err
 	}
+	if err := migrateApiCredentialUserIndex(); err != nil {
+		return err
+	}
+	if err := migrateWalletAccountCurrency(); err != nil {
+		return err
+	}
 
 	if err := ensureProductSKUMigration(); err != nil {
 		return err
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMigrateWalletAccountCurrencyMovesTransactions(t *testing.T) {
+	dsn := fmt.Sprintf("file:wallet_currency_migration_test_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	DB = db
+	if err := db.AutoMigrate(&Setting{}, &WalletAccount{}, &WalletTransaction{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+
+	if err := db.Create(&Setting{Key: "site_config", ValueJSON: JSON{"currency": "usd"}}).Error; err != nil {
+		t.Fatalf("create site config failed: %v", err)
+	}
+	if err := db.Create(&WalletAccount{UserID: 1, Currency: "CNY"}).Error; err != nil {
+		t.Fatalf("create wallet account failed: %v", err)
+	}
+	txs := []WalletTransaction{
+		{UserID: 1, Type: "recharge", Direction: "in", Currency: "CNY", Reference: "wallet-currency-1"},
+		{UserID: 2, Type: "recharge", Direction: "in", Currency: "CNY", Reference: "wallet-currency-2"},
+	}
+	if err := db.Create(&txs).Error; err != nil {
+		t.Fatalf("create wallet transactions failed: %v", err)
+	}
+
+	if err := migrateWalletAccountCurrency(); err != nil {
+		t.Fatalf("migrate wallet account currency failed: %v", err)
+	}
+
+	var account WalletAccount
+	if err := db.First(&account, "user_id = ?", 1).Error; err != nil {
+		t.Fatalf("load wallet account failed: %v", err)
+	}
+	if account.Currency != "USD" {
+		t.Fatalf("expected account currency USD, got %s", account.Currency)
+	}
+	var migrated, untouched WalletTransaction
+	if err := db.First(&migrated, "reference = ?", "wallet-currency-1").Error; err != nil {
+		t.Fatalf("load migrated transaction failed: %v", err)
+	}
+	if migrated.Currency != "USD" {
+		t.Fatalf("expected transaction currency USD, got %s", migrated.Currency)
+	}
+	if err := db.First(&untouched, "reference = ?", "wallet-currency-2").Error; err != nil {
+		t.Fatalf("load untouched transaction failed: %v", err)
+	}
+	if untouched.Currency != "CNY" {
+		t.Fatalf("expected transaction without migrated account to stay CNY, got %s", untouched.Currency)
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DeliveryLink 安全交付链接表（按子订单维度，一个交付记录一条链接）
+type DeliveryLink struct {
+	ID            uint           `gorm:"primarykey" json:"id"`                           // 主键
+	OrderID       uint           `gorm:"uniqueIndex;not null" json:"order_id"`           // 订单ID（子订单）
+	FulfillmentID uint           `gorm:"index;not null" json:"fulfillment_id"`           // 交付记录ID
+	Token         string         `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"` // 链接随机令牌（签名前部分）
+	MaxViews      int            `gorm:"not null;default:1" json:"max_views"`            // 允许查看次数
+	ViewCount     int            `gorm:"not null;default:0" json:"view_count"`           // 已查看次数
+	LastViewedAt  *time.Time     `json:"last_viewed_at,omitempty"`                       // 最近查看时间
+	ReceivedAt    *time.Time     `gorm:"index" json:"received_at,omitempty"`             // 买家确认收货时间
+	CreatedAt     time.Time      `gorm:"index" json:"created_at"`                        // 创建时间
+	UpdatedAt     time.Time      `gorm:"index" json:"updated_at"`                        // 更新时间
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`                                 // 软删除时间
+
+	Logs []DeliveryLinkLog `gorm:"foreignKey:LinkID" json:"logs,omitempty"` // 访问记录
+}
+
+// TableName 指定表名
+func (DeliveryLink) TableName() string {
+	return "delivery_links"
+}
+
+// RemainingViews 剩余可查看次数
+func (l *DeliveryLink) RemainingViews() int {
+	if l == nil {
+		return 0
+	}
+	remaining := l.MaxViews - l.ViewCount
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// DeliveryLinkLog 安全交付链接访问记录表
+type DeliveryLinkLog struct {
+	ID        uint      `gorm:"primarykey" json:"id"`                    // 主键
+	LinkID    uint      `gorm:"index;not null" json:"link_id"`           // 链接ID
+	OrderID   uint      `gorm:"index;not null" json:"order_id"`          // 订单ID（子订单）
+	Action    string    `gorm:"type:varchar(20);not null" json:"action"` // 动作（view/received）
+	ClientIP  string    `gorm:"type:varchar(64)" json:"client_ip"`       // 访问 IP
+	UserAgent string    `gorm:"type:varchar(500)" json:"user_agent"`     // 访问 UA
+	CreatedAt time.Time `gorm:"index" json:"created_at"`                 // 访问时间
+}
+
+// TableName 指定表名
+func (DeliveryLinkLog) TableName() string {
+	return "delivery_link_logs"
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FileAsset 私有文件表（文件交付商品的下载文件，不对外公开）
+type FileAsset struct {
+	ID           uint           `gorm:"primarykey" json:"id"`                               // 主键
+	OriginalName string         `gorm:"type:varchar(255);not null" json:"original_name"`    // 原始文件名（下载时使用）
+	StoragePath  string         `gorm:"type:varchar(500);not null" json:"-"`                // 存储相对路径（相对私有目录）
+	MimeType     string         `gorm:"type:varchar(120)" json:"mime_type"`                 // MIME 类型
+	Size         int64          `gorm:"not null;default:0" json:"size"`                     // 文件大小（字节）
+	SHA256       string         `gorm:"column:sha256;type:varchar(64);index" json:"sha256"` // 文件 SHA256
+	CreatedBy    *uint          `gorm:"index" json:"created_by,omitempty"`                  // 上传管理员ID
+	CreatedAt    time.Time      `gorm:"index" json:"created_at"`                            // 创建时间
+	UpdatedAt    time.Time      `gorm:"index" json:"updated_at"`                            // 更新时间
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`                                     // 软删除时间
+}
+
+// TableName 指定表名
+func (FileAsset) TableName() string {
+	return "file_assets"
+}
+
+// FileDownloadGrant 文件下载授权表（每个订单项一条）
+type FileDownloadGrant struct {
+	ID             uint       `gorm:"primarykey" json:"id"`                           // 主键
+	OrderID        uint       `gorm:"index;not null" json:"order_id"`                 // 订单ID（子订单）
+	OrderItemID    uint       `gorm:"uniqueIndex;not null" json:"order_item_id"`      // 订单项ID
+	FileAssetID    uint       `gorm:"index;not null" json:"file_asset_id"`            // 私有文件ID
+	Token          string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"` // 下载随机令牌（签名前部分）
+	ExpiresAt      time.Time  `gorm:"index" json:"expires_at"`                        // 过期时间
+	MaxDownloads   int        `gorm:"not null;default:0" json:"max_downloads"`        // 允许下载次数（0 表示不限制）
+	DownloadCount  int        `gorm:"not null;default:0" json:"download_count"`       // 已下载次数
+	LastDownloadAt *time.Time `json:"last_download_at,omitempty"`                     // 最近下载时间
+	ResumeBytes    int64      `gorm:"not null;default:0" json:"resume_bytes"`         // 最近一次计次下载后续传分段已传输字节数
+	CreatedAt      time.Time  `gorm:"index" json:"created_at"`                        // 创建时间
+	UpdatedAt      time.Time  `gorm:"index" json:"updated_at"`                        // 更新时间
+
+	FileAsset *FileAsset        `gorm:"foreignKey:FileAssetID" json:"file_asset,omitempty"` // 关联文件
+	Logs      []FileDownloadLog `gorm:"foreignKey:GrantID" json:"logs,omitempty"`           // 下载记录
+}
+
+// TableName 指定表名
+func (FileDownloadGrant) TableName() string {
+	return "file_download_grants"
+}
+
+// RemainingDownloads 剩余可下载次数（-1 表示不限制）
+func (g *FileDownloadGrant) RemainingDownloads() int {
+	if g == nil {
+		return 0
+	}
+	if g.MaxDownloads <= 0 {
+		return -1
+	}
+	remaining := g.MaxDownloads - g.DownloadCount
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// FileDownloadLog 文件下载记录表
+type FileDownloadLog struct {
+	ID          uint      `gorm:"primarykey" json:"id"`                  // 主键
+	GrantID     uint      `gorm:"index;not null" json:"grant_id"`        // 下载授权ID
+	OrderID     uint      `gorm:"index;not null" json:"order_id"`        // 订单ID（子订单）
+	FileAssetID uint      `gorm:"index;not null" json:"file_asset_id"`   // 私有文件ID
+	Counted     bool      `gorm:"not null;default:false" json:"counted"` // 是否计入下载次数（断点续传分段请求不计）
+	RangeHeader string    `gorm:"type:varchar(120)" json:"range_header"` // 请求 Range 头
+	ClientIP    string    `gorm:"type:varchar(64)" json:"client_ip"`     // 下载 IP
+	UserAgent   string    `gorm:"type:varchar(500)" json:"user_agent"`   // 下载 UA
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`               // 下载时间
+}
+
+// TableName 指定表名
+func (FileDownloadLog) TableName() string {
+	return "file_download_logs"
+}
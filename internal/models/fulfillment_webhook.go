@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// FulfillmentWebhookJob Webhook 交付任务表（每个子订单一条）
+type FulfillmentWebhookJob struct {
+	ID             uint       `gorm:"primarykey" json:"id"`                                            // 主键
+	OrderID        uint       `gorm:"uniqueIndex;not null" json:"order_id"`                            // 订单ID（子订单）
+	ProductID      uint       `gorm:"index;not null" json:"product_id"`                                // 商品ID
+	Status         string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"` // 任务状态（pending/succeeded/fallback）
+	AttemptCount   int        `gorm:"not null;default:0" json:"attempt_count"`                         // 已尝试次数
+	MaxAttempts    int        `gorm:"not null;default:5" json:"max_attempts"`                          // 最大尝试次数
+	LastStatusCode int        `gorm:"not null;default:0" json:"last_status_code"`                      // 最近一次响应状态码
+	LastError      string     `gorm:"type:text" json:"last_error"`                                     // 最近一次错误信息
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`                                       // 最近一次尝试时间
+	NextRetryAt    *time.Time `gorm:"index" json:"next_retry_at,omitempty"`                            // 下次重试时间
+	LockedUntil    *time.Time `json:"-"`                                                               // 推送占用截止时间（防止 Worker 与手动重试并发推送）
+	CreatedAt      time.Time  `gorm:"index" json:"created_at"`                                         // 创建时间
+	UpdatedAt      time.Time  `gorm:"index" json:"updated_at"`                                         // 更新时间
+
+	Attempts []FulfillmentWebhookAttempt `gorm:"foreignKey:JobID" json:"attempts,omitempty"` // 尝试记录
+}
+
+// TableName 指定表名
+func (FulfillmentWebhookJob) TableName() string {
+	return "fulfillment_webhook_jobs"
+}
+
+// FulfillmentWebhookAttempt Webhook 交付尝试记录表
+type FulfillmentWebhookAttempt struct {
+	ID           uint      `gorm:"primarykey" json:"id"`                  // 主键
+	JobID        uint      `gorm:"index;not null" json:"job_id"`          // 任务ID
+	OrderID      uint      `gorm:"index;not null" json:"order_id"`        // 订单ID（子订单）
+	Attempt      int       `gorm:"not null" json:"attempt"`               // 第几次尝试
+	URL          string    `gorm:"type:varchar(500)" json:"url"`          // 请求地址
+	StatusCode   int       `gorm:"not null;default:0" json:"status_code"` // 响应状态码
+	ResponseBody string    `gorm:"type:text" json:"response_body"`        // 响应内容（截断）
+	Error        string    `gorm:"type:text" json:"error"`                // 错误信息
+	DurationMs   int64     `gorm:"not null;default:0" json:"duration_ms"` // 耗时（毫秒）
+	TriggeredBy  *uint     `gorm:"index" json:"triggered_by,omitempty"`   // 手动重试的管理员ID
+	CreatedAt    time.Time `gorm:"index" json:"created_at"`               // 创建时间
+}
+
+// TableName 指定表名
+func (FulfillmentWebhookAttempt) TableName() string {
+	return "fulfillment_webhook_attempts"
+}
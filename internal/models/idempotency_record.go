@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// IdempotencyRecord 幂等键记录（下游携带 Idempotency-Key 重试时回放首次响应）
+type IdempotencyRecord struct {
+	ID             uint      `gorm:"primarykey" json:"id"`                                                                               // 主键
+	Scope          string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_idempotency_scope_key" json:"scope"`                       // 作用域（如 upstream:凭证ID / channel:客户端ID）
+	Key            string    `gorm:"column:idempotency_key;type:varchar(128);not null;uniqueIndex:idx_idempotency_scope_key" json:"key"` // 调用方提供的幂等键
+	Fingerprint    string    `gorm:"type:varchar(64);not null" json:"fingerprint"`                                                       // 请求指纹（方法、路径与请求体的 SHA-256）
+	Status         string    `gorm:"type:varchar(20);not null" json:"status"`                                                            // processing / completed
+	ResponseStatus int       `gorm:"not null;default:0" json:"response_status"`                                                          // 首次响应的 HTTP 状态码
+	ContentType    string    `gorm:"type:varchar(100);not null;default:''" json:"content_type"`                                          // 首次响应的 Content-Type
+	ResponseBody   string    `gorm:"type:text" json:"response_body"`                                                                     // 首次响应体
+	ExpiresAt      time.Time `gorm:"index;not null" json:"expires_at"`                                                                   // 过期时间
+	CreatedAt      time.Time `gorm:"index" json:"created_at"`                                                                            // 创建时间
+	UpdatedAt      time.Time `json:"updated_at"`                                                                                         // 更新时间
+}
+
+// TableName 指定表名
+func (IdempotencyRecord) TableName() string {
+	return "idempotency_records"
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+)
+
+// LicenseSigningKey 商品授权码签名密钥表（每个商品一条）
+type LicenseSigningKey struct {
+	ID                  uint      `gorm:"primarykey" json:"id"`                         // 主键
+	ProductID           uint      `gorm:"uniqueIndex;not null" json:"product_id"`       // 商品ID
+	PublicKey           string    `gorm:"type:varchar(128);not null" json:"public_key"` // Ed25519 公钥（Base64）
+	PrivateKeyEncrypted string    `gorm:"type:text;not null" json:"-"`                  // Ed25519 私钥（加密存储）
+	CreatedAt           time.Time `gorm:"index" json:"created_at"`                      // 创建时间
+	UpdatedAt           time.Time `gorm:"index" json:"updated_at"`                      // 更新时间
+}
+
+// TableName 指定表名
+func (LicenseSigningKey) TableName() string {
+	return "license_signing_keys"
+}
+
+// License 授权码表（每个购买数量一条）
+type License struct {
+	ID             uint       `gorm:"primarykey" json:"id"`                                           // 主键
+	Serial         string     `gorm:"type:varchar(32);uniqueIndex;not null" json:"serial"`            // 授权码序列号
+	OrderID        uint       `gorm:"index;not null" json:"order_id"`                                 // 订单ID（子订单）
+	OrderItemID    uint       `gorm:"index;not null" json:"order_item_id"`                            // 订单项ID
+	OrderNo        string     `gorm:"type:varchar(32);index;not null" json:"order_no"`                // 订单号
+	ProductID      uint       `gorm:"index;not null" json:"product_id"`                               // 商品ID
+	SKUID          uint       `gorm:"column:sku_id;index;not null;default:0" json:"sku_id"`           // SKU ID
+	SKUCode        string     `gorm:"column:sku_code;type:varchar(64)" json:"sku_code"`               // SKU 编码
+	LicenseKey     string     `gorm:"type:text;not null" json:"license_key"`                          // 签名后的授权码
+	PublicKey      string     `gorm:"type:varchar(128);not null" json:"public_key"`                   // 签发时使用的公钥（Base64）
+	MaxActivations int        `gorm:"not null;default:0" json:"max_activations"`                      // 最多可激活设备数（0 表示不限制）
+	ExpiresAt      *time.Time `gorm:"index" json:"expires_at,omitempty"`                              // 过期时间（为空表示永久）
+	Status         string     `gorm:"type:varchar(20);not null;default:'active';index" json:"status"` // 状态（active/revoked）
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`                                           // 吊销时间
+	CreatedAt      time.Time  `gorm:"index" json:"created_at"`                                        // 创建时间
+	UpdatedAt      time.Time  `gorm:"index" json:"updated_at"`                                        // 更新时间
+
+	Activations []LicenseActivation `gorm:"foreignKey:LicenseID" json:"activations,omitempty"` // 激活记录
+}
+
+// TableName 指定表名
+func (License) TableName() string {
+	return "licenses"
+}
+
+// LicenseActivation 授权码设备激活记录表
+type LicenseActivation struct {
+	ID          uint      `gorm:"primarykey" json:"id"`                                                                    // 主键
+	LicenseID   uint      `gorm:"uniqueIndex:idx_license_activation_machine;not null" json:"license_id"`                   // 授权码ID
+	MachineID   string    `gorm:"type:varchar(191);uniqueIndex:idx_license_activation_machine;not null" json:"machine_id"` // 设备标识
+	ClientIP    string    `gorm:"type:varchar(64)" json:"client_ip"`                                                       // 首次激活 IP
+	ActivatedAt time.Time `gorm:"index" json:"activated_at"`                                                               // 首次激活时间
+	LastSeenAt  time.Time `gorm:"index" json:"last_seen_at"`                                                               // 最近校验时间
+}
+
+// TableName 指定表名
+func (LicenseActivation) TableName() string {
+	return "license_activations"
+}
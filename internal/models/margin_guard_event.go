@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// MarginGuardEvent 利润保护触发记录（上游涨价导致利润低于下限时的处理结果）
+type MarginGuardEvent struct {
+	ID               uint            `gorm:"primarykey" json:"id"`                                            // 主键
+	ConnectionID     uint            `gorm:"index;not null" json:"connection_id"`                             // 上游连接ID
+	ProductMappingID uint            `gorm:"index;not null" json:"product_mapping_id"`                        // 商品映射ID
+	LocalProductID   uint            `gorm:"index;not null" json:"local_product_id"`                          // 本地商品ID
+	LocalSKUID       uint            `gorm:"column:local_sku_id;not null" json:"local_sku_id"`                // 本地 SKU ID
+	UpstreamSKUID    uint            `gorm:"column:upstream_sku_id;not null" json:"upstream_sku_id"`          // 上游 SKU ID
+	UpstreamPrice    Money           `gorm:"type:decimal(20,2);not null;default:0" json:"upstream_price"`     // 上游价格（上游币种）
+	ExchangeRate     decimal.Decimal `gorm:"type:decimal(16,6);not null;default:1" json:"exchange_rate"`      // 触发时的汇率
+	CostAmount       Money           `gorm:"type:decimal(20,2);not null;default:0" json:"cost_amount"`        // 换算后的本地成本
+	PriceBefore      Money           `gorm:"type:decimal(20,2);not null;default:0" json:"price_before"`       // 处理前售价
+	PriceAfter       Money           `gorm:"type:decimal(20,2);not null;default:0" json:"price_after"`        // 处理后售价（下架时不变）
+	MinMarginPercent decimal.Decimal `gorm:"type:decimal(10,4);not null;default:0" json:"min_margin_percent"` // 生效的最低利润率
+	Action           string          `gorm:"type:varchar(20);not null" json:"action"`                         // deactivate / reprice
+	Reason           string          `gorm:"type:varchar(500);not null;default:''" json:"reason"`             // 触发原因
+	CreatedAt        time.Time       `gorm:"index" json:"created_at"`                                         // 创建时间
+}
+
+// TableName 指定表名
+func (MarginGuardEvent) TableName() string {
+	return "margin_guard_events"
+}
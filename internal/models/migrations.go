@@ -83,6 +83,84 @@ func migrateCartSKUUniqueIndex() error {
 	return nil
 }
 
+// migrateApiCredentialUserIndex 移除 api_credentials.user_id 的历史唯一索引，允许一个用户持有多个密钥。
+func migrateApiCredentialUserIndex() error {
+	migrator := DB.Migrator()
+
+	if migrator.HasIndex(&ApiCredential{}, "idx_api_credentials_user_id") {
+		if err := migrator.DropIndex(&ApiCredential{}, "idx_api_credentials_user_id"); err != nil {
+			return err
+		}
+	}
+
+	if !migrator.HasIndex(&ApiCredential{}, "idx_api_credential_user") {
+		if err := migrator.CreateIndex(&ApiCredential{}, "idx_api_credential_user"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateWalletAccountCurrency 将钱包账户迁移为按币种分户：移除 user_id 唯一索引，
+// 并将历史账户及其流水（迁移时默认 CNY）归入站点币种，仅执行一次。
+func migrateWalletAccountCurrency() error {
+	migrator := DB.Migrator()
+
+	if migrator.HasIndex(&WalletAccount{}, "idx_wallet_accounts_user_id") {
+		if err := migrator.DropIndex(&WalletAccount{}, "idx_wallet_accounts_user_id"); err != nil {
+			return err
+		}
+	}
+	if !migrator.HasIndex(&WalletAccount{}, "idx_wallet_account_user_currency") {
+		if err := migrator.CreateIndex(&WalletAccount{}, "idx_wallet_account_user_currency"); err != nil {
+			return err
+		}
+	}
+
+	var marker Setting
+	if err := DB.First(&marker, "key = ?", walletAccountCurrencyMigrationKey).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	} else if migrationDone(marker.ValueJSON) {
+		return nil
+	}
+
+	currency := "CNY"
+	var siteConfig Setting
+	if err := DB.First(&siteConfig, "key = ?", "site_config").Error; err == nil {
+		if raw, ok := siteConfig.ValueJSON["currency"].(string); ok && strings.TrimSpace(raw) != "" {
+			currency = strings.ToUpper(strings.TrimSpace(raw))
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if currency != "CNY" {
+			// 先迁移同一批用户的历史流水币种，避免账户与流水币种不一致
+			migratedUsers := tx.Model(&WalletAccount{}).Select("user_id").Where("currency = ?", "CNY")
+			if err := tx.Model(&WalletTransaction{}).
+				Where("currency = ? AND user_id IN (?)", "CNY", migratedUsers).
+				Update("currency", currency).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&WalletAccount{}).Where("currency = ?", "CNY").Update("currency", currency).Error; err != nil {
+				return err
+			}
+		}
+		marker := Setting{
+			Key: walletAccountCurrencyMigrationKey,
+			ValueJSON: JSON{
+				"done":        true,
+				"currency":    currency,
+				"migrated_at": time.Now().UTC().Format(time.RFC3339),
+			},
+		}
+		return tx.Save(&marker).Error
+	})
+}
+
 // ensureProductSKUMigration 执行 SKU 迁移：补默认 SKU、回填 sku_id、完整性校验。
 // 迁移完成后写入幂等标记，后续启动跳过。
 func ensureProductSKUMigration() error {
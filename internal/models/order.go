@@ -26,10 +26,15 @@ type Order struct {
 	OnlinePaidAmount        Money          `gorm:"type:decimal(20,2);not null;default:0" json:"online_paid_amount"`        // 在线支付金额
 	RefundedAmount          Money          `gorm:"type:decimal(20,2);not null;default:0" json:"refunded_amount"`           // 已退款金额（退回钱包）
 	MemberLevelID           *uint          `gorm:"index" json:"member_level_id,omitempty"`                                 // 下单时等级快照
+	PriceListID             *uint          `gorm:"index" json:"price_list_id,omitempty"`                                   // 分销价目表快照
 	CouponID                *uint          `gorm:"index" json:"coupon_id,omitempty"`                                       // 优惠券ID
 	PromotionID             *uint          `gorm:"index" json:"promotion_id,omitempty"`                                    // 活动价ID（单品订单）
 	AffiliateProfileID      *uint          `gorm:"index" json:"affiliate_profile_id,omitempty"`                            // 推广返利关联用户ID快照
 	AffiliateCode           string         `gorm:"type:varchar(32);index" json:"affiliate_code,omitempty"`                 // 推广返利联盟ID快照
+	AffiliateSubID          string         `gorm:"type:varchar(64);index" json:"affiliate_sub_id,omitempty"`               // 推广子渠道标识快照
+	AffiliateUTMSource      string         `gorm:"type:varchar(100);index" json:"affiliate_utm_source,omitempty"`          // 推广点击 UTM 来源快照
+	AffiliateUTMMedium      string         `gorm:"type:varchar(100);index" json:"affiliate_utm_medium,omitempty"`          // 推广点击 UTM 媒介快照
+	AffiliateUTMCampaign    string         `gorm:"type:varchar(100);index" json:"affiliate_utm_campaign,omitempty"`        // 推广点击 UTM 活动快照
 	ClientIP                string         `gorm:"type:varchar(64)" json:"client_ip,omitempty"`                            // 下单客户端IP
 	ExpiresAt               *time.Time     `gorm:"index" json:"expires_at"`                                                // 过期时间
 	PaidAt                  *time.Time     `gorm:"index" json:"paid_at"`                                                   // 支付时间
@@ -28,6 +28,8 @@ type OrderItem struct {
 	ManualFormSchemaSnapshotJSON JSON           `gorm:"type:json" json:"manual_form_schema_snapshot"`                           // 人工交付表单 schema 快照
 	ManualFormSubmissionJSON     JSON           `gorm:"type:json" json:"manual_form_submission"`                                // 人工交付表单提交值
 	InstructionsJSON             JSON           `gorm:"type:json" json:"instructions"`                                          // 交付后使用说明快照（多语言）
+	DeliveryMode                 string         `gorm:"type:varchar(20);not null;default:'inline'" json:"delivery_mode"`        // 交付内容展示方式快照
+	DeliveryRevealLimit          int            `gorm:"not null;default:1" json:"delivery_reveal_limit"`                        // 安全链接可查看次数快照
 	CreatedAt                    time.Time      `gorm:"index" json:"created_at"`                                                // 创建时间
 	UpdatedAt                    time.Time      `gorm:"index" json:"updated_at"`                                                // 更新时间
 	DeletedAt                    gorm.DeletedAt `gorm:"index" json:"-"`                                                         // 软删除时间
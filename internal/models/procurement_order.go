@@ -13,6 +13,12 @@ type ProcurementOrder struct {
 	ConnectionID             uint           `gorm:"index;not null" json:"connection_id"`
 	LocalOrderID             uint           `gorm:"index;not null" json:"local_order_id"`
 	LocalOrderNo             string         `gorm:"type:varchar(64);index" json:"local_order_no"`
+	ReplenishRuleID          *uint          `gorm:"index" json:"replenish_rule_id,omitempty"`                          // 自动补货规则ID（补货采购单无本地订单）
+	ReplenishQuantity        int            `gorm:"not null;default:0" json:"replenish_quantity"`                      // 补货采购数量
+	ReplenishBatchID         *uint          `json:"replenish_batch_id,omitempty"`                                      // 到货后导入的卡密批次ID
+	SKUSourceID              *uint          `gorm:"column:sku_source_id;index" json:"sku_source_id,omitempty"`         // 实际供货的 SKU 来源ID（多来源时；请求结果未知时为锁定重试的来源）
+	SourceType               string         `gorm:"type:varchar(20);not null;default:''" json:"source_type,omitempty"` // 实际供货的来源类型（upstream / card_pool）
+	SourceAttempts           string         `gorm:"type:text" json:"source_attempts,omitempty"`                        // 多来源逐个尝试的记录
 	UpstreamOrderID          uint           `json:"-"`
 	UpstreamOrderNo          string         `gorm:"type:varchar(64);index" json:"upstream_order_no,omitempty"`
 	Status                   string         `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
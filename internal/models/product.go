@@ -25,6 +25,17 @@ type Product struct {
 	MaxPurchaseQuantity  int            `gorm:"not null;default:0" json:"max_purchase_quantity"`                    // 单次最大购买数量（0 表示不限制）
 	FulfillmentType      string         `gorm:"type:varchar(20);not null;default:'manual'" json:"fulfillment_type"` // 交付类型（auto/manual）
 	ManualFormSchemaJSON JSON           `gorm:"type:json" json:"manual_form_schema"`                                // 人工交付表单 schema
+	DeliveryMode         string         `gorm:"type:varchar(20);not null;default:'inline'" json:"delivery_mode"`    // 交付内容展示方式（inline/secure_link）
+	DeliveryRevealLimit  int            `gorm:"not null;default:1" json:"delivery_reveal_limit"`                    // 安全链接可查看次数
+	WebhookURL           string         `gorm:"type:varchar(500)" json:"webhook_url"`                               // Webhook 交付推送地址
+	WebhookSecret        string         `gorm:"type:varchar(255)" json:"-"`                                         // Webhook 交付签名密钥
+	WebhookTimeoutSecs   int            `gorm:"not null;default:10" json:"webhook_timeout_seconds"`                 // Webhook 交付单次请求超时（秒）
+	WebhookMaxAttempts   int            `gorm:"not null;default:5" json:"webhook_max_attempts"`                     // Webhook 交付最大尝试次数（超过后转人工）
+	HasWebhookSecret     bool           `gorm:"-" json:"has_webhook_secret"`                                        // 是否已配置签名密钥（仅结构，不写入数据库）
+	LicenseValidDays     int            `gorm:"not null;default:0" json:"license_valid_days"`                       // 授权码有效天数（0 表示永久）
+	LicenseMaxDevices    int            `gorm:"not null;default:0" json:"license_max_activations"`                  // 授权码最多可激活设备数（0 表示不限制）
+	DownloadExpireHours  int            `gorm:"not null;default:0" json:"download_expire_hours"`                    // 文件下载链接有效小时数（0 表示使用默认值）
+	DownloadMaxCount     int            `gorm:"not null;default:0" json:"download_max_count"`                       // 文件最大下载次数（0 表示使用默认值，-1 表示不限制）
 	ManualStockTotal     int            `gorm:"not null;default:0" json:"manual_stock_total"`                       // 手动剩余库存（-1 表示无限库存，>=0 表示当前可售数量）
 	ManualStockLocked    int            `gorm:"not null;default:0" json:"manual_stock_locked"`                      // 手动库存占用量（待支付）
 	ManualStockSold      int            `gorm:"not null;default:0" json:"manual_stock_sold"`                        // 手动库存已售量（支付成功后累加）
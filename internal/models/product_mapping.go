@@ -3,21 +3,24 @@ package models
 import (
 	"time"
 
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
 // ProductMapping 商品映射表
 type ProductMapping struct {
-	ID                      uint           `gorm:"primarykey" json:"id"`
-	ConnectionID            uint           `gorm:"index;not null" json:"connection_id"`
-	LocalProductID          uint           `gorm:"uniqueIndex;not null" json:"local_product_id"`
-	UpstreamProductID       uint           `gorm:"not null" json:"upstream_product_id"`
-	UpstreamFulfillmentType string         `gorm:"type:varchar(20);not null;default:'manual'" json:"upstream_fulfillment_type"` // 上游原始交付类型（auto/manual）
-	IsActive                bool           `gorm:"not null;default:true" json:"is_active"`
-	LastSyncedAt            *time.Time     `json:"last_synced_at,omitempty"`
-	CreatedAt               time.Time      `gorm:"index" json:"created_at"`
-	UpdatedAt               time.Time      `gorm:"index" json:"updated_at"`
-	DeletedAt               gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                      uint             `gorm:"primarykey" json:"id"`
+	ConnectionID            uint             `gorm:"index;not null" json:"connection_id"`
+	LocalProductID          uint             `gorm:"uniqueIndex;not null" json:"local_product_id"`
+	UpstreamProductID       uint             `gorm:"not null" json:"upstream_product_id"`
+	UpstreamFulfillmentType string           `gorm:"type:varchar(20);not null;default:'manual'" json:"upstream_fulfillment_type"` // 上游原始交付类型（auto/manual）
+	IsActive                bool             `gorm:"not null;default:true" json:"is_active"`
+	MarginGuardMode         string           `gorm:"type:varchar(20);not null;default:''" json:"margin_guard_mode"` // 利润保护模式，为空时沿用连接配置
+	MinMarginPercent        *decimal.Decimal `gorm:"type:decimal(10,4)" json:"min_margin_percent,omitempty"`        // 最低利润率，为空时沿用连接配置
+	LastSyncedAt            *time.Time       `json:"last_synced_at,omitempty"`
+	CreatedAt               time.Time        `gorm:"index" json:"created_at"`
+	UpdatedAt               time.Time        `gorm:"index" json:"updated_at"`
+	DeletedAt               gorm.DeletedAt   `gorm:"index" json:"-"`
 
 	Connection *SiteConnection `gorm:"foreignKey:ConnectionID" json:"connection,omitempty"`
 	Product    *Product        `gorm:"foreignKey:LocalProductID" json:"product,omitempty"`
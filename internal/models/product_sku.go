@@ -27,6 +27,7 @@ type ProductSKU struct {
 	AutoStockLocked    int64          `gorm:"-" json:"auto_stock_locked"`                                                                 // 自动发货库存占用量（仅结构，不写入数据库）
 	AutoStockSold      int64          `gorm:"-" json:"auto_stock_sold"`                                                                   // 自动发货库存已售量（仅结构，不写入数据库）
 	UpstreamStock      int            `gorm:"-" json:"upstream_stock"`                                                                    // 上游库存（-1=无限, 0=售罄, >0=有货；仅结构，不写入数据库）
+	FileAssetID        *uint          `gorm:"index" json:"file_asset_id,omitempty"`                                                       // 文件交付关联的私有文件ID
 	IsActive           bool           `gorm:"default:true;index" json:"is_active"`                                                        // 是否启用
 	SortOrder          int            `gorm:"default:0;index" json:"sort_order"`                                                          // 排序权重
 	CreatedAt          time.Time      `gorm:"index" json:"created_at"`                                                                    // 创建时间
@@ -7,7 +7,11 @@ import (
 // ReconciliationJob 对账任务表
 type ReconciliationJob struct {
 	ID              uint       `gorm:"primarykey" json:"id"`
+	Source          string     `gorm:"type:varchar(20);not null;default:'upstream'" json:"source"`
 	ConnectionID    uint       `gorm:"index;not null" json:"connection_id"`
+	ChannelID       uint       `gorm:"index;not null;default:0" json:"channel_id,omitempty"`
+	StatementFormat string     `gorm:"type:varchar(20)" json:"statement_format,omitempty"`
+	StatementFile   string     `gorm:"type:varchar(255)" json:"statement_file,omitempty"`
 	Type            string     `gorm:"type:varchar(20);not null" json:"type"`
 	Status          string     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
 	TimeRangeStart  time.Time  `json:"time_range_start"`
@@ -34,6 +38,7 @@ type ReconciliationItem struct {
 	ID                 uint       `gorm:"primarykey" json:"id"`
 	JobID              uint       `gorm:"index;not null" json:"job_id"`
 	ProcurementOrderID uint       `gorm:"index" json:"procurement_order_id"`
+	PaymentID          uint       `gorm:"index" json:"payment_id,omitempty"`
 	LocalOrderNo       string     `gorm:"type:varchar(64)" json:"local_order_no"`
 	UpstreamOrderNo    string     `gorm:"type:varchar(64)" json:"upstream_order_no"`
 	LocalStatus        string     `gorm:"type:varchar(20)" json:"local_status"`
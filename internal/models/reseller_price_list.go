@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ResellerPriceList 分销价目表，可分配给 API 凭证或用户，对其上游 API 采购生效
+type ResellerPriceList struct {
+	ID                     uint            `gorm:"primarykey" json:"id"`
+	Name                   string          `gorm:"type:varchar(100);not null" json:"name"`                                // 名称
+	Description            string          `gorm:"type:varchar(500)" json:"description"`                                  // 备注
+	DefaultDiscountPercent decimal.Decimal `gorm:"type:decimal(10,4);not null;default:0" json:"default_discount_percent"` // 未单独定价商品的默认折扣百分比，如 10 = 零售价减 10%，0 = 不打折
+	IsActive               bool            `gorm:"not null;default:true" json:"is_active"`                                // 是否启用
+	CreatedAt              time.Time       `gorm:"index" json:"created_at"`
+	UpdatedAt              time.Time       `gorm:"index" json:"updated_at"`
+	DeletedAt              gorm.DeletedAt  `gorm:"index" json:"-"`
+}
+
+// TableName 指定表名
+func (ResellerPriceList) TableName() string {
+	return "reseller_price_lists"
+}
+
+// ResellerPriceListItem 价目表条目：商品或 SKU 的固定价/折扣
+type ResellerPriceListItem struct {
+	ID              uint            `gorm:"primarykey" json:"id"`
+	PriceListID     uint            `gorm:"uniqueIndex:idx_reseller_price_item;not null" json:"price_list_id"`                  // 关联价目表
+	ProductID       uint            `gorm:"uniqueIndex:idx_reseller_price_item;not null" json:"product_id"`                     // 关联商品
+	SKUID           uint            `gorm:"column:sku_id;uniqueIndex:idx_reseller_price_item;not null;default:0" json:"sku_id"` // 0=商品级，>0=SKU级
+	PriceMode       string          `gorm:"type:varchar(20);not null" json:"price_mode"`                                        // fixed / discount
+	FixedPrice      Money           `gorm:"type:decimal(20,2);not null;default:0" json:"fixed_price"`                           // 固定价（price_mode=fixed）
+	DiscountPercent decimal.Decimal `gorm:"type:decimal(10,4);not null;default:0" json:"discount_percent"`                      // 折扣百分比（price_mode=discount），如 15 = 零售价减 15%
+	CreatedAt       time.Time       `gorm:"index" json:"created_at"`
+	UpdatedAt       time.Time       `gorm:"index" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (ResellerPriceListItem) TableName() string {
+	return "reseller_price_list_items"
+}
@@ -9,25 +9,35 @@ import (
 
 // SiteConnection 对接连接表
 type SiteConnection struct {
-	ID                 uint            `gorm:"primarykey" json:"id"`
-	Name               string          `gorm:"type:varchar(100);not null" json:"name"`
-	BaseURL            string          `gorm:"type:varchar(500);not null" json:"base_url"`
-	ApiKey             string          `gorm:"type:varchar(64);not null" json:"api_key"`
-	ApiSecret          string          `gorm:"type:varchar(512);not null" json:"-"` // AES-256 加密存储
-	Protocol           string          `gorm:"type:varchar(20);not null;default:'dujiao-next'" json:"protocol"`
-	CallbackURL        string          `gorm:"type:varchar(500)" json:"callback_url"`
-	Status             string          `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
-	LastPingAt         *time.Time      `json:"last_ping_at,omitempty"`
-	LastPingOK         bool            `gorm:"not null;default:false" json:"last_ping_ok"`
-	RetryMax           int             `gorm:"not null;default:5" json:"retry_max"`
-	RetryIntervals     string          `gorm:"type:varchar(200);not null;default:'[30,60,300]'" json:"retry_intervals"`
-	ExchangeRate       decimal.Decimal `gorm:"type:decimal(16,6);not null;default:1" json:"exchange_rate"`          // 汇率，上游价格 × 汇率 = 本地价格，默认 1
-	PriceMarkupPercent decimal.Decimal `gorm:"type:decimal(10,4);not null;default:0" json:"price_markup_percent"`   // 加价百分比，如 100 = +100%（翻倍）
-	PriceRoundingMode  string          `gorm:"type:varchar(20);not null;default:'none'" json:"price_rounding_mode"` // none / ceil_int / ceil_tenth
-	AutoSyncPrice      bool            `gorm:"not null;default:false" json:"auto_sync_price"`                       // 同步时自动更新本地价格
-	CreatedAt          time.Time       `gorm:"index" json:"created_at"`
-	UpdatedAt          time.Time       `gorm:"index" json:"updated_at"`
-	DeletedAt          gorm.DeletedAt  `gorm:"index" json:"-"`
+	ID                    uint             `gorm:"primarykey" json:"id"`
+	Name                  string           `gorm:"type:varchar(100);not null" json:"name"`
+	BaseURL               string           `gorm:"type:varchar(500);not null" json:"base_url"`
+	ApiKey                string           `gorm:"type:varchar(64);not null" json:"api_key"`
+	ApiSecret             string           `gorm:"type:varchar(512);not null" json:"-"` // AES-256 加密存储
+	Protocol              string           `gorm:"type:varchar(20);not null;default:'dujiao-next'" json:"protocol"`
+	ProtocolVersion       string           `gorm:"type:varchar(20);not null;default:''" json:"protocol_version"` // 最近一次 ping 得到的上游协议版本
+	CallbackURL           string           `gorm:"type:varchar(500)" json:"callback_url"`
+	RestTemplate          JSON             `gorm:"type:json" json:"rest_template,omitempty"` // generic-rest 协议的接口映射模板
+	Status                string           `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	LastPingAt            *time.Time       `json:"last_ping_at,omitempty"`
+	LastPingOK            bool             `gorm:"not null;default:false" json:"last_ping_ok"`
+	RetryMax              int              `gorm:"not null;default:5" json:"retry_max"`
+	RetryIntervals        string           `gorm:"type:varchar(200);not null;default:'[30,60,300]'" json:"retry_intervals"`
+	ExchangeRate          decimal.Decimal  `gorm:"type:decimal(16,6);not null;default:1" json:"exchange_rate"`           // 汇率，上游价格 × 汇率 = 本地价格，默认 1
+	PriceMarkupPercent    decimal.Decimal  `gorm:"type:decimal(10,4);not null;default:0" json:"price_markup_percent"`    // 加价百分比，如 100 = +100%（翻倍）
+	PriceRoundingMode     string           `gorm:"type:varchar(20);not null;default:'none'" json:"price_rounding_mode"`  // none / ceil_int / ceil_tenth
+	AutoSyncPrice         bool             `gorm:"not null;default:false" json:"auto_sync_price"`                        // 同步时自动更新本地价格
+	MarginGuardMode       string           `gorm:"type:varchar(20);not null;default:'off'" json:"margin_guard_mode"`     // 利润保护：off / deactivate / reprice
+	MinMarginPercent      decimal.Decimal  `gorm:"type:decimal(10,4);not null;default:0" json:"min_margin_percent"`      // 最低利润率百分比（占售价）
+	LastBalance           *decimal.Decimal `gorm:"type:decimal(20,2)" json:"last_balance,omitempty"`                     // 最近一次获取的上游余额，为空表示未知
+	LastBalanceCurrency   string           `gorm:"type:varchar(10);not null;default:''" json:"last_balance_currency"`    // 上游余额币种
+	LastBalanceAt         *time.Time       `json:"last_balance_at,omitempty"`                                            // 最近一次获取余额时间
+	BalanceAlertThreshold decimal.Decimal  `gorm:"type:decimal(20,2);not null;default:0" json:"balance_alert_threshold"` // 余额告警下限，0 表示不告警
+	BalanceAlertDays      int              `gorm:"not null;default:0" json:"balance_alert_days"`                         // 预计可用天数告警下限，0 表示不告警
+	BalanceAlerting       bool             `gorm:"not null;default:false" json:"balance_alerting"`                       // 是否处于低余额告警状态（避免重复告警）
+	CreatedAt             time.Time        `gorm:"index" json:"created_at"`
+	UpdatedAt             time.Time        `gorm:"index" json:"updated_at"`
+	DeletedAt             gorm.DeletedAt   `gorm:"index" json:"-"`
 }
 
 // TableName 指定表名
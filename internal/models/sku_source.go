@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// SKUSource SKU 供货来源表（一个本地 SKU 可按优先级配置多个来源）
+type SKUSource struct {
+	ID                uint       `gorm:"primarykey" json:"id"`                                              // 主键
+	LocalProductID    uint       `gorm:"index;not null" json:"local_product_id"`                            // 本地商品ID
+	LocalSKUID        uint       `gorm:"column:local_sku_id;index;not null" json:"local_sku_id"`            // 本地 SKU ID
+	Priority          int        `gorm:"not null;default:0" json:"priority"`                                // 优先级（越小越优先）
+	SourceType        string     `gorm:"type:varchar(20);not null" json:"source_type"`                      // upstream / card_pool
+	ConnectionID      uint       `gorm:"index;not null;default:0" json:"connection_id"`                     // 上游连接ID（card_pool 为 0）
+	UpstreamProductID uint       `gorm:"not null;default:0" json:"upstream_product_id"`                     // 上游商品ID
+	UpstreamSKUID     uint       `gorm:"column:upstream_sku_id;not null;default:0" json:"upstream_sku_id"`  // 上游 SKU ID
+	MaxUnitCost       Money      `gorm:"type:decimal(20,2);not null;default:0" json:"max_unit_cost"`        // 上游单价上限（上游货币，0 表示不限）
+	IsActive          bool       `gorm:"not null;default:true" json:"is_active"`                            // 是否启用
+	LastUsedAt        *time.Time `json:"last_used_at,omitempty"`                                            // 最近成功供货时间
+	LastError         string     `gorm:"type:varchar(500);not null;default:''" json:"last_error,omitempty"` // 最近一次被跳过的原因
+	CreatedAt         time.Time  `gorm:"index" json:"created_at"`                                           // 创建时间
+	UpdatedAt         time.Time  `gorm:"index" json:"updated_at"`                                           // 更新时间
+
+	Connection *SiteConnection `gorm:"foreignKey:ConnectionID" json:"connection,omitempty"` // 上游连接
+}
+
+// TableName 指定表名
+func (SKUSource) TableName() string {
+	return "sku_sources"
+}
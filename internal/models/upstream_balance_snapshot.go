@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// UpstreamBalanceSnapshot 上游余额快照（定时记录在上游站点的钱包余额与预计可用天数）
+type UpstreamBalanceSnapshot struct {
+	ID             uint             `gorm:"primarykey" json:"id"`                                      // 主键
+	ConnectionID   uint             `gorm:"index;not null" json:"connection_id"`                       // 上游连接ID
+	Balance        Money            `gorm:"type:decimal(20,2);not null;default:0" json:"balance"`      // 上游余额（上游币种）
+	Currency       string           `gorm:"type:varchar(10);not null;default:''" json:"currency"`      // 上游币种
+	SpendAmount    Money            `gorm:"type:decimal(20,2);not null;default:0" json:"spend_amount"` // 统计窗口内的采购支出
+	SpendDays      int              `gorm:"not null;default:0" json:"spend_days"`                      // 统计窗口天数
+	DailySpend     Money            `gorm:"type:decimal(20,2);not null;default:0" json:"daily_spend"`  // 日均采购支出
+	RunwayDays     *decimal.Decimal `gorm:"type:decimal(10,2)" json:"runway_days,omitempty"`           // 预计可用天数，无支出时为空
+	BelowThreshold bool             `gorm:"not null;default:false" json:"below_threshold"`             // 是否低于告警阈值
+	CreatedAt      time.Time        `gorm:"index" json:"created_at"`                                   // 记录时间
+}
+
+// TableName 指定表名
+func (UpstreamBalanceSnapshot) TableName() string {
+	return "upstream_balance_snapshots"
+}
@@ -16,6 +16,7 @@ type User struct {
 	Locale                string         `gorm:"default:'zh-CN'" json:"locale"`                                // 语言偏好
 	Status                string         `gorm:"default:'active'" json:"status"`                               // 账号状态
 	MemberLevelID         uint           `gorm:"not null;default:0" json:"member_level_id"`                    // 当前会员等级ID
+	PriceListID           *uint          `gorm:"index" json:"price_list_id,omitempty"`                         // 分销价目表（上游 API 采购生效）
 	TotalRecharged        Money          `gorm:"type:decimal(20,2);not null;default:0" json:"total_recharged"` // 充值累计
 	TotalSpent            Money          `gorm:"type:decimal(20,2);not null;default:0" json:"total_spent"`     // 消费累计
 	AdminNote             string         `gorm:"type:text;default:''" json:"admin_note,omitempty"`             // 管理员备注（仅后台可见）
@@ -6,14 +6,16 @@ import (
 	"gorm.io/gorm"
 )
 
-// WalletAccount 用户钱包账户
+// WalletAccount 用户钱包账户（每个用户每个币种一个账户）
 type WalletAccount struct {
-	ID        uint           `gorm:"primarykey" json:"id"`                                 // 主键
-	UserID    uint           `gorm:"uniqueIndex;not null" json:"user_id"`                  // 用户ID
-	Balance   Money          `gorm:"type:decimal(20,2);not null;default:0" json:"balance"` // 当前余额
-	CreatedAt time.Time      `gorm:"index" json:"created_at"`                              // 创建时间
-	UpdatedAt time.Time      `gorm:"index" json:"updated_at"`                              // 更新时间
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`                                       // 软删除时间
+	ID          uint           `gorm:"primarykey" json:"id"`                                                                                 // 主键
+	UserID      uint           `gorm:"uniqueIndex:idx_wallet_account_user_currency;not null" json:"user_id"`                                 // 用户ID
+	Currency    string         `gorm:"type:varchar(16);uniqueIndex:idx_wallet_account_user_currency;not null;default:'CNY'" json:"currency"` // 币种
+	Balance     Money          `gorm:"type:decimal(20,2);not null;default:0" json:"balance"`                                                 // 当前余额
+	LockedBonus Money          `gorm:"type:decimal(20,2);not null;default:0" json:"locked_bonus"`                                            // 余额中不可提现的充值赠送部分
+	CreatedAt   time.Time      `gorm:"index" json:"created_at"`                                                                              // 创建时间
+	UpdatedAt   time.Time      `gorm:"index" json:"updated_at"`                                                                              // 更新时间
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`                                                                                       // 软删除时间
 }
 
 // TableName 指定表名
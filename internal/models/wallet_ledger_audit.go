@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// WalletLedgerAudit 钱包账本核对记录（回放账户流水并与订单金额交叉核对）
+type WalletLedgerAudit struct {
+	ID               uint       `gorm:"primarykey" json:"id"`                                            // 主键
+	Source           string     `gorm:"type:varchar(20);not null;index" json:"source"`                   // 触发方式（scheduled/manual）
+	TriggeredBy      *uint      `gorm:"index" json:"triggered_by,omitempty"`                             // 手动触发的管理员ID
+	Status           string     `gorm:"type:varchar(20);not null;default:'running';index" json:"status"` // 状态（running/completed/failed）
+	AccountCount     int        `gorm:"not null;default:0" json:"account_count"`                         // 核对账户数
+	TransactionCount int        `gorm:"not null;default:0" json:"transaction_count"`                     // 回放流水数
+	OrderCount       int        `gorm:"not null;default:0" json:"order_count"`                           // 核对订单数
+	DiscrepancyCount int        `gorm:"not null;default:0" json:"discrepancy_count"`                     // 差异数
+	ErrorMessage     string     `gorm:"type:text" json:"error_message,omitempty"`                        // 失败原因
+	StartedAt        time.Time  `json:"started_at"`                                                      // 开始时间
+	FinishedAt       *time.Time `json:"finished_at,omitempty"`                                           // 完成时间
+	CreatedAt        time.Time  `gorm:"index" json:"created_at"`                                         // 创建时间
+	UpdatedAt        time.Time  `json:"updated_at"`                                                      // 更新时间
+}
+
+// TableName 指定表名
+func (WalletLedgerAudit) TableName() string {
+	return "wallet_ledger_audits"
+}
+
+// WalletLedgerDiscrepancy 钱包账本差异明细
+type WalletLedgerDiscrepancy struct {
+	ID            uint       `gorm:"primarykey" json:"id"`                                  // 主键
+	AuditID       uint       `gorm:"index;not null" json:"audit_id"`                        // 核对记录ID
+	Type          string     `gorm:"type:varchar(40);not null;index" json:"type"`           // 差异类型
+	UserID        uint       `gorm:"index;not null;default:0" json:"user_id"`               // 用户ID
+	Currency      string     `gorm:"type:varchar(10);not null;default:''" json:"currency"`  // 币种
+	OrderID       *uint      `gorm:"index" json:"order_id,omitempty"`                       // 关联订单ID
+	TransactionID *uint      `gorm:"index" json:"transaction_id,omitempty"`                 // 关联流水ID
+	Expected      Money      `gorm:"type:decimal(20,2);not null;default:0" json:"expected"` // 期望金额
+	Actual        Money      `gorm:"type:decimal(20,2);not null;default:0" json:"actual"`   // 实际金额
+	Detail        string     `gorm:"type:varchar(255)" json:"detail,omitempty"`             // 差异说明
+	Resolved      bool       `gorm:"not null;default:false;index" json:"resolved"`          // 是否已处理
+	ResolvedBy    *uint      `json:"resolved_by,omitempty"`                                 // 处理管理员ID
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`                                 // 处理时间
+	Remark        string     `gorm:"type:text" json:"remark,omitempty"`                     // 处理备注
+	CreatedAt     time.Time  `gorm:"index" json:"created_at"`                               // 创建时间
+}
+
+// TableName 指定表名
+func (WalletLedgerDiscrepancy) TableName() string {
+	return "wallet_ledger_discrepancies"
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WalletRechargeBonusTier 充值赠送档位
+type WalletRechargeBonusTier struct {
+	MinAmount Money  `json:"min_amount"` // 单笔充值达到该金额时适用
+	Type      string `json:"type"`       // 赠送方式（fixed/percent）
+	Value     Money  `json:"value"`      // 固定赠送金额或赠送百分比
+	MaxBonus  Money  `json:"max_bonus"`  // 按比例赠送时的单笔上限（0 表示不限制）
+}
+
+// WalletRechargeBonusTiers 充值赠送档位列表，序列化为 JSON
+type WalletRechargeBonusTiers []WalletRechargeBonusTier
+
+// Value 实现 driver.Valuer 接口
+func (t WalletRechargeBonusTiers) Value() (driver.Value, error) {
+	if t == nil {
+		return nil, nil
+	}
+	return json.Marshal(t)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (t *WalletRechargeBonusTiers) Scan(value interface{}) error {
+	if value == nil {
+		*t = WalletRechargeBonusTiers{}
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(bytes, t)
+}
+
+// WalletRechargeBonusRule 充值赠送活动规则
+type WalletRechargeBonusRule struct {
+	ID                uint                     `gorm:"primarykey" json:"id"`                                            // 主键
+	Name              string                   `gorm:"type:varchar(100);not null" json:"name"`                          // 活动名称
+	Currency          string                   `gorm:"type:varchar(16);not null;default:''" json:"currency"`            // 适用币种（留空不限制）
+	Tiers             WalletRechargeBonusTiers `gorm:"type:json" json:"tiers"`                                          // 赠送档位
+	FirstRechargeOnly bool                     `gorm:"not null;default:false" json:"first_recharge_only"`               // 仅首次充值可享
+	MemberLevels      UintArray                `gorm:"type:json" json:"member_levels"`                                  // 会员等级限制（留空不限制）
+	PerUserLimit      int                      `gorm:"not null;default:0" json:"per_user_limit"`                        // 每人可享次数上限（0 表示不限制）
+	PerUserBonusCap   Money                    `gorm:"type:decimal(20,2);not null;default:0" json:"per_user_bonus_cap"` // 每人累计赠送金额上限（0 表示不限制）
+	Locked            bool                     `gorm:"not null;default:false" json:"locked"`                            // 赠送余额是否不可提现
+	StartsAt          *time.Time               `gorm:"index" json:"starts_at"`                                          // 生效时间
+	EndsAt            *time.Time               `gorm:"index" json:"ends_at"`                                            // 失效时间
+	IsActive          bool                     `gorm:"not null;default:true" json:"is_active"`                          // 是否启用
+	CreatedAt         time.Time                `gorm:"index" json:"created_at"`                                         // 创建时间
+	UpdatedAt         time.Time                `gorm:"index" json:"updated_at"`                                         // 更新时间
+	DeletedAt         gorm.DeletedAt           `gorm:"index" json:"-"`                                                  // 软删除时间
+}
+
+// TableName 指定表名
+func (WalletRechargeBonusRule) TableName() string {
+	return "wallet_recharge_bonus_rules"
+}
+
+// WalletRechargeBonus 充值赠送发放记录（每笔充值至多一条）
+type WalletRechargeBonus struct {
+	ID         uint       `gorm:"primarykey" json:"id"`                                    // 主键
+	RuleID     uint       `gorm:"index;not null" json:"rule_id"`                           // 命中的活动规则ID
+	UserID     uint       `gorm:"index;not null" json:"user_id"`                           // 用户ID
+	RechargeID uint       `gorm:"uniqueIndex;not null" json:"recharge_id"`                 // 充值单ID
+	Amount     Money      `gorm:"type:decimal(20,2);not null" json:"amount"`               // 赠送金额
+	Currency   string     `gorm:"type:varchar(16);not null;default:'CNY'" json:"currency"` // 币种
+	Locked     bool       `gorm:"not null;default:false" json:"locked"`                    // 是否不可提现
+	Status     string     `gorm:"type:varchar(20);index;not null" json:"status"`           // 状态（granted/revoked）
+	RevokedAt  *time.Time `gorm:"index" json:"revoked_at"`                                 // 追回时间
+	CreatedAt  time.Time  `gorm:"index" json:"created_at"`                                 // 创建时间
+	UpdatedAt  time.Time  `gorm:"index" json:"updated_at"`                                 // 更新时间
+}
+
+// TableName 指定表名
+func (WalletRechargeBonus) TableName() string {
+	return "wallet_recharge_bonuses"
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WalletTransfer 用户间钱包转账记录
+type WalletTransfer struct {
+	ID         uint           `gorm:"primarykey" json:"id"`                                     // 主键
+	TransferNo string         `gorm:"type:varchar(40);uniqueIndex;not null" json:"transfer_no"` // 转账单号（同时作为配对流水参考号前缀）
+	FromUserID uint           `gorm:"index;not null" json:"from_user_id"`                       // 转出用户ID
+	ToUserID   uint           `gorm:"index;not null" json:"to_user_id"`                         // 转入用户ID
+	Amount     Money          `gorm:"type:decimal(20,2);not null" json:"amount"`                // 到账金额
+	FeeAmount  Money          `gorm:"type:decimal(20,2);not null;default:0" json:"fee_amount"`  // 手续费（由转出方承担）
+	Currency   string         `gorm:"type:varchar(16);not null;default:'CNY'" json:"currency"`  // 币种
+	Source     string         `gorm:"type:varchar(20);not null;default:'web'" json:"source"`    // 发起来源（web/channel）
+	Remark     string         `gorm:"type:varchar(255)" json:"remark"`                          // 备注
+	CreatedAt  time.Time      `gorm:"index" json:"created_at"`                                  // 创建时间
+	UpdatedAt  time.Time      `gorm:"index" json:"updated_at"`                                  // 更新时间
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`                                           // 软删除时间
+}
+
+// TableName 指定表名
+func (WalletTransfer) TableName() string {
+	return "wallet_transfers"
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WalletWithdrawRequest 钱包余额提现申请
+type WalletWithdrawRequest struct {
+	ID              uint           `gorm:"primarykey" json:"id"`                                     // 主键
+	WithdrawNo      string         `gorm:"type:varchar(40);uniqueIndex;not null" json:"withdraw_no"` // 提现单号
+	UserID          uint           `gorm:"not null;index" json:"user_id"`                            // 用户ID
+	Amount          Money          `gorm:"type:decimal(20,2);not null;default:0" json:"amount"`      // 申请金额（申请时即从余额冻结扣除）
+	Currency        string         `gorm:"type:varchar(16);not null;default:'CNY'" json:"currency"`  // 币种
+	Channel         string         `gorm:"type:varchar(50);not null" json:"channel"`                 // 提现渠道
+	Account         string         `gorm:"type:varchar(255);not null" json:"account"`                // 提现账号
+	AccountName     string         `gorm:"type:varchar(100)" json:"account_name"`                    // 收款人姓名
+	Status          string         `gorm:"type:varchar(32);not null;index" json:"status"`            // 提现状态
+	RejectReason    string         `gorm:"type:varchar(255)" json:"reject_reason"`                   // 拒绝原因
+	PayoutReference string         `gorm:"type:varchar(120)" json:"payout_reference"`                // 线下打款流水号
+	ProcessedBy     *uint          `gorm:"index" json:"processed_by,omitempty"`                      // 审核管理员ID
+	ProcessedAt     *time.Time     `gorm:"index" json:"processed_at,omitempty"`                      // 审核时间
+	CreatedAt       time.Time      `gorm:"index" json:"created_at"`                                  // 创建时间
+	UpdatedAt       time.Time      `gorm:"index" json:"updated_at"`                                  // 更新时间
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`                                           // 软删除时间
+
+	User      *User  `gorm:"foreignKey:UserID" json:"user,omitempty"`           // 申请用户
+	Processor *Admin `gorm:"foreignKey:ProcessedBy" json:"processor,omitempty"` // 审核管理员
+}
+
+// TableName 指定表名
+func (WalletWithdrawRequest) TableName() string {
+	return "wallet_withdraw_requests"
+}
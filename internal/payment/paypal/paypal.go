@@ -274,6 +274,91 @@ func CaptureOrder(ctx context.Context, cfg *Config, orderID string) (*CaptureRes
 	return result, nil
 }
 
+// StatementRecord 对账用的 PayPal 交易记录。
+type StatementRecord struct {
+	TransactionID string
+	InvoiceID     string
+	Status        string
+	Amount        string
+	Currency      string
+	CreatedAt     time.Time
+}
+
+// ListTransactions 通过 Reporting API 拉取时间范围内的收款交易（PayPal 限制单次最长 31 天）。
+func ListTransactions(ctx context.Context, cfg *Config, start, end time.Time) ([]StatementRecord, error) {
+	if err := ValidateConfig(cfg); err != nil {
+		return nil, err
+	}
+	token, err := getAccessToken(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]StatementRecord, 0)
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("start_date", start.UTC().Format(time.RFC3339))
+		query.Set("end_date", end.UTC().Format(time.RFC3339))
+		query.Set("fields", "transaction_info")
+		query.Set("page_size", "500")
+		query.Set("page", strconv.Itoa(page))
+		respBody, statusCode, err := doJSONRequest(ctx, cfg, http.MethodGet, "/v1/reporting/transactions?"+query.Encode(), token, nil)
+		if err != nil {
+			return nil, err
+		}
+		if statusCode < 200 || statusCode >= 300 {
+			return nil, fmt.Errorf("%w: list transactions status %d", ErrResponseInvalid, statusCode)
+		}
+		var parsed map[string]interface{}
+		if err := json.Unmarshal(respBody, &parsed); err != nil {
+			return nil, fmt.Errorf("%w: decode transactions failed", ErrResponseInvalid)
+		}
+		for _, item := range readArray(parsed, "transaction_details") {
+			detail, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			amount, err := decimal.NewFromString(readString(detail, "transaction_info", "transaction_amount", "value"))
+			if err != nil || !amount.IsPositive() {
+				// 手续费、退款等出账记录不参与收款对账
+				continue
+			}
+			record := StatementRecord{
+				TransactionID: strings.TrimSpace(readString(detail, "transaction_info", "transaction_id")),
+				InvoiceID:     strings.TrimSpace(readString(detail, "transaction_info", "invoice_id")),
+				Status:        mapTransactionStatus(readString(detail, "transaction_info", "transaction_status")),
+				Amount:        amount.StringFixed(2),
+				Currency:      strings.ToUpper(strings.TrimSpace(readString(detail, "transaction_info", "transaction_amount", "currency_code"))),
+			}
+			initiatedAt := readString(detail, "transaction_info", "transaction_initiation_date")
+			for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05-0700"} {
+				if initiated, err := time.Parse(layout, initiatedAt); err == nil {
+					record.CreatedAt = initiated
+					break
+				}
+			}
+			records = append(records, record)
+		}
+		totalPages, _ := strconv.Atoi(readString(parsed, "total_pages"))
+		if page >= totalPages {
+			break
+		}
+	}
+	return records, nil
+}
+
+// mapTransactionStatus 映射 Reporting API 交易状态（S 成功 / P 处理中 / D 拒绝 / V 撤销）。
+func mapTransactionStatus(status string) string {
+	switch strings.ToUpper(strings.TrimSpace(status)) {
+	case "S":
+		return constants.PaymentStatusSuccess
+	case "P":
+		return constants.PaymentStatusPending
+	default:
+		return constants.PaymentStatusFailed
+	}
+}
+
 // VerifyWebhookSignature 校验 PayPal Webhook 签名。
 func VerifyWebhookSignature(ctx context.Context, cfg *Config, headers http.Header, event map[string]interface{}) error {
 	if cfg == nil {
@@ -278,6 +278,81 @@ func QueryPayment(ctx context.Context, cfg *Config, providerRef string) (*QueryR
 	return queryPaymentIntent(ctx, cfg, providerRef)
 }
 
+// StatementRecord 对账用的 Stripe 收款记录（PaymentIntent）。
+type StatementRecord struct {
+	PaymentIntentID string
+	OrderNo         string
+	Status          string
+	Amount          string
+	Currency        string
+	CreatedAt       time.Time
+}
+
+// ListPaymentIntents 拉取时间范围内创建且已成功收款的 PaymentIntent，用于支付对账。
+func ListPaymentIntents(ctx context.Context, cfg *Config, start, end time.Time) ([]StatementRecord, error) {
+	if err := ValidateConfig(cfg); err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	records := make([]StatementRecord, 0)
+	startingAfter := ""
+	for {
+		query := url.Values{}
+		query.Set("limit", "100")
+		query.Set("created[gte]", strconv.FormatInt(start.Unix(), 10))
+		query.Set("created[lte]", strconv.FormatInt(end.Unix(), 10))
+		if startingAfter != "" {
+			query.Set("starting_after", startingAfter)
+		}
+		respBody, statusCode, err := doJSONRequest(ctx, cfg, http.MethodGet, "/v1/payment_intents?"+query.Encode())
+		if err != nil {
+			return nil, err
+		}
+		if statusCode < 200 || statusCode >= 300 {
+			return nil, fmt.Errorf("%w: list payment intents status %d", ErrResponseInvalid, statusCode)
+		}
+		raw, err := decodeRawMap(respBody)
+		if err != nil {
+			return nil, err
+		}
+		items, _ := raw["data"].([]interface{})
+		for _, item := range items {
+			intent, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if id := readString(intent, "id"); id != "" {
+				startingAfter = id
+			}
+			// 仅已成功收款的 PaymentIntent 参与对账，未完成或已取消的不计入
+			if !strings.EqualFold(strings.TrimSpace(readString(intent, "status")), stripePIStatusSucceeded) {
+				continue
+			}
+			record := StatementRecord{
+				PaymentIntentID: readString(intent, "id"),
+				OrderNo:         readString(readMap(intent, "metadata"), "order_no"),
+				Status:          mapPaymentIntentStatus(readString(intent, "status")),
+				Currency:        strings.ToUpper(readString(intent, "currency")),
+				CreatedAt:       time.Unix(readInt64(intent, "created"), 0),
+			}
+			amountMinor := readInt64(intent, "amount_received")
+			if amountMinor <= 0 {
+				amountMinor = readInt64(intent, "amount")
+			}
+			record.Amount = fromMinorAmount(amountMinor, record.Currency)
+			records = append(records, record)
+		}
+		hasMore, _ := raw["has_more"].(bool)
+		if !hasMore || len(items) == 0 || startingAfter == "" {
+			break
+		}
+	}
+	return records, nil
+}
+
 // VerifyAndParseWebhook 校验并解析 Stripe webhook。
 func VerifyAndParseWebhook(cfg *Config, headers map[string]string, body []byte, now time.Time) (*WebhookResult, error) {
 	if cfg == nil {
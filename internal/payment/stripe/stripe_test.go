@@ -1,7 +1,10 @@
 package stripe
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -171,3 +174,42 @@ func TestMapCheckoutSessionStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestListPaymentIntentsOnlySucceeded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("starting_after") == "" {
+			_, _ = w.Write([]byte(`{"has_more":true,"data":[
+				{"id":"pi_1","status":"succeeded","amount":1000,"amount_received":1000,"currency":"usd","created":1760000000,"metadata":{"order_no":"DJP1"}},
+				{"id":"pi_2","status":"requires_payment_method","amount":2000,"currency":"usd","created":1760000001,"metadata":{"order_no":"DJP2"}}
+			]}`))
+			return
+		}
+		if r.URL.Query().Get("starting_after") != "pi_2" {
+			t.Errorf("unexpected starting_after: %s", r.URL.Query().Get("starting_after"))
+		}
+		_, _ = w.Write([]byte(`{"has_more":false,"data":[
+			{"id":"pi_3","status":"processing","amount":3000,"currency":"usd","created":1760000002,"metadata":{"order_no":"DJP3"}},
+			{"id":"pi_4","status":"succeeded","amount":4000,"amount_received":4000,"currency":"usd","created":1760000003,"metadata":{"order_no":"DJP4"}}
+		]}`))
+	}))
+	defer server.Close()
+
+	cfg := &Config{
+		SecretKey:          "sk_test_123",
+		WebhookSecret:      "whsec_123",
+		SuccessURL:         "https://example.com/success",
+		CancelURL:          "https://example.com/cancel",
+		APIBaseURL:         server.URL,
+		PaymentMethodTypes: []string{"card"},
+	}
+	records, err := ListPaymentIntents(context.Background(), cfg, time.Unix(1759990000, 0), time.Unix(1760010000, 0))
+	if err != nil {
+		t.Fatalf("list payment intents failed: %v", err)
+	}
+	if len(records) != 2 || records[0].PaymentIntentID != "pi_1" || records[1].PaymentIntentID != "pi_4" {
+		t.Fatalf("expected only succeeded intents, got %+v", records)
+	}
+	if records[1].OrderNo != "DJP4" || records[1].Amount != "40.00" || records[1].Status != constants.PaymentStatusSuccess {
+		t.Fatalf("unexpected record: %+v", records[1])
+	}
+}
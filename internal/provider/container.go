@@ -26,8 +26,13 @@ type Container struct {
 	PaymentChannelRepo     repository.PaymentChannelRepository
 	CardSecretRepo         repository.CardSecretRepository
 	CardSecretBatchRepo    repository.CardSecretBatchRepository
+	CardReplacementRepo    repository.CardSecretReplacementRepository
 	GiftCardRepo           repository.GiftCardRepository
 	FulfillmentRepo        repository.FulfillmentRepository
+	DeliveryLinkRepo       repository.DeliveryLinkRepository
+	FulfillmentWebhookRepo repository.FulfillmentWebhookRepository
+	LicenseRepo            repository.LicenseRepository
+	FileAssetRepo          repository.FileAssetRepository
 	ProductRepo            repository.ProductRepository
 	ProductSKURepo         repository.ProductSKURepository
 	CartRepo               repository.CartRepository
@@ -47,11 +52,20 @@ type Container struct {
 	DashboardRepo          repository.DashboardRepository
 	AffiliateRepo          repository.AffiliateRepository
 	ApiCredentialRepo      repository.ApiCredentialRepository
+	ApiCredentialUsageRepo repository.ApiCredentialUsageRepository
 	SiteConnectionRepo     repository.SiteConnectionRepository
 	ProductMappingRepo     repository.ProductMappingRepository
 	SKUMappingRepo         repository.SKUMappingRepository
 	ProcurementOrderRepo   repository.ProcurementOrderRepository
+	CardReplenishRuleRepo  repository.CardReplenishRuleRepository
+	SKUSourceRepo          repository.SKUSourceRepository
+	MarginGuardEventRepo   repository.MarginGuardEventRepository
+	UpstreamBalanceRepo    repository.UpstreamBalanceSnapshotRepository
+	WalletLedgerAuditRepo  repository.WalletLedgerAuditRepository
+	IdempotencyRecordRepo  repository.IdempotencyRecordRepository
+	ResellerPriceListRepo  repository.ResellerPriceListRepository
 	DownstreamOrderRefRepo repository.DownstreamOrderRefRepository
+	CatalogWebhookRepo     repository.CatalogWebhookRepository
 	ReconciliationJobRepo  repository.ReconciliationJobRepository
 	ReconciliationItemRepo repository.ReconciliationItemRepository
 	ChannelClientRepo      repository.ChannelClientRepository
@@ -76,14 +90,20 @@ type Container struct {
 	SettingService            *service.SettingService
 	CartService               *service.CartService
 	WalletService             *service.WalletService
+	WalletTransferService     *service.WalletTransferService
 	OrderRefundService        *service.OrderRefundService
 	OrderService              *service.OrderService
 	FulfillmentService        *service.FulfillmentService
+	DeliveryLinkService       *service.DeliveryLinkService
+	FulfillmentWebhookService *service.FulfillmentWebhookService
+	LicenseService            *service.LicenseService
+	FileDeliveryService       *service.FileDeliveryService
 	CouponAdminService        *service.CouponAdminService
 	PromotionAdminService     *service.PromotionAdminService
 	BannerService             *service.BannerService
 	PaymentService            *service.PaymentService
 	CardSecretService         *service.CardSecretService
+	CardReplacementService    *service.CardSecretReplacementService
 	GiftCardService           *service.GiftCardService
 	UserLoginLogService       *service.UserLoginLogService
 	AuthzAuditService         *service.AuthzAuditService
@@ -95,7 +115,14 @@ type Container struct {
 	SiteConnectionService     *service.SiteConnectionService
 	ProductMappingService     *service.ProductMappingService
 	ProcurementOrderService   *service.ProcurementOrderService
+	CardReplenishService      *service.CardReplenishService
+	UpstreamBalanceService    *service.UpstreamBalanceService
+	WalletLedgerAuditService  *service.WalletLedgerAuditService
+	IdempotencyService        *service.IdempotencyService
+	ResellerPriceListService  *service.ResellerPriceListService
+	SKUSourceService          *service.SKUSourceService
 	DownstreamCallbackService *service.DownstreamCallbackService
+	CatalogWebhookService     *service.CatalogWebhookService
 	ReconciliationService     *service.ReconciliationService
 	ChannelClientService      *service.ChannelClientService
 	TelegramBroadcastService  *service.TelegramBroadcastService
@@ -148,8 +175,13 @@ func (c *Container) initRepositories() {
 	c.PaymentChannelRepo = repository.NewPaymentChannelRepository(db)
 	c.CardSecretRepo = repository.NewCardSecretRepository(db)
 	c.CardSecretBatchRepo = repository.NewCardSecretBatchRepository(db)
+	c.CardReplacementRepo = repository.NewCardSecretReplacementRepository(db)
 	c.GiftCardRepo = repository.NewGiftCardRepository(db)
 	c.FulfillmentRepo = repository.NewFulfillmentRepository(db)
+	c.DeliveryLinkRepo = repository.NewDeliveryLinkRepository(db)
+	c.FulfillmentWebhookRepo = repository.NewFulfillmentWebhookRepository(db)
+	c.LicenseRepo = repository.NewLicenseRepository(db)
+	c.FileAssetRepo = repository.NewFileAssetRepository(db)
 	c.ProductRepo = repository.NewProductRepository(db)
 	c.ProductSKURepo = repository.NewProductSKURepository(db)
 	c.CartRepo = repository.NewCartRepository(db)
@@ -169,11 +201,20 @@ func (c *Container) initRepositories() {
 	c.DashboardRepo = repository.NewDashboardRepository(db)
 	c.AffiliateRepo = repository.NewAffiliateRepository(db)
 	c.ApiCredentialRepo = repository.NewApiCredentialRepository(db)
+	c.ApiCredentialUsageRepo = repository.NewApiCredentialUsageRepository(db)
 	c.SiteConnectionRepo = repository.NewSiteConnectionRepository(db)
 	c.ProductMappingRepo = repository.NewProductMappingRepository(db)
 	c.SKUMappingRepo = repository.NewSKUMappingRepository(db)
 	c.ProcurementOrderRepo = repository.NewProcurementOrderRepository(db)
+	c.CardReplenishRuleRepo = repository.NewCardReplenishRuleRepository(db)
+	c.SKUSourceRepo = repository.NewSKUSourceRepository(db)
+	c.MarginGuardEventRepo = repository.NewMarginGuardEventRepository(db)
+	c.UpstreamBalanceRepo = repository.NewUpstreamBalanceSnapshotRepository(db)
+	c.WalletLedgerAuditRepo = repository.NewWalletLedgerAuditRepository(db)
+	c.IdempotencyRecordRepo = repository.NewIdempotencyRecordRepository(db)
+	c.ResellerPriceListRepo = repository.NewResellerPriceListRepository(db)
 	c.DownstreamOrderRefRepo = repository.NewDownstreamOrderRefRepository(db)
+	c.CatalogWebhookRepo = repository.NewCatalogWebhookRepository(db)
 	c.ReconciliationJobRepo = repository.NewReconciliationJobRepository(db)
 	c.ReconciliationItemRepo = repository.NewReconciliationItemRepository(db)
 	c.ChannelClientRepo = repository.NewChannelClientRepository(db)
@@ -231,6 +272,7 @@ func (c *Container) initServices() {
 	c.CategoryService = service.NewCategoryService(c.CategoryRepo)
 	c.CartService = service.NewCartService(c.CartRepo, c.ProductRepo, c.ProductSKURepo, c.PromotionRepo, c.SettingService)
 	c.WalletService = service.NewWalletService(c.WalletRepo, c.OrderRepo, c.UserRepo, c.AffiliateService, c.SettingService)
+	c.WalletTransferService = service.NewWalletTransferService(c.WalletRepo, c.UserRepo, c.WalletService, c.SettingService, c.UserTOTPService)
 	c.OrderRefundService = service.NewOrderRefundService(c.OrderRepo, c.UserRepo, c.OrderRefundRecordRepo, c.AffiliateService, c.SettingService)
 	c.MemberLevelService = service.NewMemberLevelService(c.MemberLevelRepo, c.MemberLevelPriceRepo, c.UserRepo)
 	c.OrderRiskControlService = service.NewOrderRiskControlService(c.SettingService, c.OrderRepo)
@@ -258,7 +300,22 @@ func (c *Container) initServices() {
 		c.SettingService, c.Config.Email,
 		c.UserOAuthIdentityRepo,
 	)
+	c.DeliveryLinkService = service.NewDeliveryLinkService(
+		c.DeliveryLinkRepo, c.FulfillmentRepo, c.OrderRepo,
+		c.SettingService, c.Config.App.SecretKey,
+	)
+	c.LicenseService = service.NewLicenseService(
+		c.LicenseRepo, c.OrderRepo, c.ProductRepo, c.FulfillmentService, c.Config.App.SecretKey,
+	)
+	c.FileDeliveryService = service.NewFileDeliveryService(
+		c.FileAssetRepo, c.OrderRepo, c.ProductRepo, c.ProductSKURepo, c.FulfillmentService,
+		c.SettingService, c.Config.Upload.PrivateDir, c.Config.Upload.PrivateMaxSize, c.Config.App.SecretKey,
+	)
 	c.CardSecretService = service.NewCardSecretService(c.CardSecretRepo, c.CardSecretBatchRepo, c.ProductRepo, c.ProductSKURepo)
+	c.CardReplacementService = service.NewCardSecretReplacementService(
+		c.OrderRepo, c.CardSecretRepo, c.CardReplacementRepo, c.QueueClient,
+		c.SettingService, c.Config.Email,
+	)
 	c.GiftCardService = service.NewGiftCardService(c.GiftCardRepo, c.UserRepo, c.WalletService, c.SettingService)
 	c.CouponAdminService = service.NewCouponAdminService(c.CouponRepo)
 	c.PromotionAdminService = service.NewPromotionAdminService(c.PromotionRepo)
@@ -268,11 +325,28 @@ func (c *Container) initServices() {
 	c.NotificationLogService = service.NewNotificationLogService(c.NotificationLogRepo)
 	c.DashboardService = service.NewDashboardService(c.DashboardRepo, c.SettingService)
 	c.NotificationService = service.NewNotificationService(c.SettingService, c.EmailService, c.QueueClient, c.DashboardService, c.NotificationLogService, c.Config.TelegramAuth)
-	c.ApiCredentialService = service.NewApiCredentialService(c.ApiCredentialRepo)
+	c.ApiCredentialService = service.NewApiCredentialService(c.ApiCredentialRepo, c.ApiCredentialUsageRepo)
+	c.IdempotencyService = service.NewIdempotencyService(c.IdempotencyRecordRepo)
+	c.ResellerPriceListService = service.NewResellerPriceListService(c.ResellerPriceListRepo, c.ApiCredentialRepo, c.UserRepo)
+	c.OrderService.SetResellerPriceListService(c.ResellerPriceListService)
 	c.SiteConnectionService = service.NewSiteConnectionService(c.SiteConnectionRepo, c.Config.App.SecretKey, "uploads")
 	c.ProductMappingService = service.NewProductMappingService(c.ProductMappingRepo, c.SKUMappingRepo, c.ProductRepo, c.ProductSKURepo, c.CategoryRepo, c.SiteConnectionService)
 	c.ProductMappingService.SetCategoryService(c.CategoryService)
+	c.ProductMappingService.SetMarginGuard(c.MarginGuardEventRepo, c.NotificationService)
+	c.SiteConnectionService.SetExchangeRateChangedHook(c.ProductMappingService.ApplyMarginGuardForConnection)
 	c.DownstreamCallbackService = service.NewDownstreamCallbackService(c.DownstreamOrderRefRepo, c.OrderRepo, c.ApiCredentialRepo, c.QueueClient)
+	c.CatalogWebhookService = service.NewCatalogWebhookService(service.CatalogWebhookServiceOptions{
+		WebhookRepo:        c.CatalogWebhookRepo,
+		CredentialRepo:     c.ApiCredentialRepo,
+		UserRepo:           c.UserRepo,
+		ProductMappingRepo: c.ProductMappingRepo,
+		SKUMappingRepo:     c.SKUMappingRepo,
+		ProductService:     c.ProductService,
+		MemberLevelService: c.MemberLevelService,
+		PriceListService:   c.ResellerPriceListService,
+		SettingService:     c.SettingService,
+		QueueClient:        c.QueueClient,
+	})
 	c.PaymentService = service.NewPaymentService(service.PaymentServiceOptions{
 		OrderRepo:             c.OrderRepo,
 		ProductRepo:           c.ProductRepo,
@@ -290,14 +364,32 @@ func (c *Container) initServices() {
 		AffiliateService:      c.AffiliateService,
 		NotificationService:   c.NotificationService,
 	})
+	c.FulfillmentWebhookService = service.NewFulfillmentWebhookService(
+		c.FulfillmentWebhookRepo, c.OrderRepo, c.ProductRepo, c.UserRepo,
+		c.FulfillmentService, c.NotificationService, c.SettingService, c.QueueClient,
+	)
 	c.ProcurementOrderService = service.NewProcurementOrderService(
 		c.ProcurementOrderRepo, c.OrderRepo, c.ProductMappingRepo, c.SKUMappingRepo,
 		c.SiteConnectionService, c.QueueClient, c.SettingService, c.Config.Email, c.FulfillmentService,
+		c.SKUSourceRepo,
+	)
+	c.CardReplenishService = service.NewCardReplenishService(
+		c.CardReplenishRuleRepo, c.ProcurementOrderRepo, c.CardSecretRepo, c.ProductRepo, c.ProductSKURepo,
+		c.CardSecretService, c.SiteConnectionService, c.QueueClient,
+	)
+	c.ProcurementOrderService.SetCardReplenishService(c.CardReplenishService)
+	c.UpstreamBalanceService = service.NewUpstreamBalanceService(
+		c.SiteConnectionRepo, c.UpstreamBalanceRepo, c.ProcurementOrderRepo, c.SiteConnectionService, c.NotificationService,
+	)
+	c.WalletLedgerAuditService = service.NewWalletLedgerAuditService(
+		c.WalletLedgerAuditRepo, c.OrderRefundRecordRepo, c.NotificationService,
 	)
+	c.SKUSourceService = service.NewSKUSourceService(c.SKUSourceRepo, c.ProductRepo, c.ProductSKURepo, c.SiteConnectionService)
 	c.ReconciliationService = service.NewReconciliationService(
 		c.ReconciliationJobRepo, c.ReconciliationItemRepo, c.ProcurementOrderRepo,
 		c.SiteConnectionService, c.QueueClient, c.NotificationService,
 	)
+	c.ReconciliationService.SetPaymentReconciliation(c.PaymentRepo, c.PaymentChannelRepo)
 	c.ChannelClientService = service.NewChannelClientService(c.ChannelClientRepo, c.Config.App.SecretKey)
 	c.TelegramBroadcastService = service.NewTelegramBroadcastService(
 		c.TelegramBroadcastRepo,
@@ -311,10 +403,15 @@ func (c *Container) initServices() {
 	c.PaymentService.SetMemberLevelService(c.MemberLevelService)
 	c.PaymentService.SetProcurementService(c.ProcurementOrderService)
 	c.PaymentService.SetDownstreamCallbackService(c.DownstreamCallbackService)
+	c.PaymentService.SetWebhookFulfillmentService(c.FulfillmentWebhookService)
 	c.FulfillmentService.SetDownstreamCallbackService(c.DownstreamCallbackService)
 	c.ProcurementOrderService.SetDownstreamCallbackService(c.DownstreamCallbackService)
 	c.ProcurementOrderService.SetNotificationService(c.NotificationService)
 	c.MediaService = service.NewMediaService(c.MediaRepo)
 	c.ProductMappingService.SetMediaService(c.MediaService)
+	c.ProductService.SetCatalogWebhookService(c.CatalogWebhookService)
+	c.CardSecretService.SetCatalogWebhookService(c.CatalogWebhookService)
+	c.OrderService.SetCatalogWebhookService(c.CatalogWebhookService)
+	c.ProductMappingService.SetCatalogWebhookService(c.CatalogWebhookService)
 	c.AdProxyService = service.NewAdProxyService()
 }
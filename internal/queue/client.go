@@ -78,6 +78,52 @@ func (c *Client) EnqueueOrderAutoFulfill(payload OrderAutoFulfillPayload, opts .
 	return err
 }
 
+// EnqueueOrderWebhookFulfill 推送 Webhook 交付任务
+func (c *Client) EnqueueOrderWebhookFulfill(payload OrderWebhookFulfillPayload, delay time.Duration) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	task, err := NewOrderWebhookFulfillTask(payload)
+	if err != nil {
+		return err
+	}
+	// Webhook 交付服务自行管理重试节奏，asynq 仅处理瞬态错误（DB/Redis 不可达等）
+	options := []asynq.Option{asynq.Queue(c.defaultQueue), asynq.ProcessIn(delay), asynq.MaxRetry(3)}
+	_, err = c.client.Enqueue(task, options...)
+	return err
+}
+
+// EnqueueOrderLicenseIssue 推送授权码签发任务
+func (c *Client) EnqueueOrderLicenseIssue(payload OrderLicenseIssuePayload, opts ...asynq.Option) error {
+	if !c.Enabled() {
+		return nil
+	}
+	task, err := NewOrderLicenseIssueTask(payload)
+	if err != nil {
+		return err
+	}
+	options := append([]asynq.Option{asynq.Queue(c.defaultQueue)}, opts...)
+	_, err = c.client.Enqueue(task, options...)
+	return err
+}
+
+// EnqueueOrderFileDeliver 推送文件交付任务
+func (c *Client) EnqueueOrderFileDeliver(payload OrderFileDeliverPayload, opts ...asynq.Option) error {
+	if !c.Enabled() {
+		return nil
+	}
+	task, err := NewOrderFileDeliverTask(payload)
+	if err != nil {
+		return err
+	}
+	options := append([]asynq.Option{asynq.Queue(c.defaultQueue)}, opts...)
+	_, err = c.client.Enqueue(task, options...)
+	return err
+}
+
 // EnqueueOrderTimeoutCancel 推送订单超时取消任务
 func (c *Client) EnqueueOrderTimeoutCancel(payload OrderTimeoutCancelPayload, delay time.Duration) error {
 	if !c.Enabled() {
@@ -172,6 +218,20 @@ func (c *Client) EnqueueDownstreamCallback(payload DownstreamCallbackPayload, op
 	return err
 }
 
+// EnqueueCatalogWebhookDispatch 推送商品目录变更推送任务
+func (c *Client) EnqueueCatalogWebhookDispatch(payload CatalogWebhookDispatchPayload, opts ...asynq.Option) error {
+	if !c.Enabled() {
+		return nil
+	}
+	task, err := NewCatalogWebhookDispatchTask(payload)
+	if err != nil {
+		return err
+	}
+	options := append([]asynq.Option{asynq.Queue(c.defaultQueue)}, opts...)
+	_, err = c.client.Enqueue(task, options...)
+	return err
+}
+
 // EnqueueReconciliationRun 入队对账执行任务
 func (c *Client) EnqueueReconciliationRun(payload ReconciliationRunPayload, opts ...asynq.Option) error {
 	if !c.Enabled() {
@@ -13,6 +13,12 @@ const (
 	TaskOrderStatusEmail = constants.TaskOrderStatusEmail
 	// TaskOrderAutoFulfill 自动交付任务
 	TaskOrderAutoFulfill = constants.TaskOrderAutoFulfill
+	// TaskOrderWebhookFulfill Webhook 交付任务
+	TaskOrderWebhookFulfill = constants.TaskOrderWebhookFulfill
+	// TaskOrderLicenseIssue 授权码签发交付任务
+	TaskOrderLicenseIssue = constants.TaskOrderLicenseIssue
+	// TaskOrderFileDeliver 文件下载授权签发交付任务
+	TaskOrderFileDeliver = constants.TaskOrderFileDeliver
 	// TaskOrderTimeoutCancel 超时取消任务
 	TaskOrderTimeoutCancel = constants.TaskOrderTimeoutCancel
 	// TaskWalletRechargeExpire 钱包充值超时过期任务
@@ -23,20 +29,30 @@ const (
 	TaskAffiliateConfirmCommissions = constants.TaskAffiliateConfirmCommissions
 	// TaskUpstreamSyncStock 上游库存同步任务
 	TaskUpstreamSyncStock = constants.TaskUpstreamSyncStock
+	// TaskUpstreamBalanceCheck 上游余额巡检任务
+	TaskUpstreamBalanceCheck = constants.TaskUpstreamBalanceCheck
+	// TaskWalletLedgerAudit 钱包账本核对任务
+	TaskWalletLedgerAudit = constants.TaskWalletLedgerAudit
 	// TaskProcurementSubmit 采购提交任务
 	TaskProcurementSubmit = constants.TaskProcurementSubmit
 	// TaskProcurementPollStatus 采购状态轮询任务
 	TaskProcurementPollStatus = constants.TaskProcurementPollStatus
 	// TaskProcurementSyncAccepted 采购单定时巡检任务
 	TaskProcurementSyncAccepted = constants.TaskProcurementSyncAccepted
+	// TaskCardReplenishRun 卡密自动补货巡检任务
+	TaskCardReplenishRun = constants.TaskCardReplenishRun
 	// TaskDownstreamCallback 下游回调通知任务
 	TaskDownstreamCallback = constants.TaskDownstreamCallback
+	// TaskCatalogWebhookDispatch 商品目录变更推送任务
+	TaskCatalogWebhookDispatch = constants.TaskCatalogWebhookDispatch
 	// TaskReconciliationRun 对账执行任务
 	TaskReconciliationRun = constants.TaskReconciliationRun
 	// TaskBotNotify Bot 交付通知任务
 	TaskBotNotify = constants.TaskBotNotify
 	// TaskTelegramBroadcast Telegram 群发任务
 	TaskTelegramBroadcast = constants.TaskTelegramBroadcast
+	// TaskIdempotencyPurge 过期幂等键清理任务
+	TaskIdempotencyPurge = constants.TaskIdempotencyPurge
 )
 
 // OrderStatusEmailPayload 订单状态邮件任务载荷
@@ -138,11 +154,31 @@ func NewUpstreamSyncStockTask() *asynq.Task {
 	return asynq.NewTask(TaskUpstreamSyncStock, nil)
 }
 
+// NewUpstreamBalanceCheckTask 创建上游余额巡检任务
+func NewUpstreamBalanceCheckTask() *asynq.Task {
+	return asynq.NewTask(TaskUpstreamBalanceCheck, nil)
+}
+
+// NewWalletLedgerAuditTask 创建钱包账本核对任务
+func NewWalletLedgerAuditTask() *asynq.Task {
+	return asynq.NewTask(TaskWalletLedgerAudit, nil)
+}
+
+// NewIdempotencyPurgeTask 创建过期幂等键清理任务
+func NewIdempotencyPurgeTask() *asynq.Task {
+	return asynq.NewTask(TaskIdempotencyPurge, nil)
+}
+
 // NewProcurementSyncAcceptedTask 创建采购单定时巡检任务
 func NewProcurementSyncAcceptedTask() *asynq.Task {
 	return asynq.NewTask(TaskProcurementSyncAccepted, nil)
 }
 
+// NewCardReplenishRunTask 创建卡密自动补货巡检任务
+func NewCardReplenishRunTask() *asynq.Task {
+	return asynq.NewTask(TaskCardReplenishRun, nil)
+}
+
 // ProcurementSubmitPayload 采购提交任务载荷
 type ProcurementSubmitPayload struct {
 	ProcurementOrderID uint `json:"procurement_order_id"`
@@ -199,6 +235,20 @@ func NewDownstreamCallbackTask(payload DownstreamCallbackPayload) (*asynq.Task,
 	return asynq.NewTask(TaskDownstreamCallback, body), nil
 }
 
+// CatalogWebhookDispatchPayload 商品目录变更推送任务载荷
+type CatalogWebhookDispatchPayload struct {
+	ProductID uint `json:"product_id"`
+}
+
+// NewCatalogWebhookDispatchTask 创建商品目录变更推送任务
+func NewCatalogWebhookDispatchTask(payload CatalogWebhookDispatchPayload) (*asynq.Task, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(TaskCatalogWebhookDispatch, body), nil
+}
+
 // BotNotifyPayload Bot 交付通知任务载荷
 type BotNotifyPayload struct {
 	EventType      string `json:"event_type,omitempty"`
@@ -240,3 +290,45 @@ func NewTelegramBroadcastTask(payload TelegramBroadcastPayload) (*asynq.Task, er
 	}
 	return asynq.NewTask(TaskTelegramBroadcast, body), nil
 }
+
+// OrderWebhookFulfillPayload Webhook 交付任务载荷
+type OrderWebhookFulfillPayload struct {
+	OrderID uint `json:"order_id"`
+}
+
+// NewOrderWebhookFulfillTask 创建 Webhook 交付任务
+func NewOrderWebhookFulfillTask(payload OrderWebhookFulfillPayload) (*asynq.Task, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(TaskOrderWebhookFulfill, body), nil
+}
+
+// OrderLicenseIssuePayload 授权码签发任务载荷
+type OrderLicenseIssuePayload struct {
+	OrderID uint `json:"order_id"`
+}
+
+// NewOrderLicenseIssueTask 创建授权码签发任务
+func NewOrderLicenseIssueTask(payload OrderLicenseIssuePayload) (*asynq.Task, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(TaskOrderLicenseIssue, body), nil
+}
+
+// OrderFileDeliverPayload 文件交付任务载荷
+type OrderFileDeliverPayload struct {
+	OrderID uint `json:"order_id"`
+}
+
+// NewOrderFileDeliverTask 创建文件交付任务
+func NewOrderFileDeliverTask(payload OrderFileDeliverPayload) (*asynq.Task, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(TaskOrderFileDeliver, body), nil
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -26,11 +28,19 @@ type AffiliateRepository interface {
 	ListProfiles(filter AffiliateProfileListFilter) ([]models.AffiliateProfile, int64, error)
 
 	CreateClick(click *models.AffiliateClick) error
-	HasRecentClick(profileID uint, visitorKey, landingPath string, since time.Time) (bool, error)
-	GetLatestActiveProfileByVisitorKey(visitorKey string, since time.Time) (*models.AffiliateProfile, error)
+	HasRecentClick(profileID uint, visitorKey, landingPath, subID string, since time.Time) (bool, error)
+	GetLatestActiveClickByVisitorKey(visitorKey string, since time.Time) (*models.AffiliateClick, error)
 	CountClicksByProfile(profileID uint) (int64, error)
+	ListFunnelRows(filter AffiliateFunnelFilter) ([]AffiliateFunnelRow, error)
+	CountFunnelUniqueVisitors(filter AffiliateFunnelFilter) (int64, error)
+
+	GetReferralByUserID(userID uint) (*models.AffiliateReferral, error)
+	CreateReferral(referral *models.AffiliateReferral) error
+	CountReferralsByProfile(profileID uint) (int64, error)
 
 	GetCommissionByOrderAndProfile(orderID, profileID uint, commissionType string) (*models.AffiliateCommission, error)
+	GetCommissionByID(id uint) (*models.AffiliateCommission, error)
+	GetCommissionByIDForUpdate(id uint) (*models.AffiliateCommission, error)
 	CreateCommission(commission *models.AffiliateCommission) error
 	UpdateCommission(commission *models.AffiliateCommission) error
 	ListCommissions(filter AffiliateCommissionListFilter) ([]models.AffiliateCommission, int64, error)
@@ -38,11 +48,30 @@ type AffiliateRepository interface {
 	ListCommissionsByOrderForUpdate(orderID uint, statuses []string) ([]models.AffiliateCommission, error)
 	ListCommissionsByWithdrawIDForUpdate(withdrawID uint) ([]models.AffiliateCommission, error)
 	MarkPendingCommissionsAvailable(before, now time.Time) (int64, error)
+	MarkProfilePendingCommissionsAvailable(profileID uint, before, now time.Time) (int64, error)
+	ListDuePendingCommissions(before time.Time, limit int) ([]models.AffiliateCommission, error)
+	MarkPendingCommissionsAvailableByIDs(ids []uint, now time.Time) (int64, error)
+	FlagPendingCommissionForReview(id uint, riskFlags string, now time.Time) error
 	CountValidOrdersByProfile(profileID uint) (int64, error)
 	SumCommissionByProfile(profileID uint, statuses []string, unboundOnly bool) (decimal.Decimal, error)
+	SumCommissionByProfileAndLevel(profileID uint, level int, statuses []string) (decimal.Decimal, error)
 	ListAvailableCommissionsForUpdate(profileID uint) ([]models.AffiliateCommission, error)
 	BatchUpdateCommissions(ids []uint, updates map[string]interface{}) error
 
+	ListCommissionRules(scopeType string) ([]models.AffiliateCommissionRule, error)
+	ListCommissionRulesByScopes(scopeType string, scopeIDs []uint) ([]models.AffiliateCommissionRule, error)
+	GetCommissionRuleByID(id uint) (*models.AffiliateCommissionRule, error)
+	GetCommissionRuleByScope(scopeType string, scopeID uint) (*models.AffiliateCommissionRule, error)
+	SaveCommissionRule(rule *models.AffiliateCommissionRule) error
+	DeleteCommissionRule(id uint) error
+	ListCategoryParentIDs(categoryIDs []uint) (map[uint]uint, error)
+
+	GetUserFingerprint(userID uint, since time.Time) (AffiliateUserFingerprint, error)
+	GetProfileConversionStats(profileID uint, since time.Time) (int64, int64, error)
+	CountRefundedOrdersByBuyer(profileID, buyerUserID uint, since time.Time) (int64, error)
+	ListRefundingBuyersByProfile(profileID uint, since time.Time, minRefunds int64) ([]AffiliateRiskBuyerRow, error)
+	ListCommissionRiskFlags(profileID uint, since time.Time) ([]string, error)
+
 	CreateWithdraw(req *models.AffiliateWithdrawRequest) error
 	UpdateWithdraw(req *models.AffiliateWithdrawRequest) error
 	GetWithdrawByID(id uint) (*models.AffiliateWithdrawRequest, error)
@@ -187,8 +216,8 @@ func (r *GormAffiliateRepository) CreateClick(click *models.AffiliateClick) erro
 	return r.db.Create(click).Error
 }
 
-// HasRecentClick 查询是否存在近期重复点击记录
-func (r *GormAffiliateRepository) HasRecentClick(profileID uint, visitorKey, landingPath string, since time.Time) (bool, error) {
+// HasRecentClick 查询是否存在近期重复点击记录（同一访客、落地页与子渠道）
+func (r *GormAffiliateRepository) HasRecentClick(profileID uint, visitorKey, landingPath, subID string, since time.Time) (bool, error) {
 	if profileID == 0 || strings.TrimSpace(visitorKey) == "" {
 		return false, nil
 	}
@@ -201,6 +230,7 @@ func (r *GormAffiliateRepository) HasRecentClick(profileID uint, visitorKey, lan
 	if path := strings.TrimSpace(landingPath); path != "" {
 		query = query.Where("landing_path = ?", path)
 	}
+	query = query.Where("sub_id = ?", strings.TrimSpace(subID))
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
 		return false, err
@@ -208,32 +238,32 @@ func (r *GormAffiliateRepository) HasRecentClick(profileID uint, visitorKey, lan
 	return total > 0, nil
 }
 
-// GetLatestActiveProfileByVisitorKey 查询访客最近一次有效点击对应的推广用户
-func (r *GormAffiliateRepository) GetLatestActiveProfileByVisitorKey(visitorKey string, since time.Time) (*models.AffiliateProfile, error) {
+// GetLatestActiveClickByVisitorKey 查询访客最近一次有效点击（仅限状态正常的推广用户）
+func (r *GormAffiliateRepository) GetLatestActiveClickByVisitorKey(visitorKey string, since time.Time) (*models.AffiliateClick, error) {
 	key := strings.TrimSpace(visitorKey)
 	if key == "" {
 		return nil, nil
 	}
 
-	var profile models.AffiliateProfile
-	err := r.db.Model(&models.AffiliateProfile{}).
-		Joins("JOIN affiliate_clicks ac ON ac.affiliate_profile_id = affiliate_profiles.id").
-		Where("ac.visitor_key = ? AND ac.created_at >= ? AND affiliate_profiles.status = ?",
+	var click models.AffiliateClick
+	err := r.db.Model(&models.AffiliateClick{}).
+		Joins("JOIN affiliate_profiles ap ON ap.id = affiliate_clicks.affiliate_profile_id").
+		Where("affiliate_clicks.visitor_key = ? AND affiliate_clicks.created_at >= ? AND ap.status = ?",
 			key,
 			since,
 			constants.AffiliateProfileStatusActive,
 		).
-		Order("ac.created_at DESC, ac.id DESC").
+		Order("affiliate_clicks.created_at DESC, affiliate_clicks.id DESC").
 		Limit(1).
-		Preload("User").
-		First(&profile).Error
+		Preload("AffiliateProfile").
+		First(&click).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
 		return nil, err
 	}
-	return &profile, nil
+	return &click, nil
 }
 
 // CountClicksByProfile 统计推广点击数
@@ -248,6 +278,188 @@ func (r *GormAffiliateRepository) CountClicksByProfile(profileID uint) (int64, e
 	return total, nil
 }
 
+// CountFunnelUniqueVisitors 统计时间范围内的独立访客数（整个范围去重，不按日期与子渠道拆分）
+func (r *GormAffiliateRepository) CountFunnelUniqueVisitors(filter AffiliateFunnelFilter) (int64, error) {
+	if filter.AffiliateProfileID == 0 {
+		return 0, nil
+	}
+	query := r.db.Model(&models.AffiliateClick{}).
+		Where("affiliate_profile_id = ? AND created_at >= ? AND created_at < ? AND visitor_key <> ''", filter.AffiliateProfileID, filter.StartAt, filter.EndAt)
+	if subID := strings.TrimSpace(filter.SubID); subID != "" {
+		query = query.Where("sub_id = ?", subID)
+	}
+	query = applyAffiliateFunnelUTMFilter(query, filter, "utm_")
+	var total int64
+	if err := query.Distinct("visitor_key").Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// applyAffiliateFunnelUTMFilter 按 UTM 参数过滤漏斗查询，columnPrefix 为点击表（utm_）或订单表（affiliate_utm_）的列前缀
+func applyAffiliateFunnelUTMFilter(query *gorm.DB, filter AffiliateFunnelFilter, columnPrefix string) *gorm.DB {
+	if value := strings.TrimSpace(filter.UTMSource); value != "" {
+		query = query.Where(columnPrefix+"source = ?", value)
+	}
+	if value := strings.TrimSpace(filter.UTMMedium); value != "" {
+		query = query.Where(columnPrefix+"medium = ?", value)
+	}
+	if value := strings.TrimSpace(filter.UTMCampaign); value != "" {
+		query = query.Where(columnPrefix+"campaign = ?", value)
+	}
+	return query
+}
+
+// ListFunnelRows 按日期与子渠道聚合推广转化漏斗（点击、独立访客、下单、支付、佣金）
+func (r *GormAffiliateRepository) ListFunnelRows(filter AffiliateFunnelFilter) ([]AffiliateFunnelRow, error) {
+	if filter.AffiliateProfileID == 0 {
+		return []AffiliateFunnelRow{}, nil
+	}
+	subID := strings.TrimSpace(filter.SubID)
+	rowMap := make(map[string]*AffiliateFunnelRow)
+	pick := func(day, sub string) *AffiliateFunnelRow {
+		key := day + "\x00" + sub
+		row, ok := rowMap[key]
+		if !ok {
+			row = &AffiliateFunnelRow{Day: day, SubID: sub, Commission: decimal.Zero}
+			rowMap[key] = row
+		}
+		return row
+	}
+
+	clickDayExpr := dateGroupExpr(r.db, "created_at", filter.StartAt.Location(), filter.StartAt)
+	var clickRows []struct {
+		Day            string `gorm:"column:day"`
+		SubID          string `gorm:"column:sub_id"`
+		Clicks         int64  `gorm:"column:clicks"`
+		UniqueVisitors int64  `gorm:"column:unique_visitors"`
+	}
+	clickQuery := r.db.Model(&models.AffiliateClick{}).
+		Select(fmt.Sprintf(`%s AS day, sub_id,
+			COUNT(*) AS clicks,
+			COUNT(DISTINCT CASE WHEN visitor_key <> '' THEN visitor_key END) AS unique_visitors`, clickDayExpr)).
+		Where("affiliate_profile_id = ? AND created_at >= ? AND created_at < ?", filter.AffiliateProfileID, filter.StartAt, filter.EndAt)
+	if subID != "" {
+		clickQuery = clickQuery.Where("sub_id = ?", subID)
+	}
+	clickQuery = applyAffiliateFunnelUTMFilter(clickQuery, filter, "utm_")
+	if err := clickQuery.Group(clickDayExpr + ", sub_id").Scan(&clickRows).Error; err != nil {
+		return nil, err
+	}
+	for _, item := range clickRows {
+		row := pick(item.Day, item.SubID)
+		row.Clicks = item.Clicks
+		row.UniqueVisitors = item.UniqueVisitors
+	}
+
+	var orderRows []struct {
+		Day   string `gorm:"column:day"`
+		SubID string `gorm:"column:sub_id"`
+		Total int64  `gorm:"column:total"`
+	}
+	orderDayExpr := dateGroupExpr(r.db, "created_at", filter.StartAt.Location(), filter.StartAt)
+	orderQuery := r.db.Model(&models.Order{}).
+		Select(fmt.Sprintf("%s AS day, affiliate_sub_id AS sub_id, COUNT(*) AS total", orderDayExpr)).
+		Where("parent_id IS NULL AND affiliate_profile_id = ? AND created_at >= ? AND created_at < ?", filter.AffiliateProfileID, filter.StartAt, filter.EndAt)
+	if subID != "" {
+		orderQuery = orderQuery.Where("affiliate_sub_id = ?", subID)
+	}
+	orderQuery = applyAffiliateFunnelUTMFilter(orderQuery, filter, "affiliate_utm_")
+	if err := orderQuery.Group(orderDayExpr + ", affiliate_sub_id").Scan(&orderRows).Error; err != nil {
+		return nil, err
+	}
+	for _, item := range orderRows {
+		pick(item.Day, item.SubID).Orders = item.Total
+	}
+
+	orderRows = orderRows[:0]
+	paidDayExpr := dateGroupExpr(r.db, "paid_at", filter.StartAt.Location(), filter.StartAt)
+	paidQuery := r.db.Model(&models.Order{}).
+		Select(fmt.Sprintf("%s AS day, affiliate_sub_id AS sub_id, COUNT(*) AS total", paidDayExpr)).
+		Where("parent_id IS NULL AND affiliate_profile_id = ? AND paid_at IS NOT NULL AND paid_at >= ? AND paid_at < ?", filter.AffiliateProfileID, filter.StartAt, filter.EndAt)
+	if subID != "" {
+		paidQuery = paidQuery.Where("affiliate_sub_id = ?", subID)
+	}
+	paidQuery = applyAffiliateFunnelUTMFilter(paidQuery, filter, "affiliate_utm_")
+	if err := paidQuery.Group(paidDayExpr + ", affiliate_sub_id").Scan(&orderRows).Error; err != nil {
+		return nil, err
+	}
+	for _, item := range orderRows {
+		pick(item.Day, item.SubID).PaidOrders = item.Total
+	}
+
+	var commissionRows []struct {
+		Day   string          `gorm:"column:day"`
+		SubID string          `gorm:"column:sub_id"`
+		Total decimal.Decimal `gorm:"column:total"`
+	}
+	commissionDayExpr := dateGroupExpr(r.db, "affiliate_commissions.created_at", filter.StartAt.Location(), filter.StartAt)
+	commissionQuery := r.db.Model(&models.AffiliateCommission{}).
+		Select(fmt.Sprintf("%s AS day, COALESCE(o.affiliate_sub_id, '') AS sub_id, COALESCE(SUM(affiliate_commissions.commission_amount), 0) AS total", commissionDayExpr)).
+		Joins("LEFT JOIN orders o ON o.id = affiliate_commissions.order_id").
+		Where("affiliate_commissions.affiliate_profile_id = ? AND affiliate_commissions.level = ? AND affiliate_commissions.status <> ?",
+			filter.AffiliateProfileID,
+			constants.AffiliateCommissionLevelDirect,
+			constants.AffiliateCommissionStatusRejected,
+		).
+		Where("affiliate_commissions.created_at >= ? AND affiliate_commissions.created_at < ?", filter.StartAt, filter.EndAt)
+	if subID != "" {
+		commissionQuery = commissionQuery.Where("o.affiliate_sub_id = ?", subID)
+	}
+	commissionQuery = applyAffiliateFunnelUTMFilter(commissionQuery, filter, "o.affiliate_utm_")
+	if err := commissionQuery.Group(commissionDayExpr + ", o.affiliate_sub_id").Scan(&commissionRows).Error; err != nil {
+		return nil, err
+	}
+	for _, item := range commissionRows {
+		row := pick(item.Day, item.SubID)
+		row.Commission = row.Commission.Add(item.Total).Round(2)
+	}
+
+	rows := make([]AffiliateFunnelRow, 0, len(rowMap))
+	for _, row := range rowMap {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Day != rows[j].Day {
+			return rows[i].Day < rows[j].Day
+		}
+		return rows[i].SubID < rows[j].SubID
+	})
+	return rows, nil
+}
+
+// GetReferralByUserID 按被推广用户查询推广关系
+func (r *GormAffiliateRepository) GetReferralByUserID(userID uint) (*models.AffiliateReferral, error) {
+	if userID == 0 {
+		return nil, nil
+	}
+	var referral models.AffiliateReferral
+	if err := r.db.Where("user_id = ?", userID).First(&referral).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &referral, nil
+}
+
+// CreateReferral 创建推广关系
+func (r *GormAffiliateRepository) CreateReferral(referral *models.AffiliateReferral) error {
+	return r.db.Create(referral).Error
+}
+
+// CountReferralsByProfile 统计推广用户名下的被推广用户数
+func (r *GormAffiliateRepository) CountReferralsByProfile(profileID uint) (int64, error) {
+	if profileID == 0 {
+		return 0, nil
+	}
+	var total int64
+	if err := r.db.Model(&models.AffiliateReferral{}).Where("affiliate_profile_id = ?", profileID).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
 // GetCommissionByOrderAndProfile 按订单和推广人查询佣金
 func (r *GormAffiliateRepository) GetCommissionByOrderAndProfile(orderID, profileID uint, commissionType string) (*models.AffiliateCommission, error) {
 	if orderID == 0 || profileID == 0 {
@@ -297,6 +509,12 @@ func (r *GormAffiliateRepository) ListCommissions(filter AffiliateCommissionList
 	if status := strings.TrimSpace(filter.Status); status != "" {
 		query = query.Where("affiliate_commissions.status = ?", status)
 	}
+	if filter.Level > 0 {
+		query = query.Where("affiliate_commissions.level = ?", filter.Level)
+	}
+	if filter.RiskFlagged {
+		query = query.Where("affiliate_commissions.risk_flags <> ''")
+	}
 	if keyword := strings.TrimSpace(filter.Keyword); keyword != "" {
 		like := "%" + keyword + "%"
 		query = query.
@@ -324,6 +542,39 @@ func (r *GormAffiliateRepository) ListCommissions(filter AffiliateCommissionList
 	return rows, total, nil
 }
 
+// GetCommissionByID 按ID获取佣金记录（含推广用户与订单）
+func (r *GormAffiliateRepository) GetCommissionByID(id uint) (*models.AffiliateCommission, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	var row models.AffiliateCommission
+	if err := r.db.Preload("AffiliateProfile").
+		Preload("AffiliateProfile.User").
+		Preload("Order").
+		First(&row, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// GetCommissionByIDForUpdate 按ID获取并锁定佣金记录
+func (r *GormAffiliateRepository) GetCommissionByIDForUpdate(id uint) (*models.AffiliateCommission, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	var row models.AffiliateCommission
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&row, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
 // ListCommissionsByOrder 按订单查询佣金记录
 func (r *GormAffiliateRepository) ListCommissionsByOrder(orderID uint, statuses []string) ([]models.AffiliateCommission, error) {
 	if orderID == 0 {
@@ -389,6 +640,75 @@ func (r *GormAffiliateRepository) MarkPendingCommissionsAvailable(before, now ti
 	return result.RowsAffected, nil
 }
 
+// MarkProfilePendingCommissionsAvailable 将指定推广用户已到期的待确认佣金转为可提现
+func (r *GormAffiliateRepository) MarkProfilePendingCommissionsAvailable(profileID uint, before, now time.Time) (int64, error) {
+	if profileID == 0 {
+		return 0, nil
+	}
+	result := r.db.Model(&models.AffiliateCommission{}).
+		Where("affiliate_profile_id = ? AND status = ? AND confirm_at IS NOT NULL AND confirm_at <= ? AND withdraw_request_id IS NULL",
+			profileID, constants.AffiliateCommissionStatusPendingConfirm, before).
+		Updates(map[string]interface{}{
+			"status":       constants.AffiliateCommissionStatusAvailable,
+			"available_at": now,
+			"updated_at":   now,
+		})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// ListDuePendingCommissions 查询已到确认时间的待确认佣金（含订单与推广用户）
+func (r *GormAffiliateRepository) ListDuePendingCommissions(before time.Time, limit int) ([]models.AffiliateCommission, error) {
+	query := r.db.Model(&models.AffiliateCommission{}).
+		Preload("AffiliateProfile").
+		Preload("Order").
+		Where("status = ? AND confirm_at IS NOT NULL AND confirm_at <= ? AND withdraw_request_id IS NULL",
+			constants.AffiliateCommissionStatusPendingConfirm, before).
+		Order("id asc")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var rows []models.AffiliateCommission
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// MarkPendingCommissionsAvailableByIDs 将指定待确认佣金转为可提现
+func (r *GormAffiliateRepository) MarkPendingCommissionsAvailableByIDs(ids []uint, now time.Time) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := r.db.Model(&models.AffiliateCommission{}).
+		Where("id IN ? AND status = ? AND withdraw_request_id IS NULL", ids, constants.AffiliateCommissionStatusPendingConfirm).
+		Updates(map[string]interface{}{
+			"status":       constants.AffiliateCommissionStatusAvailable,
+			"available_at": now,
+			"updated_at":   now,
+		})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// FlagPendingCommissionForReview 将命中风控规则的待确认佣金转入人工复核
+func (r *GormAffiliateRepository) FlagPendingCommissionForReview(id uint, riskFlags string, now time.Time) error {
+	if id == 0 {
+		return nil
+	}
+	return r.db.Model(&models.AffiliateCommission{}).
+		Where("id = ? AND status = ?", id, constants.AffiliateCommissionStatusPendingConfirm).
+		Updates(map[string]interface{}{
+			"status":     constants.AffiliateCommissionStatusPendingReview,
+			"risk_flags": riskFlags,
+			"updated_at": now,
+		}).Error
+}
+
 // CountValidOrdersByProfile 统计有效订单数
 func (r *GormAffiliateRepository) CountValidOrdersByProfile(profileID uint) (int64, error) {
 	if profileID == 0 {
@@ -424,6 +744,23 @@ func (r *GormAffiliateRepository) SumCommissionByProfile(profileID uint, statuse
 	return row.Total.Round(2), nil
 }
 
+// SumCommissionByProfileAndLevel 按佣金层级汇总指定状态佣金金额
+func (r *GormAffiliateRepository) SumCommissionByProfileAndLevel(profileID uint, level int, statuses []string) (decimal.Decimal, error) {
+	if profileID == 0 || level <= 0 || len(statuses) == 0 {
+		return decimal.Zero, nil
+	}
+	var row struct {
+		Total decimal.Decimal `gorm:"column:total"`
+	}
+	if err := r.db.Model(&models.AffiliateCommission{}).
+		Where("affiliate_profile_id = ? AND level = ? AND status IN ?", profileID, level, statuses).
+		Select("COALESCE(SUM(commission_amount), 0) AS total").
+		Scan(&row).Error; err != nil {
+		return decimal.Zero, err
+	}
+	return row.Total.Round(2), nil
+}
+
 // ListAvailableCommissionsForUpdate 查询并锁定可提现佣金
 func (r *GormAffiliateRepository) ListAvailableCommissionsForUpdate(profileID uint) ([]models.AffiliateCommission, error) {
 	if profileID == 0 {
@@ -448,6 +785,250 @@ func (r *GormAffiliateRepository) BatchUpdateCommissions(ids []uint, updates map
 	return r.db.Model(&models.AffiliateCommission{}).Where("id IN ?", ids).Updates(updates).Error
 }
 
+// ListCommissionRules 查询返利比例规则（scopeType 为空时返回全部）
+func (r *GormAffiliateRepository) ListCommissionRules(scopeType string) ([]models.AffiliateCommissionRule, error) {
+	query := r.db.Model(&models.AffiliateCommissionRule{})
+	if scope := strings.TrimSpace(scopeType); scope != "" {
+		query = query.Where("scope_type = ?", scope)
+	}
+	var rows []models.AffiliateCommissionRule
+	if err := query.Order("scope_type asc, scope_id asc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ListCommissionRulesByScopes 按范围对象批量查询返利比例规则
+func (r *GormAffiliateRepository) ListCommissionRulesByScopes(scopeType string, scopeIDs []uint) ([]models.AffiliateCommissionRule, error) {
+	if strings.TrimSpace(scopeType) == "" || len(scopeIDs) == 0 {
+		return []models.AffiliateCommissionRule{}, nil
+	}
+	var rows []models.AffiliateCommissionRule
+	if err := r.db.Where("scope_type = ? AND scope_id IN ?", strings.TrimSpace(scopeType), scopeIDs).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetCommissionRuleByID 按ID查询返利比例规则
+func (r *GormAffiliateRepository) GetCommissionRuleByID(id uint) (*models.AffiliateCommissionRule, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	var row models.AffiliateCommissionRule
+	if err := r.db.First(&row, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// GetCommissionRuleByScope 按范围查询返利比例规则
+func (r *GormAffiliateRepository) GetCommissionRuleByScope(scopeType string, scopeID uint) (*models.AffiliateCommissionRule, error) {
+	if strings.TrimSpace(scopeType) == "" || scopeID == 0 {
+		return nil, nil
+	}
+	var row models.AffiliateCommissionRule
+	if err := r.db.Where("scope_type = ? AND scope_id = ?", strings.TrimSpace(scopeType), scopeID).
+		First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &row, nil
+}
+
+// SaveCommissionRule 保存返利比例规则
+func (r *GormAffiliateRepository) SaveCommissionRule(rule *models.AffiliateCommissionRule) error {
+	return r.db.Save(rule).Error
+}
+
+// DeleteCommissionRule 删除返利比例规则
+func (r *GormAffiliateRepository) DeleteCommissionRule(id uint) error {
+	if id == 0 {
+		return nil
+	}
+	return r.db.Delete(&models.AffiliateCommissionRule{}, id).Error
+}
+
+// ListCategoryParentIDs 查询分类的父分类ID映射
+func (r *GormAffiliateRepository) ListCategoryParentIDs(categoryIDs []uint) (map[uint]uint, error) {
+	result := make(map[uint]uint, len(categoryIDs))
+	if len(categoryIDs) == 0 {
+		return result, nil
+	}
+	var rows []models.Category
+	if err := r.db.Select("id", "parent_id").Where("id IN ?", categoryIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.ID] = row.ParentID
+	}
+	return result, nil
+}
+
+// GetUserFingerprint 汇总用户近期登录 IP、登录设备（IP 与 UA 组合）、下单 IP 与 Telegram 身份
+func (r *GormAffiliateRepository) GetUserFingerprint(userID uint, since time.Time) (AffiliateUserFingerprint, error) {
+	result := AffiliateUserFingerprint{}
+	if userID == 0 {
+		return result, nil
+	}
+	if err := r.db.Model(&models.User{}).Where("id = ?", userID).Limit(1).Pluck("email", &result.Email).Error; err != nil {
+		return result, err
+	}
+	if len(result.Email) == 0 {
+		return result, nil
+	}
+
+	var loginRows []struct {
+		ClientIP  string `gorm:"column:client_ip"`
+		UserAgent string `gorm:"column:user_agent"`
+	}
+	if err := r.db.Model(&models.UserLoginLog{}).
+		Select("client_ip, user_agent").
+		Where("user_id = ? AND status = ? AND created_at >= ?", userID, constants.LoginLogStatusSuccess, since).
+		Scan(&loginRows).Error; err != nil {
+		return result, err
+	}
+	var orderIPs []string
+	if err := r.db.Model(&models.Order{}).
+		Where("user_id = ? AND parent_id IS NULL AND created_at >= ? AND client_ip <> ''", userID, since).
+		Distinct().
+		Pluck("client_ip", &orderIPs).Error; err != nil {
+		return result, err
+	}
+	var identities []models.UserOAuthIdentity
+	if err := r.db.Where("user_id = ? AND provider = ?", userID, constants.UserOAuthProviderTelegram).
+		Find(&identities).Error; err != nil {
+		return result, err
+	}
+
+	ipSeen := make(map[string]struct{})
+	deviceSeen := make(map[string]struct{})
+	appendIP := func(ip string) {
+		ip = strings.TrimSpace(ip)
+		if ip == "" {
+			return
+		}
+		if _, ok := ipSeen[ip]; ok {
+			return
+		}
+		ipSeen[ip] = struct{}{}
+		result.IPs = append(result.IPs, ip)
+	}
+	for _, row := range loginRows {
+		appendIP(row.ClientIP)
+		ip := strings.TrimSpace(row.ClientIP)
+		agent := strings.TrimSpace(row.UserAgent)
+		if ip == "" || agent == "" {
+			continue
+		}
+		device := ip + "|" + agent
+		if _, ok := deviceSeen[device]; ok {
+			continue
+		}
+		deviceSeen[device] = struct{}{}
+		result.Devices = append(result.Devices, device)
+	}
+	for _, ip := range orderIPs {
+		appendIP(ip)
+	}
+	for _, identity := range identities {
+		if id := strings.TrimSpace(identity.ProviderUserID); id != "" {
+			result.TelegramIDs = append(result.TelegramIDs, id)
+		}
+		if username := strings.TrimSpace(identity.Username); username != "" {
+			result.TelegramUsernames = append(result.TelegramUsernames, username)
+		}
+	}
+	return result, nil
+}
+
+// GetProfileConversionStats 统计推广用户窗口期内的点击数与已支付归因订单数
+func (r *GormAffiliateRepository) GetProfileConversionStats(profileID uint, since time.Time) (int64, int64, error) {
+	if profileID == 0 {
+		return 0, 0, nil
+	}
+	var clicks int64
+	if err := r.db.Model(&models.AffiliateClick{}).
+		Where("affiliate_profile_id = ? AND created_at >= ?", profileID, since).
+		Count(&clicks).Error; err != nil {
+		return 0, 0, err
+	}
+	var orders int64
+	if err := r.db.Model(&models.Order{}).
+		Where("parent_id IS NULL AND affiliate_profile_id = ? AND paid_at IS NOT NULL AND created_at >= ?", profileID, since).
+		Count(&orders).Error; err != nil {
+		return 0, 0, err
+	}
+	return clicks, orders, nil
+}
+
+// CountRefundedOrdersByBuyer 统计同一被推广用户在窗口期内产生佣金后又退款的订单数
+func (r *GormAffiliateRepository) CountRefundedOrdersByBuyer(profileID, buyerUserID uint, since time.Time) (int64, error) {
+	if profileID == 0 || buyerUserID == 0 {
+		return 0, nil
+	}
+	var total int64
+	if err := affiliateRefundedOrderQuery(r.db, profileID, since).
+		Where("o.user_id = ?", buyerUserID).
+		Distinct("o.id").
+		Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// ListRefundingBuyersByProfile 查询窗口期内退款订单数达到阈值的被推广用户
+func (r *GormAffiliateRepository) ListRefundingBuyersByProfile(profileID uint, since time.Time, minRefunds int64) ([]AffiliateRiskBuyerRow, error) {
+	rows := make([]AffiliateRiskBuyerRow, 0)
+	if profileID == 0 {
+		return rows, nil
+	}
+	if minRefunds <= 0 {
+		minRefunds = 1
+	}
+	if err := affiliateRefundedOrderQuery(r.db, profileID, since).
+		Select("o.user_id AS user_id, COALESCE(MAX(u.email), '') AS email, COUNT(DISTINCT o.id) AS refunded_orders").
+		Joins("LEFT JOIN users u ON u.id = o.user_id").
+		Where("o.user_id > 0").
+		Group("o.user_id").
+		Having("COUNT(DISTINCT o.id) >= ?", minRefunds).
+		Order("refunded_orders DESC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ListCommissionRiskFlags 查询推广用户窗口期内佣金命中的风控规则
+func (r *GormAffiliateRepository) ListCommissionRiskFlags(profileID uint, since time.Time) ([]string, error) {
+	var flags []string
+	if profileID == 0 {
+		return flags, nil
+	}
+	if err := r.db.Model(&models.AffiliateCommission{}).
+		Where("affiliate_profile_id = ? AND created_at >= ? AND risk_flags <> ''", profileID, since).
+		Pluck("risk_flags", &flags).Error; err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+func affiliateRefundedOrderQuery(db *gorm.DB, profileID uint, since time.Time) *gorm.DB {
+	return db.Model(&models.AffiliateCommission{}).
+		Joins("JOIN orders o ON o.id = affiliate_commissions.order_id").
+		Where("affiliate_commissions.affiliate_profile_id = ? AND o.created_at >= ?", profileID, since).
+		Where("(o.status IN ? OR o.refunded_amount > 0)", []string{
+			constants.OrderStatusRefunded,
+			constants.OrderStatusPartiallyRefunded,
+		})
+}
+
 // CreateWithdraw 创建提现申请
 func (r *GormAffiliateRepository) CreateWithdraw(req *models.AffiliateWithdrawRequest) error {
 	return r.db.Create(req).Error
@@ -587,7 +1168,10 @@ func (r *GormAffiliateRepository) GetProfileStatsBatch(profileIDs []uint) (map[u
 	}
 	if err := r.db.Model(&models.AffiliateCommission{}).
 		Select("affiliate_profile_id, COALESCE(SUM(commission_amount), 0) AS total").
-		Where("affiliate_profile_id IN ? AND status = ?", profileIDs, constants.AffiliateCommissionStatusPendingConfirm).
+		Where("affiliate_profile_id IN ? AND status IN ?", profileIDs, []string{
+			constants.AffiliateCommissionStatusPendingConfirm,
+			constants.AffiliateCommissionStatusPendingReview,
+		}).
 		Group("affiliate_profile_id").
 		Scan(&pendingRows).Error; err != nil {
 		return nil, err
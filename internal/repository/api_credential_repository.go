@@ -2,6 +2,7 @@ package repository
 
 import (
 	"errors"
+	"time"
 
 	"github.com/dujiao-next/internal/models"
 
@@ -14,6 +15,9 @@ type ApiCredentialRepository interface {
 	GetByUserID(userID uint) (*models.ApiCredential, error)
 	GetAnyByUserID(userID uint) (*models.ApiCredential, error)
 	GetByApiKey(apiKey string) (*models.ApiCredential, error)
+	ListByUserID(userID uint) ([]models.ApiCredential, error)
+	CountByUserID(userID uint) (int64, error)
+	TouchLastUsed(id uint, at time.Time) error
 	Create(cred *models.ApiCredential) error
 	Update(cred *models.ApiCredential) error
 	UpdateAny(cred *models.ApiCredential) error
@@ -87,6 +91,29 @@ func (r *GormApiCredentialRepository) GetByApiKey(apiKey string) (*models.ApiCre
 	return &cred, nil
 }
 
+// ListByUserID 获取用户的全部凭证（按创建顺序）
+func (r *GormApiCredentialRepository) ListByUserID(userID uint) ([]models.ApiCredential, error) {
+	var creds []models.ApiCredential
+	if err := r.db.Where("user_id = ?", userID).Order("id asc").Find(&creds).Error; err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// CountByUserID 统计用户的凭证数量
+func (r *GormApiCredentialRepository) CountByUserID(userID uint) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.ApiCredential{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// TouchLastUsed 仅更新最后使用时间，避免覆盖并发修改的其他字段
+func (r *GormApiCredentialRepository) TouchLastUsed(id uint, at time.Time) error {
+	return r.db.Model(&models.ApiCredential{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
+
 // Create 创建凭证
 func (r *GormApiCredentialRepository) Create(cred *models.ApiCredential) error {
 	return r.db.Create(cred).Error
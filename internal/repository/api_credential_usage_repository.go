@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ApiCredentialUsageRepository API 凭证用量数据访问接口
+type ApiCredentialUsageRepository interface {
+	Increment(credentialID, userID uint, date string, delta ApiCredentialUsageDelta) error
+	GetByCredentialAndDate(credentialID uint, date string) (*models.ApiCredentialUsage, error)
+	GetByCredentialAndDateForUpdate(credentialID uint, date string) (*models.ApiCredentialUsage, error)
+	List(filter ApiCredentialUsageListFilter) ([]models.ApiCredentialUsage, int64, error)
+	Transaction(fn func(tx *gorm.DB) error) error
+	WithTx(tx *gorm.DB) ApiCredentialUsageRepository
+}
+
+// GormApiCredentialUsageRepository GORM 实现
+type GormApiCredentialUsageRepository struct {
+	db *gorm.DB
+}
+
+// NewApiCredentialUsageRepository 创建 API 凭证用量仓库
+func NewApiCredentialUsageRepository(db *gorm.DB) *GormApiCredentialUsageRepository {
+	return &GormApiCredentialUsageRepository{db: db}
+}
+
+// Transaction 执行数据库事务
+func (r *GormApiCredentialUsageRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	if fn == nil {
+		return nil
+	}
+	return r.db.Transaction(fn)
+}
+
+// WithTx 绑定事务
+func (r *GormApiCredentialUsageRepository) WithTx(tx *gorm.DB) ApiCredentialUsageRepository {
+	if tx == nil {
+		return r
+	}
+	return &GormApiCredentialUsageRepository{db: tx}
+}
+
+// Increment 累加当日用量，当日记录不存在时创建
+func (r *GormApiCredentialUsageRepository) Increment(credentialID, userID uint, date string, delta ApiCredentialUsageDelta) error {
+	for attempt := 0; attempt < 2; attempt++ {
+		result := r.db.Model(&models.ApiCredentialUsage{}).
+			Where("credential_id = ? AND usage_date = ?", credentialID, date).
+			Updates(map[string]interface{}{
+				"request_count":  gorm.Expr("request_count + ?", delta.Requests),
+				"rejected_count": gorm.Expr("rejected_count + ?", delta.Rejected),
+				"order_count":    gorm.Expr("order_count + ?", delta.Orders),
+				"order_amount":   gorm.Expr("order_amount + ?", delta.OrderAmount.Round(2)),
+				"updated_at":     time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			return nil
+		}
+		usage := &models.ApiCredentialUsage{
+			CredentialID:  credentialID,
+			UsageDate:     date,
+			UserID:        userID,
+			RequestCount:  delta.Requests,
+			RejectedCount: delta.Rejected,
+			OrderCount:    delta.Orders,
+			OrderAmount:   models.NewMoneyFromDecimal(delta.OrderAmount),
+		}
+		if err := r.db.Create(usage).Error; err == nil {
+			return nil
+		} else if attempt > 0 {
+			return err
+		}
+		// 并发请求抢先创建了当日记录，重试累加
+	}
+	return nil
+}
+
+// GetByCredentialAndDate 获取凭证某日用量
+func (r *GormApiCredentialUsageRepository) GetByCredentialAndDate(credentialID uint, date string) (*models.ApiCredentialUsage, error) {
+	var usage models.ApiCredentialUsage
+	if err := r.db.Where("credential_id = ? AND usage_date = ?", credentialID, date).First(&usage).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// GetByCredentialAndDateForUpdate 获取并锁定凭证某日用量
+func (r *GormApiCredentialUsageRepository) GetByCredentialAndDateForUpdate(credentialID uint, date string) (*models.ApiCredentialUsage, error) {
+	var usage models.ApiCredentialUsage
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("credential_id = ? AND usage_date = ?", credentialID, date).
+		First(&usage).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// List 用量列表（按日期倒序）
+func (r *GormApiCredentialUsageRepository) List(filter ApiCredentialUsageListFilter) ([]models.ApiCredentialUsage, int64, error) {
+	var usages []models.ApiCredentialUsage
+	query := r.db.Model(&models.ApiCredentialUsage{})
+	if filter.CredentialID > 0 {
+		query = query.Where("credential_id = ?", filter.CredentialID)
+	}
+	if filter.UserID > 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if from := strings.TrimSpace(filter.DateFrom); from != "" {
+		query = query.Where("usage_date >= ?", from)
+	}
+	if to := strings.TrimSpace(filter.DateTo); to != "" {
+		query = query.Where("usage_date <= ?", to)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = applyPagination(query, filter.Page, filter.PageSize)
+	if err := query.Order("usage_date DESC, credential_id ASC").Find(&usages).Error; err != nil {
+		return nil, 0, err
+	}
+	return usages, total, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dujiao-next/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CardReplenishRuleRepository 卡密自动补货规则数据访问接口
+type CardReplenishRuleRepository interface {
+	Create(rule *models.CardReplenishRule) error
+	Update(rule *models.CardReplenishRule) error
+	Delete(id uint) error
+	GetByID(id uint) (*models.CardReplenishRule, error)
+	GetBySKUID(skuID uint) (*models.CardReplenishRule, error)
+	List(filter CardReplenishRuleListFilter) ([]models.CardReplenishRule, int64, error)
+	ListActive() ([]models.CardReplenishRule, error)
+	UpdateRunState(id uint, updates map[string]interface{}) error
+}
+
+// GormCardReplenishRuleRepository GORM 实现
+type GormCardReplenishRuleRepository struct {
+	db *gorm.DB
+}
+
+// NewCardReplenishRuleRepository 创建卡密自动补货规则仓库
+func NewCardReplenishRuleRepository(db *gorm.DB) *GormCardReplenishRuleRepository {
+	return &GormCardReplenishRuleRepository{db: db}
+}
+
+// Create 创建规则
+func (r *GormCardReplenishRuleRepository) Create(rule *models.CardReplenishRule) error {
+	return r.db.Create(rule).Error
+}
+
+// Update 更新规则
+func (r *GormCardReplenishRuleRepository) Update(rule *models.CardReplenishRule) error {
+	return r.db.Omit("Product", "SKU", "Connection").Save(rule).Error
+}
+
+// Delete 删除规则
+func (r *GormCardReplenishRuleRepository) Delete(id uint) error {
+	return r.db.Delete(&models.CardReplenishRule{}, id).Error
+}
+
+// GetByID 根据 ID 获取规则
+func (r *GormCardReplenishRuleRepository) GetByID(id uint) (*models.CardReplenishRule, error) {
+	var rule models.CardReplenishRule
+	if err := r.db.First(&rule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// GetBySKUID 根据本地 SKU 获取规则
+func (r *GormCardReplenishRuleRepository) GetBySKUID(skuID uint) (*models.CardReplenishRule, error) {
+	var rule models.CardReplenishRule
+	if err := r.db.Where("sku_id = ?", skuID).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// List 规则列表
+func (r *GormCardReplenishRuleRepository) List(filter CardReplenishRuleListFilter) ([]models.CardReplenishRule, int64, error) {
+	var rules []models.CardReplenishRule
+	query := r.db.Model(&models.CardReplenishRule{})
+	if filter.ProductID > 0 {
+		query = query.Where("product_id = ?", filter.ProductID)
+	}
+	if filter.ConnectionID > 0 {
+		query = query.Where("connection_id = ?", filter.ConnectionID)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = applyPagination(query, filter.Page, filter.PageSize)
+	if err := query.Preload("Product").Preload("SKU").Preload("Connection").Order("id DESC").Find(&rules).Error; err != nil {
+		return nil, 0, err
+	}
+	return rules, total, nil
+}
+
+// ListActive 获取全部启用中的规则
+func (r *GormCardReplenishRuleRepository) ListActive() ([]models.CardReplenishRule, error) {
+	var rules []models.CardReplenishRule
+	if err := r.db.Where("is_active = ?", true).Order("id ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// UpdateRunState 更新规则的巡检状态字段
+func (r *GormCardReplenishRuleRepository) UpdateRunState(id uint, updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	if _, ok := updates["updated_at"]; !ok {
+		updates["updated_at"] = time.Now()
+	}
+	return r.db.Model(&models.CardReplenishRule{}).Where("id = ?", id).Updates(updates).Error
+}
@@ -12,6 +12,7 @@ import (
 type CardSecretBatchRepository interface {
 	Create(batch *models.CardSecretBatch) error
 	GetByID(id uint) (*models.CardSecretBatch, error)
+	ListByIDs(ids []uint) ([]models.CardSecretBatch, error)
 	ListByProduct(productID, skuID uint, page, pageSize int) ([]models.CardSecretBatch, int64, error)
 	DeleteByProduct(productID uint) error
 	WithTx(tx *gorm.DB) *GormCardSecretBatchRepository
@@ -58,6 +59,18 @@ func (r *GormCardSecretBatchRepository) GetByID(id uint) (*models.CardSecretBatc
 	return &batch, nil
 }
 
+// ListByIDs 按 ID 列表获取批次
+func (r *GormCardSecretBatchRepository) ListByIDs(ids []uint) ([]models.CardSecretBatch, error) {
+	if len(ids) == 0 {
+		return []models.CardSecretBatch{}, nil
+	}
+	var items []models.CardSecretBatch
+	if err := r.db.Where("id IN ?", ids).Order("id desc").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 // ListByProduct 按商品获取批次列表
 func (r *GormCardSecretBatchRepository) ListByProduct(productID, skuID uint, page, pageSize int) ([]models.CardSecretBatch, int64, error) {
 	if productID == 0 {
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"github.com/dujiao-next/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CardSecretReplacementRepository 问题卡密补发记录数据访问接口
+type CardSecretReplacementRepository interface {
+	CreateBatch(items []models.CardSecretReplacement) error
+	ListByOrderIDs(orderIDs []uint) ([]models.CardSecretReplacement, error)
+	WithTx(tx *gorm.DB) *GormCardSecretReplacementRepository
+}
+
+// GormCardSecretReplacementRepository GORM 实现
+type GormCardSecretReplacementRepository struct {
+	db *gorm.DB
+}
+
+// NewCardSecretReplacementRepository 创建问题卡密补发记录仓库
+func NewCardSecretReplacementRepository(db *gorm.DB) *GormCardSecretReplacementRepository {
+	return &GormCardSecretReplacementRepository{db: db}
+}
+
+// WithTx 绑定事务
+func (r *GormCardSecretReplacementRepository) WithTx(tx *gorm.DB) *GormCardSecretReplacementRepository {
+	if tx == nil {
+		return r
+	}
+	return &GormCardSecretReplacementRepository{db: tx}
+}
+
+// CreateBatch 批量创建补发记录
+func (r *GormCardSecretReplacementRepository) CreateBatch(items []models.CardSecretReplacement) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return r.db.Create(&items).Error
+}
+
+// ListByOrderIDs 按订单批量获取补发记录（含问题卡密与补发卡密）
+func (r *GormCardSecretReplacementRepository) ListByOrderIDs(orderIDs []uint) ([]models.CardSecretReplacement, error) {
+	if len(orderIDs) == 0 {
+		return []models.CardSecretReplacement{}, nil
+	}
+	var items []models.CardSecretReplacement
+	err := r.db.Where("order_id IN ?", orderIDs).
+		Preload("DefectiveSecret", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
+		Preload("ReplacementSecret", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
+		Order("id asc").
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -50,6 +50,8 @@ type CardSecretRepository interface {
 	Reserve(ids []uint, orderID uint, reservedAt time.Time) (int64, error)
 	ReleaseByOrder(orderID uint) (int64, error)
 	MarkUsed(ids []uint, orderID uint, usedAt time.Time) (int64, error)
+	MarkDefective(ids []uint, orderID uint, markedAt time.Time) (int64, error)
+	ListDefectiveBatchIDs(productID, skuID uint) ([]uint, error)
 	DeleteByProduct(productID uint) error
 	Transaction(fn func(tx *gorm.DB) error) error
 	WithTx(tx *gorm.DB) *GormCardSecretRepository
@@ -200,6 +202,23 @@ func (r *GormCardSecretRepository) ListByOrderAndStatus(orderID uint, status str
 	return items, nil
 }
 
+// ListDefectiveBatchIDs 查询存在问题卡密的批次 ID
+func (r *GormCardSecretRepository) ListDefectiveBatchIDs(productID, skuID uint) ([]uint, error) {
+	query := r.db.Model(&models.CardSecret{}).
+		Where("status = ? AND batch_id IS NOT NULL", models.CardSecretStatusDefective)
+	if productID > 0 {
+		query = query.Where("product_id = ?", productID)
+	}
+	if skuID > 0 {
+		query = query.Where("sku_id = ?", skuID)
+	}
+	var ids []uint
+	if err := query.Distinct("batch_id").Order("batch_id desc").Pluck("batch_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
 // GetByID 根据 ID 获取卡密
 func (r *GormCardSecretRepository) GetByID(id uint) (*models.CardSecret, error) {
 	var secret models.CardSecret
@@ -415,3 +434,17 @@ func (r *GormCardSecretRepository) MarkUsed(ids []uint, orderID uint, usedAt tim
 		})
 	return result.RowsAffected, result.Error
 }
+
+// MarkDefective 将已交付给订单的卡密标记为问题卡密
+func (r *GormCardSecretRepository) MarkDefective(ids []uint, orderID uint, markedAt time.Time) (int64, error) {
+	if len(ids) == 0 || orderID == 0 {
+		return 0, nil
+	}
+	result := r.db.Model(&models.CardSecret{}).
+		Where("id IN ? AND status = ? AND order_id = ?", ids, models.CardSecretStatusUsed, orderID).
+		Updates(map[string]interface{}{
+			"status":     models.CardSecretStatusDefective,
+			"updated_at": markedAt,
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dujiao-next/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CatalogWebhookRepository 商品目录 Webhook 数据访问接口
+type CatalogWebhookRepository interface {
+	GetByCredentialID(credentialID uint) (*models.CatalogWebhook, error)
+	Save(webhook *models.CatalogWebhook) error
+	DeleteByCredentialID(credentialID uint) error
+	ListActive() ([]models.CatalogWebhook, error)
+	UpdateDelivery(id uint, updates map[string]interface{}) error
+}
+
+// GormCatalogWebhookRepository GORM 实现
+type GormCatalogWebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewCatalogWebhookRepository 创建商品目录 Webhook 仓库
+func NewCatalogWebhookRepository(db *gorm.DB) *GormCatalogWebhookRepository {
+	return &GormCatalogWebhookRepository{db: db}
+}
+
+// GetByCredentialID 根据 API 凭证获取 Webhook
+func (r *GormCatalogWebhookRepository) GetByCredentialID(credentialID uint) (*models.CatalogWebhook, error) {
+	var webhook models.CatalogWebhook
+	if err := r.db.Where("api_credential_id = ?", credentialID).First(&webhook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// Save 创建或更新 Webhook
+func (r *GormCatalogWebhookRepository) Save(webhook *models.CatalogWebhook) error {
+	return r.db.Save(webhook).Error
+}
+
+// DeleteByCredentialID 删除凭证的 Webhook
+func (r *GormCatalogWebhookRepository) DeleteByCredentialID(credentialID uint) error {
+	return r.db.Where("api_credential_id = ?", credentialID).Delete(&models.CatalogWebhook{}).Error
+}
+
+// ListActive 获取全部启用的 Webhook
+func (r *GormCatalogWebhookRepository) ListActive() ([]models.CatalogWebhook, error) {
+	var webhooks []models.CatalogWebhook
+	if err := r.db.Where("is_active = ?", true).Order("id ASC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// UpdateDelivery 更新推送结果字段
+func (r *GormCatalogWebhookRepository) UpdateDelivery(id uint, updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	if _, ok := updates["updated_at"]; !ok {
+		updates["updated_at"] = time.Now()
+	}
+	return r.db.Model(&models.CatalogWebhook{}).Where("id = ?", id).Updates(updates).Error
+}
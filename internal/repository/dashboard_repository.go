@@ -24,7 +24,7 @@ type DashboardRepository interface {
 	GetInventoryAlertItems(lowStockThreshold int64) ([]DashboardInventoryAlertRow, error)
 	GetTopProducts(startAt, endAt time.Time, limit int) ([]DashboardProductRankingRow, error)
 	GetTopChannels(startAt, endAt time.Time, limit int) ([]DashboardChannelRankingRow, error)
-	GetTotalUserBalance() (float64, error)
+	GetTotalUserBalance(currency string) (float64, error)
 }
 
 // DashboardOverviewRow 仪表盘总览原始统计结果
@@ -812,10 +812,11 @@ func (r *GormDashboardRepository) GetTopChannels(startAt, endAt time.Time, limit
 	return rows, nil
 }
 
-// GetTotalUserBalance 获取全站用户余额总数
-func (r *GormDashboardRepository) GetTotalUserBalance() (float64, error) {
+// GetTotalUserBalance 获取全站用户指定币种余额总数
+func (r *GormDashboardRepository) GetTotalUserBalance(currency string) (float64, error) {
 	var total float64
 	if err := r.db.Model(&models.WalletAccount{}).
+		Where("currency = ?", currency).
 		Select("COALESCE(SUM(balance), 0)").
 		Scan(&total).Error; err != nil {
 		return 0, err
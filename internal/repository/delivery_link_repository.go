@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dujiao-next/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DeliveryLinkRepository 安全交付链接数据访问接口
+type DeliveryLinkRepository interface {
+	Create(link *models.DeliveryLink) error
+	GetByOrderID(orderID uint) (*models.DeliveryLink, error)
+	GetByToken(token string) (*models.DeliveryLink, error)
+	IncrementView(id uint, viewedAt time.Time) (bool, error)
+	MarkReceived(id uint, receivedAt time.Time) error
+	CreateLog(log *models.DeliveryLinkLog) error
+	ListByOrderIDs(orderIDs []uint) ([]models.DeliveryLink, error)
+}
+
+// GormDeliveryLinkRepository GORM 实现
+type GormDeliveryLinkRepository struct {
+	db *gorm.DB
+}
+
+// NewDeliveryLinkRepository 创建安全交付链接仓库
+func NewDeliveryLinkRepository(db *gorm.DB) *GormDeliveryLinkRepository {
+	return &GormDeliveryLinkRepository{db: db}
+}
+
+// Create 创建链接
+func (r *GormDeliveryLinkRepository) Create(link *models.DeliveryLink) error {
+	return r.db.Create(link).Error
+}
+
+// GetByOrderID 根据订单 ID 获取链接
+func (r *GormDeliveryLinkRepository) GetByOrderID(orderID uint) (*models.DeliveryLink, error) {
+	var link models.DeliveryLink
+	if err := r.db.Where("order_id = ?", orderID).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+// GetByToken 根据令牌获取链接
+func (r *GormDeliveryLinkRepository) GetByToken(token string) (*models.DeliveryLink, error) {
+	var link models.DeliveryLink
+	if err := r.db.Where("token = ?", token).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+// IncrementView 原子递增查看次数，次数耗尽时返回 false
+func (r *GormDeliveryLinkRepository) IncrementView(id uint, viewedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.DeliveryLink{}).
+		Where("id = ? AND view_count < max_views", id).
+		Updates(map[string]interface{}{
+			"view_count":     gorm.Expr("view_count + 1"),
+			"last_viewed_at": viewedAt,
+			"updated_at":     viewedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// MarkReceived 标记买家已确认收货（仅首次生效）
+func (r *GormDeliveryLinkRepository) MarkReceived(id uint, receivedAt time.Time) error {
+	return r.db.Model(&models.DeliveryLink{}).
+		Where("id = ? AND received_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"received_at": receivedAt,
+			"updated_at":  receivedAt,
+		}).Error
+}
+
+// CreateLog 写入访问记录
+func (r *GormDeliveryLinkRepository) CreateLog(log *models.DeliveryLinkLog) error {
+	return r.db.Create(log).Error
+}
+
+// ListByOrderIDs 按订单批量获取链接（含访问记录）
+func (r *GormDeliveryLinkRepository) ListByOrderIDs(orderIDs []uint) ([]models.DeliveryLink, error) {
+	if len(orderIDs) == 0 {
+		return []models.DeliveryLink{}, nil
+	}
+	var links []models.DeliveryLink
+	err := r.db.Where("order_id IN ?", orderIDs).
+		Preload("Logs", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id asc")
+		}).
+		Order("id asc").
+		Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
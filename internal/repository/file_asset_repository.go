@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// FileAssetRepository 私有文件与下载授权数据访问接口
+type FileAssetRepository interface {
+	Create(asset *models.FileAsset) error
+	GetByID(id uint) (*models.FileAsset, error)
+	List(filter FileAssetListFilter) ([]models.FileAsset, int64, error)
+	Delete(id uint) error
+	CountSKUReferences(id uint) (int64, error)
+	CreateGrants(grants []models.FileDownloadGrant) error
+	ListGrantsByOrderIDs(orderIDs []uint) ([]models.FileDownloadGrant, error)
+	GetGrantByToken(token string) (*models.FileDownloadGrant, error)
+	IncrementDownload(id uint, downloadedAt time.Time) (bool, error)
+	AddResumeBytes(id uint, bytes, limit int64, since time.Time) (bool, error)
+	CreateLog(log *models.FileDownloadLog) error
+	WithTx(tx *gorm.DB) *GormFileAssetRepository
+}
+
+// GormFileAssetRepository GORM 实现
+type GormFileAssetRepository struct {
+	db *gorm.DB
+}
+
+// NewFileAssetRepository 创建私有文件仓库
+func NewFileAssetRepository(db *gorm.DB) *GormFileAssetRepository {
+	return &GormFileAssetRepository{db: db}
+}
+
+// WithTx 绑定事务
+func (r *GormFileAssetRepository) WithTx(tx *gorm.DB) *GormFileAssetRepository {
+	if tx == nil {
+		return r
+	}
+	return &GormFileAssetRepository{db: tx}
+}
+
+// Create 创建文件记录
+func (r *GormFileAssetRepository) Create(asset *models.FileAsset) error {
+	return r.db.Create(asset).Error
+}
+
+// GetByID 根据 ID 获取文件
+func (r *GormFileAssetRepository) GetByID(id uint) (*models.FileAsset, error) {
+	var asset models.FileAsset
+	if err := r.db.First(&asset, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// List 文件列表
+func (r *GormFileAssetRepository) List(filter FileAssetListFilter) ([]models.FileAsset, int64, error) {
+	var items []models.FileAsset
+	query := r.db.Model(&models.FileAsset{})
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		query = query.Where("original_name "+determineLikeOp(r.db)+" ?", "%"+search+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = applyPagination(query, filter.Page, filter.PageSize)
+	if err := query.Order("id DESC").Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// Delete 软删除文件记录
+func (r *GormFileAssetRepository) Delete(id uint) error {
+	return r.db.Delete(&models.FileAsset{}, id).Error
+}
+
+// CountSKUReferences 统计引用该文件的 SKU 数量
+func (r *GormFileAssetRepository) CountSKUReferences(id uint) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.ProductSKU{}).Where("file_asset_id = ?", id).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// CreateGrants 批量创建下载授权
+func (r *GormFileAssetRepository) CreateGrants(grants []models.FileDownloadGrant) error {
+	if len(grants) == 0 {
+		return nil
+	}
+	return r.db.Create(&grants).Error
+}
+
+// ListGrantsByOrderIDs 按订单批量获取下载授权（含文件与下载记录）
+func (r *GormFileAssetRepository) ListGrantsByOrderIDs(orderIDs []uint) ([]models.FileDownloadGrant, error) {
+	if len(orderIDs) == 0 {
+		return []models.FileDownloadGrant{}, nil
+	}
+	var grants []models.FileDownloadGrant
+	err := r.db.Where("order_id IN ?", orderIDs).
+		Preload("FileAsset", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
+		Preload("Logs", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id asc")
+		}).
+		Order("id asc").
+		Find(&grants).Error
+	if err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// GetGrantByToken 根据令牌获取下载授权（含文件）
+func (r *GormFileAssetRepository) GetGrantByToken(token string) (*models.FileDownloadGrant, error) {
+	var grant models.FileDownloadGrant
+	err := r.db.Where("token = ?", token).
+		Preload("FileAsset", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
+		First(&grant).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// IncrementDownload 原子递增下载次数并重置续传字节数，次数耗尽时返回 false
+func (r *GormFileAssetRepository) IncrementDownload(id uint, downloadedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.FileDownloadGrant{}).
+		Where("id = ? AND (max_downloads <= 0 OR download_count < max_downloads)", id).
+		Updates(map[string]interface{}{
+			"download_count":   gorm.Expr("download_count + 1"),
+			"last_download_at": downloadedAt,
+			"resume_bytes":     0,
+			"updated_at":       downloadedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// AddResumeBytes 原子累加续传分段字节数：要求 since 之后有过计次下载且累计不超过 limit，否则返回 false
+func (r *GormFileAssetRepository) AddResumeBytes(id uint, bytes, limit int64, since time.Time) (bool, error) {
+	result := r.db.Model(&models.FileDownloadGrant{}).
+		Where("id = ? AND download_count > 0 AND last_download_at >= ? AND resume_bytes + ? <= ?", id, since, bytes, limit).
+		Update("resume_bytes", gorm.Expr("resume_bytes + ?", bytes))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CreateLog 写入下载记录
+func (r *GormFileAssetRepository) CreateLog(log *models.FileDownloadLog) error {
+	return r.db.Create(log).Error
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dujiao-next/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// FulfillmentWebhookRepository Webhook 交付任务数据访问接口
+type FulfillmentWebhookRepository interface {
+	Create(job *models.FulfillmentWebhookJob) error
+	Update(job *models.FulfillmentWebhookJob) error
+	GetByOrderID(orderID uint) (*models.FulfillmentWebhookJob, error)
+	CreateAttempt(attempt *models.FulfillmentWebhookAttempt) error
+	ListByOrderIDs(orderIDs []uint) ([]models.FulfillmentWebhookJob, error)
+	Claim(id uint, statuses []string, now, until time.Time) (bool, error)
+	ReleaseClaim(id uint) error
+}
+
+// GormFulfillmentWebhookRepository GORM 实现
+type GormFulfillmentWebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewFulfillmentWebhookRepository 创建 Webhook 交付任务仓库
+func NewFulfillmentWebhookRepository(db *gorm.DB) *GormFulfillmentWebhookRepository {
+	return &GormFulfillmentWebhookRepository{db: db}
+}
+
+// Create 创建任务
+func (r *GormFulfillmentWebhookRepository) Create(job *models.FulfillmentWebhookJob) error {
+	return r.db.Create(job).Error
+}
+
+// Update 更新任务
+func (r *GormFulfillmentWebhookRepository) Update(job *models.FulfillmentWebhookJob) error {
+	return r.db.Omit("Attempts").Save(job).Error
+}
+
+// GetByOrderID 根据订单 ID 获取任务
+func (r *GormFulfillmentWebhookRepository) GetByOrderID(orderID uint) (*models.FulfillmentWebhookJob, error) {
+	var job models.FulfillmentWebhookJob
+	if err := r.db.Where("order_id = ?", orderID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CreateAttempt 写入尝试记录
+func (r *GormFulfillmentWebhookRepository) CreateAttempt(attempt *models.FulfillmentWebhookAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+// ListByOrderIDs 按订单批量获取任务（含尝试记录）
+func (r *GormFulfillmentWebhookRepository) ListByOrderIDs(orderIDs []uint) ([]models.FulfillmentWebhookJob, error) {
+	if len(orderIDs) == 0 {
+		return []models.FulfillmentWebhookJob{}, nil
+	}
+	var jobs []models.FulfillmentWebhookJob
+	err := r.db.Where("order_id IN ?", orderIDs).
+		Preload("Attempts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id asc")
+		}).
+		Order("id asc").
+		Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// Claim 任务处于指定状态且未被占用时占用至 until，返回是否占用成功
+func (r *GormFulfillmentWebhookRepository) Claim(id uint, statuses []string, now, until time.Time) (bool, error) {
+	result := r.db.Model(&models.FulfillmentWebhookJob{}).
+		Where("id = ? AND status IN ? AND (locked_until IS NULL OR locked_until < ?)", id, statuses, now).
+		Update("locked_until", until)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ReleaseClaim 释放任务占用
+func (r *GormFulfillmentWebhookRepository) ReleaseClaim(id uint) error {
+	return r.db.Model(&models.FulfillmentWebhookJob{}).Where("id = ?", id).Update("locked_until", nil).Error
+}
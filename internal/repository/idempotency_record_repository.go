@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dujiao-next/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// IdempotencyRecordRepository 幂等键记录数据访问接口
+type IdempotencyRecordRepository interface {
+	Create(record *models.IdempotencyRecord) error
+	GetByScopeAndKey(scope, key string) (*models.IdempotencyRecord, error)
+	Update(id uint, updates map[string]interface{}) error
+	Delete(id uint) error
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+// GormIdempotencyRecordRepository GORM 实现
+type GormIdempotencyRecordRepository struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRecordRepository 创建幂等键记录仓库
+func NewIdempotencyRecordRepository(db *gorm.DB) *GormIdempotencyRecordRepository {
+	return &GormIdempotencyRecordRepository{db: db}
+}
+
+// Create 创建记录（作用域 + 幂等键唯一，并发插入时仅一条成功）
+func (r *GormIdempotencyRecordRepository) Create(record *models.IdempotencyRecord) error {
+	return r.db.Create(record).Error
+}
+
+// GetByScopeAndKey 按作用域与幂等键查询
+func (r *GormIdempotencyRecordRepository) GetByScopeAndKey(scope, key string) (*models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	if err := r.db.Where("scope = ? AND idempotency_key = ?", scope, key).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Update 更新记录
+func (r *GormIdempotencyRecordRepository) Update(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.IdempotencyRecord{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// Delete 删除记录
+func (r *GormIdempotencyRecordRepository) Delete(id uint) error {
+	return r.db.Delete(&models.IdempotencyRecord{}, id).Error
+}
+
+// DeleteExpired 清理已过期的记录
+func (r *GormIdempotencyRecordRepository) DeleteExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", now).Delete(&models.IdempotencyRecord{})
+	return result.RowsAffected, result.Error
+}
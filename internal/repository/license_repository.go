@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dujiao-next/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LicenseRepository 授权码数据访问接口
+type LicenseRepository interface {
+	GetSigningKey(productID uint) (*models.LicenseSigningKey, error)
+	SaveSigningKey(key *models.LicenseSigningKey) error
+	CreateBatch(licenses []models.License) error
+	GetByID(id uint) (*models.License, error)
+	GetByIDForUpdate(id uint) (*models.License, error)
+	GetBySerial(serial string) (*models.License, error)
+	ListByOrderIDs(orderIDs []uint) ([]models.License, error)
+	Update(license *models.License) error
+	GetActivation(licenseID uint, machineID string) (*models.LicenseActivation, error)
+	CountActivations(licenseID uint) (int64, error)
+	CreateActivation(activation *models.LicenseActivation) error
+	TouchActivation(id uint, at time.Time) error
+	DeleteActivation(licenseID uint, machineID string) (bool, error)
+	Transaction(fn func(tx *gorm.DB) error) error
+	WithTx(tx *gorm.DB) LicenseRepository
+}
+
+// GormLicenseRepository GORM 实现
+type GormLicenseRepository struct {
+	db *gorm.DB
+}
+
+// NewLicenseRepository 创建授权码仓库
+func NewLicenseRepository(db *gorm.DB) *GormLicenseRepository {
+	return &GormLicenseRepository{db: db}
+}
+
+// GetSigningKey 获取商品签名密钥
+func (r *GormLicenseRepository) GetSigningKey(productID uint) (*models.LicenseSigningKey, error) {
+	var key models.LicenseSigningKey
+	if err := r.db.Where("product_id = ?", productID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// SaveSigningKey 创建或更新商品签名密钥
+func (r *GormLicenseRepository) SaveSigningKey(key *models.LicenseSigningKey) error {
+	return r.db.Save(key).Error
+}
+
+// CreateBatch 批量创建授权码
+func (r *GormLicenseRepository) CreateBatch(licenses []models.License) error {
+	if len(licenses) == 0 {
+		return nil
+	}
+	return r.db.Create(&licenses).Error
+}
+
+// GetByID 根据 ID 获取授权码
+func (r *GormLicenseRepository) GetByID(id uint) (*models.License, error) {
+	var license models.License
+	if err := r.db.First(&license, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &license, nil
+}
+
+// GetByIDForUpdate 根据 ID 加锁获取授权码
+func (r *GormLicenseRepository) GetByIDForUpdate(id uint) (*models.License, error) {
+	var license models.License
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&license, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &license, nil
+}
+
+// GetBySerial 根据序列号获取授权码
+func (r *GormLicenseRepository) GetBySerial(serial string) (*models.License, error) {
+	var license models.License
+	if err := r.db.Where("serial = ?", serial).First(&license).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &license, nil
+}
+
+// ListByOrderIDs 按订单批量获取授权码（含激活记录）
+func (r *GormLicenseRepository) ListByOrderIDs(orderIDs []uint) ([]models.License, error) {
+	if len(orderIDs) == 0 {
+		return []models.License{}, nil
+	}
+	var licenses []models.License
+	err := r.db.Where("order_id IN ?", orderIDs).
+		Preload("Activations", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id asc")
+		}).
+		Order("id asc").
+		Find(&licenses).Error
+	if err != nil {
+		return nil, err
+	}
+	return licenses, nil
+}
+
+// Update 更新授权码
+func (r *GormLicenseRepository) Update(license *models.License) error {
+	return r.db.Omit("Activations").Save(license).Error
+}
+
+// GetActivation 获取设备激活记录
+func (r *GormLicenseRepository) GetActivation(licenseID uint, machineID string) (*models.LicenseActivation, error) {
+	var activation models.LicenseActivation
+	if err := r.db.Where("license_id = ? AND machine_id = ?", licenseID, machineID).First(&activation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &activation, nil
+}
+
+// CountActivations 统计授权码已激活设备数
+func (r *GormLicenseRepository) CountActivations(licenseID uint) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.LicenseActivation{}).Where("license_id = ?", licenseID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// CreateActivation 创建设备激活记录
+func (r *GormLicenseRepository) CreateActivation(activation *models.LicenseActivation) error {
+	return r.db.Create(activation).Error
+}
+
+// TouchActivation 刷新设备最近校验时间
+func (r *GormLicenseRepository) TouchActivation(id uint, at time.Time) error {
+	return r.db.Model(&models.LicenseActivation{}).Where("id = ?", id).Update("last_seen_at", at).Error
+}
+
+// DeleteActivation 解除设备激活，返回是否存在记录
+func (r *GormLicenseRepository) DeleteActivation(licenseID uint, machineID string) (bool, error) {
+	result := r.db.Where("license_id = ? AND machine_id = ?", licenseID, machineID).Delete(&models.LicenseActivation{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Transaction 执行数据库事务
+func (r *GormLicenseRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	if fn == nil {
+		return nil
+	}
+	return r.db.Transaction(fn)
+}
+
+// WithTx 绑定事务
+func (r *GormLicenseRepository) WithTx(tx *gorm.DB) LicenseRepository {
+	if tx == nil {
+		return r
+	}
+	return &GormLicenseRepository{db: tx}
+}
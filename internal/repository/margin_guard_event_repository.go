@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"github.com/dujiao-next/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MarginGuardEventRepository 利润保护记录数据访问接口
+type MarginGuardEventRepository interface {
+	Create(event *models.MarginGuardEvent) error
+	List(filter MarginGuardEventListFilter) ([]models.MarginGuardEvent, int64, error)
+}
+
+// GormMarginGuardEventRepository GORM 实现
+type GormMarginGuardEventRepository struct {
+	db *gorm.DB
+}
+
+// NewMarginGuardEventRepository 创建利润保护记录仓库
+func NewMarginGuardEventRepository(db *gorm.DB) *GormMarginGuardEventRepository {
+	return &GormMarginGuardEventRepository{db: db}
+}
+
+// Create 创建记录
+func (r *GormMarginGuardEventRepository) Create(event *models.MarginGuardEvent) error {
+	return r.db.Create(event).Error
+}
+
+// List 记录列表（按时间倒序）
+func (r *GormMarginGuardEventRepository) List(filter MarginGuardEventListFilter) ([]models.MarginGuardEvent, int64, error) {
+	var events []models.MarginGuardEvent
+	query := r.db.Model(&models.MarginGuardEvent{})
+	if filter.ConnectionID > 0 {
+		query = query.Where("connection_id = ?", filter.ConnectionID)
+	}
+	if filter.LocalProductID > 0 {
+		query = query.Where("local_product_id = ?", filter.LocalProductID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = applyPagination(query, filter.Page, filter.PageSize)
+	if err := query.Order("id DESC").Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
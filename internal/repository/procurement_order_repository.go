@@ -4,6 +4,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/dujiao-next/internal/constants"
 	"github.com/dujiao-next/internal/models"
 
 	"gorm.io/gorm"
@@ -23,6 +24,8 @@ type ProcurementOrderRepository interface {
 	ListRetriable(now time.Time, limit int) ([]models.ProcurementOrder, error)
 	ListByLocalOrderIDs(localOrderIDs []uint) ([]models.ProcurementOrder, error)
 	ListByConnectionAndTimeRange(connectionID uint, start, end time.Time) ([]models.ProcurementOrder, error)
+	CountOpenReplenishOrders(ruleID uint) (int64, error)
+	ListReplenishOrdersSince(ruleID uint, since time.Time) ([]models.ProcurementOrder, error)
 	Transaction(fn func(tx *gorm.DB) error) error
 	WithTx(tx *gorm.DB) *GormProcurementOrderRepository
 }
@@ -238,3 +241,28 @@ func (r *GormProcurementOrderRepository) ListByConnectionAndTimeRange(connection
 	}
 	return orders, nil
 }
+
+// CountOpenReplenishOrders 统计补货规则下尚未结束的采购单数量（待提交/已接单）
+func (r *GormProcurementOrderRepository) CountOpenReplenishOrders(ruleID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ProcurementOrder{}).
+		Where("replenish_rule_id = ? AND status IN ?", ruleID, []string{
+			constants.ProcurementStatusPending,
+			constants.ProcurementStatusSubmitted,
+			constants.ProcurementStatusAccepted,
+		}).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ListReplenishOrdersSince 查询补货规则在指定时间之后创建的采购单
+func (r *GormProcurementOrderRepository) ListReplenishOrdersSince(ruleID uint, since time.Time) ([]models.ProcurementOrder, error) {
+	var orders []models.ProcurementOrder
+	if err := r.db.Where("replenish_rule_id = ? AND created_at >= ?", ruleID, since).Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
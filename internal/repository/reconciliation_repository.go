@@ -24,6 +24,7 @@ type ReconciliationItemRepository interface {
 // ReconciliationJobListFilter 对账任务列表过滤
 type ReconciliationJobListFilter struct {
 	Pagination
+	Source       string `form:"source"`
 	ConnectionID uint   `form:"connection_id"`
 	Status       string `form:"status"`
 	Type         string `form:"type"`
@@ -60,6 +61,9 @@ func (r *GormReconciliationJobRepository) List(filter ReconciliationJobListFilte
 	var total int64
 
 	query := r.db.Model(&models.ReconciliationJob{})
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
 	if filter.ConnectionID > 0 {
 		query = query.Where("connection_id = ?", filter.ConnectionID)
 	}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/dujiao-next/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ResellerPriceListRepository 分销价目表数据访问接口
+type ResellerPriceListRepository interface {
+	GetByID(id uint) (*models.ResellerPriceList, error)
+	Create(list *models.ResellerPriceList) error
+	Update(list *models.ResellerPriceList) error
+	Delete(id uint) error
+	List(filter ResellerPriceListListFilter) ([]models.ResellerPriceList, int64, error)
+	ListItems(listID uint) ([]models.ResellerPriceListItem, error)
+	ReplaceItems(listID uint, items []models.ResellerPriceListItem) error
+	AssignCredential(credentialID uint, listID *uint) error
+	AssignUser(userID uint, listID *uint) error
+	MarginReport(filter ResellerMarginFilter) ([]ResellerMarginRow, error)
+}
+
+// GormResellerPriceListRepository GORM 实现
+type GormResellerPriceListRepository struct {
+	db *gorm.DB
+}
+
+// NewResellerPriceListRepository 创建分销价目表仓库
+func NewResellerPriceListRepository(db *gorm.DB) *GormResellerPriceListRepository {
+	return &GormResellerPriceListRepository{db: db}
+}
+
+// GetByID 根据 ID 获取
+func (r *GormResellerPriceListRepository) GetByID(id uint) (*models.ResellerPriceList, error) {
+	var list models.ResellerPriceList
+	if err := r.db.First(&list, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Create 创建价目表
+func (r *GormResellerPriceListRepository) Create(list *models.ResellerPriceList) error {
+	return r.db.Create(list).Error
+}
+
+// Update 更新价目表
+func (r *GormResellerPriceListRepository) Update(list *models.ResellerPriceList) error {
+	return r.db.Save(list).Error
+}
+
+// Delete 删除价目表及其条目，并解除凭证与用户的分配（订单上的价目表快照保留）
+func (r *GormResellerPriceListRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("price_list_id = ?", id).Delete(&models.ResellerPriceListItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ApiCredential{}).Where("price_list_id = ?", id).Update("price_list_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("price_list_id = ?", id).Update("price_list_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.ResellerPriceList{}, id).Error
+	})
+}
+
+// List 价目表列表
+func (r *GormResellerPriceListRepository) List(filter ResellerPriceListListFilter) ([]models.ResellerPriceList, int64, error) {
+	var lists []models.ResellerPriceList
+	query := r.db.Model(&models.ResellerPriceList{})
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		query = query.Where("name LIKE ?", "%"+search+"%")
+	}
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = applyPagination(query, filter.Page, filter.PageSize)
+	if err := query.Order("id DESC").Find(&lists).Error; err != nil {
+		return nil, 0, err
+	}
+	return lists, total, nil
+}
+
+// ListItems 获取价目表的全部条目
+func (r *GormResellerPriceListRepository) ListItems(listID uint) ([]models.ResellerPriceListItem, error) {
+	var items []models.ResellerPriceListItem
+	if err := r.db.Where("price_list_id = ?", listID).Order("product_id asc, sku_id asc").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// ReplaceItems 以整表覆盖方式保存价目表条目
+func (r *GormResellerPriceListRepository) ReplaceItems(listID uint, items []models.ResellerPriceListItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("price_list_id = ?", listID).Delete(&models.ResellerPriceListItem{}).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		for i := range items {
+			items[i].ID = 0
+			items[i].PriceListID = listID
+		}
+		return tx.CreateInBatches(items, 200).Error
+	})
+}
+
+// AssignCredential 设置 API 凭证的价目表，listID 为 nil 时取消分配
+func (r *GormResellerPriceListRepository) AssignCredential(credentialID uint, listID *uint) error {
+	return r.db.Model(&models.ApiCredential{}).Where("id = ?", credentialID).Update("price_list_id", listID).Error
+}
+
+// AssignUser 设置用户的价目表，listID 为 nil 时取消分配
+func (r *GormResellerPriceListRepository) AssignUser(userID uint, listID *uint) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("price_list_id", listID).Error
+}
+
+// MarginReport 按用户与价目表统计已支付订单的销售额与成本。
+// 订单项挂在子订单上，订单数按父订单去重；未录入成本价的订单项单独统计销售额。
+func (r *GormResellerPriceListRepository) MarginReport(filter ResellerMarginFilter) ([]ResellerMarginRow, error) {
+	query := r.db.Model(&models.OrderItem{}).
+		Select(`
+			orders.user_id as user_id,
+			orders.price_list_id as price_list_id,
+			COUNT(DISTINCT COALESCE(orders.parent_id, orders.id)) as order_count,
+			COALESCE(SUM(order_items.quantity), 0) as quantity,
+			COALESCE(SUM(order_items.total_price - order_items.coupon_discount), 0) as revenue,
+			COALESCE(SUM(CASE WHEN order_items.cost_price > 0 THEN order_items.cost_price * order_items.quantity ELSE 0 END), 0) as cost,
+			COALESCE(SUM(CASE WHEN order_items.cost_price > 0 THEN 0 ELSE order_items.total_price - order_items.coupon_discount END), 0) as uncosted_revenue
+		`).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.price_list_id IS NOT NULL AND orders.status IN ? AND orders.deleted_at IS NULL", paidOrderStatuses())
+	if filter.PriceListID > 0 {
+		query = query.Where("orders.price_list_id = ?", filter.PriceListID)
+	}
+	if filter.UserID > 0 {
+		query = query.Where("orders.user_id = ?", filter.UserID)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("orders.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("orders.created_at <= ?", *filter.CreatedTo)
+	}
+
+	rows := make([]ResellerMarginRow, 0)
+	if err := query.Group("orders.user_id, orders.price_list_id").Order("revenue DESC").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -14,6 +14,7 @@ type SiteConnectionRepository interface {
 	GetByApiKey(apiKey string) (*models.SiteConnection, error)
 	Create(conn *models.SiteConnection) error
 	Update(conn *models.SiteConnection) error
+	UpdateBalance(conn *models.SiteConnection) error
 	Delete(id uint) error
 	List(filter SiteConnectionListFilter) ([]models.SiteConnection, int64, error)
 	ListActive() ([]models.SiteConnection, error)
@@ -69,6 +70,16 @@ func (r *GormSiteConnectionRepository) Update(conn *models.SiteConnection) error
 	return r.db.Save(conn).Error
 }
 
+// UpdateBalance 仅更新余额相关字段，避免覆盖管理员并发修改的其他配置
+func (r *GormSiteConnectionRepository) UpdateBalance(conn *models.SiteConnection) error {
+	return r.db.Model(&models.SiteConnection{}).Where("id = ?", conn.ID).Updates(map[string]interface{}{
+		"last_balance":          conn.LastBalance,
+		"last_balance_currency": conn.LastBalanceCurrency,
+		"last_balance_at":       conn.LastBalanceAt,
+		"balance_alerting":      conn.BalanceAlerting,
+	}).Error
+}
+
 // Delete 软删除连接
 func (r *GormSiteConnectionRepository) Delete(id uint) error {
 	return r.db.Delete(&models.SiteConnection{}, id).Error
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dujiao-next/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SKUSourceRepository SKU 供货来源数据访问接口
+type SKUSourceRepository interface {
+	Create(source *models.SKUSource) error
+	Update(source *models.SKUSource) error
+	Delete(id uint) error
+	GetByID(id uint) (*models.SKUSource, error)
+	List(filter SKUSourceListFilter) ([]models.SKUSource, int64, error)
+	ListByLocalSKUID(skuID uint) ([]models.SKUSource, error)
+	ListActiveByLocalSKUID(skuID uint) ([]models.SKUSource, error)
+	UpdateUsage(id uint, updates map[string]interface{}) error
+}
+
+// GormSKUSourceRepository GORM 实现
+type GormSKUSourceRepository struct {
+	db *gorm.DB
+}
+
+// NewSKUSourceRepository 创建 SKU 供货来源仓库
+func NewSKUSourceRepository(db *gorm.DB) *GormSKUSourceRepository {
+	return &GormSKUSourceRepository{db: db}
+}
+
+// Create 创建来源
+func (r *GormSKUSourceRepository) Create(source *models.SKUSource) error {
+	return r.db.Create(source).Error
+}
+
+// Update 更新来源
+func (r *GormSKUSourceRepository) Update(source *models.SKUSource) error {
+	return r.db.Omit("Connection").Save(source).Error
+}
+
+// Delete 删除来源
+func (r *GormSKUSourceRepository) Delete(id uint) error {
+	return r.db.Delete(&models.SKUSource{}, id).Error
+}
+
+// GetByID 根据 ID 获取来源
+func (r *GormSKUSourceRepository) GetByID(id uint) (*models.SKUSource, error) {
+	var source models.SKUSource
+	if err := r.db.First(&source, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &source, nil
+}
+
+// List 来源列表
+func (r *GormSKUSourceRepository) List(filter SKUSourceListFilter) ([]models.SKUSource, int64, error) {
+	var sources []models.SKUSource
+	query := r.db.Model(&models.SKUSource{})
+	if filter.LocalProductID > 0 {
+		query = query.Where("local_product_id = ?", filter.LocalProductID)
+	}
+	if filter.LocalSKUID > 0 {
+		query = query.Where("local_sku_id = ?", filter.LocalSKUID)
+	}
+	if filter.ConnectionID > 0 {
+		query = query.Where("connection_id = ?", filter.ConnectionID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = applyPagination(query, filter.Page, filter.PageSize)
+	if err := query.Preload("Connection").Order("local_sku_id ASC, priority ASC, id ASC").Find(&sources).Error; err != nil {
+		return nil, 0, err
+	}
+	return sources, total, nil
+}
+
+// ListByLocalSKUID 获取本地 SKU 的全部来源（按优先级排序）
+func (r *GormSKUSourceRepository) ListByLocalSKUID(skuID uint) ([]models.SKUSource, error) {
+	var sources []models.SKUSource
+	if err := r.db.Where("local_sku_id = ?", skuID).Order("priority ASC, id ASC").Find(&sources).Error; err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// ListActiveByLocalSKUID 获取本地 SKU 启用中的来源（按优先级排序）
+func (r *GormSKUSourceRepository) ListActiveByLocalSKUID(skuID uint) ([]models.SKUSource, error) {
+	var sources []models.SKUSource
+	if err := r.db.Where("local_sku_id = ? AND is_active = ?", skuID, true).Order("priority ASC, id ASC").Find(&sources).Error; err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// UpdateUsage 更新来源的使用状态字段
+func (r *GormSKUSourceRepository) UpdateUsage(id uint, updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	if _, ok := updates["updated_at"]; !ok {
+		updates["updated_at"] = time.Now()
+	}
+	return r.db.Model(&models.SKUSource{}).Where("id = ?", id).Updates(updates).Error
+}
@@ -126,6 +126,7 @@ type WalletAccountListFilter struct {
 	Page     int
 	PageSize int
 	UserID   uint
+	Currency string
 }
 
 // WalletTransactionListFilter 查询钱包流水列表的过滤条件
@@ -136,10 +137,19 @@ type WalletTransactionListFilter struct {
 	OrderID     uint
 	Type        string
 	Direction   string
+	Currency    string
 	CreatedFrom *time.Time
 	CreatedTo   *time.Time
 }
 
+// WalletCurrencySummaryRow 钱包流水按币种与方向聚合的统计结果
+type WalletCurrencySummaryRow struct {
+	Currency  string
+	Direction string
+	TxnCount  int64
+	Amount    float64
+}
+
 // WalletRechargeListFilter 查询钱包充值单列表的过滤条件
 type WalletRechargeListFilter struct {
 	Page         int
@@ -158,6 +168,31 @@ type WalletRechargeListFilter struct {
 	PaidTo       *time.Time
 }
 
+// WalletTransferListFilter 钱包转账列表过滤条件
+type WalletTransferListFilter struct {
+	Page        int
+	PageSize    int
+	TransferNo  string
+	UserID      uint // 转出或转入任一方
+	FromUserID  uint
+	ToUserID    uint
+	Currency    string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+// WalletWithdrawListFilter 钱包提现申请列表过滤条件
+type WalletWithdrawListFilter struct {
+	Page        int
+	PageSize    int
+	IDs         []uint
+	UserID      uint
+	Status      string
+	Keyword     string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
 // UserLoginLogListFilter 查询用户登录日志列表的过滤条件
 type UserLoginLogListFilter struct {
 	Page        int
@@ -225,11 +260,24 @@ type AffiliateCommissionListFilter struct {
 	OrderID            uint
 	OrderNo            string
 	Status             string
+	Level              int
+	RiskFlagged        bool
 	Keyword            string
 	CreatedFrom        *time.Time
 	CreatedTo          *time.Time
 }
 
+// AffiliateFunnelFilter 推广转化漏斗查询条件（时间区间左闭右开，SubID 为空表示全部子渠道）
+type AffiliateFunnelFilter struct {
+	AffiliateProfileID uint
+	SubID              string
+	UTMSource          string
+	UTMMedium          string
+	UTMCampaign        string
+	StartAt            time.Time
+	EndAt              time.Time
+}
+
 // AffiliateWithdrawListFilter 推广提现列表过滤条件
 type AffiliateWithdrawListFilter struct {
 	Page               int
@@ -249,6 +297,94 @@ type MediaListFilter struct {
 	Search   string // 按素材名称/原始文件名模糊搜索
 }
 
+// FileAssetListFilter 查询私有文件列表的过滤条件
+type FileAssetListFilter struct {
+	Page     int
+	PageSize int
+	Search   string // 按原始文件名模糊搜索
+}
+
+// CardReplenishRuleListFilter 查询卡密自动补货规则列表的过滤条件
+type CardReplenishRuleListFilter struct {
+	Page         int
+	PageSize     int
+	ProductID    uint
+	ConnectionID uint
+	IsActive     *bool
+}
+
+// SKUSourceListFilter 查询 SKU 供货来源列表的过滤条件
+type SKUSourceListFilter struct {
+	Page           int
+	PageSize       int
+	LocalProductID uint
+	LocalSKUID     uint
+	ConnectionID   uint
+}
+
+// MarginGuardEventListFilter 查询利润保护记录的过滤条件
+type MarginGuardEventListFilter struct {
+	Page           int
+	PageSize       int
+	ConnectionID   uint
+	LocalProductID uint
+	Action         string
+}
+
+// UpstreamBalanceSnapshotListFilter 查询上游余额快照的过滤条件
+type UpstreamBalanceSnapshotListFilter struct {
+	Page         int
+	PageSize     int
+	ConnectionID uint
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+}
+
+// ResellerPriceListListFilter 查询分销价目表的过滤条件
+type ResellerPriceListListFilter struct {
+	Page     int
+	PageSize int
+	Search   string
+	IsActive *bool
+}
+
+// ResellerMarginFilter 分销利润报表的过滤条件
+type ResellerMarginFilter struct {
+	PriceListID uint
+	UserID      uint
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+// ResellerMarginRow 分销利润报表原始统计结果（按用户与价目表聚合）
+type ResellerMarginRow struct {
+	UserID          uint
+	PriceListID     uint
+	OrderCount      int64
+	Quantity        int64
+	Revenue         float64
+	Cost            float64
+	UncostedRevenue float64
+}
+
+// ApiCredentialUsageListFilter 查询 API 凭证用量的过滤条件
+type ApiCredentialUsageListFilter struct {
+	Page         int
+	PageSize     int
+	CredentialID uint
+	UserID       uint
+	DateFrom     string // YYYY-MM-DD
+	DateTo       string // YYYY-MM-DD
+}
+
+// ApiCredentialUsageDelta API 凭证用量增量
+type ApiCredentialUsageDelta struct {
+	Requests    int64
+	Rejected    int64
+	Orders      int64
+	OrderAmount decimal.Decimal
+}
+
 // AffiliateProfileStatsAggregate 推广用户统计聚合结果
 type AffiliateProfileStatsAggregate struct {
 	ClickCount          int64
@@ -257,3 +393,57 @@ type AffiliateProfileStatsAggregate struct {
 	AvailableCommission decimal.Decimal
 	WithdrawnCommission decimal.Decimal
 }
+
+// AffiliateFunnelRow 推广转化漏斗按日期与子渠道聚合行
+type AffiliateFunnelRow struct {
+	Day            string
+	SubID          string
+	Clicks         int64
+	UniqueVisitors int64
+	Orders         int64
+	PaidOrders     int64
+	Commission     decimal.Decimal
+}
+
+// AffiliateUserFingerprint 推广风控使用的用户身份特征
+type AffiliateUserFingerprint struct {
+	Email             string
+	IPs               []string
+	Devices           []string // 登录设备特征：同一次登录的 IP 与 UA 组合，单独的 UA 重合不足以认定同一设备
+	TelegramIDs       []string
+	TelegramUsernames []string
+}
+
+// AffiliateRiskBuyerRow 推广风控退款用户统计行
+type AffiliateRiskBuyerRow struct {
+	UserID         uint   `json:"user_id"`
+	Email          string `json:"email"`
+	RefundedOrders int64  `json:"refunded_orders"`
+}
+
+// WalletRechargeBonusRuleListFilter 充值赠送活动列表筛选
+type WalletRechargeBonusRuleListFilter struct {
+	Page     int
+	PageSize int
+	Keyword  string
+	IsActive *bool
+}
+
+// WalletLedgerAuditListFilter 钱包账本核对记录列表筛选
+type WalletLedgerAuditListFilter struct {
+	Page     int
+	PageSize int
+	Source   string
+	Status   string
+}
+
+// WalletLedgerDiscrepancyListFilter 钱包账本差异列表筛选
+type WalletLedgerDiscrepancyListFilter struct {
+	Page     int
+	PageSize int
+	AuditID  uint
+	UserID   uint
+	OrderID  uint
+	Type     string
+	Resolved *bool
+}
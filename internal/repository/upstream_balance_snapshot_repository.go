@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"github.com/dujiao-next/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// UpstreamBalanceSnapshotRepository 上游余额快照数据访问接口
+type UpstreamBalanceSnapshotRepository interface {
+	Create(snapshot *models.UpstreamBalanceSnapshot) error
+	List(filter UpstreamBalanceSnapshotListFilter) ([]models.UpstreamBalanceSnapshot, int64, error)
+}
+
+// GormUpstreamBalanceSnapshotRepository GORM 实现
+type GormUpstreamBalanceSnapshotRepository struct {
+	db *gorm.DB
+}
+
+// NewUpstreamBalanceSnapshotRepository 创建上游余额快照仓库
+func NewUpstreamBalanceSnapshotRepository(db *gorm.DB) *GormUpstreamBalanceSnapshotRepository {
+	return &GormUpstreamBalanceSnapshotRepository{db: db}
+}
+
+// Create 创建快照
+func (r *GormUpstreamBalanceSnapshotRepository) Create(snapshot *models.UpstreamBalanceSnapshot) error {
+	return r.db.Create(snapshot).Error
+}
+
+// List 快照列表（按时间倒序）
+func (r *GormUpstreamBalanceSnapshotRepository) List(filter UpstreamBalanceSnapshotListFilter) ([]models.UpstreamBalanceSnapshot, int64, error) {
+	var snapshots []models.UpstreamBalanceSnapshot
+	query := r.db.Model(&models.UpstreamBalanceSnapshot{})
+	if filter.ConnectionID > 0 {
+		query = query.Where("connection_id = ?", filter.ConnectionID)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at <= ?", *filter.CreatedTo)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = applyPagination(query, filter.Page, filter.PageSize)
+	if err := query.Order("id DESC").Find(&snapshots).Error; err != nil {
+		return nil, 0, err
+	}
+	return snapshots, total, nil
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// WalletLedgerAuditRepository 钱包账本核对数据访问接口
+type WalletLedgerAuditRepository interface {
+	CreateAudit(audit *models.WalletLedgerAudit) error
+	UpdateAudit(audit *models.WalletLedgerAudit) error
+	GetAuditByID(id uint) (*models.WalletLedgerAudit, error)
+	ListAudits(filter WalletLedgerAuditListFilter) ([]models.WalletLedgerAudit, int64, error)
+	CreateDiscrepancies(items []models.WalletLedgerDiscrepancy) error
+	GetDiscrepancyByID(id uint) (*models.WalletLedgerDiscrepancy, error)
+	UpdateDiscrepancy(item *models.WalletLedgerDiscrepancy) error
+	ListDiscrepancies(filter WalletLedgerDiscrepancyListFilter) ([]models.WalletLedgerDiscrepancy, int64, error)
+	ListAccountsAfter(afterID uint, limit int) ([]models.WalletAccount, error)
+	GetAccount(userID uint, currency string) (*models.WalletAccount, error)
+	ListAccountTransactionsAfter(userID uint, currency string, afterID uint, limit int) ([]models.WalletTransaction, error)
+	ListWalletOrdersAfter(afterID uint, limit int) ([]models.Order, error)
+	ListOrderTransactionsByOrderIDs(orderIDs []uint) ([]models.WalletTransaction, error)
+}
+
+// GormWalletLedgerAuditRepository GORM 实现
+type GormWalletLedgerAuditRepository struct {
+	db *gorm.DB
+}
+
+// NewWalletLedgerAuditRepository 创建钱包账本核对仓库
+func NewWalletLedgerAuditRepository(db *gorm.DB) *GormWalletLedgerAuditRepository {
+	return &GormWalletLedgerAuditRepository{db: db}
+}
+
+// CreateAudit 创建核对记录
+func (r *GormWalletLedgerAuditRepository) CreateAudit(audit *models.WalletLedgerAudit) error {
+	return r.db.Create(audit).Error
+}
+
+// UpdateAudit 更新核对记录
+func (r *GormWalletLedgerAuditRepository) UpdateAudit(audit *models.WalletLedgerAudit) error {
+	return r.db.Save(audit).Error
+}
+
+// GetAuditByID 按ID获取核对记录
+func (r *GormWalletLedgerAuditRepository) GetAuditByID(id uint) (*models.WalletLedgerAudit, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	var audit models.WalletLedgerAudit
+	if err := r.db.First(&audit, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &audit, nil
+}
+
+// ListAudits 核对记录列表（按时间倒序）
+func (r *GormWalletLedgerAuditRepository) ListAudits(filter WalletLedgerAuditListFilter) ([]models.WalletLedgerAudit, int64, error) {
+	query := r.db.Model(&models.WalletLedgerAudit{})
+	if source := strings.TrimSpace(filter.Source); source != "" {
+		query = query.Where("source = ?", source)
+	}
+	if status := strings.TrimSpace(filter.Status); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = applyPagination(query, filter.Page, filter.PageSize)
+
+	var rows []models.WalletLedgerAudit
+	if err := query.Order("id desc").Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+// CreateDiscrepancies 批量写入差异明细
+func (r *GormWalletLedgerAuditRepository) CreateDiscrepancies(items []models.WalletLedgerDiscrepancy) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(&items, 200).Error
+}
+
+// GetDiscrepancyByID 按ID获取差异明细
+func (r *GormWalletLedgerAuditRepository) GetDiscrepancyByID(id uint) (*models.WalletLedgerDiscrepancy, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	var item models.WalletLedgerDiscrepancy
+	if err := r.db.First(&item, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// UpdateDiscrepancy 更新差异明细
+func (r *GormWalletLedgerAuditRepository) UpdateDiscrepancy(item *models.WalletLedgerDiscrepancy) error {
+	return r.db.Save(item).Error
+}
+
+// ListDiscrepancies 差异明细列表
+func (r *GormWalletLedgerAuditRepository) ListDiscrepancies(filter WalletLedgerDiscrepancyListFilter) ([]models.WalletLedgerDiscrepancy, int64, error) {
+	query := r.db.Model(&models.WalletLedgerDiscrepancy{})
+	if filter.AuditID != 0 {
+		query = query.Where("audit_id = ?", filter.AuditID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.OrderID != 0 {
+		query = query.Where("order_id = ?", filter.OrderID)
+	}
+	if t := strings.TrimSpace(filter.Type); t != "" {
+		query = query.Where("type = ?", t)
+	}
+	if filter.Resolved != nil {
+		query = query.Where("resolved = ?", *filter.Resolved)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = applyPagination(query, filter.Page, filter.PageSize)
+
+	var rows []models.WalletLedgerDiscrepancy
+	if err := query.Order("id asc").Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+// ListAccountsAfter 按ID升序分批获取钱包账户
+func (r *GormWalletLedgerAuditRepository) ListAccountsAfter(afterID uint, limit int) ([]models.WalletAccount, error) {
+	var rows []models.WalletAccount
+	if err := r.db.Where("id > ?", afterID).Order("id asc").Limit(limit).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetAccount 获取用户指定币种的钱包账户
+func (r *GormWalletLedgerAuditRepository) GetAccount(userID uint, currency string) (*models.WalletAccount, error) {
+	var account models.WalletAccount
+	if err := r.db.Where("user_id = ? AND currency = ?", userID, currency).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+// ListAccountTransactionsAfter 按ID升序分批获取账户流水
+func (r *GormWalletLedgerAuditRepository) ListAccountTransactionsAfter(userID uint, currency string, afterID uint, limit int) ([]models.WalletTransaction, error) {
+	var rows []models.WalletTransaction
+	if err := r.db.Where("user_id = ? AND currency = ? AND id > ?", userID, currency, afterID).
+		Order("id asc").
+		Limit(limit).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ListWalletOrdersAfter 按ID升序分批获取使用过钱包的订单（钱包支付、退回钱包或存在订单流水）
+func (r *GormWalletLedgerAuditRepository) ListWalletOrdersAfter(afterID uint, limit int) ([]models.Order, error) {
+	walletOrderIDs := r.db.Model(&models.WalletTransaction{}).
+		Select("order_id").
+		Where("order_id IS NOT NULL AND type IN ?", []string{
+			constants.WalletTxnTypeOrderPay,
+			constants.WalletTxnTypeOrderRefund,
+			constants.WalletTxnTypeAdminRefund,
+		})
+	var rows []models.Order
+	if err := r.db.Where("id > ?", afterID).
+		Where("wallet_paid_amount > 0 OR refunded_amount > 0 OR id IN (?)", walletOrderIDs).
+		Order("id asc").
+		Limit(limit).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ListOrderTransactionsByOrderIDs 获取订单关联的全部钱包流水
+func (r *GormWalletLedgerAuditRepository) ListOrderTransactionsByOrderIDs(orderIDs []uint) ([]models.WalletTransaction, error) {
+	if len(orderIDs) == 0 {
+		return []models.WalletTransaction{}, nil
+	}
+	var rows []models.WalletTransaction
+	if err := r.db.Where("order_id IN ?", orderIDs).Order("id asc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
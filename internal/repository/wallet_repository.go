@@ -3,7 +3,9 @@ package repository
 import (
 	"errors"
 	"strings"
+	"time"
 
+	"github.com/dujiao-next/internal/constants"
 	"github.com/dujiao-next/internal/models"
 
 	"gorm.io/gorm"
@@ -12,8 +14,10 @@ import (
 
 // WalletRepository 钱包数据访问接口
 type WalletRepository interface {
-	GetAccountByUserID(userID uint) (*models.WalletAccount, error)
-	GetAccountByUserIDForUpdate(userID uint) (*models.WalletAccount, error)
+	GetAccount(userID uint, currency string) (*models.WalletAccount, error)
+	GetAccountForUpdate(userID uint, currency string) (*models.WalletAccount, error)
+	ListAccountsByUserID(userID uint) ([]models.WalletAccount, error)
+	ListAccountsByUserIDForUpdate(userID uint) ([]models.WalletAccount, error)
 	GetAccountsByUserIDs(userIDs []uint) ([]models.WalletAccount, error)
 	CreateAccount(account *models.WalletAccount) error
 	UpdateAccount(account *models.WalletAccount) error
@@ -21,6 +25,8 @@ type WalletRepository interface {
 	CreateTransaction(txn *models.WalletTransaction) error
 	GetTransactionByReference(reference string) (*models.WalletTransaction, error)
 	ListTransactions(filter WalletTransactionListFilter) ([]models.WalletTransaction, int64, error)
+	ListOrderTransactions(orderID uint, txnType string) ([]models.WalletTransaction, error)
+	SummarizeTransactions(filter WalletTransactionListFilter) ([]WalletCurrencySummaryRow, error)
 	CreateRechargeOrder(order *models.WalletRechargeOrder) error
 	UpdateRechargeOrder(order *models.WalletRechargeOrder) error
 	GetRechargeOrderByRechargeNo(userID uint, rechargeNo string) (*models.WalletRechargeOrder, error)
@@ -29,6 +35,26 @@ type WalletRepository interface {
 	GetRechargeOrderByPaymentIDForUpdate(paymentID uint) (*models.WalletRechargeOrder, error)
 	ListRechargeOrdersAdmin(filter WalletRechargeListFilter) ([]models.WalletRechargeOrder, int64, error)
 	GetRechargeOrdersByPaymentIDs(paymentIDs []uint) ([]models.WalletRechargeOrder, error)
+	CreateTransfer(transfer *models.WalletTransfer) error
+	SumTransfersSince(fromUserID uint, currency string, since time.Time) (int64, float64, error)
+	ListTransfers(filter WalletTransferListFilter) ([]models.WalletTransfer, int64, error)
+	CreateWithdraw(req *models.WalletWithdrawRequest) error
+	UpdateWithdraw(req *models.WalletWithdrawRequest) error
+	GetWithdrawByID(id uint) (*models.WalletWithdrawRequest, error)
+	GetWithdrawByIDForUpdate(id uint) (*models.WalletWithdrawRequest, error)
+	ListWithdraws(filter WalletWithdrawListFilter) ([]models.WalletWithdrawRequest, int64, error)
+	GetRechargeOrderByIDForUpdate(id uint) (*models.WalletRechargeOrder, error)
+	CountTransactionsByType(userID uint, txnType string) (int64, error)
+	CreateBonusRule(rule *models.WalletRechargeBonusRule) error
+	UpdateBonusRule(rule *models.WalletRechargeBonusRule) error
+	DeleteBonusRule(id uint) error
+	GetBonusRuleByID(id uint) (*models.WalletRechargeBonusRule, error)
+	ListBonusRules(filter WalletRechargeBonusRuleListFilter) ([]models.WalletRechargeBonusRule, int64, error)
+	ListActiveBonusRules(now time.Time) ([]models.WalletRechargeBonusRule, error)
+	CreateRechargeBonus(bonus *models.WalletRechargeBonus) error
+	UpdateRechargeBonus(bonus *models.WalletRechargeBonus) error
+	GetRechargeBonusByRechargeID(rechargeID uint) (*models.WalletRechargeBonus, error)
+	SumRechargeBonuses(ruleID, userID uint) (int64, float64, error)
 	Transaction(fn func(tx *gorm.DB) error) error
 	WithTx(tx *gorm.DB) *GormWalletRepository
 }
@@ -51,13 +77,13 @@ func (r *GormWalletRepository) WithTx(tx *gorm.DB) *GormWalletRepository {
 	return &GormWalletRepository{BaseRepository: BaseRepository{db: tx}}
 }
 
-// GetAccountByUserID 按用户ID获取钱包账户
-func (r *GormWalletRepository) GetAccountByUserID(userID uint) (*models.WalletAccount, error) {
-	if userID == 0 {
+// GetAccount 按用户ID与币种获取钱包账户
+func (r *GormWalletRepository) GetAccount(userID uint, currency string) (*models.WalletAccount, error) {
+	if userID == 0 || currency == "" {
 		return nil, nil
 	}
 	var account models.WalletAccount
-	if err := r.db.Where("user_id = ?", userID).First(&account).Error; err != nil {
+	if err := r.db.Where("user_id = ? AND currency = ?", userID, currency).First(&account).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -66,14 +92,14 @@ func (r *GormWalletRepository) GetAccountByUserID(userID uint) (*models.WalletAc
 	return &account, nil
 }
 
-// GetAccountByUserIDForUpdate 按用户ID加锁获取钱包账户
-func (r *GormWalletRepository) GetAccountByUserIDForUpdate(userID uint) (*models.WalletAccount, error) {
-	if userID == 0 {
+// GetAccountForUpdate 按用户ID与币种加锁获取钱包账户
+func (r *GormWalletRepository) GetAccountForUpdate(userID uint, currency string) (*models.WalletAccount, error) {
+	if userID == 0 || currency == "" {
 		return nil, nil
 	}
 	var account models.WalletAccount
 	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
-		Where("user_id = ?", userID).
+		Where("user_id = ? AND currency = ?", userID, currency).
 		First(&account).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -83,6 +109,33 @@ func (r *GormWalletRepository) GetAccountByUserIDForUpdate(userID uint) (*models
 	return &account, nil
 }
 
+// ListAccountsByUserID 获取用户全部币种的钱包账户（按币种排序）
+func (r *GormWalletRepository) ListAccountsByUserID(userID uint) ([]models.WalletAccount, error) {
+	if userID == 0 {
+		return []models.WalletAccount{}, nil
+	}
+	var accounts []models.WalletAccount
+	if err := r.db.Where("user_id = ?", userID).Order("currency asc").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// ListAccountsByUserIDForUpdate 按账户ID升序加锁获取用户全部币种的钱包账户
+func (r *GormWalletRepository) ListAccountsByUserIDForUpdate(userID uint) ([]models.WalletAccount, error) {
+	if userID == 0 {
+		return []models.WalletAccount{}, nil
+	}
+	var accounts []models.WalletAccount
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		Order("id asc").
+		Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
 // GetAccountsByUserIDs 批量获取钱包账户
 func (r *GormWalletRepository) GetAccountsByUserIDs(userIDs []uint) ([]models.WalletAccount, error) {
 	if len(userIDs) == 0 {
@@ -102,6 +155,13 @@ func (r *GormWalletRepository) CreateAccount(account *models.WalletAccount) erro
 
 // UpdateAccount 更新钱包账户
 func (r *GormWalletRepository) UpdateAccount(account *models.WalletAccount) error {
+	// 不可提现赠送额度不应超过余额：余额被消费后同步收敛
+	if account.LockedBonus.Decimal.GreaterThan(account.Balance.Decimal) {
+		account.LockedBonus = account.Balance
+	}
+	if account.LockedBonus.Decimal.IsNegative() {
+		account.LockedBonus = models.Money{}
+	}
 	return r.db.Save(account).Error
 }
 
@@ -111,6 +171,9 @@ func (r *GormWalletRepository) ListAccounts(filter WalletAccountListFilter) ([]m
 	if filter.UserID != 0 {
 		query = query.Where("user_id = ?", filter.UserID)
 	}
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
 
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
@@ -149,7 +212,49 @@ func (r *GormWalletRepository) GetTransactionByReference(reference string) (*mod
 
 // ListTransactions 分页查询钱包流水
 func (r *GormWalletRepository) ListTransactions(filter WalletTransactionListFilter) ([]models.WalletTransaction, int64, error) {
-	query := r.db.Model(&models.WalletTransaction{})
+	query := r.applyTransactionFilter(r.db.Model(&models.WalletTransaction{}), filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = applyPagination(query, filter.Page, filter.PageSize)
+
+	var txns []models.WalletTransaction
+	if err := query.Order("id desc").Find(&txns).Error; err != nil {
+		return nil, 0, err
+	}
+	return txns, total, nil
+}
+
+// ListOrderTransactions 获取订单指定类型的全部钱包流水（多币种支付时每个币种一条）
+func (r *GormWalletRepository) ListOrderTransactions(orderID uint, txnType string) ([]models.WalletTransaction, error) {
+	if orderID == 0 {
+		return []models.WalletTransaction{}, nil
+	}
+	var txns []models.WalletTransaction
+	if err := r.db.Where("order_id = ? AND type = ?", orderID, txnType).Order("id asc").Find(&txns).Error; err != nil {
+		return nil, err
+	}
+	return txns, nil
+}
+
+// SummarizeTransactions 按币种与资金方向汇总钱包流水（忽略分页参数）
+func (r *GormWalletRepository) SummarizeTransactions(filter WalletTransactionListFilter) ([]WalletCurrencySummaryRow, error) {
+	var rows []WalletCurrencySummaryRow
+	query := r.applyTransactionFilter(r.db.Model(&models.WalletTransaction{}), filter)
+	if err := query.
+		Select("currency, direction, COUNT(*) AS txn_count, COALESCE(SUM(amount), 0) AS amount").
+		Group("currency, direction").
+		Order("currency asc, direction asc").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *GormWalletRepository) applyTransactionFilter(query *gorm.DB, filter WalletTransactionListFilter) *gorm.DB {
 	if filter.UserID != 0 {
 		query = query.Where("user_id = ?", filter.UserID)
 	}
@@ -162,25 +267,16 @@ func (r *GormWalletRepository) ListTransactions(filter WalletTransactionListFilt
 	if filter.Direction != "" {
 		query = query.Where("direction = ?", filter.Direction)
 	}
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
 	if filter.CreatedFrom != nil {
 		query = query.Where("created_at >= ?", *filter.CreatedFrom)
 	}
 	if filter.CreatedTo != nil {
 		query = query.Where("created_at <= ?", *filter.CreatedTo)
 	}
-
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	query = applyPagination(query, filter.Page, filter.PageSize)
-
-	var txns []models.WalletTransaction
-	if err := query.Order("id desc").Find(&txns).Error; err != nil {
-		return nil, 0, err
-	}
-	return txns, total, nil
+	return query
 }
 
 // CreateRechargeOrder 创建钱包充值支付单
@@ -328,3 +424,278 @@ func (r *GormWalletRepository) GetRechargeOrdersByPaymentIDs(paymentIDs []uint)
 	}
 	return orders, nil
 }
+
+// CreateTransfer 创建钱包转账记录
+func (r *GormWalletRepository) CreateTransfer(transfer *models.WalletTransfer) error {
+	return r.db.Create(transfer).Error
+}
+
+// SumTransfersSince 统计用户自指定时间起的转出笔数与金额（不含手续费）
+func (r *GormWalletRepository) SumTransfersSince(fromUserID uint, currency string, since time.Time) (int64, float64, error) {
+	var row struct {
+		Count  int64
+		Amount float64
+	}
+	if err := r.db.Model(&models.WalletTransfer{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Where("from_user_id = ? AND currency = ? AND created_at >= ?", fromUserID, currency, since).
+		Scan(&row).Error; err != nil {
+		return 0, 0, err
+	}
+	return row.Count, row.Amount, nil
+}
+
+// ListTransfers 分页查询钱包转账记录
+func (r *GormWalletRepository) ListTransfers(filter WalletTransferListFilter) ([]models.WalletTransfer, int64, error) {
+	query := r.db.Model(&models.WalletTransfer{})
+	if filter.TransferNo != "" {
+		query = query.Where("transfer_no = ?", filter.TransferNo)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("(from_user_id = ? OR to_user_id = ?)", filter.UserID, filter.UserID)
+	}
+	if filter.FromUserID != 0 {
+		query = query.Where("from_user_id = ?", filter.FromUserID)
+	}
+	if filter.ToUserID != 0 {
+		query = query.Where("to_user_id = ?", filter.ToUserID)
+	}
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at <= ?", *filter.CreatedTo)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = applyPagination(query, filter.Page, filter.PageSize)
+
+	var transfers []models.WalletTransfer
+	if err := query.Order("id DESC").Find(&transfers).Error; err != nil {
+		return nil, 0, err
+	}
+	return transfers, total, nil
+}
+
+// CreateWithdraw 创建钱包提现申请
+func (r *GormWalletRepository) CreateWithdraw(req *models.WalletWithdrawRequest) error {
+	return r.db.Create(req).Error
+}
+
+// UpdateWithdraw 更新钱包提现申请
+func (r *GormWalletRepository) UpdateWithdraw(req *models.WalletWithdrawRequest) error {
+	return r.db.Save(req).Error
+}
+
+// GetWithdrawByID 按ID查询钱包提现申请
+func (r *GormWalletRepository) GetWithdrawByID(id uint) (*models.WalletWithdrawRequest, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	var req models.WalletWithdrawRequest
+	if err := r.db.Preload("User").Preload("Processor").First(&req, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &req, nil
+}
+
+// GetWithdrawByIDForUpdate 按ID锁定查询钱包提现申请
+func (r *GormWalletRepository) GetWithdrawByIDForUpdate(id uint) (*models.WalletWithdrawRequest, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	var req models.WalletWithdrawRequest
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&req, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &req, nil
+}
+
+// ListWithdraws 分页查询钱包提现申请（PageSize 为 0 时返回全部，用于导出）
+func (r *GormWalletRepository) ListWithdraws(filter WalletWithdrawListFilter) ([]models.WalletWithdrawRequest, int64, error) {
+	query := r.db.Model(&models.WalletWithdrawRequest{}).
+		Preload("User").
+		Preload("Processor")
+
+	if len(filter.IDs) > 0 {
+		query = query.Where("wallet_withdraw_requests.id IN ?", filter.IDs)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("wallet_withdraw_requests.user_id = ?", filter.UserID)
+	}
+	if status := strings.TrimSpace(filter.Status); status != "" {
+		query = query.Where("wallet_withdraw_requests.status = ?", status)
+	}
+	if keyword := strings.TrimSpace(filter.Keyword); keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.
+			Joins("LEFT JOIN users u ON u.id = wallet_withdraw_requests.user_id").
+			Where("(u.email LIKE ? OR u.display_name LIKE ? OR wallet_withdraw_requests.withdraw_no LIKE ? OR wallet_withdraw_requests.account LIKE ?)",
+				like, like, like, like)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("wallet_withdraw_requests.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("wallet_withdraw_requests.created_at <= ?", *filter.CreatedTo)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = applyPagination(query, filter.Page, filter.PageSize)
+
+	var rows []models.WalletWithdrawRequest
+	if err := query.Order("wallet_withdraw_requests.id desc").Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+// GetRechargeOrderByIDForUpdate 按ID获取充值单并加行锁
+func (r *GormWalletRepository) GetRechargeOrderByIDForUpdate(id uint) (*models.WalletRechargeOrder, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	var order models.WalletRechargeOrder
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// CountTransactionsByType 统计用户指定类型的钱包流水数量
+func (r *GormWalletRepository) CountTransactionsByType(userID uint, txnType string) (int64, error) {
+	if userID == 0 {
+		return 0, nil
+	}
+	var count int64
+	if err := r.db.Model(&models.WalletTransaction{}).
+		Where("user_id = ? AND type = ?", userID, txnType).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// CreateBonusRule 创建充值赠送活动
+func (r *GormWalletRepository) CreateBonusRule(rule *models.WalletRechargeBonusRule) error {
+	return r.db.Create(rule).Error
+}
+
+// UpdateBonusRule 更新充值赠送活动
+func (r *GormWalletRepository) UpdateBonusRule(rule *models.WalletRechargeBonusRule) error {
+	return r.db.Save(rule).Error
+}
+
+// DeleteBonusRule 删除充值赠送活动
+func (r *GormWalletRepository) DeleteBonusRule(id uint) error {
+	return r.db.Delete(&models.WalletRechargeBonusRule{}, id).Error
+}
+
+// GetBonusRuleByID 按ID获取充值赠送活动
+func (r *GormWalletRepository) GetBonusRuleByID(id uint) (*models.WalletRechargeBonusRule, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	var rule models.WalletRechargeBonusRule
+	if err := r.db.First(&rule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListBonusRules 分页查询充值赠送活动
+func (r *GormWalletRepository) ListBonusRules(filter WalletRechargeBonusRuleListFilter) ([]models.WalletRechargeBonusRule, int64, error) {
+	query := r.db.Model(&models.WalletRechargeBonusRule{})
+	if keyword := strings.TrimSpace(filter.Keyword); keyword != "" {
+		query = query.Where("name LIKE ?", "%"+keyword+"%")
+	}
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = applyPagination(query, filter.Page, filter.PageSize)
+
+	var rows []models.WalletRechargeBonusRule
+	if err := query.Order("id desc").Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+// ListActiveBonusRules 获取当前时间生效中的充值赠送活动
+func (r *GormWalletRepository) ListActiveBonusRules(now time.Time) ([]models.WalletRechargeBonusRule, error) {
+	var rows []models.WalletRechargeBonusRule
+	if err := r.db.Where("is_active = ?", true).
+		Where("starts_at IS NULL OR starts_at <= ?", now).
+		Where("ends_at IS NULL OR ends_at >= ?", now).
+		Order("id asc").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// CreateRechargeBonus 创建充值赠送发放记录
+func (r *GormWalletRepository) CreateRechargeBonus(bonus *models.WalletRechargeBonus) error {
+	return r.db.Create(bonus).Error
+}
+
+// UpdateRechargeBonus 更新充值赠送发放记录
+func (r *GormWalletRepository) UpdateRechargeBonus(bonus *models.WalletRechargeBonus) error {
+	return r.db.Save(bonus).Error
+}
+
+// GetRechargeBonusByRechargeID 按充值单获取赠送发放记录
+func (r *GormWalletRepository) GetRechargeBonusByRechargeID(rechargeID uint) (*models.WalletRechargeBonus, error) {
+	if rechargeID == 0 {
+		return nil, nil
+	}
+	var bonus models.WalletRechargeBonus
+	if err := r.db.Where("recharge_id = ?", rechargeID).First(&bonus).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &bonus, nil
+}
+
+// SumRechargeBonuses 统计用户在指定活动下已发放（未追回）的赠送次数与金额
+func (r *GormWalletRepository) SumRechargeBonuses(ruleID, userID uint) (int64, float64, error) {
+	var row struct {
+		Count int64
+		Total float64
+	}
+	if err := r.db.Model(&models.WalletRechargeBonus{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(amount), 0) AS total").
+		Where("rule_id = ? AND user_id = ? AND status = ?", ruleID, userID, constants.WalletRechargeBonusStatusGranted).
+		Scan(&row).Error; err != nil {
+		return 0, 0, err
+	}
+	return row.Count, row.Total, nil
+}
@@ -0,0 +1,132 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dujiao-next/internal/http/response"
+	"github.com/dujiao-next/internal/i18n"
+	"github.com/dujiao-next/internal/logger"
+	"github.com/dujiao-next/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyHeaderKey      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+)
+
+// idempotencyErrorResponder 按各 API 自身的错误格式输出幂等校验失败（errorCode 对应 i18n 键 error.<errorCode>）
+type idempotencyErrorResponder func(c *gin.Context, httpStatus int, errorCode string)
+
+// IdempotencyMiddleware 幂等键中间件：请求携带 Idempotency-Key 时，同一作用域内相同键的重试回放首次响应，
+// 键被用于内容不同的请求时返回 409。未携带时不做处理。
+func IdempotencyMiddleware(svc *service.IdempotencyService, scopeOf func(c *gin.Context) string, respond idempotencyErrorResponder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(idempotencyHeaderKey))
+		if key == "" || svc == nil {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 10<<20)
+			body, err = io.ReadAll(c.Request.Body)
+			if err != nil {
+				respond(c, http.StatusBadRequest, "bad_request")
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(&bodyReader{data: body})
+		}
+
+		fingerprint := service.IdempotencyFingerprint(c.Request.Method, c.Request.URL.Path, body)
+		record, replay, err := svc.Begin(scopeOf(c), key, fingerprint)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrIdempotencyKeyInvalid):
+				respond(c, http.StatusBadRequest, "idempotency_key_invalid")
+			case errors.Is(err, service.ErrIdempotencyKeyConflict):
+				respond(c, http.StatusConflict, "idempotency_key_conflict")
+			case errors.Is(err, service.ErrIdempotencyInProgress):
+				respond(c, http.StatusConflict, "idempotency_in_progress")
+			default:
+				logger.Errorw("idempotency_begin_failed", "path", c.Request.URL.Path, "error", err)
+				respond(c, http.StatusInternalServerError, "internal_error")
+			}
+			c.Abort()
+			return
+		}
+		if replay {
+			c.Header(idempotencyReplayedHeader, "true")
+			c.Data(record.ResponseStatus, record.ContentType, []byte(record.ResponseBody))
+			c.Abort()
+			return
+		}
+
+		writer := &idempotencyResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		svc.Complete(record, writer.Status(), writer.Header().Get("Content-Type"), writer.body.Bytes())
+	}
+}
+
+// idempotencyResponseWriter 在写出响应的同时保留一份副本
+type idempotencyResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyResponseWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyResponseWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// upstreamIdempotencyScope 上游 API 按 API 凭证隔离幂等键
+func upstreamIdempotencyScope(c *gin.Context) string {
+	credentialID, _ := c.Get(upstreamCredentialIDKey)
+	return fmt.Sprintf("upstream:%v", credentialID)
+}
+
+// channelIdempotencyScope 渠道 API 按渠道客户端隔离幂等键
+func channelIdempotencyScope(c *gin.Context) string {
+	clientID, _ := c.Get(channelClientIDKey)
+	return fmt.Sprintf("channel:%v", clientID)
+}
+
+// userIdempotencyScope 用户中心按登录用户隔离幂等键
+func userIdempotencyScope(c *gin.Context) string {
+	userID, _ := c.Get("user_id")
+	return fmt.Sprintf("user:%v", userID)
+}
+
+// respondUpstreamIdempotencyError 上游 API 错误格式（ok / error_code / error_message）
+func respondUpstreamIdempotencyError(c *gin.Context, httpStatus int, errorCode string) {
+	c.JSON(httpStatus, gin.H{
+		"ok":            false,
+		"error_code":    errorCode,
+		"error_message": i18n.T(i18n.LocaleEN, "error."+errorCode),
+	})
+}
+
+// respondChannelIdempotencyError 渠道 API 错误格式
+func respondChannelIdempotencyError(c *gin.Context, httpStatus int, errorCode string) {
+	response.ChannelError(c, httpStatus, httpStatus, i18n.T(i18n.ResolveLocale(c), "error."+errorCode), errorCode)
+}
+
+// respondUserIdempotencyError 用户中心错误格式
+func respondUserIdempotencyError(c *gin.Context, httpStatus int, errorCode string) {
+	response.Error(c, httpStatus, i18n.T(i18n.ResolveLocale(c), "error."+errorCode))
+}
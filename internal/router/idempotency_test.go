@@ -0,0 +1,90 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/dujiao-next/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestIdempotencyMiddlewareReplaysAndRejectsConflicts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dsn := fmt.Sprintf("file:idempotency_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	if err := db.AutoMigrate(&models.IdempotencyRecord{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	svc := service.NewIdempotencyService(repository.NewIdempotencyRecordRepository(db))
+
+	created, failures := 0, 0
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(upstreamCredentialIDKey, uint(7))
+		c.Next()
+	})
+	r.POST("/orders", IdempotencyMiddleware(svc, upstreamIdempotencyScope, respondUpstreamIdempotencyError), func(c *gin.Context) {
+		if c.Query("fail") != "" {
+			failures++
+			c.JSON(http.StatusInternalServerError, gin.H{"ok": false})
+			return
+		}
+		created++
+		c.JSON(http.StatusOK, gin.H{"ok": true, "order_no": fmt.Sprintf("DJ%d", created)})
+	})
+
+	send := func(path, key, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(idempotencyHeaderKey, key)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := send("/orders", "key-1", `{"sku_id":1}`)
+	replayed := send("/orders", "key-1", `{"sku_id":1}`)
+	if created != 1 || replayed.Code != http.StatusOK || replayed.Body.String() != first.Body.String() || replayed.Header().Get(idempotencyReplayedHeader) != "true" {
+		t.Fatalf("expected replay of first response, created=%d body=%s", created, replayed.Body.String())
+	}
+
+	conflict := send("/orders", "key-1", `{"sku_id":2}`)
+	if conflict.Code != http.StatusConflict || !strings.Contains(conflict.Body.String(), "idempotency_key_conflict") || created != 1 {
+		t.Fatalf("expected conflict for reused key, got %d %s", conflict.Code, conflict.Body.String())
+	}
+
+	// 不带幂等键的请求不受影响
+	send("/orders", "", `{"sku_id":1}`)
+	if created != 2 {
+		t.Fatalf("expected request without key to be processed, created=%d", created)
+	}
+
+	// 服务端错误不保存，同一键可以重试
+	send("/orders?fail=1", "key-2", `{}`)
+	send("/orders?fail=1", "key-2", `{}`)
+	if failures != 2 {
+		t.Fatalf("expected server error not to be replayed, failures=%d", failures)
+	}
+
+	// 过期记录不再回放
+	if err := db.Model(&models.IdempotencyRecord{}).Where("idempotency_key = ?", "key-1").Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("expire record failed: %v", err)
+	}
+	send("/orders", "key-1", `{"sku_id":1}`)
+	if created != 3 {
+		t.Fatalf("expected expired key to be processed again, created=%d", created)
+	}
+}
@@ -2,7 +2,9 @@ package router
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -57,14 +59,7 @@ func RateLimitMiddleware(client *redis.Client, rule RateLimitRule, keyFunc RateL
 			key = fmt.Sprintf("%s:%s", rule.Prefix, key)
 		}
 
-		result, err := rateLimitScript.Run(
-			c.Request.Context(),
-			client,
-			[]string{key},
-			rule.WindowSeconds,
-			rule.MaxRequests,
-			rule.BlockSeconds,
-		).Result()
+		count, ttlSeconds, err := runRateLimit(c.Request.Context(), client, key, rule)
 		if err != nil {
 			msg := i18n.T(i18n.ResolveLocale(c), "error.rate_limit_unavailable")
 			if isChannelAPIRequest(c) {
@@ -75,38 +70,8 @@ func RateLimitMiddleware(client *redis.Client, rule RateLimitRule, keyFunc RateL
 			c.Abort()
 			return
 		}
-
-		values, ok := result.([]interface{})
-		if !ok || len(values) < 2 {
-			msg := i18n.T(i18n.ResolveLocale(c), "error.rate_limit_unavailable")
-			if isChannelAPIRequest(c) {
-				response.ChannelError(c, 500, response.CodeInternal, msg, "internal_error")
-			} else {
-				response.Error(c, response.CodeInternal, msg)
-			}
-			c.Abort()
-			return
-		}
-		count, ok := toInt64(values[0])
-		if !ok {
-			msg := i18n.T(i18n.ResolveLocale(c), "error.rate_limit_unavailable")
-			if isChannelAPIRequest(c) {
-				response.ChannelError(c, 500, response.CodeInternal, msg, "internal_error")
-			} else {
-				response.Error(c, response.CodeInternal, msg)
-			}
-			c.Abort()
-			return
-		}
-		ttlSeconds, _ := toInt64(values[1])
 		if count > int64(rule.MaxRequests) {
-			waitSeconds := int(ttlSeconds)
-			if waitSeconds < 1 {
-				waitSeconds = rule.WindowSeconds
-			}
-			if waitSeconds < 1 {
-				waitSeconds = 1
-			}
+			waitSeconds := rateLimitWaitSeconds(ttlSeconds, rule)
 			msgKey := strings.TrimSpace(rule.MessageKey)
 			if msgKey == "" {
 				msgKey = "error.rate_limited"
@@ -125,6 +90,35 @@ func RateLimitMiddleware(client *redis.Client, rule RateLimitRule, keyFunc RateL
 	}
 }
 
+// runRateLimit 对 key 计数，返回窗口内的请求数与剩余秒数
+func runRateLimit(ctx context.Context, client *redis.Client, key string, rule RateLimitRule) (int64, int64, error) {
+	result, err := rateLimitScript.Run(ctx, client, []string{key}, rule.WindowSeconds, rule.MaxRequests, rule.BlockSeconds).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+	values, ok := result.([]interface{})
+	if !ok || len(values) < 2 {
+		return 0, 0, errors.New("unexpected rate limit result")
+	}
+	count, ok := toInt64(values[0])
+	if !ok {
+		return 0, 0, errors.New("unexpected rate limit count")
+	}
+	ttlSeconds, _ := toInt64(values[1])
+	return count, ttlSeconds, nil
+}
+
+func rateLimitWaitSeconds(ttlSeconds int64, rule RateLimitRule) int {
+	waitSeconds := int(ttlSeconds)
+	if waitSeconds < 1 {
+		waitSeconds = rule.WindowSeconds
+	}
+	if waitSeconds < 1 {
+		waitSeconds = 1
+	}
+	return waitSeconds
+}
+
 func isChannelAPIRequest(c *gin.Context) bool {
 	if c == nil || c.Request == nil {
 		return false
@@ -137,6 +131,14 @@ func KeyByIP(c *gin.Context) string {
 	return c.ClientIP()
 }
 
+// KeyByUserID 使用登录用户 ID 作为限流 key（未登录时回退为 IP）
+func KeyByUserID(c *gin.Context) string {
+	if userID, ok := c.Get("user_id"); ok {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	return c.ClientIP()
+}
+
 // KeyByUpstreamApiKey 使用上游 API Key 作为限流 key
 func KeyByUpstreamApiKey(c *gin.Context) string {
 	apiKey := c.GetHeader("Dujiao-Next-Api-Key")
@@ -54,6 +54,27 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 		BlockSeconds:  cfg.Security.LoginRateLimit.BlockSeconds,
 		MessageKey:    "error.login_too_many",
 	}
+	walletTransferRule := RateLimitRule{
+		Prefix:        fmt.Sprintf("%s:rate:wallet_transfer", redisPrefix),
+		WindowSeconds: 60,
+		MaxRequests:   10,
+		BlockSeconds:  300,
+		MessageKey:    "error.rate_limited",
+	}
+	walletTransferRecipientRule := RateLimitRule{
+		Prefix:        fmt.Sprintf("%s:rate:wallet_transfer_recipient", redisPrefix),
+		WindowSeconds: 60,
+		MaxRequests:   20,
+		BlockSeconds:  300,
+		MessageKey:    "error.rate_limited",
+	}
+	licenseAPIRule := RateLimitRule{
+		Prefix:        fmt.Sprintf("%s:rate:license_api", redisPrefix),
+		WindowSeconds: 60,
+		MaxRequests:   60,
+		BlockSeconds:  60,
+		MessageKey:    "error.rate_limited",
+	}
 	upstreamAPIRule := RateLimitRule{
 		Prefix:        fmt.Sprintf("%s:rate:upstream_api", redisPrefix),
 		WindowSeconds: 60,
@@ -61,6 +82,14 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 		BlockSeconds:  30,
 		MessageKey:    "error.rate_limited",
 	}
+	// 鉴权前按 API Key（缺失时按 IP）兜底限流，上限取密钥可配置的最大每分钟请求数
+	upstreamPreAuthRule := RateLimitRule{
+		Prefix:        fmt.Sprintf("%s:rate:upstream_api_pre", redisPrefix),
+		WindowSeconds: 60,
+		MaxRequests:   constants.ApiCredentialMaxRateLimitPerMinute,
+		BlockSeconds:  30,
+		MessageKey:    "error.rate_limited",
+	}
 
 	// 中间件
 	r.Use(gin.Recovery())
@@ -88,6 +117,11 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 			public.GET("/captcha/image", publicHandler.GetImageCaptcha)
 			public.POST("/affiliate/click", publicHandler.TrackAffiliateClick)
 			public.GET("/member-levels", publicHandler.GetPublicMemberLevels)
+			public.GET("/deliveries/:token", publicHandler.GetDeliveryLink)
+			public.POST("/deliveries/:token/reveal", publicHandler.RevealDeliveryLink)
+			public.POST("/deliveries/:token/received", publicHandler.ConfirmDeliveryLinkReceived)
+			public.GET("/downloads/:token", publicHandler.DownloadFile)
+			public.HEAD("/downloads/:token", publicHandler.DownloadFile)
 		}
 
 		// 游客接口
@@ -119,6 +153,7 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 		// 用户接口（需鉴权）
 		user := apiV1.Group("")
 		user.Use(UserJWTAuthMiddleware(cfg.UserJWT.SecretKey, c.UserRepo))
+		userIdempotency := IdempotencyMiddleware(c.IdempotencyService, userIdempotencyScope, respondUserIdempotencyError)
 		{
 			user.GET("/me", publicHandler.GetCurrentUser)
 			user.GET("/me/login-logs", publicHandler.GetMyLoginLogs)
@@ -151,14 +186,23 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 			user.GET("/payments/latest", publicHandler.GetLatestPayment)
 			user.GET("/wallet", publicHandler.GetMyWallet)
 			user.GET("/wallet/transactions", publicHandler.GetMyWalletTransactions)
+			user.GET("/wallet/statement", publicHandler.GetMyWalletStatement)
 			user.POST("/wallet/payment-channels", publicHandler.GetMyWalletPaymentChannels)
 			user.POST("/wallet/recharge", publicHandler.RechargeWallet)
+			user.GET("/wallet/recharge-bonus-rules", publicHandler.ListWalletRechargeBonusRules)
+			user.GET("/wallet/transfer/recipient", RateLimitMiddleware(redisClient, walletTransferRecipientRule, KeyByUserID), publicHandler.LookupWalletTransferRecipient)
+			user.POST("/wallet/transfer", RateLimitMiddleware(redisClient, walletTransferRule, KeyByUserID), userIdempotency, publicHandler.TransferWallet)
+			user.GET("/wallet/transfers", publicHandler.ListMyWalletTransfers)
+			user.GET("/wallet/withdraws", publicHandler.ListMyWalletWithdraws)
+			user.POST("/wallet/withdraws", publicHandler.ApplyWalletWithdraw)
 			user.GET("/wallet/recharges", publicHandler.ListMyWalletRecharges)
 			user.GET("/wallet/recharges/:recharge_no", publicHandler.GetMyWalletRecharge)
 			user.POST("/wallet/recharge/payments/:id/capture", publicHandler.CaptureMyWalletRechargePayment)
 			user.POST("/gift-cards/redeem", publicHandler.RedeemGiftCard)
 			user.POST("/affiliate/open", publicHandler.OpenAffiliate)
 			user.GET("/affiliate/dashboard", publicHandler.GetAffiliateDashboard)
+			user.GET("/affiliate/funnel", publicHandler.GetAffiliateFunnel)
+			user.GET("/affiliate/funnel/export", publicHandler.ExportAffiliateFunnel)
 			user.GET("/affiliate/commissions", publicHandler.ListAffiliateCommissions)
 			user.GET("/affiliate/withdraws", publicHandler.ListAffiliateWithdraws)
 			user.POST("/affiliate/withdraws", publicHandler.ApplyAffiliateWithdraw)
@@ -168,28 +212,54 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 			user.POST("/api-credential/apply", publicHandler.ApplyApiCredential)
 			user.POST("/api-credential/regenerate", publicHandler.RegenerateMyApiCredential)
 			user.PUT("/api-credential/status", publicHandler.UpdateMyApiCredentialStatus)
+			user.GET("/api-credentials", publicHandler.ListMyApiCredentials)
+			user.POST("/api-credentials", publicHandler.CreateMyApiCredential)
+			user.GET("/api-credentials/usage", publicHandler.GetMyApiCredentialUsage)
+			user.PUT("/api-credentials/:id", publicHandler.UpdateMyApiCredential)
+			user.DELETE("/api-credentials/:id", publicHandler.DeleteMyApiCredential)
+			user.POST("/api-credentials/:id/regenerate", publicHandler.RegenerateMyApiCredentialKey)
+			user.PUT("/api-credentials/:id/status", publicHandler.UpdateMyApiCredentialKeyStatus)
 		}
 
 		// 上游 API（本站作为 B 站点，暴露给下游 A 调用）
 		upstreamAPI := apiV1.Group("/upstream")
-		upstreamAPI.Use(RateLimitMiddleware(redisClient, upstreamAPIRule, KeyByUpstreamApiKey))
-		upstreamAPI.Use(UpstreamAPIAuthMiddleware(c.ApiCredentialRepo))
+		upstreamAPI.Use(RateLimitMiddleware(redisClient, upstreamPreAuthRule, KeyByUpstreamApiKey))
+		upstreamAPI.Use(UpstreamAPIAuthMiddleware(c.ApiCredentialService, redisClient, upstreamAPIRule))
+		catalogScope := RequireUpstreamScope(c.ApiCredentialService, constants.ApiScopeCatalogRead)
+		orderCreateScope := RequireUpstreamScope(c.ApiCredentialService, constants.ApiScopeOrderCreate)
+		orderReadScope := RequireUpstreamScope(c.ApiCredentialService, constants.ApiScopeOrderRead)
+		orderQuota := UpstreamOrderQuotaMiddleware(c.ApiCredentialService)
+		upstreamIdempotency := IdempotencyMiddleware(c.IdempotencyService, upstreamIdempotencyScope, respondUpstreamIdempotencyError)
 		{
 			upstreamAPI.POST("/ping", upstreamHandler.Ping)
-			upstreamAPI.GET("/categories", upstreamHandler.ListCategories)
-			upstreamAPI.GET("/products", upstreamHandler.ListProducts)
-			upstreamAPI.GET("/products/:id", upstreamHandler.GetProduct)
-			upstreamAPI.POST("/orders", upstreamHandler.CreateOrder)
-			upstreamAPI.GET("/orders/:id", upstreamHandler.GetOrder)
-			upstreamAPI.POST("/orders/:id/cancel", upstreamHandler.CancelOrder)
+			upstreamAPI.GET("/categories", catalogScope, upstreamHandler.ListCategories)
+			upstreamAPI.GET("/products", catalogScope, upstreamHandler.ListProducts)
+			upstreamAPI.GET("/products/:id", catalogScope, upstreamHandler.GetProduct)
+			upstreamAPI.POST("/orders", orderCreateScope, upstreamIdempotency, orderQuota, upstreamHandler.CreateOrder)
+			upstreamAPI.GET("/orders/:id", orderReadScope, upstreamHandler.GetOrder)
+			upstreamAPI.POST("/orders/:id/cancel", orderCreateScope, upstreamHandler.CancelOrder)
+			upstreamAPI.POST("/webhooks", catalogScope, upstreamHandler.RegisterCatalogWebhook)
+			upstreamAPI.GET("/webhooks", catalogScope, upstreamHandler.GetCatalogWebhook)
+			upstreamAPI.DELETE("/webhooks", catalogScope, upstreamHandler.DeleteCatalogWebhook)
+		}
+
+		// 授权码校验（供商家自有软件调用）
+		licenseAPI := apiV1.Group("/licenses")
+		licenseAPI.Use(RateLimitMiddleware(redisClient, licenseAPIRule, KeyByIP))
+		{
+			licenseAPI.POST("/verify", publicHandler.VerifyLicense)
+			licenseAPI.POST("/deactivate", publicHandler.DeactivateLicense)
 		}
 
 		// 上游回调接收（本站作为 A 站点，接收 B 的回调）
 		apiV1.POST("/upstream/callback", upstreamHandler.HandleCallback)
+		// 上游协议 OpenAPI 文档
+		apiV1.GET("/upstream/openapi.json", upstreamHandler.OpenAPISpec)
 
 		// 渠道 API（Telegram Bot 等外部服务调用）
 		channelAPI := apiV1.Group("/channel")
 		channelAPI.Use(ChannelAPIAuthMiddleware(c))
+		channelIdempotency := IdempotencyMiddleware(c.IdempotencyService, channelIdempotencyScope, respondChannelIdempotencyError)
 		{
 			channelAPI.GET("/telegram/config", channelHandler.GetBotConfig)
 			channelAPI.POST("/telegram/heartbeat", channelHandler.ReportHeartbeat)
@@ -200,6 +270,8 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 			channelAPI.POST("/affiliate/click", channelHandler.TrackAffiliateClick)
 			channelAPI.POST("/affiliate/open", channelHandler.OpenAffiliate)
 			channelAPI.GET("/affiliate/dashboard", channelHandler.GetAffiliateDashboard)
+			channelAPI.GET("/affiliate/funnel", channelHandler.GetAffiliateFunnel)
+			channelAPI.GET("/affiliate/funnel/export", channelHandler.ExportAffiliateFunnel)
 			channelAPI.GET("/affiliate/commissions", channelHandler.ListAffiliateCommissions)
 			channelAPI.GET("/affiliate/withdraws", channelHandler.ListAffiliateWithdraws)
 			channelAPI.POST("/affiliate/withdraws", channelHandler.ApplyAffiliateWithdraw)
@@ -212,7 +284,7 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 
 			// Order / Payment 端点（购买流程）
 			channelAPI.POST("/orders/preview", channelHandler.PreviewOrder)
-			channelAPI.POST("/orders", channelHandler.CreateOrder)
+			channelAPI.POST("/orders", channelIdempotency, channelHandler.CreateOrder)
 			channelAPI.GET("/orders", channelHandler.ListOrders)
 			channelAPI.GET("/orders/by-order-no/:order_no", channelHandler.GetOrderByOrderNo)
 			channelAPI.GET("/orders/:id", channelHandler.GetOrderStatus)
@@ -221,13 +293,16 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 			channelAPI.GET("/payment-methods", channelHandler.GetPaymentChannels)
 			channelAPI.GET("/payments/latest", channelHandler.GetLatestPayment)
 			channelAPI.GET("/payments/:id", channelHandler.GetPaymentDetail)
-			channelAPI.POST("/payments", channelHandler.CreatePayment)
+			channelAPI.POST("/payments", channelIdempotency, channelHandler.CreatePayment)
 
 			// Wallet 端点（钱包）
 			channelAPI.GET("/wallet", channelHandler.GetWallet)
 			channelAPI.GET("/wallet/transactions", channelHandler.GetWalletTransactions)
 			channelAPI.POST("/wallet/gift-card/redeem", channelHandler.RedeemGiftCard)
-			channelAPI.POST("/wallet/recharge", channelHandler.CreateWalletRecharge)
+			channelAPI.POST("/wallet/recharge", channelIdempotency, channelHandler.CreateWalletRecharge)
+			channelAPI.POST("/wallet/transfer", channelIdempotency, channelHandler.TransferWallet)
+			channelAPI.GET("/wallet/withdraws", channelHandler.GetWalletWithdraws)
+			channelAPI.POST("/wallet/withdraws", channelIdempotency, channelHandler.ApplyWalletWithdraw)
 		}
 
 		apiV1.POST("/payments/callback", publicHandler.PaymentCallback)
@@ -265,6 +340,8 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 				authorized.POST("/products/batch-status", adminHandler.BatchUpdateProductStatus)
 				authorized.POST("/products/batch-category", adminHandler.BatchUpdateProductCategory)
 				authorized.POST("/products/batch-delete", adminHandler.BatchDeleteProducts)
+				authorized.GET("/products/:id/license-key", adminHandler.GetProductLicenseKey)
+				authorized.PUT("/products/:id/license-key", adminHandler.UpdateProductLicenseKey)
 
 				// 文章管理
 				authorized.GET("/posts", adminHandler.GetAdminPosts)
@@ -324,10 +401,16 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 				authorized.GET("/affiliates/users", adminHandler.ListAffiliateUsers)
 				authorized.PATCH("/affiliates/users/:id/status", adminHandler.UpdateAffiliateUserStatus)
 				authorized.PATCH("/affiliates/users/batch-status", adminHandler.BatchUpdateAffiliateUserStatus)
+				authorized.GET("/affiliates/users/:id/risk-report", adminHandler.GetAffiliateUserRiskReport)
 				authorized.GET("/affiliates/commissions", adminHandler.ListAffiliateCommissions)
+				authorized.POST("/affiliates/commissions/:id/approve", adminHandler.ApproveAffiliateCommission)
+				authorized.POST("/affiliates/commissions/:id/reject", adminHandler.RejectAffiliateCommission)
 				authorized.GET("/affiliates/withdraws", adminHandler.ListAffiliateWithdraws)
 				authorized.POST("/affiliates/withdraws/:id/reject", adminHandler.RejectAffiliateWithdraw)
 				authorized.POST("/affiliates/withdraws/:id/pay", adminHandler.PayAffiliateWithdraw)
+				authorized.GET("/affiliates/commission-rules", adminHandler.ListAffiliateCommissionRules)
+				authorized.PUT("/affiliates/commission-rules", adminHandler.SaveAffiliateCommissionRule)
+				authorized.DELETE("/affiliates/commission-rules/:id", adminHandler.DeleteAffiliateCommissionRule)
 
 				// 权限管理
 				authorized.GET("/authz/me", adminHandler.GetAuthzMe)
@@ -357,10 +440,23 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 				authorized.PUT("/media/:id", adminHandler.UpdateMedia)
 				authorized.DELETE("/media/:id", adminHandler.DeleteMedia)
 
+				// 私有文件（文件交付）
+				authorized.GET("/file-assets", adminHandler.ListFileAssets)
+				authorized.POST("/file-assets", adminHandler.UploadFileAsset)
+				authorized.DELETE("/file-assets/:id", adminHandler.DeleteFileAsset)
+
 				// 订单管理
 				authorized.GET("/orders", adminHandler.AdminListOrders)
 				authorized.GET("/orders/:id", adminHandler.AdminGetOrder)
 				authorized.GET("/orders/:id/fulfillment/download", adminHandler.AdminDownloadFulfillment)
+				authorized.GET("/orders/:id/delivery-links", adminHandler.AdminListOrderDeliveryLinks)
+				authorized.GET("/orders/:id/webhook-fulfillments", adminHandler.AdminListOrderWebhookFulfillments)
+				authorized.POST("/orders/:id/webhook-fulfillments/retry", adminHandler.AdminRetryOrderWebhookFulfillment)
+				authorized.GET("/orders/:id/licenses", adminHandler.AdminListOrderLicenses)
+				authorized.GET("/orders/:id/file-downloads", adminHandler.AdminListOrderFileDownloads)
+				authorized.GET("/orders/:id/card-secret-replacements", adminHandler.AdminListOrderCardSecretReplacements)
+				authorized.POST("/orders/:id/card-secret-replacements", adminHandler.AdminReplaceOrderCardSecrets)
+				authorized.POST("/licenses/:id/revoke", adminHandler.AdminRevokeLicense)
 				authorized.PATCH("/orders/:id", adminHandler.AdminUpdateOrderStatus)
 				authorized.POST("/orders/:id/refund-to-wallet", adminHandler.AdminRefundOrderToWallet)
 				authorized.POST("/orders/:id/manual-refund", adminHandler.AdminManualRefundOrder)
@@ -376,6 +472,7 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 				authorized.POST("/card-secrets/export", adminHandler.ExportCardSecrets)
 				authorized.GET("/card-secrets/stats", adminHandler.GetCardSecretStats)
 				authorized.GET("/card-secrets/batches", adminHandler.GetCardSecretBatches)
+				authorized.GET("/card-secrets/defect-report", adminHandler.GetCardSecretDefectReport)
 				authorized.GET("/card-secrets/template", adminHandler.GetCardSecretTemplate)
 				authorized.POST("/gift-cards/generate", adminHandler.GenerateGiftCards)
 				authorized.GET("/gift-cards", adminHandler.GetGiftCards)
@@ -423,18 +520,46 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 				authorized.GET("/users/:id/coupon-usages", adminHandler.GetAdminUserCouponUsages)
 				authorized.GET("/users/:id/wallet", adminHandler.GetAdminUserWallet)
 				authorized.GET("/users/:id/wallet/transactions", adminHandler.GetAdminUserWalletTransactions)
+				authorized.GET("/users/:id/wallet/statement", adminHandler.GetAdminUserWalletStatement)
 				authorized.POST("/users/:id/wallet/adjust", adminHandler.AdjustAdminUserWallet)
 				authorized.PUT("/users/:id/member-level", adminHandler.SetUserMemberLevel)
 				authorized.DELETE("/users/:id/2fa", adminHandler.ResetUser2FA)
 				authorized.GET("/wallet/recharges", adminHandler.GetAdminWalletRecharges)
+				authorized.POST("/wallet/recharges/:id/refund", adminHandler.RefundAdminWalletRecharge)
+				authorized.GET("/wallet/recharge-bonus-rules", adminHandler.GetAdminWalletRechargeBonusRules)
+				authorized.POST("/wallet/recharge-bonus-rules", adminHandler.CreateAdminWalletRechargeBonusRule)
+				authorized.PUT("/wallet/recharge-bonus-rules/:id", adminHandler.UpdateAdminWalletRechargeBonusRule)
+				authorized.DELETE("/wallet/recharge-bonus-rules/:id", adminHandler.DeleteAdminWalletRechargeBonusRule)
+				authorized.GET("/wallet/transfers", adminHandler.GetAdminWalletTransfers)
+				authorized.GET("/wallet/withdraws", adminHandler.GetAdminWalletWithdraws)
+				authorized.POST("/wallet/withdraws/export", adminHandler.ExportAdminWalletWithdraws)
+				authorized.POST("/wallet/withdraws/:id/reject", adminHandler.RejectAdminWalletWithdraw)
+				authorized.POST("/wallet/withdraws/:id/pay", adminHandler.PayAdminWalletWithdraw)
+				authorized.GET("/wallet/ledger-audits", adminHandler.GetAdminWalletLedgerAudits)
+				authorized.POST("/wallet/ledger-audits", adminHandler.RunAdminWalletLedgerAudit)
+				authorized.GET("/wallet/ledger-audits/:id", adminHandler.GetAdminWalletLedgerAudit)
+				authorized.GET("/wallet/ledger-discrepancies", adminHandler.GetAdminWalletLedgerDiscrepancies)
+				authorized.PUT("/wallet/ledger-discrepancies/:id/resolve", adminHandler.ResolveAdminWalletLedgerDiscrepancy)
 
 				// API 凭证审核管理
 				authorized.GET("/api-credentials", adminHandler.GetApiCredentials)
 				authorized.GET("/api-credentials/:id", adminHandler.GetApiCredential)
+				authorized.PUT("/api-credentials/:id", adminHandler.UpdateApiCredentialSettings)
 				authorized.POST("/api-credentials/:id/approve", adminHandler.ApproveApiCredential)
 				authorized.POST("/api-credentials/:id/reject", adminHandler.RejectApiCredential)
 				authorized.PUT("/api-credentials/:id/status", adminHandler.UpdateApiCredentialStatus)
 				authorized.DELETE("/api-credentials/:id", adminHandler.DeleteApiCredential)
+				authorized.PUT("/api-credentials/:id/price-list", adminHandler.AssignApiCredentialPriceList)
+				authorized.GET("/api-credential-usages", adminHandler.GetApiCredentialUsages)
+				authorized.PUT("/users/:id/price-list", adminHandler.AssignUserPriceList)
+				authorized.GET("/reseller-price-lists", adminHandler.GetResellerPriceLists)
+				authorized.POST("/reseller-price-lists", adminHandler.CreateResellerPriceList)
+				authorized.GET("/reseller-price-lists/margins", adminHandler.GetResellerMargins)
+				authorized.GET("/reseller-price-lists/:id", adminHandler.GetResellerPriceList)
+				authorized.PUT("/reseller-price-lists/:id", adminHandler.UpdateResellerPriceList)
+				authorized.DELETE("/reseller-price-lists/:id", adminHandler.DeleteResellerPriceList)
+				authorized.GET("/reseller-price-lists/:id/items", adminHandler.GetResellerPriceListItems)
+				authorized.PUT("/reseller-price-lists/:id/items", adminHandler.ReplaceResellerPriceListItems)
 
 				// 站点对接连接管理
 				authorized.GET("/site-connections", adminHandler.GetSiteConnections)
@@ -443,8 +568,11 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 				authorized.PUT("/site-connections/:id", adminHandler.UpdateSiteConnection)
 				authorized.DELETE("/site-connections/:id", adminHandler.DeleteSiteConnection)
 				authorized.POST("/site-connections/:id/ping", adminHandler.PingSiteConnection)
+				authorized.POST("/site-connections/:id/dry-run", adminHandler.DryRunSiteConnection)
 				authorized.PUT("/site-connections/:id/status", adminHandler.UpdateSiteConnectionStatus)
 				authorized.POST("/site-connections/:id/reapply-markup", adminHandler.ReapplyConnectionMarkup)
+				authorized.POST("/site-connections/:id/balance-check", adminHandler.CheckSiteConnectionBalance)
+				authorized.GET("/site-connections/:id/balance-snapshots", adminHandler.GetSiteConnectionBalanceSnapshots)
 
 				// 商品映射管理
 				authorized.GET("/product-mappings", adminHandler.GetProductMappings)
@@ -453,6 +581,7 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 				authorized.POST("/product-mappings/batch-import", adminHandler.BatchImportUpstreamProducts)
 				authorized.POST("/product-mappings/:id/sync", adminHandler.SyncProductMapping)
 				authorized.PUT("/product-mappings/:id/status", adminHandler.UpdateProductMappingStatus)
+				authorized.PUT("/product-mappings/:id/margin-guard", adminHandler.UpdateProductMappingMarginGuard)
 				authorized.DELETE("/product-mappings/:id", adminHandler.DeleteProductMapping)
 				authorized.POST("/product-mappings/batch-sync", adminHandler.BatchSyncProductMappings)
 				authorized.POST("/product-mappings/batch-status", adminHandler.BatchUpdateProductMappingStatus)
@@ -460,6 +589,7 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 				authorized.GET("/upstream-products", adminHandler.ListUpstreamProducts)
 				authorized.GET("/upstream-categories", adminHandler.ListUpstreamCategories)
 				authorized.POST("/product-mappings/batch-import-by-category", adminHandler.BatchImportByCategory)
+				authorized.GET("/margin-guard-events", adminHandler.GetMarginGuardEvents)
 
 				// 采购单管理
 				authorized.GET("/procurement-orders", adminHandler.GetProcurementOrders)
@@ -469,8 +599,23 @@ func SetupRouter(cfg *config.Config, c *provider.Container) *gin.Engine {
 				authorized.POST("/procurement-orders/:id/retry", adminHandler.RetryProcurementOrder)
 				authorized.POST("/procurement-orders/:id/cancel", adminHandler.CancelProcurementOrder)
 
+				// 卡密自动补货规则
+				authorized.GET("/card-replenish-rules", adminHandler.GetCardReplenishRules)
+				authorized.POST("/card-replenish-rules", adminHandler.CreateCardReplenishRule)
+				authorized.PUT("/card-replenish-rules/:id", adminHandler.UpdateCardReplenishRule)
+				authorized.DELETE("/card-replenish-rules/:id", adminHandler.DeleteCardReplenishRule)
+				authorized.POST("/card-replenish-rules/:id/run", adminHandler.RunCardReplenishRule)
+
+				// SKU 多来源供货
+				authorized.GET("/sku-sources", adminHandler.GetSKUSources)
+				authorized.POST("/sku-sources", adminHandler.CreateSKUSource)
+				authorized.PUT("/sku-sources/:id", adminHandler.UpdateSKUSource)
+				authorized.DELETE("/sku-sources/:id", adminHandler.DeleteSKUSource)
+
 				// 对账管理
 				authorized.POST("/reconciliation/run", adminHandler.RunReconciliation)
+				authorized.POST("/reconciliation/payment/import", adminHandler.ImportPaymentStatement)
+				authorized.POST("/reconciliation/payment/run", adminHandler.RunPaymentReconciliation)
 				authorized.GET("/reconciliation/jobs", adminHandler.GetReconciliationJobs)
 				authorized.GET("/reconciliation/jobs/:id", adminHandler.GetReconciliationJob)
 				authorized.PUT("/reconciliation/items/:id/resolve", adminHandler.ResolveReconciliationItem)
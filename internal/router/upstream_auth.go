@@ -1,56 +1,62 @@
 package router
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
-	"time"
 
 	"github.com/dujiao-next/internal/constants"
 	"github.com/dujiao-next/internal/logger"
-	"github.com/dujiao-next/internal/repository"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/service"
 	"github.com/dujiao-next/internal/upstream"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
 )
 
 const upstreamUserIDKey = "upstream_user_id"
 const upstreamCredentialIDKey = "upstream_credential_id"
+const upstreamCredentialKey = "upstream_credential"
 
-// UpstreamAPIAuthMiddleware 上游 API 签名鉴权中间件
-func UpstreamAPIAuthMiddleware(credRepo repository.ApiCredentialRepository) gin.HandlerFunc {
+// UpstreamAPIAuthMiddleware 上游 API 签名鉴权中间件。
+// 签名通过后依次校验 IP 白名单与按密钥的频率限制（密钥未配置时使用 rule 的全局默认值）。
+func UpstreamAPIAuthMiddleware(credSvc *service.ApiCredentialService, redisClient *redis.Client, rule RateLimitRule) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		apiKey := c.GetHeader(upstream.HeaderApiKey)
 		timestampStr := c.GetHeader(upstream.HeaderTimestamp)
 		signature := c.GetHeader(upstream.HeaderSignature)
 
 		if apiKey == "" || timestampStr == "" || signature == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": "missing_auth_headers", "error_message": "missing authentication headers"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": upstream.ErrorCodeMissingAuthHeaders, "error_message": "missing authentication headers"})
 			return
 		}
 
 		timestamp, err := upstream.ParseTimestamp(timestampStr)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": "invalid_timestamp", "error_message": "invalid timestamp"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": upstream.ErrorCodeInvalidTimestamp, "error_message": "invalid timestamp"})
 			return
 		}
 
 		if !upstream.IsTimestampValid(timestamp) {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": "timestamp_expired", "error_message": "timestamp expired"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": upstream.ErrorCodeTimestampExpired, "error_message": "timestamp expired"})
 			return
 		}
 
-		cred, err := credRepo.GetByApiKey(apiKey)
+		cred, err := credSvc.GetByApiKey(apiKey)
 		if err != nil {
 			logger.Errorw("upstream_auth_db_error", "error", err)
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": "internal_error", "error_message": "internal error"})
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": upstream.ErrorCodeInternal, "error_message": "internal error"})
 			return
 		}
 		if cred == nil || cred.Status != constants.ApiCredentialStatusApproved || !cred.IsActive {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"ok": false, "error_code": "invalid_api_key", "error_message": "api key is invalid or disabled"})
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"ok": false, "error_code": upstream.ErrorCodeInvalidApiKey, "error_message": "api key is invalid or disabled"})
 			return
 		}
 		if cred.User == nil || cred.User.Status != constants.UserStatusActive {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"ok": false, "error_code": "user_disabled", "error_message": "user account is disabled"})
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"ok": false, "error_code": upstream.ErrorCodeUserDisabled, "error_message": "user account is disabled"})
 			return
 		}
 
@@ -60,7 +66,7 @@ func UpstreamAPIAuthMiddleware(credRepo repository.ApiCredentialRepository) gin.
 			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 10<<20)
 			body, err = io.ReadAll(c.Request.Body)
 			if err != nil {
-				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": "bad_request", "error_message": "failed to read request body"})
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"ok": false, "error_code": upstream.ErrorCodeBadRequest, "error_message": "failed to read request body"})
 				return
 			}
 			// 重置 body 供后续 handler 读取
@@ -73,28 +79,94 @@ func UpstreamAPIAuthMiddleware(credRepo repository.ApiCredentialRepository) gin.
 		path := c.Request.URL.Path
 
 		if !upstream.Verify(cred.ApiSecret, method, path, signature, timestamp, body) {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": "invalid_signature", "error_message": "signature verification failed"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"ok": false, "error_code": upstream.ErrorCodeInvalidSignature, "error_message": "signature verification failed"})
 			return
 		}
 
-		// 更新最后使用时间（异步，不阻塞请求）
-		now := time.Now()
-		cred.LastUsedAt = &now
-		go func() {
-			if updateErr := credRepo.Update(cred); updateErr != nil {
-				logger.Warnw("upstream_auth_update_last_used_failed", "error", updateErr)
-			}
-		}()
+		if !service.ApiCredentialAllowsIP(cred, c.ClientIP()) {
+			credSvc.RecordRejected(cred)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"ok": false, "error_code": upstream.ErrorCodeIPNotAllowed, "error_message": "client ip is not in the allowlist"})
+			return
+		}
+		if !allowUpstreamRequest(c, redisClient, rule, cred) {
+			return
+		}
+
+		credSvc.TouchLastUsed(cred)
+		credSvc.RecordRequest(cred)
 
 		// 将凭证信息存入 context
 		c.Set(upstreamUserIDKey, cred.UserID)
 		c.Set(upstreamCredentialIDKey, cred.ID)
+		c.Set(upstreamCredentialKey, cred)
 		c.Set("upstream_api_key", cred.ApiKey)
 
 		c.Next()
 	}
 }
 
+// allowUpstreamRequest 按密钥执行频率限制，超限或限流服务不可用时中止请求
+func allowUpstreamRequest(c *gin.Context, redisClient *redis.Client, rule RateLimitRule, cred *models.ApiCredential) bool {
+	if cred.RateLimitPerMinute > 0 {
+		rule.WindowSeconds = 60
+		rule.MaxRequests = cred.RateLimitPerMinute
+	}
+	if redisClient == nil || rule.WindowSeconds <= 0 || rule.MaxRequests <= 0 {
+		return true
+	}
+	key := fmt.Sprintf("%s:%d", rule.Prefix, cred.ID)
+	count, ttlSeconds, err := runRateLimit(c.Request.Context(), redisClient, key, rule)
+	if err != nil {
+		logger.Warnw("upstream_rate_limit_unavailable", "credential_id", cred.ID, "error", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": upstream.ErrorCodeInternal, "error_message": "rate limit service unavailable"})
+		return false
+	}
+	if count > int64(rule.MaxRequests) {
+		waitSeconds := rateLimitWaitSeconds(ttlSeconds, rule)
+		c.Header("Retry-After", fmt.Sprintf("%d", waitSeconds))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"ok": false, "error_code": upstream.ErrorCodeRateLimitExceeded, "error_message": fmt.Sprintf("too many requests, retry after %d seconds", waitSeconds)})
+		return false
+	}
+	return true
+}
+
+// RequireUpstreamScope 要求当前密钥拥有指定权限范围
+func RequireUpstreamScope(credSvc *service.ApiCredentialService, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cred := upstreamCredentialFromContext(c)
+		if !service.ApiCredentialHasScope(cred, scope) {
+			credSvc.RecordRejected(cred)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"ok": false, "error_code": upstream.ErrorCodeInsufficientScope, "error_message": fmt.Sprintf("api key lacks scope %s", scope)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// UpstreamOrderQuotaMiddleware 当日下单数或下单金额已达上限时拒绝下单（金额的逐单校验在下单时进行）
+func UpstreamOrderQuotaMiddleware(credSvc *service.ApiCredentialService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cred := upstreamCredentialFromContext(c)
+		if err := credSvc.CheckOrderQuota(cred, decimal.Zero); err != nil {
+			if errors.Is(err, service.ErrApiCredentialOrderQuota) || errors.Is(err, service.ErrApiCredentialAmountQuota) {
+				credSvc.RecordRejected(cred)
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"ok": false, "error_code": upstream.ErrorCodeQuotaExceeded, "error_message": err.Error()})
+				return
+			}
+			logger.Errorw("upstream_order_quota_check_failed", "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"ok": false, "error_code": upstream.ErrorCodeInternal, "error_message": "internal error"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func upstreamCredentialFromContext(c *gin.Context) *models.ApiCredential {
+	v, _ := c.Get(upstreamCredentialKey)
+	cred, _ := v.(*models.ApiCredential)
+	return cred
+}
+
 // bodyReader 实现 io.Reader，用于重置 body
 type bodyReader struct {
 	data   []byte
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+const affiliateCommissionRuleRemarkMaxRune = 255
+
+// AffiliateCommissionRuleInput 返利比例规则保存输入
+type AffiliateCommissionRuleInput struct {
+	ScopeType   string
+	ScopeID     uint
+	RatePercent float64
+	Remark      string
+}
+
+// affiliateOrderCommission 订单佣金计算结果
+type affiliateOrderCommission struct {
+	BaseAmount       decimal.Decimal
+	CommissionAmount decimal.Decimal
+}
+
+// affiliateRateResolver 按 SKU > 商品 > 分类（本级、上级）> 推广用户专属 > 全局 的优先级解析返利比例
+type affiliateRateResolver struct {
+	skuRates       map[uint]decimal.Decimal
+	productRates   map[uint]decimal.Decimal
+	categoryRates  map[uint]decimal.Decimal
+	categoryParent map[uint]uint
+	defaultRate    decimal.Decimal
+}
+
+func (r affiliateRateResolver) resolve(skuID uint, product models.Product) decimal.Decimal {
+	if rate, ok := r.skuRates[skuID]; ok && skuID > 0 {
+		return rate
+	}
+	if rate, ok := r.productRates[product.ID]; ok {
+		return rate
+	}
+	if rate, ok := r.categoryRates[product.CategoryID]; ok {
+		return rate
+	}
+	if parentID := r.categoryParent[product.CategoryID]; parentID > 0 {
+		if rate, ok := r.categoryRates[parentID]; ok {
+			return rate
+		}
+	}
+	return r.defaultRate
+}
+
+// ListCommissionRules 后台查询返利比例规则
+func (s *AffiliateService) ListCommissionRules(scopeType string) ([]models.AffiliateCommissionRule, error) {
+	if s.repo == nil {
+		return []models.AffiliateCommissionRule{}, nil
+	}
+	scope := strings.TrimSpace(scopeType)
+	if scope != "" && !isAffiliateRuleScope(scope) {
+		return nil, ErrAffiliateCommissionRuleInvalid
+	}
+	return s.repo.ListCommissionRules(scope)
+}
+
+// SaveCommissionRule 后台新增或更新返利比例规则（同一范围对象仅保留一条）
+func (s *AffiliateService) SaveCommissionRule(input AffiliateCommissionRuleInput) (*models.AffiliateCommissionRule, error) {
+	if s.repo == nil {
+		return nil, ErrNotFound
+	}
+	scope := strings.TrimSpace(input.ScopeType)
+	if !isAffiliateRuleScope(scope) || input.ScopeID == 0 {
+		return nil, ErrAffiliateCommissionRuleInvalid
+	}
+	rate := decimal.NewFromFloat(input.RatePercent).Round(2)
+	if rate.LessThan(decimal.NewFromInt(affiliateCommissionRateMin)) || rate.GreaterThan(decimal.NewFromInt(affiliateCommissionRateMax)) {
+		return nil, ErrAffiliateCommissionRuleInvalid
+	}
+
+	switch scope {
+	case constants.AffiliateRuleScopeAffiliate:
+		profile, err := s.repo.GetProfileByID(input.ScopeID)
+		if err != nil {
+			return nil, err
+		}
+		if profile == nil {
+			return nil, ErrNotFound
+		}
+	case constants.AffiliateRuleScopeProduct:
+		if s.productRepo != nil {
+			products, err := s.productRepo.ListByIDs([]uint{input.ScopeID})
+			if err != nil {
+				return nil, err
+			}
+			if len(products) == 0 {
+				return nil, ErrNotFound
+			}
+		}
+	}
+
+	rule, err := s.repo.GetCommissionRuleByScope(scope, input.ScopeID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if rule == nil {
+		rule = &models.AffiliateCommissionRule{
+			ScopeType: scope,
+			ScopeID:   input.ScopeID,
+			CreatedAt: now,
+		}
+	}
+	rule.RatePercent = models.NewMoneyFromDecimal(rate)
+	rule.Remark = normalizeSettingTextWithRuneLimit(input.Remark, affiliateCommissionRuleRemarkMaxRune)
+	rule.UpdatedAt = now
+	if err := s.repo.SaveCommissionRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeleteCommissionRule 后台删除返利比例规则
+func (s *AffiliateService) DeleteCommissionRule(id uint) error {
+	if s.repo == nil || id == 0 {
+		return ErrNotFound
+	}
+	rule, err := s.repo.GetCommissionRuleByID(id)
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		return ErrNotFound
+	}
+	return s.repo.DeleteCommissionRule(id)
+}
+
+// calculateOrderCommission 按商品逐项解析返利比例并汇总佣金基数与佣金金额（比例为 0 的商品不计入基数）
+func (s *AffiliateService) calculateOrderCommission(order *models.Order, profileID uint, setting AffiliateSetting) (affiliateOrderCommission, error) {
+	result := affiliateOrderCommission{BaseAmount: decimal.Zero, CommissionAmount: decimal.Zero}
+	if order == nil || s.productRepo == nil {
+		return result, nil
+	}
+	productIDs := collectAffiliateProductIDs(order)
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+	products, err := s.productRepo.ListByIDs(productIDs)
+	if err != nil {
+		return result, err
+	}
+	productMap := make(map[uint]models.Product, len(products))
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+
+	targetOrders := order.Children
+	if len(targetOrders) == 0 {
+		targetOrders = []models.Order{*order}
+	}
+
+	resolver, err := s.loadAffiliateRateResolver(targetOrders, productMap, profileID, setting)
+	if err != nil {
+		return result, err
+	}
+
+	useProfit := setting.CommissionBasis == constants.AffiliateCommissionBasisProfit
+	weighted := decimal.Zero
+	for _, current := range targetOrders {
+		for _, item := range current.Items {
+			product, ok := productMap[item.ProductID]
+			if !ok || !product.IsAffiliateEnabled {
+				continue
+			}
+			base := item.TotalPrice.Decimal.Sub(item.CouponDiscount.Decimal).Round(2)
+			if useProfit {
+				cost := item.CostPrice.Decimal.Mul(decimal.NewFromInt(int64(item.Quantity)))
+				base = base.Sub(cost).Round(2)
+			}
+			if base.LessThanOrEqual(decimal.Zero) {
+				continue
+			}
+			// 比例为 0 视为排除返利，不计入基数，二级返利同样不按其计算
+			rate := resolver.resolve(item.SKUID, product)
+			if rate.LessThanOrEqual(decimal.Zero) {
+				continue
+			}
+			result.BaseAmount = result.BaseAmount.Add(base).Round(2)
+			weighted = weighted.Add(base.Mul(rate))
+		}
+	}
+	result.CommissionAmount = weighted.Div(decimal.NewFromInt(100)).Round(2)
+	return result, nil
+}
+
+func (s *AffiliateService) loadAffiliateRateResolver(
+	targetOrders []models.Order,
+	productMap map[uint]models.Product,
+	profileID uint,
+	setting AffiliateSetting,
+) (affiliateRateResolver, error) {
+	resolver := affiliateRateResolver{
+		skuRates:       map[uint]decimal.Decimal{},
+		productRates:   map[uint]decimal.Decimal{},
+		categoryRates:  map[uint]decimal.Decimal{},
+		categoryParent: map[uint]uint{},
+		defaultRate:    decimal.NewFromFloat(setting.CommissionRate).Round(2),
+	}
+	if s.repo == nil {
+		return resolver, nil
+	}
+
+	skuIDs := make([]uint, 0)
+	for _, current := range targetOrders {
+		for _, item := range current.Items {
+			if item.SKUID > 0 {
+				skuIDs = append(skuIDs, item.SKUID)
+			}
+		}
+	}
+	productIDs := make([]uint, 0, len(productMap))
+	categoryIDs := make([]uint, 0, len(productMap))
+	for id, product := range productMap {
+		productIDs = append(productIDs, id)
+		if product.CategoryID > 0 {
+			categoryIDs = append(categoryIDs, product.CategoryID)
+		}
+	}
+
+	parents, err := s.repo.ListCategoryParentIDs(categoryIDs)
+	if err != nil {
+		return resolver, err
+	}
+	resolver.categoryParent = parents
+	for _, parentID := range parents {
+		if parentID > 0 {
+			categoryIDs = append(categoryIDs, parentID)
+		}
+	}
+
+	scopes := []struct {
+		scopeType string
+		ids       []uint
+		target    map[uint]decimal.Decimal
+	}{
+		{constants.AffiliateRuleScopeSKU, skuIDs, resolver.skuRates},
+		{constants.AffiliateRuleScopeProduct, productIDs, resolver.productRates},
+		{constants.AffiliateRuleScopeCategory, categoryIDs, resolver.categoryRates},
+	}
+	for _, scope := range scopes {
+		rules, err := s.repo.ListCommissionRulesByScopes(scope.scopeType, scope.ids)
+		if err != nil {
+			return resolver, err
+		}
+		for _, rule := range rules {
+			scope.target[rule.ScopeID] = rule.RatePercent.Decimal.Round(2)
+		}
+	}
+
+	if profileID > 0 {
+		rule, err := s.repo.GetCommissionRuleByScope(constants.AffiliateRuleScopeAffiliate, profileID)
+		if err != nil {
+			return resolver, err
+		}
+		if rule != nil {
+			resolver.defaultRate = rule.RatePercent.Decimal.Round(2)
+		}
+	}
+	return resolver, nil
+}
+
+func isAffiliateRuleScope(scope string) bool {
+	switch scope {
+	case constants.AffiliateRuleScopeCategory,
+		constants.AffiliateRuleScopeProduct,
+		constants.AffiliateRuleScopeSKU,
+		constants.AffiliateRuleScopeAffiliate:
+		return true
+	default:
+		return false
+	}
+}
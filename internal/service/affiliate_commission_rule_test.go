@@ -0,0 +1,230 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func TestHandleOrderPaidResolvesCommissionRulePrecedence(t *testing.T) {
+	svc, db := setupAffiliateServiceTest(t)
+
+	promoter := createAffiliateTestUser(t, db, "affiliate-rule-promoter@example.com")
+	buyer := createAffiliateTestUser(t, db, "affiliate-rule-buyer@example.com")
+	profile := createAffiliateTestProfile(t, db, promoter.ID, "AFFRL001", constants.AffiliateProfileStatusActive)
+
+	parent := models.Category{Slug: "rule-parent", NameJSON: models.JSON{"zh-CN": "父分类"}}
+	if err := db.Create(&parent).Error; err != nil {
+		t.Fatalf("create parent category failed: %v", err)
+	}
+	child := models.Category{ParentID: parent.ID, Slug: "rule-child", NameJSON: models.JSON{"zh-CN": "子分类"}}
+	if err := db.Create(&child).Error; err != nil {
+		t.Fatalf("create child category failed: %v", err)
+	}
+
+	skuProduct := createAffiliateRuleTestProduct(t, db, child.ID)
+	productRuled := createAffiliateRuleTestProduct(t, db, child.ID)
+	categoryOnly := createAffiliateRuleTestProduct(t, db, child.ID)
+
+	rules := []AffiliateCommissionRuleInput{
+		{ScopeType: constants.AffiliateRuleScopeSKU, ScopeID: 901, RatePercent: 30},
+		{ScopeType: constants.AffiliateRuleScopeProduct, ScopeID: skuProduct.ID, RatePercent: 1},
+		{ScopeType: constants.AffiliateRuleScopeProduct, ScopeID: productRuled.ID, RatePercent: 10},
+		{ScopeType: constants.AffiliateRuleScopeCategory, ScopeID: parent.ID, RatePercent: 5},
+		{ScopeType: constants.AffiliateRuleScopeAffiliate, ScopeID: profile.ID, RatePercent: 50},
+	}
+	for _, rule := range rules {
+		if _, err := svc.SaveCommissionRule(rule); err != nil {
+			t.Fatalf("save rule %+v failed: %v", rule, err)
+		}
+	}
+	if _, err := svc.SaveCommissionRule(AffiliateCommissionRuleInput{ScopeType: "unknown", ScopeID: 1, RatePercent: 5}); err != ErrAffiliateCommissionRuleInvalid {
+		t.Fatalf("expected invalid scope rejected, got %v", err)
+	}
+
+	order := createAffiliateRuleTestOrder(t, db, buyer.ID, profile.ID, []models.OrderItem{
+		{ProductID: skuProduct.ID, SKUID: 901, UnitPrice: affiliateTestMoney(100), TotalPrice: affiliateTestMoney(100), Quantity: 1},
+		{ProductID: productRuled.ID, SKUID: 902, UnitPrice: affiliateTestMoney(100), TotalPrice: affiliateTestMoney(100), Quantity: 1},
+		{ProductID: categoryOnly.ID, SKUID: 903, UnitPrice: affiliateTestMoney(100), TotalPrice: affiliateTestMoney(100), Quantity: 1},
+	})
+	if err := svc.HandleOrderPaid(order.ID); err != nil {
+		t.Fatalf("handle order paid failed: %v", err)
+	}
+
+	var commission models.AffiliateCommission
+	if err := db.Where("order_id = ?", order.ID).First(&commission).Error; err != nil {
+		t.Fatalf("load commission failed: %v", err)
+	}
+	// SKU 30 + 商品 10 + 上级分类 5，推广用户专属比例仅替代全局比例。
+	if !commission.CommissionAmount.Decimal.Equal(decimal.NewFromInt(45)) {
+		t.Fatalf("expected commission 45, got %s", commission.CommissionAmount.String())
+	}
+	if !commission.BaseAmount.Decimal.Equal(decimal.NewFromInt(300)) || !commission.RatePercent.Decimal.Equal(decimal.NewFromInt(15)) {
+		t.Fatalf("unexpected base/rate: %s / %s", commission.BaseAmount.String(), commission.RatePercent.String())
+	}
+}
+
+func TestHandleOrderPaidUsesProfitBasisAndAffiliateRate(t *testing.T) {
+	svc, db := setupAffiliateServiceTest(t)
+	if _, err := svc.settingService.UpdateAffiliateSetting(AffiliateSetting{
+		Enabled:         true,
+		CommissionRate:  20,
+		CommissionBasis: constants.AffiliateCommissionBasisProfit,
+	}); err != nil {
+		t.Fatalf("update affiliate setting failed: %v", err)
+	}
+
+	promoter := createAffiliateTestUser(t, db, "affiliate-profit-promoter@example.com")
+	buyer := createAffiliateTestUser(t, db, "affiliate-profit-buyer@example.com")
+	profile := createAffiliateTestProfile(t, db, promoter.ID, "AFFPF001", constants.AffiliateProfileStatusActive)
+	if _, err := svc.SaveCommissionRule(AffiliateCommissionRuleInput{
+		ScopeType:   constants.AffiliateRuleScopeAffiliate,
+		ScopeID:     profile.ID,
+		RatePercent: 40,
+	}); err != nil {
+		t.Fatalf("save affiliate rule failed: %v", err)
+	}
+
+	product := createAffiliateRuleTestProduct(t, db, 1)
+	order := createAffiliateRuleTestOrder(t, db, buyer.ID, profile.ID, []models.OrderItem{
+		{
+			ProductID:      product.ID,
+			SKUID:          1,
+			UnitPrice:      affiliateTestMoney(50),
+			CostPrice:      affiliateTestMoney(30),
+			Quantity:       2,
+			TotalPrice:     affiliateTestMoney(100),
+			CouponDiscount: affiliateTestMoney(10),
+		},
+	})
+	if err := svc.HandleOrderPaid(order.ID); err != nil {
+		t.Fatalf("handle order paid failed: %v", err)
+	}
+
+	var commission models.AffiliateCommission
+	if err := db.Where("order_id = ?", order.ID).First(&commission).Error; err != nil {
+		t.Fatalf("load commission failed: %v", err)
+	}
+	// 利润 = 100 - 10 - 30*2 = 30，按推广用户专属比例 40% 计算。
+	if !commission.BaseAmount.Decimal.Equal(decimal.NewFromInt(30)) || !commission.CommissionAmount.Decimal.Equal(decimal.NewFromInt(12)) {
+		t.Fatalf("unexpected profit commission: base=%s amount=%s", commission.BaseAmount.String(), commission.CommissionAmount.String())
+	}
+}
+
+func TestHandleOrderPaidSecondTierExcludesZeroRateItems(t *testing.T) {
+	svc, db := setupAffiliateServiceTest(t)
+	if _, err := svc.settingService.UpdateAffiliateSetting(AffiliateSetting{
+		Enabled:           true,
+		CommissionRate:    20,
+		SecondTierEnabled: true,
+		SecondTierRate:    5,
+	}); err != nil {
+		t.Fatalf("update affiliate setting failed: %v", err)
+	}
+
+	parentUser := createAffiliateTestUser(t, db, "affiliate-zero-parent@example.com")
+	promoter := createAffiliateTestUser(t, db, "affiliate-zero-promoter@example.com")
+	buyer := createAffiliateTestUser(t, db, "affiliate-zero-buyer@example.com")
+	parentProfile := createAffiliateTestProfile(t, db, parentUser.ID, "AFFZP001", constants.AffiliateProfileStatusActive)
+	profile := createAffiliateTestProfile(t, db, promoter.ID, "AFFZR001", constants.AffiliateProfileStatusActive)
+	if err := svc.BindUserReferral(promoter.ID, parentProfile.AffiliateCode, ""); err != nil {
+		t.Fatalf("bind referral failed: %v", err)
+	}
+
+	rated := createAffiliateRuleTestProduct(t, db, 1)
+	excluded := createAffiliateRuleTestProduct(t, db, 1)
+	if _, err := svc.SaveCommissionRule(AffiliateCommissionRuleInput{
+		ScopeType:   constants.AffiliateRuleScopeProduct,
+		ScopeID:     excluded.ID,
+		RatePercent: 0,
+	}); err != nil {
+		t.Fatalf("save zero rate rule failed: %v", err)
+	}
+
+	order := createAffiliateRuleTestOrder(t, db, buyer.ID, profile.ID, []models.OrderItem{
+		{ProductID: rated.ID, SKUID: 1, UnitPrice: affiliateTestMoney(100), TotalPrice: affiliateTestMoney(100), Quantity: 1},
+		{ProductID: excluded.ID, SKUID: 2, UnitPrice: affiliateTestMoney(300), TotalPrice: affiliateTestMoney(300), Quantity: 1},
+	})
+	if err := svc.HandleOrderPaid(order.ID); err != nil {
+		t.Fatalf("handle order paid failed: %v", err)
+	}
+
+	var rows []models.AffiliateCommission
+	if err := db.Where("order_id = ?", order.ID).Order("level asc").Find(&rows).Error; err != nil {
+		t.Fatalf("load commissions failed: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 commissions, got %d", len(rows))
+	}
+	// 比例为 0 的商品不计入基数：直推 100*20%，二级 100*5%。
+	if !rows[0].BaseAmount.Decimal.Equal(decimal.NewFromInt(100)) || !rows[0].CommissionAmount.Decimal.Equal(decimal.NewFromInt(20)) {
+		t.Fatalf("unexpected direct commission: base=%s amount=%s", rows[0].BaseAmount.String(), rows[0].CommissionAmount.String())
+	}
+	if rows[1].AffiliateProfileID != parentProfile.ID || !rows[1].BaseAmount.Decimal.Equal(decimal.NewFromInt(100)) ||
+		!rows[1].CommissionAmount.Decimal.Equal(decimal.NewFromInt(5)) {
+		t.Fatalf("unexpected second tier commission: %+v", rows[1])
+	}
+}
+
+func affiliateTestMoney(value int64) models.Money {
+	return models.NewMoneyFromDecimal(decimal.NewFromInt(value))
+}
+
+func createAffiliateRuleTestProduct(t *testing.T, db *gorm.DB, categoryID uint) models.Product {
+	t.Helper()
+
+	row := models.Product{
+		CategoryID:         categoryID,
+		Slug:               fmt.Sprintf("affiliate-rule-%d", time.Now().UnixNano()),
+		TitleJSON:          models.JSON{"zh-CN": "返利规则商品"},
+		PriceAmount:        affiliateTestMoney(100),
+		FulfillmentType:    constants.FulfillmentTypeManual,
+		IsAffiliateEnabled: true,
+		IsActive:           true,
+	}
+	if err := db.Create(&row).Error; err != nil {
+		t.Fatalf("create product failed: %v", err)
+	}
+	return row
+}
+
+func createAffiliateRuleTestOrder(t *testing.T, db *gorm.DB, userID, profileID uint, items []models.OrderItem) models.Order {
+	t.Helper()
+
+	now := time.Now()
+	total := decimal.Zero
+	for _, item := range items {
+		total = total.Add(item.TotalPrice.Decimal)
+	}
+	order := models.Order{
+		OrderNo:            fmt.Sprintf("AFF-RULE-%d", now.UnixNano()),
+		UserID:             userID,
+		Status:             constants.OrderStatusPaid,
+		Currency:           "CNY",
+		OriginalAmount:     models.NewMoneyFromDecimal(total),
+		TotalAmount:        models.NewMoneyFromDecimal(total),
+		AffiliateProfileID: &profileID,
+		PaidAt:             &now,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatalf("create order failed: %v", err)
+	}
+	for _, item := range items {
+		item.OrderID = order.ID
+		item.TitleJSON = models.JSON{"zh-CN": "返利规则商品"}
+		item.FulfillmentType = constants.FulfillmentTypeManual
+		item.CreatedAt = now
+		item.UpdatedAt = now
+		if err := db.Create(&item).Error; err != nil {
+			t.Fatalf("create order item failed: %v", err)
+		}
+	}
+	return order
+}
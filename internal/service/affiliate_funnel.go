@@ -0,0 +1,190 @@
+package service
+
+import (
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	affiliateFunnelDateLayout  = "2006-01-02"
+	affiliateFunnelDefaultDays = 30
+	affiliateFunnelMaxDays     = 180
+)
+
+// AffiliateFunnelQuery 推广转化漏斗查询输入（日期格式 YYYY-MM-DD，默认最近30天；子渠道与 UTM 参数为可选过滤条件）
+type AffiliateFunnelQuery struct {
+	From        string
+	To          string
+	SubID       string
+	UTMSource   string
+	UTMMedium   string
+	UTMCampaign string
+}
+
+// AffiliateFunnelSummary 推广转化漏斗指标
+type AffiliateFunnelSummary struct {
+	Clicks         int64        `json:"clicks"`
+	UniqueVisitors int64        `json:"unique_visitors"`
+	Orders         int64        `json:"orders"`
+	PaidOrders     int64        `json:"paid_orders"`
+	Commission     models.Money `json:"commission"`
+}
+
+// AffiliateFunnelItem 推广转化漏斗按日期与子渠道明细
+type AffiliateFunnelItem struct {
+	Day   string `json:"day"`
+	SubID string `json:"sub_id"`
+	AffiliateFunnelSummary
+}
+
+// AffiliateFunnel 推广转化漏斗
+type AffiliateFunnel struct {
+	From        string                 `json:"from"`
+	To          string                 `json:"to"`
+	SubID       string                 `json:"sub_id"`
+	UTMSource   string                 `json:"utm_source"`
+	UTMMedium   string                 `json:"utm_medium"`
+	UTMCampaign string                 `json:"utm_campaign"`
+	Summary     AffiliateFunnelSummary `json:"summary"`
+	Items       []AffiliateFunnelItem  `json:"items"`
+}
+
+// GetUserFunnel 查询推广用户按日期与子渠道的转化漏斗
+func (s *AffiliateService) GetUserFunnel(userID uint, query AffiliateFunnelQuery) (AffiliateFunnel, error) {
+	startAt, endAt, err := resolveAffiliateFunnelRange(query.From, query.To, time.Now())
+	if err != nil {
+		return AffiliateFunnel{}, err
+	}
+	funnel := AffiliateFunnel{
+		From:        startAt.Format(affiliateFunnelDateLayout),
+		To:          endAt.AddDate(0, 0, -1).Format(affiliateFunnelDateLayout),
+		SubID:       normalizeSettingTextWithRuneLimit(query.SubID, affiliateSubIDMaxRune),
+		UTMSource:   normalizeSettingTextWithRuneLimit(query.UTMSource, affiliateUTMMaxRune),
+		UTMMedium:   normalizeSettingTextWithRuneLimit(query.UTMMedium, affiliateUTMMaxRune),
+		UTMCampaign: normalizeSettingTextWithRuneLimit(query.UTMCampaign, affiliateUTMMaxRune),
+		Summary:     AffiliateFunnelSummary{Commission: models.NewMoneyFromDecimal(decimal.Zero)},
+		Items:       []AffiliateFunnelItem{},
+	}
+	if userID == 0 || s.repo == nil {
+		return funnel, nil
+	}
+	profile, err := s.repo.GetProfileByUserID(userID)
+	if err != nil {
+		return funnel, err
+	}
+	if profile == nil {
+		return funnel, nil
+	}
+
+	filter := repository.AffiliateFunnelFilter{
+		AffiliateProfileID: profile.ID,
+		SubID:              funnel.SubID,
+		UTMSource:          funnel.UTMSource,
+		UTMMedium:          funnel.UTMMedium,
+		UTMCampaign:        funnel.UTMCampaign,
+		StartAt:            startAt,
+		EndAt:              endAt,
+	}
+	rows, err := s.repo.ListFunnelRows(filter)
+	if err != nil {
+		return funnel, err
+	}
+	// 同一访客可能跨日期或子渠道多次访问，汇总独立访客需在整个范围内去重，不能累加各行
+	uniqueVisitors, err := s.repo.CountFunnelUniqueVisitors(filter)
+	if err != nil {
+		return funnel, err
+	}
+	totalCommission := decimal.Zero
+	for _, row := range rows {
+		funnel.Items = append(funnel.Items, AffiliateFunnelItem{
+			Day:   row.Day,
+			SubID: row.SubID,
+			AffiliateFunnelSummary: AffiliateFunnelSummary{
+				Clicks:         row.Clicks,
+				UniqueVisitors: row.UniqueVisitors,
+				Orders:         row.Orders,
+				PaidOrders:     row.PaidOrders,
+				Commission:     models.NewMoneyFromDecimal(row.Commission.Round(2)),
+			},
+		})
+		funnel.Summary.Clicks += row.Clicks
+		funnel.Summary.Orders += row.Orders
+		funnel.Summary.PaidOrders += row.PaidOrders
+		totalCommission = totalCommission.Add(row.Commission)
+	}
+	funnel.Summary.UniqueVisitors = uniqueVisitors
+	funnel.Summary.Commission = models.NewMoneyFromDecimal(totalCommission.Round(2))
+	return funnel, nil
+}
+
+// ExportUserFunnelCSV 导出推广用户转化漏斗 CSV
+func (s *AffiliateService) ExportUserFunnelCSV(userID uint, query AffiliateFunnelQuery) ([]byte, string, error) {
+	funnel, err := s.GetUserFunnel(userID, query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	builder := &strings.Builder{}
+	writer := csv.NewWriter(builder)
+	if err := writer.Write([]string{
+		"day",
+		"sub_id",
+		"clicks",
+		"unique_visitors",
+		"orders",
+		"paid_orders",
+		"commission",
+	}); err != nil {
+		return nil, "", err
+	}
+	for _, item := range funnel.Items {
+		if err := writer.Write([]string{
+			item.Day,
+			sanitizeCSVCell(item.SubID),
+			strconv.FormatInt(item.Clicks, 10),
+			strconv.FormatInt(item.UniqueVisitors, 10),
+			strconv.FormatInt(item.Orders, 10),
+			strconv.FormatInt(item.PaidOrders, 10),
+			item.Commission.StringFixed(2),
+		}); err != nil {
+			return nil, "", err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, "", err
+	}
+	return []byte(builder.String()), "text/csv; charset=utf-8", nil
+}
+
+// resolveAffiliateFunnelRange 解析漏斗日期区间，返回左闭右开的时间范围
+func resolveAffiliateFunnelRange(rawFrom, rawTo string, now time.Time) (time.Time, time.Time, error) {
+	loc := now.Location()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	endDay := today
+	if raw := strings.TrimSpace(rawTo); raw != "" {
+		parsed, err := time.ParseInLocation(affiliateFunnelDateLayout, raw, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrAffiliateFunnelRangeInvalid
+		}
+		endDay = parsed
+	}
+	startDay := endDay.AddDate(0, 0, -(affiliateFunnelDefaultDays - 1))
+	if raw := strings.TrimSpace(rawFrom); raw != "" {
+		parsed, err := time.ParseInLocation(affiliateFunnelDateLayout, raw, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrAffiliateFunnelRangeInvalid
+		}
+		startDay = parsed
+	}
+	if startDay.After(endDay) || endDay.Sub(startDay) >= affiliateFunnelMaxDays*24*time.Hour {
+		return time.Time{}, time.Time{}, ErrAffiliateFunnelRangeInvalid
+	}
+	return startDay, endDay.AddDate(0, 0, 1), nil
+}
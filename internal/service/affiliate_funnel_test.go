@@ -0,0 +1,116 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+func TestAffiliateFunnelGroupsBySubIDAndDay(t *testing.T) {
+	svc, db := setupAffiliateServiceTest(t)
+
+	promoter := createAffiliateTestUser(t, db, "affiliate-funnel@example.com")
+	buyer := createAffiliateTestUser(t, db, "affiliate-funnel-buyer@example.com")
+	profile := createAffiliateTestProfile(t, db, promoter.ID, "AFFFN001", constants.AffiliateProfileStatusActive)
+
+	for _, input := range []AffiliateTrackClickInput{
+		{AffiliateCode: profile.AffiliateCode, VisitorKey: "visitor-a", LandingPath: "/c", SubID: "youtube"},
+		{AffiliateCode: profile.AffiliateCode, VisitorKey: "visitor-a", LandingPath: "/a", SubID: "tiktok"},
+		{AffiliateCode: profile.AffiliateCode, VisitorKey: "visitor-a", LandingPath: "/b", SubID: "tiktok", UTMSource: "tiktok", UTMCampaign: "spring"},
+		{AffiliateCode: profile.AffiliateCode, VisitorKey: "visitor-b", LandingPath: "/a", SubID: "youtube"},
+	} {
+		if err := svc.TrackClick(input); err != nil {
+			t.Fatalf("track click failed: %v", err)
+		}
+	}
+
+	attribution, err := svc.ResolveOrderAffiliateAttribution(buyer.ID, "", "visitor-a")
+	if err != nil {
+		t.Fatalf("resolve attribution failed: %v", err)
+	}
+	if attribution.ProfileID == nil || *attribution.ProfileID != profile.ID || attribution.SubID != "tiktok" ||
+		attribution.UTMSource != "tiktok" || attribution.UTMCampaign != "spring" {
+		t.Fatalf("unexpected attribution: %+v", attribution)
+	}
+
+	order := createAffiliateTestPaidOrder(t, db, buyer.ID, profile.ID, decimal.NewFromInt(100))
+	if err := db.Model(&models.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+		"affiliate_sub_id":       attribution.SubID,
+		"affiliate_utm_source":   attribution.UTMSource,
+		"affiliate_utm_campaign": attribution.UTMCampaign,
+	}).Error; err != nil {
+		t.Fatalf("update order attribution failed: %v", err)
+	}
+	if err := svc.HandleOrderPaid(order.ID); err != nil {
+		t.Fatalf("handle order paid failed: %v", err)
+	}
+
+	funnel, err := svc.GetUserFunnel(promoter.ID, AffiliateFunnelQuery{})
+	if err != nil {
+		t.Fatalf("get funnel failed: %v", err)
+	}
+	if len(funnel.Items) != 2 {
+		t.Fatalf("expected 2 funnel rows, got %+v", funnel.Items)
+	}
+	tiktok := funnel.Items[0]
+	if tiktok.SubID != "tiktok" || tiktok.Clicks != 2 || tiktok.UniqueVisitors != 1 || tiktok.Orders != 1 || tiktok.PaidOrders != 1 ||
+		!tiktok.Commission.Decimal.Equal(decimal.NewFromInt(20)) {
+		t.Fatalf("unexpected tiktok row: %+v", tiktok)
+	}
+	if funnel.Items[1].SubID != "youtube" || funnel.Items[1].Clicks != 2 || funnel.Items[1].UniqueVisitors != 2 || funnel.Items[1].Orders != 0 {
+		t.Fatalf("unexpected youtube row: %+v", funnel.Items[1])
+	}
+	// visitor-a 同时出现在两个子渠道，汇总独立访客按整个范围去重
+	if funnel.Summary.Clicks != 4 || funnel.Summary.UniqueVisitors != 2 || funnel.Summary.PaidOrders != 1 {
+		t.Fatalf("unexpected funnel summary: %+v", funnel.Summary)
+	}
+
+	filtered, err := svc.GetUserFunnel(promoter.ID, AffiliateFunnelQuery{SubID: "youtube"})
+	if err != nil {
+		t.Fatalf("get filtered funnel failed: %v", err)
+	}
+	if len(filtered.Items) != 1 || filtered.Summary.Orders != 0 || filtered.Summary.UniqueVisitors != 2 {
+		t.Fatalf("unexpected filtered funnel: %+v", filtered)
+	}
+
+	// UTM 参数随订单快照，可按活动过滤点击与订单
+	campaign, err := svc.GetUserFunnel(promoter.ID, AffiliateFunnelQuery{UTMCampaign: "spring"})
+	if err != nil {
+		t.Fatalf("get campaign funnel failed: %v", err)
+	}
+	if len(campaign.Items) != 1 || campaign.Summary.Clicks != 1 || campaign.Summary.Orders != 1 || campaign.Summary.PaidOrders != 1 ||
+		!campaign.Summary.Commission.Decimal.Equal(decimal.NewFromInt(20)) {
+		t.Fatalf("unexpected campaign funnel: %+v", campaign)
+	}
+
+	// 同一访客在去重窗口内点击不同子渠道的链接分别计入
+	for _, subID := range []string{"youtube", "=HYPERLINK(\"http://evil\")", "youtube"} {
+		if err := svc.TrackClick(AffiliateTrackClickInput{AffiliateCode: profile.AffiliateCode, VisitorKey: "visitor-c", LandingPath: "/a", SubID: subID}); err != nil {
+			t.Fatalf("track click failed: %v", err)
+		}
+	}
+	var visitorClicks int64
+	if err := db.Model(&models.AffiliateClick{}).Where("visitor_key = ?", "visitor-c").Count(&visitorClicks).Error; err != nil || visitorClicks != 2 {
+		t.Fatalf("expected 2 clicks for visitor-c, got %d err=%v", visitorClicks, err)
+	}
+
+	content, contentType, err := svc.ExportUserFunnelCSV(promoter.ID, AffiliateFunnelQuery{})
+	if err != nil {
+		t.Fatalf("export funnel failed: %v", err)
+	}
+	if !strings.HasPrefix(contentType, "text/csv") {
+		t.Fatalf("unexpected content type: %s", contentType)
+	}
+	today := time.Now().Format("2006-01-02")
+	if !strings.Contains(string(content), today+",tiktok,2,1,1,1,20.00") || !strings.Contains(string(content), `"'=HYPERLINK(""http://evil"")"`) {
+		t.Fatalf("unexpected csv content: %s", content)
+	}
+
+	if _, err := svc.GetUserFunnel(promoter.ID, AffiliateFunnelQuery{From: "2026-02-01", To: "2026-01-01"}); err != ErrAffiliateFunnelRangeInvalid {
+		t.Fatalf("expected range invalid, got %v", err)
+	}
+}
@@ -0,0 +1,432 @@
+package service
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/logger"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	affiliateRiskBatchSize             = 200
+	affiliateRiskReportCommissionLimit = 20
+	affiliateRiskReasonMaxRune         = 255
+	affiliateEmailPatternMinBaseRune   = 3
+)
+
+// AffiliateRiskReport 推广用户风险报告
+type AffiliateRiskReport struct {
+	AffiliateProfileID     uint                               `json:"affiliate_profile_id"`
+	AffiliateCode          string                             `json:"affiliate_code"`
+	UserID                 uint                               `json:"user_id"`
+	UserEmail              string                             `json:"user_email"`
+	ProfileStatus          string                             `json:"profile_status"`
+	WindowDays             int                                `json:"window_days"`
+	RiskLevel              string                             `json:"risk_level"`
+	ClickCount             int64                              `json:"click_count"`
+	PaidOrderCount         int64                              `json:"paid_order_count"`
+	ConversionRate         float64                            `json:"conversion_rate"`
+	ConversionAbnormal     bool                               `json:"conversion_abnormal"`
+	FlagCounts             map[string]int64                   `json:"flag_counts"`
+	RefundingBuyers        []repository.AffiliateRiskBuyerRow `json:"refunding_buyers"`
+	ReviewCommissionCount  int64                              `json:"review_commission_count"`
+	ReviewCommissionAmount models.Money                       `json:"review_commission_amount"`
+	FlaggedCommissions     []models.AffiliateCommission       `json:"flagged_commissions"`
+}
+
+// affiliateRiskScreener 单次确认任务内的风控检测上下文，缓存用户特征与推广用户统计
+type affiliateRiskScreener struct {
+	repo         repository.AffiliateRepository
+	setting      AffiliateSetting
+	since        time.Time
+	fingerprints map[uint]repository.AffiliateUserFingerprint
+	conversion   map[uint]bool
+	refunds      map[[2]uint]int64
+}
+
+// ConfirmDueCommissions 将到期佣金转可提现（开启风控时先执行规则检测，命中的佣金转人工复核）
+func (s *AffiliateService) ConfirmDueCommissions(now time.Time) error {
+	if s.repo == nil {
+		return nil
+	}
+	setting, err := s.settingService.GetAffiliateSetting()
+	if err != nil {
+		return err
+	}
+	if !setting.FraudCheckEnabled {
+		_, err := s.repo.MarkPendingCommissionsAvailable(now, now)
+		return err
+	}
+
+	screener := &affiliateRiskScreener{
+		repo:         s.repo,
+		setting:      setting,
+		since:        now.AddDate(0, 0, -setting.FraudWindowDays),
+		fingerprints: make(map[uint]repository.AffiliateUserFingerprint),
+		conversion:   make(map[uint]bool),
+		refunds:      make(map[[2]uint]int64),
+	}
+	for {
+		rows, err := s.repo.ListDuePendingCommissions(now, affiliateRiskBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		cleanIDs := make([]uint, 0, len(rows))
+		for _, row := range rows {
+			flags, err := screener.evaluate(row)
+			if err != nil {
+				return err
+			}
+			if len(flags) == 0 {
+				cleanIDs = append(cleanIDs, row.ID)
+				continue
+			}
+			if err := s.repo.FlagPendingCommissionForReview(row.ID, strings.Join(flags, ","), now); err != nil {
+				return err
+			}
+			logger.Warnw("affiliate_commission_flagged_for_review",
+				"commission_id", row.ID,
+				"affiliate_profile_id", row.AffiliateProfileID,
+				"order_id", row.OrderID,
+				"risk_flags", flags,
+			)
+		}
+		if _, err := s.repo.MarkPendingCommissionsAvailableByIDs(cleanIDs, now); err != nil {
+			return err
+		}
+		if len(rows) < affiliateRiskBatchSize {
+			return nil
+		}
+	}
+}
+
+// ReviewCommission 后台复核命中风控规则的佣金：通过转可提现，驳回则作废
+func (s *AffiliateService) ReviewCommission(adminID, commissionID uint, action, reason string) (*models.AffiliateCommission, error) {
+	if commissionID == 0 || s.repo == nil {
+		return nil, ErrNotFound
+	}
+	act := strings.ToLower(strings.TrimSpace(action))
+	if act != constants.AffiliateCommissionActionApprove && act != constants.AffiliateCommissionActionReject {
+		return nil, ErrAffiliateCommissionStatusInvalid
+	}
+	reasonText := normalizeSettingTextWithRuneLimit(reason, affiliateRiskReasonMaxRune)
+
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		repoTx := s.repo.WithTx(tx)
+		row, err := repoTx.GetCommissionByIDForUpdate(commissionID)
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			return ErrNotFound
+		}
+		if row.Status != constants.AffiliateCommissionStatusPendingReview {
+			return ErrAffiliateCommissionStatusInvalid
+		}
+
+		now := time.Now()
+		row.ProcessedBy = &adminID
+		row.ProcessedAt = &now
+		row.UpdatedAt = now
+		if act == constants.AffiliateCommissionActionApprove {
+			row.Status = constants.AffiliateCommissionStatusAvailable
+			row.AvailableAt = &now
+		} else {
+			if reasonText == "" {
+				reasonText = "risk_rejected"
+			}
+			row.Status = constants.AffiliateCommissionStatusRejected
+			row.InvalidReason = reasonText
+		}
+		return repoTx.UpdateCommission(row)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetCommissionByID(commissionID)
+}
+
+// GetProfileRiskReport 后台查询推广用户风险报告
+func (s *AffiliateService) GetProfileRiskReport(profileID uint) (*AffiliateRiskReport, error) {
+	if profileID == 0 || s.repo == nil {
+		return nil, ErrNotFound
+	}
+	profile, err := s.repo.GetProfileByID(profileID)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, ErrNotFound
+	}
+	setting, err := s.settingService.GetAffiliateSetting()
+	if err != nil {
+		return nil, err
+	}
+	since := time.Now().AddDate(0, 0, -setting.FraudWindowDays)
+
+	report := &AffiliateRiskReport{
+		AffiliateProfileID:     profile.ID,
+		AffiliateCode:          profile.AffiliateCode,
+		UserID:                 profile.UserID,
+		UserEmail:              profile.User.Email,
+		ProfileStatus:          profile.Status,
+		WindowDays:             setting.FraudWindowDays,
+		FlagCounts:             map[string]int64{},
+		RefundingBuyers:        []repository.AffiliateRiskBuyerRow{},
+		ReviewCommissionAmount: models.NewMoneyFromDecimal(decimal.Zero),
+		FlaggedCommissions:     []models.AffiliateCommission{},
+	}
+
+	clicks, orders, err := s.repo.GetProfileConversionStats(profile.ID, since)
+	if err != nil {
+		return nil, err
+	}
+	report.ClickCount = clicks
+	report.PaidOrderCount = orders
+	report.ConversionRate = calcAffiliateConversion(orders, clicks)
+	report.ConversionAbnormal = isAffiliateConversionAbnormal(setting, clicks, orders)
+
+	flagRows, err := s.repo.ListCommissionRiskFlags(profile.ID, since)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range flagRows {
+		for _, flag := range strings.Split(raw, ",") {
+			if flag = strings.TrimSpace(flag); flag != "" {
+				report.FlagCounts[flag]++
+			}
+		}
+	}
+
+	refundThreshold := int64(setting.FraudRefundThreshold)
+	if refundThreshold <= 0 {
+		refundThreshold = 1
+	}
+	buyers, err := s.repo.ListRefundingBuyersByProfile(profile.ID, since, refundThreshold)
+	if err != nil {
+		return nil, err
+	}
+	report.RefundingBuyers = buyers
+
+	_, reviewCount, err := s.repo.ListCommissions(repository.AffiliateCommissionListFilter{
+		Page:               1,
+		PageSize:           1,
+		AffiliateProfileID: profile.ID,
+		Status:             constants.AffiliateCommissionStatusPendingReview,
+	})
+	if err != nil {
+		return nil, err
+	}
+	reviewAmount, err := s.repo.SumCommissionByProfile(profile.ID, []string{constants.AffiliateCommissionStatusPendingReview}, false)
+	if err != nil {
+		return nil, err
+	}
+	report.ReviewCommissionCount = reviewCount
+	report.ReviewCommissionAmount = models.NewMoneyFromDecimal(reviewAmount.Round(2))
+
+	flagged, _, err := s.repo.ListCommissions(repository.AffiliateCommissionListFilter{
+		Page:               1,
+		PageSize:           affiliateRiskReportCommissionLimit,
+		AffiliateProfileID: profile.ID,
+		RiskFlagged:        true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.FlaggedCommissions = flagged
+	report.RiskLevel = resolveAffiliateRiskLevel(report)
+	return report, nil
+}
+
+// evaluate 对单条到期佣金执行风控规则，返回命中的规则列表
+func (r *affiliateRiskScreener) evaluate(row models.AffiliateCommission) ([]string, error) {
+	flags := make([]string, 0)
+	affiliateUserID := row.AffiliateProfile.UserID
+	buyerUserID := row.Order.UserID
+
+	if affiliateUserID > 0 {
+		affiliate, err := r.fingerprint(affiliateUserID)
+		if err != nil {
+			return nil, err
+		}
+		buyer := repository.AffiliateUserFingerprint{Email: row.Order.GuestEmail}
+		if buyerUserID > 0 {
+			if buyer, err = r.fingerprint(buyerUserID); err != nil {
+				return nil, err
+			}
+		}
+		if ip := strings.TrimSpace(row.Order.ClientIP); ip != "" {
+			buyer.IPs = append(buyer.IPs, ip)
+		}
+		flags = append(flags, matchAffiliateSelfReferral(affiliate, buyer)...)
+	}
+
+	if r.setting.FraudMaxConversionRate > 0 {
+		abnormal, ok := r.conversion[row.AffiliateProfileID]
+		if !ok {
+			clicks, orders, err := r.repo.GetProfileConversionStats(row.AffiliateProfileID, r.since)
+			if err != nil {
+				return nil, err
+			}
+			abnormal = isAffiliateConversionAbnormal(r.setting, clicks, orders)
+			r.conversion[row.AffiliateProfileID] = abnormal
+		}
+		if abnormal {
+			flags = append(flags, constants.AffiliateRiskFlagAbnormalConversion)
+		}
+	}
+
+	if r.setting.FraudRefundThreshold > 0 && buyerUserID > 0 {
+		key := [2]uint{row.AffiliateProfileID, buyerUserID}
+		refunded, ok := r.refunds[key]
+		if !ok {
+			count, err := r.repo.CountRefundedOrdersByBuyer(row.AffiliateProfileID, buyerUserID, r.since)
+			if err != nil {
+				return nil, err
+			}
+			refunded = count
+			r.refunds[key] = refunded
+		}
+		if refunded >= int64(r.setting.FraudRefundThreshold) {
+			flags = append(flags, constants.AffiliateRiskFlagRepeatedRefund)
+		}
+	}
+	return flags, nil
+}
+
+func (r *affiliateRiskScreener) fingerprint(userID uint) (repository.AffiliateUserFingerprint, error) {
+	if cached, ok := r.fingerprints[userID]; ok {
+		return cached, nil
+	}
+	result, err := r.repo.GetUserFingerprint(userID, r.since)
+	if err != nil {
+		return result, err
+	}
+	r.fingerprints[userID] = result
+	return result, nil
+}
+
+// matchAffiliateSelfReferral 比对推广用户与买家的身份特征，识别自买自返
+func matchAffiliateSelfReferral(affiliate, buyer repository.AffiliateUserFingerprint) []string {
+	flags := make([]string, 0)
+	if hasCommonAffiliateValue(affiliate.IPs, buyer.IPs, false) {
+		flags = append(flags, constants.AffiliateRiskFlagSharedIP)
+	}
+	if hasCommonAffiliateValue(affiliate.Devices, buyer.Devices, false) {
+		flags = append(flags, constants.AffiliateRiskFlagSharedDevice)
+	}
+	if isAffiliateEmailPatternMatch(affiliate.Email, buyer.Email) {
+		flags = append(flags, constants.AffiliateRiskFlagEmailPattern)
+	}
+	if hasCommonAffiliateValue(affiliate.TelegramIDs, buyer.TelegramIDs, false) ||
+		hasCommonAffiliateValue(affiliate.TelegramUsernames, buyer.TelegramUsernames, true) {
+		flags = append(flags, constants.AffiliateRiskFlagTelegramID)
+	}
+	return flags
+}
+
+func hasCommonAffiliateValue(left, right []string, foldCase bool) bool {
+	if len(left) == 0 || len(right) == 0 {
+		return false
+	}
+	normalize := func(value string) string {
+		value = strings.TrimSpace(value)
+		if foldCase {
+			value = strings.ToLower(value)
+		}
+		return value
+	}
+	seen := make(map[string]struct{}, len(left))
+	for _, value := range left {
+		if key := normalize(value); key != "" {
+			seen[key] = struct{}{}
+		}
+	}
+	for _, value := range right {
+		key := normalize(value)
+		if key == "" {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isAffiliateEmailPatternMatch 判断两个邮箱是否属于同一人常见的变体（+别名、Gmail 点号、尾部数字编号）
+func isAffiliateEmailPatternMatch(left, right string) bool {
+	leftLocal, leftDomain, ok := canonicalAffiliateEmail(left)
+	if !ok {
+		return false
+	}
+	rightLocal, rightDomain, ok := canonicalAffiliateEmail(right)
+	if !ok || leftDomain != rightDomain {
+		return false
+	}
+	if leftLocal == rightLocal {
+		return true
+	}
+	leftBase := strings.TrimRightFunc(leftLocal, unicode.IsDigit)
+	rightBase := strings.TrimRightFunc(rightLocal, unicode.IsDigit)
+	return leftBase == rightBase && len([]rune(leftBase)) >= affiliateEmailPatternMinBaseRune
+}
+
+func canonicalAffiliateEmail(raw string) (string, string, bool) {
+	email := strings.ToLower(strings.TrimSpace(raw))
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 {
+		return "", "", false
+	}
+	local, domain := email[:at], email[at+1:]
+	if idx := strings.Index(local, "+"); idx >= 0 {
+		local = local[:idx]
+	}
+	if domain == "googlemail.com" {
+		domain = "gmail.com"
+	}
+	if domain == "gmail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+	if local == "" {
+		return "", "", false
+	}
+	return local, domain, true
+}
+
+// isAffiliateConversionAbnormal 订单数达到阈值且点击下单比超过上限（无点击仅凭推广码下单视为异常）
+func isAffiliateConversionAbnormal(setting AffiliateSetting, clicks, orders int64) bool {
+	if setting.FraudMaxConversionRate <= 0 || orders < int64(setting.FraudMinOrders) {
+		return false
+	}
+	if clicks <= 0 {
+		return true
+	}
+	return float64(orders)*100/float64(clicks) > setting.FraudMaxConversionRate
+}
+
+func resolveAffiliateRiskLevel(report *AffiliateRiskReport) string {
+	for _, flag := range []string{
+		constants.AffiliateRiskFlagSharedIP,
+		constants.AffiliateRiskFlagSharedDevice,
+		constants.AffiliateRiskFlagEmailPattern,
+		constants.AffiliateRiskFlagTelegramID,
+	} {
+		if report.FlagCounts[flag] > 0 {
+			return constants.AffiliateRiskLevelHigh
+		}
+	}
+	if report.ConversionAbnormal || len(report.RefundingBuyers) > 0 || len(report.FlagCounts) > 0 {
+		return constants.AffiliateRiskLevelMedium
+	}
+	return constants.AffiliateRiskLevelLow
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func TestConfirmDueCommissionsFlagsSelfReferralForReview(t *testing.T) {
+	svc, db := setupAffiliateServiceTest(t)
+	enableAffiliateFraudCheck(t, svc)
+
+	promoter := createAffiliateTestUser(t, db, "farmer+aff@gmail.com")
+	selfBuyer := createAffiliateTestUser(t, db, "far.mer@gmail.com")
+	cleanBuyer := createAffiliateTestUser(t, db, "someone@example.com")
+	profile := createAffiliateTestProfile(t, db, promoter.ID, "AFFRK001", constants.AffiliateProfileStatusActive)
+	createAffiliateRiskTestLogin(t, db, promoter.ID, "10.0.0.1")
+
+	selfOrder := createAffiliateTestPaidOrder(t, db, selfBuyer.ID, profile.ID, decimal.NewFromInt(100))
+	setAffiliateRiskTestOrder(t, db, selfOrder.ID, map[string]interface{}{"client_ip": "10.0.0.1"})
+	cleanOrder := createAffiliateTestPaidOrder(t, db, cleanBuyer.ID, profile.ID, decimal.NewFromInt(100))
+	setAffiliateRiskTestOrder(t, db, cleanOrder.ID, map[string]interface{}{"client_ip": "10.0.0.9"})
+	for _, orderID := range []uint{selfOrder.ID, cleanOrder.ID} {
+		if err := svc.HandleOrderPaid(orderID); err != nil {
+			t.Fatalf("handle order paid failed: %v", err)
+		}
+	}
+
+	if err := svc.ConfirmDueCommissions(time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("confirm due commissions failed: %v", err)
+	}
+
+	selfCommission := loadAffiliateRiskTestCommission(t, db, selfOrder.ID)
+	if selfCommission.Status != constants.AffiliateCommissionStatusPendingReview {
+		t.Fatalf("expected self referral commission under review, got %s", selfCommission.Status)
+	}
+	for _, flag := range []string{constants.AffiliateRiskFlagSharedIP, constants.AffiliateRiskFlagEmailPattern} {
+		if !strings.Contains(selfCommission.RiskFlags, flag) {
+			t.Fatalf("expected risk flag %s, got %q", flag, selfCommission.RiskFlags)
+		}
+	}
+	cleanCommission := loadAffiliateRiskTestCommission(t, db, cleanOrder.ID)
+	if cleanCommission.Status != constants.AffiliateCommissionStatusAvailable || cleanCommission.RiskFlags != "" {
+		t.Fatalf("expected clean commission available, got %+v", cleanCommission)
+	}
+
+	approved, err := svc.ReviewCommission(1, selfCommission.ID, constants.AffiliateCommissionActionApprove, "")
+	if err != nil {
+		t.Fatalf("approve commission failed: %v", err)
+	}
+	if approved.Status != constants.AffiliateCommissionStatusAvailable || approved.ProcessedBy == nil || approved.AvailableAt == nil {
+		t.Fatalf("unexpected approved commission: %+v", approved)
+	}
+	if _, err := svc.ReviewCommission(1, selfCommission.ID, constants.AffiliateCommissionActionReject, ""); err != ErrAffiliateCommissionStatusInvalid {
+		t.Fatalf("expected status invalid on second review, got %v", err)
+	}
+}
+
+func TestConfirmDueCommissionsFlagsRepeatedRefundsAndBuildsRiskReport(t *testing.T) {
+	svc, db := setupAffiliateServiceTest(t)
+	enableAffiliateFraudCheck(t, svc)
+
+	promoter := createAffiliateTestUser(t, db, "promoter-refund@example.com")
+	buyer := createAffiliateTestUser(t, db, "buyer-refund@example.org")
+	profile := createAffiliateTestProfile(t, db, promoter.ID, "AFFRK002", constants.AffiliateProfileStatusActive)
+
+	for i := 0; i < 2; i++ {
+		order := createAffiliateTestPaidOrder(t, db, buyer.ID, profile.ID, decimal.NewFromInt(50))
+		if err := svc.HandleOrderPaid(order.ID); err != nil {
+			t.Fatalf("handle order paid failed: %v", err)
+		}
+		if err := svc.HandleOrderCanceled(order.ID, "order_refunded"); err != nil {
+			t.Fatalf("handle order canceled failed: %v", err)
+		}
+		setAffiliateRiskTestOrder(t, db, order.ID, map[string]interface{}{"status": constants.OrderStatusRefunded})
+	}
+	order := createAffiliateTestPaidOrder(t, db, buyer.ID, profile.ID, decimal.NewFromInt(50))
+	if err := svc.HandleOrderPaid(order.ID); err != nil {
+		t.Fatalf("handle order paid failed: %v", err)
+	}
+
+	if err := svc.ConfirmDueCommissions(time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("confirm due commissions failed: %v", err)
+	}
+	commission := loadAffiliateRiskTestCommission(t, db, order.ID)
+	if commission.Status != constants.AffiliateCommissionStatusPendingReview || commission.RiskFlags != constants.AffiliateRiskFlagRepeatedRefund {
+		t.Fatalf("expected repeated refund review, got status=%s flags=%q", commission.Status, commission.RiskFlags)
+	}
+
+	report, err := svc.GetProfileRiskReport(profile.ID)
+	if err != nil {
+		t.Fatalf("get risk report failed: %v", err)
+	}
+	if report.RiskLevel != constants.AffiliateRiskLevelMedium {
+		t.Fatalf("expected medium risk level, got %s", report.RiskLevel)
+	}
+	if len(report.RefundingBuyers) != 1 || report.RefundingBuyers[0].UserID != buyer.ID || report.RefundingBuyers[0].RefundedOrders != 2 {
+		t.Fatalf("unexpected refunding buyers: %+v", report.RefundingBuyers)
+	}
+	if report.FlagCounts[constants.AffiliateRiskFlagRepeatedRefund] != 1 || report.ReviewCommissionCount != 1 ||
+		!report.ReviewCommissionAmount.Decimal.Equal(decimal.NewFromInt(10)) || len(report.FlaggedCommissions) != 1 {
+		t.Fatalf("unexpected risk report: %+v", report)
+	}
+}
+
+func TestMatchAffiliateSelfReferralRequiresSameIPForSharedDevice(t *testing.T) {
+	svc, db := setupAffiliateServiceTest(t)
+
+	promoter := createAffiliateTestUser(t, db, "device-promoter@example.com")
+	buyer := createAffiliateTestUser(t, db, "device-buyer@example.net")
+	createAffiliateRiskTestLogin(t, db, promoter.ID, "10.0.0.1")
+	createAffiliateRiskTestLogin(t, db, buyer.ID, "10.0.0.2")
+
+	since := time.Now().Add(-time.Hour)
+	fingerprints := func() (repository.AffiliateUserFingerprint, repository.AffiliateUserFingerprint) {
+		affiliate, err := svc.repo.GetUserFingerprint(promoter.ID, since)
+		if err != nil {
+			t.Fatalf("load promoter fingerprint failed: %v", err)
+		}
+		buyerPrint, err := svc.repo.GetUserFingerprint(buyer.ID, since)
+		if err != nil {
+			t.Fatalf("load buyer fingerprint failed: %v", err)
+		}
+		return affiliate, buyerPrint
+	}
+
+	// 仅 UA 相同（常见浏览器 UA 大量重合）不视为共用设备
+	if flags := matchAffiliateSelfReferral(fingerprints()); len(flags) != 0 {
+		t.Fatalf("expected no flags for shared user agent only, got %v", flags)
+	}
+
+	createAffiliateRiskTestLogin(t, db, buyer.ID, "10.0.0.1")
+	flags := matchAffiliateSelfReferral(fingerprints())
+	if strings.Join(flags, ",") != constants.AffiliateRiskFlagSharedIP+","+constants.AffiliateRiskFlagSharedDevice {
+		t.Fatalf("expected shared ip and device flags, got %v", flags)
+	}
+}
+
+func enableAffiliateFraudCheck(t *testing.T, svc *AffiliateService) {
+	t.Helper()
+
+	if _, err := svc.settingService.UpdateAffiliateSetting(AffiliateSetting{
+		Enabled:              true,
+		CommissionRate:       20,
+		FraudCheckEnabled:    true,
+		FraudRefundThreshold: 2,
+	}); err != nil {
+		t.Fatalf("update affiliate setting failed: %v", err)
+	}
+}
+
+func createAffiliateRiskTestLogin(t *testing.T, db *gorm.DB, userID uint, clientIP string) {
+	t.Helper()
+
+	row := models.UserLoginLog{
+		UserID:    userID,
+		Email:     "login@example.com",
+		Status:    constants.LoginLogStatusSuccess,
+		ClientIP:  clientIP,
+		UserAgent: "risk-test-agent",
+		CreatedAt: time.Now(),
+	}
+	if err := db.Create(&row).Error; err != nil {
+		t.Fatalf("create login log failed: %v", err)
+	}
+}
+
+func setAffiliateRiskTestOrder(t *testing.T, db *gorm.DB, orderID uint, updates map[string]interface{}) {
+	t.Helper()
+
+	if err := db.Model(&models.Order{}).Where("id = ?", orderID).Updates(updates).Error; err != nil {
+		t.Fatalf("update order failed: %v", err)
+	}
+}
+
+func loadAffiliateRiskTestCommission(t *testing.T, db *gorm.DB, orderID uint) models.AffiliateCommission {
+	t.Helper()
+
+	var row models.AffiliateCommission
+	if err := db.Where("order_id = ?", orderID).First(&row).Error; err != nil {
+		t.Fatalf("load commission failed: %v", err)
+	}
+	return row
+}
+
+func TestApplyWithdrawPromotesOnlyOwnDueCommissions(t *testing.T) {
+	svc, db := setupAffiliateServiceTest(t)
+	if err := db.AutoMigrate(&models.AffiliateWithdrawRequest{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	setting := AffiliateSetting{Enabled: true, CommissionRate: 20, ConfirmDays: 1}
+	if _, err := svc.settingService.UpdateAffiliateSetting(setting); err != nil {
+		t.Fatalf("update affiliate setting failed: %v", err)
+	}
+
+	promoter := createAffiliateTestUser(t, db, "withdraw-promoter@example.com")
+	other := createAffiliateTestUser(t, db, "withdraw-other@example.com")
+	buyer := createAffiliateTestUser(t, db, "withdraw-buyer@example.com")
+	profile := createAffiliateTestProfile(t, db, promoter.ID, "AFFWD001", constants.AffiliateProfileStatusActive)
+	otherProfile := createAffiliateTestProfile(t, db, other.ID, "AFFWD002", constants.AffiliateProfileStatusActive)
+	paidDue := func(profileID uint) models.AffiliateCommission {
+		t.Helper()
+		order := createAffiliateTestPaidOrder(t, db, buyer.ID, profileID, decimal.NewFromInt(100))
+		if err := svc.HandleOrderPaid(order.ID); err != nil {
+			t.Fatalf("handle order paid failed: %v", err)
+		}
+		if err := db.Model(&models.AffiliateCommission{}).Where("order_id = ?", order.ID).Update("confirm_at", time.Now().Add(-time.Hour)).Error; err != nil {
+			t.Fatalf("update confirm_at failed: %v", err)
+		}
+		return loadAffiliateRiskTestCommission(t, db, order.ID)
+	}
+	own := paidDue(profile.ID)
+	foreign := paidDue(otherProfile.ID)
+
+	// 未开启风控时提现仅将本人到期佣金转可提现
+	if _, err := svc.ApplyWithdraw(promoter.ID, AffiliateWithdrawApplyInput{Amount: decimal.NewFromInt(20), Channel: "alipay", Account: "promoter"}); err != nil {
+		t.Fatalf("apply withdraw failed: %v", err)
+	}
+	if reloaded := loadAffiliateRiskTestCommission(t, db, own.OrderID); reloaded.WithdrawRequestID == nil {
+		t.Fatalf("expected own due commission bound to withdraw, got %+v", reloaded)
+	}
+	if reloaded := loadAffiliateRiskTestCommission(t, db, foreign.OrderID); reloaded.Status != constants.AffiliateCommissionStatusPendingConfirm {
+		t.Fatalf("expected other profile commission untouched, got %s", reloaded.Status)
+	}
+
+	// 开启风控后到期佣金须经定时任务筛查，提现时不直接转入
+	setting.FraudCheckEnabled = true
+	if _, err := svc.settingService.UpdateAffiliateSetting(setting); err != nil {
+		t.Fatalf("update affiliate setting failed: %v", err)
+	}
+	screened := paidDue(profile.ID)
+	if _, err := svc.ApplyWithdraw(promoter.ID, AffiliateWithdrawApplyInput{Amount: decimal.NewFromInt(20), Channel: "alipay", Account: "promoter"}); err != ErrAffiliateWithdrawInsufficient {
+		t.Fatalf("expected insufficient before screening, got %v", err)
+	}
+	if reloaded := loadAffiliateRiskTestCommission(t, db, screened.OrderID); reloaded.Status != constants.AffiliateCommissionStatusPendingConfirm {
+		t.Fatalf("expected commission pending screening, got %s", reloaded.Status)
+	}
+}
@@ -20,6 +20,8 @@ const (
 	affiliateSplitTypePrefix   = "sp"
 	affiliateAttributionWindow = 30 * 24 * time.Hour
 	affiliateClickDedupeWindow = 10 * time.Minute
+	affiliateSubIDMaxRune      = 64
+	affiliateUTMMaxRune        = 100
 )
 
 // AffiliateService 推广返利业务服务
@@ -56,19 +58,26 @@ type AffiliateTrackClickInput struct {
 	Referrer      string
 	ClientIP      string
 	UserAgent     string
+	SubID         string
+	UTMSource     string
+	UTMMedium     string
+	UTMCampaign   string
 }
 
 // AffiliateDashboard 推广用户中心数据
 type AffiliateDashboard struct {
-	Opened              bool         `json:"opened"`
-	AffiliateCode       string       `json:"affiliate_code"`
-	PromotionPath       string       `json:"promotion_path"`
-	ClickCount          int64        `json:"click_count"`
-	ValidOrderCount     int64        `json:"valid_order_count"`
-	ConversionRate      float64      `json:"conversion_rate"`
-	PendingCommission   models.Money `json:"pending_commission"`
-	AvailableCommission models.Money `json:"available_commission"`
-	WithdrawnCommission models.Money `json:"withdrawn_commission"`
+	Opened               bool         `json:"opened"`
+	AffiliateCode        string       `json:"affiliate_code"`
+	PromotionPath        string       `json:"promotion_path"`
+	ClickCount           int64        `json:"click_count"`
+	ValidOrderCount      int64        `json:"valid_order_count"`
+	ConversionRate       float64      `json:"conversion_rate"`
+	PendingCommission    models.Money `json:"pending_commission"`
+	AvailableCommission  models.Money `json:"available_commission"`
+	WithdrawnCommission  models.Money `json:"withdrawn_commission"`
+	ReferredUserCount    int64        `json:"referred_user_count"`
+	DirectCommission     models.Money `json:"direct_commission"`
+	SecondTierCommission models.Money `json:"second_tier_commission"`
 }
 
 // AffiliateStats 推广统计数据
@@ -101,6 +110,8 @@ type AffiliateAdminCommissionListFilter struct {
 	AffiliateProfileID uint
 	OrderNo            string
 	Status             string
+	Level              int
+	RiskFlagged        bool
 	Keyword            string
 }
 
@@ -219,53 +230,81 @@ func (s *AffiliateService) OpenAffiliate(userID uint) (*models.AffiliateProfile,
 	return nil, ErrAffiliateCodeInvalid
 }
 
+// AffiliateOrderAttribution 下单推广归因结果
+type AffiliateOrderAttribution struct {
+	ProfileID   *uint
+	Code        string
+	SubID       string
+	UTMSource   string
+	UTMMedium   string
+	UTMCampaign string
+}
+
 // ResolveOrderAffiliateSnapshot 解析下单归因快照（最近30天最后一次有效点击优先）
 func (s *AffiliateService) ResolveOrderAffiliateSnapshot(userID uint, rawCode, rawVisitorKey string) (*uint, string, error) {
+	attribution, err := s.ResolveOrderAffiliateAttribution(userID, rawCode, rawVisitorKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return attribution.ProfileID, attribution.Code, nil
+}
+
+// ResolveOrderAffiliateAttribution 解析下单归因（含点击携带的子渠道标识与 UTM 参数）
+func (s *AffiliateService) ResolveOrderAffiliateAttribution(userID uint, rawCode, rawVisitorKey string) (AffiliateOrderAttribution, error) {
+	result := AffiliateOrderAttribution{}
 	code := normalizeAffiliateCode(rawCode)
 	visitorKey := strings.TrimSpace(rawVisitorKey)
 	if s.repo == nil {
-		return nil, "", nil
+		return result, nil
 	}
 
 	setting, err := s.settingService.GetAffiliateSetting()
 	if err != nil {
-		return nil, "", err
+		return result, err
 	}
 	if !setting.Enabled {
-		return nil, "", nil
+		return result, nil
 	}
 
 	if visitorKey != "" {
-		profile, err := s.repo.GetLatestActiveProfileByVisitorKey(visitorKey, time.Now().Add(-affiliateAttributionWindow))
+		click, err := s.repo.GetLatestActiveClickByVisitorKey(visitorKey, time.Now().Add(-affiliateAttributionWindow))
 		if err != nil {
-			return nil, "", err
+			return result, err
 		}
-		if profile != nil {
-			if userID > 0 && profile.UserID == userID {
-				return nil, "", nil
+		if click != nil {
+			if userID > 0 && click.AffiliateProfile.UserID == userID {
+				return result, nil
 			}
-			profileID := profile.ID
-			return &profileID, profile.AffiliateCode, nil
+			profileID := click.AffiliateProfileID
+			result.ProfileID = &profileID
+			result.Code = click.AffiliateProfile.AffiliateCode
+			result.SubID = click.SubID
+			result.UTMSource = click.UTMSource
+			result.UTMMedium = click.UTMMedium
+			result.UTMCampaign = click.UTMCampaign
+			return result, nil
 		}
 	}
 
 	if code == "" {
-		return nil, "", nil
+		return result, nil
 	}
 
 	profile, err := s.repo.GetProfileByCode(code)
 	if err != nil {
-		return nil, "", err
+		return result, err
 	}
 	if profile == nil || strings.TrimSpace(profile.Status) != constants.AffiliateProfileStatusActive {
-		return nil, "", nil
+		return result, nil
 	}
 	if userID > 0 && profile.UserID == userID {
-		return nil, "", nil
+		return result, nil
 	}
 
 	profileID := profile.ID
-	return &profileID, profile.AffiliateCode, nil
+	result.ProfileID = &profileID
+	result.Code = profile.AffiliateCode
+	return result, nil
 }
 
 // TrackClick 记录推广点击
@@ -293,8 +332,10 @@ func (s *AffiliateService) TrackClick(input AffiliateTrackClickInput) error {
 	}
 	visitorKey := strings.TrimSpace(input.VisitorKey)
 	landingPath := strings.TrimSpace(input.LandingPath)
+	subID := normalizeSettingTextWithRuneLimit(input.SubID, affiliateSubIDMaxRune)
 	if visitorKey != "" {
-		duplicated, err := s.repo.HasRecentClick(profile.ID, visitorKey, landingPath, time.Now().Add(-affiliateClickDedupeWindow))
+		// 同一访客点击不同子渠道的链接分别计入，仅合并同一子渠道的重复点击
+		duplicated, err := s.repo.HasRecentClick(profile.ID, visitorKey, landingPath, subID, time.Now().Add(-affiliateClickDedupeWindow))
 		if err != nil {
 			return err
 		}
@@ -310,6 +351,10 @@ func (s *AffiliateService) TrackClick(input AffiliateTrackClickInput) error {
 		Referrer:           strings.TrimSpace(input.Referrer),
 		ClientIP:           strings.TrimSpace(input.ClientIP),
 		UserAgent:          strings.TrimSpace(input.UserAgent),
+		SubID:              subID,
+		UTMSource:          normalizeSettingTextWithRuneLimit(input.UTMSource, affiliateUTMMaxRune),
+		UTMMedium:          normalizeSettingTextWithRuneLimit(input.UTMMedium, affiliateUTMMaxRune),
+		UTMCampaign:        normalizeSettingTextWithRuneLimit(input.UTMCampaign, affiliateUTMMaxRune),
 		CreatedAt:          time.Now(),
 	}
 	return s.repo.CreateClick(click)
@@ -324,7 +369,7 @@ func (s *AffiliateService) HandleOrderPaid(orderID uint) error {
 	if err != nil {
 		return err
 	}
-	if !setting.Enabled || setting.CommissionRate <= 0 {
+	if !setting.Enabled {
 		return nil
 	}
 
@@ -349,8 +394,12 @@ func (s *AffiliateService) HandleOrderPaid(orderID uint) error {
 		return nil
 	}
 
-	commissionType := constants.AffiliateCommissionTypeOrder
-	existing, err := s.repo.GetCommissionByOrderAndProfile(order.ID, profile.ID, commissionType)
+	// 首次归因成交时绑定推广关系，供后续二级返利使用。
+	if err := s.bindReferral(order.UserID, profile, constants.AffiliateReferralSourceOrder); err != nil {
+		return err
+	}
+
+	existing, err := s.repo.GetCommissionByOrderAndProfile(order.ID, profile.ID, constants.AffiliateCommissionTypeOrder)
 	if err != nil {
 		return err
 	}
@@ -358,55 +407,52 @@ func (s *AffiliateService) HandleOrderPaid(orderID uint) error {
 		return nil
 	}
 
-	baseAmount, err := s.calculateCommissionBaseAmount(order)
+	calculated, err := s.calculateOrderCommission(order, profile.ID, setting)
 	if err != nil {
 		return err
 	}
+	baseAmount := calculated.BaseAmount
 	if baseAmount.LessThanOrEqual(decimal.Zero) {
 		return nil
 	}
-	rate := decimal.NewFromFloat(setting.CommissionRate).Round(2)
-	commissionAmount := baseAmount.Mul(rate).Div(decimal.NewFromInt(100)).Round(2)
-	if commissionAmount.LessThanOrEqual(decimal.Zero) {
-		return nil
+	// 各商品比例可能不同，记录按基数加权后的综合比例。
+	rate := calculated.CommissionAmount.Mul(decimal.NewFromInt(100)).Div(baseAmount).Round(2)
+	if err := s.createOrderCommission(order, profile.ID, constants.AffiliateCommissionTypeOrder, constants.AffiliateCommissionLevelDirect, baseAmount, rate, calculated.CommissionAmount, setting); err != nil {
+		return err
 	}
 
-	paidAt := time.Now()
-	if order.PaidAt != nil {
-		paidAt = *order.PaidAt
+	if !setting.SecondTierEnabled || setting.SecondTierRate <= 0 {
+		return nil
 	}
-	status := constants.AffiliateCommissionStatusPendingConfirm
-	var confirmAt *time.Time
-	var availableAt *time.Time
-	if setting.ConfirmDays <= 0 {
-		status = constants.AffiliateCommissionStatusAvailable
-		availableAt = &paidAt
-	} else {
-		t := paidAt.Add(time.Duration(setting.ConfirmDays) * 24 * time.Hour)
-		confirmAt = &t
+	parent, err := s.resolveSecondTierProfile(profile, order.UserID)
+	if err != nil {
+		return err
 	}
-
-	commission := &models.AffiliateCommission{
-		AffiliateProfileID: profile.ID,
-		OrderID:            order.ID,
-		CommissionType:     commissionType,
-		BaseAmount:         models.NewMoneyFromDecimal(baseAmount),
-		RatePercent:        models.NewMoneyFromDecimal(rate),
-		CommissionAmount:   models.NewMoneyFromDecimal(commissionAmount),
-		Status:             status,
-		ConfirmAt:          confirmAt,
-		AvailableAt:        availableAt,
+	if parent == nil {
+		return nil
 	}
-	return s.repo.CreateCommission(commission)
+	secondTierRate := decimal.NewFromFloat(setting.SecondTierRate).Round(2)
+	secondTierAmount := baseAmount.Mul(secondTierRate).Div(decimal.NewFromInt(100)).Round(2)
+	return s.createOrderCommission(order, parent.ID, constants.AffiliateCommissionTypeSecondTier, constants.AffiliateCommissionLevelSecondTier, baseAmount, secondTierRate, secondTierAmount, setting)
 }
 
-// ConfirmDueCommissions 将到期佣金转可提现
-func (s *AffiliateService) ConfirmDueCommissions(now time.Time) error {
-	if s.repo == nil {
+// BindUserReferral 注册时按推广码/访客标识绑定推广关系（已绑定则忽略）
+func (s *AffiliateService) BindUserReferral(userID uint, rawCode, rawVisitorKey string) error {
+	if userID == 0 || s.repo == nil {
 		return nil
 	}
-	_, err := s.repo.MarkPendingCommissionsAvailable(now, now)
-	return err
+	profileID, _, err := s.ResolveOrderAffiliateSnapshot(userID, rawCode, rawVisitorKey)
+	if err != nil {
+		return err
+	}
+	if profileID == nil {
+		return nil
+	}
+	profile, err := s.repo.GetProfileByID(*profileID)
+	if err != nil {
+		return err
+	}
+	return s.bindReferral(userID, profile, constants.AffiliateReferralSourceRegister)
 }
 
 // HandleOrderCanceled 处理订单取消/退款后的佣金逆向
@@ -416,6 +462,7 @@ func (s *AffiliateService) HandleOrderCanceled(orderID uint, reason string) erro
 	}
 	rows, err := s.repo.ListCommissionsByOrder(orderID, []string{
 		constants.AffiliateCommissionStatusPendingConfirm,
+		constants.AffiliateCommissionStatusPendingReview,
 		constants.AffiliateCommissionStatusAvailable,
 	})
 	if err != nil {
@@ -483,6 +530,7 @@ func (s *AffiliateService) HandleOrderRefundedTx(
 	repoTx := s.repo.WithTx(tx)
 	rows, err := repoTx.ListCommissionsByOrderForUpdate(order.ID, []string{
 		constants.AffiliateCommissionStatusPendingConfirm,
+		constants.AffiliateCommissionStatusPendingReview,
 		constants.AffiliateCommissionStatusAvailable,
 	})
 	if err != nil {
@@ -552,10 +600,12 @@ func (s *AffiliateService) HandleOrderRefundedTx(
 // GetUserDashboard 获取用户返利中心数据
 func (s *AffiliateService) GetUserDashboard(userID uint) (AffiliateDashboard, error) {
 	dashboard := AffiliateDashboard{
-		Opened:              false,
-		PendingCommission:   models.NewMoneyFromDecimal(decimal.Zero),
-		AvailableCommission: models.NewMoneyFromDecimal(decimal.Zero),
-		WithdrawnCommission: models.NewMoneyFromDecimal(decimal.Zero),
+		Opened:               false,
+		PendingCommission:    models.NewMoneyFromDecimal(decimal.Zero),
+		AvailableCommission:  models.NewMoneyFromDecimal(decimal.Zero),
+		WithdrawnCommission:  models.NewMoneyFromDecimal(decimal.Zero),
+		DirectCommission:     models.NewMoneyFromDecimal(decimal.Zero),
+		SecondTierCommission: models.NewMoneyFromDecimal(decimal.Zero),
 	}
 	if userID == 0 || s.repo == nil {
 		return dashboard, nil
@@ -581,11 +631,33 @@ func (s *AffiliateService) GetUserDashboard(userID uint) (AffiliateDashboard, er
 	dashboard.PendingCommission = stats.PendingCommission
 	dashboard.AvailableCommission = stats.AvailableCommission
 	dashboard.WithdrawnCommission = stats.WithdrawnCommission
+
+	referredCount, err := s.repo.CountReferralsByProfile(profile.ID)
+	if err != nil {
+		return dashboard, err
+	}
+	validStatuses := []string{
+		constants.AffiliateCommissionStatusPendingConfirm,
+		constants.AffiliateCommissionStatusPendingReview,
+		constants.AffiliateCommissionStatusAvailable,
+		constants.AffiliateCommissionStatusWithdrawn,
+	}
+	directAmount, err := s.repo.SumCommissionByProfileAndLevel(profile.ID, constants.AffiliateCommissionLevelDirect, validStatuses)
+	if err != nil {
+		return dashboard, err
+	}
+	secondTierAmount, err := s.repo.SumCommissionByProfileAndLevel(profile.ID, constants.AffiliateCommissionLevelSecondTier, validStatuses)
+	if err != nil {
+		return dashboard, err
+	}
+	dashboard.ReferredUserCount = referredCount
+	dashboard.DirectCommission = models.NewMoneyFromDecimal(directAmount)
+	dashboard.SecondTierCommission = models.NewMoneyFromDecimal(secondTierAmount)
 	return dashboard, nil
 }
 
-// ListUserCommissions 查询用户佣金记录
-func (s *AffiliateService) ListUserCommissions(userID uint, page, pageSize int, status string) ([]models.AffiliateCommission, int64, error) {
+// ListUserCommissions 查询用户佣金记录（level 为 0 时不区分层级）
+func (s *AffiliateService) ListUserCommissions(userID uint, page, pageSize int, status string, level int) ([]models.AffiliateCommission, int64, error) {
 	if userID == 0 || s.repo == nil {
 		return []models.AffiliateCommission{}, 0, nil
 	}
@@ -601,6 +673,7 @@ func (s *AffiliateService) ListUserCommissions(userID uint, page, pageSize int,
 		PageSize:           pageSize,
 		AffiliateProfileID: profile.ID,
 		Status:             strings.TrimSpace(status),
+		Level:              level,
 	})
 }
 
@@ -653,9 +726,7 @@ func (s *AffiliateService) ApplyWithdraw(userID uint, input AffiliateWithdrawApp
 	if len(setting.WithdrawChannels) > 0 && !containsWithdrawChannel(setting.WithdrawChannels, channel) {
 		return nil, ErrAffiliateWithdrawChannelInvalid
 	}
-	if err := s.ConfirmDueCommissions(time.Now()); err != nil {
-		return nil, err
-	}
+	now := time.Now()
 
 	var createdID uint
 	err = s.repo.Transaction(func(tx *gorm.DB) error {
@@ -670,6 +741,12 @@ func (s *AffiliateService) ApplyWithdraw(userID uint, input AffiliateWithdrawApp
 		if strings.TrimSpace(profile.Status) != constants.AffiliateProfileStatusActive {
 			return ErrAffiliateNotOpened
 		}
+		// 未开启风控时仅将本人到期佣金转可提现；开启风控时由定时任务统一筛查后转入
+		if !setting.FraudCheckEnabled {
+			if _, err := repoTx.MarkProfilePendingCommissionsAvailable(profile.ID, now, now); err != nil {
+				return err
+			}
+		}
 
 		commissions, err := repoTx.ListAvailableCommissionsForUpdate(profile.ID)
 		if err != nil {
@@ -677,8 +754,16 @@ func (s *AffiliateService) ApplyWithdraw(userID uint, input AffiliateWithdrawApp
 		}
 
 		remaining := amount
+		directAmount := decimal.Zero
+		secondTierAmount := decimal.Zero
+		addLevelAmount := func(level int, value decimal.Decimal) {
+			if level == constants.AffiliateCommissionLevelSecondTier {
+				secondTierAmount = secondTierAmount.Add(value).Round(2)
+				return
+			}
+			directAmount = directAmount.Add(value).Round(2)
+		}
 		selectedIDs := make([]uint, 0)
-		now := time.Now()
 		for _, commission := range commissions {
 			if remaining.LessThanOrEqual(decimal.Zero) {
 				break
@@ -689,6 +774,7 @@ func (s *AffiliateService) ApplyWithdraw(userID uint, input AffiliateWithdrawApp
 			}
 			if rowAmount.LessThanOrEqual(remaining) {
 				selectedIDs = append(selectedIDs, commission.ID)
+				addLevelAmount(commission.Level, rowAmount)
 				remaining = remaining.Sub(rowAmount).Round(2)
 				continue
 			}
@@ -716,6 +802,7 @@ func (s *AffiliateService) ApplyWithdraw(userID uint, input AffiliateWithdrawApp
 			}
 
 			selectedIDs = append(selectedIDs, commission.ID)
+			addLevelAmount(commission.Level, boundAmount)
 			remaining = decimal.Zero
 			break
 		}
@@ -726,6 +813,8 @@ func (s *AffiliateService) ApplyWithdraw(userID uint, input AffiliateWithdrawApp
 		req := &models.AffiliateWithdrawRequest{
 			AffiliateProfileID: profile.ID,
 			Amount:             models.NewMoneyFromDecimal(amount),
+			DirectAmount:       models.NewMoneyFromDecimal(directAmount),
+			SecondTierAmount:   models.NewMoneyFromDecimal(secondTierAmount),
 			Channel:            channel,
 			Account:            account,
 			Status:             constants.AffiliateWithdrawStatusPendingReview,
@@ -864,6 +953,8 @@ func (s *AffiliateService) ListAdminCommissions(filter AffiliateAdminCommissionL
 		AffiliateProfileID: filter.AffiliateProfileID,
 		OrderNo:            strings.TrimSpace(filter.OrderNo),
 		Status:             strings.TrimSpace(filter.Status),
+		Level:              filter.Level,
+		RiskFlagged:        filter.RiskFlagged,
 		Keyword:            strings.TrimSpace(filter.Keyword),
 	})
 }
@@ -895,43 +986,128 @@ func (s *AffiliateService) resolveAffiliateProfileForOrder(order *models.Order)
 	return nil, nil
 }
 
-func (s *AffiliateService) calculateCommissionBaseAmount(order *models.Order) (decimal.Decimal, error) {
-	if order == nil || s.productRepo == nil {
-		return decimal.Zero, nil
+func (s *AffiliateService) createOrderCommission(
+	order *models.Order,
+	profileID uint,
+	commissionType string,
+	level int,
+	baseAmount decimal.Decimal,
+	rate decimal.Decimal,
+	commissionAmount decimal.Decimal,
+	setting AffiliateSetting,
+) error {
+	existing, err := s.repo.GetCommissionByOrderAndProfile(order.ID, profileID, commissionType)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+	commissionAmount = commissionAmount.Round(2)
+	if commissionAmount.LessThanOrEqual(decimal.Zero) {
+		return nil
+	}
+
+	paidAt := time.Now()
+	if order.PaidAt != nil {
+		paidAt = *order.PaidAt
+	}
+	status := constants.AffiliateCommissionStatusPendingConfirm
+	var confirmAt *time.Time
+	var availableAt *time.Time
+	switch {
+	case setting.ConfirmDays > 0:
+		t := paidAt.Add(time.Duration(setting.ConfirmDays) * 24 * time.Hour)
+		confirmAt = &t
+	case setting.FraudCheckEnabled:
+		// 开启风控时即使无需等待确认期，也须经定时任务检测后才可提现。
+		confirmAt = &paidAt
+	default:
+		status = constants.AffiliateCommissionStatusAvailable
+		availableAt = &paidAt
+	}
+
+	commission := &models.AffiliateCommission{
+		AffiliateProfileID: profileID,
+		OrderID:            order.ID,
+		CommissionType:     commissionType,
+		Level:              level,
+		BaseAmount:         models.NewMoneyFromDecimal(baseAmount),
+		RatePercent:        models.NewMoneyFromDecimal(rate),
+		CommissionAmount:   models.NewMoneyFromDecimal(commissionAmount),
+		Status:             status,
+		ConfirmAt:          confirmAt,
+		AvailableAt:        availableAt,
 	}
-	productIDs := collectAffiliateProductIDs(order)
-	if len(productIDs) == 0 {
-		return decimal.Zero, nil
+	return s.repo.CreateCommission(commission)
+}
+
+// bindReferral 绑定被推广用户与推广用户的关系，仅首次生效
+func (s *AffiliateService) bindReferral(userID uint, profile *models.AffiliateProfile, source string) error {
+	if userID == 0 || profile == nil || profile.ID == 0 || profile.UserID == userID || s.repo == nil {
+		return nil
 	}
-	products, err := s.productRepo.ListByIDs(productIDs)
+	existing, err := s.repo.GetReferralByUserID(userID)
 	if err != nil {
-		return decimal.Zero, err
+		return err
 	}
-	productMap := make(map[uint]models.Product, len(products))
-	for _, product := range products {
-		productMap[product.ID] = product
+	if existing != nil {
+		return nil
 	}
 
-	targetOrders := order.Children
-	if len(targetOrders) == 0 {
-		targetOrders = []models.Order{*order}
+	// 推广用户本身由当前用户推广而来时不再反向绑定，避免形成环。
+	upstream, err := s.repo.GetReferralByUserID(profile.UserID)
+	if err != nil {
+		return err
+	}
+	if upstream != nil {
+		own, err := s.repo.GetProfileByUserID(userID)
+		if err != nil {
+			return err
+		}
+		if own != nil && own.ID == upstream.AffiliateProfileID {
+			return nil
+		}
 	}
 
-	total := decimal.Zero
-	for _, current := range targetOrders {
-		for _, item := range current.Items {
-			product, ok := productMap[item.ProductID]
-			if !ok || !product.IsAffiliateEnabled {
-				continue
-			}
-			payable := item.TotalPrice.Decimal.Sub(item.CouponDiscount.Decimal).Round(2)
-			if payable.LessThan(decimal.Zero) {
-				payable = decimal.Zero
-			}
-			total = total.Add(payable).Round(2)
+	referral := &models.AffiliateReferral{
+		UserID:             userID,
+		AffiliateProfileID: profile.ID,
+		Source:             source,
+		CreatedAt:          time.Now(),
+	}
+	if err := s.repo.CreateReferral(referral); err != nil {
+		if isUniqueViolation(err) {
+			return nil
 		}
+		return err
+	}
+	return nil
+}
+
+// resolveSecondTierProfile 查找直推推广用户的上级推广用户
+func (s *AffiliateService) resolveSecondTierProfile(profile *models.AffiliateProfile, buyerUserID uint) (*models.AffiliateProfile, error) {
+	if profile == nil || s.repo == nil {
+		return nil, nil
+	}
+	referral, err := s.repo.GetReferralByUserID(profile.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if referral == nil || referral.AffiliateProfileID == profile.ID {
+		return nil, nil
+	}
+	parent, err := s.repo.GetProfileByID(referral.AffiliateProfileID)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil || strings.TrimSpace(parent.Status) != constants.AffiliateProfileStatusActive {
+		return nil, nil
+	}
+	if buyerUserID > 0 && parent.UserID == buyerUserID {
+		return nil, nil
 	}
-	return total, nil
+	return parent, nil
 }
 
 func collectAffiliateProductIDs(order *models.Order) []uint {
@@ -980,6 +1156,7 @@ func (s *AffiliateService) buildProfileStats(profileID uint) (AffiliateStats, er
 	}
 	pendingAmount, err := s.repo.SumCommissionByProfile(profileID, []string{
 		constants.AffiliateCommissionStatusPendingConfirm,
+		constants.AffiliateCommissionStatusPendingReview,
 	}, false)
 	if err != nil {
 		return stats, err
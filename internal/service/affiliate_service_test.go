@@ -9,6 +9,7 @@ import (
 	"github.com/dujiao-next/internal/models"
 	"github.com/dujiao-next/internal/repository"
 	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -123,6 +124,74 @@ func TestBatchUpdateAffiliateProfileStatus(t *testing.T) {
 	}
 }
 
+func TestHandleOrderPaidCreatesSecondTierCommission(t *testing.T) {
+	svc, db := setupAffiliateServiceTest(t)
+	if _, err := svc.settingService.UpdateAffiliateSetting(AffiliateSetting{
+		Enabled:           true,
+		CommissionRate:    20,
+		SecondTierEnabled: true,
+		SecondTierRate:    5,
+	}); err != nil {
+		t.Fatalf("update affiliate setting failed: %v", err)
+	}
+
+	userA := createAffiliateTestUser(t, db, "affiliate-tier-a@example.com")
+	userB := createAffiliateTestUser(t, db, "affiliate-tier-b@example.com")
+	buyer := createAffiliateTestUser(t, db, "affiliate-tier-buyer@example.com")
+	profileA := createAffiliateTestProfile(t, db, userA.ID, "AFFTA001", constants.AffiliateProfileStatusActive)
+	profileB := createAffiliateTestProfile(t, db, userB.ID, "AFFTB002", constants.AffiliateProfileStatusActive)
+
+	if err := svc.BindUserReferral(userB.ID, profileA.AffiliateCode, ""); err != nil {
+		t.Fatalf("bind referral failed: %v", err)
+	}
+	// 反向绑定应被忽略，避免 A、B 互为上级。
+	if err := svc.BindUserReferral(userA.ID, profileB.AffiliateCode, ""); err != nil {
+		t.Fatalf("bind reverse referral failed: %v", err)
+	}
+	if reverse, err := svc.repo.GetReferralByUserID(userA.ID); err != nil || reverse != nil {
+		t.Fatalf("expected reverse referral ignored, got %+v err=%v", reverse, err)
+	}
+
+	order := createAffiliateTestPaidOrder(t, db, buyer.ID, profileB.ID, decimal.NewFromInt(100))
+	if err := svc.HandleOrderPaid(order.ID); err != nil {
+		t.Fatalf("handle order paid failed: %v", err)
+	}
+	// 重复回调不应重复生成佣金。
+	if err := svc.HandleOrderPaid(order.ID); err != nil {
+		t.Fatalf("handle order paid again failed: %v", err)
+	}
+
+	var rows []models.AffiliateCommission
+	if err := db.Order("level asc").Find(&rows).Error; err != nil {
+		t.Fatalf("load commissions failed: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 commissions, got %d", len(rows))
+	}
+	if rows[0].AffiliateProfileID != profileB.ID || rows[0].Level != constants.AffiliateCommissionLevelDirect ||
+		!rows[0].CommissionAmount.Decimal.Equal(decimal.NewFromInt(20)) {
+		t.Fatalf("unexpected direct commission: %+v", rows[0])
+	}
+	if rows[1].AffiliateProfileID != profileA.ID || rows[1].Level != constants.AffiliateCommissionLevelSecondTier ||
+		rows[1].CommissionType != constants.AffiliateCommissionTypeSecondTier ||
+		!rows[1].CommissionAmount.Decimal.Equal(decimal.NewFromInt(5)) {
+		t.Fatalf("unexpected second tier commission: %+v", rows[1])
+	}
+
+	buyerReferral, err := svc.repo.GetReferralByUserID(buyer.ID)
+	if err != nil || buyerReferral == nil || buyerReferral.AffiliateProfileID != profileB.ID {
+		t.Fatalf("expected buyer bound to profile B on first attribution, got %+v err=%v", buyerReferral, err)
+	}
+
+	dashboard, err := svc.GetUserDashboard(userA.ID)
+	if err != nil {
+		t.Fatalf("get dashboard failed: %v", err)
+	}
+	if dashboard.ReferredUserCount != 1 || !dashboard.SecondTierCommission.Decimal.Equal(decimal.NewFromInt(5)) {
+		t.Fatalf("unexpected dashboard for profile A: %+v", dashboard)
+	}
+}
+
 func setupAffiliateServiceTest(t *testing.T) (*AffiliateService, *gorm.DB) {
 	t.Helper()
 
@@ -131,7 +200,21 @@ func setupAffiliateServiceTest(t *testing.T) (*AffiliateService, *gorm.DB) {
 	if err != nil {
 		t.Fatalf("open sqlite failed: %v", err)
 	}
-	if err := db.AutoMigrate(&models.User{}, &models.AffiliateProfile{}, &models.AffiliateClick{}); err != nil {
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.AffiliateProfile{},
+		&models.AffiliateClick{},
+		&models.AffiliateReferral{},
+		&models.AffiliateCommission{},
+		&models.AffiliateCommissionRule{},
+		&models.UserLoginLog{},
+		&models.UserOAuthIdentity{},
+		&models.Category{},
+		&models.Product{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.Fulfillment{},
+	); err != nil {
 		t.Fatalf("auto migrate failed: %v", err)
 	}
 
@@ -145,7 +228,13 @@ func setupAffiliateServiceTest(t *testing.T) (*AffiliateService, *gorm.DB) {
 	}
 
 	affiliateRepo := repository.NewAffiliateRepository(db)
-	return NewAffiliateService(affiliateRepo, repository.NewUserRepository(db), nil, nil, settingSvc), db
+	return NewAffiliateService(
+		affiliateRepo,
+		repository.NewUserRepository(db),
+		repository.NewOrderRepository(db),
+		repository.NewProductRepository(db),
+		settingSvc,
+	), db
 }
 
 func createAffiliateTestUser(t *testing.T, db *gorm.DB, email string) models.User {
@@ -194,3 +283,52 @@ func createAffiliateTestClick(t *testing.T, db *gorm.DB, profileID uint, visitor
 		t.Fatalf("create affiliate click failed: %v", err)
 	}
 }
+
+func createAffiliateTestPaidOrder(t *testing.T, db *gorm.DB, userID, profileID uint, amount decimal.Decimal) models.Order {
+	t.Helper()
+
+	now := time.Now()
+	product := models.Product{
+		CategoryID:         1,
+		Slug:               fmt.Sprintf("affiliate-product-%d", now.UnixNano()),
+		TitleJSON:          models.JSON{"zh-CN": "推广商品"},
+		PriceAmount:        models.NewMoneyFromDecimal(amount),
+		FulfillmentType:    constants.FulfillmentTypeManual,
+		IsAffiliateEnabled: true,
+		IsActive:           true,
+	}
+	if err := db.Create(&product).Error; err != nil {
+		t.Fatalf("create product failed: %v", err)
+	}
+	order := models.Order{
+		OrderNo:            fmt.Sprintf("AFF-%d", now.UnixNano()),
+		UserID:             userID,
+		Status:             constants.OrderStatusPaid,
+		Currency:           "CNY",
+		OriginalAmount:     models.NewMoneyFromDecimal(amount),
+		TotalAmount:        models.NewMoneyFromDecimal(amount),
+		AffiliateProfileID: &profileID,
+		PaidAt:             &now,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatalf("create order failed: %v", err)
+	}
+	item := models.OrderItem{
+		OrderID:         order.ID,
+		ProductID:       product.ID,
+		SKUID:           1,
+		TitleJSON:       models.JSON{"zh-CN": "推广商品"},
+		UnitPrice:       models.NewMoneyFromDecimal(amount),
+		Quantity:        1,
+		TotalPrice:      models.NewMoneyFromDecimal(amount),
+		FulfillmentType: constants.FulfillmentTypeManual,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := db.Create(&item).Error; err != nil {
+		t.Fatalf("create order item failed: %v", err)
+	}
+	return order
+}
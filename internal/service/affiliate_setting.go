@@ -17,15 +17,28 @@ const (
 	affiliateMinWithdrawAmountMin    = 0
 	affiliateWithdrawChannelsMaxSize = 20
 	affiliateWithdrawChannelMaxRune  = 50
+	affiliateFraudConversionRateMax  = 100
+	affiliateFraudMinOrdersDefault   = 5
+	affiliateFraudWindowDaysDefault  = 30
+	affiliateFraudWindowDaysMax      = 365
 )
 
 // AffiliateSetting 推广返利配置
 type AffiliateSetting struct {
 	Enabled           bool     `json:"enabled"`
 	CommissionRate    float64  `json:"commission_rate"`
+	CommissionBasis   string   `json:"commission_basis"`
+	SecondTierEnabled bool     `json:"second_tier_enabled"`
+	SecondTierRate    float64  `json:"second_tier_rate"`
 	ConfirmDays       int      `json:"confirm_days"`
 	MinWithdrawAmount float64  `json:"min_withdraw_amount"`
 	WithdrawChannels  []string `json:"withdraw_channels"`
+	// 风控：佣金转可提现前执行规则检测，命中后转人工复核
+	FraudCheckEnabled      bool    `json:"fraud_check_enabled"`
+	FraudMaxConversionRate float64 `json:"fraud_max_conversion_rate"` // 点击下单比上限（百分比），0 表示不检测
+	FraudMinOrders         int     `json:"fraud_min_orders"`          // 点击下单比检测的最少订单数
+	FraudRefundThreshold   int     `json:"fraud_refund_threshold"`    // 同一被推广用户退款订单数阈值，0 表示不检测
+	FraudWindowDays        int     `json:"fraud_window_days"`         // 风控统计窗口天数
 }
 
 // AffiliateDefaultSetting 默认推广返利配置
@@ -33,9 +46,18 @@ func AffiliateDefaultSetting() AffiliateSetting {
 	return NormalizeAffiliateSetting(AffiliateSetting{
 		Enabled:           false,
 		CommissionRate:    0,
+		CommissionBasis:   constants.AffiliateCommissionBasisPaidAmount,
+		SecondTierEnabled: false,
+		SecondTierRate:    0,
 		ConfirmDays:       0,
 		MinWithdrawAmount: 0,
 		WithdrawChannels:  []string{},
+
+		FraudCheckEnabled:      true,
+		FraudMaxConversionRate: 60,
+		FraudMinOrders:         affiliateFraudMinOrdersDefault,
+		FraudRefundThreshold:   2,
+		FraudWindowDays:        affiliateFraudWindowDaysDefault,
 	})
 }
 
@@ -49,6 +71,16 @@ func NormalizeAffiliateSetting(setting AffiliateSetting) AffiliateSetting {
 		setting.CommissionRate = affiliateCommissionRateMax
 	}
 
+	setting.CommissionBasis = normalizeAffiliateCommissionBasis(setting.CommissionBasis)
+
+	setting.SecondTierRate = roundAffiliateDecimal(setting.SecondTierRate)
+	if setting.SecondTierRate < affiliateCommissionRateMin {
+		setting.SecondTierRate = affiliateCommissionRateMin
+	}
+	if setting.SecondTierRate > affiliateCommissionRateMax {
+		setting.SecondTierRate = affiliateCommissionRateMax
+	}
+
 	if setting.ConfirmDays < affiliateConfirmDaysMin {
 		setting.ConfirmDays = affiliateConfirmDaysMin
 	}
@@ -62,6 +94,26 @@ func NormalizeAffiliateSetting(setting AffiliateSetting) AffiliateSetting {
 	}
 
 	setting.WithdrawChannels = normalizeAffiliateWithdrawChannels(setting.WithdrawChannels)
+
+	setting.FraudMaxConversionRate = roundAffiliateDecimal(setting.FraudMaxConversionRate)
+	if setting.FraudMaxConversionRate < 0 {
+		setting.FraudMaxConversionRate = 0
+	}
+	if setting.FraudMaxConversionRate > affiliateFraudConversionRateMax {
+		setting.FraudMaxConversionRate = affiliateFraudConversionRateMax
+	}
+	if setting.FraudMinOrders <= 0 {
+		setting.FraudMinOrders = affiliateFraudMinOrdersDefault
+	}
+	if setting.FraudRefundThreshold < 0 {
+		setting.FraudRefundThreshold = 0
+	}
+	if setting.FraudWindowDays <= 0 {
+		setting.FraudWindowDays = affiliateFraudWindowDaysDefault
+	}
+	if setting.FraudWindowDays > affiliateFraudWindowDaysMax {
+		setting.FraudWindowDays = affiliateFraudWindowDaysMax
+	}
 	return setting
 }
 
@@ -71,6 +123,9 @@ func ValidateAffiliateSetting(setting AffiliateSetting) error {
 	if normalized.CommissionRate < affiliateCommissionRateMin || normalized.CommissionRate > affiliateCommissionRateMax {
 		return fmt.Errorf("%w: 返利比例必须在 0-100 之间", ErrAffiliateConfigInvalid)
 	}
+	if normalized.SecondTierRate < affiliateCommissionRateMin || normalized.SecondTierRate > affiliateCommissionRateMax {
+		return fmt.Errorf("%w: 二级返利比例必须在 0-100 之间", ErrAffiliateConfigInvalid)
+	}
 	if normalized.ConfirmDays < affiliateConfirmDaysMin || normalized.ConfirmDays > affiliateConfirmDaysMax {
 		return fmt.Errorf("%w: 佣金确认天数必须在 0-3650 之间", ErrAffiliateConfigInvalid)
 	}
@@ -86,9 +141,18 @@ func AffiliateSettingToMap(setting AffiliateSetting) map[string]interface{} {
 	return map[string]interface{}{
 		"enabled":             normalized.Enabled,
 		"commission_rate":     normalized.CommissionRate,
+		"commission_basis":    normalized.CommissionBasis,
+		"second_tier_enabled": normalized.SecondTierEnabled,
+		"second_tier_rate":    normalized.SecondTierRate,
 		"confirm_days":        normalized.ConfirmDays,
 		"min_withdraw_amount": normalized.MinWithdrawAmount,
 		"withdraw_channels":   cloneStringSlice(normalized.WithdrawChannels),
+
+		"fraud_check_enabled":       normalized.FraudCheckEnabled,
+		"fraud_max_conversion_rate": normalized.FraudMaxConversionRate,
+		"fraud_min_orders":          normalized.FraudMinOrders,
+		"fraud_refund_threshold":    normalized.FraudRefundThreshold,
+		"fraud_window_days":         normalized.FraudWindowDays,
 	}
 }
 
@@ -103,6 +167,17 @@ func affiliateSettingFromJSON(raw models.JSON, fallback AffiliateSetting) Affili
 			result.CommissionRate = parsed
 		}
 	}
+	if basisRaw, ok := raw["commission_basis"]; ok {
+		result.CommissionBasis = normalizeSettingText(basisRaw)
+	}
+	if secondTierEnabledRaw, ok := raw["second_tier_enabled"]; ok {
+		result.SecondTierEnabled = parseSettingBool(secondTierEnabledRaw)
+	}
+	if secondTierRateRaw, ok := raw["second_tier_rate"]; ok {
+		if parsed, err := parseSettingFloat(secondTierRateRaw); err == nil {
+			result.SecondTierRate = parsed
+		}
+	}
 	if confirmDaysRaw, ok := raw["confirm_days"]; ok {
 		if parsed, err := parseSettingInt(confirmDaysRaw); err == nil {
 			result.ConfirmDays = parsed
@@ -116,6 +191,29 @@ func affiliateSettingFromJSON(raw models.JSON, fallback AffiliateSetting) Affili
 	if channelsRaw, ok := raw["withdraw_channels"]; ok {
 		result.WithdrawChannels = normalizeSettingStringList(channelsRaw)
 	}
+	if fraudEnabledRaw, ok := raw["fraud_check_enabled"]; ok {
+		result.FraudCheckEnabled = parseSettingBool(fraudEnabledRaw)
+	}
+	if conversionRaw, ok := raw["fraud_max_conversion_rate"]; ok {
+		if parsed, err := parseSettingFloat(conversionRaw); err == nil {
+			result.FraudMaxConversionRate = parsed
+		}
+	}
+	if minOrdersRaw, ok := raw["fraud_min_orders"]; ok {
+		if parsed, err := parseSettingInt(minOrdersRaw); err == nil {
+			result.FraudMinOrders = parsed
+		}
+	}
+	if refundRaw, ok := raw["fraud_refund_threshold"]; ok {
+		if parsed, err := parseSettingInt(refundRaw); err == nil {
+			result.FraudRefundThreshold = parsed
+		}
+	}
+	if windowRaw, ok := raw["fraud_window_days"]; ok {
+		if parsed, err := parseSettingInt(windowRaw); err == nil {
+			result.FraudWindowDays = parsed
+		}
+	}
 
 	return NormalizeAffiliateSetting(result)
 }
@@ -158,6 +256,13 @@ func roundAffiliateDecimal(value float64) float64 {
 	return math.Round(value*100) / 100
 }
 
+func normalizeAffiliateCommissionBasis(raw string) string {
+	if strings.ToLower(strings.TrimSpace(raw)) == constants.AffiliateCommissionBasisProfit {
+		return constants.AffiliateCommissionBasisProfit
+	}
+	return constants.AffiliateCommissionBasisPaidAmount
+}
+
 func normalizeAffiliateWithdrawChannels(channels []string) []string {
 	if len(channels) == 0 {
 		return []string{}
@@ -4,33 +4,72 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/logger"
 	"github.com/dujiao-next/internal/models"
 	"github.com/dujiao-next/internal/repository"
+
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
 var (
-	ErrApiCredentialExists       = errors.New("api credential already exists for this user")
-	ErrApiCredentialNotFound     = errors.New("api credential not found")
-	ErrApiCredentialNotApproved  = errors.New("api credential is not approved")
-	ErrApiCredentialPendingExist = errors.New("pending application already exists")
+	ErrApiCredentialExists          = errors.New("api credential already exists for this user")
+	ErrApiCredentialNotFound        = errors.New("api credential not found")
+	ErrApiCredentialNotApproved     = errors.New("api credential is not approved")
+	ErrApiCredentialPendingExist    = errors.New("pending application already exists")
+	ErrApiCredentialLimitReached    = errors.New("api credential limit reached")
+	ErrApiCredentialScopeInvalid    = errors.New("api credential scope invalid")
+	ErrApiCredentialIPInvalid       = errors.New("api credential ip allowlist invalid")
+	ErrApiCredentialSettingsInvalid = errors.New("api credential settings invalid")
+	ErrApiCredentialOrderQuota      = errors.New("api credential daily order quota exceeded")
+	ErrApiCredentialAmountQuota     = errors.New("api credential daily amount quota exceeded")
+	ErrApiCredentialUsageFilter     = errors.New("api credential usage filter invalid")
+)
+
+const (
+	// MaxApiCredentialsPerUser 每个用户可持有的密钥数量上限
+	MaxApiCredentialsPerUser = 10
+	// maxApiCredentialIPAllowlist 单个密钥 IP 白名单条目上限
+	maxApiCredentialIPAllowlist  = 50
+	apiCredentialUsageDateLayout = "2006-01-02"
 )
 
+// ApiCredentialKeyInput 密钥配置输入；限额字段为 nil 时保持不变（用户侧不可修改限额）
+type ApiCredentialKeyInput struct {
+	Name               string
+	Scopes             []string
+	IPAllowlist        []string
+	RateLimitPerMinute *int
+	DailyOrderLimit    *int
+	DailyAmountLimit   *decimal.Decimal
+}
+
 // ApiCredentialService API 凭证服务
 type ApiCredentialService struct {
-	credRepo repository.ApiCredentialRepository
+	credRepo  repository.ApiCredentialRepository
+	usageRepo repository.ApiCredentialUsageRepository
 }
 
 // NewApiCredentialService 创建凭证服务
-func NewApiCredentialService(credRepo repository.ApiCredentialRepository) *ApiCredentialService {
-	return &ApiCredentialService{credRepo: credRepo}
+func NewApiCredentialService(credRepo repository.ApiCredentialRepository, usageRepo repository.ApiCredentialUsageRepository) *ApiCredentialService {
+	return &ApiCredentialService{credRepo: credRepo, usageRepo: usageRepo}
 }
 
 // Apply 用户申请 API 对接权限
 func (s *ApiCredentialService) Apply(userID uint) (*models.ApiCredential, error) {
+	approved, err := s.hasApprovedCredential(userID)
+	if err != nil {
+		return nil, err
+	}
+	if approved {
+		return nil, ErrApiCredentialExists
+	}
+
 	existing, err := s.credRepo.GetAnyByUserID(userID)
 	if err != nil {
 		return nil, err
@@ -237,6 +276,403 @@ func (s *ApiCredentialService) Delete(id uint) error {
 	return s.credRepo.Delete(id)
 }
 
+// ListByUserID 获取用户的全部密钥
+func (s *ApiCredentialService) ListByUserID(userID uint) ([]models.ApiCredential, error) {
+	return s.credRepo.ListByUserID(userID)
+}
+
+// GetByApiKey 根据 API Key 获取凭证
+func (s *ApiCredentialService) GetByApiKey(apiKey string) (*models.ApiCredential, error) {
+	return s.credRepo.GetByApiKey(apiKey)
+}
+
+// CreateKey 已通过审核的用户新建具名密钥，Secret 仅在创建时返回一次
+func (s *ApiCredentialService) CreateKey(userID uint, input ApiCredentialKeyInput) (*models.ApiCredential, string, error) {
+	approved, err := s.hasApprovedCredential(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !approved {
+		return nil, "", ErrApiCredentialNotApproved
+	}
+	count, err := s.credRepo.CountByUserID(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if count >= MaxApiCredentialsPerUser {
+		return nil, "", ErrApiCredentialLimitReached
+	}
+
+	cred := &models.ApiCredential{UserID: userID}
+	if err := applyApiCredentialKeyInput(cred, input); err != nil {
+		return nil, "", err
+	}
+	apiKey, err := generateRandomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	apiSecret, err := generateRandomHex(64)
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	cred.ApiKey = apiKey
+	cred.ApiSecret = apiSecret
+	cred.Status = constants.ApiCredentialStatusApproved
+	cred.ApprovedAt = &now
+	cred.IsActive = true
+	if err := s.credRepo.Create(cred); err != nil {
+		return nil, "", err
+	}
+	return cred, apiSecret, nil
+}
+
+// UpdateKeyByUser 用户修改自己密钥的名称、权限范围与 IP 白名单
+func (s *ApiCredentialService) UpdateKeyByUser(userID, id uint, input ApiCredentialKeyInput) (*models.ApiCredential, error) {
+	cred, err := s.getOwned(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	input.RateLimitPerMinute = nil
+	input.DailyOrderLimit = nil
+	input.DailyAmountLimit = nil
+	if err := applyApiCredentialKeyInput(cred, input); err != nil {
+		return nil, err
+	}
+	if err := s.credRepo.Update(cred); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// UpdateSettings admin 修改密钥配置（含限流与每日配额）
+func (s *ApiCredentialService) UpdateSettings(id uint, input ApiCredentialKeyInput) (*models.ApiCredential, error) {
+	cred, err := s.credRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if cred == nil {
+		return nil, ErrApiCredentialNotFound
+	}
+	if err := applyApiCredentialKeyInput(cred, input); err != nil {
+		return nil, err
+	}
+	if err := s.credRepo.Update(cred); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// RegenerateKeyByUser 用户重新生成指定密钥的 Secret
+func (s *ApiCredentialService) RegenerateKeyByUser(userID, id uint) (string, error) {
+	cred, err := s.getOwned(userID, id)
+	if err != nil {
+		return "", err
+	}
+	return s.Regenerate(cred.ID)
+}
+
+// SetKeyActiveByUser 用户启用/禁用指定密钥
+func (s *ApiCredentialService) SetKeyActiveByUser(userID, id uint, active bool) error {
+	cred, err := s.getOwned(userID, id)
+	if err != nil {
+		return err
+	}
+	return s.SetActive(cred.ID, active)
+}
+
+// DeleteKeyByUser 用户删除指定密钥
+func (s *ApiCredentialService) DeleteKeyByUser(userID, id uint) error {
+	cred, err := s.getOwned(userID, id)
+	if err != nil {
+		return err
+	}
+	return s.credRepo.Delete(cred.ID)
+}
+
+// TouchLastUsed 更新最后使用时间
+func (s *ApiCredentialService) TouchLastUsed(cred *models.ApiCredential) {
+	if cred == nil {
+		return
+	}
+	if err := s.credRepo.TouchLastUsed(cred.ID, time.Now()); err != nil {
+		logger.Warnw("api_credential_touch_last_used_failed", "credential_id", cred.ID, "error", err)
+	}
+}
+
+// RecordRequest 记录一次通过鉴权的请求
+func (s *ApiCredentialService) RecordRequest(cred *models.ApiCredential) {
+	s.recordUsage(cred, repository.ApiCredentialUsageDelta{Requests: 1})
+}
+
+// RecordRejected 记录一次因权限范围、IP 或配额被拒绝的请求
+func (s *ApiCredentialService) RecordRejected(cred *models.ApiCredential) {
+	s.recordUsage(cred, repository.ApiCredentialUsageDelta{Rejected: 1})
+}
+
+// RecordOrder 记录一笔已支付的下单
+func (s *ApiCredentialService) RecordOrder(cred *models.ApiCredential, amount decimal.Decimal) {
+	s.recordUsage(cred, repository.ApiCredentialUsageDelta{Orders: 1, OrderAmount: amount})
+}
+
+func (s *ApiCredentialService) recordUsage(cred *models.ApiCredential, delta repository.ApiCredentialUsageDelta) {
+	if cred == nil || s.usageRepo == nil {
+		return
+	}
+	if err := s.usageRepo.Increment(cred.ID, cred.UserID, time.Now().Format(apiCredentialUsageDateLayout), delta); err != nil {
+		logger.Warnw("api_credential_record_usage_failed", "credential_id", cred.ID, "error", err)
+	}
+}
+
+// CheckOrderQuota 校验当日下单配额；amount 为本次下单金额，传 0 时仅校验是否已用尽
+func (s *ApiCredentialService) CheckOrderQuota(cred *models.ApiCredential, amount decimal.Decimal) error {
+	if cred == nil || s.usageRepo == nil || !apiCredentialHasOrderQuota(cred) {
+		return nil
+	}
+	usage, err := s.usageRepo.GetByCredentialAndDate(cred.ID, time.Now().Format(apiCredentialUsageDateLayout))
+	if err != nil {
+		return err
+	}
+	return checkApiCredentialOrderQuota(cred, usage, amount)
+}
+
+// ReserveOrderQuota 在锁定当日用量记录的事务内校验并预占下单配额（计入下单数与金额），
+// 返回预占所在的用量日期。下单或支付失败时需以该日期调用 ReleaseOrderQuota 归还。
+func (s *ApiCredentialService) ReserveOrderQuota(cred *models.ApiCredential, amount decimal.Decimal) (string, error) {
+	date := time.Now().Format(apiCredentialUsageDateLayout)
+	if cred == nil || s.usageRepo == nil {
+		return date, nil
+	}
+	delta := repository.ApiCredentialUsageDelta{Orders: 1, OrderAmount: amount}
+	if !apiCredentialHasOrderQuota(cred) {
+		return date, s.usageRepo.Increment(cred.ID, cred.UserID, date, delta)
+	}
+	// 确保当日记录存在，以便事务内加行锁
+	if err := s.usageRepo.Increment(cred.ID, cred.UserID, date, repository.ApiCredentialUsageDelta{}); err != nil {
+		return date, err
+	}
+	return date, s.usageRepo.Transaction(func(tx *gorm.DB) error {
+		repoTx := s.usageRepo.WithTx(tx)
+		usage, err := repoTx.GetByCredentialAndDateForUpdate(cred.ID, date)
+		if err != nil {
+			return err
+		}
+		if err := checkApiCredentialOrderQuota(cred, usage, amount); err != nil {
+			return err
+		}
+		return repoTx.Increment(cred.ID, cred.UserID, date, delta)
+	})
+}
+
+// ReleaseOrderQuota 归还 ReserveOrderQuota 预占的下单配额；date 为预占时返回的用量日期，
+// 避免跨日归还时冲减到次日的用量记录
+func (s *ApiCredentialService) ReleaseOrderQuota(cred *models.ApiCredential, date string, amount decimal.Decimal) {
+	if cred == nil || s.usageRepo == nil {
+		return
+	}
+	if strings.TrimSpace(date) == "" {
+		date = time.Now().Format(apiCredentialUsageDateLayout)
+	}
+	delta := repository.ApiCredentialUsageDelta{Orders: -1, OrderAmount: amount.Neg()}
+	if err := s.usageRepo.Increment(cred.ID, cred.UserID, date, delta); err != nil {
+		logger.Warnw("api_credential_release_quota_failed", "credential_id", cred.ID, "date", date, "error", err)
+	}
+}
+
+func apiCredentialHasOrderQuota(cred *models.ApiCredential) bool {
+	return cred.DailyOrderLimit > 0 || cred.DailyAmountLimit.Decimal.IsPositive()
+}
+
+func checkApiCredentialOrderQuota(cred *models.ApiCredential, usage *models.ApiCredentialUsage, amount decimal.Decimal) error {
+	var orders int64
+	used := decimal.Zero
+	if usage != nil {
+		orders = usage.OrderCount
+		used = usage.OrderAmount.Decimal
+	}
+	if cred.DailyOrderLimit > 0 && orders >= int64(cred.DailyOrderLimit) {
+		return ErrApiCredentialOrderQuota
+	}
+	amountLimit := cred.DailyAmountLimit.Decimal
+	if amountLimit.IsPositive() && (used.GreaterThanOrEqual(amountLimit) || used.Add(amount).GreaterThan(amountLimit)) {
+		return ErrApiCredentialAmountQuota
+	}
+	return nil
+}
+
+// ListUsage 用量日志列表，日期格式为 YYYY-MM-DD
+func (s *ApiCredentialService) ListUsage(filter repository.ApiCredentialUsageListFilter) ([]models.ApiCredentialUsage, int64, error) {
+	for _, raw := range []string{filter.DateFrom, filter.DateTo} {
+		if raw == "" {
+			continue
+		}
+		if _, err := time.Parse(apiCredentialUsageDateLayout, raw); err != nil {
+			return nil, 0, ErrApiCredentialUsageFilter
+		}
+	}
+	return s.usageRepo.List(filter)
+}
+
+// ApiCredentialHasScope 判断密钥是否拥有指定权限范围（未配置时视为全部权限）
+func ApiCredentialHasScope(cred *models.ApiCredential, scope string) bool {
+	if cred == nil {
+		return false
+	}
+	if len(cred.Scopes) == 0 {
+		return true
+	}
+	for _, item := range cred.Scopes {
+		if item == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ApiCredentialAllowsIP 判断来源 IP 是否在密钥白名单内（未配置时不限制）
+func ApiCredentialAllowsIP(cred *models.ApiCredential, ip string) bool {
+	if cred == nil {
+		return false
+	}
+	if len(cred.IPAllowlist) == 0 {
+		return true
+	}
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return false
+	}
+	for _, entry := range cred.IPAllowlist {
+		if strings.Contains(entry, "/") {
+			if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(parsed) {
+				return true
+			}
+			continue
+		}
+		if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ApiCredentialService) getOwned(userID, id uint) (*models.ApiCredential, error) {
+	cred, err := s.credRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if cred == nil || cred.UserID != userID {
+		return nil, ErrApiCredentialNotFound
+	}
+	return cred, nil
+}
+
+func (s *ApiCredentialService) hasApprovedCredential(userID uint) (bool, error) {
+	creds, err := s.credRepo.ListByUserID(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, cred := range creds {
+		if cred.Status == constants.ApiCredentialStatusApproved {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func applyApiCredentialKeyInput(cred *models.ApiCredential, input ApiCredentialKeyInput) error {
+	scopes, err := normalizeApiCredentialScopes(input.Scopes)
+	if err != nil {
+		return err
+	}
+	allowlist, err := normalizeApiCredentialIPAllowlist(input.IPAllowlist)
+	if err != nil {
+		return err
+	}
+	if input.RateLimitPerMinute != nil && (*input.RateLimitPerMinute < 0 || *input.RateLimitPerMinute > constants.ApiCredentialMaxRateLimitPerMinute) {
+		return ErrApiCredentialSettingsInvalid
+	}
+	if input.DailyOrderLimit != nil && *input.DailyOrderLimit < 0 {
+		return ErrApiCredentialSettingsInvalid
+	}
+	if input.DailyAmountLimit != nil && input.DailyAmountLimit.IsNegative() {
+		return ErrApiCredentialSettingsInvalid
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if len([]rune(name)) > 100 {
+		return ErrApiCredentialSettingsInvalid
+	}
+	cred.Name = name
+	cred.Scopes = scopes
+	cred.IPAllowlist = allowlist
+	if input.RateLimitPerMinute != nil {
+		cred.RateLimitPerMinute = *input.RateLimitPerMinute
+	}
+	if input.DailyOrderLimit != nil {
+		cred.DailyOrderLimit = *input.DailyOrderLimit
+	}
+	if input.DailyAmountLimit != nil {
+		cred.DailyAmountLimit = models.NewMoneyFromDecimal(input.DailyAmountLimit.Round(2))
+	}
+	return nil
+}
+
+func normalizeApiCredentialScopes(raw []string) (models.StringArray, error) {
+	selected := make(map[string]bool, len(raw))
+	for _, item := range raw {
+		scope := strings.ToLower(strings.TrimSpace(item))
+		if scope == "" {
+			continue
+		}
+		switch scope {
+		case constants.ApiScopeCatalogRead, constants.ApiScopeOrderCreate, constants.ApiScopeOrderRead:
+			selected[scope] = true
+		default:
+			return nil, ErrApiCredentialScopeInvalid
+		}
+	}
+	scopes := make(models.StringArray, 0, len(selected))
+	for _, scope := range []string{constants.ApiScopeCatalogRead, constants.ApiScopeOrderCreate, constants.ApiScopeOrderRead} {
+		if selected[scope] {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+func normalizeApiCredentialIPAllowlist(raw []string) (models.StringArray, error) {
+	allowlist := make(models.StringArray, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, item := range raw {
+		entry := strings.TrimSpace(item)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, ErrApiCredentialIPInvalid
+			}
+			entry = network.String()
+		} else {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, ErrApiCredentialIPInvalid
+			}
+			entry = ip.String()
+		}
+		if seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		allowlist = append(allowlist, entry)
+	}
+	if len(allowlist) > maxApiCredentialIPAllowlist {
+		return nil, ErrApiCredentialIPInvalid
+	}
+	return allowlist, nil
+}
+
 func generateRandomHex(byteLen int) (string, error) {
 	b := make([]byte, byteLen)
 	if _, err := rand.Read(b); err != nil {
@@ -10,6 +10,7 @@ import (
 	"github.com/dujiao-next/internal/models"
 	"github.com/dujiao-next/internal/repository"
 	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -23,12 +24,12 @@ func setupApiCredentialServiceTest(t *testing.T) (*ApiCredentialService, reposit
 	if err != nil {
 		t.Fatalf("open sqlite failed: %v", err)
 	}
-	if err := db.AutoMigrate(&models.ApiCredential{}); err != nil {
+	if err := db.AutoMigrate(&models.ApiCredential{}, &models.ApiCredentialUsage{}); err != nil {
 		t.Fatalf("auto migrate api credential failed: %v", err)
 	}
 
 	repo := repository.NewApiCredentialRepository(db)
-	return NewApiCredentialService(repo), repo, db
+	return NewApiCredentialService(repo, repository.NewApiCredentialUsageRepository(db)), repo, db
 }
 
 func TestApiCredentialServiceApplyCreatesPendingRecordWhenMissing(t *testing.T) {
@@ -209,3 +210,145 @@ func TestApiCredentialServiceApplyBlocksApprovedAndDisabled(t *testing.T) {
 		})
 	}
 }
+
+func TestApiCredentialServiceNamedKeysScopesAndQuota(t *testing.T) {
+	svc, repo, _ := setupApiCredentialServiceTest(t)
+
+	// 未通过审核不能新建密钥
+	if _, _, err := svc.CreateKey(2001, ApiCredentialKeyInput{Name: "bot"}); !errors.Is(err, ErrApiCredentialNotApproved) {
+		t.Fatalf("expected not approved, got %v", err)
+	}
+	applied, err := svc.Apply(2001)
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if _, _, err := svc.Approve(applied.ID); err != nil {
+		t.Fatalf("approve failed: %v", err)
+	}
+	if _, err := svc.Apply(2001); !errors.Is(err, ErrApiCredentialExists) {
+		t.Fatalf("expected exists after approval, got %v", err)
+	}
+
+	if _, _, err := svc.CreateKey(2001, ApiCredentialKeyInput{Scopes: []string{"admin:all"}}); !errors.Is(err, ErrApiCredentialScopeInvalid) {
+		t.Fatalf("expected invalid scope, got %v", err)
+	}
+	if _, _, err := svc.CreateKey(2001, ApiCredentialKeyInput{IPAllowlist: []string{"not-an-ip"}}); !errors.Is(err, ErrApiCredentialIPInvalid) {
+		t.Fatalf("expected invalid ip, got %v", err)
+	}
+	key, secret, err := svc.CreateKey(2001, ApiCredentialKeyInput{
+		Name:        " 只读 ",
+		Scopes:      []string{"order:read", "CATALOG:READ", "order:read"},
+		IPAllowlist: []string{"10.0.0.0/8", "192.168.1.10", "192.168.1.10"},
+	})
+	if err != nil {
+		t.Fatalf("create key failed: %v", err)
+	}
+	if secret == "" || key.Status != constants.ApiCredentialStatusApproved || !key.IsActive || key.Name != "只读" {
+		t.Fatalf("unexpected key: %+v", key)
+	}
+
+	stored, err := repo.GetByID(key.ID)
+	if err != nil || stored == nil {
+		t.Fatalf("reload key failed: %v", err)
+	}
+	if len(stored.Scopes) != 2 || stored.Scopes[0] != constants.ApiScopeCatalogRead || stored.Scopes[1] != constants.ApiScopeOrderRead {
+		t.Fatalf("unexpected scopes: %v", stored.Scopes)
+	}
+	if len(stored.IPAllowlist) != 2 {
+		t.Fatalf("unexpected allowlist: %v", stored.IPAllowlist)
+	}
+	if ApiCredentialHasScope(stored, constants.ApiScopeOrderCreate) || !ApiCredentialHasScope(stored, constants.ApiScopeCatalogRead) {
+		t.Fatalf("unexpected scope check for %v", stored.Scopes)
+	}
+	if !ApiCredentialAllowsIP(stored, "10.2.3.4") || !ApiCredentialAllowsIP(stored, "192.168.1.10") || ApiCredentialAllowsIP(stored, "192.168.1.11") {
+		t.Fatalf("unexpected ip allowlist check for %v", stored.IPAllowlist)
+	}
+
+	creds, err := svc.ListByUserID(2001)
+	if err != nil || len(creds) != 2 {
+		t.Fatalf("expected two keys, got %d err=%v", len(creds), err)
+	}
+	// 其他用户不能操作该密钥
+	if err := svc.DeleteKeyByUser(2002, key.ID); !errors.Is(err, ErrApiCredentialNotFound) {
+		t.Fatalf("expected not found for other user, got %v", err)
+	}
+
+	// 用户侧修改不影响 admin 配置的配额
+	orders, amount := 2, decimal.NewFromInt(100)
+	if _, err := svc.UpdateSettings(key.ID, ApiCredentialKeyInput{Name: "只读", Scopes: []string{"catalog:read"}, DailyOrderLimit: &orders, DailyAmountLimit: &amount}); err != nil {
+		t.Fatalf("update settings failed: %v", err)
+	}
+	updated, err := svc.UpdateKeyByUser(2001, key.ID, ApiCredentialKeyInput{Name: "bot"})
+	if err != nil {
+		t.Fatalf("update by user failed: %v", err)
+	}
+	if updated.DailyOrderLimit != 2 || !updated.DailyAmountLimit.Decimal.Equal(amount) || len(updated.Scopes) != 0 {
+		t.Fatalf("unexpected settings after user update: %+v", updated)
+	}
+
+	svc.RecordRequest(updated)
+	svc.RecordRequest(updated)
+	svc.RecordRejected(updated)
+	if err := svc.CheckOrderQuota(updated, decimal.NewFromInt(120)); !errors.Is(err, ErrApiCredentialAmountQuota) {
+		t.Fatalf("expected amount quota, got %v", err)
+	}
+	svc.RecordOrder(updated, decimal.NewFromInt(30))
+	if err := svc.CheckOrderQuota(updated, decimal.NewFromInt(70)); err != nil {
+		t.Fatalf("expected quota available, got %v", err)
+	}
+	svc.RecordOrder(updated, decimal.NewFromInt(30))
+	if err := svc.CheckOrderQuota(updated, decimal.Zero); !errors.Is(err, ErrApiCredentialOrderQuota) {
+		t.Fatalf("expected order quota, got %v", err)
+	}
+
+	usages, total, err := svc.ListUsage(repository.ApiCredentialUsageListFilter{UserID: 2001})
+	if err != nil || total != 1 {
+		t.Fatalf("expected one usage row, got %d err=%v", total, err)
+	}
+	usage := usages[0]
+	if usage.CredentialID != key.ID || usage.RequestCount != 2 || usage.RejectedCount != 1 || usage.OrderCount != 2 ||
+		!usage.OrderAmount.Decimal.Equal(decimal.NewFromInt(60)) {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+	if _, _, err := svc.ListUsage(repository.ApiCredentialUsageListFilter{DateFrom: "2024/01/01"}); !errors.Is(err, ErrApiCredentialUsageFilter) {
+		t.Fatalf("expected invalid date filter, got %v", err)
+	}
+}
+
+func TestApiCredentialServiceReserveOrderQuota(t *testing.T) {
+	svc, _, _ := setupApiCredentialServiceTest(t)
+
+	cred := &models.ApiCredential{ID: 3001, UserID: 3001, DailyOrderLimit: 2, DailyAmountLimit: models.NewMoneyFromDecimal(decimal.NewFromInt(100))}
+	if _, err := svc.ReserveOrderQuota(cred, decimal.NewFromInt(120)); !errors.Is(err, ErrApiCredentialAmountQuota) {
+		t.Fatalf("expected amount quota, got %v", err)
+	}
+	reservedDate, err := svc.ReserveOrderQuota(cred, decimal.NewFromInt(60))
+	if err != nil {
+		t.Fatalf("reserve failed: %v", err)
+	}
+	if _, err := svc.ReserveOrderQuota(cred, decimal.NewFromInt(60)); !errors.Is(err, ErrApiCredentialAmountQuota) {
+		t.Fatalf("expected amount quota after reservation, got %v", err)
+	}
+	// 下单失败归还后可再次预占
+	svc.ReleaseOrderQuota(cred, reservedDate, decimal.NewFromInt(60))
+	for i := 0; i < 2; i++ {
+		if _, err := svc.ReserveOrderQuota(cred, decimal.NewFromInt(30)); err != nil {
+			t.Fatalf("reserve %d failed: %v", i, err)
+		}
+	}
+	if _, err := svc.ReserveOrderQuota(cred, decimal.NewFromInt(1)); !errors.Is(err, ErrApiCredentialOrderQuota) {
+		t.Fatalf("expected order quota, got %v", err)
+	}
+
+	// 跨日归还只冲减预占所在日期的记录，不影响当日用量
+	yesterday := time.Now().AddDate(0, 0, -1).Format(apiCredentialUsageDateLayout)
+	svc.ReleaseOrderQuota(cred, yesterday, decimal.NewFromInt(30))
+
+	usages, _, err := svc.ListUsage(repository.ApiCredentialUsageListFilter{UserID: 3001, DateFrom: reservedDate})
+	if err != nil || len(usages) != 1 {
+		t.Fatalf("expected one usage row, got %d err=%v", len(usages), err)
+	}
+	if usages[0].OrderCount != 2 || !usages[0].OrderAmount.Decimal.Equal(decimal.NewFromInt(60)) {
+		t.Fatalf("unexpected usage: %+v", usages[0])
+	}
+}
@@ -0,0 +1,532 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/logger"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/queue"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/dujiao-next/internal/upstream"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// CardReplenishService 卡密自动补货服务：本地库存低于下限时向上游采购并导入为新批次
+type CardReplenishService struct {
+	ruleRepo       repository.CardReplenishRuleRepository
+	procRepo       repository.ProcurementOrderRepository
+	secretRepo     repository.CardSecretRepository
+	productRepo    repository.ProductRepository
+	productSKURepo repository.ProductSKURepository
+	cardSecretSvc  *CardSecretService
+	connSvc        *SiteConnectionService
+	queueClient    *queue.Client
+}
+
+// NewCardReplenishService 创建卡密自动补货服务
+func NewCardReplenishService(
+	ruleRepo repository.CardReplenishRuleRepository,
+	procRepo repository.ProcurementOrderRepository,
+	secretRepo repository.CardSecretRepository,
+	productRepo repository.ProductRepository,
+	productSKURepo repository.ProductSKURepository,
+	cardSecretSvc *CardSecretService,
+	connSvc *SiteConnectionService,
+	queueClient *queue.Client,
+) *CardReplenishService {
+	return &CardReplenishService{
+		ruleRepo:       ruleRepo,
+		procRepo:       procRepo,
+		secretRepo:     secretRepo,
+		productRepo:    productRepo,
+		productSKURepo: productSKURepo,
+		cardSecretSvc:  cardSecretSvc,
+		connSvc:        connSvc,
+		queueClient:    queueClient,
+	}
+}
+
+// CardReplenishRuleInput 补货规则创建/更新输入
+type CardReplenishRuleInput struct {
+	ProductID         uint
+	SKUID             uint
+	ConnectionID      uint
+	UpstreamProductID uint
+	UpstreamSKUID     uint
+	MinLevel          int
+	TargetLevel       int
+	MaxUnitCost       models.Money
+	DailySpendCap     models.Money
+	IsActive          *bool
+}
+
+// CardReplenishRunResult 单条规则的巡检结果
+type CardReplenishRunResult struct {
+	RuleID           uint                     `json:"rule_id"`
+	Available        int64                    `json:"available"`
+	Ordered          bool                     `json:"ordered"`
+	SkipReason       string                   `json:"skip_reason,omitempty"`
+	ProcurementOrder *models.ProcurementOrder `json:"procurement_order,omitempty"`
+}
+
+// 补货跳过原因
+const (
+	replenishSkipInactive        = "rule_inactive"
+	replenishSkipInProgress      = "replenishment_in_progress"
+	replenishSkipStockSufficient = "stock_sufficient"
+	replenishSkipConnection      = "connection_unavailable"
+	replenishSkipUpstreamSKU     = "upstream_sku_unavailable"
+	replenishSkipUpstreamStock   = "upstream_out_of_stock"
+	replenishSkipUnitCost        = "unit_cost_exceeds_limit"
+	replenishSkipSpendCap        = "daily_spend_cap_reached"
+	replenishSkipOrderFailed     = "upstream_order_failed"
+)
+
+// ListRules 规则列表
+func (s *CardReplenishService) ListRules(filter repository.CardReplenishRuleListFilter) ([]models.CardReplenishRule, int64, error) {
+	return s.ruleRepo.List(filter)
+}
+
+// CreateRule 创建补货规则
+func (s *CardReplenishService) CreateRule(input CardReplenishRuleInput) (*models.CardReplenishRule, error) {
+	rule := &models.CardReplenishRule{IsActive: true}
+	if err := s.applyRuleInput(rule, input); err != nil {
+		return nil, err
+	}
+	existing, err := s.ruleRepo.GetBySKUID(rule.SKUID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrCardReplenishRuleExists
+	}
+	if err := s.ruleRepo.Create(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// UpdateRule 更新补货规则
+func (s *CardReplenishService) UpdateRule(id uint, input CardReplenishRuleInput) (*models.CardReplenishRule, error) {
+	rule, err := s.ruleRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrCardReplenishRuleNotFound
+	}
+	if err := s.applyRuleInput(rule, input); err != nil {
+		return nil, err
+	}
+	existing, err := s.ruleRepo.GetBySKUID(rule.SKUID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.ID != rule.ID {
+		return nil, ErrCardReplenishRuleExists
+	}
+	if err := s.ruleRepo.Update(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeleteRule 删除补货规则（存在进行中的补货采购单时不允许删除）
+func (s *CardReplenishService) DeleteRule(id uint) error {
+	rule, err := s.ruleRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		return ErrCardReplenishRuleNotFound
+	}
+	open, err := s.procRepo.CountOpenReplenishOrders(rule.ID)
+	if err != nil {
+		return err
+	}
+	if open > 0 {
+		return ErrCardReplenishRuleBusy
+	}
+	return s.ruleRepo.Delete(rule.ID)
+}
+
+// applyRuleInput 校验并写入规则字段
+func (s *CardReplenishService) applyRuleInput(rule *models.CardReplenishRule, input CardReplenishRuleInput) error {
+	if input.ProductID == 0 || input.SKUID == 0 || input.ConnectionID == 0 || input.UpstreamProductID == 0 || input.UpstreamSKUID == 0 {
+		return ErrCardReplenishRuleInvalid
+	}
+	if input.MinLevel <= 0 || input.TargetLevel < input.MinLevel {
+		return ErrCardReplenishRuleInvalid
+	}
+	if input.MaxUnitCost.Decimal.IsNegative() || input.DailySpendCap.Decimal.IsNegative() {
+		return ErrCardReplenishRuleInvalid
+	}
+
+	product, err := s.productRepo.GetByID(fmt.Sprintf("%d", input.ProductID))
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return ErrProductNotFound
+	}
+	if product.FulfillmentType != constants.FulfillmentTypeAuto {
+		return ErrCardReplenishRuleInvalid
+	}
+	sku, err := s.productSKURepo.GetByID(input.SKUID)
+	if err != nil {
+		return err
+	}
+	if sku == nil || sku.ProductID != product.ID {
+		return ErrProductSKUInvalid
+	}
+	conn, err := s.connSvc.GetByID(input.ConnectionID)
+	if err != nil {
+		return err
+	}
+	if conn == nil {
+		return ErrConnectionNotFound
+	}
+
+	rule.ProductID = product.ID
+	rule.SKUID = sku.ID
+	rule.ConnectionID = conn.ID
+	rule.UpstreamProductID = input.UpstreamProductID
+	rule.UpstreamSKUID = input.UpstreamSKUID
+	rule.MinLevel = input.MinLevel
+	rule.TargetLevel = input.TargetLevel
+	rule.MaxUnitCost = models.NewMoneyFromDecimal(input.MaxUnitCost.Decimal.Round(2))
+	rule.DailySpendCap = models.NewMoneyFromDecimal(input.DailySpendCap.Decimal.Round(2))
+	if input.IsActive != nil {
+		rule.IsActive = *input.IsActive
+	}
+	return nil
+}
+
+// RunAll 定时巡检：逐条检查启用的规则并按需向上游下单
+func (s *CardReplenishService) RunAll() {
+	rules, err := s.ruleRepo.ListActive()
+	if err != nil {
+		logger.Warnw("card_replenish_list_rules_failed", "error", err)
+		return
+	}
+	for i := range rules {
+		if _, err := s.runRule(&rules[i], false); err != nil {
+			logger.Warnw("card_replenish_run_rule_failed",
+				"rule_id", rules[i].ID,
+				"error", err,
+			)
+		}
+	}
+}
+
+// RunRule 手动触发单条规则的补货检查（忽略启用状态）
+func (s *CardReplenishService) RunRule(id uint) (*CardReplenishRunResult, error) {
+	rule, err := s.ruleRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrCardReplenishRuleNotFound
+	}
+	return s.runRule(rule, true)
+}
+
+// runRule 检查单条规则：库存低于下限时在单价与日限额约束内下单补足至目标库存
+func (s *CardReplenishService) runRule(rule *models.CardReplenishRule, force bool) (*CardReplenishRunResult, error) {
+	now := time.Now()
+	result := &CardReplenishRunResult{RuleID: rule.ID}
+	if !rule.IsActive && !force {
+		result.SkipReason = replenishSkipInactive
+		return result, nil
+	}
+
+	open, err := s.procRepo.CountOpenReplenishOrders(rule.ID)
+	if err != nil {
+		return nil, err
+	}
+	if open > 0 {
+		result.SkipReason = replenishSkipInProgress
+		return result, s.recordRun(rule, now, "")
+	}
+
+	available, err := s.secretRepo.CountAvailable(rule.ProductID, rule.SKUID)
+	if err != nil {
+		return nil, err
+	}
+	result.Available = available
+	if available >= int64(rule.MinLevel) {
+		result.SkipReason = replenishSkipStockSufficient
+		return result, s.recordRun(rule, now, "")
+	}
+	quantity := rule.TargetLevel - int(available)
+
+	conn, err := s.connSvc.GetByID(rule.ConnectionID)
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil || conn.Status == constants.ConnectionStatusDisabled {
+		return s.skipRun(rule, result, now, replenishSkipConnection)
+	}
+	adapter, err := s.connSvc.GetAdapter(conn)
+	if err != nil {
+		return s.skipRun(rule, result, now, replenishSkipConnection)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	upstreamProduct, err := adapter.GetProduct(ctx, rule.UpstreamProductID)
+	if err != nil {
+		logger.Warnw("card_replenish_fetch_upstream_product_failed",
+			"rule_id", rule.ID,
+			"upstream_product_id", rule.UpstreamProductID,
+			"error", err,
+		)
+		return s.skipRun(rule, result, now, replenishSkipUpstreamSKU)
+	}
+	upstreamSKU := findReplenishUpstreamSKU(upstreamProduct, rule.UpstreamSKUID)
+	if upstreamSKU == nil || !upstreamSKU.IsActive || !upstreamProduct.IsActive {
+		return s.skipRun(rule, result, now, replenishSkipUpstreamSKU)
+	}
+	unitCost, err := decimal.NewFromString(strings.TrimSpace(upstreamSKU.PriceAmount))
+	if err != nil || unitCost.LessThanOrEqual(decimal.Zero) {
+		return s.skipRun(rule, result, now, replenishSkipUpstreamSKU)
+	}
+	// 单价上限与每日预算按本地币种填写，上游价格需先按连接汇率换算
+	localUnitCost := convertCurrency(unitCost, conn.ExchangeRate)
+	if rule.MaxUnitCost.Decimal.GreaterThan(decimal.Zero) && localUnitCost.GreaterThan(rule.MaxUnitCost.Decimal) {
+		return s.skipRun(rule, result, now, replenishSkipUnitCost)
+	}
+	if upstreamSKU.StockQuantity >= 0 && quantity > upstreamSKU.StockQuantity {
+		quantity = upstreamSKU.StockQuantity
+	}
+	if quantity <= 0 {
+		return s.skipRun(rule, result, now, replenishSkipUpstreamStock)
+	}
+
+	if rule.DailySpendCap.Decimal.GreaterThan(decimal.Zero) {
+		spent, err := s.dailySpend(rule.ID, now)
+		if err != nil {
+			return nil, err
+		}
+		affordable := rule.DailySpendCap.Decimal.Sub(convertCurrency(spent, conn.ExchangeRate)).Div(localUnitCost).Floor().IntPart()
+		if int64(quantity) > affordable {
+			quantity = int(affordable)
+		}
+		if quantity <= 0 {
+			return s.skipRun(rule, result, now, replenishSkipSpendCap)
+		}
+	}
+
+	ruleID := rule.ID
+	procOrder := &models.ProcurementOrder{
+		ConnectionID:      conn.ID,
+		LocalOrderNo:      generateReplenishOrderNo(),
+		ReplenishRuleID:   &ruleID,
+		ReplenishQuantity: quantity,
+		Status:            constants.ProcurementStatusPending,
+		UpstreamAmount:    models.NewMoneyFromDecimal(unitCost.Mul(decimal.NewFromInt(int64(quantity))).Round(2)),
+		UpstreamCurrency:  upstreamProduct.Currency,
+		Currency:          upstreamProduct.Currency,
+		TraceID:           uuid.NewString(),
+	}
+	if err := s.procRepo.Create(procOrder); err != nil {
+		return nil, fmt.Errorf("create replenish procurement order: %w", err)
+	}
+
+	resp, err := adapter.CreateOrder(ctx, upstream.CreateUpstreamOrderReq{
+		SKUID:             rule.UpstreamSKUID,
+		Quantity:          quantity,
+		DownstreamOrderNo: procOrder.LocalOrderNo,
+		TraceID:           procOrder.TraceID,
+		CallbackURL:       conn.CallbackURL,
+	})
+	if err != nil || !resp.OK {
+		// 补货采购单不自动重试，下一轮巡检会重新评估库存
+		status := constants.ProcurementStatusRejected
+		var errMsg string
+		if err != nil {
+			status = constants.ProcurementStatusFailed
+			errMsg = fmt.Sprintf("upstream request error: %v", err)
+		} else {
+			errMsg = resp.ErrorMessage
+			if errMsg == "" {
+				errMsg = resp.ErrorCode
+			}
+		}
+		_ = s.procRepo.UpdateStatus(procOrder.ID, status, map[string]interface{}{
+			"error_message": errMsg,
+			"updated_at":    now,
+		})
+		procOrder.Status = status
+		procOrder.ErrorMessage = errMsg
+		logger.Warnw("card_replenish_upstream_order_failed",
+			"rule_id", rule.ID,
+			"procurement_order_id", procOrder.ID,
+			"error", errMsg,
+		)
+		result.ProcurementOrder = procOrder
+		return s.skipRun(rule, result, now, replenishSkipOrderFailed)
+	}
+
+	updates := map[string]interface{}{
+		"upstream_order_id": resp.OrderID,
+		"upstream_order_no": resp.OrderNo,
+		"updated_at":        now,
+	}
+	if amount, parseErr := decimal.NewFromString(strings.TrimSpace(resp.Amount)); parseErr == nil {
+		updates["upstream_amount"] = models.NewMoneyFromDecimal(amount.Round(2))
+	}
+	if currency := strings.TrimSpace(resp.Currency); currency != "" {
+		updates["upstream_currency"] = currency
+	}
+	if err := s.procRepo.UpdateStatus(procOrder.ID, constants.ProcurementStatusAccepted, updates); err != nil {
+		return nil, fmt.Errorf("update replenish procurement status: %w", err)
+	}
+	procOrder.Status = constants.ProcurementStatusAccepted
+	procOrder.UpstreamOrderID = resp.OrderID
+	procOrder.UpstreamOrderNo = resp.OrderNo
+
+	if err := s.ruleRepo.UpdateRunState(rule.ID, map[string]interface{}{
+		"last_checked_at": now,
+		"last_ordered_at": now,
+		"last_error":      "",
+	}); err != nil {
+		return nil, err
+	}
+	logger.Infow("card_replenish_upstream_order_accepted",
+		"rule_id", rule.ID,
+		"procurement_order_id", procOrder.ID,
+		"upstream_order_no", resp.OrderNo,
+		"quantity", quantity,
+		"available", available,
+	)
+
+	// 入队轮询任务（回调的兜底），之后由采购单定时巡检接管
+	if s.queueClient != nil {
+		_ = s.queueClient.EnqueueProcurementPollStatus(queue.ProcurementPollStatusPayload{
+			ProcurementOrderID: procOrder.ID,
+		}, 30*time.Second)
+	}
+
+	result.Ordered = true
+	result.ProcurementOrder = procOrder
+	return result, nil
+}
+
+// ImportDelivery 上游交付后将卡密导入为新批次，并标记补货采购单已交付
+func (s *CardReplenishService) ImportDelivery(procOrder *models.ProcurementOrder, fulfillment *upstream.UpstreamFulfillment) error {
+	if procOrder == nil || procOrder.ReplenishRuleID == nil {
+		return ErrProcurementStatusInvalid
+	}
+	if procOrder.ReplenishBatchID != nil {
+		return nil
+	}
+	if fulfillment == nil || strings.TrimSpace(fulfillment.Payload) == "" {
+		return fmt.Errorf("replenish procurement %d delivered without payload", procOrder.ID)
+	}
+	rule, err := s.ruleRepo.GetByID(*procOrder.ReplenishRuleID)
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		return ErrCardReplenishRuleNotFound
+	}
+
+	note := fmt.Sprintf("upstream order %s", procOrder.UpstreamOrderNo)
+	batch, count, err := s.cardSecretSvc.CreateCardSecretBatch(CreateCardSecretBatchInput{
+		ProductID: rule.ProductID,
+		SKUID:     rule.SKUID,
+		Secrets:   strings.Split(fulfillment.Payload, "\n"),
+		BatchNo:   procOrder.LocalOrderNo,
+		Note:      note,
+		Source:    constants.CardSecretSourceReplenish,
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := s.procRepo.UpdateStatus(procOrder.ID, constants.ProcurementStatusFulfilled, map[string]interface{}{
+		"upstream_payload":   fulfillment.Payload,
+		"replenish_batch_id": batch.ID,
+		"updated_at":         now,
+	}); err != nil {
+		return fmt.Errorf("update replenish procurement status: %w", err)
+	}
+	if count != procOrder.ReplenishQuantity {
+		logger.Warnw("card_replenish_delivered_quantity_mismatch",
+			"procurement_order_id", procOrder.ID,
+			"ordered", procOrder.ReplenishQuantity,
+			"imported", count,
+		)
+	}
+	logger.Infow("card_replenish_delivery_imported",
+		"rule_id", rule.ID,
+		"procurement_order_id", procOrder.ID,
+		"batch_id", batch.ID,
+		"count", count,
+	)
+	return nil
+}
+
+// dailySpend 统计规则当日已占用的采购金额（上游币种；失败、拒绝与取消的采购单不计）
+func (s *CardReplenishService) dailySpend(ruleID uint, now time.Time) (decimal.Decimal, error) {
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	orders, err := s.procRepo.ListReplenishOrdersSince(ruleID, startOfDay)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	spent := decimal.Zero
+	for _, order := range orders {
+		switch order.Status {
+		case constants.ProcurementStatusFailed, constants.ProcurementStatusRejected, constants.ProcurementStatusCanceled:
+			continue
+		}
+		spent = spent.Add(order.UpstreamAmount.Decimal)
+	}
+	return spent, nil
+}
+
+// skipRun 记录跳过原因并返回结果
+func (s *CardReplenishService) skipRun(rule *models.CardReplenishRule, result *CardReplenishRunResult, now time.Time, reason string) (*CardReplenishRunResult, error) {
+	result.SkipReason = reason
+	logger.Infow("card_replenish_skipped",
+		"rule_id", rule.ID,
+		"available", result.Available,
+		"reason", reason,
+	)
+	return result, s.recordRun(rule, now, reason)
+}
+
+// recordRun 记录规则最近巡检时间与跳过原因
+func (s *CardReplenishService) recordRun(rule *models.CardReplenishRule, now time.Time, lastError string) error {
+	return s.ruleRepo.UpdateRunState(rule.ID, map[string]interface{}{
+		"last_checked_at": now,
+		"last_error":      lastError,
+	})
+}
+
+// findReplenishUpstreamSKU 在上游商品中查找指定 SKU
+func findReplenishUpstreamSKU(product *upstream.UpstreamProduct, skuID uint) *upstream.UpstreamSKU {
+	if product == nil {
+		return nil
+	}
+	for i := range product.SKUs {
+		if product.SKUs[i].ID == skuID {
+			return &product.SKUs[i]
+		}
+	}
+	return nil
+}
+
+// generateReplenishOrderNo 生成补货采购单号（作为上游的 downstream_order_no 与卡密批次号）
+func generateReplenishOrderNo() string {
+	return fmt.Sprintf("RP%s%s", time.Now().Format("20060102150405"), strings.ToUpper(uuid.NewString()[:8]))
+}
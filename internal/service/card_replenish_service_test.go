@@ -0,0 +1,241 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/dujiao-next/internal/upstream"
+
+	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func TestCardReplenishRunAndImportDelivery(t *testing.T) {
+	dsn := fmt.Sprintf("file:card_replenish_test_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.Product{},
+		&models.ProductSKU{},
+		&models.CardSecret{},
+		&models.CardSecretBatch{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.ProcurementOrder{},
+		&models.SiteConnection{},
+		&models.CardReplenishRule{},
+	); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+
+	var orderedQuantities []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/upstream/products/77":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"ok": true,
+				"product": map[string]any{
+					"id":        77,
+					"currency":  "CNY",
+					"is_active": true,
+					"skus": []map[string]any{
+						{"id": 701, "price_amount": "2.50", "stock_quantity": -1, "is_active": true},
+					},
+				},
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/upstream/orders":
+			var req upstream.CreateUpstreamOrderReq
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			orderedQuantities = append(orderedQuantities, req.Quantity)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"ok":       true,
+				"order_id": 900 + len(orderedQuantities),
+				"order_no": fmt.Sprintf("UP-%d", len(orderedQuantities)),
+				"status":   "paid",
+				"amount":   decimal.NewFromFloat(2.5).Mul(decimal.NewFromInt(int64(req.Quantity))).StringFixed(2),
+				"currency": "CNY",
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	product := &models.Product{
+		CategoryID:      1,
+		Slug:            fmt.Sprintf("replenish-%d", time.Now().UnixNano()),
+		TitleJSON:       models.JSON{"zh-CN": "补货商品"},
+		PriceAmount:     models.NewMoneyFromDecimal(decimal.NewFromInt(5)),
+		FulfillmentType: constants.FulfillmentTypeAuto,
+		IsActive:        true,
+	}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product failed: %v", err)
+	}
+	sku := &models.ProductSKU{ProductID: product.ID, SKUCode: "DEFAULT", PriceAmount: product.PriceAmount, IsActive: true}
+	if err := db.Create(sku).Error; err != nil {
+		t.Fatalf("create sku failed: %v", err)
+	}
+	if err := db.Create(&models.CardSecret{ProductID: product.ID, SKUID: sku.ID, Secret: "LOCAL-1", Status: models.CardSecretStatusAvailable}).Error; err != nil {
+		t.Fatalf("create card secret failed: %v", err)
+	}
+
+	connSvc := NewSiteConnectionService(repository.NewSiteConnectionRepository(db), "test-key", t.TempDir())
+	conn, err := connSvc.Create(CreateConnectionInput{
+		Name:      "replenish-upstream",
+		BaseURL:   server.URL,
+		ApiKey:    "key",
+		ApiSecret: "secret",
+		Protocol:  constants.ConnectionProtocolDujiaoNext,
+	})
+	if err != nil {
+		t.Fatalf("create connection: %v", err)
+	}
+
+	secretRepo := repository.NewCardSecretRepository(db)
+	batchRepo := repository.NewCardSecretBatchRepository(db)
+	productRepo := repository.NewProductRepository(db)
+	skuRepo := repository.NewProductSKURepository(db)
+	procRepo := repository.NewProcurementOrderRepository(db)
+	svc := NewCardReplenishService(
+		repository.NewCardReplenishRuleRepository(db), procRepo, secretRepo, productRepo, skuRepo,
+		NewCardSecretService(secretRepo, batchRepo, productRepo, skuRepo), connSvc, nil,
+	)
+	procSvc := newTestProcurementService(db, connSvc)
+	procSvc.SetCardReplenishService(svc)
+
+	if _, err := svc.CreateRule(CardReplenishRuleInput{
+		ProductID: product.ID, SKUID: sku.ID, ConnectionID: conn.ID, UpstreamProductID: 77, UpstreamSKUID: 701,
+		MinLevel: 5, TargetLevel: 3,
+	}); !errors.Is(err, ErrCardReplenishRuleInvalid) {
+		t.Fatalf("expected invalid rule when target below min, got %v", err)
+	}
+	rule, err := svc.CreateRule(CardReplenishRuleInput{
+		ProductID: product.ID, SKUID: sku.ID, ConnectionID: conn.ID, UpstreamProductID: 77, UpstreamSKUID: 701,
+		MinLevel:      3,
+		TargetLevel:   10,
+		MaxUnitCost:   models.NewMoneyFromDecimal(decimal.NewFromInt(2)),
+		DailySpendCap: models.NewMoneyFromDecimal(decimal.NewFromInt(20)),
+	})
+	if err != nil {
+		t.Fatalf("create rule failed: %v", err)
+	}
+
+	// 上游单价 2.50 超过单价上限 2
+	result, err := svc.RunRule(rule.ID)
+	if err != nil || result.SkipReason != replenishSkipUnitCost {
+		t.Fatalf("expected unit cost skip, got %+v err=%v", result, err)
+	}
+	rule, err = svc.UpdateRule(rule.ID, CardReplenishRuleInput{
+		ProductID: product.ID, SKUID: sku.ID, ConnectionID: conn.ID, UpstreamProductID: 77, UpstreamSKUID: 701,
+		MinLevel:      3,
+		TargetLevel:   10,
+		MaxUnitCost:   models.NewMoneyFromDecimal(decimal.NewFromInt(3)),
+		DailySpendCap: models.NewMoneyFromDecimal(decimal.NewFromInt(20)),
+	})
+	if err != nil {
+		t.Fatalf("update rule failed: %v", err)
+	}
+
+	// 单价上限按本地币种比较：汇率 2 时上游 2.50 折合 5.00 超过上限 3
+	if err := db.Model(&models.SiteConnection{}).Where("id = ?", conn.ID).Update("exchange_rate", decimal.NewFromInt(2)).Error; err != nil {
+		t.Fatalf("update exchange rate failed: %v", err)
+	}
+	result, err = svc.RunRule(rule.ID)
+	if err != nil || result.SkipReason != replenishSkipUnitCost {
+		t.Fatalf("expected unit cost skip after currency conversion, got %+v err=%v", result, err)
+	}
+	if err := db.Model(&models.SiteConnection{}).Where("id = ?", conn.ID).Update("exchange_rate", decimal.NewFromInt(1)).Error; err != nil {
+		t.Fatalf("restore exchange rate failed: %v", err)
+	}
+
+	// 需补 9 张，日限额 20 / 2.50 仅允许 8 张
+	svc.RunAll()
+	if len(orderedQuantities) != 1 || orderedQuantities[0] != 8 {
+		t.Fatalf("unexpected upstream orders: %v", orderedQuantities)
+	}
+	var procOrder models.ProcurementOrder
+	if err := db.Where("replenish_rule_id = ?", rule.ID).First(&procOrder).Error; err != nil {
+		t.Fatalf("load replenish procurement failed: %v", err)
+	}
+	if procOrder.Status != constants.ProcurementStatusAccepted || procOrder.LocalOrderID != 0 || procOrder.ReplenishQuantity != 8 {
+		t.Fatalf("unexpected procurement order: %+v", procOrder)
+	}
+	if err := procSvc.RetryManual(procOrder.ID); !errors.Is(err, ErrProcurementStatusInvalid) {
+		t.Fatalf("expected retry rejected for replenish order, got %v", err)
+	}
+
+	// 进行中的补货单未到货前不重复下单
+	result, err = svc.RunRule(rule.ID)
+	if err != nil || result.SkipReason != replenishSkipInProgress {
+		t.Fatalf("expected in-progress skip, got %+v err=%v", result, err)
+	}
+	if err := svc.DeleteRule(rule.ID); !errors.Is(err, ErrCardReplenishRuleBusy) {
+		t.Fatalf("expected busy rule, got %v", err)
+	}
+
+	payload := make([]string, 0, 8)
+	for i := 1; i <= 8; i++ {
+		payload = append(payload, fmt.Sprintf("UP-CARD-%d", i))
+	}
+	delivered := &upstream.UpstreamFulfillment{Type: "auto", Status: "delivered", Payload: strings.Join(payload, "\n")}
+	if err := procSvc.HandleUpstreamCallback(procOrder.ID, "delivered", delivered); err != nil {
+		t.Fatalf("handle delivered callback failed: %v", err)
+	}
+	if err := procSvc.HandleUpstreamCallback(procOrder.ID, "delivered", delivered); err != nil {
+		t.Fatalf("repeated delivered callback failed: %v", err)
+	}
+	available, err := secretRepo.CountAvailable(product.ID, sku.ID)
+	if err != nil || available != 9 {
+		t.Fatalf("expected 9 available secrets, got %d err=%v", available, err)
+	}
+	var batch models.CardSecretBatch
+	if err := db.Where("source = ?", constants.CardSecretSourceReplenish).First(&batch).Error; err != nil {
+		t.Fatalf("load replenish batch failed: %v", err)
+	}
+	if batch.TotalCount != 8 || batch.BatchNo != procOrder.LocalOrderNo {
+		t.Fatalf("unexpected batch: %+v", batch)
+	}
+	if err := db.First(&procOrder, procOrder.ID).Error; err != nil {
+		t.Fatalf("reload procurement failed: %v", err)
+	}
+	if procOrder.Status != constants.ProcurementStatusFulfilled || procOrder.ReplenishBatchID == nil || *procOrder.ReplenishBatchID != batch.ID {
+		t.Fatalf("unexpected fulfilled procurement: %+v", procOrder)
+	}
+
+	result, err = svc.RunRule(rule.ID)
+	if err != nil || result.SkipReason != replenishSkipStockSufficient {
+		t.Fatalf("expected stock sufficient, got %+v err=%v", result, err)
+	}
+
+	// 库存再次降到下限以下，但当日已花费 20 达到日限额
+	if err := db.Model(&models.CardSecret{}).Where("batch_id = ?", batch.ID).Update("status", models.CardSecretStatusUsed).Error; err != nil {
+		t.Fatalf("consume secrets failed: %v", err)
+	}
+	result, err = svc.RunRule(rule.ID)
+	if err != nil || result.SkipReason != replenishSkipSpendCap {
+		t.Fatalf("expected spend cap skip, got %+v err=%v", result, err)
+	}
+	if len(orderedQuantities) != 1 {
+		t.Fatalf("expected no additional upstream order, got %v", orderedQuantities)
+	}
+	reloaded, err := repository.NewCardReplenishRuleRepository(db).GetByID(rule.ID)
+	if err != nil || reloaded == nil || reloaded.LastError != replenishSkipSpendCap || reloaded.LastOrderedAt == nil {
+		t.Fatalf("unexpected rule state: %+v err=%v", reloaded, err)
+	}
+}
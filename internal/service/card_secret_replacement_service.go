@@ -0,0 +1,281 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dujiao-next/internal/config"
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/logger"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/queue"
+	"github.com/dujiao-next/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// cardSecretReplaceReasonMaxLength 问题原因最大长度（字符）
+const cardSecretReplaceReasonMaxLength = 500
+
+// CardSecretReplacementService 问题卡密补发服务
+type CardSecretReplacementService struct {
+	orderRepo          repository.OrderRepository
+	secretRepo         repository.CardSecretRepository
+	replacementRepo    repository.CardSecretReplacementRepository
+	queueClient        *queue.Client
+	settingService     *SettingService
+	defaultEmailConfig config.EmailConfig
+}
+
+// NewCardSecretReplacementService 创建问题卡密补发服务
+func NewCardSecretReplacementService(
+	orderRepo repository.OrderRepository,
+	secretRepo repository.CardSecretRepository,
+	replacementRepo repository.CardSecretReplacementRepository,
+	queueClient *queue.Client,
+	settingService *SettingService,
+	defaultEmailConfig config.EmailConfig,
+) *CardSecretReplacementService {
+	return &CardSecretReplacementService{
+		orderRepo:          orderRepo,
+		secretRepo:         secretRepo,
+		replacementRepo:    replacementRepo,
+		queueClient:        queueClient,
+		settingService:     settingService,
+		defaultEmailConfig: defaultEmailConfig,
+	}
+}
+
+// ReplaceDefectiveCardSecretsInput 问题卡密补发输入
+type ReplaceDefectiveCardSecretsInput struct {
+	OrderID       uint
+	OrderItemID   uint
+	CardSecretIDs []uint
+	Reason        string
+	AdminID       uint
+}
+
+// CardSecretReplacementResult 问题卡密补发结果
+type CardSecretReplacementResult struct {
+	Replacements []models.CardSecretReplacement `json:"replacements"`
+	Fulfillment  *models.Fulfillment            `json:"fulfillment"`
+}
+
+// OrderCardSecretDetail 订单已交付卡密及补发历史
+type OrderCardSecretDetail struct {
+	DeliveredSecrets []models.CardSecret            `json:"delivered_secrets"`
+	Replacements     []models.CardSecretReplacement `json:"replacements"`
+}
+
+// ListByOrder 获取订单（含子订单）已交付卡密与补发记录
+func (s *CardSecretReplacementService) ListByOrder(order *models.Order) (*OrderCardSecretDetail, error) {
+	detail := &OrderCardSecretDetail{
+		DeliveredSecrets: []models.CardSecret{},
+		Replacements:     []models.CardSecretReplacement{},
+	}
+	if order == nil {
+		return detail, nil
+	}
+	ids := []uint{order.ID}
+	for _, child := range order.Children {
+		ids = append(ids, child.ID)
+	}
+	for _, id := range ids {
+		rows, err := s.secretRepo.ListByOrderAndStatus(id, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if row.Status == models.CardSecretStatusUsed || row.Status == models.CardSecretStatusDefective {
+				detail.DeliveredSecrets = append(detail.DeliveredSecrets, row)
+			}
+		}
+	}
+	replacements, err := s.replacementRepo.ListByOrderIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	detail.Replacements = replacements
+	return detail, nil
+}
+
+// ReplaceDefective 将订单项已交付的卡密标记为问题卡密，从同 SKU 库存补发并追加到交付内容
+func (s *CardSecretReplacementService) ReplaceDefective(input ReplaceDefectiveCardSecretsInput) (*CardSecretReplacementResult, error) {
+	secretIDs := normalizeCardSecretIDs(input.CardSecretIDs)
+	if input.OrderID == 0 || input.OrderItemID == 0 || input.AdminID == 0 || len(secretIDs) == 0 {
+		return nil, ErrCardSecretReplaceInvalid
+	}
+	reason := strings.TrimSpace(input.Reason)
+	if utf8.RuneCountInString(reason) > cardSecretReplaceReasonMaxLength {
+		reason = string([]rune(reason)[:cardSecretReplaceReasonMaxLength])
+	}
+
+	rootOrder, err := s.orderRepo.GetByID(input.OrderID)
+	if err != nil {
+		return nil, ErrOrderFetchFailed
+	}
+	if rootOrder == nil {
+		return nil, ErrOrderNotFound
+	}
+	order, item := findOrderItemForReplacement(rootOrder, input.OrderItemID)
+	if order == nil || item == nil {
+		return nil, ErrInvalidOrderItem
+	}
+	if strings.TrimSpace(item.FulfillmentType) != constants.FulfillmentTypeAuto {
+		return nil, ErrFulfillmentNotAuto
+	}
+	if order.Status != constants.OrderStatusCompleted && order.Status != constants.OrderStatusDelivered {
+		return nil, ErrOrderStatusInvalid
+	}
+	if order.Fulfillment == nil || order.Fulfillment.Type != constants.FulfillmentTypeAuto {
+		return nil, ErrFulfillmentInvalid
+	}
+
+	now := time.Now()
+	var replacements []models.CardSecretReplacement
+	var fulfillment models.Fulfillment
+	err = s.orderRepo.Transaction(func(tx *gorm.DB) error {
+		secretRepo := s.secretRepo.WithTx(tx)
+		defective, err := secretRepo.ListByIDs(secretIDs)
+		if err != nil {
+			return err
+		}
+		if len(defective) != len(secretIDs) {
+			return ErrCardSecretReplaceInvalid
+		}
+		for _, secret := range defective {
+			if secret.OrderID == nil || *secret.OrderID != order.ID || secret.Status != models.CardSecretStatusUsed {
+				return ErrCardSecretReplaceInvalid
+			}
+			if secret.ProductID != item.ProductID || (item.SKUID > 0 && secret.SKUID != item.SKUID) {
+				return ErrCardSecretReplaceInvalid
+			}
+		}
+
+		var available []models.CardSecret
+		query := tx.Where("product_id = ? AND status = ?", item.ProductID, models.CardSecretStatusAvailable)
+		if item.SKUID > 0 {
+			query = query.Where("sku_id = ?", item.SKUID)
+		}
+		if err := query.Order("id asc").Limit(len(defective)).Find(&available).Error; err != nil {
+			return err
+		}
+		if len(available) < len(defective) {
+			return ErrCardSecretInsufficient
+		}
+
+		affected, err := secretRepo.MarkDefective(secretIDs, order.ID, now)
+		if err != nil {
+			return err
+		}
+		if int(affected) != len(secretIDs) {
+			return ErrCardSecretReplaceInvalid
+		}
+		replacementIDs := make([]uint, 0, len(available))
+		lines := make([]string, 0, len(available))
+		for _, secret := range available {
+			replacementIDs = append(replacementIDs, secret.ID)
+			lines = append(lines, secret.Secret)
+		}
+		affected, err = secretRepo.MarkUsed(replacementIDs, order.ID, now)
+		if err != nil {
+			return err
+		}
+		if int(affected) != len(replacementIDs) {
+			return ErrCardSecretInsufficient
+		}
+
+		adminID := input.AdminID
+		for i, secret := range defective {
+			replacements = append(replacements, models.CardSecretReplacement{
+				OrderID:             order.ID,
+				OrderItemID:         item.ID,
+				ProductID:           item.ProductID,
+				SKUID:               item.SKUID,
+				DefectiveSecretID:   secret.ID,
+				DefectiveBatchID:    secret.BatchID,
+				ReplacementSecretID: available[i].ID,
+				Reason:              reason,
+				CreatedBy:           &adminID,
+				CreatedAt:           now,
+			})
+		}
+		if err := s.replacementRepo.WithTx(tx).CreateBatch(replacements); err != nil {
+			return err
+		}
+
+		if err := tx.Where("order_id = ?", order.ID).First(&fulfillment).Error; err != nil {
+			return err
+		}
+		payload := strings.TrimRight(fulfillment.Payload, "\n")
+		if payload != "" {
+			payload += "\n"
+		}
+		fulfillment.Payload = payload + strings.Join(lines, "\n")
+		fulfillment.UpdatedAt = now
+		return tx.Model(&models.Fulfillment{}).Where("id = ?", fulfillment.ID).Updates(map[string]interface{}{
+			"payload":    fulfillment.Payload,
+			"updated_at": now,
+		}).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrCardSecretReplaceInvalid):
+			return nil, ErrCardSecretReplaceInvalid
+		case errors.Is(err, ErrCardSecretInsufficient):
+			return nil, ErrCardSecretInsufficient
+		default:
+			logger.Warnw("card_secret_replace_failed", "order_id", order.ID, "order_item_id", item.ID, "error", err)
+			return nil, ErrCardSecretReplaceFailed
+		}
+	}
+
+	s.resendDeliveryEmail(order)
+	return &CardSecretReplacementResult{
+		Replacements: replacements,
+		Fulfillment:  &fulfillment,
+	}, nil
+}
+
+// resendDeliveryEmail 补发后重新发送交付邮件（子订单以父订单为准）
+func (s *CardSecretReplacementService) resendDeliveryEmail(order *models.Order) {
+	targetID := order.ID
+	status := order.Status
+	if order.ParentID != nil {
+		parent, err := s.orderRepo.GetByID(*order.ParentID)
+		if err != nil || parent == nil {
+			logger.Warnw("card_secret_replace_fetch_parent_failed", "order_id", order.ID, "parent_order_id", *order.ParentID, "error", err)
+			return
+		}
+		targetID = parent.ID
+		status = parent.Status
+	}
+	if _, err := enqueueOrderStatusEmailTaskIfEligible(s.orderRepo, s.queueClient, s.settingService, s.defaultEmailConfig, targetID, status); err != nil {
+		logger.Warnw("card_secret_replace_enqueue_email_failed",
+			"order_id", order.ID,
+			"target_order_id", targetID,
+			"status", status,
+			"error", err,
+		)
+	}
+}
+
+// findOrderItemForReplacement 在订单及其子订单中查找订单项，返回订单项所属订单
+func findOrderItemForReplacement(order *models.Order, itemID uint) (*models.Order, *models.OrderItem) {
+	for i := range order.Items {
+		if order.Items[i].ID == itemID {
+			return order, &order.Items[i]
+		}
+	}
+	for i := range order.Children {
+		child := &order.Children[i]
+		for j := range child.Items {
+			if child.Items[j].ID == itemID {
+				return child, &child.Items[j]
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/config"
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func TestCardSecretReplaceDefectiveAndDefectReport(t *testing.T) {
+	dsn := fmt.Sprintf("file:card_secret_replacement_test_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.Product{},
+		&models.ProductSKU{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.Fulfillment{},
+		&models.CardSecretBatch{},
+		&models.CardSecret{},
+		&models.CardSecretReplacement{},
+	); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+
+	now := time.Now()
+	product := &models.Product{
+		CategoryID:      1,
+		Slug:            fmt.Sprintf("card-product-%d", now.UnixNano()),
+		TitleJSON:       models.JSON{"zh-CN": "卡密商品"},
+		PriceAmount:     models.NewMoneyFromDecimal(decimal.NewFromInt(10)),
+		FulfillmentType: constants.FulfillmentTypeAuto,
+		IsActive:        true,
+	}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product failed: %v", err)
+	}
+	sku := &models.ProductSKU{ProductID: product.ID, SKUCode: "STD", PriceAmount: product.PriceAmount, IsActive: true}
+	if err := db.Create(sku).Error; err != nil {
+		t.Fatalf("create sku failed: %v", err)
+	}
+	batchA := &models.CardSecretBatch{ProductID: product.ID, SKUID: sku.ID, BatchNo: "BATCH-A", Source: constants.CardSecretSourceManual, TotalCount: 2}
+	batchB := &models.CardSecretBatch{ProductID: product.ID, SKUID: sku.ID, BatchNo: "BATCH-B", Source: constants.CardSecretSourceManual, TotalCount: 2}
+	if err := db.Create(batchA).Error; err != nil {
+		t.Fatalf("create batch failed: %v", err)
+	}
+	if err := db.Create(batchB).Error; err != nil {
+		t.Fatalf("create batch failed: %v", err)
+	}
+	secrets := []models.CardSecret{
+		{ProductID: product.ID, SKUID: sku.ID, BatchID: &batchA.ID, Secret: "A-1", Status: models.CardSecretStatusAvailable},
+		{ProductID: product.ID, SKUID: sku.ID, BatchID: &batchA.ID, Secret: "A-2", Status: models.CardSecretStatusAvailable},
+		{ProductID: product.ID, SKUID: sku.ID, BatchID: &batchB.ID, Secret: "B-1", Status: models.CardSecretStatusAvailable},
+		{ProductID: product.ID, SKUID: sku.ID, BatchID: &batchB.ID, Secret: "B-2", Status: models.CardSecretStatusAvailable},
+	}
+	if err := db.Create(&secrets).Error; err != nil {
+		t.Fatalf("create secrets failed: %v", err)
+	}
+
+	order := &models.Order{
+		OrderNo:     fmt.Sprintf("CS-%d", now.UnixNano()),
+		UserID:      1,
+		Status:      constants.OrderStatusPaid,
+		Currency:    "CNY",
+		TotalAmount: models.NewMoneyFromDecimal(decimal.NewFromInt(20)),
+	}
+	if err := db.Create(order).Error; err != nil {
+		t.Fatalf("create order failed: %v", err)
+	}
+	item := &models.OrderItem{
+		OrderID:         order.ID,
+		ProductID:       product.ID,
+		SKUID:           sku.ID,
+		TitleJSON:       product.TitleJSON,
+		UnitPrice:       product.PriceAmount,
+		Quantity:        2,
+		TotalPrice:      models.NewMoneyFromDecimal(decimal.NewFromInt(20)),
+		FulfillmentType: constants.FulfillmentTypeAuto,
+	}
+	if err := db.Create(item).Error; err != nil {
+		t.Fatalf("create order item failed: %v", err)
+	}
+
+	orderRepo := repository.NewOrderRepository(db)
+	secretRepo := repository.NewCardSecretRepository(db)
+	fulfillmentSvc := NewFulfillmentService(orderRepo, repository.NewFulfillmentRepository(db), secretRepo, nil, nil, config.EmailConfig{}, nil)
+	if _, err := fulfillmentSvc.CreateAuto(order.ID); err != nil {
+		t.Fatalf("create auto fulfillment failed: %v", err)
+	}
+
+	svc := NewCardSecretReplacementService(orderRepo, secretRepo, repository.NewCardSecretReplacementRepository(db), nil, nil, config.EmailConfig{})
+
+	// 未交付给该订单的卡密不可补发
+	if _, err := svc.ReplaceDefective(ReplaceDefectiveCardSecretsInput{
+		OrderID: order.ID, OrderItemID: item.ID, CardSecretIDs: []uint{secrets[2].ID}, AdminID: 1,
+	}); !errors.Is(err, ErrCardSecretReplaceInvalid) {
+		t.Fatalf("expected replace invalid, got %v", err)
+	}
+
+	result, err := svc.ReplaceDefective(ReplaceDefectiveCardSecretsInput{
+		OrderID:       order.ID,
+		OrderItemID:   item.ID,
+		CardSecretIDs: []uint{secrets[1].ID},
+		Reason:        "buyer reported invalid key",
+		AdminID:       1,
+	})
+	if err != nil {
+		t.Fatalf("replace defective failed: %v", err)
+	}
+	if len(result.Replacements) != 1 || result.Replacements[0].ReplacementSecretID != secrets[2].ID {
+		t.Fatalf("unexpected replacements: %+v", result.Replacements)
+	}
+	if result.Replacements[0].DefectiveBatchID == nil || *result.Replacements[0].DefectiveBatchID != batchA.ID {
+		t.Fatalf("expected defective batch recorded, got %+v", result.Replacements[0])
+	}
+	if got := strings.Split(result.Fulfillment.Payload, "\n"); len(got) != 3 || got[2] != "B-1" {
+		t.Fatalf("unexpected fulfillment payload: %q", result.Fulfillment.Payload)
+	}
+
+	// 同一张卡密不能重复补发
+	if _, err := svc.ReplaceDefective(ReplaceDefectiveCardSecretsInput{
+		OrderID: order.ID, OrderItemID: item.ID, CardSecretIDs: []uint{secrets[1].ID}, AdminID: 1,
+	}); !errors.Is(err, ErrCardSecretReplaceInvalid) {
+		t.Fatalf("expected replace invalid for defective secret, got %v", err)
+	}
+
+	reloadedOrder, err := orderRepo.GetByID(order.ID)
+	if err != nil || reloadedOrder == nil {
+		t.Fatalf("reload order failed: %v", err)
+	}
+	detail, err := svc.ListByOrder(reloadedOrder)
+	if err != nil {
+		t.Fatalf("list replacements failed: %v", err)
+	}
+	if len(detail.DeliveredSecrets) != 3 || len(detail.Replacements) != 1 {
+		t.Fatalf("unexpected detail: secrets=%d replacements=%d", len(detail.DeliveredSecrets), len(detail.Replacements))
+	}
+	if detail.Replacements[0].DefectiveSecret == nil || detail.Replacements[0].DefectiveSecret.Status != models.CardSecretStatusDefective {
+		t.Fatalf("expected defective secret preloaded, got %+v", detail.Replacements[0].DefectiveSecret)
+	}
+
+	cardSecretSvc := NewCardSecretService(secretRepo, repository.NewCardSecretBatchRepository(db), repository.NewProductRepository(db), repository.NewProductSKURepository(db))
+	report, err := cardSecretSvc.GetDefectReport(product.ID, 0)
+	if err != nil {
+		t.Fatalf("defect report failed: %v", err)
+	}
+	if len(report) != 1 || report[0].ID != batchA.ID || report[0].DefectiveCount != 1 || report[0].DefectRate != 50 {
+		t.Fatalf("unexpected defect report: %+v", report)
+	}
+	stats, err := cardSecretSvc.GetStats(product.ID, 0)
+	if err != nil {
+		t.Fatalf("stats failed: %v", err)
+	}
+	if stats.Defective != 1 || stats.Used != 2 || stats.Available != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
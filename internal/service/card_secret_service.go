@@ -6,8 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"math/rand"
 	"mime/multipart"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -25,6 +27,8 @@ type CardSecretService struct {
 	batchRepo      repository.CardSecretBatchRepository
 	productRepo    repository.ProductRepository
 	productSKURepo repository.ProductSKURepository
+
+	catalogWebhookSvc *CatalogWebhookService
 }
 
 // NewCardSecretService 创建卡密库存服务
@@ -37,6 +41,27 @@ func NewCardSecretService(secretRepo repository.CardSecretRepository, batchRepo
 	}
 }
 
+// SetCatalogWebhookService 设置商品目录推送服务（库存变更时推送给下游）
+func (s *CardSecretService) SetCatalogWebhookService(svc *CatalogWebhookService) {
+	s.catalogWebhookSvc = svc
+}
+
+// cardSecretProductIDs 查询卡密所属商品，用于推送目录变更
+func (s *CardSecretService) cardSecretProductIDs(ids []uint) []uint {
+	if s.catalogWebhookSvc == nil || len(ids) == 0 {
+		return nil
+	}
+	items, err := s.secretRepo.ListByIDs(ids)
+	if err != nil {
+		return nil
+	}
+	productIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	return productIDs
+}
+
 // CreateCardSecretBatchInput 批量录入卡密输入
 type CreateCardSecretBatchInput struct {
 	ProductID   uint
@@ -131,6 +156,7 @@ func (s *CardSecretService) CreateCardSecretBatch(input CreateCardSecretBatchInp
 		}
 		return nil, 0, ErrCardSecretCreateFailed
 	}
+	s.catalogWebhookSvc.NotifyProductChanged(batch.ProductID)
 	return batch, batch.TotalCount, nil
 }
 
@@ -251,6 +277,7 @@ func (s *CardSecretService) BatchUpdateCardSecretStatus(ids []uint, batchID uint
 	if err != nil {
 		return 0, ErrCardSecretUpdateFailed
 	}
+	s.catalogWebhookSvc.NotifyProductChanged(s.cardSecretProductIDs(normalizedIDs)...)
 	return rows, nil
 }
 
@@ -260,10 +287,12 @@ func (s *CardSecretService) BatchDeleteCardSecrets(ids []uint, batchID uint, fil
 	if err != nil {
 		return 0, err
 	}
+	productIDs := s.cardSecretProductIDs(normalizedIDs)
 	rows, err := s.secretRepo.BatchDeleteByIDs(normalizedIDs)
 	if err != nil {
 		return 0, ErrCardSecretDeleteFailed
 	}
+	s.catalogWebhookSvc.NotifyProductChanged(productIDs...)
 	return rows, nil
 }
 
@@ -393,6 +422,7 @@ func (s *CardSecretService) UpdateCardSecret(id uint, secret, status string) (*m
 	if err := s.secretRepo.Update(item); err != nil {
 		return nil, ErrCardSecretUpdateFailed
 	}
+	s.catalogWebhookSvc.NotifyProductChanged(item.ProductID)
 	return item, nil
 }
 
@@ -402,6 +432,7 @@ type CardSecretStats struct {
 	Available int64 `json:"available"`
 	Reserved  int64 `json:"reserved"`
 	Used      int64 `json:"used"`
+	Defective int64 `json:"defective"`
 }
 
 // CardSecretBatchSummary 卡密批次列表摘要
@@ -417,6 +448,8 @@ type CardSecretBatchSummary struct {
 	AvailableCount int64     `json:"available_count"`
 	ReservedCount  int64     `json:"reserved_count"`
 	UsedCount      int64     `json:"used_count"`
+	DefectiveCount int64     `json:"defective_count"`
+	DefectRate     float64   `json:"defect_rate"`
 	CreatedAt      time.Time `json:"created_at"`
 }
 
@@ -443,6 +476,7 @@ func (s *CardSecretService) GetStats(productID, skuID uint) (*CardSecretStats, e
 		Available: available,
 		Reserved:  reserved,
 		Used:      used,
+		Defective: total - available - reserved - used,
 	}, nil
 }
 
@@ -467,19 +501,65 @@ func (s *CardSecretService) ListBatches(productID, skuID uint, page, pageSize in
 		return []CardSecretBatchSummary{}, total, nil
 	}
 
+	result, err := s.buildBatchSummaries(items)
+	if err != nil {
+		return nil, 0, err
+	}
+	return result, total, nil
+}
+
+// GetDefectReport 获取存在问题卡密的批次统计（用于与供应商对账）
+func (s *CardSecretService) GetDefectReport(productID, skuID uint) ([]CardSecretBatchSummary, error) {
+	if productID == 0 && skuID > 0 {
+		return nil, ErrCardSecretInvalid
+	}
+	if skuID > 0 {
+		if _, err := s.resolveCardSecretSKU(productID, skuID); err != nil {
+			return nil, err
+		}
+	}
+	if s.batchRepo == nil {
+		return nil, ErrCardSecretBatchFetchFailed
+	}
+	batchIDs, err := s.secretRepo.ListDefectiveBatchIDs(productID, skuID)
+	if err != nil {
+		return nil, ErrCardSecretBatchFetchFailed
+	}
+	if len(batchIDs) == 0 {
+		return []CardSecretBatchSummary{}, nil
+	}
+	items, err := s.batchRepo.ListByIDs(batchIDs)
+	if err != nil {
+		return nil, ErrCardSecretBatchFetchFailed
+	}
+	result, err := s.buildBatchSummaries(items)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].DefectRate > result[j].DefectRate
+	})
+	return result, nil
+}
+
+func (s *CardSecretService) buildBatchSummaries(items []models.CardSecretBatch) ([]CardSecretBatchSummary, error) {
+	if len(items) == 0 {
+		return []CardSecretBatchSummary{}, nil
+	}
 	batchIDs := make([]uint, 0, len(items))
 	for _, item := range items {
 		batchIDs = append(batchIDs, item.ID)
 	}
 	countRows, err := s.secretRepo.CountByBatchIDs(batchIDs)
 	if err != nil {
-		return nil, 0, ErrCardSecretBatchFetchFailed
+		return nil, ErrCardSecretBatchFetchFailed
 	}
 
 	type batchCounter struct {
 		available int64
 		reserved  int64
 		used      int64
+		defective int64
 	}
 	counterMap := make(map[uint]batchCounter, len(batchIDs))
 	for _, row := range countRows {
@@ -491,6 +571,8 @@ func (s *CardSecretService) ListBatches(productID, skuID uint, page, pageSize in
 			counter.reserved = row.Total
 		case models.CardSecretStatusUsed:
 			counter.used = row.Total
+		case models.CardSecretStatusDefective:
+			counter.defective = row.Total
 		}
 		counterMap[row.BatchID] = counter
 	}
@@ -506,14 +588,25 @@ func (s *CardSecretService) ListBatches(productID, skuID uint, page, pageSize in
 			BatchNo:        item.BatchNo,
 			Source:         item.Source,
 			Note:           item.Note,
-			TotalCount:     counter.available + counter.reserved + counter.used,
+			TotalCount:     counter.available + counter.reserved + counter.used + counter.defective,
 			AvailableCount: counter.available,
 			ReservedCount:  counter.reserved,
 			UsedCount:      counter.used,
+			DefectiveCount: counter.defective,
+			DefectRate:     calcCardSecretDefectRate(counter.used, counter.defective),
 			CreatedAt:      item.CreatedAt,
 		})
 	}
-	return result, total, nil
+	return result, nil
+}
+
+// calcCardSecretDefectRate 计算问题率（百分比，问题数 / 已交付数）
+func calcCardSecretDefectRate(used, defective int64) float64 {
+	delivered := used + defective
+	if delivered <= 0 || defective <= 0 {
+		return 0
+	}
+	return math.Round(float64(defective)*10000/float64(delivered)) / 100
 }
 
 func (s *CardSecretService) resolveCardSecretSKU(productID, rawSKUID uint) (*models.ProductSKU, error) {
@@ -154,7 +154,11 @@ func (s *CartService) UpsertItem(input UpsertCartItemInput) error {
 	if fulfillmentType == "" {
 		fulfillmentType = constants.FulfillmentTypeManual
 	}
-	if fulfillmentType != constants.FulfillmentTypeManual && fulfillmentType != constants.FulfillmentTypeAuto {
+	if fulfillmentType != constants.FulfillmentTypeManual &&
+		fulfillmentType != constants.FulfillmentTypeAuto &&
+		fulfillmentType != constants.FulfillmentTypeWebhook &&
+		fulfillmentType != constants.FulfillmentTypeLicense &&
+		fulfillmentType != constants.FulfillmentTypeFile {
 		return ErrFulfillmentInvalid
 	}
 	if fulfillmentType == constants.FulfillmentTypeManual &&
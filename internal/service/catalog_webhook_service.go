@@ -0,0 +1,468 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/cache"
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/logger"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/queue"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/dujiao-next/internal/upstream"
+
+	"github.com/hibiken/asynq"
+)
+
+var (
+	ErrCatalogWebhookInvalid  = errors.New("catalog webhook is invalid")
+	ErrCatalogWebhookNotFound = errors.New("catalog webhook not found")
+)
+
+// catalogWebhookDebounce 同一商品的变更在该时间窗口内合并为一次推送
+const catalogWebhookDebounce = 3 * time.Second
+
+// CatalogWebhookService B 侧商品目录变更推送服务（库存、价格、上下架）
+type CatalogWebhookService struct {
+	webhookRepo        repository.CatalogWebhookRepository
+	credentialRepo     repository.ApiCredentialRepository
+	userRepo           repository.UserRepository
+	productMappingRepo repository.ProductMappingRepository
+	skuMappingRepo     repository.SKUMappingRepository
+	productService     *ProductService
+	memberLevelService *MemberLevelService
+	priceListService   *ResellerPriceListService
+	settingService     *SettingService
+	queueClient        *queue.Client
+	httpClient         *http.Client
+}
+
+// CatalogWebhookServiceOptions 商品目录推送服务构造参数
+type CatalogWebhookServiceOptions struct {
+	WebhookRepo        repository.CatalogWebhookRepository
+	CredentialRepo     repository.ApiCredentialRepository
+	UserRepo           repository.UserRepository
+	ProductMappingRepo repository.ProductMappingRepository
+	SKUMappingRepo     repository.SKUMappingRepository
+	ProductService     *ProductService
+	MemberLevelService *MemberLevelService
+	PriceListService   *ResellerPriceListService
+	SettingService     *SettingService
+	QueueClient        *queue.Client
+}
+
+// NewCatalogWebhookService 创建商品目录推送服务
+func NewCatalogWebhookService(opts CatalogWebhookServiceOptions) *CatalogWebhookService {
+	return &CatalogWebhookService{
+		webhookRepo:        opts.WebhookRepo,
+		credentialRepo:     opts.CredentialRepo,
+		userRepo:           opts.UserRepo,
+		productMappingRepo: opts.ProductMappingRepo,
+		skuMappingRepo:     opts.SKUMappingRepo,
+		productService:     opts.ProductService,
+		memberLevelService: opts.MemberLevelService,
+		priceListService:   opts.PriceListService,
+		settingService:     opts.SettingService,
+		queueClient:        opts.QueueClient,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+// Register 注册（或替换）API 凭证的 Webhook 地址
+func (s *CatalogWebhookService) Register(credentialID, userID uint, url string) (*models.CatalogWebhook, error) {
+	url = strings.TrimSpace(url)
+	if credentialID == 0 || url == "" {
+		return nil, ErrCatalogWebhookInvalid
+	}
+	webhook, err := s.webhookRepo.GetByCredentialID(credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if webhook == nil {
+		webhook = &models.CatalogWebhook{ApiCredentialID: credentialID}
+	}
+	webhook.UserID = userID
+	webhook.URL = url
+	webhook.IsActive = true
+	webhook.FailCount = 0
+	webhook.LastError = ""
+	if err := s.webhookRepo.Save(webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// Get 获取 API 凭证的 Webhook
+func (s *CatalogWebhookService) Get(credentialID uint) (*models.CatalogWebhook, error) {
+	webhook, err := s.webhookRepo.GetByCredentialID(credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if webhook == nil {
+		return nil, ErrCatalogWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// Unregister 注销 API 凭证的 Webhook
+func (s *CatalogWebhookService) Unregister(credentialID uint) error {
+	webhook, err := s.webhookRepo.GetByCredentialID(credentialID)
+	if err != nil {
+		return err
+	}
+	if webhook == nil {
+		return ErrCatalogWebhookNotFound
+	}
+	return s.webhookRepo.DeleteByCredentialID(credentialID)
+}
+
+// NotifyProductChanged 商品库存、价格或上下架状态可能变化时调用，按商品去抖后异步推送。
+// 接收者为 nil 或队列未启用时忽略，下游仍可通过定时同步获取变更。
+func (s *CatalogWebhookService) NotifyProductChanged(productIDs ...uint) {
+	if s == nil || !s.queueClient.Enabled() {
+		return
+	}
+	seen := make(map[uint]struct{}, len(productIDs))
+	for _, productID := range productIDs {
+		if productID == 0 {
+			continue
+		}
+		if _, ok := seen[productID]; ok {
+			continue
+		}
+		seen[productID] = struct{}{}
+		err := s.queueClient.EnqueueCatalogWebhookDispatch(queue.CatalogWebhookDispatchPayload{
+			ProductID: productID,
+		}, asynq.ProcessIn(catalogWebhookDebounce), asynq.Unique(catalogWebhookDebounce*2))
+		if err != nil && !errors.Is(err, asynq.ErrDuplicateTask) {
+			logger.Warnw("catalog_webhook_enqueue_failed", "product_id", productID, "error", err)
+		}
+	}
+}
+
+// Dispatch 构建商品当前快照，逐个推送给内容与上次成功推送不同的启用 Webhook
+func (s *CatalogWebhookService) Dispatch(productID uint) error {
+	webhooks, err := s.webhookRepo.ListActive()
+	if err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+	snapshot, product, err := s.buildSnapshot(productID)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	memberLevels := make(map[uint]uint)
+	pricings := make(map[uint]*ResellerPricing)
+	for i := range webhooks {
+		webhook := &webhooks[i]
+		levelID, ok := memberLevels[webhook.UserID]
+		if !ok {
+			if user, userErr := s.userRepo.GetByID(webhook.UserID); userErr == nil && user != nil {
+				levelID = user.MemberLevelID
+			}
+			memberLevels[webhook.UserID] = levelID
+		}
+		pricing := s.loadResellerPricing(webhook, pricings)
+		priced := s.applyBuyerPrices(snapshot, product, levelID, pricing)
+		// 摘要按 Webhook 记录且仅在推送成功后写入，推送失败的接收者下次变更时仍会收到
+		digest := catalogSnapshotDigest(priced)
+		digestKey := fmt.Sprintf("catalog_webhook:%d:product:%d", webhook.ID, productID)
+		if previous, _ := cache.GetString(ctx, digestKey); previous != "" && previous == digest {
+			continue
+		}
+		if s.deliver(webhook, priced) {
+			_ = cache.SetString(ctx, digestKey, digest, 24*time.Hour)
+		}
+	}
+	return nil
+}
+
+// buildSnapshot 按上游 API 的商品格式构建快照，包含已停用的 SKU 以便下游同步停用；
+// 商品已删除时返回仅含下架标记的快照
+func (s *CatalogWebhookService) buildSnapshot(productID uint) (*upstream.UpstreamProduct, *models.Product, error) {
+	product, err := s.productService.GetAdminByID(strconv.FormatUint(uint64(productID), 10))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return &upstream.UpstreamProduct{ID: productID, SKUs: []upstream.UpstreamSKU{}}, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	products := []models.Product{*product}
+	if err := s.productService.ApplyAutoStockCounts(products); err != nil {
+		logger.Warnw("catalog_webhook_apply_stock_counts_failed", "product_id", productID, "error", err)
+	}
+	p := products[0]
+
+	fulfillmentType := p.FulfillmentType
+	if p.FulfillmentType == constants.FulfillmentTypeUpstream {
+		for i := range p.SKUs {
+			if mapping, _ := s.skuMappingRepo.GetByLocalSKUID(p.SKUs[i].ID); mapping != nil {
+				p.SKUs[i].UpstreamStock = mapping.UpstreamStock
+			}
+		}
+		if p.IsMapped {
+			if mapping, _ := s.productMappingRepo.GetByLocalProductID(p.ID); mapping != nil {
+				fulfillmentType = constants.FulfillmentTypeManual
+				if mapping.UpstreamFulfillmentType == constants.FulfillmentTypeAuto {
+					fulfillmentType = constants.FulfillmentTypeAuto
+				}
+			}
+		}
+	}
+	currency, _ := s.settingService.GetSiteCurrency("CNY")
+
+	createdAt := p.CreatedAt
+	snapshot := &upstream.UpstreamProduct{
+		ID:               p.ID,
+		Slug:             p.Slug,
+		SeoMeta:          p.SeoMetaJSON,
+		Title:            p.TitleJSON,
+		Description:      p.DescriptionJSON,
+		Content:          p.ContentJSON,
+		Images:           p.Images,
+		Tags:             p.Tags,
+		PriceAmount:      p.PriceAmount.StringFixed(2),
+		Currency:         currency,
+		FulfillmentType:  UpstreamVisibleFulfillmentType(fulfillmentType),
+		ManualFormSchema: p.ManualFormSchemaJSON,
+		IsActive:         p.IsActive,
+		CategoryID:       p.CategoryID,
+		SKUs:             make([]upstream.UpstreamSKU, 0, len(p.SKUs)),
+		CreatedAt:        &createdAt,
+		UpdatedAt:        p.UpdatedAt,
+	}
+	for _, sku := range p.SKUs {
+		stockStatus, stockQuantity := ComputeUpstreamSKUStock(p, sku)
+		snapshot.SKUs = append(snapshot.SKUs, upstream.UpstreamSKU{
+			ID:            sku.ID,
+			SKUCode:       sku.SKUCode,
+			SpecValues:    sku.SpecValuesJSON,
+			PriceAmount:   sku.PriceAmount.StringFixed(2),
+			StockStatus:   stockStatus,
+			StockQuantity: stockQuantity,
+			IsActive:      sku.IsActive,
+		})
+	}
+	return snapshot, &p, nil
+}
+
+// loadResellerPricing 解析 Webhook 对应凭证（或用户）分配的分销价目表，按价目表缓存
+func (s *CatalogWebhookService) loadResellerPricing(webhook *models.CatalogWebhook, cached map[uint]*ResellerPricing) *ResellerPricing {
+	if s.priceListService == nil {
+		return nil
+	}
+	listID := s.priceListService.ResolveListID(webhook.ApiCredentialID, webhook.UserID)
+	if listID == 0 {
+		return nil
+	}
+	if pricing, ok := cached[listID]; ok {
+		return pricing
+	}
+	pricing, err := s.priceListService.LoadPricing(listID)
+	if err != nil {
+		logger.Warnw("catalog_webhook_load_reseller_pricing_failed", "price_list_id", listID, "error", err)
+		pricing = nil
+	}
+	cached[listID] = pricing
+	return pricing
+}
+
+// applyBuyerPrices 按下游的分销价目表或会员等级替换售价（分销价优先，与上游 API 查询一致），返回副本
+func (s *CatalogWebhookService) applyBuyerPrices(snapshot *upstream.UpstreamProduct, product *models.Product, levelID uint, pricing *ResellerPricing) *upstream.UpstreamProduct {
+	if product == nil || (pricing == nil && (levelID == 0 || s.memberLevelService == nil)) {
+		return snapshot
+	}
+	priced := *snapshot
+	if rp, ok := pricing.Resolve(product.ID, 0, product.PriceAmount.Decimal); ok {
+		priced.OriginalPrice = priced.PriceAmount
+		priced.PriceAmount = models.NewMoneyFromDecimal(rp).StringFixed(2)
+	} else if levelID > 0 && s.memberLevelService != nil {
+		if mp, _ := s.memberLevelService.ResolveMemberPrice(levelID, product.ID, 0, product.PriceAmount.Decimal); mp.LessThan(product.PriceAmount.Decimal) {
+			priced.OriginalPrice = priced.PriceAmount
+			priced.MemberPrice = models.NewMoneyFromDecimal(mp).StringFixed(2)
+			priced.PriceAmount = priced.MemberPrice
+		}
+	}
+	priced.SKUs = make([]upstream.UpstreamSKU, len(snapshot.SKUs))
+	copy(priced.SKUs, snapshot.SKUs)
+	for i := range priced.SKUs {
+		if i >= len(product.SKUs) {
+			break
+		}
+		base := product.SKUs[i].PriceAmount.Decimal
+		if rp, ok := pricing.Resolve(product.ID, product.SKUs[i].ID, base); ok {
+			priced.SKUs[i].OriginalPrice = priced.SKUs[i].PriceAmount
+			priced.SKUs[i].PriceAmount = models.NewMoneyFromDecimal(rp).StringFixed(2)
+			continue
+		}
+		if levelID == 0 || s.memberLevelService == nil {
+			continue
+		}
+		if mp, _ := s.memberLevelService.ResolveMemberPrice(levelID, product.ID, product.SKUs[i].ID, base); mp.LessThan(base) {
+			priced.SKUs[i].OriginalPrice = priced.SKUs[i].PriceAmount
+			priced.SKUs[i].MemberPrice = models.NewMoneyFromDecimal(mp).StringFixed(2)
+			priced.SKUs[i].PriceAmount = priced.SKUs[i].MemberPrice
+		}
+	}
+	return &priced
+}
+
+// deliver 签名并推送到单个 Webhook，记录推送结果，返回是否推送成功
+func (s *CatalogWebhookService) deliver(webhook *models.CatalogWebhook, snapshot *upstream.UpstreamProduct) bool {
+	credential, err := s.credentialRepo.GetByID(webhook.ApiCredentialID)
+	if err != nil {
+		logger.Warnw("catalog_webhook_credential_lookup_failed", "webhook_id", webhook.ID, "error", err)
+		return false
+	}
+	if credential == nil || credential.Status != constants.ApiCredentialStatusApproved || !credential.IsActive {
+		// 凭证失效后停止推送，重新注册时恢复
+		_ = s.webhookRepo.UpdateDelivery(webhook.ID, map[string]interface{}{
+			"is_active":  false,
+			"last_error": "api credential is not active",
+		})
+		return false
+	}
+
+	now := time.Now()
+	bodyBytes, err := json.Marshal(upstream.CatalogCallbackEvent{
+		Event:     constants.CatalogEventProductChanged,
+		Product:   snapshot,
+		Timestamp: now.Unix(),
+	})
+	if err != nil {
+		return false
+	}
+	signature := upstream.Sign(credential.ApiSecret, "POST", upstream.PathCallback, now.Unix(), bodyBytes)
+
+	deliverErr := func() error {
+		httpReq, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(bodyBytes))
+		if err != nil {
+			return err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set(upstream.HeaderApiKey, credential.ApiKey)
+		httpReq.Header.Set(upstream.HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+		httpReq.Header.Set(upstream.HeaderSignature, signature)
+		resp, err := s.httpClient.Do(httpReq)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var result struct {
+			OK bool `json:"ok"`
+		}
+		if resp.StatusCode != http.StatusOK || json.Unmarshal(respBody, &result) != nil || !result.OK {
+			return fmt.Errorf("webhook returned %d: %s", resp.StatusCode, string(respBody))
+		}
+		return nil
+	}()
+
+	if deliverErr != nil {
+		logger.Warnw("catalog_webhook_deliver_failed",
+			"webhook_id", webhook.ID,
+			"product_id", snapshot.ID,
+			"error", deliverErr,
+		)
+		_ = s.webhookRepo.UpdateDelivery(webhook.ID, map[string]interface{}{
+			"fail_count": webhook.FailCount + 1,
+			"last_error": truncateSKUSourceError(deliverErr.Error()),
+		})
+		return false
+	}
+	_ = s.webhookRepo.UpdateDelivery(webhook.ID, map[string]interface{}{
+		"last_delivered_at": &now,
+		"fail_count":        0,
+		"last_error":        "",
+	})
+	return true
+}
+
+// catalogSnapshotDigest 计算快照摘要，用于跳过内容未变化的推送
+func catalogSnapshotDigest(snapshot *upstream.UpstreamProduct) string {
+	body, _ := json.Marshal(snapshot)
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// UpstreamVisibleFulfillmentType 将本地交付类型转换为对下游展示的交付类型
+func UpstreamVisibleFulfillmentType(fulfillmentType string) string {
+	switch fulfillmentType {
+	case constants.FulfillmentTypeWebhook:
+		// Webhook 交付对下游按人工交付展示
+		return constants.FulfillmentTypeManual
+	case constants.FulfillmentTypeLicense, constants.FulfillmentTypeFile:
+		// 授权码 / 文件交付对下游按自动交付展示
+		return constants.FulfillmentTypeAuto
+	default:
+		return fulfillmentType
+	}
+}
+
+// ComputeUpstreamSKUStock 计算对下游展示的 SKU 库存状态和实际可用量
+// 上游对接商品需先将 SKU 映射中的上游库存写入虚拟字段 UpstreamStock
+func ComputeUpstreamSKUStock(p models.Product, s models.ProductSKU) (status string, quantity int) {
+	if p.FulfillmentType == constants.FulfillmentTypeWebhook || p.FulfillmentType == constants.FulfillmentTypeLicense ||
+		p.FulfillmentType == constants.FulfillmentTypeFile {
+		// Webhook / 授权码 / 文件交付：发货时生成内容，不限库存
+		return constants.ProductStockStatusUnlimited, -1
+	}
+	if p.FulfillmentType == constants.FulfillmentTypeManual {
+		// 手动交付：根据 SKU 级别手动库存判断
+		skuTotal := s.ManualStockTotal
+		if skuTotal == constants.ManualStockUnlimited {
+			return constants.ProductStockStatusUnlimited, -1
+		}
+		available := skuTotal - s.ManualStockLocked
+		if available <= 0 {
+			return constants.ProductStockStatusOutOfStock, 0
+		}
+		if available <= 20 {
+			return constants.ProductStockStatusLowStock, available
+		}
+		return constants.ProductStockStatusInStock, available
+	}
+
+	if p.FulfillmentType == constants.FulfillmentTypeUpstream {
+		// 上游对接商品：使用 SKU 映射中的上游库存（通过虚拟字段 UpstreamStock 传入）
+		available := s.UpstreamStock
+		if available < 0 {
+			return constants.ProductStockStatusUnlimited, -1
+		}
+		if available == 0 {
+			return constants.ProductStockStatusOutOfStock, 0
+		}
+		if available <= 20 {
+			return constants.ProductStockStatusLowStock, available
+		}
+		return constants.ProductStockStatusInStock, available
+	}
+
+	// 自动发货：根据卡密库存判断
+	available := int(s.AutoStockAvailable)
+	if available <= 0 {
+		return constants.ProductStockStatusOutOfStock, 0
+	}
+	if available <= 20 {
+		return constants.ProductStockStatusLowStock, available
+	}
+	return constants.ProductStockStatusInStock, available
+}
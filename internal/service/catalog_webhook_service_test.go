@@ -0,0 +1,169 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/dujiao-next/internal/upstream"
+	"github.com/shopspring/decimal"
+)
+
+func TestCatalogWebhookDispatchSignsSnapshot(t *testing.T) {
+	productSvc, db := newProductServiceForTest(t)
+	if err := db.AutoMigrate(&models.User{}, &models.ApiCredential{}, &models.CatalogWebhook{}, &models.ResellerPriceList{}, &models.ResellerPriceListItem{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+
+	user := &models.User{Email: "reseller@example.com", PasswordHash: "x", Status: constants.UserStatusActive}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user failed: %v", err)
+	}
+	priceList := &models.ResellerPriceList{Name: "reseller", DefaultDiscountPercent: decimal.NewFromInt(10), IsActive: true}
+	if err := db.Create(priceList).Error; err != nil {
+		t.Fatalf("create price list failed: %v", err)
+	}
+	credential := &models.ApiCredential{UserID: user.ID, ApiKey: "key", ApiSecret: "secret", Status: constants.ApiCredentialStatusApproved, IsActive: true, PriceListID: &priceList.ID}
+	if err := db.Create(credential).Error; err != nil {
+		t.Fatalf("create credential failed: %v", err)
+	}
+	price := models.NewMoneyFromDecimal(decimal.NewFromInt(10))
+	product := &models.Product{CategoryID: 1, Slug: "catalog-push", TitleJSON: models.JSON{"zh-CN": "推送"}, PriceAmount: price, FulfillmentType: constants.FulfillmentTypeManual, IsActive: true}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product failed: %v", err)
+	}
+	sku := &models.ProductSKU{ProductID: product.ID, SKUCode: "DEFAULT", PriceAmount: price, ManualStockTotal: 5, ManualStockLocked: 2, IsActive: true}
+	if err := db.Create(sku).Error; err != nil {
+		t.Fatalf("create sku failed: %v", err)
+	}
+
+	var received upstream.CatalogCallbackEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(upstream.HeaderTimestamp), 10, 64)
+		if r.Header.Get(upstream.HeaderApiKey) != "key" ||
+			!upstream.Verify("secret", "POST", "/api/v1/upstream/callback", r.Header.Get(upstream.HeaderSignature), ts, body) {
+			_, _ = w.Write([]byte(`{"ok":false}`))
+			return
+		}
+		_ = json.Unmarshal(body, &received)
+		_, _ = w.Write([]byte(`{"ok":true,"message":"received"}`))
+	}))
+	defer server.Close()
+
+	webhookRepo := repository.NewCatalogWebhookRepository(db)
+	credentialRepo := repository.NewApiCredentialRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	svc := NewCatalogWebhookService(CatalogWebhookServiceOptions{
+		WebhookRepo:        webhookRepo,
+		CredentialRepo:     credentialRepo,
+		UserRepo:           userRepo,
+		ProductMappingRepo: repository.NewProductMappingRepository(db),
+		SKUMappingRepo:     repository.NewSKUMappingRepository(db),
+		ProductService:     productSvc,
+		PriceListService:   NewResellerPriceListService(repository.NewResellerPriceListRepository(db), credentialRepo, userRepo),
+	})
+	if _, err := svc.Register(credential.ID, user.ID, server.URL); err != nil {
+		t.Fatalf("register webhook failed: %v", err)
+	}
+
+	if err := svc.Dispatch(product.ID); err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if received.Event != constants.CatalogEventProductChanged || received.Product == nil || received.Product.ID != product.ID {
+		t.Fatalf("unexpected catalog event: %+v", received)
+	}
+	if len(received.Product.SKUs) != 1 || received.Product.SKUs[0].StockQuantity != 3 || !received.Product.SKUs[0].IsActive {
+		t.Fatalf("unexpected sku snapshot: %+v", received.Product.SKUs)
+	}
+	// 凭证分配了价目表时推送分销价，与上游 API 查询一致
+	if received.Product.SKUs[0].PriceAmount != "9.00" || received.Product.SKUs[0].OriginalPrice != "10.00" || received.Product.PriceAmount != "9.00" {
+		t.Fatalf("expected reseller price in snapshot, got product=%s sku=%+v", received.Product.PriceAmount, received.Product.SKUs[0])
+	}
+	webhook, err := svc.Get(credential.ID)
+	if err != nil || webhook.LastDeliveredAt == nil || webhook.FailCount != 0 {
+		t.Fatalf("expected delivery recorded, got %+v err=%v", webhook, err)
+	}
+
+	// 凭证停用后停止推送
+	if err := db.Model(credential).Update("is_active", false).Error; err != nil {
+		t.Fatalf("disable credential failed: %v", err)
+	}
+	if err := svc.Dispatch(product.ID); err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if webhook, _ = svc.Get(credential.ID); webhook.IsActive {
+		t.Fatalf("expected webhook deactivated after credential disabled")
+	}
+}
+
+func TestApplyCatalogEventSyncsMappedProduct(t *testing.T) {
+	_, db := newProductServiceForTest(t)
+	if err := db.AutoMigrate(&models.SiteConnection{}, &models.MarginGuardEvent{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+
+	connSvc := NewSiteConnectionService(repository.NewSiteConnectionRepository(db), "test-key", t.TempDir())
+	conn, err := connSvc.Create(CreateConnectionInput{Name: "push", BaseURL: "http://upstream.test", ApiKey: "key", ApiSecret: "secret"})
+	if err != nil {
+		t.Fatalf("create connection failed: %v", err)
+	}
+	price := models.NewMoneyFromDecimal(decimal.NewFromInt(10))
+	product := &models.Product{CategoryID: 1, Slug: "catalog-apply", TitleJSON: models.JSON{"zh-CN": "应用"}, PriceAmount: price, FulfillmentType: constants.FulfillmentTypeUpstream, IsActive: true}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product failed: %v", err)
+	}
+	sku := &models.ProductSKU{ProductID: product.ID, SKUCode: "DEFAULT", PriceAmount: price, IsActive: true}
+	if err := db.Create(sku).Error; err != nil {
+		t.Fatalf("create sku failed: %v", err)
+	}
+	mapping := &models.ProductMapping{ConnectionID: conn.ID, LocalProductID: product.ID, UpstreamProductID: 20, IsActive: true}
+	if err := db.Create(mapping).Error; err != nil {
+		t.Fatalf("create mapping failed: %v", err)
+	}
+	if err := db.Create(&models.SKUMapping{ProductMappingID: mapping.ID, LocalSKUID: sku.ID, UpstreamSKUID: 200, UpstreamIsActive: true, UpstreamStock: 10}).Error; err != nil {
+		t.Fatalf("create sku mapping failed: %v", err)
+	}
+
+	svc := NewProductMappingService(
+		repository.NewProductMappingRepository(db),
+		repository.NewSKUMappingRepository(db),
+		repository.NewProductRepository(db),
+		repository.NewProductSKURepository(db),
+		repository.NewCategoryRepository(db),
+		connSvc,
+	)
+
+	// 未映射的上游商品忽略
+	if err := svc.ApplyCatalogEvent(conn, &upstream.UpstreamProduct{ID: 99, IsActive: true}); err != nil {
+		t.Fatalf("apply unmapped event failed: %v", err)
+	}
+	if err := svc.ApplyCatalogEvent(conn, &upstream.UpstreamProduct{
+		ID: 20, IsActive: true, UpdatedAt: time.Now(),
+		SKUs: []upstream.UpstreamSKU{{ID: 200, PriceAmount: "8.00", StockQuantity: 0, IsActive: false}},
+	}); err != nil {
+		t.Fatalf("apply catalog event failed: %v", err)
+	}
+
+	var skuMapping models.SKUMapping
+	if err := db.Where("local_sku_id = ?", sku.ID).First(&skuMapping).Error; err != nil {
+		t.Fatalf("reload sku mapping failed: %v", err)
+	}
+	if skuMapping.UpstreamIsActive || skuMapping.UpstreamStock != 0 {
+		t.Fatalf("expected sku mapping synced from event, got active=%v stock=%d", skuMapping.UpstreamIsActive, skuMapping.UpstreamStock)
+	}
+	var localSKU models.ProductSKU
+	if err := db.First(&localSKU, sku.ID).Error; err != nil {
+		t.Fatalf("reload sku failed: %v", err)
+	}
+	if localSKU.IsActive {
+		t.Fatalf("expected local sku deactivated by catalog event")
+	}
+}
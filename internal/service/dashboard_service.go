@@ -199,7 +199,7 @@ func (s *DashboardService) GetOverview(ctx context.Context, input DashboardQuery
 	if err != nil {
 		return nil, err
 	}
-	totalUserBalance, err := s.repo.GetTotalUserBalance()
+	totalUserBalance, err := s.repo.GetTotalUserBalance(resolveServiceSiteCurrency(s.settingService))
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +49,7 @@ func (s dashboardServiceRepoStub) GetTopChannels(startAt, endAt time.Time, limit
 	return []repository.DashboardChannelRankingRow{}, nil
 }
 
-func (s dashboardServiceRepoStub) GetTotalUserBalance() (float64, error) {
+func (s dashboardServiceRepoStub) GetTotalUserBalance(currency string) (float64, error) {
 	return 0, nil
 }
 
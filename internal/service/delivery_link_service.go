@@ -0,0 +1,332 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/logger"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+)
+
+// deliveryLinkPathPrefix 安全交付链接前台路径
+const deliveryLinkPathPrefix = "/delivery/"
+
+// DeliveryLinkService 安全交付链接服务
+type DeliveryLinkService struct {
+	linkRepo        repository.DeliveryLinkRepository
+	fulfillmentRepo repository.FulfillmentRepository
+	orderRepo       repository.OrderRepository
+	settingService  *SettingService
+	secret          []byte
+}
+
+// NewDeliveryLinkService 创建安全交付链接服务
+func NewDeliveryLinkService(
+	linkRepo repository.DeliveryLinkRepository,
+	fulfillmentRepo repository.FulfillmentRepository,
+	orderRepo repository.OrderRepository,
+	settingService *SettingService,
+	secretKey string,
+) *DeliveryLinkService {
+	return &DeliveryLinkService{
+		linkRepo:        linkRepo,
+		fulfillmentRepo: fulfillmentRepo,
+		orderRepo:       orderRepo,
+		settingService:  settingService,
+		secret:          []byte(secretKey),
+	}
+}
+
+// DeliveryLinkAccess 链接访问来源信息
+type DeliveryLinkAccess struct {
+	ClientIP  string
+	UserAgent string
+}
+
+// DeliveryLinkInfo 链接状态（不含交付内容）
+type DeliveryLinkInfo struct {
+	OrderNo        string     `json:"order_no"`
+	MaxViews       int        `json:"max_views"`
+	ViewCount      int        `json:"view_count"`
+	RemainingViews int        `json:"remaining_views"`
+	LastViewedAt   *time.Time `json:"last_viewed_at,omitempty"`
+	ReceivedAt     *time.Time `json:"received_at,omitempty"`
+}
+
+// DeliveryLinkReveal 链接查看结果
+type DeliveryLinkReveal struct {
+	DeliveryLinkInfo
+	Payload string `json:"payload"`
+}
+
+// NormalizeDeliveryMode 规范化交付内容展示方式
+func NormalizeDeliveryMode(mode string) string {
+	if strings.TrimSpace(mode) == constants.DeliveryModeSecureLink {
+		return constants.DeliveryModeSecureLink
+	}
+	return constants.DeliveryModeInline
+}
+
+// NormalizeDeliveryRevealLimit 规范化安全链接可查看次数
+func NormalizeDeliveryRevealLimit(limit int) int {
+	if limit < 1 {
+		return 1
+	}
+	return limit
+}
+
+// isSecureDeliveryOrder 判断订单（子订单）是否使用安全链接交付
+func isSecureDeliveryOrder(order *models.Order) bool {
+	if order == nil {
+		return false
+	}
+	for _, item := range order.Items {
+		if item.DeliveryMode == constants.DeliveryModeSecureLink {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveRevealLimit 获取订单项上的查看次数快照
+func resolveRevealLimit(order *models.Order) int {
+	limit := 0
+	for _, item := range order.Items {
+		if item.DeliveryMode == constants.DeliveryModeSecureLink && item.DeliveryRevealLimit > limit {
+			limit = item.DeliveryRevealLimit
+		}
+	}
+	return NormalizeDeliveryRevealLimit(limit)
+}
+
+// MaskOrderPayloads 将订单及子订单中安全链接模式的交付内容替换为查看链接，
+// 链接按需创建，已存在时复用。
+func (s *DeliveryLinkService) MaskOrderPayloads(order *models.Order) error {
+	if s == nil || order == nil {
+		return nil
+	}
+	if order.Fulfillment != nil && order.Fulfillment.Payload != "" && isSecureDeliveryOrder(order) {
+		link, err := s.EnsureLink(order)
+		if err != nil {
+			return err
+		}
+		order.Fulfillment.Payload = s.BuildURL(link)
+	}
+	for i := range order.Children {
+		if err := s.MaskOrderPayloads(&order.Children[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnsureLink 获取或创建订单的安全交付链接
+func (s *DeliveryLinkService) EnsureLink(order *models.Order) (*models.DeliveryLink, error) {
+	if order == nil || order.Fulfillment == nil {
+		return nil, ErrFulfillmentInvalid
+	}
+	existing, err := s.linkRepo.GetByOrderID(order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+	token, err := generateDeliveryLinkToken()
+	if err != nil {
+		return nil, err
+	}
+	link := &models.DeliveryLink{
+		OrderID:       order.ID,
+		FulfillmentID: order.Fulfillment.ID,
+		Token:         token,
+		MaxViews:      resolveRevealLimit(order),
+	}
+	if err := s.linkRepo.Create(link); err != nil {
+		// 并发创建时唯一索引冲突，回读已有记录
+		if existing, getErr := s.linkRepo.GetByOrderID(order.ID); getErr == nil && existing != nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return link, nil
+}
+
+// BuildURL 构造带签名的前台查看链接
+func (s *DeliveryLinkService) BuildURL(link *models.DeliveryLink) string {
+	if link == nil {
+		return ""
+	}
+	path := deliveryLinkPathPrefix + s.signToken(link.Token)
+	if s.settingService == nil {
+		return path
+	}
+	brand, err := s.settingService.GetSiteBrand()
+	if err != nil {
+		logger.Warnw("delivery_link_load_site_brand_failed", "order_id", link.OrderID, "error", err)
+		return path
+	}
+	return strings.TrimRight(strings.TrimSpace(brand.SiteURL), "/") + path
+}
+
+// Resolve 校验签名并返回链接状态
+func (s *DeliveryLinkService) Resolve(signedToken string) (*DeliveryLinkInfo, error) {
+	link, err := s.resolveLink(signedToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildInfo(link)
+}
+
+// Reveal 查看交付内容，消耗一次查看次数并记录访问
+func (s *DeliveryLinkService) Reveal(signedToken string, access DeliveryLinkAccess) (*DeliveryLinkReveal, error) {
+	link, err := s.resolveLink(signedToken)
+	if err != nil {
+		return nil, err
+	}
+	fulfillment, err := s.fulfillmentRepo.GetByOrderID(link.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if fulfillment == nil {
+		return nil, ErrDeliveryLinkNotFound
+	}
+	now := time.Now()
+	ok, err := s.linkRepo.IncrementView(link.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrDeliveryLinkExhausted
+	}
+	s.writeLog(link, constants.DeliveryLinkActionView, access, now)
+
+	link.ViewCount++
+	link.LastViewedAt = &now
+	info, err := s.buildInfo(link)
+	if err != nil {
+		return nil, err
+	}
+	return &DeliveryLinkReveal{DeliveryLinkInfo: *info, Payload: fulfillment.Payload}, nil
+}
+
+// MarkReceived 买家确认已收到交付内容
+func (s *DeliveryLinkService) MarkReceived(signedToken string, access DeliveryLinkAccess) (*DeliveryLinkInfo, error) {
+	link, err := s.resolveLink(signedToken)
+	if err != nil {
+		return nil, err
+	}
+	if link.ReceivedAt == nil {
+		now := time.Now()
+		if err := s.linkRepo.MarkReceived(link.ID, now); err != nil {
+			return nil, err
+		}
+		s.writeLog(link, constants.DeliveryLinkActionReceived, access, now)
+		link.ReceivedAt = &now
+	}
+	return s.buildInfo(link)
+}
+
+// ListByOrder 获取订单（含子订单）的链接及访问记录，供后台排查
+func (s *DeliveryLinkService) ListByOrder(order *models.Order) ([]models.DeliveryLink, error) {
+	if order == nil {
+		return []models.DeliveryLink{}, nil
+	}
+	ids := []uint{order.ID}
+	for _, child := range order.Children {
+		ids = append(ids, child.ID)
+	}
+	return s.linkRepo.ListByOrderIDs(ids)
+}
+
+func (s *DeliveryLinkService) resolveLink(signedToken string) (*models.DeliveryLink, error) {
+	token, ok := s.verifyToken(signedToken)
+	if !ok {
+		return nil, ErrDeliveryLinkInvalid
+	}
+	link, err := s.linkRepo.GetByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, ErrDeliveryLinkNotFound
+	}
+	return link, nil
+}
+
+func (s *DeliveryLinkService) buildInfo(link *models.DeliveryLink) (*DeliveryLinkInfo, error) {
+	info := &DeliveryLinkInfo{
+		MaxViews:       link.MaxViews,
+		ViewCount:      link.ViewCount,
+		RemainingViews: link.RemainingViews(),
+		LastViewedAt:   link.LastViewedAt,
+		ReceivedAt:     link.ReceivedAt,
+	}
+	if s.orderRepo != nil {
+		order, err := s.orderRepo.GetByID(link.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		if order != nil {
+			info.OrderNo = order.OrderNo
+		}
+	}
+	return info, nil
+}
+
+func (s *DeliveryLinkService) writeLog(link *models.DeliveryLink, action string, access DeliveryLinkAccess, at time.Time) {
+	userAgent := strings.TrimSpace(access.UserAgent)
+	if len(userAgent) > 500 {
+		userAgent = userAgent[:500]
+	}
+	log := &models.DeliveryLinkLog{
+		LinkID:    link.ID,
+		OrderID:   link.OrderID,
+		Action:    action,
+		ClientIP:  strings.TrimSpace(access.ClientIP),
+		UserAgent: userAgent,
+		CreatedAt: at,
+	}
+	if err := s.linkRepo.CreateLog(log); err != nil {
+		logger.Warnw("delivery_link_write_log_failed", "link_id", link.ID, "action", action, "error", err)
+	}
+}
+
+// signToken 生成 token.signature 形式的对外令牌
+func (s *DeliveryLinkService) signToken(token string) string {
+	return token + "." + s.tokenSignature(token)
+}
+
+// verifyToken 校验对外令牌签名并返回原始令牌
+func (s *DeliveryLinkService) verifyToken(signedToken string) (string, bool) {
+	token, sig, found := strings.Cut(strings.TrimSpace(signedToken), ".")
+	if !found || token == "" || sig == "" {
+		return "", false
+	}
+	expected := s.tokenSignature(token)
+	if !hmac.Equal([]byte(sig), []byte(expected)) {
+		return "", false
+	}
+	return token, true
+}
+
+func (s *DeliveryLinkService) tokenSignature(token string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("delivery-link:" + token))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func generateDeliveryLinkToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
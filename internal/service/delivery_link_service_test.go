@@ -0,0 +1,188 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func setupDeliveryLinkServiceTest(t *testing.T) (*gorm.DB, *DeliveryLinkService) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:delivery_link_service_test_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.Order{},
+		&models.OrderItem{},
+		&models.Fulfillment{},
+		&models.DeliveryLink{},
+		&models.DeliveryLinkLog{},
+	); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	svc := NewDeliveryLinkService(
+		repository.NewDeliveryLinkRepository(db),
+		repository.NewFulfillmentRepository(db),
+		repository.NewOrderRepository(db),
+		nil,
+		"test-secret",
+	)
+	return db, svc
+}
+
+func createDeliveryLinkTestOrder(t *testing.T, db *gorm.DB, mode string, limit int) *models.Order {
+	t.Helper()
+	now := time.Now()
+	order := &models.Order{
+		OrderNo:     fmt.Sprintf("DL-%d", now.UnixNano()),
+		UserID:      1,
+		Status:      constants.OrderStatusDelivered,
+		Currency:    "CNY",
+		TotalAmount: models.NewMoneyFromDecimal(decimal.NewFromInt(10)),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := db.Create(order).Error; err != nil {
+		t.Fatalf("create order failed: %v", err)
+	}
+	item := &models.OrderItem{
+		OrderID:             order.ID,
+		ProductID:           1,
+		TitleJSON:           models.JSON{"zh-CN": "测试商品"},
+		UnitPrice:           models.NewMoneyFromDecimal(decimal.NewFromInt(10)),
+		Quantity:            1,
+		TotalPrice:          models.NewMoneyFromDecimal(decimal.NewFromInt(10)),
+		FulfillmentType:     constants.FulfillmentTypeAuto,
+		DeliveryMode:        mode,
+		DeliveryRevealLimit: limit,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	if err := db.Create(item).Error; err != nil {
+		t.Fatalf("create order item failed: %v", err)
+	}
+	fulfillment := &models.Fulfillment{
+		OrderID:     order.ID,
+		Type:        constants.FulfillmentTypeAuto,
+		Status:      constants.FulfillmentStatusDelivered,
+		Payload:     "CARD-SECRET-001",
+		DeliveredAt: &now,
+	}
+	if err := db.Create(fulfillment).Error; err != nil {
+		t.Fatalf("create fulfillment failed: %v", err)
+	}
+	loaded, err := repository.NewOrderRepository(db).GetByID(order.ID)
+	if err != nil || loaded == nil {
+		t.Fatalf("reload order failed: %v", err)
+	}
+	return loaded
+}
+
+func extractDeliveryLinkToken(t *testing.T, url string) string {
+	t.Helper()
+	idx := strings.Index(url, deliveryLinkPathPrefix)
+	if idx < 0 {
+		t.Fatalf("unexpected delivery url: %s", url)
+	}
+	return url[idx+len(deliveryLinkPathPrefix):]
+}
+
+func TestDeliveryLinkMaskKeepsInlinePayload(t *testing.T) {
+	db, svc := setupDeliveryLinkServiceTest(t)
+	order := createDeliveryLinkTestOrder(t, db, constants.DeliveryModeInline, 1)
+
+	if err := svc.MaskOrderPayloads(order); err != nil {
+		t.Fatalf("mask payload failed: %v", err)
+	}
+	if order.Fulfillment.Payload != "CARD-SECRET-001" {
+		t.Fatalf("inline payload should not be masked, got %q", order.Fulfillment.Payload)
+	}
+}
+
+func TestDeliveryLinkRevealLimitAndReceived(t *testing.T) {
+	db, svc := setupDeliveryLinkServiceTest(t)
+	order := createDeliveryLinkTestOrder(t, db, constants.DeliveryModeSecureLink, 2)
+
+	if err := svc.MaskOrderPayloads(order); err != nil {
+		t.Fatalf("mask payload failed: %v", err)
+	}
+	if strings.Contains(order.Fulfillment.Payload, "CARD-SECRET-001") {
+		t.Fatalf("secure payload leaked: %q", order.Fulfillment.Payload)
+	}
+	token := extractDeliveryLinkToken(t, order.Fulfillment.Payload)
+
+	// 再次脱敏应复用同一链接
+	again := reloadDeliveryLinkTestOrder(t, db, order.ID)
+	if err := svc.MaskOrderPayloads(again); err != nil {
+		t.Fatalf("mask payload again failed: %v", err)
+	}
+	if extractDeliveryLinkToken(t, again.Fulfillment.Payload) != token {
+		t.Fatalf("expected link reuse")
+	}
+
+	if _, err := svc.Reveal(token+"x", DeliveryLinkAccess{}); !errors.Is(err, ErrDeliveryLinkInvalid) {
+		t.Fatalf("expected invalid signature error, got %v", err)
+	}
+
+	access := DeliveryLinkAccess{ClientIP: "1.2.3.4", UserAgent: "test-agent"}
+	for i := 0; i < 2; i++ {
+		result, err := svc.Reveal(token, access)
+		if err != nil {
+			t.Fatalf("reveal %d failed: %v", i, err)
+		}
+		if result.Payload != "CARD-SECRET-001" {
+			t.Fatalf("unexpected payload: %q", result.Payload)
+		}
+		if result.RemainingViews != 1-i {
+			t.Fatalf("unexpected remaining views: %d", result.RemainingViews)
+		}
+	}
+	if _, err := svc.Reveal(token, access); !errors.Is(err, ErrDeliveryLinkExhausted) {
+		t.Fatalf("expected exhausted error, got %v", err)
+	}
+
+	info, err := svc.MarkReceived(token, access)
+	if err != nil {
+		t.Fatalf("mark received failed: %v", err)
+	}
+	if info.ReceivedAt == nil {
+		t.Fatalf("expected received_at to be set")
+	}
+
+	links, err := svc.ListByOrder(order)
+	if err != nil {
+		t.Fatalf("list links failed: %v", err)
+	}
+	if len(links) != 1 || links[0].ViewCount != 2 {
+		t.Fatalf("unexpected links: %+v", links)
+	}
+	if len(links[0].Logs) != 3 {
+		t.Fatalf("expected 3 access logs, got %d", len(links[0].Logs))
+	}
+	if links[0].Logs[0].ClientIP != "1.2.3.4" || links[0].Logs[2].Action != constants.DeliveryLinkActionReceived {
+		t.Fatalf("unexpected logs: %+v", links[0].Logs)
+	}
+}
+
+func reloadDeliveryLinkTestOrder(t *testing.T, db *gorm.DB, orderID uint) *models.Order {
+	t.Helper()
+	loaded, err := repository.NewOrderRepository(db).GetByID(orderID)
+	if err != nil || loaded == nil {
+		t.Fatalf("reload order failed: %v", err)
+	}
+	return loaded
+}
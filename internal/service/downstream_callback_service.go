@@ -113,17 +113,6 @@ func (s *DownstreamCallbackService) EnqueueCallback(orderID uint) {
 	}
 }
 
-// callbackRequest 回调请求体
-type callbackRequest struct {
-	Event             string                        `json:"event"`
-	OrderID           uint                          `json:"order_id"`
-	OrderNo           string                        `json:"order_no"`
-	DownstreamOrderNo string                        `json:"downstream_order_no"`
-	Status            string                        `json:"status"`
-	Fulfillment       *upstream.UpstreamFulfillment `json:"fulfillment,omitempty"`
-	Timestamp         int64                         `json:"timestamp"`
-}
-
 // SendCallback 执行回调发送
 func (s *DownstreamCallbackService) SendCallback(refID uint) error {
 	ref, err := s.refRepo.GetByID(refID)
@@ -152,9 +141,9 @@ func (s *DownstreamCallbackService) SendCallback(refID uint) error {
 	}
 
 	// 构建回调请求
-	event := "order.status_changed"
+	event := constants.CallbackEventOrderStatusChanged
 	if order.Status == constants.OrderStatusDelivered || order.Status == constants.OrderStatusCompleted {
-		event = "order.fulfilled"
+		event = constants.CallbackEventOrderFulfilled
 	}
 
 	// 获取交付信息：优先使用订单自身的 fulfillment，否则从子订单中获取
@@ -183,7 +172,7 @@ func (s *DownstreamCallbackService) SendCallback(refID uint) error {
 
 	now := time.Now()
 	timestamp := now.Unix()
-	reqBody := callbackRequest{
+	reqBody := upstream.OrderCallbackEvent{
 		Event:             event,
 		OrderID:           order.ID,
 		OrderNo:           order.OrderNo,
@@ -199,7 +188,7 @@ func (s *DownstreamCallbackService) SendCallback(refID uint) error {
 	}
 
 	// 签名
-	signature := upstream.Sign(credential.ApiSecret, "POST", "/api/v1/upstream/callback", timestamp, bodyBytes)
+	signature := upstream.Sign(credential.ApiSecret, "POST", upstream.PathCallback, timestamp, bodyBytes)
 
 	// 发送请求
 	logger.Infow("downstream_callback_sending",
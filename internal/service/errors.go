@@ -123,6 +123,23 @@ var (
 	ErrWalletNotSupportedForGuest          = errors.New("wallet not supported for guest")
 	ErrWalletRechargeNotFound              = errors.New("wallet recharge not found")
 	ErrWalletRechargeStatusInvalid         = errors.New("wallet recharge status invalid")
+	ErrWalletCurrencyInvalid               = errors.New("wallet currency invalid")
+	ErrWalletTransferDisabled              = errors.New("wallet transfer disabled")
+	ErrWalletTransferRecipientNotFound     = errors.New("wallet transfer recipient not found")
+	ErrWalletTransferSelf                  = errors.New("wallet transfer to self")
+	ErrWalletTransferAmountInvalid         = errors.New("wallet transfer amount invalid")
+	ErrWalletTransferDailyLimitExceeded    = errors.New("wallet transfer daily limit exceeded")
+	ErrWalletTransferTOTPRequired          = errors.New("wallet transfer totp required")
+	ErrWalletWithdrawDisabled              = errors.New("wallet withdraw disabled")
+	ErrWalletWithdrawAmountInvalid         = errors.New("wallet withdraw amount invalid")
+	ErrWalletWithdrawChannelInvalid        = errors.New("wallet withdraw channel invalid")
+	ErrWalletWithdrawNotFound              = errors.New("wallet withdraw not found")
+	ErrWalletWithdrawStatusInvalid         = errors.New("wallet withdraw status invalid")
+	ErrWalletBonusLocked                   = errors.New("wallet bonus balance locked")
+	ErrWalletRechargeBonusRuleInvalid      = errors.New("wallet recharge bonus rule invalid")
+	ErrWalletRechargeBonusRuleNotFound     = errors.New("wallet recharge bonus rule not found")
+	ErrWalletLedgerAuditNotFound           = errors.New("wallet ledger audit not found")
+	ErrWalletLedgerDiscrepancyNotFound     = errors.New("wallet ledger discrepancy not found")
 	ErrRefundRecordCreateFailed            = errors.New("refund record create failed")
 	ErrCardSecretInsufficient              = errors.New("card secret insufficient")
 	ErrFulfillmentNotAuto                  = errors.New("fulfillment not auto")
@@ -157,6 +174,9 @@ var (
 	ErrAffiliateWithdrawChannelInvalid     = errors.New("affiliate withdraw channel invalid")
 	ErrAffiliateWithdrawInsufficient       = errors.New("affiliate withdraw insufficient")
 	ErrAffiliateWithdrawStatusInvalid      = errors.New("affiliate withdraw status invalid")
+	ErrAffiliateCommissionRuleInvalid      = errors.New("affiliate commission rule invalid")
+	ErrAffiliateFunnelRangeInvalid         = errors.New("affiliate funnel range invalid")
+	ErrAffiliateCommissionStatusInvalid    = errors.New("affiliate commission status invalid")
 	ErrNotificationConfigInvalid           = errors.New("notification config invalid")
 	ErrNotificationSendFailed              = errors.New("notification send failed")
 	ErrNotificationEventInvalid            = errors.New("notification event invalid")
@@ -177,4 +197,35 @@ var (
 	ErrProductHasOrderRecord               = errors.New("product has order record")
 	ErrMediaNotFound                       = errors.New("media not found")
 	ErrMediaNameEmpty                      = errors.New("media name empty")
+	ErrDeliveryLinkInvalid                 = errors.New("delivery link invalid")
+	ErrDeliveryLinkNotFound                = errors.New("delivery link not found")
+	ErrDeliveryLinkExhausted               = errors.New("delivery link exhausted")
+	ErrProductWebhookInvalid               = errors.New("product webhook config invalid")
+	ErrFulfillmentWebhookNotApplicable     = errors.New("fulfillment webhook not applicable")
+	ErrFulfillmentWebhookFailed            = errors.New("fulfillment webhook failed")
+	ErrFulfillmentWebhookInProgress        = errors.New("fulfillment webhook in progress")
+	ErrLicenseInvalid                      = errors.New("license invalid")
+	ErrLicenseNotFound                     = errors.New("license not found")
+	ErrLicenseRevoked                      = errors.New("license revoked")
+	ErrLicenseExpired                      = errors.New("license expired")
+	ErrLicenseActivationLimit              = errors.New("license activation limit reached")
+	ErrLicenseMachineIDInvalid             = errors.New("license machine id invalid")
+	ErrLicenseSigningKeyInvalid            = errors.New("license signing key invalid")
+	ErrCardSecretReplaceInvalid            = errors.New("card secret replacement invalid")
+	ErrCardSecretReplaceFailed             = errors.New("card secret replacement failed")
+	ErrFileAssetNotFound                   = errors.New("file asset not found")
+	ErrFileAssetInvalid                    = errors.New("file asset invalid")
+	ErrFileAssetTooLarge                   = errors.New("file asset too large")
+	ErrFileAssetInUse                      = errors.New("file asset in use")
+	ErrFileDownloadInvalid                 = errors.New("file download link invalid")
+	ErrFileDownloadExpired                 = errors.New("file download link expired")
+	ErrFileDownloadExhausted               = errors.New("file download limit reached")
+	ErrProductFileInvalid                  = errors.New("product file config invalid")
+	ErrCardReplenishRuleNotFound           = errors.New("card replenish rule not found")
+	ErrCardReplenishRuleInvalid            = errors.New("card replenish rule invalid")
+	ErrCardReplenishRuleExists             = errors.New("card replenish rule already exists")
+	ErrCardReplenishRuleBusy               = errors.New("card replenish rule has open procurement orders")
+	ErrSKUSourceNotFound                   = errors.New("sku source not found")
+	ErrSKUSourceInvalid                    = errors.New("sku source invalid")
+	ErrSKUSourceExists                     = errors.New("sku source already exists")
 )
@@ -0,0 +1,612 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/logger"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// fileDownloadPathPrefix 文件下载接口路径
+const fileDownloadPathPrefix = "/api/v1/public/downloads/"
+
+// FileDeliveryService 私有文件交付服务
+type FileDeliveryService struct {
+	assetRepo      repository.FileAssetRepository
+	orderRepo      repository.OrderRepository
+	productRepo    repository.ProductRepository
+	skuRepo        repository.ProductSKURepository
+	fulfillmentSvc *FulfillmentService
+	settingService *SettingService
+	privateDir     string
+	maxSize        int64
+	secret         []byte
+}
+
+// NewFileDeliveryService 创建私有文件交付服务
+func NewFileDeliveryService(
+	assetRepo repository.FileAssetRepository,
+	orderRepo repository.OrderRepository,
+	productRepo repository.ProductRepository,
+	skuRepo repository.ProductSKURepository,
+	fulfillmentSvc *FulfillmentService,
+	settingService *SettingService,
+	privateDir string,
+	maxSize int64,
+	secretKey string,
+) *FileDeliveryService {
+	return &FileDeliveryService{
+		assetRepo:      assetRepo,
+		orderRepo:      orderRepo,
+		productRepo:    productRepo,
+		skuRepo:        skuRepo,
+		fulfillmentSvc: fulfillmentSvc,
+		settingService: settingService,
+		privateDir:     strings.TrimSpace(privateDir),
+		maxSize:        maxSize,
+		secret:         []byte(secretKey),
+	}
+}
+
+// FileDownloadAccess 下载请求来源信息
+type FileDownloadAccess struct {
+	ClientIP    string
+	UserAgent   string
+	RangeHeader string
+}
+
+// FileDownload 已打开的下载文件（调用方负责关闭 File）
+type FileDownload struct {
+	File     *os.File
+	Name     string
+	MimeType string
+	ModTime  time.Time
+}
+
+// NormalizeFileDownloadExpireHours 规范化下载链接有效小时数（<=0 使用默认值）
+func NormalizeFileDownloadExpireHours(hours int) int {
+	if hours <= 0 {
+		return constants.FileDownloadDefaultExpireHours
+	}
+	if hours > constants.FileDownloadMaxExpireHours {
+		return constants.FileDownloadMaxExpireHours
+	}
+	return hours
+}
+
+// NormalizeFileDownloadMaxCount 规范化最大下载次数（0 使用默认值，-1 表示不限制）
+func NormalizeFileDownloadMaxCount(count int) int {
+	if count < 0 {
+		return -1
+	}
+	if count == 0 {
+		return constants.FileDownloadDefaultMaxCount
+	}
+	if count > constants.FileDownloadMaxCountLimit {
+		return constants.FileDownloadMaxCountLimit
+	}
+	return count
+}
+
+// shouldFileFulfill 判断订单（子订单）是否全部为文件交付
+func shouldFileFulfill(order *models.Order) bool {
+	if order == nil || len(order.Items) == 0 {
+		return false
+	}
+	for _, item := range order.Items {
+		if strings.TrimSpace(item.FulfillmentType) != constants.FulfillmentTypeFile {
+			return false
+		}
+	}
+	return true
+}
+
+// Upload 保存私有文件到非公开目录并记录元数据
+func (s *FileDeliveryService) Upload(file *multipart.FileHeader, adminID uint) (*models.FileAsset, error) {
+	if file == nil || file.Size <= 0 {
+		return nil, ErrFileAssetInvalid
+	}
+	if s.maxSize > 0 && file.Size > s.maxSize {
+		return nil, ErrFileAssetTooLarge
+	}
+	originalName := sanitizeFileAssetName(file.Filename)
+	if originalName == "" {
+		return nil, ErrFileAssetInvalid
+	}
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	now := time.Now()
+	relPath := filepath.ToSlash(filepath.Join(now.Format("2006"), now.Format("01"), uuid.New().String()+sanitizeFileAssetExt(originalName)))
+	absPath, err := s.resolvePath(relPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(absPath), 0750); err != nil {
+		return nil, err
+	}
+	dst, err := os.OpenFile(absPath, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	// 读取文件头部识别 MIME 类型，同时计算 SHA256
+	hasher := sha256.New()
+	head := make([]byte, 512)
+	n, readErr := io.ReadFull(src, head)
+	if readErr != nil && readErr != io.ErrUnexpectedEOF && readErr != io.EOF {
+		dst.Close()
+		_ = os.Remove(absPath)
+		return nil, readErr
+	}
+	head = head[:n]
+	size, err := io.Copy(io.MultiWriter(dst, hasher), io.MultiReader(bytes.NewReader(head), src))
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(absPath)
+		return nil, err
+	}
+	if s.maxSize > 0 && size > s.maxSize {
+		_ = os.Remove(absPath)
+		return nil, ErrFileAssetTooLarge
+	}
+
+	mimeType := mime.TypeByExtension(filepath.Ext(originalName))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(head)
+	}
+	asset := &models.FileAsset{
+		OriginalName: originalName,
+		StoragePath:  relPath,
+		MimeType:     mimeType,
+		Size:         size,
+		SHA256:       hex.EncodeToString(hasher.Sum(nil)),
+	}
+	if adminID > 0 {
+		asset.CreatedBy = &adminID
+	}
+	if err := s.assetRepo.Create(asset); err != nil {
+		_ = os.Remove(absPath)
+		return nil, err
+	}
+	return asset, nil
+}
+
+// ListAssets 私有文件列表
+func (s *FileDeliveryService) ListAssets(search string, page, pageSize int) ([]models.FileAsset, int64, error) {
+	return s.assetRepo.List(repository.FileAssetListFilter{
+		Page:     page,
+		PageSize: pageSize,
+		Search:   search,
+	})
+}
+
+// DeleteAsset 删除私有文件（仍被 SKU 引用时拒绝；已签发的下载授权保留物理文件）
+func (s *FileDeliveryService) DeleteAsset(id uint) error {
+	asset, err := s.assetRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if asset == nil {
+		return ErrFileAssetNotFound
+	}
+	refs, err := s.assetRepo.CountSKUReferences(id)
+	if err != nil {
+		return err
+	}
+	if refs > 0 {
+		return ErrFileAssetInUse
+	}
+	return s.assetRepo.Delete(id)
+}
+
+// EnsureAssetExists 校验私有文件存在
+func (s *FileDeliveryService) EnsureAssetExists(id uint) error {
+	asset, err := s.assetRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if asset == nil {
+		return ErrFileAssetNotFound
+	}
+	return nil
+}
+
+// DeliverForOrder 为订单（子订单）签发文件下载授权并完成交付
+func (s *FileDeliveryService) DeliverForOrder(orderID uint) (*models.Fulfillment, error) {
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		return nil, ErrOrderFetchFailed
+	}
+	if order == nil {
+		return nil, ErrOrderNotFound
+	}
+	if !shouldFileFulfill(order) {
+		return nil, ErrFulfillmentInvalid
+	}
+	if order.Fulfillment != nil {
+		return nil, ErrFulfillmentExists
+	}
+	if order.Status != constants.OrderStatusPaid && order.Status != constants.OrderStatusFulfilling {
+		return nil, ErrOrderStatusInvalid
+	}
+
+	grants, err := s.assetRepo.ListGrantsByOrderIDs([]uint{order.ID})
+	if err != nil {
+		return nil, err
+	}
+	if len(grants) == 0 {
+		grants, err = s.buildOrderGrants(order)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.assetRepo.CreateGrants(grants); err != nil {
+			return nil, err
+		}
+		grants, err = s.assetRepo.ListGrantsByOrderIDs([]uint{order.ID})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lines := make([]string, 0, len(grants))
+	for i := range grants {
+		name := ""
+		if grants[i].FileAsset != nil {
+			name = grants[i].FileAsset.OriginalName
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", name, s.BuildURL(&grants[i])))
+	}
+	return s.fulfillmentSvc.CreateFile(order.ID, strings.Join(lines, "\n"))
+}
+
+// BuildURL 构造带签名的下载链接
+func (s *FileDeliveryService) BuildURL(grant *models.FileDownloadGrant) string {
+	if grant == nil {
+		return ""
+	}
+	path := fileDownloadPathPrefix + s.signToken(grant.Token)
+	if s.settingService == nil {
+		return path
+	}
+	brand, err := s.settingService.GetSiteBrand()
+	if err != nil {
+		logger.Warnw("file_download_load_site_brand_failed", "order_id", grant.OrderID, "error", err)
+		return path
+	}
+	return strings.TrimRight(strings.TrimSpace(brand.SiteURL), "/") + path
+}
+
+// Resolve 校验下载链接是否可用（不消耗次数，供 HEAD 请求使用）
+func (s *FileDeliveryService) Resolve(signedToken string) (*models.FileDownloadGrant, error) {
+	grant, err := s.resolveGrant(signedToken)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(grant.ExpiresAt) {
+		return nil, ErrFileDownloadExpired
+	}
+	if grant.MaxDownloads > 0 && grant.DownloadCount >= grant.MaxDownloads {
+		return nil, ErrFileDownloadExhausted
+	}
+	return grant, nil
+}
+
+// OpenDownload 校验下载链接并打开文件，记录下载日志。
+// 完整下载或覆盖首字节的 Range 请求计一次下载；断点续传的后续分段仅在最近一次计次下载后的
+// 续传窗口内、且累计传输字节不超过文件大小时不计次，超出后按新的下载计次。
+func (s *FileDeliveryService) OpenDownload(signedToken string, access FileDownloadAccess) (*FileDownload, error) {
+	grant, err := s.resolveGrant(signedToken)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if now.After(grant.ExpiresAt) {
+		return nil, ErrFileDownloadExpired
+	}
+	if grant.FileAsset == nil {
+		return nil, ErrFileAssetNotFound
+	}
+	absPath, err := s.resolvePath(grant.FileAsset.StoragePath)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrFileAssetNotFound
+		}
+		return nil, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	counted, rangeBytes := resolveDownloadRange(access.RangeHeader, stat.Size())
+	if !counted {
+		if grant.DownloadCount <= 0 {
+			file.Close()
+			return nil, ErrFileDownloadInvalid
+		}
+		since := now.Add(-time.Duration(constants.FileDownloadResumeWindowHours) * time.Hour)
+		resumed, err := s.assetRepo.AddResumeBytes(grant.ID, rangeBytes, stat.Size(), since)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		counted = !resumed
+	}
+	if counted {
+		ok, err := s.assetRepo.IncrementDownload(grant.ID, now)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if !ok {
+			file.Close()
+			return nil, ErrFileDownloadExhausted
+		}
+	}
+	s.writeLog(grant, counted, access, now)
+
+	return &FileDownload{
+		File:     file,
+		Name:     grant.FileAsset.OriginalName,
+		MimeType: grant.FileAsset.MimeType,
+		ModTime:  stat.ModTime(),
+	}, nil
+}
+
+// ListByOrder 获取订单（含子订单）的下载授权及下载记录，供后台排查
+func (s *FileDeliveryService) ListByOrder(order *models.Order) ([]models.FileDownloadGrant, error) {
+	if order == nil {
+		return []models.FileDownloadGrant{}, nil
+	}
+	ids := []uint{order.ID}
+	for _, child := range order.Children {
+		ids = append(ids, child.ID)
+	}
+	return s.assetRepo.ListGrantsByOrderIDs(ids)
+}
+
+func (s *FileDeliveryService) buildOrderGrants(order *models.Order) ([]models.FileDownloadGrant, error) {
+	now := time.Now()
+	grants := make([]models.FileDownloadGrant, 0, len(order.Items))
+	for _, item := range order.Items {
+		sku, err := s.skuRepo.GetByID(item.SKUID)
+		if err != nil {
+			return nil, err
+		}
+		if sku == nil || sku.FileAssetID == nil || *sku.FileAssetID == 0 {
+			return nil, ErrProductFileInvalid
+		}
+		asset, err := s.assetRepo.GetByID(*sku.FileAssetID)
+		if err != nil {
+			return nil, err
+		}
+		if asset == nil {
+			return nil, ErrFileAssetNotFound
+		}
+		product, err := s.productRepo.GetByID(strconv.FormatUint(uint64(item.ProductID), 10))
+		if err != nil {
+			return nil, err
+		}
+		if product == nil {
+			return nil, ErrProductNotFound
+		}
+		token, err := generateDeliveryLinkToken()
+		if err != nil {
+			return nil, err
+		}
+		maxDownloads := NormalizeFileDownloadMaxCount(product.DownloadMaxCount)
+		if maxDownloads < 0 {
+			maxDownloads = 0
+		}
+		grants = append(grants, models.FileDownloadGrant{
+			OrderID:      order.ID,
+			OrderItemID:  item.ID,
+			FileAssetID:  asset.ID,
+			Token:        token,
+			ExpiresAt:    now.Add(time.Duration(NormalizeFileDownloadExpireHours(product.DownloadExpireHours)) * time.Hour),
+			MaxDownloads: maxDownloads,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		})
+	}
+	return grants, nil
+}
+
+func (s *FileDeliveryService) resolveGrant(signedToken string) (*models.FileDownloadGrant, error) {
+	token, ok := s.verifyToken(signedToken)
+	if !ok {
+		return nil, ErrFileDownloadInvalid
+	}
+	grant, err := s.assetRepo.GetGrantByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if grant == nil {
+		return nil, ErrFileDownloadInvalid
+	}
+	return grant, nil
+}
+
+// resolvePath 将相对路径解析到私有目录下，拒绝越界路径
+func (s *FileDeliveryService) resolvePath(relPath string) (string, error) {
+	base, err := filepath.Abs(s.privateDir)
+	if err != nil || strings.TrimSpace(s.privateDir) == "" {
+		return "", ErrFileAssetInvalid
+	}
+	target := filepath.Join(base, filepath.FromSlash(relPath))
+	if !strings.HasPrefix(target, base+string(filepath.Separator)) {
+		return "", ErrFileAssetInvalid
+	}
+	return target, nil
+}
+
+func (s *FileDeliveryService) writeLog(grant *models.FileDownloadGrant, counted bool, access FileDownloadAccess, at time.Time) {
+	userAgent := strings.TrimSpace(access.UserAgent)
+	if len(userAgent) > 500 {
+		userAgent = userAgent[:500]
+	}
+	rangeHeader := strings.TrimSpace(access.RangeHeader)
+	if len(rangeHeader) > 120 {
+		rangeHeader = rangeHeader[:120]
+	}
+	log := &models.FileDownloadLog{
+		GrantID:     grant.ID,
+		OrderID:     grant.OrderID,
+		FileAssetID: grant.FileAssetID,
+		Counted:     counted,
+		RangeHeader: rangeHeader,
+		ClientIP:    strings.TrimSpace(access.ClientIP),
+		UserAgent:   userAgent,
+		CreatedAt:   at,
+	}
+	if err := s.assetRepo.CreateLog(log); err != nil {
+		logger.Warnw("file_download_write_log_failed", "grant_id", grant.ID, "error", err)
+	}
+}
+
+// signToken 生成 token.signature 形式的对外令牌
+func (s *FileDeliveryService) signToken(token string) string {
+	return token + "." + s.tokenSignature(token)
+}
+
+// verifyToken 校验对外令牌签名并返回原始令牌
+func (s *FileDeliveryService) verifyToken(signedToken string) (string, bool) {
+	token, sig, found := strings.Cut(strings.TrimSpace(signedToken), ".")
+	if !found || token == "" || sig == "" {
+		return "", false
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.tokenSignature(token))) {
+		return "", false
+	}
+	return token, true
+}
+
+func (s *FileDeliveryService) tokenSignature(token string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("file-download:" + token))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// resolveDownloadRange 解析 Range 请求：返回是否按完整下载计次（无 Range、覆盖首字节或无法解析）
+// 以及续传分段将传输的字节数
+func resolveDownloadRange(rangeHeader string, size int64) (bool, int64) {
+	value := strings.TrimSpace(rangeHeader)
+	if value == "" {
+		return true, size
+	}
+	spec, ok := strings.CutPrefix(strings.ToLower(value), "bytes=")
+	if !ok {
+		return true, size
+	}
+	var total int64
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		rawStart, rawEnd, found := strings.Cut(part, "-")
+		if !found {
+			return true, size
+		}
+		rawStart, rawEnd = strings.TrimSpace(rawStart), strings.TrimSpace(rawEnd)
+		var start, end int64
+		if rawStart == "" {
+			// 后缀区间（bytes=-N）取文件末尾 N 字节
+			suffix, err := strconv.ParseInt(rawEnd, 10, 64)
+			if err != nil || suffix < 0 {
+				return true, size
+			}
+			start = size - suffix
+			if start < 0 {
+				start = 0
+			}
+			end = size - 1
+		} else {
+			parsed, err := strconv.ParseInt(rawStart, 10, 64)
+			if err != nil || parsed < 0 {
+				return true, size
+			}
+			start, end = parsed, size-1
+			if rawEnd != "" {
+				parsedEnd, err := strconv.ParseInt(rawEnd, 10, 64)
+				if err != nil || parsedEnd < start {
+					return true, size
+				}
+				if parsedEnd < end {
+					end = parsedEnd
+				}
+			}
+		}
+		if start <= 0 {
+			return true, size
+		}
+		if start < size {
+			total += end - start + 1
+		}
+	}
+	if total > size {
+		return true, size
+	}
+	return false, total
+}
+
+// sanitizeFileAssetName 清理原始文件名（去除路径与控制字符）
+func sanitizeFileAssetName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(strings.TrimSpace(name), "\\", "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if len([]rune(name)) > 255 {
+		name = string([]rune(name)[:255])
+	}
+	return strings.TrimSpace(name)
+}
+
+// sanitizeFileAssetExt 提取安全的存储扩展名
+func sanitizeFileAssetExt(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if len(ext) < 2 || len(ext) > 16 {
+		return ""
+	}
+	for _, r := range ext[1:] {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return ext
+}
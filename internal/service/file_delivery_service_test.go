@@ -0,0 +1,211 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/config"
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func TestFileDeliveryDownloadLimitsAndRange(t *testing.T) {
+	dsn := fmt.Sprintf("file:file_delivery_test_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.Product{},
+		&models.ProductSKU{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.Fulfillment{},
+		&models.FileAsset{},
+		&models.FileDownloadGrant{},
+		&models.FileDownloadLog{},
+	); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+
+	privateDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(privateDir, "2026"), 0750); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(privateDir, "2026", "manual.pdf"), []byte("0123456789"), 0640); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+	asset := &models.FileAsset{OriginalName: "manual.pdf", StoragePath: "2026/manual.pdf", MimeType: "application/pdf", Size: 10}
+	if err := db.Create(asset).Error; err != nil {
+		t.Fatalf("create asset failed: %v", err)
+	}
+
+	now := time.Now()
+	product := &models.Product{
+		CategoryID:       1,
+		Slug:             fmt.Sprintf("file-product-%d", now.UnixNano()),
+		TitleJSON:        models.JSON{"zh-CN": "文件商品"},
+		PriceAmount:      models.NewMoneyFromDecimal(decimal.NewFromInt(5)),
+		FulfillmentType:  constants.FulfillmentTypeFile,
+		DownloadMaxCount: 2,
+		IsActive:         true,
+	}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product failed: %v", err)
+	}
+	sku := &models.ProductSKU{ProductID: product.ID, SKUCode: "PDF", PriceAmount: product.PriceAmount, FileAssetID: &asset.ID, IsActive: true}
+	if err := db.Create(sku).Error; err != nil {
+		t.Fatalf("create sku failed: %v", err)
+	}
+	order := &models.Order{
+		OrderNo:     fmt.Sprintf("FD-%d", now.UnixNano()),
+		UserID:      1,
+		Status:      constants.OrderStatusPaid,
+		Currency:    "CNY",
+		TotalAmount: product.PriceAmount,
+	}
+	if err := db.Create(order).Error; err != nil {
+		t.Fatalf("create order failed: %v", err)
+	}
+	item := &models.OrderItem{
+		OrderID:         order.ID,
+		ProductID:       product.ID,
+		SKUID:           sku.ID,
+		TitleJSON:       product.TitleJSON,
+		UnitPrice:       product.PriceAmount,
+		Quantity:        1,
+		TotalPrice:      product.PriceAmount,
+		FulfillmentType: constants.FulfillmentTypeFile,
+	}
+	if err := db.Create(item).Error; err != nil {
+		t.Fatalf("create order item failed: %v", err)
+	}
+
+	orderRepo := repository.NewOrderRepository(db)
+	fulfillmentSvc := NewFulfillmentService(orderRepo, repository.NewFulfillmentRepository(db), repository.NewCardSecretRepository(db), nil, nil, config.EmailConfig{}, nil)
+	assetRepo := repository.NewFileAssetRepository(db)
+	svc := NewFileDeliveryService(
+		assetRepo, orderRepo, repository.NewProductRepository(db), repository.NewProductSKURepository(db),
+		fulfillmentSvc, nil, privateDir, 1024, "test-secret",
+	)
+
+	fulfillment, err := svc.DeliverForOrder(order.ID)
+	if err != nil {
+		t.Fatalf("deliver failed: %v", err)
+	}
+	if fulfillment.Type != constants.FulfillmentTypeFile {
+		t.Fatalf("unexpected fulfillment type: %s", fulfillment.Type)
+	}
+	_, url, found := strings.Cut(fulfillment.Payload, "manual.pdf: ")
+	if !found || !strings.HasPrefix(url, fileDownloadPathPrefix) {
+		t.Fatalf("unexpected payload: %q", fulfillment.Payload)
+	}
+	token := strings.TrimPrefix(url, fileDownloadPathPrefix)
+
+	if _, err := svc.OpenDownload(token+"x", FileDownloadAccess{}); !errors.Is(err, ErrFileDownloadInvalid) {
+		t.Fatalf("expected tampered token invalid, got %v", err)
+	}
+	// 未有完整下载前，续传分段请求被拒绝
+	if _, err := svc.OpenDownload(token, FileDownloadAccess{RangeHeader: "bytes=5-"}); !errors.Is(err, ErrFileDownloadInvalid) {
+		t.Fatalf("expected resume without download rejected, got %v", err)
+	}
+
+	download, err := svc.OpenDownload(token, FileDownloadAccess{ClientIP: "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("first download failed: %v", err)
+	}
+	body, _ := io.ReadAll(download.File)
+	download.File.Close()
+	if string(body) != "0123456789" || download.Name != "manual.pdf" {
+		t.Fatalf("unexpected download: name=%s body=%q", download.Name, body)
+	}
+	for _, rangeHeader := range []string{"bytes=5-", "bytes=0-"} {
+		download, err := svc.OpenDownload(token, FileDownloadAccess{RangeHeader: rangeHeader})
+		if err != nil {
+			t.Fatalf("download with range %q failed: %v", rangeHeader, err)
+		}
+		download.File.Close()
+	}
+	// 计次已达上限，但断点续传分段仍可继续
+	if _, err := svc.OpenDownload(token, FileDownloadAccess{}); !errors.Is(err, ErrFileDownloadExhausted) {
+		t.Fatalf("expected exhausted, got %v", err)
+	}
+	resumed, err := svc.OpenDownload(token, FileDownloadAccess{RangeHeader: "bytes=8-"})
+	if err != nil {
+		t.Fatalf("resume after exhausted failed: %v", err)
+	}
+	resumed.File.Close()
+	// 覆盖整个文件的后缀区间、或续传累计超过文件大小的分段按新下载计次
+	for _, rangeHeader := range []string{"bytes=-10", "bytes=-999999999", "bytes=1-"} {
+		if _, err := svc.OpenDownload(token, FileDownloadAccess{RangeHeader: rangeHeader}); !errors.Is(err, ErrFileDownloadExhausted) {
+			t.Fatalf("range %q: expected exhausted, got %v", rangeHeader, err)
+		}
+	}
+
+	reloaded, err := orderRepo.GetByID(order.ID)
+	if err != nil || reloaded == nil {
+		t.Fatalf("reload order failed: %v", err)
+	}
+	if reloaded.Status != constants.OrderStatusCompleted {
+		t.Fatalf("expected completed order, got %s", reloaded.Status)
+	}
+	grants, err := svc.ListByOrder(reloaded)
+	if err != nil || len(grants) != 1 {
+		t.Fatalf("list grants failed: %v len=%d", err, len(grants))
+	}
+	if grants[0].DownloadCount != 2 || len(grants[0].Logs) != 4 {
+		t.Fatalf("unexpected grant: count=%d logs=%d", grants[0].DownloadCount, len(grants[0].Logs))
+	}
+
+	if err := svc.DeleteAsset(asset.ID); !errors.Is(err, ErrFileAssetInUse) {
+		t.Fatalf("expected asset in use, got %v", err)
+	}
+
+	// 过期后拒绝下载
+	if err := db.Model(&models.FileDownloadGrant{}).Where("id = ?", grants[0].ID).Update("expires_at", now.Add(-time.Hour)).Error; err != nil {
+		t.Fatalf("expire grant failed: %v", err)
+	}
+	if _, err := svc.OpenDownload(token, FileDownloadAccess{RangeHeader: "bytes=8-"}); !errors.Is(err, ErrFileDownloadExpired) {
+		t.Fatalf("expected expired, got %v", err)
+	}
+}
+
+func TestResolveDownloadRange(t *testing.T) {
+	type result struct {
+		counted bool
+		bytes   int64
+	}
+	cases := map[string]result{
+		"":                 {true, 1000},
+		"bytes=0-":         {true, 1000},
+		"bytes=0-99":       {true, 1000},
+		"bytes=100-":       {false, 900},
+		"bytes=100-199":    {false, 100},
+		"bytes=-500":       {false, 500},
+		"bytes=-1000":      {true, 1000},
+		"bytes=-999999999": {true, 1000},
+		"bytes=00-10":      {true, 1000},
+		"items=5-10":       {true, 1000},
+		"bytes=10-20,":     {false, 11},
+		"bytes=1-,2-":      {true, 1000},
+	}
+	for header, want := range cases {
+		counted, bytes := resolveDownloadRange(header, 1000)
+		if counted != want.counted || bytes != want.bytes {
+			t.Fatalf("range %q: want %+v got counted=%v bytes=%d", header, want, counted, bytes)
+		}
+	}
+}
@@ -141,55 +141,22 @@ func (s *FulfillmentService) CreateManual(input CreateManualInput) (*models.Fulf
 		}
 		return nil, ErrFulfillmentCreateFailed
 	}
-	if s.queueClient != nil {
-		if order.ParentID != nil {
-			status, syncErr := syncParentStatus(s.orderRepo, *order.ParentID, now)
-			if syncErr != nil {
-				logger.Warnw("fulfillment_sync_parent_status_failed",
-					"order_id", order.ID,
-					"parent_order_id", *order.ParentID,
-					"target_status", constants.OrderStatusDelivered,
-					"error", syncErr,
-				)
-			} else {
-				if status == "" {
-					status = constants.OrderStatusDelivered
-				}
-				if _, err := enqueueOrderStatusEmailTaskIfEligible(s.orderRepo, s.queueClient, s.settingService, s.defaultEmailConfig, *order.ParentID, status); err != nil {
-					logger.Warnw("fulfillment_enqueue_status_email_failed",
-						"order_id", order.ID,
-						"target_order_id", *order.ParentID,
-						"status", status,
-						"error", err,
-					)
-				}
-			}
-		} else {
-			if _, err := enqueueOrderStatusEmailTaskIfEligible(s.orderRepo, s.queueClient, s.settingService, s.defaultEmailConfig, input.OrderID, constants.OrderStatusDelivered); err != nil {
-				logger.Warnw("fulfillment_enqueue_status_email_failed",
-					"order_id", order.ID,
-					"target_order_id", input.OrderID,
-					"status", constants.OrderStatusDelivered,
-					"error", err,
-				)
-			}
-		}
-	}
-	// Telegram 通知：交付完成后推送给用户
-	notifyOrderID := input.OrderID
-	if order.ParentID != nil {
-		notifyOrderID = *order.ParentID
-	}
-	go s.NotifyBotOrderFulfilled(order.UserID, notifyOrderID)
-	// B 侧：人工交付完成后触发下游回调
-	if s.downstreamCallbackSvc != nil {
-		s.downstreamCallbackSvc.EnqueueCallback(input.OrderID)
-	}
+	s.afterFulfillmentCreated(order, constants.OrderStatusDelivered, now)
 	return created, nil
 }
 
 // CreateAuto 自动交付
 func (s *FulfillmentService) CreateAuto(orderID uint) (*models.Fulfillment, error) {
+	return s.createCardSecretFulfillment(orderID, constants.FulfillmentTypeAuto)
+}
+
+// CreateFromCardPool 上游交付订单改由本地卡密库存交付（多来源供货的 card_pool 来源）
+func (s *FulfillmentService) CreateFromCardPool(orderID uint) (*models.Fulfillment, error) {
+	return s.createCardSecretFulfillment(orderID, constants.FulfillmentTypeUpstream)
+}
+
+// createCardSecretFulfillment 从卡密库存取卡完成交付，itemType 为订单项要求的交付类型
+func (s *FulfillmentService) createCardSecretFulfillment(orderID uint, itemType string) (*models.Fulfillment, error) {
 	if orderID == 0 {
 		return nil, ErrFulfillmentInvalid
 	}
@@ -212,7 +179,7 @@ func (s *FulfillmentService) CreateAuto(orderID uint) (*models.Fulfillment, erro
 	}
 
 	for _, item := range order.Items {
-		if strings.TrimSpace(item.FulfillmentType) != constants.FulfillmentTypeAuto {
+		if strings.TrimSpace(item.FulfillmentType) != itemType {
 			return nil, ErrFulfillmentNotAuto
 		}
 	}
@@ -325,6 +292,98 @@ func (s *FulfillmentService) CreateAuto(orderID uint) (*models.Fulfillment, erro
 			return nil, ErrFulfillmentCreateFailed
 		}
 	}
+	s.afterFulfillmentCreated(order, constants.OrderStatusCompleted, now)
+	return fulfillment, nil
+}
+
+// CreateWebhook 使用外部 Webhook 返回的内容完成交付
+func (s *FulfillmentService) CreateWebhook(orderID uint, payload string, deliveryData models.JSON) (*models.Fulfillment, error) {
+	return s.createSystemFulfillment(orderID, constants.FulfillmentTypeWebhook, payload, deliveryData)
+}
+
+// CreateLicense 使用系统签发的授权码完成交付
+func (s *FulfillmentService) CreateLicense(orderID uint, payload string) (*models.Fulfillment, error) {
+	return s.createSystemFulfillment(orderID, constants.FulfillmentTypeLicense, payload, nil)
+}
+
+// CreateFile 使用系统签发的文件下载链接完成交付
+func (s *FulfillmentService) CreateFile(orderID uint, payload string) (*models.Fulfillment, error) {
+	return s.createSystemFulfillment(orderID, constants.FulfillmentTypeFile, payload, nil)
+}
+
+// createSystemFulfillment 由系统生成交付内容并直接完成订单
+func (s *FulfillmentService) createSystemFulfillment(orderID uint, fulfillmentType, payload string, deliveryData models.JSON) (*models.Fulfillment, error) {
+	if orderID == 0 {
+		return nil, ErrFulfillmentInvalid
+	}
+	payload = strings.TrimSpace(payload)
+	if payload == "" && len(deliveryData) == 0 {
+		return nil, ErrFulfillmentInvalid
+	}
+
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		return nil, ErrOrderFetchFailed
+	}
+	if order == nil {
+		return nil, ErrOrderNotFound
+	}
+	if order.ParentID == nil && len(order.Children) > 0 {
+		return nil, ErrFulfillmentInvalid
+	}
+	if order.Status != constants.OrderStatusPaid && order.Status != constants.OrderStatusFulfilling {
+		return nil, ErrOrderStatusInvalid
+	}
+	if deliveryData == nil {
+		deliveryData = models.JSON{}
+	}
+
+	now := time.Now()
+	var fulfillment *models.Fulfillment
+	err = s.orderRepo.Transaction(func(tx *gorm.DB) error {
+		var existing models.Fulfillment
+		if err := tx.Where("order_id = ?", orderID).First(&existing).Error; err == nil {
+			return ErrFulfillmentExists
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		fulfillment = &models.Fulfillment{
+			OrderID:       orderID,
+			Type:          fulfillmentType,
+			Status:        constants.FulfillmentStatusDelivered,
+			Payload:       payload,
+			LogisticsJSON: deliveryData,
+			DeliveredAt:   &now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if err := tx.Create(fulfillment).Error; err != nil {
+			return ErrFulfillmentCreateFailed
+		}
+		if err := tx.Model(&models.Order{}).Where("id = ?", orderID).Updates(map[string]interface{}{
+			"status":     constants.OrderStatusCompleted,
+			"updated_at": now,
+		}).Error; err != nil {
+			return ErrOrderUpdateFailed
+		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrFulfillmentExists):
+			return nil, ErrFulfillmentExists
+		case errors.Is(err, ErrOrderUpdateFailed):
+			return nil, ErrOrderUpdateFailed
+		default:
+			return nil, ErrFulfillmentCreateFailed
+		}
+	}
+	s.afterFulfillmentCreated(order, constants.OrderStatusCompleted, now)
+	return fulfillment, nil
+}
+
+// afterFulfillmentCreated 交付记录创建后的后续处理：同步父订单状态、邮件通知、Bot 通知与下游回调
+func (s *FulfillmentService) afterFulfillmentCreated(order *models.Order, targetStatus string, now time.Time) {
 	if s.queueClient != nil {
 		if order.ParentID != nil {
 			status, syncErr := syncParentStatus(s.orderRepo, *order.ParentID, now)
@@ -332,12 +391,12 @@ func (s *FulfillmentService) CreateAuto(orderID uint) (*models.Fulfillment, erro
 				logger.Warnw("fulfillment_sync_parent_status_failed",
 					"order_id", order.ID,
 					"parent_order_id", *order.ParentID,
-					"target_status", constants.OrderStatusCompleted,
+					"target_status", targetStatus,
 					"error", syncErr,
 				)
 			} else {
 				if status == "" {
-					status = constants.OrderStatusCompleted
+					status = targetStatus
 				}
 				if _, err := enqueueOrderStatusEmailTaskIfEligible(s.orderRepo, s.queueClient, s.settingService, s.defaultEmailConfig, *order.ParentID, status); err != nil {
 					logger.Warnw("fulfillment_enqueue_status_email_failed",
@@ -349,27 +408,26 @@ func (s *FulfillmentService) CreateAuto(orderID uint) (*models.Fulfillment, erro
 				}
 			}
 		} else {
-			if _, err := enqueueOrderStatusEmailTaskIfEligible(s.orderRepo, s.queueClient, s.settingService, s.defaultEmailConfig, orderID, constants.OrderStatusCompleted); err != nil {
+			if _, err := enqueueOrderStatusEmailTaskIfEligible(s.orderRepo, s.queueClient, s.settingService, s.defaultEmailConfig, order.ID, targetStatus); err != nil {
 				logger.Warnw("fulfillment_enqueue_status_email_failed",
 					"order_id", order.ID,
-					"target_order_id", orderID,
-					"status", constants.OrderStatusCompleted,
+					"target_order_id", order.ID,
+					"status", targetStatus,
 					"error", err,
 				)
 			}
 		}
 	}
 	// Telegram 通知：交付完成后推送给用户
-	notifyOrderID := orderID
+	notifyOrderID := order.ID
 	if order.ParentID != nil {
 		notifyOrderID = *order.ParentID
 	}
 	go s.NotifyBotOrderFulfilled(order.UserID, notifyOrderID)
-	// B 侧：自动交付完成后触发下游回调
+	// B 侧：交付完成后触发下游回调
 	if s.downstreamCallbackSvc != nil {
-		s.downstreamCallbackSvc.EnqueueCallback(orderID)
+		s.downstreamCallbackSvc.EnqueueCallback(order.ID)
 	}
-	return fulfillment, nil
 }
 
 // NotifyBotOrderFulfilled 查找用户 Telegram 绑定并入队 asynq 任务通知 Bot
@@ -0,0 +1,634 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/logger"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/queue"
+	"github.com/dujiao-next/internal/repository"
+)
+
+// Webhook 交付请求头
+const (
+	WebhookHeaderEvent     = "X-Dujiao-Event"
+	WebhookHeaderDelivery  = "X-Dujiao-Delivery"
+	WebhookHeaderTimestamp = "X-Dujiao-Timestamp"
+	WebhookHeaderSignature = "X-Dujiao-Signature"
+)
+
+// webhookFulfillmentEvent Webhook 交付事件名
+const webhookFulfillmentEvent = "order.paid"
+
+// webhookResponseMaxBytes 响应内容读取上限
+const webhookResponseMaxBytes = 64 * 1024
+
+// webhookAttemptLogMaxBytes 尝试记录中保存的响应内容上限
+const webhookAttemptLogMaxBytes = 2000
+
+// webhookRetryDelays 递增间隔重试：30s, 1m, 2m, 5m, 10m, 30m
+var webhookRetryDelays = []time.Duration{
+	30 * time.Second,
+	1 * time.Minute,
+	2 * time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	30 * time.Minute,
+}
+
+// webhookClaimLease 单次推送的占用时长（最长超时加余量），进程异常退出后占用到期自动释放
+var webhookClaimLease = time.Duration(constants.WebhookFulfillmentMaxTimeoutSeconds)*time.Second + 30*time.Second
+
+// webhookClaimBusyDelay Worker 遇到手动重试占用任务时的重新入队间隔
+const webhookClaimBusyDelay = 30 * time.Second
+
+// FulfillmentWebhookService 外部 Webhook 交付服务
+type FulfillmentWebhookService struct {
+	jobRepo         repository.FulfillmentWebhookRepository
+	orderRepo       repository.OrderRepository
+	productRepo     repository.ProductRepository
+	userRepo        repository.UserRepository
+	fulfillmentSvc  *FulfillmentService
+	notificationSvc *NotificationService
+	settingService  *SettingService
+	queueClient     *queue.Client
+	httpClient      *http.Client
+}
+
+// NewFulfillmentWebhookService 创建 Webhook 交付服务
+func NewFulfillmentWebhookService(
+	jobRepo repository.FulfillmentWebhookRepository,
+	orderRepo repository.OrderRepository,
+	productRepo repository.ProductRepository,
+	userRepo repository.UserRepository,
+	fulfillmentSvc *FulfillmentService,
+	notificationSvc *NotificationService,
+	settingService *SettingService,
+	queueClient *queue.Client,
+) *FulfillmentWebhookService {
+	return &FulfillmentWebhookService{
+		jobRepo:         jobRepo,
+		orderRepo:       orderRepo,
+		productRepo:     productRepo,
+		userRepo:        userRepo,
+		fulfillmentSvc:  fulfillmentSvc,
+		notificationSvc: notificationSvc,
+		settingService:  settingService,
+		queueClient:     queueClient,
+		// 单次请求超时由商品配置通过 context 控制
+		httpClient: &http.Client{},
+	}
+}
+
+// webhookFulfillmentItem Webhook 推送的订单项
+type webhookFulfillmentItem struct {
+	ProductID      uint        `json:"product_id"`
+	SKUID          uint        `json:"sku_id"`
+	SKUCode        string      `json:"sku_code"`
+	Title          models.JSON `json:"title"`
+	Quantity       int         `json:"quantity"`
+	UnitPrice      string      `json:"unit_price"`
+	TotalPrice     string      `json:"total_price"`
+	ManualFormData models.JSON `json:"manual_form_data,omitempty"`
+}
+
+// webhookFulfillmentRequest Webhook 推送请求体
+type webhookFulfillmentRequest struct {
+	Event         string                   `json:"event"`
+	DeliveryID    uint                     `json:"delivery_id"`
+	Attempt       int                      `json:"attempt"`
+	OrderNo       string                   `json:"order_no"`
+	ParentOrderNo string                   `json:"parent_order_no,omitempty"`
+	UserID        uint                     `json:"user_id"`
+	Email         string                   `json:"email"`
+	Currency      string                   `json:"currency"`
+	TotalAmount   string                   `json:"total_amount"`
+	PaidAt        *time.Time               `json:"paid_at,omitempty"`
+	Items         []webhookFulfillmentItem `json:"items"`
+	Timestamp     int64                    `json:"timestamp"`
+}
+
+// webhookFulfillmentResponse Webhook 响应体（JSON 形式）
+type webhookFulfillmentResponse struct {
+	Payload      string      `json:"payload"`
+	DeliveryData models.JSON `json:"delivery_data"`
+}
+
+// NormalizeWebhookTimeoutSeconds 规范化 Webhook 超时秒数
+func NormalizeWebhookTimeoutSeconds(seconds int) int {
+	if seconds <= 0 {
+		return constants.WebhookFulfillmentDefaultTimeoutSeconds
+	}
+	if seconds > constants.WebhookFulfillmentMaxTimeoutSeconds {
+		return constants.WebhookFulfillmentMaxTimeoutSeconds
+	}
+	return seconds
+}
+
+// NormalizeWebhookMaxAttempts 规范化 Webhook 最大尝试次数
+func NormalizeWebhookMaxAttempts(attempts int) int {
+	if attempts <= 0 {
+		return constants.WebhookFulfillmentDefaultMaxAttempts
+	}
+	if attempts > constants.WebhookFulfillmentMaxAttemptsLimit {
+		return constants.WebhookFulfillmentMaxAttemptsLimit
+	}
+	return attempts
+}
+
+// validateWebhookURL 校验 Webhook 地址
+func validateWebhookURL(raw string) error {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || parsed.Host == "" {
+		return ErrProductWebhookInvalid
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return ErrProductWebhookInvalid
+	}
+	return nil
+}
+
+// SignWebhookPayload 计算 Webhook 签名：hex(HMAC-SHA256(secret, "{timestamp}.{body}"))
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// shouldWebhookFulfill 判断订单（子订单）是否全部为 Webhook 交付
+func shouldWebhookFulfill(order *models.Order) bool {
+	if order == nil || len(order.Items) == 0 {
+		return false
+	}
+	for _, item := range order.Items {
+		if strings.TrimSpace(item.FulfillmentType) != constants.FulfillmentTypeWebhook {
+			return false
+		}
+	}
+	return true
+}
+
+// Start 订单支付成功后创建 Webhook 交付任务并入队
+func (s *FulfillmentWebhookService) Start(order *models.Order) error {
+	if s == nil || !shouldWebhookFulfill(order) {
+		return nil
+	}
+	job, err := s.jobRepo.GetByOrderID(order.ID)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		maxAttempts := constants.WebhookFulfillmentDefaultMaxAttempts
+		product, err := s.loadProduct(order.Items[0].ProductID)
+		if err != nil {
+			return err
+		}
+		if product != nil {
+			maxAttempts = NormalizeWebhookMaxAttempts(product.WebhookMaxAttempts)
+		}
+		job = &models.FulfillmentWebhookJob{
+			OrderID:     order.ID,
+			ProductID:   order.Items[0].ProductID,
+			Status:      constants.WebhookFulfillmentStatusPending,
+			MaxAttempts: maxAttempts,
+		}
+		if err := s.jobRepo.Create(job); err != nil {
+			return err
+		}
+	}
+	if job.Status != constants.WebhookFulfillmentStatusPending {
+		return nil
+	}
+	return s.enqueue(order.ID, 0)
+}
+
+// Execute Worker 调用：执行一次 Webhook 推送，失败时按退避策略重新入队，超过次数后转人工
+func (s *FulfillmentWebhookService) Execute(orderID uint) error {
+	job, err := s.jobRepo.GetByOrderID(orderID)
+	if err != nil {
+		return err
+	}
+	if job == nil || job.Status != constants.WebhookFulfillmentStatusPending {
+		return nil
+	}
+	job, claimed, err := s.claim(job, constants.WebhookFulfillmentStatusPending)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		// 手动重试正在推送，稍后再看结果；任务已不再待推送时无需处理
+		if job != nil && job.Status == constants.WebhookFulfillmentStatusPending {
+			return s.enqueue(orderID, webhookClaimBusyDelay)
+		}
+		return nil
+	}
+	defer s.releaseClaim(job)
+
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		return err
+	}
+	if order == nil {
+		return nil
+	}
+	if order.Fulfillment != nil {
+		job.Status = constants.WebhookFulfillmentStatusSucceeded
+		job.NextRetryAt = nil
+		return s.jobRepo.Update(job)
+	}
+	if order.Status != constants.OrderStatusPaid && order.Status != constants.OrderStatusFulfilling {
+		logger.Infow("webhook_fulfillment_skip_order_status", "order_id", order.ID, "status", order.Status)
+		return nil
+	}
+
+	if attemptErr := s.attempt(job, order, nil); attemptErr == nil {
+		return nil
+	}
+	if job.AttemptCount >= job.MaxAttempts {
+		return s.fallbackToManual(job, order)
+	}
+	idx := job.AttemptCount - 1
+	if idx >= len(webhookRetryDelays) {
+		idx = len(webhookRetryDelays) - 1
+	}
+	delay := webhookRetryDelays[idx]
+	next := time.Now().Add(delay)
+	job.NextRetryAt = &next
+	if err := s.jobRepo.Update(job); err != nil {
+		return err
+	}
+	return s.enqueue(order.ID, delay)
+}
+
+// Retry 管理员手动重试：立即同步推送一次，成功即交付，失败不改变当前任务状态
+func (s *FulfillmentWebhookService) Retry(orderID, adminID uint) (*models.FulfillmentWebhookJob, error) {
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		return nil, ErrOrderFetchFailed
+	}
+	if order == nil {
+		return nil, ErrOrderNotFound
+	}
+	if !shouldWebhookFulfill(order) {
+		return nil, ErrFulfillmentWebhookNotApplicable
+	}
+	if order.Fulfillment != nil {
+		return nil, ErrFulfillmentExists
+	}
+	if order.Status != constants.OrderStatusPaid && order.Status != constants.OrderStatusFulfilling {
+		return nil, ErrOrderStatusInvalid
+	}
+	job, err := s.jobRepo.GetByOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		job = &models.FulfillmentWebhookJob{
+			OrderID:     order.ID,
+			ProductID:   order.Items[0].ProductID,
+			Status:      constants.WebhookFulfillmentStatusFallback,
+			MaxAttempts: constants.WebhookFulfillmentDefaultMaxAttempts,
+		}
+		if err := s.jobRepo.Create(job); err != nil {
+			return nil, err
+		}
+	}
+	// 占用任务后再推送，避免与队列中的自动推送并发向下游重复请求
+	job, claimed, err := s.claim(job, constants.WebhookFulfillmentStatusPending, constants.WebhookFulfillmentStatusFallback)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		if job != nil && job.Status == constants.WebhookFulfillmentStatusSucceeded {
+			return nil, ErrFulfillmentExists
+		}
+		return nil, ErrFulfillmentWebhookInProgress
+	}
+	defer s.releaseClaim(job)
+	if err := s.attempt(job, order, &adminID); err != nil {
+		return job, fmt.Errorf("%w: %v", ErrFulfillmentWebhookFailed, err)
+	}
+	return job, nil
+}
+
+// ListByOrder 获取订单（含子订单）的 Webhook 交付任务及尝试记录
+func (s *FulfillmentWebhookService) ListByOrder(order *models.Order) ([]models.FulfillmentWebhookJob, error) {
+	if order == nil {
+		return []models.FulfillmentWebhookJob{}, nil
+	}
+	ids := []uint{order.ID}
+	for _, child := range order.Children {
+		ids = append(ids, child.ID)
+	}
+	return s.jobRepo.ListByOrderIDs(ids)
+}
+
+// claim 在任务处于指定状态且未被占用时占用任务，返回占用后重新读取的任务
+func (s *FulfillmentWebhookService) claim(job *models.FulfillmentWebhookJob, statuses ...string) (*models.FulfillmentWebhookJob, bool, error) {
+	now := time.Now()
+	claimed, err := s.jobRepo.Claim(job.ID, statuses, now, now.Add(webhookClaimLease))
+	if err != nil {
+		return nil, false, err
+	}
+	// 重新读取以获得占用期间其他推送写入的最新次数与状态
+	latest, err := s.jobRepo.GetByOrderID(job.OrderID)
+	if err != nil {
+		return nil, false, err
+	}
+	if latest == nil {
+		return nil, false, nil
+	}
+	return latest, claimed, nil
+}
+
+// releaseClaim 推送结束后释放占用
+func (s *FulfillmentWebhookService) releaseClaim(job *models.FulfillmentWebhookJob) {
+	job.LockedUntil = nil
+	if err := s.jobRepo.ReleaseClaim(job.ID); err != nil {
+		logger.Warnw("webhook_fulfillment_release_claim_failed", "order_id", job.OrderID, "job_id", job.ID, "error", err)
+	}
+}
+
+// attempt 执行一次推送并记录结果，成功时创建交付记录
+func (s *FulfillmentWebhookService) attempt(job *models.FulfillmentWebhookJob, order *models.Order, adminID *uint) error {
+	started := time.Now()
+	job.AttemptCount++
+	job.LastAttemptAt = &started
+
+	record := &models.FulfillmentWebhookAttempt{
+		JobID:       job.ID,
+		OrderID:     order.ID,
+		Attempt:     job.AttemptCount,
+		TriggeredBy: adminID,
+		CreatedAt:   started,
+	}
+	payload, deliveryData, statusCode, respBody, sendErr := s.send(job, order, record)
+	record.StatusCode = statusCode
+	record.ResponseBody = truncateWebhookText(respBody, webhookAttemptLogMaxBytes)
+	record.DurationMs = time.Since(started).Milliseconds()
+
+	if sendErr == nil {
+		if _, err := s.fulfillmentSvc.CreateWebhook(order.ID, payload, deliveryData); err != nil && !errors.Is(err, ErrFulfillmentExists) {
+			sendErr = err
+		}
+	}
+
+	job.LastStatusCode = statusCode
+	if sendErr != nil {
+		record.Error = sendErr.Error()
+		job.LastError = sendErr.Error()
+		logger.Warnw("webhook_fulfillment_attempt_failed",
+			"order_id", order.ID,
+			"job_id", job.ID,
+			"attempt", job.AttemptCount,
+			"status_code", statusCode,
+			"error", sendErr,
+		)
+	} else {
+		job.Status = constants.WebhookFulfillmentStatusSucceeded
+		job.LastError = ""
+		job.NextRetryAt = nil
+		logger.Infow("webhook_fulfillment_delivered",
+			"order_id", order.ID,
+			"job_id", job.ID,
+			"attempt", job.AttemptCount,
+		)
+	}
+	if err := s.jobRepo.CreateAttempt(record); err != nil {
+		logger.Warnw("webhook_fulfillment_write_attempt_failed", "order_id", order.ID, "job_id", job.ID, "error", err)
+	}
+	if err := s.jobRepo.Update(job); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// send 构造签名请求并解析响应
+func (s *FulfillmentWebhookService) send(job *models.FulfillmentWebhookJob, order *models.Order, record *models.FulfillmentWebhookAttempt) (string, models.JSON, int, string, error) {
+	product, err := s.loadProduct(job.ProductID)
+	if err != nil {
+		return "", nil, 0, "", err
+	}
+	if product == nil || strings.TrimSpace(product.WebhookURL) == "" {
+		return "", nil, 0, "", ErrProductWebhookInvalid
+	}
+	targetURL := strings.TrimSpace(product.WebhookURL)
+	record.URL = targetURL
+
+	timestamp := time.Now().Unix()
+	reqBody, err := s.buildRequest(job, order, timestamp)
+	if err != nil {
+		return "", nil, 0, "", err
+	}
+	bodyBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", nil, 0, "", err
+	}
+
+	timeout := time.Duration(NormalizeWebhookTimeoutSeconds(product.WebhookTimeoutSecs)) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return "", nil, 0, "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(WebhookHeaderEvent, webhookFulfillmentEvent)
+	httpReq.Header.Set(WebhookHeaderDelivery, fmt.Sprintf("%d-%d", job.ID, job.AttemptCount))
+	httpReq.Header.Set(WebhookHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(WebhookHeaderSignature, SignWebhookPayload(product.WebhookSecret, timestamp, bodyBytes))
+
+	resp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return "", nil, 0, "", err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, webhookResponseMaxBytes))
+	if err != nil {
+		return "", nil, resp.StatusCode, "", err
+	}
+	respText := string(raw)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", nil, resp.StatusCode, respText, fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	payload, deliveryData := parseWebhookResponse(resp.Header.Get("Content-Type"), raw)
+	if payload == "" && len(deliveryData) == 0 {
+		return "", nil, resp.StatusCode, respText, errors.New("webhook returned empty payload")
+	}
+	return payload, deliveryData, resp.StatusCode, respText, nil
+}
+
+func (s *FulfillmentWebhookService) buildRequest(job *models.FulfillmentWebhookJob, order *models.Order, timestamp int64) (*webhookFulfillmentRequest, error) {
+	req := &webhookFulfillmentRequest{
+		Event:       webhookFulfillmentEvent,
+		DeliveryID:  job.ID,
+		Attempt:     job.AttemptCount,
+		OrderNo:     order.OrderNo,
+		UserID:      order.UserID,
+		Email:       strings.TrimSpace(order.GuestEmail),
+		Currency:    order.Currency,
+		TotalAmount: order.TotalAmount.String(),
+		PaidAt:      order.PaidAt,
+		Items:       make([]webhookFulfillmentItem, 0, len(order.Items)),
+		Timestamp:   timestamp,
+	}
+	if order.ParentID != nil {
+		parent, err := s.orderRepo.GetByID(*order.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent != nil {
+			req.ParentOrderNo = parent.OrderNo
+		}
+	}
+	if order.UserID != 0 && s.userRepo != nil {
+		user, err := s.userRepo.GetByID(order.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			req.Email = strings.TrimSpace(user.Email)
+		}
+	}
+	for _, item := range order.Items {
+		skuCode := ""
+		if raw, ok := item.SKUSnapshotJSON["sku_code"]; ok && raw != nil {
+			skuCode = strings.TrimSpace(fmt.Sprintf("%v", raw))
+		}
+		req.Items = append(req.Items, webhookFulfillmentItem{
+			ProductID:      item.ProductID,
+			SKUID:          item.SKUID,
+			SKUCode:        skuCode,
+			Title:          item.TitleJSON,
+			Quantity:       item.Quantity,
+			UnitPrice:      item.UnitPrice.String(),
+			TotalPrice:     item.TotalPrice.String(),
+			ManualFormData: item.ManualFormSubmissionJSON,
+		})
+	}
+	return req, nil
+}
+
+// fallbackToManual 多次失败后转为人工交付
+func (s *FulfillmentWebhookService) fallbackToManual(job *models.FulfillmentWebhookJob, order *models.Order) error {
+	now := time.Now()
+	job.Status = constants.WebhookFulfillmentStatusFallback
+	job.NextRetryAt = nil
+	if err := s.jobRepo.Update(job); err != nil {
+		return err
+	}
+	if order.Status == constants.OrderStatusPaid {
+		if err := s.orderRepo.UpdateStatus(order.ID, constants.OrderStatusFulfilling, map[string]interface{}{
+			"updated_at": now,
+		}); err != nil {
+			return err
+		}
+		order.Status = constants.OrderStatusFulfilling
+		if order.ParentID != nil {
+			if _, err := syncParentStatus(s.orderRepo, *order.ParentID, now); err != nil {
+				logger.Warnw("webhook_fulfillment_sync_parent_status_failed",
+					"order_id", order.ID,
+					"parent_order_id", *order.ParentID,
+					"error", err,
+				)
+			}
+		}
+	}
+	logger.Warnw("webhook_fulfillment_fallback_manual",
+		"order_id", order.ID,
+		"job_id", job.ID,
+		"attempt_count", job.AttemptCount,
+		"last_error", job.LastError,
+	)
+	s.notifyFallback(job, order)
+	return nil
+}
+
+func (s *FulfillmentWebhookService) notifyFallback(job *models.FulfillmentWebhookJob, order *models.Order) {
+	if s.notificationSvc == nil {
+		return
+	}
+	locale := constants.LocaleZhCN
+	if s.settingService != nil {
+		if setting, err := s.settingService.GetNotificationCenterSetting(); err == nil {
+			locale = normalizeNotificationLocale(setting.DefaultLocale)
+		}
+	}
+	itemsSummary, fulfillmentItemsSummary, counts := buildNotificationOrderItemSummaries(order.Items, locale)
+	data := models.JSON{
+		"order_id":                  fmt.Sprintf("%d", order.ID),
+		"order_no":                  strings.TrimSpace(order.OrderNo),
+		"user_id":                   fmt.Sprintf("%d", order.UserID),
+		"guest_email":               strings.TrimSpace(order.GuestEmail),
+		"amount":                    order.TotalAmount.String(),
+		"currency":                  strings.ToUpper(strings.TrimSpace(order.Currency)),
+		"order_status":              strings.TrimSpace(order.Status),
+		"items_summary":             itemsSummary,
+		"fulfillment_items_summary": fulfillmentItemsSummary,
+		"item_count":                fmt.Sprintf("%d", counts.Total),
+		"webhook_attempt_count":     fmt.Sprintf("%d", job.AttemptCount),
+		"webhook_last_error":        job.LastError,
+	}
+	if err := s.notificationSvc.Enqueue(NotificationEnqueueInput{
+		EventType: constants.NotificationEventManualFulfillmentPending,
+		BizType:   constants.NotificationBizTypeOrder,
+		BizID:     order.ID,
+		Data:      data,
+	}); err != nil {
+		logger.Warnw("webhook_fulfillment_notify_fallback_failed", "order_id", order.ID, "error", err)
+	}
+}
+
+func (s *FulfillmentWebhookService) enqueue(orderID uint, delay time.Duration) error {
+	if s.queueClient == nil {
+		return ErrQueueUnavailable
+	}
+	return s.queueClient.EnqueueOrderWebhookFulfill(queue.OrderWebhookFulfillPayload{OrderID: orderID}, delay)
+}
+
+func (s *FulfillmentWebhookService) loadProduct(productID uint) (*models.Product, error) {
+	if s.productRepo == nil || productID == 0 {
+		return nil, nil
+	}
+	return s.productRepo.GetByID(strconv.FormatUint(uint64(productID), 10))
+}
+
+// parseWebhookResponse 解析响应：JSON 对象取 payload/delivery_data 字段，否则整体作为交付内容
+func parseWebhookResponse(contentType string, raw []byte) (string, models.JSON) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return "", nil
+	}
+	if strings.Contains(strings.ToLower(contentType), "json") || trimmed[0] == '{' {
+		var parsed webhookFulfillmentResponse
+		if err := json.Unmarshal(trimmed, &parsed); err == nil {
+			return strings.TrimSpace(parsed.Payload), parsed.DeliveryData
+		}
+	}
+	return string(trimmed), nil
+}
+
+func truncateWebhookText(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+	return text[:max]
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/config"
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func setupFulfillmentWebhookServiceTest(t *testing.T) (*gorm.DB, *FulfillmentWebhookService) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:fulfillment_webhook_service_test_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.Product{},
+		&models.ProductSKU{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.Fulfillment{},
+		&models.FulfillmentWebhookJob{},
+		&models.FulfillmentWebhookAttempt{},
+	); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	orderRepo := repository.NewOrderRepository(db)
+	fulfillmentSvc := NewFulfillmentService(
+		orderRepo,
+		repository.NewFulfillmentRepository(db),
+		repository.NewCardSecretRepository(db),
+		nil, nil, config.EmailConfig{}, nil,
+	)
+	svc := NewFulfillmentWebhookService(
+		repository.NewFulfillmentWebhookRepository(db),
+		orderRepo,
+		repository.NewProductRepository(db),
+		repository.NewUserRepository(db),
+		fulfillmentSvc,
+		nil,
+		nil,
+		nil,
+	)
+	return db, svc
+}
+
+func createFulfillmentWebhookTestOrder(t *testing.T, db *gorm.DB, webhookURL string, maxAttempts int) *models.Order {
+	t.Helper()
+	now := time.Now()
+	product := &models.Product{
+		CategoryID:         1,
+		Slug:               fmt.Sprintf("webhook-product-%d", now.UnixNano()),
+		TitleJSON:          models.JSON{"zh-CN": "Webhook 商品"},
+		PriceAmount:        models.NewMoneyFromDecimal(decimal.NewFromInt(10)),
+		FulfillmentType:    constants.FulfillmentTypeWebhook,
+		WebhookURL:         webhookURL,
+		WebhookSecret:      "whsec-test",
+		WebhookTimeoutSecs: 5,
+		WebhookMaxAttempts: maxAttempts,
+		IsActive:           true,
+	}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product failed: %v", err)
+	}
+	order := &models.Order{
+		OrderNo:     fmt.Sprintf("WH-%d", now.UnixNano()),
+		GuestEmail:  "buyer@example.com",
+		Status:      constants.OrderStatusPaid,
+		Currency:    "CNY",
+		TotalAmount: models.NewMoneyFromDecimal(decimal.NewFromInt(10)),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := db.Create(order).Error; err != nil {
+		t.Fatalf("create order failed: %v", err)
+	}
+	item := &models.OrderItem{
+		OrderID:                  order.ID,
+		ProductID:                product.ID,
+		TitleJSON:                product.TitleJSON,
+		SKUSnapshotJSON:          models.JSON{"sku_code": "DEFAULT"},
+		UnitPrice:                models.NewMoneyFromDecimal(decimal.NewFromInt(10)),
+		Quantity:                 1,
+		TotalPrice:               models.NewMoneyFromDecimal(decimal.NewFromInt(10)),
+		FulfillmentType:          constants.FulfillmentTypeWebhook,
+		ManualFormSubmissionJSON: models.JSON{"account": "player-1"},
+		CreatedAt:                now,
+		UpdatedAt:                now,
+	}
+	if err := db.Create(item).Error; err != nil {
+		t.Fatalf("create order item failed: %v", err)
+	}
+	job := &models.FulfillmentWebhookJob{
+		OrderID:     order.ID,
+		ProductID:   product.ID,
+		Status:      constants.WebhookFulfillmentStatusPending,
+		MaxAttempts: maxAttempts,
+	}
+	if err := db.Create(job).Error; err != nil {
+		t.Fatalf("create webhook job failed: %v", err)
+	}
+	loaded, err := repository.NewOrderRepository(db).GetByID(order.ID)
+	if err != nil || loaded == nil {
+		t.Fatalf("reload order failed: %v", err)
+	}
+	return loaded
+}
+
+func TestFulfillmentWebhookExecuteDelivers(t *testing.T) {
+	var received webhookFulfillmentRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(WebhookHeaderTimestamp), 10, 64)
+		if r.Header.Get(WebhookHeaderSignature) != SignWebhookPayload("whsec-test", ts, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.Unmarshal(body, &received)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"payload":"LICENSE-KEY-001","delivery_data":{"account":"player-1"}}`))
+	}))
+	defer server.Close()
+
+	db, svc := setupFulfillmentWebhookServiceTest(t)
+	order := createFulfillmentWebhookTestOrder(t, db, server.URL, 3)
+
+	if err := svc.Execute(order.ID); err != nil {
+		t.Fatalf("execute webhook failed: %v", err)
+	}
+	if received.OrderNo != order.OrderNo || received.Email != "buyer@example.com" {
+		t.Fatalf("unexpected webhook request: %+v", received)
+	}
+	if len(received.Items) != 1 || received.Items[0].SKUCode != "DEFAULT" || received.Items[0].ManualFormData["account"] != "player-1" {
+		t.Fatalf("unexpected webhook items: %+v", received.Items)
+	}
+
+	reloaded, err := repository.NewOrderRepository(db).GetByID(order.ID)
+	if err != nil || reloaded == nil {
+		t.Fatalf("reload order failed: %v", err)
+	}
+	if reloaded.Status != constants.OrderStatusCompleted {
+		t.Fatalf("expected completed order, got %s", reloaded.Status)
+	}
+	if reloaded.Fulfillment == nil || reloaded.Fulfillment.Payload != "LICENSE-KEY-001" || reloaded.Fulfillment.Type != constants.FulfillmentTypeWebhook {
+		t.Fatalf("unexpected fulfillment: %+v", reloaded.Fulfillment)
+	}
+
+	jobs, err := svc.ListByOrder(reloaded)
+	if err != nil {
+		t.Fatalf("list jobs failed: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Status != constants.WebhookFulfillmentStatusSucceeded || len(jobs[0].Attempts) != 1 {
+		t.Fatalf("unexpected jobs: %+v", jobs)
+	}
+	if jobs[0].Attempts[0].StatusCode != http.StatusOK {
+		t.Fatalf("unexpected attempt status code: %d", jobs[0].Attempts[0].StatusCode)
+	}
+}
+
+func TestFulfillmentWebhookFallbackToManualAndRetry(t *testing.T) {
+	healthy := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("upstream down"))
+			return
+		}
+		_, _ = w.Write([]byte("PLAIN-SECRET"))
+	}))
+	defer server.Close()
+
+	db, svc := setupFulfillmentWebhookServiceTest(t)
+	order := createFulfillmentWebhookTestOrder(t, db, server.URL, 1)
+
+	if err := svc.Execute(order.ID); err != nil {
+		t.Fatalf("execute webhook failed: %v", err)
+	}
+	reloaded, err := repository.NewOrderRepository(db).GetByID(order.ID)
+	if err != nil || reloaded == nil {
+		t.Fatalf("reload order failed: %v", err)
+	}
+	if reloaded.Status != constants.OrderStatusFulfilling || reloaded.Fulfillment != nil {
+		t.Fatalf("expected fallback to manual fulfilling, got status=%s", reloaded.Status)
+	}
+	jobs, err := svc.ListByOrder(reloaded)
+	if err != nil {
+		t.Fatalf("list jobs failed: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Status != constants.WebhookFulfillmentStatusFallback || jobs[0].LastStatusCode != http.StatusBadGateway {
+		t.Fatalf("unexpected jobs after fallback: %+v", jobs)
+	}
+	if jobs[0].Attempts[0].ResponseBody != "upstream down" {
+		t.Fatalf("unexpected attempt response: %q", jobs[0].Attempts[0].ResponseBody)
+	}
+
+	if _, err := svc.Retry(order.ID, 7); !errors.Is(err, ErrFulfillmentWebhookFailed) {
+		t.Fatalf("expected retry failure, got %v", err)
+	}
+
+	healthy = true
+	job, err := svc.Retry(order.ID, 7)
+	if err != nil {
+		t.Fatalf("manual retry failed: %v", err)
+	}
+	if job.Status != constants.WebhookFulfillmentStatusSucceeded || job.AttemptCount != 3 {
+		t.Fatalf("unexpected job after retry: %+v", job)
+	}
+	reloaded, err = repository.NewOrderRepository(db).GetByID(order.ID)
+	if err != nil || reloaded == nil {
+		t.Fatalf("reload order failed: %v", err)
+	}
+	if reloaded.Fulfillment == nil || reloaded.Fulfillment.Payload != "PLAIN-SECRET" {
+		t.Fatalf("unexpected fulfillment after retry: %+v", reloaded.Fulfillment)
+	}
+	jobs, err = svc.ListByOrder(reloaded)
+	if err != nil {
+		t.Fatalf("list jobs failed: %v", err)
+	}
+	last := jobs[0].Attempts[len(jobs[0].Attempts)-1]
+	if last.TriggeredBy == nil || *last.TriggeredBy != 7 {
+		t.Fatalf("expected manual attempt to record admin, got %+v", last)
+	}
+}
+
+func TestFulfillmentWebhookRetrySkipsWhileJobClaimed(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte("PLAIN-SECRET"))
+	}))
+	defer server.Close()
+
+	db, svc := setupFulfillmentWebhookServiceTest(t)
+	order := createFulfillmentWebhookTestOrder(t, db, server.URL, 3)
+
+	// 模拟 Worker 正在推送：任务已被占用
+	lockedUntil := time.Now().Add(time.Minute)
+	if err := db.Model(&models.FulfillmentWebhookJob{}).Where("order_id = ?", order.ID).Update("locked_until", lockedUntil).Error; err != nil {
+		t.Fatalf("claim job failed: %v", err)
+	}
+	if _, err := svc.Retry(order.ID, 7); !errors.Is(err, ErrFulfillmentWebhookInProgress) {
+		t.Fatalf("expected retry in progress, got %v", err)
+	}
+	if err := svc.Execute(order.ID); !errors.Is(err, ErrQueueUnavailable) {
+		t.Fatalf("expected execute to requeue while claimed, got %v", err)
+	}
+	if requests != 0 {
+		t.Fatalf("expected no webhook request while claimed, got %d", requests)
+	}
+
+	// 占用到期后手动重试可正常推送，并释放占用
+	expired := time.Now().Add(-time.Second)
+	if err := db.Model(&models.FulfillmentWebhookJob{}).Where("order_id = ?", order.ID).Update("locked_until", expired).Error; err != nil {
+		t.Fatalf("expire claim failed: %v", err)
+	}
+	job, err := svc.Retry(order.ID, 7)
+	if err != nil {
+		t.Fatalf("manual retry failed: %v", err)
+	}
+	if requests != 1 || job.Status != constants.WebhookFulfillmentStatusSucceeded {
+		t.Fatalf("unexpected retry result: requests=%d job=%+v", requests, job)
+	}
+	var reloaded models.FulfillmentWebhookJob
+	if err := db.First(&reloaded, job.ID).Error; err != nil {
+		t.Fatalf("reload job failed: %v", err)
+	}
+	if reloaded.LockedUntil != nil {
+		t.Fatalf("expected claim released, got %v", reloaded.LockedUntil)
+	}
+	if err := svc.Execute(order.ID); err != nil || requests != 1 {
+		t.Fatalf("expected queued execute to skip succeeded job: err=%v requests=%d", err, requests)
+	}
+}
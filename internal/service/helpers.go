@@ -65,3 +65,15 @@ func appendURLQuery(rawURL string, params map[string]string) string {
 	parsed.RawQuery = query.Encode()
 	return parsed.String()
 }
+
+// sanitizeCSVCell 用户可控的内容以公式字符开头时加单引号前缀，避免导出的 CSV 在表格软件中被当作公式执行。
+func sanitizeCSVCell(value string) string {
+	if value == "" {
+		return value
+	}
+	switch value[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/logger"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+)
+
+var (
+	ErrIdempotencyKeyInvalid  = errors.New("idempotency key is invalid")
+	ErrIdempotencyKeyConflict = errors.New("idempotency key reused with a different request")
+	ErrIdempotencyInProgress  = errors.New("idempotent request is still in progress")
+)
+
+const (
+	// IdempotencyTTL 幂等键记录保留时长
+	IdempotencyTTL = 24 * time.Hour
+	// idempotencyKeyMaxLength 幂等键最大长度
+	idempotencyKeyMaxLength = 128
+	// idempotencyProcessingTimeout 处理中的记录超过该时长视为首次请求已中断（如进程重启），允许接管
+	idempotencyProcessingTimeout = 2 * time.Minute
+)
+
+// IdempotencyService 幂等键服务：保存首次请求的指纹与响应，重试时回放
+type IdempotencyService struct {
+	repo repository.IdempotencyRecordRepository
+}
+
+// NewIdempotencyService 创建幂等键服务
+func NewIdempotencyService(repo repository.IdempotencyRecordRepository) *IdempotencyService {
+	return &IdempotencyService{repo: repo}
+}
+
+// IdempotencyFingerprint 计算请求指纹
+func IdempotencyFingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(strings.ToUpper(method)))
+	hash.Write([]byte("\n"))
+	hash.Write([]byte(path))
+	hash.Write([]byte("\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Begin 登记一次带幂等键的请求。
+// replay 为 true 时 record 为已完成的首次请求，调用方应直接回放其响应；
+// 否则 record 为新登记的处理中记录，请求结束后须调用 Complete 或 Release。
+func (s *IdempotencyService) Begin(scope, key, fingerprint string) (record *models.IdempotencyRecord, replay bool, err error) {
+	key = strings.TrimSpace(key)
+	if key == "" || len(key) > idempotencyKeyMaxLength {
+		return nil, false, ErrIdempotencyKeyInvalid
+	}
+
+	var createErr error
+	for attempt := 0; attempt < 2; attempt++ {
+		now := time.Now()
+		existing, err := s.repo.GetByScopeAndKey(scope, key)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing != nil {
+			abandoned := existing.Status == constants.IdempotencyStatusProcessing && now.Sub(existing.UpdatedAt) > idempotencyProcessingTimeout
+			if existing.ExpiresAt.After(now) && !abandoned {
+				if existing.Fingerprint != fingerprint {
+					return nil, false, ErrIdempotencyKeyConflict
+				}
+				if existing.Status != constants.IdempotencyStatusCompleted {
+					return nil, false, ErrIdempotencyInProgress
+				}
+				return existing, true, nil
+			}
+			// 已过期或首次请求已中断：删除后按新请求处理
+			if err := s.repo.Delete(existing.ID); err != nil {
+				return nil, false, err
+			}
+		}
+
+		record = &models.IdempotencyRecord{
+			Scope:       scope,
+			Key:         key,
+			Fingerprint: fingerprint,
+			Status:      constants.IdempotencyStatusProcessing,
+			ExpiresAt:   now.Add(IdempotencyTTL),
+		}
+		if createErr = s.repo.Create(record); createErr == nil {
+			return record, false, nil
+		}
+		// 并发的相同请求抢先写入，重新读取后按已有记录处理
+	}
+	return nil, false, createErr
+}
+
+// Complete 保存首次请求的响应。
+// 服务端错误、并发冲突（409）与限流（429）属于暂时性结果，不保存，调用方可用同一幂等键重试。
+func (s *IdempotencyService) Complete(record *models.IdempotencyRecord, status int, contentType string, body []byte) {
+	if record == nil {
+		return
+	}
+	if status >= http.StatusInternalServerError || status == http.StatusConflict || status == http.StatusTooManyRequests {
+		s.Release(record)
+		return
+	}
+	err := s.repo.Update(record.ID, map[string]interface{}{
+		"status":          constants.IdempotencyStatusCompleted,
+		"response_status": status,
+		"content_type":    contentType,
+		"response_body":   string(body),
+		"updated_at":      time.Now(),
+	})
+	if err != nil {
+		logger.Warnw("idempotency_complete_failed", "record_id", record.ID, "error", err)
+	}
+}
+
+// Release 删除处理中的记录，允许调用方重试
+func (s *IdempotencyService) Release(record *models.IdempotencyRecord) {
+	if record == nil {
+		return
+	}
+	if err := s.repo.Delete(record.ID); err != nil {
+		logger.Warnw("idempotency_release_failed", "record_id", record.ID, "error", err)
+	}
+}
+
+// PurgeExpired 清理过期记录
+func (s *IdempotencyService) PurgeExpired() {
+	count, err := s.repo.DeleteExpired(time.Now())
+	if err != nil {
+		logger.Warnw("idempotency_purge_failed", "error", err)
+		return
+	}
+	if count > 0 {
+		logger.Infow("idempotency_purged", "count", count)
+	}
+}
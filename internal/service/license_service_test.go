@@ -0,0 +1,246 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/config"
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func setupLicenseServiceTest(t *testing.T) (*gorm.DB, *LicenseService) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:license_service_test_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.Product{},
+		&models.ProductSKU{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.Fulfillment{},
+		&models.LicenseSigningKey{},
+		&models.License{},
+		&models.LicenseActivation{},
+	); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	orderRepo := repository.NewOrderRepository(db)
+	fulfillmentSvc := NewFulfillmentService(
+		orderRepo,
+		repository.NewFulfillmentRepository(db),
+		repository.NewCardSecretRepository(db),
+		nil, nil, config.EmailConfig{}, nil,
+	)
+	svc := NewLicenseService(
+		repository.NewLicenseRepository(db),
+		orderRepo,
+		repository.NewProductRepository(db),
+		fulfillmentSvc,
+		"test-secret",
+	)
+	return db, svc
+}
+
+func createLicenseTestOrder(t *testing.T, db *gorm.DB, quantity, validDays, maxDevices int) (*models.Product, *models.Order) {
+	t.Helper()
+	now := time.Now()
+	product := &models.Product{
+		CategoryID:        1,
+		Slug:              fmt.Sprintf("license-product-%d", now.UnixNano()),
+		TitleJSON:         models.JSON{"zh-CN": "授权码商品"},
+		PriceAmount:       models.NewMoneyFromDecimal(decimal.NewFromInt(99)),
+		FulfillmentType:   constants.FulfillmentTypeLicense,
+		LicenseValidDays:  validDays,
+		LicenseMaxDevices: maxDevices,
+		IsActive:          true,
+	}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product failed: %v", err)
+	}
+	order := &models.Order{
+		OrderNo:     fmt.Sprintf("LIC-%d", now.UnixNano()),
+		UserID:      1,
+		Status:      constants.OrderStatusPaid,
+		Currency:    "CNY",
+		TotalAmount: models.NewMoneyFromDecimal(decimal.NewFromInt(int64(99 * quantity))),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := db.Create(order).Error; err != nil {
+		t.Fatalf("create order failed: %v", err)
+	}
+	item := &models.OrderItem{
+		OrderID:         order.ID,
+		ProductID:       product.ID,
+		SKUID:           3,
+		TitleJSON:       product.TitleJSON,
+		SKUSnapshotJSON: models.JSON{"sku_code": "PRO"},
+		UnitPrice:       models.NewMoneyFromDecimal(decimal.NewFromInt(99)),
+		Quantity:        quantity,
+		TotalPrice:      models.NewMoneyFromDecimal(decimal.NewFromInt(int64(99 * quantity))),
+		FulfillmentType: constants.FulfillmentTypeLicense,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := db.Create(item).Error; err != nil {
+		t.Fatalf("create order item failed: %v", err)
+	}
+	return product, order
+}
+
+func TestLicenseIssueAndVerifyActivations(t *testing.T) {
+	db, svc := setupLicenseServiceTest(t)
+	_, order := createLicenseTestOrder(t, db, 2, 30, 1)
+
+	fulfillment, err := svc.IssueForOrder(order.ID)
+	if err != nil {
+		t.Fatalf("issue licenses failed: %v", err)
+	}
+	keys := strings.Split(fulfillment.Payload, "\n")
+	if len(keys) != 2 || keys[0] == keys[1] {
+		t.Fatalf("expected two distinct license keys, got %q", fulfillment.Payload)
+	}
+	if fulfillment.Type != constants.FulfillmentTypeLicense {
+		t.Fatalf("unexpected fulfillment type: %s", fulfillment.Type)
+	}
+
+	claims, _, _, err := parseLicenseKey(keys[0])
+	if err != nil {
+		t.Fatalf("parse license key failed: %v", err)
+	}
+	if claims.OrderNo != order.OrderNo || claims.SKUCode != "PRO" || claims.SKUID != 3 || claims.ExpiresAt == 0 {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	result, err := svc.Verify(LicenseVerifyInput{LicenseKey: keys[0]})
+	if err != nil {
+		t.Fatalf("verify without machine failed: %v", err)
+	}
+	if !result.Valid || result.Activated || result.Activations != 0 {
+		t.Fatalf("unexpected verify result: %+v", result)
+	}
+
+	if _, err := svc.Verify(LicenseVerifyInput{LicenseKey: keys[0], MachineID: "machine-a"}); err != nil {
+		t.Fatalf("activate machine-a failed: %v", err)
+	}
+	again, err := svc.Verify(LicenseVerifyInput{LicenseKey: keys[0], MachineID: "machine-a"})
+	if err != nil {
+		t.Fatalf("re-verify machine-a failed: %v", err)
+	}
+	if again.Activations != 1 {
+		t.Fatalf("expected single activation, got %d", again.Activations)
+	}
+	if _, err := svc.Verify(LicenseVerifyInput{LicenseKey: keys[0], MachineID: "machine-b"}); !errors.Is(err, ErrLicenseActivationLimit) {
+		t.Fatalf("expected activation limit, got %v", err)
+	}
+
+	if _, err := svc.Deactivate(LicenseVerifyInput{LicenseKey: keys[0], MachineID: "machine-a"}); err != nil {
+		t.Fatalf("deactivate failed: %v", err)
+	}
+	if _, err := svc.Verify(LicenseVerifyInput{LicenseKey: keys[0], MachineID: "machine-b"}); err != nil {
+		t.Fatalf("activate machine-b after deactivate failed: %v", err)
+	}
+
+	// 篡改声明后签名失效
+	parts := strings.Split(keys[1], ".")
+	tampered := parts[0] + "." + strings.Split(keys[0], ".")[1] + "." + parts[2]
+	if _, err := svc.Verify(LicenseVerifyInput{LicenseKey: tampered}); !errors.Is(err, ErrLicenseInvalid) {
+		t.Fatalf("expected tampered key invalid, got %v", err)
+	}
+
+	reloaded, err := repository.NewOrderRepository(db).GetByID(order.ID)
+	if err != nil || reloaded == nil {
+		t.Fatalf("reload order failed: %v", err)
+	}
+	if reloaded.Status != constants.OrderStatusCompleted {
+		t.Fatalf("expected completed order, got %s", reloaded.Status)
+	}
+	licenses, err := svc.ListByOrder(reloaded)
+	if err != nil || len(licenses) != 2 {
+		t.Fatalf("list licenses failed: %v len=%d", err, len(licenses))
+	}
+	if _, err := svc.Revoke(licenses[1].ID); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+	if _, err := svc.Verify(LicenseVerifyInput{LicenseKey: keys[1]}); !errors.Is(err, ErrLicenseRevoked) {
+		t.Fatalf("expected revoked error, got %v", err)
+	}
+}
+
+func TestLicenseSigningKeyRotationKeepsIssuedKeysValid(t *testing.T) {
+	db, svc := setupLicenseServiceTest(t)
+	product, order := createLicenseTestOrder(t, db, 1, 0, 0)
+
+	info, err := svc.GetSigningKeyInfo(product.ID)
+	if err != nil {
+		t.Fatalf("get signing key failed: %v", err)
+	}
+	if info.Configured {
+		t.Fatalf("signing key should not be configured yet")
+	}
+	if _, err := svc.SetSigningKey(product.ID, "not-a-key"); !errors.Is(err, ErrLicenseSigningKeyInvalid) {
+		t.Fatalf("expected invalid signing key, got %v", err)
+	}
+
+	fulfillment, err := svc.IssueForOrder(order.ID)
+	if err != nil {
+		t.Fatalf("issue license failed: %v", err)
+	}
+	issuedInfo, err := svc.GetSigningKeyInfo(product.ID)
+	if err != nil || !issuedInfo.Configured {
+		t.Fatalf("signing key should be generated on issue: %v", err)
+	}
+
+	rotated, err := svc.SetSigningKey(product.ID, "")
+	if err != nil {
+		t.Fatalf("rotate signing key failed: %v", err)
+	}
+	if rotated.PublicKey == issuedInfo.PublicKey {
+		t.Fatalf("expected rotated public key")
+	}
+	result, err := svc.Verify(LicenseVerifyInput{LicenseKey: fulfillment.Payload, MachineID: "m1"})
+	if err != nil {
+		t.Fatalf("verify after rotation failed: %v", err)
+	}
+	if result.ExpiresAt != nil || result.MaxActivations != 0 {
+		t.Fatalf("expected perpetual unlimited license, got %+v", result)
+	}
+}
+
+func TestLicenseIssueForOrderConcurrentCallsIssueOnce(t *testing.T) {
+	db, svc := setupLicenseServiceTest(t)
+	_, order := createLicenseTestOrder(t, db, 2, 0, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = svc.IssueForOrder(order.ID)
+		}()
+	}
+	wg.Wait()
+
+	var total int64
+	if err := db.Model(&models.License{}).Where("order_id = ?", order.ID).Count(&total).Error; err != nil {
+		t.Fatalf("count licenses failed: %v", err)
+	}
+	if total != 2 {
+		t.Fatalf("expected 2 licenses after concurrent issuance, got %d", total)
+	}
+}
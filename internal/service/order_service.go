@@ -35,6 +35,8 @@ type OrderService struct {
 	affiliateSvc          *AffiliateService
 	memberLevelService    *MemberLevelService
 	riskControlSvc        *OrderRiskControlService
+	catalogWebhookSvc     *CatalogWebhookService
+	resellerPriceListSvc  *ResellerPriceListService
 	expireMinutes         int
 }
 
@@ -82,6 +84,33 @@ func NewOrderService(opts OrderServiceOptions) *OrderService {
 	}
 }
 
+// SetCatalogWebhookService 设置商品目录推送服务（下单占用/取消释放库存后推送给下游）
+func (s *OrderService) SetCatalogWebhookService(svc *CatalogWebhookService) {
+	s.catalogWebhookSvc = svc
+}
+
+// SetResellerPriceListService 设置分销价目表服务（上游 API 下单按价目表定价）
+func (s *OrderService) SetResellerPriceListService(svc *ResellerPriceListService) {
+	s.resellerPriceListSvc = svc
+}
+
+// notifyOrderProducts 推送订单（含子订单）涉及商品的库存变更
+func (s *OrderService) notifyOrderProducts(order *models.Order) {
+	if s.catalogWebhookSvc == nil || order == nil {
+		return
+	}
+	productIDs := make([]uint, 0, len(order.Items))
+	for _, item := range order.Items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	for _, child := range order.Children {
+		for _, item := range child.Items {
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+	s.catalogWebhookSvc.NotifyProductChanged(productIDs...)
+}
+
 // CreateOrderInput 创建订单输入
 type CreateOrderInput struct {
 	UserID              uint
@@ -93,6 +122,7 @@ type CreateOrderInput struct {
 	ManualFormData      map[string]models.JSON
 	SkipRiskControl     bool // 完全跳过风控（下游订单）
 	SkipIPRiskControl   bool // 跳过 IP 维度风控（渠道/Bot 订单）
+	PriceListID         uint // 分销价目表（上游 API 订单），0 表示按零售/会员价
 }
 
 // CreateGuestOrderInput 游客创建订单输入
@@ -181,6 +211,7 @@ func (s *OrderService) CreateOrder(input CreateOrderInput) (*models.Order, error
 		ManualFormData:      input.ManualFormData,
 		SkipRiskControl:     input.SkipRiskControl,
 		SkipIPRiskControl:   input.SkipIPRiskControl,
+		PriceListID:         input.PriceListID,
 	})
 }
 
@@ -224,6 +255,7 @@ type orderCreateParams struct {
 	ManualFormData      map[string]models.JSON
 	SkipRiskControl     bool
 	SkipIPRiskControl   bool
+	PriceListID         uint
 }
 
 // OrderPreview 订单金额预览
@@ -264,6 +296,7 @@ type orderBuildResult struct {
 	Currency                string
 	OrderPromotionID        *uint
 	MemberLevelID           *uint
+	PriceListID             *uint
 	AppliedCoupon           *models.Coupon
 }
 
@@ -280,6 +313,7 @@ func (s *OrderService) PreviewOrder(input CreateOrderInput) (*OrderPreview, erro
 		AffiliateVisitorKey: input.AffiliateVisitorKey,
 		ClientIP:            input.ClientIP,
 		ManualFormData:      input.ManualFormData,
+		PriceListID:         input.PriceListID,
 	})
 }
 
@@ -365,11 +399,11 @@ func (s *OrderService) createOrder(input orderCreateParams) (*models.Order, erro
 		if s.walletService == nil {
 			return nil, ErrWalletOnlyPaymentRequired
 		}
-		account, accErr := s.walletService.GetAccount(input.UserID)
+		spendable, accErr := s.walletService.SpendableBalance(input.UserID, result.Currency)
 		if accErr != nil {
 			return nil, ErrWalletOnlyPaymentRequired
 		}
-		if account.Balance.Decimal.LessThan(result.TotalAmount) {
+		if spendable.LessThan(result.TotalAmount) {
 			return nil, ErrWalletInsufficientBalance
 		}
 	}
@@ -377,13 +411,15 @@ func (s *OrderService) createOrder(input orderCreateParams) (*models.Order, erro
 	affiliateCode := normalizeAffiliateCode(input.AffiliateCode)
 	affiliateVisitorKey := strings.TrimSpace(input.AffiliateVisitorKey)
 	var affiliateProfileID *uint
+	affiliateAttribution := AffiliateOrderAttribution{}
 	if s.affiliateSvc != nil {
-		resolvedID, resolvedCode, resolveErr := s.affiliateSvc.ResolveOrderAffiliateSnapshot(input.UserID, affiliateCode, affiliateVisitorKey)
+		attribution, resolveErr := s.affiliateSvc.ResolveOrderAffiliateAttribution(input.UserID, affiliateCode, affiliateVisitorKey)
 		if resolveErr != nil {
 			return nil, resolveErr
 		}
-		affiliateProfileID = resolvedID
-		affiliateCode = resolvedCode
+		affiliateProfileID = attribution.ProfileID
+		affiliateCode = attribution.Code
+		affiliateAttribution = attribution
 	}
 
 	if len(input.Items) == 0 {
@@ -419,10 +455,15 @@ func (s *OrderService) createOrder(input orderCreateParams) (*models.Order, erro
 		OnlinePaidAmount:        models.NewMoneyFromDecimal(result.TotalAmount),
 		RefundedAmount:          models.NewMoneyFromDecimal(decimal.Zero),
 		MemberLevelID:           result.MemberLevelID,
+		PriceListID:             result.PriceListID,
 		CouponID:                nil,
 		PromotionID:             result.OrderPromotionID,
 		AffiliateProfileID:      affiliateProfileID,
 		AffiliateCode:           affiliateCode,
+		AffiliateSubID:          affiliateAttribution.SubID,
+		AffiliateUTMSource:      affiliateAttribution.UTMSource,
+		AffiliateUTMMedium:      affiliateAttribution.UTMMedium,
+		AffiliateUTMCampaign:    affiliateAttribution.UTMCampaign,
 		ExpiresAt:               &expiresAt,
 		ClientIP:                strings.TrimSpace(input.ClientIP),
 		CreatedAt:               now,
@@ -462,10 +503,15 @@ func (s *OrderService) createOrder(input orderCreateParams) (*models.Order, erro
 				WalletPaidAmount:        models.NewMoneyFromDecimal(decimal.Zero),
 				OnlinePaidAmount:        models.NewMoneyFromDecimal(normalizeOrderAmount(plan.TotalAmount.Sub(plan.CouponDiscount))),
 				RefundedAmount:          models.NewMoneyFromDecimal(decimal.Zero),
+				PriceListID:             result.PriceListID,
 				CouponID:                nil,
 				PromotionID:             plan.Item.PromotionID,
 				AffiliateProfileID:      affiliateProfileID,
 				AffiliateCode:           affiliateCode,
+				AffiliateSubID:          affiliateAttribution.SubID,
+				AffiliateUTMSource:      affiliateAttribution.UTMSource,
+				AffiliateUTMMedium:      affiliateAttribution.UTMMedium,
+				AffiliateUTMCampaign:    affiliateAttribution.UTMCampaign,
 				ExpiresAt:               &expiresAt,
 				ClientIP:                order.ClientIP,
 				CreatedAt:               now,
@@ -575,6 +621,8 @@ func (s *OrderService) createOrder(input orderCreateParams) (*models.Order, erro
 		}
 	}
 
+	s.notifyOrderProducts(order)
+
 	full, err := s.orderRepo.GetByID(order.ID)
 	if err == nil && full != nil {
 		fillOrderItemsFromChildren(full)
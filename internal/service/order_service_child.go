@@ -107,6 +107,7 @@ func (s *OrderService) cancelOrderWithChildren(order *models.Order, rollbackCoup
 		order.Children[i].CanceledAt = &now
 		order.Children[i].UpdatedAt = now
 	}
+	s.notifyOrderProducts(order)
 	return nil
 }
 
@@ -286,6 +286,11 @@ func (s *OrderRefundService) AdminManualRefund(input AdminManualRefundInput) (*m
 				return ErrOrderUpdateFailed
 			}
 		}
+		if markRefunded {
+			if err := revokeOrderLicensesTx(tx, order.ID, now); err != nil {
+				return err
+			}
+		}
 		if s.affiliateSvc != nil && order.UserID > 0 {
 			if err := s.affiliateSvc.HandleOrderRefundedTx(
 				tx,
@@ -35,6 +35,7 @@ func setupOrderRefundServiceTest(t *testing.T) (*OrderRefundService, *gorm.DB) {
 		&models.WalletAccount{},
 		&models.WalletTransaction{},
 		&models.OrderRefundRecord{},
+		&models.License{},
 		&models.Setting{},
 	); err != nil {
 		t.Fatalf("auto migrate failed: %v", err)
@@ -564,3 +565,64 @@ func TestOrderRefundServiceResolveStatusEmailRefundDetails(t *testing.T) {
 		t.Fatalf("unexpected fallback from nil service: %+v", fallbackFromNilSvc)
 	}
 }
+
+func TestOrderRefundServiceAdminManualRefundRevokesLicensesOnFullRefund(t *testing.T) {
+	svc, db := setupOrderRefundServiceTest(t)
+	now := time.Now()
+	order := &models.Order{
+		OrderNo:          "REFUND-MANUAL-LICENSE-001",
+		UserID:           0,
+		GuestEmail:       "guest-refund-license@example.com",
+		Status:           constants.OrderStatusCompleted,
+		Currency:         "CNY",
+		OriginalAmount:   models.NewMoneyFromDecimal(decimal.NewFromInt(50)),
+		TotalAmount:      models.NewMoneyFromDecimal(decimal.NewFromInt(50)),
+		OnlinePaidAmount: models.NewMoneyFromDecimal(decimal.NewFromInt(50)),
+		PaidAt:           &now,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if err := db.Create(order).Error; err != nil {
+		t.Fatalf("create order failed: %v", err)
+	}
+	license := &models.License{
+		Serial:     "REFUND-LICENSE-SERIAL",
+		OrderID:    order.ID,
+		OrderNo:    order.OrderNo,
+		ProductID:  1,
+		LicenseKey: "refund-license-key",
+		Status:     constants.LicenseStatusActive,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := db.Create(license).Error; err != nil {
+		t.Fatalf("create license failed: %v", err)
+	}
+
+	if _, _, err := svc.AdminManualRefund(AdminManualRefundInput{
+		OrderID: order.ID,
+		Amount:  models.NewMoneyFromDecimal(decimal.NewFromInt(20)),
+	}); err != nil {
+		t.Fatalf("partial refund failed: %v", err)
+	}
+	var reloaded models.License
+	if err := db.First(&reloaded, license.ID).Error; err != nil {
+		t.Fatalf("reload license failed: %v", err)
+	}
+	if reloaded.Status != constants.LicenseStatusActive {
+		t.Fatalf("partial refund should keep license active, got %s", reloaded.Status)
+	}
+
+	if _, _, err := svc.AdminManualRefund(AdminManualRefundInput{
+		OrderID: order.ID,
+		Amount:  models.NewMoneyFromDecimal(decimal.NewFromInt(30)),
+	}); err != nil {
+		t.Fatalf("full refund failed: %v", err)
+	}
+	if err := db.First(&reloaded, license.ID).Error; err != nil {
+		t.Fatalf("reload license failed: %v", err)
+	}
+	if reloaded.Status != constants.LicenseStatusRevoked || reloaded.RevokedAt == nil {
+		t.Fatalf("expected license revoked after full refund, got %+v", reloaded)
+	}
+}
@@ -56,6 +56,20 @@ func (s *OrderService) buildOrderResult(input orderCreateParams) (*orderBuildRes
 		}
 	}
 
+	// 解析分销价目表（上游 API 订单）
+	var resellerPricing *ResellerPricing
+	if input.PriceListID > 0 && s.resellerPriceListSvc != nil {
+		resellerPricing, err = s.resellerPriceListSvc.LoadPricing(input.PriceListID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var priceListIDSnapshot *uint
+	if resellerPricing != nil {
+		plid := resellerPricing.ListID()
+		priceListIDSnapshot = &plid
+	}
+
 	promotionService := NewPromotionService(s.promotionRepo)
 	manualFormData := input.ManualFormData
 	if manualFormData == nil {
@@ -99,10 +113,12 @@ func (s *OrderService) buildOrderResult(input orderCreateParams) (*orderBuildRes
 		}
 		promoUnitPriceAmount := promoUnitPrice.Decimal.Round(2)
 
-		// 2. 计算会员价
+		// 2. 计算会员价（命中分销价目表时以分销价代替会员价）
 		memberUnitPrice := basePrice
 		itemMemberDiscount := decimal.Zero
-		if userMemberLevelID > 0 && s.memberLevelService != nil {
+		if resellerPrice, ok := resellerPricing.Resolve(product.ID, sku.ID, basePrice); ok {
+			memberUnitPrice = resellerPrice
+		} else if userMemberLevelID > 0 && s.memberLevelService != nil {
 			memberUnitPrice, _ = s.memberLevelService.ResolveMemberPrice(userMemberLevelID, product.ID, sku.ID, basePrice)
 		}
 
@@ -136,7 +152,9 @@ func (s *OrderService) buildOrderResult(input orderCreateParams) (*orderBuildRes
 		if fulfillmentType == "" {
 			fulfillmentType = constants.FulfillmentTypeManual
 		}
-		if fulfillmentType != constants.FulfillmentTypeManual && fulfillmentType != constants.FulfillmentTypeAuto && fulfillmentType != constants.FulfillmentTypeUpstream {
+		if fulfillmentType != constants.FulfillmentTypeManual && fulfillmentType != constants.FulfillmentTypeAuto &&
+			fulfillmentType != constants.FulfillmentTypeUpstream && fulfillmentType != constants.FulfillmentTypeWebhook &&
+			fulfillmentType != constants.FulfillmentTypeLicense && fulfillmentType != constants.FulfillmentTypeFile {
 			return nil, ErrFulfillmentInvalid
 		}
 		if fulfillmentType == constants.FulfillmentTypeManual &&
@@ -148,7 +166,8 @@ func (s *OrderService) buildOrderResult(input orderCreateParams) (*orderBuildRes
 		manualSchemaSnapshot := models.JSON{}
 		manualSubmission := models.JSON{}
 		if fulfillmentType == constants.FulfillmentTypeManual ||
-			(fulfillmentType == constants.FulfillmentTypeUpstream && len(product.ManualFormSchemaJSON) > 0) {
+			((fulfillmentType == constants.FulfillmentTypeUpstream || fulfillmentType == constants.FulfillmentTypeWebhook) &&
+				len(product.ManualFormSchemaJSON) > 0) {
 			submission := resolveManualFormSubmission(manualFormData, product.ID, sku.ID)
 			normalizedSchema, normalizedSubmission, err := validateAndNormalizeManualForm(product.ManualFormSchemaJSON, submission)
 			if err != nil {
@@ -195,6 +214,8 @@ func (s *OrderService) buildOrderResult(input orderCreateParams) (*orderBuildRes
 			ManualFormSchemaSnapshotJSON: manualSchemaSnapshot,
 			ManualFormSubmissionJSON:     manualSubmission,
 			InstructionsJSON:             product.InstructionsJSON,
+			DeliveryMode:                 NormalizeDeliveryMode(product.DeliveryMode),
+			DeliveryRevealLimit:          NormalizeDeliveryRevealLimit(product.DeliveryRevealLimit),
 			CreatedAt:                    now,
 			UpdatedAt:                    now,
 		}
@@ -276,6 +297,7 @@ func (s *OrderService) buildOrderResult(input orderCreateParams) (*orderBuildRes
 		Currency:                currency,
 		OrderPromotionID:        orderPromotionID,
 		MemberLevelID:           memberLevelIDSnapshot,
+		PriceListID:             priceListIDSnapshot,
 		AppliedCoupon:           appliedCoupon,
 	}, nil
 }
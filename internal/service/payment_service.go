@@ -40,6 +40,7 @@ type PaymentService struct {
 	procurementSvc        *ProcurementOrderService
 	downstreamCallbackSvc *DownstreamCallbackService
 	memberLevelSvc        *MemberLevelService
+	webhookFulfillmentSvc *FulfillmentWebhookService
 }
 
 // SetProcurementService 设置采购单服务（解决循环依赖）
@@ -52,6 +53,11 @@ func (s *PaymentService) SetDownstreamCallbackService(svc *DownstreamCallbackSer
 	s.downstreamCallbackSvc = svc
 }
 
+// SetWebhookFulfillmentService 设置 Webhook 交付服务（解决循环依赖）
+func (s *PaymentService) SetWebhookFulfillmentService(svc *FulfillmentWebhookService) {
+	s.webhookFulfillmentSvc = svc
+}
+
 // SetMemberLevelService 设置会员等级服务
 func (s *PaymentService) SetMemberLevelService(svc *MemberLevelService) {
 	s.memberLevelSvc = svc
@@ -535,6 +541,9 @@ func (s *PaymentService) CreateWalletRechargePayment(input CreateWalletRechargeP
 	}
 	payableAmount := amount.Add(feeAmount).Round(2)
 	currency := normalizeWalletCurrency(input.Currency)
+	if !s.settingService.GetWalletCurrencyConfig().AllowsCurrency(currency) {
+		return nil, ErrWalletCurrencyInvalid
+	}
 	if err := validatePaymentCurrencyForChannel(currency, channel); err != nil {
 		return nil, err
 	}
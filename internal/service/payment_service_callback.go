@@ -545,6 +545,15 @@ func (s *PaymentService) enqueueOrderPaidAsync(order *models.Order, payment *mod
 					)
 				}
 			}
+			if shouldWebhookFulfill(&child) {
+				s.enqueueWebhookFulfillmentAsync(&child, log)
+			}
+			if shouldLicenseFulfill(&child) {
+				s.enqueueLicenseIssueAsync(&child, log)
+			}
+			if shouldFileFulfill(&child) {
+				s.enqueueFileDeliverAsync(&child, log)
+			}
 		}
 		// 上游采购：为包含上游交付类型的订单创建采购单
 		s.enqueueProcurementAsync(order, log)
@@ -566,6 +575,15 @@ func (s *PaymentService) enqueueOrderPaidAsync(order *models.Order, payment *mod
 			)
 		}
 	}
+	if shouldWebhookFulfill(order) {
+		s.enqueueWebhookFulfillmentAsync(order, log)
+	}
+	if shouldLicenseFulfill(order) {
+		s.enqueueLicenseIssueAsync(order, log)
+	}
+	if shouldFileFulfill(order) {
+		s.enqueueFileDeliverAsync(order, log)
+	}
 	// 上游采购：为包含上游交付类型的订单创建采购单
 	s.enqueueProcurementAsync(order, log)
 	// B 侧：订单支付成功后检查是否需要回调下游
@@ -588,6 +606,46 @@ func (s *PaymentService) enqueueProcurementAsync(order *models.Order, log *zap.S
 	}
 }
 
+// enqueueWebhookFulfillmentAsync 订单全部为 Webhook 交付时创建推送任务
+func (s *PaymentService) enqueueWebhookFulfillmentAsync(order *models.Order, log *zap.SugaredLogger) {
+	if s.webhookFulfillmentSvc == nil || order == nil {
+		return
+	}
+	if err := s.webhookFulfillmentSvc.Start(order); err != nil {
+		log.Warnw("payment_enqueue_webhook_fulfill_failed",
+			"order_id", order.ID,
+			"order_no", order.OrderNo,
+			"error", err,
+		)
+	}
+}
+
+// enqueueLicenseIssueAsync 订单全部为授权码交付时入队签发任务
+func (s *PaymentService) enqueueLicenseIssueAsync(order *models.Order, log *zap.SugaredLogger) {
+	if err := s.queueClient.EnqueueOrderLicenseIssue(queue.OrderLicenseIssuePayload{
+		OrderID: order.ID,
+	}, asynq.MaxRetry(3)); err != nil {
+		log.Warnw("payment_enqueue_license_issue_failed",
+			"order_id", order.ID,
+			"order_no", order.OrderNo,
+			"error", err,
+		)
+	}
+}
+
+// enqueueFileDeliverAsync 订单全部为文件交付时入队下载授权签发任务
+func (s *PaymentService) enqueueFileDeliverAsync(order *models.Order, log *zap.SugaredLogger) {
+	if err := s.queueClient.EnqueueOrderFileDeliver(queue.OrderFileDeliverPayload{
+		OrderID: order.ID,
+	}, asynq.MaxRetry(3)); err != nil {
+		log.Warnw("payment_enqueue_file_deliver_failed",
+			"order_id", order.ID,
+			"order_no", order.OrderNo,
+			"error", err,
+		)
+	}
+}
+
 // enqueueDownstreamCallbackAsync B 侧：通知下游 A 站点订单已支付
 func (s *PaymentService) enqueueDownstreamCallbackAsync(order *models.Order, log *zap.SugaredLogger) {
 	if s.downstreamCallbackSvc == nil || order == nil {
@@ -958,7 +1016,7 @@ func notificationFulfillmentLabel(locale, fulfillmentType string) string {
 
 func normalizeNotificationFulfillmentType(fulfillmentType string) string {
 	switch strings.ToLower(strings.TrimSpace(fulfillmentType)) {
-	case constants.FulfillmentTypeAuto:
+	case constants.FulfillmentTypeAuto, constants.FulfillmentTypeWebhook, constants.FulfillmentTypeLicense, constants.FulfillmentTypeFile:
 		return constants.FulfillmentTypeAuto
 	case constants.FulfillmentTypeUpstream:
 		return constants.FulfillmentTypeUpstream
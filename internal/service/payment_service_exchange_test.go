@@ -119,6 +119,8 @@ func setupExchangeTest(t *testing.T) (*PaymentService, *gorm.DB) {
 		&models.WalletAccount{},
 		&models.WalletTransaction{},
 		&models.WalletRechargeOrder{},
+		&models.WalletRechargeBonusRule{},
+		&models.WalletRechargeBonus{},
 		&models.PaymentChannel{},
 		&models.Payment{},
 	); err != nil {
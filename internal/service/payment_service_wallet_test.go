@@ -31,6 +31,8 @@ func setupPaymentServiceWalletTest(t *testing.T) (*PaymentService, *gorm.DB) {
 		&models.WalletAccount{},
 		&models.WalletTransaction{},
 		&models.WalletRechargeOrder{},
+		&models.WalletRechargeBonusRule{},
+		&models.WalletRechargeBonus{},
 		&models.PaymentChannel{},
 		&models.Payment{},
 	); err != nil {
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dujiao-next/internal/constants"
+
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// ErrPaymentStatementInvalid 对账单格式不支持或内容无法解析
+var ErrPaymentStatementInvalid = errors.New("payment statement is invalid")
+
+// PaymentStatementRow 网关对账单中的一笔收款
+type PaymentStatementRow struct {
+	ProviderRef    string          // 网关流水号（支付宝交易号 / 微信订单号 / PaymentIntent / PayPal 交易号）
+	GatewayOrderNo string          // 商户订单号（即本站 Payment.GatewayOrderNo）
+	Amount         decimal.Decimal // 收款金额
+	Currency       string          // 币种，为空表示与本站一致
+	Status         string          // 映射后的支付状态
+	TradeAt        *time.Time      // 交易时间
+}
+
+// paymentStatementLayout 对账单表头映射：每个字段按候选列名依次匹配（忽略大小写与空白）
+type paymentStatementLayout struct {
+	headerMarker string   // 表头行必须包含的列名
+	footerPrefix []string // 遇到以该前缀开头的行即停止（汇总区）
+	providerRef  []string
+	orderNo      []string
+	amount       []string
+	currency     []string
+	status       []string
+	tradeAt      []string
+	kind         []string
+	// acceptRow 根据类型/状态列判断是否为收款记录，并返回映射后的支付状态
+	acceptRow func(kind, status string) (string, bool)
+}
+
+var paymentStatementLayouts = map[string]paymentStatementLayout{
+	constants.PaymentStatementFormatAlipay: {
+		headerMarker: "商户订单号",
+		footerPrefix: []string{"#"},
+		providerRef:  []string{"支付宝交易号"},
+		orderNo:      []string{"商户订单号"},
+		amount:       []string{"订单金额（元）", "订单金额(元)", "收入（+元）", "收入(+元)"},
+		tradeAt:      []string{"完成时间", "入账时间", "创建时间"},
+		kind:         []string{"业务类型"},
+		acceptRow: func(kind, _ string) (string, bool) {
+			return constants.PaymentStatusSuccess, kind == "" || kind == "交易" || strings.EqualFold(kind, "trade")
+		},
+	},
+	constants.PaymentStatementFormatWechat: {
+		headerMarker: "商户订单号",
+		footerPrefix: []string{"总交易单数", "总交易笔数"},
+		providerRef:  []string{"微信订单号"},
+		orderNo:      []string{"商户订单号"},
+		amount:       []string{"订单金额", "应结订单金额", "应结订单总金额"},
+		currency:     []string{"货币种类"},
+		tradeAt:      []string{"交易时间"},
+		status:       []string{"交易状态"},
+		acceptRow: func(_, status string) (string, bool) {
+			return constants.PaymentStatusSuccess, strings.EqualFold(status, "SUCCESS")
+		},
+	},
+	constants.PaymentStatementFormatStripe: {
+		headerMarker: "amount",
+		providerRef:  []string{"payment_intent_id", "source", "source_id"},
+		orderNo:      []string{"payment_metadata[order_no]", "metadata[order_no]", "order_no (metadata)"},
+		amount:       []string{"gross", "amount"},
+		currency:     []string{"currency"},
+		tradeAt:      []string{"created (utc)", "created_utc", "created"},
+		kind:         []string{"reporting_category", "type"},
+		acceptRow: func(kind, _ string) (string, bool) {
+			kind = strings.ToLower(kind)
+			return constants.PaymentStatusSuccess, kind == "charge" || kind == "payment"
+		},
+	},
+	constants.PaymentStatementFormatPaypal: {
+		headerMarker: "transaction id",
+		providerRef:  []string{"transaction id"},
+		orderNo:      []string{"invoice number", "invoice id"},
+		amount:       []string{"gross"},
+		currency:     []string{"currency"},
+		tradeAt:      []string{"date"},
+		status:       []string{"status"},
+		kind:         []string{"type"},
+		acceptRow: func(kind, status string) (string, bool) {
+			// 货币兑换、转账等记录也可能为正数，只保留付款类交易
+			if kind != "" && !strings.Contains(strings.ToLower(kind), "payment") {
+				return "", false
+			}
+			switch strings.ToLower(status) {
+			case "completed":
+				return constants.PaymentStatusSuccess, true
+			case "pending":
+				return constants.PaymentStatusPending, true
+			default:
+				return "", false
+			}
+		},
+	},
+}
+
+// ParsePaymentStatement 解析网关对账单 CSV，仅返回收款记录（退款、手续费等出账行被忽略）。
+// GBK 编码（支付宝 / 微信下载的账单）会自动转为 UTF-8。
+func ParsePaymentStatement(format string, r io.Reader) ([]PaymentStatementRow, error) {
+	layout, ok := paymentStatementLayouts[strings.ToLower(strings.TrimSpace(format))]
+	if !ok || r == nil {
+		return nil, ErrPaymentStatementInvalid
+	}
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, ErrPaymentStatementInvalid
+	}
+	if !utf8.Valid(raw) {
+		if decoded, decodeErr := simplifiedchinese.GB18030.NewDecoder().Bytes(raw); decodeErr == nil {
+			raw = decoded
+		}
+	}
+	raw = bytes.TrimPrefix(raw, []byte("\ufeff"))
+
+	reader := csv.NewReader(bytes.NewReader(raw))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var header map[string]int
+	rows := make([]PaymentStatementRow, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrPaymentStatementInvalid
+		}
+		if len(record) == 0 {
+			continue
+		}
+		first := normalizeStatementCell(record[0])
+		if header == nil {
+			if strings.HasPrefix(first, "#") {
+				continue
+			}
+			candidate := make(map[string]int, len(record))
+			for i, cell := range record {
+				candidate[strings.ToLower(normalizeStatementCell(cell))] = i
+			}
+			if _, ok := candidate[strings.ToLower(layout.headerMarker)]; ok {
+				header = candidate
+			}
+			continue
+		}
+		if statementFooterReached(first, layout.footerPrefix) {
+			break
+		}
+
+		cell := func(names []string) string {
+			for _, name := range names {
+				if idx, ok := header[strings.ToLower(name)]; ok && idx < len(record) {
+					return normalizeStatementCell(record[idx])
+				}
+			}
+			return ""
+		}
+		status, accept := layout.acceptRow(cell(layout.kind), cell(layout.status))
+		if !accept {
+			continue
+		}
+		amount, err := decimal.NewFromString(strings.ReplaceAll(cell(layout.amount), ",", ""))
+		if err != nil || !amount.IsPositive() {
+			continue
+		}
+		row := PaymentStatementRow{
+			ProviderRef:    cell(layout.providerRef),
+			GatewayOrderNo: cell(layout.orderNo),
+			Amount:         amount,
+			Currency:       strings.ToUpper(cell(layout.currency)),
+			Status:         status,
+			TradeAt:        parseStatementTime(cell(layout.tradeAt)),
+		}
+		if row.ProviderRef == "" && row.GatewayOrderNo == "" {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	if header == nil {
+		return nil, ErrPaymentStatementInvalid
+	}
+	return rows, nil
+}
+
+// normalizeStatementCell 去除空白、BOM 以及微信账单中防止科学计数法的反引号前缀
+func normalizeStatementCell(value string) string {
+	value = strings.TrimSpace(strings.TrimPrefix(value, "\ufeff"))
+	value = strings.TrimPrefix(value, "`")
+	return strings.TrimSpace(value)
+}
+
+func statementFooterReached(first string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(first, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStatementTime 解析各网关对账单中常见的时间格式，失败返回 nil
+func parseStatementTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	layouts := []string{
+		"2006-01-02 15:04:05",
+		"2006/01/02 15:04:05",
+		"2006-01-02 15:04",
+		time.RFC3339,
+		"01/02/2006",
+		"2006-01-02",
+	}
+	for _, layout := range layouts {
+		if parsed, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return &parsed
+		}
+	}
+	return nil
+}
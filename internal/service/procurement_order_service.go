@@ -34,6 +34,7 @@ type ProcurementOrderService struct {
 	orderRepo             repository.OrderRepository
 	mappingRepo           repository.ProductMappingRepository
 	skuMapRepo            repository.SKUMappingRepository
+	skuSourceRepo         repository.SKUSourceRepository
 	connSvc               *SiteConnectionService
 	queueClient           *queue.Client
 	settingService        *SettingService
@@ -41,6 +42,7 @@ type ProcurementOrderService struct {
 	fulfillSvc            *FulfillmentService
 	downstreamCallbackSvc *DownstreamCallbackService
 	notificationSvc       *NotificationService
+	replenishSvc          *CardReplenishService
 }
 
 // SetDownstreamCallbackService 设置下游回调服务（解决循环依赖）
@@ -53,6 +55,11 @@ func (s *ProcurementOrderService) SetNotificationService(svc *NotificationServic
 	s.notificationSvc = svc
 }
 
+// SetCardReplenishService 设置卡密自动补货服务（解决循环依赖）
+func (s *ProcurementOrderService) SetCardReplenishService(svc *CardReplenishService) {
+	s.replenishSvc = svc
+}
+
 // NewProcurementOrderService 创建采购单服务
 func NewProcurementOrderService(
 	procRepo repository.ProcurementOrderRepository,
@@ -64,6 +71,7 @@ func NewProcurementOrderService(
 	settingService *SettingService,
 	defaultEmailConfig config.EmailConfig,
 	fulfillSvc *FulfillmentService,
+	skuSourceRepo repository.SKUSourceRepository,
 ) *ProcurementOrderService {
 	return &ProcurementOrderService{
 		procRepo:           procRepo,
@@ -75,6 +83,7 @@ func NewProcurementOrderService(
 		settingService:     settingService,
 		defaultEmailConfig: defaultEmailConfig,
 		fulfillSvc:         fulfillSvc,
+		skuSourceRepo:      skuSourceRepo,
 	}
 }
 
@@ -222,6 +231,18 @@ func (s *ProcurementOrderService) SubmitToUpstream(procurementOrderID uint) erro
 	}
 	item := localOrder.Items[0]
 
+	// 配置了多来源的 SKU 按优先级逐个尝试
+	if s.skuSourceRepo != nil {
+		sources, err := s.skuSourceRepo.ListActiveByLocalSKUID(item.SKUID)
+		if err != nil {
+			s.markProcurementError(procOrder, fmt.Sprintf("load sku sources failed: %v", err))
+			return fmt.Errorf("load sku sources: %w", err)
+		}
+		if len(sources) > 0 {
+			return s.submitWithSources(procOrder, conn, localOrder, &item, sources)
+		}
+	}
+
 	// 查找 SKU 映射
 	skuMapping, err := s.skuMapRepo.GetByLocalSKUID(item.SKUID)
 	if err != nil {
@@ -233,6 +254,12 @@ func (s *ProcurementOrderService) SubmitToUpstream(procurementOrderID uint) erro
 		return nil // 永久性错误，不重试
 	}
 
+	// 刷新后的上游余额仍不足时直接拒绝，不再向上游下单（与上游返回 insufficient_balance 的处理一致）
+	required := skuMapping.UpstreamPrice.Decimal.Mul(decimal.NewFromInt(int64(item.Quantity)))
+	if err := s.ensureUpstreamBalance(conn, adapter, required); err != nil {
+		return s.handleSubmitFailure(procOrder, conn, err.Error(), false)
+	}
+
 	// 构建上游请求
 	req := upstream.CreateUpstreamOrderReq{
 		SKUID:             skuMapping.UpstreamSKUID,
@@ -264,7 +291,11 @@ func (s *ProcurementOrderService) SubmitToUpstream(procurementOrderID uint) erro
 		return s.handleSubmitFailure(procOrder, conn, errMsg, retryable)
 	}
 
-	// 成功：更新状态，重置 retry_count 用于轮询阶段
+	return s.markSubmitAccepted(procOrder, localOrder, resp, nil)
+}
+
+// markSubmitAccepted 上游受理成功：更新状态，重置 retry_count 用于轮询阶段
+func (s *ProcurementOrderService) markSubmitAccepted(procOrder *models.ProcurementOrder, localOrder *models.Order, resp *upstream.CreateUpstreamOrderResp, extra map[string]interface{}) error {
 	now := time.Now()
 	updates := map[string]interface{}{
 		"upstream_order_id": resp.OrderID,
@@ -275,6 +306,9 @@ func (s *ProcurementOrderService) SubmitToUpstream(procurementOrderID uint) erro
 		"retry_count":       0,
 		"updated_at":        now,
 	}
+	for key, val := range extra {
+		updates[key] = val
+	}
 	if err := s.procRepo.UpdateStatus(procOrder.ID, "accepted", updates); err != nil {
 		return fmt.Errorf("update procurement status: %w", err)
 	}
@@ -370,6 +404,23 @@ func (s *ProcurementOrderService) notifyProcurementFailure(procOrder *models.Pro
 	})
 }
 
+// ensureUpstreamBalance 缓存余额显示不足时先经 Ping 刷新再判断，避免因过时余额误拒；刷新失败时放行由上游裁决
+func (s *ProcurementOrderService) ensureUpstreamBalance(conn *models.SiteConnection, adapter upstream.Adapter, required decimal.Decimal) error {
+	if err := checkUpstreamBalance(conn, required, time.Now()); err == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := s.connSvc.RefreshBalance(ctx, conn, adapter); err != nil {
+		logger.Warnw("procurement_refresh_upstream_balance_failed",
+			"connection_id", conn.ID,
+			"error", err,
+		)
+		return nil
+	}
+	return checkUpstreamBalance(conn, required, time.Now())
+}
+
 // handleSubmitFailure 处理提交失败
 func (s *ProcurementOrderService) handleSubmitFailure(procOrder *models.ProcurementOrder, conn *models.SiteConnection, errMsg string, retryable bool) error {
 	now := time.Now()
@@ -445,6 +496,14 @@ func (s *ProcurementOrderService) HandleUpstreamCallback(procurementOrderID uint
 
 	switch upstreamStatus {
 	case "delivered", "completed", "fulfilled":
+		// 自动补货采购单：交付内容导入卡密库存，不涉及本地订单
+		if procOrder.ReplenishRuleID != nil {
+			if s.replenishSvc == nil {
+				return fmt.Errorf("card replenish service unavailable for procurement %d", procOrder.ID)
+			}
+			return s.replenishSvc.ImportDelivery(procOrder, fulfillment)
+		}
+
 		// 更新采购单状态
 		updates := map[string]interface{}{
 			"updated_at": now,
@@ -605,6 +664,10 @@ func (s *ProcurementOrderService) PollUpstreamStatus(procurementOrderID uint) er
 	if procOrder.Status != "accepted" {
 		return nil
 	}
+	// 上游只返回了非数字订单号时无法按ID查询，依赖上游回调更新状态
+	if procOrder.UpstreamOrderID == 0 {
+		return nil
+	}
 
 	conn, err := s.connSvc.GetByID(procOrder.ConnectionID)
 	if err != nil {
@@ -1080,6 +1143,10 @@ func (s *ProcurementOrderService) RetryManual(id uint) error {
 	if procOrder.Status != "failed" && procOrder.Status != "rejected" {
 		return ErrProcurementStatusInvalid
 	}
+	// 补货采购单由补货巡检重新评估下单，不走手动重试
+	if procOrder.ReplenishRuleID != nil {
+		return ErrProcurementStatusInvalid
+	}
 
 	now := time.Now()
 	updates := map[string]interface{}{
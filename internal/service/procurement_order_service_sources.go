@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/logger"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/upstream"
+
+	"github.com/shopspring/decimal"
+)
+
+// skuSourceOutcome 单个来源的尝试结果
+type skuSourceOutcome struct {
+	resp      *upstream.CreateUpstreamOrderResp // 上游受理结果（upstream 来源成功时）
+	delivered bool                              // 已由本地卡密库存完成交付
+	reason    string                            // 跳过或失败原因
+	retryable bool                              // 该来源的失败稍后重试可能成功
+	terminal  bool                              // 订单本身的问题，不再尝试其他来源
+	ambiguous bool                              // 请求结果未知（超时、网络错误或上游内部错误），上游可能已建单
+}
+
+// skuSourceStopCodes 属于订单本身的上游错误码，换来源也无法成功
+var skuSourceStopCodes = map[string]bool{
+	"invalid_request": true,
+	"duplicate_order": true,
+}
+
+// skuSourceFailoverCodes 上游明确拒绝且未保留订单的错误码，可安全转向下一个来源；
+// 其他错误码（如 internal_error）无法确认上游是否已建单，只能在原来源上重试
+var skuSourceFailoverCodes = map[string]bool{
+	upstream.ErrorCodeInsufficientStock:  true,
+	"product_out_of_stock":               true,
+	upstream.ErrorCodeProductNotFound:    true,
+	upstream.ErrorCodeProductUnavailable: true,
+	upstream.ErrorCodeSKUUnavailable:     true,
+	upstream.ErrorCodeInsufficientFunds:  true,
+	upstream.ErrorCodePaymentFailed:      true,
+	upstream.ErrorCodeQuotaExceeded:      true,
+	upstream.ErrorCodeRateLimitExceeded:  true,
+	upstream.ErrorCodeMissingAuthHeaders: true,
+	upstream.ErrorCodeInvalidTimestamp:   true,
+	upstream.ErrorCodeTimestampExpired:   true,
+	upstream.ErrorCodeInvalidApiKey:      true,
+	upstream.ErrorCodeUserDisabled:       true,
+	upstream.ErrorCodeInvalidSignature:   true,
+	upstream.ErrorCodeIPNotAllowed:       true,
+	upstream.ErrorCodeInsufficientScope:  true,
+	upstream.ErrorCodeUnauthorized:       true,
+	"forbidden":                          true,
+}
+
+// submitWithSources 按优先级依次尝试 SKU 的各个供货来源
+// 缺货、单价超过上限等明确拒绝时转向下一个来源；请求结果未知时锁定该来源，后续重试只在该来源上进行
+// （上游按下游订单号幂等返回已有订单），避免重复采购；全部来源失败时按是否存在可重试失败决定重试或拒绝
+func (s *ProcurementOrderService) submitWithSources(
+	procOrder *models.ProcurementOrder,
+	conn *models.SiteConnection,
+	localOrder *models.Order,
+	item *models.OrderItem,
+	sources []models.SKUSource,
+) error {
+	if procOrder.SKUSourceID != nil {
+		pinned, err := s.resolvePinnedSKUSource(*procOrder.SKUSourceID, sources)
+		if err != nil {
+			s.markProcurementError(procOrder, fmt.Sprintf("load pinned sku source failed: %v", err))
+			return fmt.Errorf("load pinned sku source: %w", err)
+		}
+		if pinned == nil {
+			return s.handleSubmitFailure(procOrder, conn, fmt.Sprintf("pinned sku source %d not found", *procOrder.SKUSourceID), false)
+		}
+		sources = []models.SKUSource{*pinned}
+	}
+
+	attempts := make([]string, 0, len(sources))
+	retryable := false
+	for i := range sources {
+		source := &sources[i]
+		outcome := s.trySKUSource(procOrder, localOrder, item, source, procOrder.SKUSourceID != nil)
+		now := time.Now()
+
+		if outcome.resp != nil || outcome.delivered {
+			attempts = append(attempts, fmt.Sprintf("#%d %s: ok", source.ID, describeSKUSource(source)))
+			_ = s.skuSourceRepo.UpdateUsage(source.ID, map[string]interface{}{
+				"last_used_at": &now,
+				"last_error":   "",
+			})
+			logger.Infow("procurement_sku_source_selected",
+				"procurement_order_id", procOrder.ID,
+				"sku_source_id", source.ID,
+				"source_type", source.SourceType,
+				"attempts", len(attempts),
+			)
+			extra := map[string]interface{}{
+				"sku_source_id":   source.ID,
+				"source_type":     source.SourceType,
+				"source_attempts": strings.Join(attempts, "\n"),
+			}
+			if outcome.delivered {
+				extra["error_message"] = ""
+				extra["updated_at"] = now
+				return s.procRepo.UpdateStatus(procOrder.ID, constants.ProcurementStatusFulfilled, extra)
+			}
+			extra["connection_id"] = source.ConnectionID
+			return s.markSubmitAccepted(procOrder, localOrder, outcome.resp, extra)
+		}
+
+		// 原因中可能带有上游响应体的换行，压缩为单行便于逐行展示
+		outcome.reason = strings.Join(strings.Fields(outcome.reason), " ")
+		attempts = append(attempts, fmt.Sprintf("#%d %s: %s", source.ID, describeSKUSource(source), outcome.reason))
+		_ = s.skuSourceRepo.UpdateUsage(source.ID, map[string]interface{}{
+			"last_error": truncateSKUSourceError(outcome.reason),
+		})
+		logger.Warnw("procurement_sku_source_skipped",
+			"procurement_order_id", procOrder.ID,
+			"sku_source_id", source.ID,
+			"source_type", source.SourceType,
+			"reason", outcome.reason,
+		)
+		if outcome.ambiguous {
+			sourceID := source.ID
+			procOrder.SKUSourceID = &sourceID
+			_ = s.procRepo.UpdateStatus(procOrder.ID, procOrder.Status, map[string]interface{}{
+				"sku_source_id":   source.ID,
+				"source_attempts": strings.Join(attempts, "\n"),
+			})
+			return s.handleSubmitFailure(procOrder, conn, outcome.reason, true)
+		}
+		if outcome.terminal {
+			_ = s.procRepo.UpdateStatus(procOrder.ID, procOrder.Status, map[string]interface{}{
+				"source_attempts": strings.Join(attempts, "\n"),
+			})
+			return s.handleSubmitFailure(procOrder, conn, outcome.reason, false)
+		}
+		retryable = retryable || outcome.retryable
+	}
+
+	_ = s.procRepo.UpdateStatus(procOrder.ID, procOrder.Status, map[string]interface{}{
+		"source_attempts": strings.Join(attempts, "\n"),
+	})
+	return s.handleSubmitFailure(procOrder, conn, "all sku sources failed: "+strings.Join(attempts, "; "), retryable)
+}
+
+// trySKUSource 尝试单个来源；pinned 为锁定重试时跳过价格、库存与余额预检，直接按下游订单号确认结果
+func (s *ProcurementOrderService) trySKUSource(
+	procOrder *models.ProcurementOrder,
+	localOrder *models.Order,
+	item *models.OrderItem,
+	source *models.SKUSource,
+	pinned bool,
+) skuSourceOutcome {
+	if source.SourceType == constants.SKUSourceTypeCardPool {
+		if s.fulfillSvc == nil {
+			return skuSourceOutcome{reason: "card pool unavailable"}
+		}
+		if _, err := s.fulfillSvc.CreateFromCardPool(localOrder.ID); err != nil {
+			if errors.Is(err, ErrCardSecretInsufficient) {
+				return skuSourceOutcome{reason: "card pool out of stock", retryable: true}
+			}
+			return skuSourceOutcome{reason: fmt.Sprintf("card pool delivery failed: %v", err), retryable: true}
+		}
+		return skuSourceOutcome{delivered: true}
+	}
+
+	conn, err := s.connSvc.GetByID(source.ConnectionID)
+	if err != nil {
+		return skuSourceOutcome{reason: fmt.Sprintf("load connection failed: %v", err), retryable: true}
+	}
+	if conn == nil || conn.Status == constants.ConnectionStatusDisabled {
+		return skuSourceOutcome{reason: "connection unavailable"}
+	}
+	adapter, err := s.connSvc.GetAdapter(conn)
+	if err != nil {
+		return skuSourceOutcome{reason: fmt.Sprintf("get adapter failed: %v", err)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// 配置了单价上限时先查询上游实时价格与库存
+	if !pinned && source.MaxUnitCost.Decimal.IsPositive() {
+		product, err := adapter.GetProduct(ctx, source.UpstreamProductID)
+		if err != nil {
+			return skuSourceOutcome{reason: fmt.Sprintf("get upstream product failed: %v", err), retryable: true}
+		}
+		upSKU := findReplenishUpstreamSKU(product, source.UpstreamSKUID)
+		if upSKU == nil || !upSKU.IsActive || !product.IsActive {
+			return skuSourceOutcome{reason: "upstream sku unavailable"}
+		}
+		if upSKU.StockQuantity >= 0 && upSKU.StockQuantity < item.Quantity {
+			return skuSourceOutcome{reason: "upstream out of stock", retryable: true}
+		}
+		price, err := decimal.NewFromString(strings.TrimSpace(upSKU.PriceAmount))
+		if err != nil {
+			return skuSourceOutcome{reason: fmt.Sprintf("invalid upstream price: %s", upSKU.PriceAmount)}
+		}
+		// 单价上限按本地币种填写，上游价格先按连接汇率换算后比较
+		if localPrice := convertCurrency(price, conn.ExchangeRate); localPrice.GreaterThan(source.MaxUnitCost.Decimal) {
+			return skuSourceOutcome{reason: fmt.Sprintf("upstream price %s above ceiling %s", localPrice.StringFixed(2), source.MaxUnitCost.Decimal.StringFixed(2))}
+		}
+		// 余额按上游币种比较；刷新后该来源余额仍不足时转向下一个来源
+		if err := s.ensureUpstreamBalance(conn, adapter, price.Mul(decimal.NewFromInt(int64(item.Quantity)))); err != nil {
+			return skuSourceOutcome{reason: err.Error()}
+		}
+	}
+
+	req := upstream.CreateUpstreamOrderReq{
+		SKUID:             source.UpstreamSKUID,
+		Quantity:          item.Quantity,
+		DownstreamOrderNo: localOrder.OrderNo,
+		TraceID:           procOrder.TraceID,
+		CallbackURL:       conn.CallbackURL,
+	}
+	if len(item.ManualFormSubmissionJSON) > 0 {
+		req.ManualFormData = item.ManualFormSubmissionJSON
+	}
+	resp, err := adapter.CreateOrder(ctx, req)
+	if err != nil {
+		var respErr *upstream.ResponseError
+		if errors.As(err, &respErr) && respErr.ErrorCode() != "" {
+			return classifySKUSourceRejection(respErr.ErrorCode(), err.Error())
+		}
+		return skuSourceOutcome{reason: fmt.Sprintf("upstream request error: %v", err), retryable: true, ambiguous: true}
+	}
+	if !resp.OK {
+		errMsg := resp.ErrorMessage
+		if errMsg == "" {
+			errMsg = resp.ErrorCode
+		}
+		return classifySKUSourceRejection(resp.ErrorCode, errMsg)
+	}
+	return skuSourceOutcome{resp: resp}
+}
+
+// classifySKUSourceRejection 按上游错误码判断是否可重试、是否终止以及能否安全转向其他来源
+func classifySKUSourceRejection(code, reason string) skuSourceOutcome {
+	code = strings.ToLower(strings.TrimSpace(code))
+	terminal := skuSourceStopCodes[code]
+	return skuSourceOutcome{
+		reason:    reason,
+		retryable: isRetryableErrorCode(code),
+		terminal:  terminal,
+		ambiguous: !terminal && !skuSourceFailoverCodes[code],
+	}
+}
+
+// resolvePinnedSKUSource 获取已锁定的来源（即使已停用也需在该来源上确认结果）
+func (s *ProcurementOrderService) resolvePinnedSKUSource(id uint, sources []models.SKUSource) (*models.SKUSource, error) {
+	for i := range sources {
+		if sources[i].ID == id {
+			return &sources[i], nil
+		}
+	}
+	return s.skuSourceRepo.GetByID(id)
+}
+
+func describeSKUSource(source *models.SKUSource) string {
+	if source.SourceType == constants.SKUSourceTypeCardPool {
+		return "card_pool"
+	}
+	return fmt.Sprintf("upstream conn=%d sku=%d", source.ConnectionID, source.UpstreamSKUID)
+}
+
+func truncateSKUSourceError(reason string) string {
+	runes := []rune(reason)
+	if len(runes) > 500 {
+		return string(runes[:500])
+	}
+	return reason
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dujiao-next/internal/config"
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/shopspring/decimal"
+)
+
+// newSourceTestUpstream 模拟上游：price 为 SKU 单价，outOfStock 时下单返回 409
+func newSourceTestUpstream(t *testing.T, price string, outOfStock bool, orders *int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v1/upstream/products/"):
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"ok": true,
+				"product": map[string]any{
+					"id": 10, "is_active": true, "currency": "CNY",
+					"skus": []map[string]any{{"id": 100, "price_amount": price, "stock_quantity": -1, "is_active": true}},
+				},
+			})
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/upstream/orders":
+			*orders++
+			if outOfStock {
+				w.WriteHeader(http.StatusConflict)
+				_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error_code": "insufficient_stock"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"ok": true, "order_id": 7000 + *orders, "order_no": fmt.Sprintf("UP-%d", *orders),
+				"status": "paid", "amount": price, "currency": "CNY",
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSubmitToUpstreamFailsOverAcrossSKUSources(t *testing.T) {
+	db := setupProcurementTestDB(t)
+	if err := db.AutoMigrate(&models.CardSecret{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	connSvc := NewSiteConnectionService(repository.NewSiteConnectionRepository(db), "test-key", t.TempDir())
+	procSvc := newTestProcurementService(db, connSvc)
+	orderRepo := repository.NewOrderRepository(db)
+	procSvc.fulfillSvc = NewFulfillmentService(orderRepo, repository.NewFulfillmentRepository(db), repository.NewCardSecretRepository(db), nil, nil, config.EmailConfig{}, nil)
+	sourceRepo := repository.NewSKUSourceRepository(db)
+
+	var expensiveOrders, emptyOrders, cheapOrders int
+	newConn := func(name, baseURL string) *models.SiteConnection {
+		conn, err := connSvc.Create(CreateConnectionInput{
+			Name: name, BaseURL: baseURL, ApiKey: "key", ApiSecret: "secret", Protocol: constants.ConnectionProtocolDujiaoNext,
+		})
+		if err != nil {
+			t.Fatalf("create connection failed: %v", err)
+		}
+		return conn
+	}
+	// 上游以外币报价 6.00，按汇率 2 折合本地 12.00，超过本地币种的单价上限 10
+	expensive := newConn("expensive", newSourceTestUpstream(t, "6.00", false, &expensiveOrders).URL)
+	if err := db.Model(&models.SiteConnection{}).Where("id = ?", expensive.ID).Update("exchange_rate", decimal.NewFromInt(2)).Error; err != nil {
+		t.Fatalf("update exchange rate failed: %v", err)
+	}
+	empty := newConn("empty", newSourceTestUpstream(t, "8.00", true, &emptyOrders).URL)
+	cheap := newConn("cheap", newSourceTestUpstream(t, "9.00", false, &cheapOrders).URL)
+
+	ceiling := models.NewMoneyFromDecimal(decimal.NewFromInt(10))
+	sources := []models.SKUSource{
+		{LocalProductID: 1, LocalSKUID: 1, Priority: 1, SourceType: constants.SKUSourceTypeUpstream, ConnectionID: expensive.ID, UpstreamProductID: 10, UpstreamSKUID: 100, MaxUnitCost: ceiling, IsActive: true},
+		{LocalProductID: 1, LocalSKUID: 1, Priority: 2, SourceType: constants.SKUSourceTypeUpstream, ConnectionID: empty.ID, UpstreamProductID: 10, UpstreamSKUID: 100, IsActive: true},
+		{LocalProductID: 1, LocalSKUID: 1, Priority: 3, SourceType: constants.SKUSourceTypeCardPool, IsActive: true},
+		{LocalProductID: 1, LocalSKUID: 1, Priority: 4, SourceType: constants.SKUSourceTypeUpstream, ConnectionID: cheap.ID, UpstreamProductID: 10, UpstreamSKUID: 100, MaxUnitCost: ceiling, IsActive: true},
+	}
+	for i := range sources {
+		if err := sourceRepo.Create(&sources[i]); err != nil {
+			t.Fatalf("create sku source failed: %v", err)
+		}
+	}
+
+	// 单价超限、缺货、本地卡池为空，最终由第四个来源受理
+	order := createProcTestOrder(t, db, "SRC-001", constants.OrderStatusPaid, constants.FulfillmentTypeUpstream)
+	proc := createTestProcurementOrder(t, db, expensive.ID, order.ID, order.OrderNo, constants.ProcurementStatusPending)
+	if err := procSvc.SubmitToUpstream(proc.ID); err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	if expensiveOrders != 0 || emptyOrders != 1 || cheapOrders != 1 {
+		t.Fatalf("unexpected upstream calls expensive=%d empty=%d cheap=%d", expensiveOrders, emptyOrders, cheapOrders)
+	}
+	var reloaded models.ProcurementOrder
+	if err := db.First(&reloaded, proc.ID).Error; err != nil {
+		t.Fatalf("reload procurement failed: %v", err)
+	}
+	if reloaded.Status != constants.ProcurementStatusAccepted || reloaded.ConnectionID != cheap.ID ||
+		reloaded.SKUSourceID == nil || *reloaded.SKUSourceID != sources[3].ID || reloaded.SourceType != constants.SKUSourceTypeUpstream {
+		t.Fatalf("unexpected procurement after failover: %+v", reloaded)
+	}
+	if lines := strings.Split(reloaded.SourceAttempts, "\n"); len(lines) != 4 || !strings.Contains(lines[0], "upstream price 12.00 above ceiling") {
+		t.Fatalf("unexpected source attempts: %q", reloaded.SourceAttempts)
+	}
+	skipped, _ := sourceRepo.GetByID(sources[0].ID)
+	if skipped == nil || !strings.Contains(skipped.LastError, "above ceiling") {
+		t.Fatalf("expected skipped source error recorded, got %+v", skipped)
+	}
+
+	// 本地卡池有库存时优先于排在后面的上游来源
+	if err := db.Create(&models.CardSecret{ProductID: 1, SKUID: 1, Secret: "POOL-1", Status: models.CardSecretStatusAvailable}).Error; err != nil {
+		t.Fatalf("create card secret failed: %v", err)
+	}
+	order2 := createProcTestOrder(t, db, "SRC-002", constants.OrderStatusPaid, constants.FulfillmentTypeUpstream)
+	proc2 := createTestProcurementOrder(t, db, expensive.ID, order2.ID, order2.OrderNo, constants.ProcurementStatusPending)
+	if err := procSvc.SubmitToUpstream(proc2.ID); err != nil {
+		t.Fatalf("submit with card pool failed: %v", err)
+	}
+	if cheapOrders != 1 {
+		t.Fatalf("expected card pool to fulfill before cheap upstream, calls=%d", cheapOrders)
+	}
+	reloaded = models.ProcurementOrder{}
+	if err := db.First(&reloaded, proc2.ID).Error; err != nil {
+		t.Fatalf("reload procurement failed: %v", err)
+	}
+	if reloaded.Status != constants.ProcurementStatusFulfilled || reloaded.SourceType != constants.SKUSourceTypeCardPool {
+		t.Fatalf("unexpected card pool procurement: %+v", reloaded)
+	}
+	var localOrder models.Order
+	if err := db.First(&localOrder, order2.ID).Error; err != nil {
+		t.Fatalf("reload order failed: %v", err)
+	}
+	if localOrder.Status != constants.OrderStatusCompleted {
+		t.Fatalf("expected local order completed from card pool, got %s", localOrder.Status)
+	}
+
+	// 仅剩单价超限的来源时拒绝采购单
+	if err := db.Model(&models.SKUSource{}).Where("id <> ?", sources[0].ID).Update("is_active", false).Error; err != nil {
+		t.Fatalf("disable sources failed: %v", err)
+	}
+	order3 := createProcTestOrder(t, db, "SRC-003", constants.OrderStatusPaid, constants.FulfillmentTypeUpstream)
+	proc3 := createTestProcurementOrder(t, db, expensive.ID, order3.ID, order3.OrderNo, constants.ProcurementStatusPending)
+	if err := procSvc.SubmitToUpstream(proc3.ID); err == nil {
+		t.Fatalf("expected rejection when every source is above ceiling")
+	}
+	reloaded = models.ProcurementOrder{}
+	if err := db.First(&reloaded, proc3.ID).Error; err != nil {
+		t.Fatalf("reload procurement failed: %v", err)
+	}
+	if reloaded.Status != constants.ProcurementStatusRejected || reloaded.SKUSourceID != nil {
+		t.Fatalf("unexpected procurement after exhausting sources: %+v", reloaded)
+	}
+}
+
+func TestSubmitToUpstreamPinsSKUSourceOnAmbiguousFailure(t *testing.T) {
+	db := setupProcurementTestDB(t)
+	connSvc := NewSiteConnectionService(repository.NewSiteConnectionRepository(db), "test-key", t.TempDir())
+	procSvc := newTestProcurementService(db, connSvc)
+	sourceRepo := repository.NewSKUSourceRepository(db)
+
+	var primaryOrders, backupOrders int
+	primaryDown := true
+	primaryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		primaryOrders++
+		if primaryDown {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error_code": "internal_error"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"ok": true, "order_id": 8001, "order_no": "UP-PRIMARY", "status": "paid", "amount": "9.00", "currency": "CNY",
+		})
+	}))
+	t.Cleanup(primaryServer.Close)
+
+	newConn := func(name, baseURL string) *models.SiteConnection {
+		conn, err := connSvc.Create(CreateConnectionInput{
+			Name: name, BaseURL: baseURL, ApiKey: "key", ApiSecret: "secret", Protocol: constants.ConnectionProtocolDujiaoNext,
+		})
+		if err != nil {
+			t.Fatalf("create connection failed: %v", err)
+		}
+		return conn
+	}
+	primary := newConn("primary", primaryServer.URL)
+	backup := newConn("backup", newSourceTestUpstream(t, "9.00", false, &backupOrders).URL)
+	sources := []models.SKUSource{
+		{LocalProductID: 1, LocalSKUID: 1, Priority: 1, SourceType: constants.SKUSourceTypeUpstream, ConnectionID: primary.ID, UpstreamProductID: 10, UpstreamSKUID: 100, IsActive: true},
+		{LocalProductID: 1, LocalSKUID: 1, Priority: 2, SourceType: constants.SKUSourceTypeUpstream, ConnectionID: backup.ID, UpstreamProductID: 10, UpstreamSKUID: 100, IsActive: true},
+	}
+	for i := range sources {
+		if err := sourceRepo.Create(&sources[i]); err != nil {
+			t.Fatalf("create sku source failed: %v", err)
+		}
+	}
+
+	// 上游内部错误无法确认是否已建单：不转向备用来源，锁定原来源等待重试
+	order := createProcTestOrder(t, db, "SRC-PIN-001", constants.OrderStatusPaid, constants.FulfillmentTypeUpstream)
+	proc := createTestProcurementOrder(t, db, primary.ID, order.ID, order.OrderNo, constants.ProcurementStatusPending)
+	_ = procSvc.SubmitToUpstream(proc.ID)
+	if primaryOrders != 1 || backupOrders != 0 {
+		t.Fatalf("unexpected upstream calls primary=%d backup=%d", primaryOrders, backupOrders)
+	}
+	var reloaded models.ProcurementOrder
+	if err := db.First(&reloaded, proc.ID).Error; err != nil {
+		t.Fatalf("reload procurement failed: %v", err)
+	}
+	if reloaded.Status != constants.ProcurementStatusFailed || reloaded.SKUSourceID == nil || *reloaded.SKUSourceID != sources[0].ID {
+		t.Fatalf("expected procurement pinned to primary source, got %+v", reloaded)
+	}
+
+	// 重试只在锁定的来源上进行
+	primaryDown = false
+	if err := procSvc.SubmitToUpstream(proc.ID); err != nil {
+		t.Fatalf("retry submit failed: %v", err)
+	}
+	if primaryOrders != 2 || backupOrders != 0 {
+		t.Fatalf("unexpected upstream calls after retry primary=%d backup=%d", primaryOrders, backupOrders)
+	}
+	reloaded = models.ProcurementOrder{}
+	if err := db.First(&reloaded, proc.ID).Error; err != nil {
+		t.Fatalf("reload procurement failed: %v", err)
+	}
+	if reloaded.Status != constants.ProcurementStatusAccepted || reloaded.ConnectionID != primary.ID || reloaded.UpstreamOrderNo != "UP-PRIMARY" {
+		t.Fatalf("unexpected procurement after retry: %+v", reloaded)
+	}
+}
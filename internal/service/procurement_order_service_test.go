@@ -39,6 +39,7 @@ func setupProcurementTestDB(t *testing.T) *gorm.DB {
 		&models.SiteConnection{},
 		&models.ProductMapping{},
 		&models.SKUMapping{},
+		&models.SKUSource{},
 	); err != nil {
 		t.Fatalf("auto migrate failed: %v", err)
 	}
@@ -113,6 +114,7 @@ func newTestProcurementService(
 		nil, // settingService
 		config.EmailConfig{},
 		nil, // fulfillmentService
+		repository.NewSKUSourceRepository(db),
 	)
 	return svc
 }
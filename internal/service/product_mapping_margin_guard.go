@@ -0,0 +1,237 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/logger"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/shopspring/decimal"
+)
+
+// SetMarginGuard 设置利润保护依赖（触发记录仓库与通知中心）
+func (s *ProductMappingService) SetMarginGuard(eventRepo repository.MarginGuardEventRepository, notificationSvc *NotificationService) {
+	s.marginEventRepo = eventRepo
+	s.notificationSvc = notificationSvc
+}
+
+// MarginGuardInput 映射级利润保护配置，字段为空表示沿用连接配置
+type MarginGuardInput struct {
+	MarginGuardMode  string   `json:"margin_guard_mode"`
+	MinMarginPercent *float64 `json:"min_margin_percent"`
+}
+
+// UpdateMarginGuard 更新映射的利润保护配置
+func (s *ProductMappingService) UpdateMarginGuard(id uint, input MarginGuardInput) (*models.ProductMapping, error) {
+	mapping, err := s.mappingRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if mapping == nil {
+		return nil, ErrMappingNotFound
+	}
+	mode := strings.TrimSpace(input.MarginGuardMode)
+	var minMargin *decimal.Decimal
+	if input.MinMarginPercent != nil {
+		value := decimal.NewFromFloat(*input.MinMarginPercent)
+		minMargin = &value
+	}
+	if mode != "" && !validMarginGuard(mode, decimal.Zero) {
+		return nil, ErrMappingMarginGuardInvalid
+	}
+	if minMargin != nil && !validMarginGuard(constants.MarginGuardModeOff, *minMargin) {
+		return nil, ErrMappingMarginGuardInvalid
+	}
+	mapping.MarginGuardMode = mode
+	mapping.MinMarginPercent = minMargin
+	if err := s.mappingRepo.Update(mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+// ListMarginGuardEvents 利润保护触发记录列表
+func (s *ProductMappingService) ListMarginGuardEvents(filter repository.MarginGuardEventListFilter) ([]models.MarginGuardEvent, int64, error) {
+	if s.marginEventRepo == nil {
+		return []models.MarginGuardEvent{}, 0, nil
+	}
+	return s.marginEventRepo.List(filter)
+}
+
+// validMarginGuard 校验利润保护模式与最低利润率（0 <= 利润率 < 100）
+func validMarginGuard(mode string, minMargin decimal.Decimal) bool {
+	switch mode {
+	case "", constants.MarginGuardModeOff, constants.MarginGuardModeDeactivate, constants.MarginGuardModeReprice:
+	default:
+		return false
+	}
+	return !minMargin.IsNegative() && minMargin.LessThan(hundred)
+}
+
+// resolveMarginGuard 计算映射生效的利润保护配置：映射覆盖优先，否则沿用连接
+func resolveMarginGuard(conn *models.SiteConnection, mapping *models.ProductMapping) (string, decimal.Decimal) {
+	mode := conn.MarginGuardMode
+	if mapping.MarginGuardMode != "" {
+		mode = mapping.MarginGuardMode
+	}
+	minMargin := conn.MinMarginPercent
+	if mapping.MinMarginPercent != nil {
+		minMargin = *mapping.MinMarginPercent
+	}
+	return mode, minMargin
+}
+
+// applyMarginGuard 检查上游成本是否仍低于 售价 - 最低利润，不足时按模式自动停用该 SKU 或调价。
+// 仅修改传入的 localSKU，调用方负责保存；wasActive 为同步前 SKU 的启用状态，
+// 已被停用的 SKU 在利润仍不足时保持停用且不重复记录。返回 true 表示 SKU 售价已调整。
+func (s *ProductMappingService) applyMarginGuard(
+	conn *models.SiteConnection,
+	mapping *models.ProductMapping,
+	localProduct *models.Product,
+	localSKU *models.ProductSKU,
+	wasActive bool,
+	upstreamSKUID uint,
+	upPrice decimal.Decimal,
+) bool {
+	mode, minMargin := resolveMarginGuard(conn, mapping)
+	if mode == "" || mode == constants.MarginGuardModeOff || localProduct == nil || !localSKU.IsActive {
+		return false
+	}
+
+	keepRatio := decimal.NewFromInt(1).Sub(minMargin.Div(hundred))
+	cost := convertCurrency(upPrice, conn.ExchangeRate).Round(2)
+	priceBefore := localSKU.PriceAmount.Decimal
+	if !cost.GreaterThan(priceBefore.Mul(keepRatio)) {
+		return false
+	}
+	if mode == constants.MarginGuardModeDeactivate && !wasActive {
+		localSKU.IsActive = false
+		return false
+	}
+
+	reason := fmt.Sprintf("upstream cost %s exceeds price %s minus %s%% margin",
+		cost.StringFixed(2), priceBefore.StringFixed(2), minMargin.String())
+	priceAfter := priceBefore
+	repriced := false
+	switch mode {
+	case constants.MarginGuardModeReprice:
+		priceAfter = ceilPrice(cost.Div(keepRatio), conn.PriceRoundingMode)
+		localSKU.PriceAmount = models.NewMoneyFromDecimal(priceAfter)
+		localSKU.CostPriceAmount = models.NewMoneyFromDecimal(cost)
+		repriced = true
+	case constants.MarginGuardModeDeactivate:
+		localSKU.IsActive = false
+	}
+
+	event := &models.MarginGuardEvent{
+		ConnectionID:     conn.ID,
+		ProductMappingID: mapping.ID,
+		LocalProductID:   localProduct.ID,
+		LocalSKUID:       localSKU.ID,
+		UpstreamSKUID:    upstreamSKUID,
+		UpstreamPrice:    models.NewMoneyFromDecimal(upPrice.Round(2)),
+		ExchangeRate:     conn.ExchangeRate,
+		CostAmount:       models.NewMoneyFromDecimal(cost),
+		PriceBefore:      models.NewMoneyFromDecimal(priceBefore),
+		PriceAfter:       models.NewMoneyFromDecimal(priceAfter),
+		MinMarginPercent: minMargin,
+		Action:           mode,
+		Reason:           reason,
+	}
+	if s.marginEventRepo != nil {
+		if err := s.marginEventRepo.Create(event); err != nil {
+			logger.Warnw("margin_guard_event_save_failed", "local_sku_id", localSKU.ID, "error", err)
+		}
+	}
+	logger.Warnw("margin_guard_triggered",
+		"connection_id", conn.ID,
+		"local_product_id", localProduct.ID,
+		"local_sku_id", localSKU.ID,
+		"action", mode,
+		"reason", reason,
+	)
+	s.notifyMarginGuard(event)
+	return repriced
+}
+
+// ApplyMarginGuardForConnection 按连接当前汇率与已记录的上游价格，对该连接下所有映射重新执行利润保护
+func (s *ProductMappingService) ApplyMarginGuardForConnection(conn *models.SiteConnection) {
+	if conn == nil {
+		return
+	}
+	mappings, err := s.mappingRepo.ListActiveByConnection(conn.ID)
+	if err != nil {
+		logger.Warnw("margin_guard_list_mappings_failed", "connection_id", conn.ID, "error", err)
+		return
+	}
+	for i := range mappings {
+		mapping := &mappings[i]
+		localProduct, err := s.productRepo.GetByID(strconv.FormatUint(uint64(mapping.LocalProductID), 10))
+		if err != nil || localProduct == nil {
+			continue
+		}
+		skuMappings, err := s.skuMappingRepo.ListByProductMapping(mapping.ID)
+		if err != nil {
+			continue
+		}
+		changed := false
+		for _, sm := range skuMappings {
+			localSKU, err := s.productSKURepo.GetByID(sm.LocalSKUID)
+			if err != nil || localSKU == nil || !localSKU.IsActive {
+				continue
+			}
+			priceBefore := localSKU.PriceAmount.Decimal
+			s.applyMarginGuard(conn, mapping, localProduct, localSKU, true, sm.UpstreamSKUID, sm.UpstreamPrice.Decimal)
+			if localSKU.IsActive && localSKU.PriceAmount.Decimal.Equal(priceBefore) {
+				continue
+			}
+			_ = s.productSKURepo.Update(localSKU)
+			changed = true
+		}
+		if changed {
+			s.recalcProductPrice(localProduct)
+		}
+	}
+}
+
+// notifyMarginGuard 发送利润保护通知到通知中心
+func (s *ProductMappingService) notifyMarginGuard(event *models.MarginGuardEvent) {
+	if s.notificationSvc == nil {
+		return
+	}
+	action := fmt.Sprintf("已自动停用 SKU #%d", event.LocalSKUID)
+	if event.Action == constants.MarginGuardModeReprice {
+		action = "已自动调价至 " + event.PriceAfter.Decimal.StringFixed(2)
+	}
+	_ = s.notificationSvc.Enqueue(NotificationEnqueueInput{
+		EventType: constants.NotificationEventExceptionAlert,
+		BizType:   constants.NotificationBizTypeMarginGuard,
+		BizID:     event.LocalProductID,
+		Data: map[string]any{
+			"message": fmt.Sprintf("商品 #%d 上游成本 %s 超出售价 %s 的利润下限，%s",
+				event.LocalProductID, event.CostAmount.Decimal.StringFixed(2), event.PriceBefore.Decimal.StringFixed(2), action),
+			"event_id":         event.ID,
+			"connection_id":    event.ConnectionID,
+			"local_product_id": event.LocalProductID,
+			"local_sku_id":     event.LocalSKUID,
+			"action":           event.Action,
+			"reason":           event.Reason,
+		},
+	})
+}
+
+// ceilPrice 按连接取整模式向上取整；none 模式向上取整到分，保证不低于目标价
+func ceilPrice(value decimal.Decimal, roundingMode string) decimal.Decimal {
+	switch roundingMode {
+	case "ceil_int":
+		return value.Ceil()
+	case "ceil_tenth":
+		return value.Div(pointOne).Ceil().Mul(pointOne)
+	default:
+		return value.Mul(hundred).Ceil().Div(hundred)
+	}
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/dujiao-next/internal/upstream"
+	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func TestSyncProductAppliesMarginGuard(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:product_mapping_margin_guard?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.Product{},
+		&models.ProductSKU{},
+		&models.SiteConnection{},
+		&models.ProductMapping{},
+		&models.SKUMapping{},
+		&models.MarginGuardEvent{},
+	); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+
+	connSvc := NewSiteConnectionService(repository.NewSiteConnectionRepository(db), "test-key", t.TempDir())
+	if _, err := connSvc.Create(CreateConnectionInput{
+		Name: "guard", BaseURL: "http://upstream.test", ApiKey: "key", ApiSecret: "secret",
+		MarginGuardMode: "discount",
+	}); err == nil {
+		t.Fatalf("expected invalid margin guard mode to be rejected")
+	}
+	conn, err := connSvc.Create(CreateConnectionInput{
+		Name: "guard", BaseURL: "http://upstream.test", ApiKey: "key", ApiSecret: "secret",
+		MarginGuardMode: constants.MarginGuardModeReprice, MinMarginPercent: 20,
+	})
+	if err != nil {
+		t.Fatalf("create connection failed: %v", err)
+	}
+
+	price := models.NewMoneyFromDecimal(decimal.NewFromInt(10))
+	product := &models.Product{CategoryID: 1, Slug: "margin-guard", TitleJSON: models.JSON{"zh-CN": "利润保护"}, PriceAmount: price, FulfillmentType: constants.FulfillmentTypeUpstream, IsActive: true}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product failed: %v", err)
+	}
+	sku := &models.ProductSKU{ProductID: product.ID, SKUCode: "DEFAULT", PriceAmount: price, IsActive: true}
+	if err := db.Create(sku).Error; err != nil {
+		t.Fatalf("create sku failed: %v", err)
+	}
+	mapping := &models.ProductMapping{ConnectionID: conn.ID, LocalProductID: product.ID, UpstreamProductID: 10, IsActive: true}
+	if err := db.Create(mapping).Error; err != nil {
+		t.Fatalf("create mapping failed: %v", err)
+	}
+	if err := db.Create(&models.SKUMapping{ProductMappingID: mapping.ID, LocalSKUID: sku.ID, UpstreamSKUID: 100, UpstreamIsActive: true}).Error; err != nil {
+		t.Fatalf("create sku mapping failed: %v", err)
+	}
+
+	eventRepo := repository.NewMarginGuardEventRepository(db)
+	svc := NewProductMappingService(
+		repository.NewProductMappingRepository(db),
+		repository.NewSKUMappingRepository(db),
+		repository.NewProductRepository(db),
+		repository.NewProductSKURepository(db),
+		repository.NewCategoryRepository(db),
+		connSvc,
+	)
+	svc.SetMarginGuard(eventRepo, nil)
+	sync := func(upPrice string) {
+		t.Helper()
+		now := time.Now()
+		svc.syncProductFromData(mapping, conn, &upstream.UpstreamProduct{
+			ID: 10, IsActive: true,
+			SKUs: []upstream.UpstreamSKU{{ID: 100, PriceAmount: upPrice, StockQuantity: -1, IsActive: true}},
+		}, &now)
+	}
+	reload := func() (models.Product, models.ProductSKU) {
+		t.Helper()
+		var p models.Product
+		var s models.ProductSKU
+		if err := db.First(&p, product.ID).Error; err != nil {
+			t.Fatalf("reload product failed: %v", err)
+		}
+		if err := db.First(&s, sku.ID).Error; err != nil {
+			t.Fatalf("reload sku failed: %v", err)
+		}
+		return p, s
+	}
+
+	// 成本 9 超过 10 × (1 - 20%)，按 9 / 0.8 向上取整调价
+	sync("9.00")
+	p, s := reload()
+	if !s.PriceAmount.Decimal.Equal(decimal.RequireFromString("11.25")) || !p.PriceAmount.Decimal.Equal(s.PriceAmount.Decimal) || !p.IsActive {
+		t.Fatalf("expected repriced sku and product, got product=%s sku=%s active=%v", p.PriceAmount.Decimal, s.PriceAmount.Decimal, p.IsActive)
+	}
+
+	// 利润仍满足下限时不触发
+	sync("8.50")
+	if _, total, _ := eventRepo.List(repository.MarginGuardEventListFilter{}); total != 1 {
+		t.Fatalf("expected one margin guard event, got %d", total)
+	}
+
+	// 映射级覆盖为下架模式
+	if _, err := svc.UpdateMarginGuard(mapping.ID, MarginGuardInput{MarginGuardMode: "bogus"}); err == nil {
+		t.Fatalf("expected invalid mapping margin guard to be rejected")
+	}
+	updated, err := svc.UpdateMarginGuard(mapping.ID, MarginGuardInput{MarginGuardMode: constants.MarginGuardModeDeactivate})
+	if err != nil {
+		t.Fatalf("update mapping margin guard failed: %v", err)
+	}
+	mapping = updated
+	sync("12.00")
+	p, s = reload()
+	if !p.IsActive || s.IsActive || !s.PriceAmount.Decimal.Equal(decimal.RequireFromString("11.25")) {
+		t.Fatalf("expected only sku deactivated without repricing, got product_active=%v sku_active=%v sku=%s", p.IsActive, s.IsActive, s.PriceAmount.Decimal)
+	}
+
+	// 利润仍不足时再次同步保持停用，且不重复记录
+	sync("12.00")
+	if _, s = reload(); s.IsActive {
+		t.Fatalf("expected sku to stay deactivated")
+	}
+
+	events, total, err := eventRepo.List(repository.MarginGuardEventListFilter{})
+	if err != nil || total != 2 {
+		t.Fatalf("expected two margin guard events, got %d err=%v", total, err)
+	}
+	if events[0].Action != constants.MarginGuardModeDeactivate || !events[0].CostAmount.Decimal.Equal(decimal.NewFromInt(12)) || events[0].Reason == "" {
+		t.Fatalf("unexpected deactivate event: %+v", events[0])
+	}
+	if events[1].Action != constants.MarginGuardModeReprice || !events[1].PriceAfter.Decimal.Equal(decimal.RequireFromString("11.25")) {
+		t.Fatalf("unexpected reprice event: %+v", events[1])
+	}
+
+	// 上游降价后随同步恢复；汇率上调导致成本超限时重新触发利润保护
+	sync("8.00")
+	if _, s = reload(); !s.IsActive {
+		t.Fatalf("expected sku reactivated after upstream price drop")
+	}
+	connSvc.SetExchangeRateChangedHook(svc.ApplyMarginGuardForConnection)
+	rate := 1.5
+	if _, err := connSvc.Update(conn.ID, UpdateConnectionInput{ExchangeRate: &rate}); err != nil {
+		t.Fatalf("update exchange rate failed: %v", err)
+	}
+	if _, s = reload(); s.IsActive {
+		t.Fatalf("expected sku deactivated after exchange rate change")
+	}
+	events, total, err = eventRepo.List(repository.MarginGuardEventListFilter{})
+	if err != nil || total != 3 {
+		t.Fatalf("expected three margin guard events, got %d err=%v", total, err)
+	}
+	if !events[0].CostAmount.Decimal.Equal(decimal.NewFromInt(12)) || !events[0].ExchangeRate.Equal(decimal.RequireFromString("1.5")) {
+		t.Fatalf("unexpected exchange rate event: %+v", events[0])
+	}
+}
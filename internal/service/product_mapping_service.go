@@ -26,6 +26,8 @@ var (
 	ErrMappingAlreadyExists    = errors.New("product mapping already exists for this upstream product")
 	ErrUpstreamProductNotFound = errors.New("upstream product not found")
 	ErrMappingInactive         = errors.New("product mapping is inactive")
+	// ErrMappingMarginGuardInvalid 利润保护模式或最低利润率不合法
+	ErrMappingMarginGuardInvalid = errors.New("product mapping margin guard is invalid")
 )
 
 // ProductMappingService 商品映射业务服务
@@ -38,6 +40,9 @@ type ProductMappingService struct {
 	connService     *SiteConnectionService
 	categoryService *CategoryService
 	mediaService    *MediaService
+	marginEventRepo repository.MarginGuardEventRepository
+	notificationSvc *NotificationService
+	catalogWebhook  *CatalogWebhookService
 }
 
 // NewProductMappingService 创建商品映射服务
@@ -69,6 +74,11 @@ func (s *ProductMappingService) SetMediaService(ms *MediaService) {
 	s.mediaService = ms
 }
 
+// SetCatalogWebhookService 设置商品目录推送服务（同步后将变更继续推送给本站下游）
+func (s *ProductMappingService) SetCatalogWebhookService(svc *CatalogWebhookService) {
+	s.catalogWebhook = svc
+}
+
 // ImportUpstreamProduct 从上游导入商品（克隆为本地商品 + 建立映射）
 func (s *ProductMappingService) ImportUpstreamProduct(connectionID uint, upstreamProductID uint, categoryID uint, slug string) (*models.ProductMapping, error) {
 	if err := validateProductCategoryAssignment(s.categoryRepo, categoryID, 0); err != nil {
@@ -465,6 +475,8 @@ func (s *ProductMappingService) SyncProduct(mappingID uint) error {
 		upstreamSKUMap[us.ID] = us
 	}
 
+	repriced := false // 利润保护是否调整了 SKU 售价
+
 	// 构建已有映射查找表（按上游 SKU ID）
 	existingByUpstreamID := make(map[uint]*models.SKUMapping, len(skuMappings))
 	for i := range skuMappings {
@@ -502,6 +514,7 @@ func (s *ProductMappingService) SyncProduct(mappingID uint) error {
 		// 同步本地 SKU 字段
 		localSKU, _ := s.productSKURepo.GetByID(skuMappings[i].LocalSKUID)
 		if localSKU != nil {
+			wasActive := localSKU.IsActive
 			localSKU.SpecValuesJSON = upSKU.SpecValues
 			localSKU.IsActive = upSKU.IsActive
 			// 如果启用了自动同步价格，按加价比例更新本地售价和成本价
@@ -510,6 +523,10 @@ func (s *ProductMappingService) SyncProduct(mappingID uint) error {
 				localSKU.PriceAmount = models.NewMoneyFromDecimal(newLocalPrice.Round(2))
 				localSKU.CostPriceAmount = models.NewMoneyFromDecimal(convertCurrency(upPrice, conn.ExchangeRate).Round(2))
 			}
+			// 上游涨价后利润不足时按利润保护规则停用 SKU 或调价
+			if s.applyMarginGuard(conn, mapping, localProduct, localSKU, wasActive, upSKU.ID, upPrice) {
+				repriced = true
+			}
 			_ = s.productSKURepo.Update(localSKU)
 		}
 	}
@@ -547,8 +564,8 @@ func (s *ProductMappingService) SyncProduct(mappingID uint) error {
 		_ = s.skuMappingRepo.Create(newMapping)
 	}
 
-	// ── 2c. 如果启用了自动同步价格，更新 Product.PriceAmount 为最低 SKU 价格 ──
-	if conn.AutoSyncPrice && localProduct != nil {
+	// ── 2c. 如果启用了自动同步价格或触发了利润保护调价，更新 Product.PriceAmount 为最低 SKU 价格 ──
+	if (conn.AutoSyncPrice || repriced) && localProduct != nil {
 		s.recalcProductPrice(localProduct)
 	}
 
@@ -559,7 +576,11 @@ func (s *ProductMappingService) SyncProduct(mappingID uint) error {
 	}
 	mapping.UpstreamFulfillmentType = upFulfillment
 	mapping.LastSyncedAt = &now
-	return s.mappingRepo.Update(mapping)
+	if err := s.mappingRepo.Update(mapping); err != nil {
+		return err
+	}
+	s.catalogWebhook.NotifyProductChanged(mapping.LocalProductID)
+	return nil
 }
 
 // SyncAllStock 同步所有活跃映射的库存（供定时任务调用）
@@ -753,6 +774,7 @@ func (s *ProductMappingService) syncProductFromData(mapping *models.ProductMappi
 		upstreamSKUMap[us.ID] = us
 	}
 
+	repriced := false // 利润保护是否调整了 SKU 售价
 	existingByUpstreamID := make(map[uint]*models.SKUMapping, len(skuMappings))
 	for i := range skuMappings {
 		existingByUpstreamID[skuMappings[i].UpstreamSKUID] = &skuMappings[i]
@@ -796,6 +818,7 @@ func (s *ProductMappingService) syncProductFromData(mapping *models.ProductMappi
 
 		localSKU, _ := s.productSKURepo.GetByID(skuMappings[i].LocalSKUID)
 		if localSKU != nil {
+			wasActive := localSKU.IsActive
 			localSKU.SpecValuesJSON = upSKU.SpecValues
 			localSKU.IsActive = upSKU.IsActive
 			if conn.AutoSyncPrice {
@@ -803,6 +826,10 @@ func (s *ProductMappingService) syncProductFromData(mapping *models.ProductMappi
 				localSKU.PriceAmount = models.NewMoneyFromDecimal(newLocalPrice.Round(2))
 				localSKU.CostPriceAmount = models.NewMoneyFromDecimal(convertCurrency(upPrice, conn.ExchangeRate).Round(2))
 			}
+			// 上游涨价后利润不足时按利润保护规则停用 SKU 或调价
+			if s.applyMarginGuard(conn, mapping, localProduct, localSKU, wasActive, upSKU.ID, upPrice) {
+				repriced = true
+			}
 			_ = s.productSKURepo.Update(localSKU)
 		}
 	}
@@ -847,7 +874,7 @@ func (s *ProductMappingService) syncProductFromData(mapping *models.ProductMappi
 	}
 
 	// 同步价格
-	if conn.AutoSyncPrice && localProduct != nil {
+	if (conn.AutoSyncPrice || repriced) && localProduct != nil {
 		s.recalcProductPrice(localProduct)
 	}
 
@@ -859,6 +886,24 @@ func (s *ProductMappingService) syncProductFromData(mapping *models.ProductMappi
 	mapping.UpstreamFulfillmentType = upFulfillment
 	mapping.LastSyncedAt = now
 	_ = s.mappingRepo.Update(mapping)
+	s.catalogWebhook.NotifyProductChanged(mapping.LocalProductID)
+}
+
+// ApplyCatalogEvent 应用上游推送的商品变更快照，未映射或已停用的映射忽略（定时同步仍作兜底）
+func (s *ProductMappingService) ApplyCatalogEvent(conn *models.SiteConnection, upProduct *upstream.UpstreamProduct) error {
+	if conn == nil || upProduct == nil || upProduct.ID == 0 {
+		return ErrUpstreamProductNotFound
+	}
+	mapping, err := s.mappingRepo.GetByConnectionAndUpstreamID(conn.ID, upProduct.ID)
+	if err != nil {
+		return err
+	}
+	if mapping == nil || !mapping.IsActive {
+		return nil
+	}
+	now := time.Now()
+	s.syncProductFromData(mapping, conn, upProduct, &now)
+	return nil
 }
 
 // GetByID 获取映射详情
@@ -24,6 +24,7 @@ type ProductService struct {
 	cartRepo             repository.CartRepository
 	productMappingRepo   repository.ProductMappingRepository
 	orderRepo            repository.OrderRepository
+	catalogWebhookSvc    *CatalogWebhookService
 }
 
 // NewProductService 创建商品服务
@@ -51,6 +52,11 @@ func NewProductService(
 	}
 }
 
+// SetCatalogWebhookService 设置商品目录推送服务（商品变更时推送给下游）
+func (s *ProductService) SetCatalogWebhookService(svc *CatalogWebhookService) {
+	s.catalogWebhookSvc = svc
+}
+
 // CreateProductInput 创建/更新商品输入
 type CreateProductInput struct {
 	CategoryID           uint
@@ -69,7 +75,17 @@ type CreateProductInput struct {
 	MinPurchaseQuantity  *int
 	MaxPurchaseQuantity  *int
 	FulfillmentType      string
+	DeliveryMode         string
+	DeliveryRevealLimit  *int
 	ManualStockTotal     *int
+	WebhookURL           string
+	WebhookSecret        string
+	WebhookTimeoutSecs   *int
+	WebhookMaxAttempts   *int
+	LicenseValidDays     *int
+	LicenseMaxDevices    *int
+	DownloadExpireHours  *int
+	DownloadMaxCount     *int
 	SKUs                 []ProductSKUInput
 	PaymentChannelIDs    []uint
 	IsAffiliateEnabled   *bool
@@ -84,6 +100,7 @@ type ProductSKUInput struct {
 	PriceAmount      decimal.Decimal
 	CostPriceAmount  decimal.Decimal
 	ManualStockTotal int
+	FileAssetID      *uint
 	IsActive         *bool
 	SortOrder        int
 }
@@ -258,6 +275,9 @@ func (s *ProductService) Create(input CreateProductInput) (*models.Product, erro
 		MinPurchaseQuantity:  minPurchaseQuantity,
 		MaxPurchaseQuantity:  maxPurchaseQuantity,
 		FulfillmentType:      fulfillmentType,
+		DeliveryMode:         NormalizeDeliveryMode(input.DeliveryMode),
+		DeliveryRevealLimit:  1,
+		LicenseMaxDevices:    constants.LicenseDefaultMaxActivations,
 		ManualStockTotal:     manualStockTotal,
 		ManualStockLocked:    0,
 		ManualStockSold:      0,
@@ -266,13 +286,24 @@ func (s *ProductService) Create(input CreateProductInput) (*models.Product, erro
 		IsActive:             isActive,
 		SortOrder:            input.SortOrder,
 	}
-	if fulfillmentType == constants.FulfillmentTypeManual {
+	if fulfillmentType == constants.FulfillmentTypeManual || fulfillmentType == constants.FulfillmentTypeWebhook {
 		_, normalizedSchemaJSON, err := parseManualFormSchema(models.JSON(input.ManualFormSchemaJSON))
 		if err != nil {
 			return nil, err
 		}
 		product.ManualFormSchemaJSON = normalizedSchemaJSON
 	}
+	if err := applyProductWebhookConfig(&product, input, fulfillmentType); err != nil {
+		return nil, err
+	}
+	applyProductLicenseConfig(&product, input)
+	applyProductFileConfig(&product, input)
+	if fulfillmentType == constants.FulfillmentTypeFile && len(normalizedSKUs) == 0 {
+		return nil, ErrProductFileInvalid
+	}
+	if input.DeliveryRevealLimit != nil {
+		product.DeliveryRevealLimit = NormalizeDeliveryRevealLimit(*input.DeliveryRevealLimit)
+	}
 
 	if err := s.repo.Transaction(func(tx *gorm.DB) error {
 		productRepo := s.repo.WithTx(tx)
@@ -373,13 +404,27 @@ func (s *ProductService) Update(id string, input CreateProductInput) (*models.Pr
 		fulfillmentType = constants.FulfillmentTypeUpstream
 	}
 	product.FulfillmentType = fulfillmentType
-	if fulfillmentType == constants.FulfillmentTypeManual {
+	if fulfillmentType == constants.FulfillmentTypeManual || fulfillmentType == constants.FulfillmentTypeWebhook {
 		_, normalizedSchemaJSON, err := parseManualFormSchema(models.JSON(input.ManualFormSchemaJSON))
 		if err != nil {
 			return nil, err
 		}
 		product.ManualFormSchemaJSON = normalizedSchemaJSON
 	}
+	if err := applyProductWebhookConfig(product, input, fulfillmentType); err != nil {
+		return nil, err
+	}
+	applyProductLicenseConfig(product, input)
+	applyProductFileConfig(product, input)
+	if fulfillmentType == constants.FulfillmentTypeFile && len(input.SKUs) == 0 {
+		return nil, ErrProductFileInvalid
+	}
+	if strings.TrimSpace(input.DeliveryMode) != "" {
+		product.DeliveryMode = NormalizeDeliveryMode(input.DeliveryMode)
+	}
+	if input.DeliveryRevealLimit != nil {
+		product.DeliveryRevealLimit = NormalizeDeliveryRevealLimit(*input.DeliveryRevealLimit)
+	}
 
 	manualStockTotal := product.ManualStockTotal
 	if input.ManualStockTotal != nil {
@@ -442,6 +487,7 @@ func (s *ProductService) Update(id string, input CreateProductInput) (*models.Pr
 	}); err != nil {
 		return nil, err
 	}
+	s.catalogWebhookSvc.NotifyProductChanged(product.ID)
 	return s.repo.GetByID(id)
 }
 
@@ -532,6 +578,7 @@ type normalizedProductSKU struct {
 	PriceAmount      models.Money
 	CostPriceAmount  models.Money
 	ManualStockTotal int
+	FileAssetID      *uint
 	IsActive         bool
 	SortOrder        int
 }
@@ -589,6 +636,15 @@ func normalizeProductSKUInputs(inputs []ProductSKUInput, fulfillmentType string,
 		if input.SpecValuesJSON != nil {
 			specValues = models.JSON(input.SpecValuesJSON)
 		}
+		var fileAssetID *uint
+		if fulfillmentType == constants.FulfillmentTypeFile {
+			if input.FileAssetID != nil && *input.FileAssetID > 0 {
+				id := *input.FileAssetID
+				fileAssetID = &id
+			} else if isActive {
+				return nil, decimal.Zero, 0, ErrProductFileInvalid
+			}
+		}
 
 		normalized = append(normalized, normalizedProductSKU{
 			ID:               input.ID,
@@ -597,6 +653,7 @@ func normalizeProductSKUInputs(inputs []ProductSKUInput, fulfillmentType string,
 			PriceAmount:      models.NewMoneyFromDecimal(priceAmount),
 			CostPriceAmount:  models.NewMoneyFromDecimal(costPriceAmount),
 			ManualStockTotal: manualTotal,
+			FileAssetID:      fileAssetID,
 			IsActive:         isActive,
 			SortOrder:        input.SortOrder,
 		})
@@ -680,6 +737,7 @@ func applyProductSKUsWithStockGuard(
 			existing.PriceAmount = row.PriceAmount
 			existing.CostPriceAmount = row.CostPriceAmount
 			existing.ManualStockTotal = row.ManualStockTotal
+			existing.FileAssetID = row.FileAssetID
 			existing.IsActive = row.IsActive
 			existing.SortOrder = row.SortOrder
 			if err := skuRepo.Update(&existing); err != nil {
@@ -696,6 +754,7 @@ func applyProductSKUsWithStockGuard(
 			existing.PriceAmount = row.PriceAmount
 			existing.CostPriceAmount = row.CostPriceAmount
 			existing.ManualStockTotal = row.ManualStockTotal
+			existing.FileAssetID = row.FileAssetID
 			existing.IsActive = row.IsActive
 			existing.SortOrder = row.SortOrder
 			if err := skuRepo.Update(&existing); err != nil {
@@ -718,6 +777,7 @@ func applyProductSKUsWithStockGuard(
 			ManualStockTotal:  row.ManualStockTotal,
 			ManualStockLocked: 0,
 			ManualStockSold:   0,
+			FileAssetID:       row.FileAssetID,
 			IsActive:          row.IsActive,
 			SortOrder:         row.SortOrder,
 		}
@@ -814,11 +874,71 @@ func normalizeFulfillmentType(raw string) string {
 		return constants.FulfillmentTypeAuto
 	case constants.FulfillmentTypeUpstream:
 		return constants.FulfillmentTypeUpstream
+	case constants.FulfillmentTypeWebhook:
+		return constants.FulfillmentTypeWebhook
+	case constants.FulfillmentTypeLicense:
+		return constants.FulfillmentTypeLicense
+	case constants.FulfillmentTypeFile:
+		return constants.FulfillmentTypeFile
 	default:
 		return ""
 	}
 }
 
+// applyProductFileConfig 写入文件交付下载限制配置
+func applyProductFileConfig(product *models.Product, input CreateProductInput) {
+	if input.DownloadExpireHours != nil {
+		product.DownloadExpireHours = 0
+		if *input.DownloadExpireHours > 0 {
+			product.DownloadExpireHours = NormalizeFileDownloadExpireHours(*input.DownloadExpireHours)
+		}
+	}
+	if input.DownloadMaxCount != nil {
+		product.DownloadMaxCount = 0
+		if *input.DownloadMaxCount != 0 {
+			product.DownloadMaxCount = NormalizeFileDownloadMaxCount(*input.DownloadMaxCount)
+		}
+	}
+}
+
+// applyProductLicenseConfig 写入授权码签发配置
+func applyProductLicenseConfig(product *models.Product, input CreateProductInput) {
+	if input.LicenseValidDays != nil {
+		product.LicenseValidDays = NormalizeLicenseValidDays(*input.LicenseValidDays)
+	}
+	if input.LicenseMaxDevices != nil {
+		product.LicenseMaxDevices = NormalizeLicenseMaxActivations(*input.LicenseMaxDevices)
+	}
+}
+
+// applyProductWebhookConfig 校验并写入 Webhook 交付配置，密钥留空时保留原值
+func applyProductWebhookConfig(product *models.Product, input CreateProductInput, fulfillmentType string) error {
+	if url := strings.TrimSpace(input.WebhookURL); url != "" || fulfillmentType == constants.FulfillmentTypeWebhook {
+		product.WebhookURL = url
+	}
+	if secret := strings.TrimSpace(input.WebhookSecret); secret != "" {
+		product.WebhookSecret = secret
+	}
+	if input.WebhookTimeoutSecs != nil {
+		product.WebhookTimeoutSecs = NormalizeWebhookTimeoutSeconds(*input.WebhookTimeoutSecs)
+	}
+	product.WebhookTimeoutSecs = NormalizeWebhookTimeoutSeconds(product.WebhookTimeoutSecs)
+	if input.WebhookMaxAttempts != nil {
+		product.WebhookMaxAttempts = NormalizeWebhookMaxAttempts(*input.WebhookMaxAttempts)
+	}
+	product.WebhookMaxAttempts = NormalizeWebhookMaxAttempts(product.WebhookMaxAttempts)
+	if fulfillmentType != constants.FulfillmentTypeWebhook {
+		return nil
+	}
+	if err := validateWebhookURL(product.WebhookURL); err != nil {
+		return err
+	}
+	if product.WebhookSecret == "" {
+		return ErrProductWebhookInvalid
+	}
+	return nil
+}
+
 func normalizeStockStatus(raw string) string {
 	value := strings.ToLower(strings.TrimSpace(raw))
 	switch value {
@@ -933,7 +1053,7 @@ func (s *ProductService) Delete(id string) error {
 	}
 
 	// 事务内级联删除
-	return s.repo.Transaction(func(tx *gorm.DB) error {
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
 		if err := s.cardSecretRepo.WithTx(tx).DeleteByProduct(product.ID); err != nil {
 			return err
 		}
@@ -954,6 +1074,11 @@ func (s *ProductService) Delete(id string) error {
 		}
 		return s.repo.WithTx(tx).Delete(id)
 	})
+	if err != nil {
+		return err
+	}
+	s.catalogWebhookSvc.NotifyProductChanged(product.ID)
+	return nil
 }
 
 // QuickUpdate 快速更新商品部分字段（如 is_active、sort_order）
@@ -968,6 +1093,7 @@ func (s *ProductService) QuickUpdate(id string, fields map[string]interface{}) (
 	if err := s.repo.QuickUpdate(id, fields); err != nil {
 		return nil, err
 	}
+	s.catalogWebhookSvc.NotifyProductChanged(product.ID)
 	return s.repo.GetByID(id)
 }
 
@@ -0,0 +1,439 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/logger"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/payment/paypal"
+	"github.com/dujiao-next/internal/payment/stripe"
+	"github.com/dujiao-next/internal/queue"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/shopspring/decimal"
+)
+
+// SetPaymentReconciliation 设置支付对账依赖（支付记录与支付渠道仓库）
+func (s *ReconciliationService) SetPaymentReconciliation(paymentRepo repository.PaymentRepository, channelRepo repository.PaymentChannelRepository) {
+	s.paymentRepo = paymentRepo
+	s.channelRepo = channelRepo
+}
+
+// ImportPaymentStatementInput 导入网关对账单的入参
+type ImportPaymentStatementInput struct {
+	Format         string
+	ChannelID      uint // 可选，限定对账的支付渠道；为空时按对账单格式匹配官方渠道
+	FileName       string
+	Reader         io.Reader
+	TimeRangeStart *time.Time // 可选，为空时取对账单中最早 / 最晚交易时间
+	TimeRangeEnd   *time.Time
+}
+
+// ImportPaymentStatement 解析上传的网关对账单并立即与支付记录对账
+func (s *ReconciliationService) ImportPaymentStatement(input ImportPaymentStatementInput) (*models.ReconciliationJob, error) {
+	if s.paymentRepo == nil {
+		return nil, ErrPaymentStatementInvalid
+	}
+	format := strings.ToLower(strings.TrimSpace(input.Format))
+	rows, err := ParsePaymentStatement(format, input.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if input.ChannelID > 0 {
+		if _, err := s.getPaymentChannel(input.ChannelID); err != nil {
+			return nil, err
+		}
+	}
+
+	start, end := statementTimeRange(rows)
+	if input.TimeRangeStart != nil {
+		start = *input.TimeRangeStart
+	}
+	if input.TimeRangeEnd != nil {
+		end = *input.TimeRangeEnd
+	}
+	now := time.Now()
+	job := &models.ReconciliationJob{
+		Source:          constants.ReconciliationSourcePayment,
+		ChannelID:       input.ChannelID,
+		StatementFormat: format,
+		StatementFile:   strings.TrimSpace(input.FileName),
+		Type:            constants.ReconciliationTypeFull,
+		Status:          constants.ReconciliationJobStatusRunning,
+		TimeRangeStart:  start,
+		TimeRangeEnd:    end,
+		StartedAt:       &now,
+	}
+	if err := s.jobRepo.Create(job); err != nil {
+		return nil, fmt.Errorf("create reconciliation job: %w", err)
+	}
+	s.finishJob(job, s.reconcilePaymentRows(job, rows))
+	return job, nil
+}
+
+// RunPaymentReconciliationInput 从网关 API 拉取对账数据的入参
+type RunPaymentReconciliationInput struct {
+	ChannelID      uint      `json:"channel_id" binding:"required"`
+	TimeRangeStart time.Time `json:"time_range_start" binding:"required"`
+	TimeRangeEnd   time.Time `json:"time_range_end" binding:"required"`
+}
+
+// CreatePaymentFetchJob 创建从网关 API 拉取对账数据的任务并入队执行（目前支持 Stripe / PayPal 官方渠道）
+func (s *ReconciliationService) CreatePaymentFetchJob(input RunPaymentReconciliationInput) (*models.ReconciliationJob, error) {
+	if s.paymentRepo == nil || s.channelRepo == nil {
+		return nil, ErrPaymentStatementFetchUnsupported
+	}
+	channel, err := s.getPaymentChannel(input.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	format, ok := paymentStatementFetchFormat(channel)
+	if !ok {
+		return nil, ErrPaymentStatementFetchUnsupported
+	}
+	if !input.TimeRangeEnd.After(input.TimeRangeStart) {
+		return nil, ErrPaymentStatementInvalid
+	}
+
+	job := &models.ReconciliationJob{
+		Source:          constants.ReconciliationSourcePayment,
+		ChannelID:       channel.ID,
+		StatementFormat: format,
+		Type:            constants.ReconciliationTypeFull,
+		Status:          constants.ReconciliationJobStatusPending,
+		TimeRangeStart:  input.TimeRangeStart,
+		TimeRangeEnd:    input.TimeRangeEnd,
+	}
+	if err := s.jobRepo.Create(job); err != nil {
+		return nil, fmt.Errorf("create reconciliation job: %w", err)
+	}
+	if s.queueClient != nil {
+		if err := s.queueClient.EnqueueReconciliationRun(queue.ReconciliationRunPayload{
+			JobID: job.ID,
+		}); err != nil {
+			logger.Warnw("reconciliation_enqueue_failed", "job_id", job.ID, "error", err)
+		}
+	}
+	return job, nil
+}
+
+// executePaymentReconciliation 拉取网关收款记录并与支付记录对账（由 worker 调用）
+func (s *ReconciliationService) executePaymentReconciliation(ctx context.Context, job *models.ReconciliationJob) error {
+	if s.paymentRepo == nil || s.channelRepo == nil {
+		return ErrPaymentStatementFetchUnsupported
+	}
+	channel, err := s.getPaymentChannel(job.ChannelID)
+	if err != nil {
+		return err
+	}
+	rows, err := fetchPaymentStatement(ctx, channel, job.TimeRangeStart, job.TimeRangeEnd)
+	if err != nil {
+		return fmt.Errorf("fetch payment statement: %w", err)
+	}
+	return s.reconcilePaymentRows(job, rows)
+}
+
+// reconcilePaymentRows 按 GatewayOrderNo / ProviderRef 匹配对账单与支付记录，写入差异项并回填统计：
+// 对账单有而本地无已支付记录 => extra；本地已支付而对账单缺失 => missing；状态或金额不一致 => status / amount / both
+func (s *ReconciliationService) reconcilePaymentRows(job *models.ReconciliationJob, rows []PaymentStatementRow) error {
+	payments, err := s.listReconcilablePayments(job)
+	if err != nil {
+		return fmt.Errorf("list payments: %w", err)
+	}
+	byOrderNo := make(map[string]*models.Payment, len(payments))
+	byRef := make(map[string]*models.Payment, len(payments))
+	for i := range payments {
+		if payments[i].GatewayOrderNo != "" {
+			byOrderNo[payments[i].GatewayOrderNo] = &payments[i]
+		}
+		if payments[i].ProviderRef != "" {
+			byRef[payments[i].ProviderRef] = &payments[i]
+		}
+	}
+
+	matched := make(map[uint]struct{}, len(rows))
+	items := make([]models.ReconciliationItem, 0)
+	extraCount, missingCount := 0, 0
+	for _, row := range rows {
+		payment := s.matchStatementPayment(row, byOrderNo, byRef)
+		if payment == nil {
+			if row.Status != constants.PaymentStatusSuccess {
+				continue
+			}
+			extraCount++
+			items = append(items, models.ReconciliationItem{
+				JobID:           job.ID,
+				LocalOrderNo:    row.GatewayOrderNo,
+				UpstreamOrderNo: row.ProviderRef,
+				UpstreamStatus:  row.Status,
+				UpstreamAmount:  models.NewMoneyFromDecimal(row.Amount),
+				MismatchType:    constants.MismatchTypeExtra,
+			})
+			continue
+		}
+		matched[payment.ID] = struct{}{}
+
+		statusMismatch := row.Status != payment.Status
+		amountMismatch := false
+		// 网关按兑换后的币种结算时无法直接比较金额
+		if row.Currency == "" || strings.EqualFold(row.Currency, payment.Currency) {
+			amountMismatch = !payment.Amount.Decimal.Equal(row.Amount)
+		}
+		mismatchType := ""
+		switch {
+		case statusMismatch && amountMismatch:
+			mismatchType = constants.MismatchTypeBoth
+		case statusMismatch:
+			mismatchType = constants.MismatchTypeStatus
+		case amountMismatch:
+			mismatchType = constants.MismatchTypeAmount
+		}
+		if mismatchType == "" {
+			continue
+		}
+		items = append(items, models.ReconciliationItem{
+			JobID:           job.ID,
+			PaymentID:       payment.ID,
+			LocalOrderNo:    payment.GatewayOrderNo,
+			UpstreamOrderNo: row.ProviderRef,
+			LocalStatus:     payment.Status,
+			UpstreamStatus:  row.Status,
+			LocalAmount:     payment.Amount,
+			UpstreamAmount:  models.NewMoneyFromDecimal(row.Amount),
+			MismatchType:    mismatchType,
+		})
+	}
+
+	for _, payment := range payments {
+		if _, ok := matched[payment.ID]; ok || payment.Status != constants.PaymentStatusSuccess {
+			continue
+		}
+		missingCount++
+		items = append(items, models.ReconciliationItem{
+			JobID:           job.ID,
+			PaymentID:       payment.ID,
+			LocalOrderNo:    payment.GatewayOrderNo,
+			UpstreamOrderNo: payment.ProviderRef,
+			LocalStatus:     payment.Status,
+			LocalAmount:     payment.Amount,
+			MismatchType:    constants.MismatchTypeMissing,
+		})
+	}
+
+	if len(items) > 0 {
+		if err := s.itemRepo.BatchCreate(items); err != nil {
+			return fmt.Errorf("batch create reconciliation items: %w", err)
+		}
+	}
+	job.TotalCount = len(rows) + missingCount
+	job.MismatchedCount = len(items)
+	job.MatchedCount = job.TotalCount - job.MismatchedCount
+
+	resultJSON, _ := json.Marshal(map[string]any{
+		"total":          job.TotalCount,
+		"matched":        job.MatchedCount,
+		"mismatched":     job.MismatchedCount,
+		"statement_rows": len(rows),
+		"missing":        missingCount,
+		"extra":          extraCount,
+	})
+	job.ResultJSON = string(resultJSON)
+	return nil
+}
+
+// paymentReconcileCreatedLookback 查询本地支付记录时创建时间向前放宽的范围，
+// 覆盖在对账范围开始前创建、范围内才完成支付的记录
+const paymentReconcileCreatedLookback = 24 * time.Hour
+
+// listReconcilablePayments 查询对账时间范围内完成支付的记录，用于发现对账单中缺失的收款
+func (s *ReconciliationService) listReconcilablePayments(job *models.ReconciliationJob) ([]models.Payment, error) {
+	if job.TimeRangeStart.IsZero() || job.TimeRangeEnd.IsZero() {
+		return nil, nil
+	}
+	start, end := job.TimeRangeStart, job.TimeRangeEnd
+	createdFrom := start.Add(-paymentReconcileCreatedLookback)
+	filter := repository.PaymentListFilter{
+		ChannelID:   job.ChannelID,
+		Status:      constants.PaymentStatusSuccess,
+		CreatedFrom: &createdFrom,
+		CreatedTo:   &end,
+		SkipCount:   true,
+	}
+	var result []models.Payment
+	if job.ChannelID > 0 {
+		payments, _, err := s.paymentRepo.ListAdmin(filter)
+		if err != nil {
+			return nil, err
+		}
+		result = payments
+	} else {
+		filter.ProviderType = constants.PaymentProviderOfficial
+		for _, channelType := range paymentStatementChannelTypes(job.StatementFormat) {
+			filter.ChannelType = channelType
+			payments, _, err := s.paymentRepo.ListAdmin(filter)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, payments...)
+		}
+	}
+
+	// 按支付完成时间收窄回对账范围，范围外完成的记录交由单号回查匹配
+	settled := result[:0]
+	for _, payment := range result {
+		settledAt := payment.CreatedAt
+		if payment.PaidAt != nil {
+			settledAt = *payment.PaidAt
+		}
+		if settledAt.Before(start) || settledAt.After(end) {
+			continue
+		}
+		settled = append(settled, payment)
+	}
+	return settled, nil
+}
+
+// matchStatementPayment 先在时间范围内的记录中匹配，找不到时按单号回查（跨范围支付）
+func (s *ReconciliationService) matchStatementPayment(row PaymentStatementRow, byOrderNo, byRef map[string]*models.Payment) *models.Payment {
+	if row.GatewayOrderNo != "" {
+		if payment, ok := byOrderNo[row.GatewayOrderNo]; ok {
+			return payment
+		}
+	}
+	if row.ProviderRef != "" {
+		if payment, ok := byRef[row.ProviderRef]; ok {
+			return payment
+		}
+	}
+	if row.GatewayOrderNo != "" {
+		if payment, err := s.paymentRepo.GetByGatewayOrderNo(row.GatewayOrderNo); err == nil && payment != nil {
+			return payment
+		}
+	}
+	if row.ProviderRef != "" {
+		if payment, err := s.paymentRepo.GetLatestByProviderRef(row.ProviderRef); err == nil && payment != nil {
+			return payment
+		}
+	}
+	return nil
+}
+
+func (s *ReconciliationService) getPaymentChannel(id uint) (*models.PaymentChannel, error) {
+	if s.channelRepo == nil || id == 0 {
+		return nil, ErrPaymentChannelNotFound
+	}
+	channel, err := s.channelRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, ErrPaymentChannelNotFound
+	}
+	return channel, nil
+}
+
+// paymentStatementChannelTypes 对账单格式对应的官方渠道类型
+func paymentStatementChannelTypes(format string) []string {
+	switch format {
+	case constants.PaymentStatementFormatAlipay:
+		return []string{constants.PaymentChannelTypeAlipay}
+	case constants.PaymentStatementFormatWechat:
+		return []string{constants.PaymentChannelTypeWechat, constants.PaymentChannelTypeWxpay}
+	case constants.PaymentStatementFormatStripe:
+		return []string{constants.PaymentChannelTypeStripe}
+	case constants.PaymentStatementFormatPaypal:
+		return []string{constants.PaymentChannelTypePaypal}
+	default:
+		return nil
+	}
+}
+
+// paymentStatementFetchFormat 判断渠道是否支持通过 API 拉取对账数据
+func paymentStatementFetchFormat(channel *models.PaymentChannel) (string, bool) {
+	if channel.ProviderType != constants.PaymentProviderOfficial {
+		return "", false
+	}
+	switch channel.ChannelType {
+	case constants.PaymentChannelTypeStripe:
+		return constants.PaymentStatementFormatStripe, true
+	case constants.PaymentChannelTypePaypal:
+		return constants.PaymentStatementFormatPaypal, true
+	default:
+		return "", false
+	}
+}
+
+// fetchPaymentStatement 通过网关 API 拉取收款记录并转换为对账单行（失败的收款不参与对账）
+func fetchPaymentStatement(ctx context.Context, channel *models.PaymentChannel, start, end time.Time) ([]PaymentStatementRow, error) {
+	rows := make([]PaymentStatementRow, 0)
+	appendRow := func(ref, orderNo, amount, currency, status string, tradeAt time.Time) {
+		parsed, err := decimal.NewFromString(amount)
+		if err != nil || status == constants.PaymentStatusFailed {
+			return
+		}
+		row := PaymentStatementRow{
+			ProviderRef:    ref,
+			GatewayOrderNo: orderNo,
+			Amount:         parsed,
+			Currency:       currency,
+			Status:         status,
+		}
+		if !tradeAt.IsZero() {
+			row.TradeAt = &tradeAt
+		}
+		rows = append(rows, row)
+	}
+
+	switch channel.ChannelType {
+	case constants.PaymentChannelTypeStripe:
+		cfg, err := stripe.ParseConfig(channel.ConfigJSON)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPaymentChannelConfigInvalid, err)
+		}
+		records, err := stripe.ListPaymentIntents(ctx, cfg, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			appendRow(record.PaymentIntentID, record.OrderNo, record.Amount, record.Currency, record.Status, record.CreatedAt)
+		}
+	case constants.PaymentChannelTypePaypal:
+		cfg, err := paypal.ParseConfig(channel.ConfigJSON)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPaymentChannelConfigInvalid, err)
+		}
+		records, err := paypal.ListTransactions(ctx, cfg, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			appendRow(record.TransactionID, record.InvoiceID, record.Amount, record.Currency, record.Status, record.CreatedAt)
+		}
+	default:
+		return nil, ErrPaymentStatementFetchUnsupported
+	}
+	return rows, nil
+}
+
+// statementTimeRange 对账单中最早与最晚的交易时间
+func statementTimeRange(rows []PaymentStatementRow) (time.Time, time.Time) {
+	var start, end time.Time
+	for _, row := range rows {
+		if row.TradeAt == nil {
+			continue
+		}
+		if start.IsZero() || row.TradeAt.Before(start) {
+			start = *row.TradeAt
+		}
+		if end.IsZero() || row.TradeAt.After(end) {
+			end = *row.TradeAt
+		}
+	}
+	return start, end
+}
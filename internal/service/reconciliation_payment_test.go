@@ -0,0 +1,158 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"gorm.io/gorm"
+)
+
+func TestParsePaymentStatementFormats(t *testing.T) {
+	alipay := strings.Join([]string{
+		"#支付宝业务明细查询",
+		"#账号：[20880000000000000156]",
+		"支付宝交易号,商户订单号,业务类型,商品名称,创建时间,完成时间,订单金额（元）,商家实收（元）",
+		"2024010122001,DJP1001,交易,商品,2024-01-01 10:00:00,2024-01-01 10:00:05,10.00,10.00",
+		"2024010122002,DJP1002,退款,商品,2024-01-01 11:00:00,2024-01-01 11:00:05,5.00,5.00",
+		"#-----------------------------------------业务明细列表结束------------------------------------",
+		"#交易合计：1笔",
+	}, "\n")
+	gbk, err := simplifiedchinese.GBK.NewEncoder().String(alipay)
+	if err != nil {
+		t.Fatalf("encode gbk failed: %v", err)
+	}
+	rows, err := ParsePaymentStatement(constants.PaymentStatementFormatAlipay, strings.NewReader(gbk))
+	if err != nil {
+		t.Fatalf("parse alipay statement failed: %v", err)
+	}
+	if len(rows) != 1 || rows[0].GatewayOrderNo != "DJP1001" || rows[0].ProviderRef != "2024010122001" || !rows[0].Amount.Equal(decimal.NewFromInt(10)) || rows[0].TradeAt == nil {
+		t.Fatalf("unexpected alipay rows: %+v", rows)
+	}
+
+	wechat := strings.Join([]string{
+		"交易时间,公众账号ID,商户号,微信订单号,商户订单号,交易状态,货币种类,应结订单金额,订单金额",
+		"`2024-01-01 10:00:00,`wx01,`1900,`4200001,`DJP2001,`SUCCESS,`CNY,`20.00,`20.00",
+		"`2024-01-01 10:05:00,`wx01,`1900,`4200002,`DJP2002,`REFUND,`CNY,`0.00,`20.00",
+		"总交易单数,应结订单总金额,退款总金额",
+		"`2,`20.00,`20.00",
+	}, "\n")
+	rows, err = ParsePaymentStatement(constants.PaymentStatementFormatWechat, strings.NewReader(wechat))
+	if err != nil {
+		t.Fatalf("parse wechat statement failed: %v", err)
+	}
+	if len(rows) != 1 || rows[0].GatewayOrderNo != "DJP2001" || rows[0].Currency != "CNY" {
+		t.Fatalf("unexpected wechat rows: %+v", rows)
+	}
+
+	if _, err := ParsePaymentStatement("unknown", strings.NewReader(wechat)); err != ErrPaymentStatementInvalid {
+		t.Fatalf("expected unknown format rejected, got %v", err)
+	}
+	if _, err := ParsePaymentStatement(constants.PaymentStatementFormatPaypal, strings.NewReader("a,b\n1,2")); err != ErrPaymentStatementInvalid {
+		t.Fatalf("expected missing header rejected, got %v", err)
+	}
+}
+
+func TestImportPaymentStatementFlagsMismatches(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:reconciliation_payment?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Payment{}, &models.PaymentChannel{}, &models.ReconciliationJob{}, &models.ReconciliationItem{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+
+	now := time.Now()
+	newPayment := func(orderNo, amount, status string) {
+		t.Helper()
+		payment := &models.Payment{
+			OrderID: 1, ChannelID: 1, ProviderType: constants.PaymentProviderOfficial, ChannelType: constants.PaymentChannelTypeStripe,
+			InteractionMode: "redirect", Amount: models.NewMoneyFromDecimal(decimal.RequireFromString(amount)),
+			Currency: "USD", Status: status, GatewayOrderNo: orderNo, ProviderRef: "cs_" + orderNo, CreatedAt: now, UpdatedAt: now,
+		}
+		if err := db.Create(payment).Error; err != nil {
+			t.Fatalf("create payment failed: %v", err)
+		}
+	}
+	newPayment("DJP1", "10.00", constants.PaymentStatusSuccess) // 一致
+	newPayment("DJP2", "20.00", constants.PaymentStatusSuccess) // 金额不一致
+	newPayment("DJP3", "30.00", constants.PaymentStatusPending) // 网关已收款，本地未支付
+	newPayment("DJP4", "40.00", constants.PaymentStatusSuccess) // 对账单缺失
+	// 范围开始前创建、范围内完成支付且对账单缺失；以及范围内创建、范围结束后才完成支付
+	for orderNo, times := range map[string][2]time.Time{
+		"DJP5": {now.Add(-3 * time.Hour), now.Add(-30 * time.Minute)},
+		"DJP6": {now.Add(-30 * time.Minute), now.Add(2 * time.Hour)},
+	} {
+		paidAt := times[1]
+		payment := &models.Payment{
+			OrderID: 1, ChannelID: 1, ProviderType: constants.PaymentProviderOfficial, ChannelType: constants.PaymentChannelTypeStripe,
+			InteractionMode: "redirect", Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(50)),
+			Currency: "USD", Status: constants.PaymentStatusSuccess, GatewayOrderNo: orderNo, ProviderRef: "cs_" + orderNo,
+			CreatedAt: times[0], UpdatedAt: times[0], PaidAt: &paidAt,
+		}
+		if err := db.Create(payment).Error; err != nil {
+			t.Fatalf("create payment failed: %v", err)
+		}
+	}
+
+	itemRepo := repository.NewReconciliationItemRepository(db)
+	svc := NewReconciliationService(repository.NewReconciliationJobRepository(db), itemRepo, nil, nil, nil, nil)
+	svc.SetPaymentReconciliation(repository.NewPaymentRepository(db), repository.NewPaymentChannelRepository(db))
+
+	statement := strings.Join([]string{
+		"id,type,source,amount,fee,net,currency,created (utc),payment_metadata[order_no]",
+		"txn_1,charge,pi_1,10.00,0.59,9.41,usd,2024-01-01 10:00,DJP1",
+		"txn_2,charge,pi_2,21.00,0.90,20.10,usd,2024-01-01 10:00,DJP2",
+		"txn_3,charge,pi_3,30.00,1.17,28.83,usd,2024-01-01 10:00,DJP3",
+		"txn_4,charge,pi_9,50.00,1.75,48.25,usd,2024-01-01 10:00,DJP9",
+		"txn_5,refund,pi_1,-10.00,0,-10.00,usd,2024-01-01 11:00,DJP1",
+	}, "\n")
+	start, end := now.Add(-time.Hour), now.Add(time.Hour)
+	job, err := svc.ImportPaymentStatement(ImportPaymentStatementInput{
+		Format:         constants.PaymentStatementFormatStripe,
+		FileName:       "balance.csv",
+		Reader:         bytes.NewBufferString(statement),
+		TimeRangeStart: &start,
+		TimeRangeEnd:   &end,
+	})
+	if err != nil {
+		t.Fatalf("import payment statement failed: %v", err)
+	}
+	if job.Status != constants.ReconciliationJobStatusCompleted || job.Source != constants.ReconciliationSourcePayment {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+	if job.TotalCount != 6 || job.MismatchedCount != 5 || job.MatchedCount != 1 {
+		t.Fatalf("unexpected counts total=%d mismatched=%d matched=%d", job.TotalCount, job.MismatchedCount, job.MatchedCount)
+	}
+
+	items, _, err := itemRepo.ListByJobID(job.ID, 1, 20)
+	if err != nil {
+		t.Fatalf("list items failed: %v", err)
+	}
+	byOrderNo := make(map[string]models.ReconciliationItem, len(items))
+	for _, item := range items {
+		byOrderNo[item.LocalOrderNo] = item
+	}
+	expected := map[string]string{
+		"DJP2": constants.MismatchTypeAmount,
+		"DJP3": constants.MismatchTypeStatus,
+		"DJP4": constants.MismatchTypeMissing,
+		"DJP5": constants.MismatchTypeMissing,
+		"DJP9": constants.MismatchTypeExtra,
+	}
+	for orderNo, mismatchType := range expected {
+		if byOrderNo[orderNo].MismatchType != mismatchType {
+			t.Fatalf("expected %s mismatch for %s, got %+v", mismatchType, orderNo, byOrderNo[orderNo])
+		}
+	}
+	if _, ok := byOrderNo["DJP6"]; ok {
+		t.Fatalf("expected payment settled after range not flagged, got %+v", byOrderNo["DJP6"])
+	}
+}
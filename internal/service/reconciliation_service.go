@@ -21,6 +21,8 @@ var (
 	ErrReconciliationJobNotFound  = errors.New("reconciliation job not found")
 	ErrReconciliationItemNotFound = errors.New("reconciliation item not found")
 	ErrReconciliationJobRunning   = errors.New("reconciliation job is already running")
+	// ErrPaymentStatementFetchUnsupported 支付渠道不支持通过 API 拉取对账数据
+	ErrPaymentStatementFetchUnsupported = errors.New("payment channel does not support statement fetching")
 )
 
 // ReconciliationService 对账服务
@@ -31,6 +33,8 @@ type ReconciliationService struct {
 	connSvc     *SiteConnectionService
 	queueClient *queue.Client
 	notifySvc   *NotificationService
+	paymentRepo repository.PaymentRepository
+	channelRepo repository.PaymentChannelRepository
 }
 
 // NewReconciliationService 创建对账服务
@@ -63,6 +67,7 @@ type RunReconciliationInput struct {
 // CreateAndEnqueue 创建对账任务并入队执行
 func (s *ReconciliationService) CreateAndEnqueue(input RunReconciliationInput) (*models.ReconciliationJob, error) {
 	job := &models.ReconciliationJob{
+		Source:         constants.ReconciliationSourceUpstream,
 		ConnectionID:   input.ConnectionID,
 		Type:           input.Type,
 		Status:         constants.ReconciliationJobStatusPending,
@@ -104,27 +109,36 @@ func (s *ReconciliationService) Execute(ctx context.Context, jobID uint) error {
 		return fmt.Errorf("update job status to running: %w", err)
 	}
 
-	if err := s.executeReconciliation(ctx, job); err != nil {
-		finishedAt := time.Now()
-		job.Status = constants.ReconciliationJobStatusFailed
-		job.FinishedAt = &finishedAt
-		resultJSON, _ := json.Marshal(map[string]string{"error": err.Error()})
-		job.ResultJSON = string(resultJSON)
-		_ = s.jobRepo.Update(job)
+	run := s.executeReconciliation
+	if job.Source == constants.ReconciliationSourcePayment {
+		run = s.executePaymentReconciliation
+	}
+	if err := run(ctx, job); err != nil {
+		s.finishJob(job, err)
 		return fmt.Errorf("execute reconciliation: %w", err)
 	}
+	s.finishJob(job, nil)
+	return nil
+}
 
+// finishJob 回写任务结束状态，有差异项时发送通知
+func (s *ReconciliationService) finishJob(job *models.ReconciliationJob, runErr error) {
 	finishedAt := time.Now()
-	job.Status = constants.ReconciliationJobStatusCompleted
 	job.FinishedAt = &finishedAt
+	if runErr != nil {
+		job.Status = constants.ReconciliationJobStatusFailed
+		resultJSON, _ := json.Marshal(map[string]string{"error": runErr.Error()})
+		job.ResultJSON = string(resultJSON)
+		_ = s.jobRepo.Update(job)
+		return
+	}
+	job.Status = constants.ReconciliationJobStatusCompleted
 	_ = s.jobRepo.Update(job)
 
 	// 如果有差异项，发送通知
 	if job.MismatchedCount > 0 {
 		s.sendMismatchNotification(job)
 	}
-
-	return nil
 }
 
 // executeReconciliation 执行单次对账主流程并回填任务统计结果。
@@ -310,7 +324,9 @@ func (s *ReconciliationService) sendMismatchNotification(job *models.Reconciliat
 		Data: map[string]any{
 			"message":          fmt.Sprintf("对账任务 #%d 完成，发现 %d 项差异", job.ID, job.MismatchedCount),
 			"job_id":           job.ID,
+			"source":           job.Source,
 			"connection_id":    job.ConnectionID,
+			"channel_id":       job.ChannelID,
 			"total_count":      job.TotalCount,
 			"mismatched_count": job.MismatchedCount,
 		},
@@ -0,0 +1,404 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrResellerPriceListNotFound = errors.New("reseller price list not found")
+	ErrResellerPriceListInvalid  = errors.New("reseller price list is invalid")
+	ErrResellerPriceItemInvalid  = errors.New("reseller price list item is invalid")
+)
+
+// ResellerPriceListService 分销价目表服务：为 API 凭证或用户配置商品/SKU 的固定价或折扣
+type ResellerPriceListService struct {
+	repo     repository.ResellerPriceListRepository
+	credRepo repository.ApiCredentialRepository
+	userRepo repository.UserRepository
+}
+
+// NewResellerPriceListService 创建分销价目表服务
+func NewResellerPriceListService(
+	repo repository.ResellerPriceListRepository,
+	credRepo repository.ApiCredentialRepository,
+	userRepo repository.UserRepository,
+) *ResellerPriceListService {
+	return &ResellerPriceListService{
+		repo:     repo,
+		credRepo: credRepo,
+		userRepo: userRepo,
+	}
+}
+
+// ResellerPriceListInput 创建/更新价目表输入
+type ResellerPriceListInput struct {
+	Name                   string
+	Description            string
+	DefaultDiscountPercent decimal.Decimal
+	IsActive               bool
+}
+
+// ResellerPriceItemInput 价目表条目输入
+type ResellerPriceItemInput struct {
+	ProductID       uint
+	SKUID           uint
+	PriceMode       string
+	FixedPrice      decimal.Decimal
+	DiscountPercent decimal.Decimal
+}
+
+// ResellerMarginReportItem 分销利润报表条目
+type ResellerMarginReportItem struct {
+	UserID          uint             `json:"user_id"`
+	UserEmail       string           `json:"user_email"`
+	UserDisplayName string           `json:"user_display_name"`
+	PriceListID     uint             `json:"price_list_id"`
+	PriceListName   string           `json:"price_list_name"`
+	OrderCount      int64            `json:"order_count"`
+	Quantity        int64            `json:"quantity"`
+	Revenue         models.Money     `json:"revenue"`          // 销售额（扣除优惠券）
+	Cost            models.Money     `json:"cost"`             // 成本（按订单项成本价快照）
+	Margin          models.Money     `json:"margin"`           // 利润（仅统计已录入成本价的订单项）
+	MarginPercent   *decimal.Decimal `json:"margin_percent"`   // 利润率（占对应销售额），无成本数据时为空
+	UncostedRevenue models.Money     `json:"uncosted_revenue"` // 未录入成本价的销售额
+}
+
+// GetByID 获取价目表
+func (s *ResellerPriceListService) GetByID(id uint) (*models.ResellerPriceList, error) {
+	list, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		return nil, ErrResellerPriceListNotFound
+	}
+	return list, nil
+}
+
+// List 价目表列表
+func (s *ResellerPriceListService) List(filter repository.ResellerPriceListListFilter) ([]models.ResellerPriceList, int64, error) {
+	return s.repo.List(filter)
+}
+
+// Create 创建价目表
+func (s *ResellerPriceListService) Create(input ResellerPriceListInput) (*models.ResellerPriceList, error) {
+	list := &models.ResellerPriceList{}
+	if err := applyResellerPriceListInput(list, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Update 更新价目表
+func (s *ResellerPriceListService) Update(id uint, input ResellerPriceListInput) (*models.ResellerPriceList, error) {
+	list, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyResellerPriceListInput(list, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Delete 删除价目表
+func (s *ResellerPriceListService) Delete(id uint) error {
+	if _, err := s.GetByID(id); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// ListItems 获取价目表条目
+func (s *ResellerPriceListService) ListItems(id uint) ([]models.ResellerPriceListItem, error) {
+	if _, err := s.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListItems(id)
+}
+
+// ReplaceItems 批量编辑：以提交的条目整体覆盖价目表
+func (s *ResellerPriceListService) ReplaceItems(id uint, inputs []ResellerPriceItemInput) ([]models.ResellerPriceListItem, error) {
+	if _, err := s.GetByID(id); err != nil {
+		return nil, err
+	}
+	items := make([]models.ResellerPriceListItem, 0, len(inputs))
+	seen := make(map[resellerPriceKey]bool, len(inputs))
+	for _, input := range inputs {
+		item, err := buildResellerPriceItem(input)
+		if err != nil {
+			return nil, err
+		}
+		key := resellerPriceKey{productID: item.ProductID, skuID: item.SKUID}
+		if seen[key] {
+			return nil, ErrResellerPriceItemInvalid
+		}
+		seen[key] = true
+		items = append(items, item)
+	}
+	if err := s.repo.ReplaceItems(id, items); err != nil {
+		return nil, err
+	}
+	return s.repo.ListItems(id)
+}
+
+// AssignCredential 为 API 凭证分配价目表，listID 为 0 时取消分配
+func (s *ResellerPriceListService) AssignCredential(credentialID, listID uint) error {
+	cred, err := s.credRepo.GetByID(credentialID)
+	if err != nil {
+		return err
+	}
+	if cred == nil {
+		return ErrApiCredentialNotFound
+	}
+	target, err := s.resolveAssignTarget(listID)
+	if err != nil {
+		return err
+	}
+	return s.repo.AssignCredential(credentialID, target)
+}
+
+// AssignUser 为用户分配价目表，listID 为 0 时取消分配
+func (s *ResellerPriceListService) AssignUser(userID, listID uint) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrNotFound
+	}
+	target, err := s.resolveAssignTarget(listID)
+	if err != nil {
+		return err
+	}
+	return s.repo.AssignUser(userID, target)
+}
+
+// MarginReport 按分销用户统计销售额、成本与利润
+func (s *ResellerPriceListService) MarginReport(filter repository.ResellerMarginFilter) ([]ResellerMarginReportItem, error) {
+	rows, err := s.repo.MarginReport(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		userIDs = append(userIDs, row.UserID)
+	}
+	users := make(map[uint]models.User, len(userIDs))
+	if len(userIDs) > 0 {
+		list, err := s.userRepo.ListByIDs(userIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range list {
+			users[user.ID] = user
+		}
+	}
+	listNames := make(map[uint]string)
+
+	items := make([]ResellerMarginReportItem, 0, len(rows))
+	for _, row := range rows {
+		revenue := decimal.NewFromFloat(row.Revenue).Round(2)
+		cost := decimal.NewFromFloat(row.Cost).Round(2)
+		uncosted := decimal.NewFromFloat(row.UncostedRevenue).Round(2)
+		costedRevenue := revenue.Sub(uncosted)
+		margin := costedRevenue.Sub(cost).Round(2)
+		var marginPercent *decimal.Decimal
+		if costedRevenue.IsPositive() {
+			percent := margin.Div(costedRevenue).Mul(decimal.NewFromInt(100)).Round(2)
+			marginPercent = &percent
+		}
+
+		name, ok := listNames[row.PriceListID]
+		if !ok {
+			// 已删除的价目表仍保留在订单快照中，名称留空
+			if list, err := s.repo.GetByID(row.PriceListID); err == nil && list != nil {
+				name = list.Name
+			}
+			listNames[row.PriceListID] = name
+		}
+
+		user := users[row.UserID]
+		items = append(items, ResellerMarginReportItem{
+			UserID:          row.UserID,
+			UserEmail:       user.Email,
+			UserDisplayName: user.DisplayName,
+			PriceListID:     row.PriceListID,
+			PriceListName:   name,
+			OrderCount:      row.OrderCount,
+			Quantity:        row.Quantity,
+			Revenue:         models.NewMoneyFromDecimal(revenue),
+			Cost:            models.NewMoneyFromDecimal(cost),
+			Margin:          models.NewMoneyFromDecimal(margin),
+			MarginPercent:   marginPercent,
+			UncostedRevenue: models.NewMoneyFromDecimal(uncosted),
+		})
+	}
+	return items, nil
+}
+
+// ResolveListID 解析上游 API 调用方适用的价目表：凭证分配的优先，其次为用户分配的；未分配时返回 0
+func (s *ResellerPriceListService) ResolveListID(credentialID, userID uint) uint {
+	if credentialID > 0 && s.credRepo != nil {
+		if cred, err := s.credRepo.GetByID(credentialID); err == nil && cred != nil && cred.PriceListID != nil {
+			return *cred.PriceListID
+		}
+	}
+	if userID > 0 && s.userRepo != nil {
+		if user, err := s.userRepo.GetByID(userID); err == nil && user != nil && user.PriceListID != nil {
+			return *user.PriceListID
+		}
+	}
+	return 0
+}
+
+// LoadPricing 加载价目表用于批量定价；价目表不存在或已停用时返回 nil
+func (s *ResellerPriceListService) LoadPricing(listID uint) (*ResellerPricing, error) {
+	if listID == 0 {
+		return nil, nil
+	}
+	list, err := s.repo.GetByID(listID)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil || !list.IsActive {
+		return nil, nil
+	}
+	items, err := s.repo.ListItems(listID)
+	if err != nil {
+		return nil, err
+	}
+	pricing := &ResellerPricing{
+		list:  list,
+		items: make(map[resellerPriceKey]models.ResellerPriceListItem, len(items)),
+	}
+	for _, item := range items {
+		pricing.items[resellerPriceKey{productID: item.ProductID, skuID: item.SKUID}] = item
+	}
+	return pricing, nil
+}
+
+// resolveAssignTarget 校验待分配的价目表，0 表示取消分配
+func (s *ResellerPriceListService) resolveAssignTarget(listID uint) (*uint, error) {
+	if listID == 0 {
+		return nil, nil
+	}
+	if _, err := s.GetByID(listID); err != nil {
+		return nil, err
+	}
+	return &listID, nil
+}
+
+type resellerPriceKey struct {
+	productID uint
+	skuID     uint
+}
+
+// ResellerPricing 已加载的价目表，用于一次请求内的批量定价
+type ResellerPricing struct {
+	list  *models.ResellerPriceList
+	items map[resellerPriceKey]models.ResellerPriceListItem
+}
+
+// ListID 价目表 ID
+func (p *ResellerPricing) ListID() uint {
+	if p == nil || p.list == nil {
+		return 0
+	}
+	return p.list.ID
+}
+
+// Resolve 计算分销价：SKU 级条目优先，其次商品级条目，最后使用价目表默认折扣。
+// 仅当分销价低于零售价时返回 matched=true。
+func (p *ResellerPricing) Resolve(productID, skuID uint, basePrice decimal.Decimal) (price decimal.Decimal, matched bool) {
+	if p == nil || p.list == nil || !basePrice.IsPositive() {
+		return basePrice, false
+	}
+	var candidate decimal.Decimal
+	if item, ok := p.items[resellerPriceKey{productID: productID, skuID: skuID}]; ok && skuID > 0 {
+		candidate = resellerItemPrice(item, basePrice)
+	} else if item, ok := p.items[resellerPriceKey{productID: productID}]; ok {
+		candidate = resellerItemPrice(item, basePrice)
+	} else if p.list.DefaultDiscountPercent.IsPositive() {
+		candidate = applyResellerDiscount(basePrice, p.list.DefaultDiscountPercent)
+	} else {
+		return basePrice, false
+	}
+	if !candidate.IsPositive() || !candidate.LessThan(basePrice) {
+		return basePrice, false
+	}
+	return candidate, true
+}
+
+func resellerItemPrice(item models.ResellerPriceListItem, basePrice decimal.Decimal) decimal.Decimal {
+	if item.PriceMode == constants.ResellerPriceModeFixed {
+		return item.FixedPrice.Decimal.Round(2)
+	}
+	return applyResellerDiscount(basePrice, item.DiscountPercent)
+}
+
+// applyResellerDiscount 零售价减去折扣百分比
+func applyResellerDiscount(basePrice, percent decimal.Decimal) decimal.Decimal {
+	hundred := decimal.NewFromInt(100)
+	return basePrice.Mul(hundred.Sub(percent)).Div(hundred).Round(2)
+}
+
+func applyResellerPriceListInput(list *models.ResellerPriceList, input ResellerPriceListInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || !validResellerDiscountPercent(input.DefaultDiscountPercent, true) {
+		return ErrResellerPriceListInvalid
+	}
+	list.Name = name
+	list.Description = strings.TrimSpace(input.Description)
+	list.DefaultDiscountPercent = input.DefaultDiscountPercent
+	list.IsActive = input.IsActive
+	return nil
+}
+
+func buildResellerPriceItem(input ResellerPriceItemInput) (models.ResellerPriceListItem, error) {
+	item := models.ResellerPriceListItem{
+		ProductID: input.ProductID,
+		SKUID:     input.SKUID,
+		PriceMode: strings.TrimSpace(input.PriceMode),
+	}
+	if item.ProductID == 0 {
+		return item, ErrResellerPriceItemInvalid
+	}
+	switch item.PriceMode {
+	case constants.ResellerPriceModeFixed:
+		if !input.FixedPrice.IsPositive() {
+			return item, ErrResellerPriceItemInvalid
+		}
+		item.FixedPrice = models.NewMoneyFromDecimal(input.FixedPrice.Round(2))
+	case constants.ResellerPriceModeDiscount:
+		if !validResellerDiscountPercent(input.DiscountPercent, false) {
+			return item, ErrResellerPriceItemInvalid
+		}
+		item.DiscountPercent = input.DiscountPercent
+	default:
+		return item, ErrResellerPriceItemInvalid
+	}
+	return item, nil
+}
+
+// validResellerDiscountPercent 折扣百分比需在 (0, 100) 内，allowZero 时允许 0（不打折）
+func validResellerDiscountPercent(percent decimal.Decimal, allowZero bool) bool {
+	if percent.IsZero() {
+		return allowZero
+	}
+	return percent.IsPositive() && percent.LessThan(decimal.NewFromInt(100))
+}
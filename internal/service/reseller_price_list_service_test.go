@@ -0,0 +1,207 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func TestResellerPriceListPricingAndMargins(t *testing.T) {
+	dsn := fmt.Sprintf("file:reseller_price_list_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Category{}, &models.Product{}, &models.ProductSKU{}, &models.Promotion{},
+		&models.User{}, &models.MemberLevel{}, &models.MemberLevelPrice{}, &models.ApiCredential{},
+		&models.ResellerPriceList{}, &models.ResellerPriceListItem{}, &models.Order{}, &models.OrderItem{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+
+	now := time.Now()
+	category := models.Category{Slug: "reseller", NameJSON: models.JSON{"zh-CN": "分销"}, CreatedAt: now}
+	if err := db.Create(&category).Error; err != nil {
+		t.Fatalf("create category failed: %v", err)
+	}
+	newSKU := func(slug string, price, cost int64) (models.Product, models.ProductSKU) {
+		product := models.Product{
+			CategoryID:      category.ID,
+			Slug:            slug,
+			TitleJSON:       models.JSON{"zh-CN": slug},
+			PriceAmount:     models.NewMoneyFromDecimal(decimal.NewFromInt(price)),
+			PurchaseType:    constants.ProductPurchaseMember,
+			FulfillmentType: constants.FulfillmentTypeManual,
+			IsActive:        true,
+		}
+		if err := db.Create(&product).Error; err != nil {
+			t.Fatalf("create product failed: %v", err)
+		}
+		sku := models.ProductSKU{
+			ProductID:        product.ID,
+			SKUCode:          models.DefaultSKUCode,
+			PriceAmount:      models.NewMoneyFromDecimal(decimal.NewFromInt(price)),
+			CostPriceAmount:  models.NewMoneyFromDecimal(decimal.NewFromInt(cost)),
+			IsActive:         true,
+			ManualStockTotal: constants.ManualStockUnlimited,
+		}
+		if err := db.Create(&sku).Error; err != nil {
+			t.Fatalf("create sku failed: %v", err)
+		}
+		return product, sku
+	}
+	fixedProduct, fixedSKU := newSKU("fixed", 100, 60)
+	defaultProduct, defaultSKU := newSKU("default", 50, 0)
+
+	// 会员等级 9 折
+	level := models.MemberLevel{NameJSON: models.JSON{"zh-CN": "VIP"}, Slug: "vip", DiscountRate: models.NewMoneyFromDecimal(decimal.NewFromInt(90)), IsActive: true}
+	if err := db.Create(&level).Error; err != nil {
+		t.Fatalf("create member level failed: %v", err)
+	}
+	user := models.User{Email: "reseller@example.com", PasswordHash: "x", MemberLevelID: level.ID}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user failed: %v", err)
+	}
+	cred := models.ApiCredential{UserID: user.ID, ApiKey: "reseller-key", Status: "approved", IsActive: true}
+	if err := db.Create(&cred).Error; err != nil {
+		t.Fatalf("create credential failed: %v", err)
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	svc := NewResellerPriceListService(repository.NewResellerPriceListRepository(db), repository.NewApiCredentialRepository(db), userRepo)
+	list, err := svc.Create(ResellerPriceListInput{Name: "金牌分销", DefaultDiscountPercent: decimal.NewFromInt(20), IsActive: true})
+	if err != nil {
+		t.Fatalf("create price list failed: %v", err)
+	}
+	if _, err := svc.ReplaceItems(list.ID, []ResellerPriceItemInput{
+		{ProductID: fixedProduct.ID, SKUID: fixedSKU.ID, PriceMode: constants.ResellerPriceModeFixed, FixedPrice: decimal.NewFromInt(75)},
+		{ProductID: fixedProduct.ID, SKUID: fixedSKU.ID, PriceMode: constants.ResellerPriceModeDiscount, DiscountPercent: decimal.NewFromInt(5)},
+	}); err != ErrResellerPriceItemInvalid {
+		t.Fatalf("expected duplicate item rejected, got %v", err)
+	}
+	if _, err := svc.ReplaceItems(list.ID, []ResellerPriceItemInput{
+		{ProductID: fixedProduct.ID, SKUID: fixedSKU.ID, PriceMode: constants.ResellerPriceModeFixed, FixedPrice: decimal.NewFromInt(75)},
+	}); err != nil {
+		t.Fatalf("replace items failed: %v", err)
+	}
+
+	// 未分配时走会员价；用户分配后生效；凭证分配优先于用户分配
+	if id := svc.ResolveListID(cred.ID, user.ID); id != 0 {
+		t.Fatalf("expected no price list, got %d", id)
+	}
+	other, err := svc.Create(ResellerPriceListInput{Name: "普通分销", IsActive: true})
+	if err != nil {
+		t.Fatalf("create second list failed: %v", err)
+	}
+	if err := svc.AssignUser(user.ID, other.ID); err != nil {
+		t.Fatalf("assign user failed: %v", err)
+	}
+	if err := svc.AssignCredential(cred.ID, list.ID); err != nil {
+		t.Fatalf("assign credential failed: %v", err)
+	}
+	if id := svc.ResolveListID(cred.ID, user.ID); id != list.ID {
+		t.Fatalf("expected credential price list %d, got %d", list.ID, id)
+	}
+	if id := svc.ResolveListID(0, user.ID); id != other.ID {
+		t.Fatalf("expected user price list %d, got %d", other.ID, id)
+	}
+
+	orderSvc := NewOrderService(OrderServiceOptions{
+		UserRepo:           userRepo,
+		ProductRepo:        repository.NewProductRepository(db),
+		ProductSKURepo:     repository.NewProductSKURepository(db),
+		PromotionRepo:      repository.NewPromotionRepository(db),
+		MemberLevelService: NewMemberLevelService(repository.NewMemberLevelRepository(db), repository.NewMemberLevelPriceRepository(db), userRepo),
+		ExpireMinutes:      15,
+	})
+	orderSvc.SetResellerPriceListService(svc)
+	params := orderCreateParams{
+		UserID: user.ID,
+		Items: []CreateOrderItem{
+			{ProductID: fixedProduct.ID, SKUID: fixedSKU.ID, Quantity: 2},
+			{ProductID: defaultProduct.ID, SKUID: defaultSKU.ID, Quantity: 1},
+		},
+	}
+
+	// 无价目表：会员 9 折 180 + 45
+	result, err := orderSvc.buildOrderResult(params)
+	if err != nil {
+		t.Fatalf("build member order failed: %v", err)
+	}
+	if !result.TotalAmount.Equal(decimal.NewFromInt(225)) || result.PriceListID != nil {
+		t.Fatalf("unexpected member pricing: total=%s price_list=%v", result.TotalAmount, result.PriceListID)
+	}
+
+	// 价目表：SKU 固定价 75×2，其余商品默认 8 折 40
+	params.PriceListID = list.ID
+	result, err = orderSvc.buildOrderResult(params)
+	if err != nil {
+		t.Fatalf("build reseller order failed: %v", err)
+	}
+	if !result.TotalAmount.Equal(decimal.NewFromInt(190)) || result.PriceListID == nil || *result.PriceListID != list.ID {
+		t.Fatalf("unexpected reseller pricing: total=%s price_list=%v", result.TotalAmount, result.PriceListID)
+	}
+
+	// 停用后回落到会员价
+	if _, err := svc.Update(list.ID, ResellerPriceListInput{Name: list.Name, DefaultDiscountPercent: decimal.NewFromInt(20), IsActive: false}); err != nil {
+		t.Fatalf("deactivate list failed: %v", err)
+	}
+	result, err = orderSvc.buildOrderResult(params)
+	if err != nil {
+		t.Fatalf("build order with inactive list failed: %v", err)
+	}
+	if !result.TotalAmount.Equal(decimal.NewFromInt(225)) || result.PriceListID != nil {
+		t.Fatalf("expected inactive list ignored: total=%s", result.TotalAmount)
+	}
+
+	// 利润报表：按子订单的订单项统计，父订单去重
+	listID := list.ID
+	parent := models.Order{OrderNo: "RS-1", UserID: user.ID, Status: constants.OrderStatusPaid, Currency: "CNY", PriceListID: &listID}
+	if err := db.Create(&parent).Error; err != nil {
+		t.Fatalf("create parent order failed: %v", err)
+	}
+	for i, item := range []models.OrderItem{
+		{ProductID: fixedProduct.ID, SKUID: fixedSKU.ID, UnitPrice: models.NewMoneyFromDecimal(decimal.NewFromInt(75)), CostPrice: models.NewMoneyFromDecimal(decimal.NewFromInt(60)), Quantity: 2, TotalPrice: models.NewMoneyFromDecimal(decimal.NewFromInt(150))},
+		{ProductID: defaultProduct.ID, SKUID: defaultSKU.ID, UnitPrice: models.NewMoneyFromDecimal(decimal.NewFromInt(40)), Quantity: 1, TotalPrice: models.NewMoneyFromDecimal(decimal.NewFromInt(40))},
+	} {
+		child := models.Order{OrderNo: fmt.Sprintf("RS-1-%d", i+1), ParentID: &parent.ID, UserID: user.ID, Status: constants.OrderStatusPaid, Currency: "CNY", PriceListID: &listID}
+		if err := db.Create(&child).Error; err != nil {
+			t.Fatalf("create child order failed: %v", err)
+		}
+		item.OrderID = child.ID
+		item.TitleJSON = models.JSON{"zh-CN": "item"}
+		if err := db.Create(&item).Error; err != nil {
+			t.Fatalf("create order item failed: %v", err)
+		}
+	}
+
+	margins, err := svc.MarginReport(repository.ResellerMarginFilter{})
+	if err != nil {
+		t.Fatalf("margin report failed: %v", err)
+	}
+	if len(margins) != 1 {
+		t.Fatalf("expected one reseller row, got %+v", margins)
+	}
+	row := margins[0]
+	if row.UserID != user.ID || row.PriceListName != list.Name || row.OrderCount != 1 || row.Quantity != 3 ||
+		!row.Revenue.Decimal.Equal(decimal.NewFromInt(190)) || !row.Cost.Decimal.Equal(decimal.NewFromInt(120)) ||
+		!row.Margin.Decimal.Equal(decimal.NewFromInt(30)) || !row.UncostedRevenue.Decimal.Equal(decimal.NewFromInt(40)) ||
+		row.MarginPercent == nil || !row.MarginPercent.Equal(decimal.NewFromInt(20)) {
+		t.Fatalf("unexpected margin row: %+v", row)
+	}
+
+	// 删除价目表解除分配
+	if err := svc.Delete(list.ID); err != nil {
+		t.Fatalf("delete list failed: %v", err)
+	}
+	if id := svc.ResolveListID(cred.ID, user.ID); id != other.ID {
+		t.Fatalf("expected credential assignment cleared, got %d", id)
+	}
+}
@@ -360,6 +360,42 @@ func (s *SettingService) GetWalletOnlyPayment() bool {
 	return parseSettingBool(raw)
 }
 
+// GetWalletCurrencyConfig 获取多币种钱包配置（允许币种与兑换汇率）
+func (s *SettingService) GetWalletCurrencyConfig() WalletCurrencyConfig {
+	if s == nil {
+		return walletCurrencyConfigFromJSON(nil)
+	}
+	value, err := s.GetByKey(constants.SettingKeyWalletConfig)
+	if err != nil {
+		return walletCurrencyConfigFromJSON(nil)
+	}
+	return walletCurrencyConfigFromJSON(value)
+}
+
+// GetWalletTransferConfig 获取用户间转账配置（开关、限额与手续费）
+func (s *SettingService) GetWalletTransferConfig() WalletTransferConfig {
+	if s == nil {
+		return walletTransferConfigFromJSON(nil)
+	}
+	value, err := s.GetByKey(constants.SettingKeyWalletConfig)
+	if err != nil {
+		return walletTransferConfigFromJSON(nil)
+	}
+	return walletTransferConfigFromJSON(value)
+}
+
+// GetWalletWithdrawConfig 获取钱包余额提现配置
+func (s *SettingService) GetWalletWithdrawConfig() WalletWithdrawConfig {
+	if s == nil {
+		return walletWithdrawConfigFromJSON(nil)
+	}
+	value, err := s.GetByKey(constants.SettingKeyWalletConfig)
+	if err != nil {
+		return walletWithdrawConfigFromJSON(nil)
+	}
+	return walletWithdrawConfigFromJSON(value)
+}
+
 // GetCallbackRoutes 获取自定义回调路由配置。未配置时返回 nil。
 func (s *SettingService) GetCallbackRoutes() *CallbackRoutesSetting {
 	if s == nil {
@@ -3,11 +3,13 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/dujiao-next/internal/constants"
 	"github.com/dujiao-next/internal/crypto"
+	"github.com/dujiao-next/internal/logger"
 	"github.com/dujiao-next/internal/models"
 	"github.com/dujiao-next/internal/repository"
 	"github.com/dujiao-next/internal/upstream"
@@ -18,6 +20,8 @@ import (
 var (
 	ErrConnectionNotFound = errors.New("site connection not found")
 	ErrConnectionInvalid  = errors.New("site connection is invalid")
+	// ErrConnectionTemplateInvalid generic-rest 连接的接口模板不合法
+	ErrConnectionTemplateInvalid = errors.New("site connection rest template is invalid")
 )
 
 // SiteConnectionService 对接连接服务
@@ -25,6 +29,8 @@ type SiteConnectionService struct {
 	connRepo   repository.SiteConnectionRepository
 	encryptKey []byte
 	uploadsDir string
+	// onExchangeRateChanged 汇率变更后的回调（用于重新执行利润保护）
+	onExchangeRateChanged func(conn *models.SiteConnection)
 }
 
 // NewSiteConnectionService 创建连接服务
@@ -38,18 +44,23 @@ func NewSiteConnectionService(connRepo repository.SiteConnectionRepository, appS
 
 // CreateConnectionInput 创建连接输入
 type CreateConnectionInput struct {
-	Name               string  `json:"name"`
-	BaseURL            string  `json:"base_url"`
-	ApiKey             string  `json:"api_key"`
-	ApiSecret          string  `json:"api_secret"`
-	Protocol           string  `json:"protocol"`
-	CallbackURL        string  `json:"callback_url"`
-	RetryMax           int     `json:"retry_max"`
-	RetryIntervals     string  `json:"retry_intervals"`
-	ExchangeRate       float64 `json:"exchange_rate"`
-	PriceMarkupPercent float64 `json:"price_markup_percent"`
-	PriceRoundingMode  string  `json:"price_rounding_mode"`
-	AutoSyncPrice      bool    `json:"auto_sync_price"`
+	Name                  string      `json:"name"`
+	BaseURL               string      `json:"base_url"`
+	ApiKey                string      `json:"api_key"`
+	ApiSecret             string      `json:"api_secret"`
+	Protocol              string      `json:"protocol"`
+	RestTemplate          models.JSON `json:"rest_template"` // generic-rest 协议必填
+	CallbackURL           string      `json:"callback_url"`
+	RetryMax              int         `json:"retry_max"`
+	RetryIntervals        string      `json:"retry_intervals"`
+	ExchangeRate          float64     `json:"exchange_rate"`
+	PriceMarkupPercent    float64     `json:"price_markup_percent"`
+	PriceRoundingMode     string      `json:"price_rounding_mode"`
+	AutoSyncPrice         bool        `json:"auto_sync_price"`
+	MarginGuardMode       string      `json:"margin_guard_mode"`       // off / deactivate / reprice，默认 off
+	MinMarginPercent      float64     `json:"min_margin_percent"`      // 最低利润率百分比，0 ~ 100
+	BalanceAlertThreshold float64     `json:"balance_alert_threshold"` // 上游余额告警下限，0 表示不告警
+	BalanceAlertDays      int         `json:"balance_alert_days"`      // 预计可用天数告警下限，0 表示不告警
 }
 
 // Create 创建连接
@@ -57,14 +68,20 @@ func (s *SiteConnectionService) Create(input CreateConnectionInput) (*models.Sit
 	if strings.TrimSpace(input.Name) == "" || strings.TrimSpace(input.BaseURL) == "" {
 		return nil, ErrConnectionInvalid
 	}
-	if strings.TrimSpace(input.ApiKey) == "" || strings.TrimSpace(input.ApiSecret) == "" {
-		return nil, ErrConnectionInvalid
-	}
-
 	protocol := strings.TrimSpace(input.Protocol)
 	if protocol == "" {
 		protocol = constants.ConnectionProtocolDujiaoNext
 	}
+	// generic-rest 上游可能只需要 Key（或无需鉴权），Secret 可为空
+	if strings.TrimSpace(input.ApiKey) == "" {
+		return nil, ErrConnectionInvalid
+	}
+	if strings.TrimSpace(input.ApiSecret) == "" && protocol != constants.ConnectionProtocolGenericREST {
+		return nil, ErrConnectionInvalid
+	}
+	if err := validateConnectionProtocol(protocol, input.RestTemplate); err != nil {
+		return nil, err
+	}
 
 	encryptedSecret, err := crypto.Encrypt(s.encryptKey, input.ApiSecret)
 	if err != nil {
@@ -84,21 +101,37 @@ func (s *SiteConnectionService) Create(input CreateConnectionInput) (*models.Sit
 	if roundingMode == "" {
 		roundingMode = "none"
 	}
+	guardMode := strings.TrimSpace(input.MarginGuardMode)
+	if guardMode == "" {
+		guardMode = constants.MarginGuardModeOff
+	}
+	minMargin := decimal.NewFromFloat(input.MinMarginPercent)
+	if !validMarginGuard(guardMode, minMargin) {
+		return nil, ErrConnectionInvalid
+	}
+	if input.BalanceAlertThreshold < 0 || input.BalanceAlertDays < 0 {
+		return nil, ErrConnectionInvalid
+	}
 
 	conn := &models.SiteConnection{
-		Name:               strings.TrimSpace(input.Name),
-		BaseURL:            strings.TrimRight(strings.TrimSpace(input.BaseURL), "/"),
-		ApiKey:             strings.TrimSpace(input.ApiKey),
-		ApiSecret:          encryptedSecret,
-		Protocol:           protocol,
-		CallbackURL:        strings.TrimSpace(input.CallbackURL),
-		Status:             constants.ConnectionStatusPending,
-		RetryMax:           retryMax,
-		RetryIntervals:     retryIntervals,
-		ExchangeRate:       s.normalizeExchangeRate(input.ExchangeRate),
-		PriceMarkupPercent: decimal.NewFromFloat(input.PriceMarkupPercent),
-		PriceRoundingMode:  roundingMode,
-		AutoSyncPrice:      input.AutoSyncPrice,
+		Name:                  strings.TrimSpace(input.Name),
+		BaseURL:               strings.TrimRight(strings.TrimSpace(input.BaseURL), "/"),
+		ApiKey:                strings.TrimSpace(input.ApiKey),
+		ApiSecret:             encryptedSecret,
+		Protocol:              protocol,
+		RestTemplate:          input.RestTemplate,
+		CallbackURL:           strings.TrimSpace(input.CallbackURL),
+		Status:                constants.ConnectionStatusPending,
+		RetryMax:              retryMax,
+		RetryIntervals:        retryIntervals,
+		ExchangeRate:          s.normalizeExchangeRate(input.ExchangeRate),
+		PriceMarkupPercent:    decimal.NewFromFloat(input.PriceMarkupPercent),
+		PriceRoundingMode:     roundingMode,
+		AutoSyncPrice:         input.AutoSyncPrice,
+		MarginGuardMode:       guardMode,
+		MinMarginPercent:      minMargin,
+		BalanceAlertThreshold: decimal.NewFromFloat(input.BalanceAlertThreshold).Round(2),
+		BalanceAlertDays:      input.BalanceAlertDays,
 	}
 
 	if err := s.connRepo.Create(conn); err != nil {
@@ -109,18 +142,23 @@ func (s *SiteConnectionService) Create(input CreateConnectionInput) (*models.Sit
 
 // UpdateConnectionInput 更新连接输入
 type UpdateConnectionInput struct {
-	Name               string   `json:"name"`
-	BaseURL            string   `json:"base_url"`
-	ApiKey             string   `json:"api_key"`
-	ApiSecret          string   `json:"api_secret"` // 为空则不更新
-	Protocol           string   `json:"protocol"`
-	CallbackURL        string   `json:"callback_url"`
-	RetryMax           int      `json:"retry_max"`
-	RetryIntervals     string   `json:"retry_intervals"`
-	ExchangeRate       *float64 `json:"exchange_rate"`
-	PriceMarkupPercent *float64 `json:"price_markup_percent"` // 指针类型，区分 0 和未传
-	PriceRoundingMode  *string  `json:"price_rounding_mode"`
-	AutoSyncPrice      *bool    `json:"auto_sync_price"`
+	Name                  string      `json:"name"`
+	BaseURL               string      `json:"base_url"`
+	ApiKey                string      `json:"api_key"`
+	ApiSecret             string      `json:"api_secret"` // 为空则不更新
+	Protocol              string      `json:"protocol"`
+	RestTemplate          models.JSON `json:"rest_template"` // 为空则不更新
+	CallbackURL           string      `json:"callback_url"`
+	RetryMax              int         `json:"retry_max"`
+	RetryIntervals        string      `json:"retry_intervals"`
+	ExchangeRate          *float64    `json:"exchange_rate"`
+	PriceMarkupPercent    *float64    `json:"price_markup_percent"` // 指针类型，区分 0 和未传
+	PriceRoundingMode     *string     `json:"price_rounding_mode"`
+	AutoSyncPrice         *bool       `json:"auto_sync_price"`
+	MarginGuardMode       *string     `json:"margin_guard_mode"`
+	MinMarginPercent      *float64    `json:"min_margin_percent"`
+	BalanceAlertThreshold *float64    `json:"balance_alert_threshold"`
+	BalanceAlertDays      *int        `json:"balance_alert_days"`
 }
 
 // Update 更新连接
@@ -152,6 +190,12 @@ func (s *SiteConnectionService) Update(id uint, input UpdateConnectionInput) (*m
 	if strings.TrimSpace(input.Protocol) != "" {
 		conn.Protocol = strings.TrimSpace(input.Protocol)
 	}
+	if len(input.RestTemplate) > 0 {
+		conn.RestTemplate = input.RestTemplate
+	}
+	if err := validateConnectionProtocol(conn.Protocol, conn.RestTemplate); err != nil {
+		return nil, err
+	}
 	if input.CallbackURL != "" {
 		conn.CallbackURL = strings.TrimSpace(input.CallbackURL)
 	}
@@ -161,6 +205,7 @@ func (s *SiteConnectionService) Update(id uint, input UpdateConnectionInput) (*m
 	if strings.TrimSpace(input.RetryIntervals) != "" {
 		conn.RetryIntervals = strings.TrimSpace(input.RetryIntervals)
 	}
+	rateBefore := conn.ExchangeRate
 	if input.ExchangeRate != nil {
 		conn.ExchangeRate = s.normalizeExchangeRate(*input.ExchangeRate)
 	}
@@ -177,13 +222,45 @@ func (s *SiteConnectionService) Update(id uint, input UpdateConnectionInput) (*m
 	if input.AutoSyncPrice != nil {
 		conn.AutoSyncPrice = *input.AutoSyncPrice
 	}
+	if input.MarginGuardMode != nil {
+		conn.MarginGuardMode = strings.TrimSpace(*input.MarginGuardMode)
+		if conn.MarginGuardMode == "" {
+			conn.MarginGuardMode = constants.MarginGuardModeOff
+		}
+	}
+	if input.MinMarginPercent != nil {
+		conn.MinMarginPercent = decimal.NewFromFloat(*input.MinMarginPercent)
+	}
+	if !validMarginGuard(conn.MarginGuardMode, conn.MinMarginPercent) {
+		return nil, ErrConnectionInvalid
+	}
+	if input.BalanceAlertThreshold != nil {
+		if *input.BalanceAlertThreshold < 0 {
+			return nil, ErrConnectionInvalid
+		}
+		conn.BalanceAlertThreshold = decimal.NewFromFloat(*input.BalanceAlertThreshold).Round(2)
+	}
+	if input.BalanceAlertDays != nil {
+		if *input.BalanceAlertDays < 0 {
+			return nil, ErrConnectionInvalid
+		}
+		conn.BalanceAlertDays = *input.BalanceAlertDays
+	}
 
 	if err := s.connRepo.Update(conn); err != nil {
 		return nil, err
 	}
+	if !conn.ExchangeRate.Equal(rateBefore) && s.onExchangeRateChanged != nil {
+		s.onExchangeRateChanged(conn)
+	}
 	return conn, nil
 }
 
+// SetExchangeRateChangedHook 设置汇率变更回调
+func (s *SiteConnectionService) SetExchangeRateChangedHook(fn func(conn *models.SiteConnection)) {
+	s.onExchangeRateChanged = fn
+}
+
 // Delete 删除连接
 func (s *SiteConnectionService) Delete(id uint) error {
 	conn, err := s.connRepo.GetByID(id)
@@ -236,11 +313,16 @@ func (s *SiteConnectionService) Ping(id uint) (*upstream.PingResult, error) {
 	}
 
 	adapter, err := upstream.NewAdapter(&models.SiteConnection{
-		BaseURL:   conn.BaseURL,
-		ApiKey:    conn.ApiKey,
-		ApiSecret: decrypted,
-		Protocol:  conn.Protocol,
+		BaseURL:         conn.BaseURL,
+		ApiKey:          conn.ApiKey,
+		ApiSecret:       decrypted,
+		Protocol:        conn.Protocol,
+		ProtocolVersion: conn.ProtocolVersion,
+		RestTemplate:    conn.RestTemplate,
 	}, s.uploadsDir)
+	if err != nil {
+		return nil, err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
@@ -253,6 +335,13 @@ func (s *SiteConnectionService) Ping(id uint) (*upstream.PingResult, error) {
 	if pingErr == nil && conn.Status == constants.ConnectionStatusPending {
 		conn.Status = constants.ConnectionStatusActive
 	}
+	// 记录上游协议版本并顺带刷新已知余额，供能力判断与采购提交前的余额检查使用
+	if pingErr == nil {
+		conn.ProtocolVersion = strings.TrimSpace(result.ProtocolVersion)
+		if balance, err := parseUpstreamBalance(result); err == nil {
+			applyUpstreamBalance(conn, balance, strings.TrimSpace(result.Currency), now)
+		}
+	}
 
 	// 更新连接状态（不管 ping 是否成功）
 	_ = s.connRepo.Update(conn)
@@ -260,9 +349,31 @@ func (s *SiteConnectionService) Ping(id uint) (*upstream.PingResult, error) {
 	if pingErr != nil {
 		return nil, pingErr
 	}
+
+	// 连通后向支持的上游注册目录变更推送（复用回调地址），失败不影响连接测试结果
+	if registrar, ok := adapter.(upstream.CatalogWebhookRegistrar); ok && conn.CallbackURL != "" &&
+		upstream.SupportsFeature(conn.ProtocolVersion, upstream.FeatureCatalogWebhooks) {
+		if err := registrar.RegisterCatalogWebhook(ctx, conn.CallbackURL); err != nil {
+			logger.Warnw("site_connection_register_catalog_webhook_failed", "connection_id", conn.ID, "error", err)
+		}
+	}
 	return result, nil
 }
 
+// RefreshBalance 通过 Ping 重新获取上游余额，仅写回余额字段
+func (s *SiteConnectionService) RefreshBalance(ctx context.Context, conn *models.SiteConnection, adapter upstream.Adapter) error {
+	result, err := adapter.Ping(ctx)
+	if err != nil {
+		return err
+	}
+	balance, err := parseUpstreamBalance(result)
+	if err != nil {
+		return err
+	}
+	applyUpstreamBalance(conn, balance, strings.TrimSpace(result.Currency), time.Now())
+	return s.connRepo.UpdateBalance(conn)
+}
+
 // GetAdapter 获取连接的适配器（解密 secret 后构建）
 func (s *SiteConnectionService) GetAdapter(conn *models.SiteConnection) (upstream.Adapter, error) {
 	decrypted, err := s.decryptSecret(conn)
@@ -271,13 +382,66 @@ func (s *SiteConnectionService) GetAdapter(conn *models.SiteConnection) (upstrea
 	}
 
 	return upstream.NewAdapter(&models.SiteConnection{
-		BaseURL:   conn.BaseURL,
-		ApiKey:    conn.ApiKey,
-		ApiSecret: decrypted,
-		Protocol:  conn.Protocol,
+		BaseURL:         conn.BaseURL,
+		ApiKey:          conn.ApiKey,
+		ApiSecret:       decrypted,
+		Protocol:        conn.Protocol,
+		ProtocolVersion: conn.ProtocolVersion,
+		RestTemplate:    conn.RestTemplate,
 	}, s.uploadsDir)
 }
 
+// DryRunTemplate 使用连接凭证试运行 generic-rest 模板
+// template 非空时使用传入的模板（便于保存前调试），否则使用连接已保存的模板
+func (s *SiteConnectionService) DryRunTemplate(id uint, template models.JSON, input upstream.RESTDryRunInput) ([]upstream.RESTDryRunStep, error) {
+	conn, err := s.connRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		return nil, ErrConnectionNotFound
+	}
+	if conn.Protocol != constants.ConnectionProtocolGenericREST {
+		return nil, ErrConnectionInvalid
+	}
+	decrypted, err := s.decryptSecret(conn)
+	if err != nil {
+		return nil, err
+	}
+	if len(template) == 0 {
+		template = conn.RestTemplate
+	}
+	adapter, err := upstream.NewGenericRESTAdapter(&models.SiteConnection{
+		BaseURL:      conn.BaseURL,
+		ApiKey:       conn.ApiKey,
+		ApiSecret:    decrypted,
+		Protocol:     conn.Protocol,
+		RestTemplate: template,
+	}, s.uploadsDir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrConnectionTemplateInvalid, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	return adapter.DryRun(ctx, input), nil
+}
+
+// validateConnectionProtocol 校验协议类型及 generic-rest 模板
+func validateConnectionProtocol(protocol string, template models.JSON) error {
+	switch protocol {
+	case constants.ConnectionProtocolDujiaoNext:
+		return nil
+	case constants.ConnectionProtocolGenericREST:
+		if _, err := upstream.ParseRESTTemplate(template); err != nil {
+			return fmt.Errorf("%w: %v", ErrConnectionTemplateInvalid, err)
+		}
+		return nil
+	default:
+		return ErrConnectionInvalid
+	}
+}
+
 func (s *SiteConnectionService) decryptSecret(conn *models.SiteConnection) (string, error) {
 	return crypto.Decrypt(s.encryptKey, conn.ApiSecret)
 }
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+)
+
+// SKUSourceService SKU 多来源供货配置服务
+type SKUSourceService struct {
+	sourceRepo     repository.SKUSourceRepository
+	productRepo    repository.ProductRepository
+	productSKURepo repository.ProductSKURepository
+	connSvc        *SiteConnectionService
+}
+
+// NewSKUSourceService 创建 SKU 供货来源服务
+func NewSKUSourceService(
+	sourceRepo repository.SKUSourceRepository,
+	productRepo repository.ProductRepository,
+	productSKURepo repository.ProductSKURepository,
+	connSvc *SiteConnectionService,
+) *SKUSourceService {
+	return &SKUSourceService{
+		sourceRepo:     sourceRepo,
+		productRepo:    productRepo,
+		productSKURepo: productSKURepo,
+		connSvc:        connSvc,
+	}
+}
+
+// SKUSourceInput 供货来源创建/更新输入
+type SKUSourceInput struct {
+	LocalSKUID        uint
+	Priority          int
+	SourceType        string
+	ConnectionID      uint
+	UpstreamProductID uint
+	UpstreamSKUID     uint
+	MaxUnitCost       models.Money
+	IsActive          *bool
+}
+
+// List 来源列表
+func (s *SKUSourceService) List(filter repository.SKUSourceListFilter) ([]models.SKUSource, int64, error) {
+	return s.sourceRepo.List(filter)
+}
+
+// Create 创建供货来源
+func (s *SKUSourceService) Create(input SKUSourceInput) (*models.SKUSource, error) {
+	source := &models.SKUSource{IsActive: true}
+	if err := s.applyInput(source, input); err != nil {
+		return nil, err
+	}
+	if err := s.sourceRepo.Create(source); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// Update 更新供货来源
+func (s *SKUSourceService) Update(id uint, input SKUSourceInput) (*models.SKUSource, error) {
+	source, err := s.sourceRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, ErrSKUSourceNotFound
+	}
+	if err := s.applyInput(source, input); err != nil {
+		return nil, err
+	}
+	if err := s.sourceRepo.Update(source); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// Delete 删除供货来源
+func (s *SKUSourceService) Delete(id uint) error {
+	source, err := s.sourceRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if source == nil {
+		return ErrSKUSourceNotFound
+	}
+	return s.sourceRepo.Delete(id)
+}
+
+// applyInput 校验并写入来源字段：仅上游交付商品可配置多来源，同一 SKU 的同一来源不可重复
+func (s *SKUSourceService) applyInput(source *models.SKUSource, input SKUSourceInput) error {
+	sourceType := strings.TrimSpace(input.SourceType)
+	if input.LocalSKUID == 0 || input.Priority < 0 || input.MaxUnitCost.Decimal.IsNegative() {
+		return ErrSKUSourceInvalid
+	}
+	switch sourceType {
+	case constants.SKUSourceTypeUpstream:
+		if input.ConnectionID == 0 || input.UpstreamProductID == 0 || input.UpstreamSKUID == 0 {
+			return ErrSKUSourceInvalid
+		}
+	case constants.SKUSourceTypeCardPool:
+		input.ConnectionID, input.UpstreamProductID, input.UpstreamSKUID = 0, 0, 0
+		input.MaxUnitCost = models.Money{}
+	default:
+		return ErrSKUSourceInvalid
+	}
+
+	sku, err := s.productSKURepo.GetByID(input.LocalSKUID)
+	if err != nil {
+		return err
+	}
+	if sku == nil {
+		return ErrProductSKUInvalid
+	}
+	product, err := s.productRepo.GetByID(fmt.Sprintf("%d", sku.ProductID))
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return ErrProductNotFound
+	}
+	if product.FulfillmentType != constants.FulfillmentTypeUpstream {
+		return ErrSKUSourceInvalid
+	}
+	if sourceType == constants.SKUSourceTypeUpstream {
+		conn, err := s.connSvc.GetByID(input.ConnectionID)
+		if err != nil {
+			return err
+		}
+		if conn == nil {
+			return ErrConnectionNotFound
+		}
+	}
+
+	existing, err := s.sourceRepo.ListByLocalSKUID(sku.ID)
+	if err != nil {
+		return err
+	}
+	for _, other := range existing {
+		if other.ID == source.ID || other.SourceType != sourceType {
+			continue
+		}
+		if sourceType == constants.SKUSourceTypeCardPool ||
+			(other.ConnectionID == input.ConnectionID && other.UpstreamSKUID == input.UpstreamSKUID) {
+			return ErrSKUSourceExists
+		}
+	}
+
+	source.LocalProductID = product.ID
+	source.LocalSKUID = sku.ID
+	source.Priority = input.Priority
+	source.SourceType = sourceType
+	source.ConnectionID = input.ConnectionID
+	source.UpstreamProductID = input.UpstreamProductID
+	source.UpstreamSKUID = input.UpstreamSKUID
+	source.MaxUnitCost = models.NewMoneyFromDecimal(input.MaxUnitCost.Decimal.Round(2))
+	if input.IsActive != nil {
+		source.IsActive = *input.IsActive
+	}
+	return nil
+}
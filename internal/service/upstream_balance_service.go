@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/logger"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+	"github.com/dujiao-next/internal/upstream"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrUpstreamBalanceUnavailable 上游未返回可解析的余额
+	ErrUpstreamBalanceUnavailable = errors.New("upstream balance unavailable")
+	// ErrUpstreamBalanceInsufficient 已知上游余额不足以支付采购
+	ErrUpstreamBalanceInsufficient = errors.New("upstream balance insufficient")
+)
+
+const (
+	// upstreamBalanceSpendDays 估算日均采购支出的统计窗口
+	upstreamBalanceSpendDays = 7
+	// upstreamBalanceFreshness 余额在该时长内视为可信，超时后提交采购不再据此拦截
+	upstreamBalanceFreshness = 30 * time.Minute
+)
+
+// UpstreamBalanceService 上游余额监控服务：定时记录余额快照、估算可用天数并在低于阈值时告警
+type UpstreamBalanceService struct {
+	connRepo        repository.SiteConnectionRepository
+	snapshotRepo    repository.UpstreamBalanceSnapshotRepository
+	procRepo        repository.ProcurementOrderRepository
+	connSvc         *SiteConnectionService
+	notificationSvc *NotificationService
+}
+
+// NewUpstreamBalanceService 创建上游余额监控服务
+func NewUpstreamBalanceService(
+	connRepo repository.SiteConnectionRepository,
+	snapshotRepo repository.UpstreamBalanceSnapshotRepository,
+	procRepo repository.ProcurementOrderRepository,
+	connSvc *SiteConnectionService,
+	notificationSvc *NotificationService,
+) *UpstreamBalanceService {
+	return &UpstreamBalanceService{
+		connRepo:        connRepo,
+		snapshotRepo:    snapshotRepo,
+		procRepo:        procRepo,
+		connSvc:         connSvc,
+		notificationSvc: notificationSvc,
+	}
+}
+
+// RunAll 定时巡检：逐个启用的连接记录余额快照
+func (s *UpstreamBalanceService) RunAll() {
+	conns, err := s.connRepo.ListActive()
+	if err != nil {
+		logger.Warnw("upstream_balance_list_connections_failed", "error", err)
+		return
+	}
+	for i := range conns {
+		if _, err := s.check(&conns[i]); err != nil {
+			logger.Warnw("upstream_balance_check_failed",
+				"connection_id", conns[i].ID,
+				"error", err,
+			)
+		}
+	}
+}
+
+// Check 手动触发单个连接的余额检查
+func (s *UpstreamBalanceService) Check(connectionID uint) (*models.UpstreamBalanceSnapshot, error) {
+	conn, err := s.connRepo.GetByID(connectionID)
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		return nil, ErrConnectionNotFound
+	}
+	return s.check(conn)
+}
+
+// ListSnapshots 余额快照列表
+func (s *UpstreamBalanceService) ListSnapshots(filter repository.UpstreamBalanceSnapshotListFilter) ([]models.UpstreamBalanceSnapshot, int64, error) {
+	return s.snapshotRepo.List(filter)
+}
+
+// check 查询上游余额，结合近期采购支出估算可用天数，记录快照并按阈值告警
+func (s *UpstreamBalanceService) check(conn *models.SiteConnection) (*models.UpstreamBalanceSnapshot, error) {
+	adapter, err := s.connSvc.GetAdapter(conn)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	result, err := adapter.Ping(ctx)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := parseUpstreamBalance(result)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	spendDays := upstreamBalanceSpendWindow(conn, now)
+	spend, err := s.recentSpend(conn.ID, now.AddDate(0, 0, -spendDays), now)
+	if err != nil {
+		return nil, err
+	}
+	dailySpend := spend.Div(decimal.NewFromInt(int64(spendDays))).Round(2)
+	var runwayDays *decimal.Decimal
+	if dailySpend.IsPositive() {
+		runway := balance.Div(dailySpend).Round(2)
+		runwayDays = &runway
+	}
+	below := upstreamBalanceBelowThreshold(conn, balance, runwayDays)
+
+	snapshot := &models.UpstreamBalanceSnapshot{
+		ConnectionID:   conn.ID,
+		Balance:        models.NewMoneyFromDecimal(balance),
+		Currency:       strings.TrimSpace(result.Currency),
+		SpendAmount:    models.NewMoneyFromDecimal(spend),
+		SpendDays:      spendDays,
+		DailySpend:     models.NewMoneyFromDecimal(dailySpend),
+		RunwayDays:     runwayDays,
+		BelowThreshold: below,
+		CreatedAt:      now,
+	}
+	if err := s.snapshotRepo.Create(snapshot); err != nil {
+		return nil, err
+	}
+
+	wasAlerting := conn.BalanceAlerting
+	applyUpstreamBalance(conn, balance, snapshot.Currency, now)
+	conn.BalanceAlerting = below
+	if err := s.connRepo.UpdateBalance(conn); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case below && !wasAlerting:
+		s.notifyLowBalance(conn, snapshot)
+	case !below && wasAlerting:
+		logger.Infow("upstream_balance_recovered",
+			"connection_id", conn.ID,
+			"balance", balance.StringFixed(2),
+		)
+	}
+	return snapshot, nil
+}
+
+// recentSpend 统计窗口内已向上游下单的采购金额（未提交、失败、取消与已退款的采购单不计）
+func (s *UpstreamBalanceService) recentSpend(connectionID uint, start, end time.Time) (decimal.Decimal, error) {
+	orders, err := s.procRepo.ListByConnectionAndTimeRange(connectionID, start, end)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	spent := decimal.Zero
+	for _, order := range orders {
+		switch order.Status {
+		case constants.ProcurementStatusPending, constants.ProcurementStatusFailed, constants.ProcurementStatusRejected,
+			constants.ProcurementStatusCanceled, constants.ProcurementStatusRefunded:
+			continue
+		}
+		spent = spent.Add(order.UpstreamAmount.Decimal)
+	}
+	return spent, nil
+}
+
+// notifyLowBalance 发送低余额异常告警
+func (s *UpstreamBalanceService) notifyLowBalance(conn *models.SiteConnection, snapshot *models.UpstreamBalanceSnapshot) {
+	logger.Warnw("upstream_balance_low",
+		"connection_id", conn.ID,
+		"balance", snapshot.Balance.Decimal.StringFixed(2),
+		"currency", snapshot.Currency,
+	)
+	if s.notificationSvc == nil {
+		return
+	}
+	message := fmt.Sprintf("上游连接「%s」余额 %s %s 低于告警阈值", conn.Name, snapshot.Balance.Decimal.StringFixed(2), snapshot.Currency)
+	runway := ""
+	if snapshot.RunwayDays != nil {
+		runway = snapshot.RunwayDays.StringFixed(2)
+		message += fmt.Sprintf("，按近 %d 天采购支出预计可用 %s 天", snapshot.SpendDays, runway)
+	}
+	_ = s.notificationSvc.Enqueue(NotificationEnqueueInput{
+		EventType: constants.NotificationEventExceptionAlert,
+		BizType:   constants.NotificationBizTypeUpstreamBalance,
+		BizID:     conn.ID,
+		Data: map[string]any{
+			"message":         message,
+			"connection_id":   conn.ID,
+			"connection_name": conn.Name,
+			"snapshot_id":     snapshot.ID,
+			"balance":         snapshot.Balance.Decimal.StringFixed(2),
+			"currency":        snapshot.Currency,
+			"daily_spend":     snapshot.DailySpend.Decimal.StringFixed(2),
+			"runway_days":     runway,
+			"threshold":       conn.BalanceAlertThreshold.StringFixed(2),
+			"threshold_days":  conn.BalanceAlertDays,
+		},
+	})
+}
+
+// parseUpstreamBalance 解析 Ping 返回的余额
+func parseUpstreamBalance(result *upstream.PingResult) (decimal.Decimal, error) {
+	if result == nil {
+		return decimal.Zero, ErrUpstreamBalanceUnavailable
+	}
+	raw := strings.ReplaceAll(strings.TrimSpace(result.Balance), ",", "")
+	if raw == "" {
+		return decimal.Zero, ErrUpstreamBalanceUnavailable
+	}
+	balance, err := decimal.NewFromString(raw)
+	if err != nil {
+		return decimal.Zero, ErrUpstreamBalanceUnavailable
+	}
+	return balance, nil
+}
+
+// applyUpstreamBalance 将最新余额写入连接（调用方负责保存）
+func applyUpstreamBalance(conn *models.SiteConnection, balance decimal.Decimal, currency string, at time.Time) {
+	conn.LastBalance = &balance
+	conn.LastBalanceCurrency = currency
+	conn.LastBalanceAt = &at
+}
+
+// upstreamBalanceSpendWindow 统计窗口天数：新建不足窗口期的连接按实际天数计算，避免低估日均支出
+func upstreamBalanceSpendWindow(conn *models.SiteConnection, now time.Time) int {
+	if conn.CreatedAt.IsZero() {
+		return upstreamBalanceSpendDays
+	}
+	days := int(math.Ceil(now.Sub(conn.CreatedAt).Hours() / 24))
+	if days < 1 {
+		return 1
+	}
+	if days > upstreamBalanceSpendDays {
+		return upstreamBalanceSpendDays
+	}
+	return days
+}
+
+// upstreamBalanceBelowThreshold 余额低于金额阈值或预计可用天数低于天数阈值
+func upstreamBalanceBelowThreshold(conn *models.SiteConnection, balance decimal.Decimal, runwayDays *decimal.Decimal) bool {
+	if conn.BalanceAlertThreshold.IsPositive() && balance.LessThan(conn.BalanceAlertThreshold) {
+		return true
+	}
+	if conn.BalanceAlertDays > 0 && runwayDays != nil && runwayDays.LessThan(decimal.NewFromInt(int64(conn.BalanceAlertDays))) {
+		return true
+	}
+	return false
+}
+
+// checkUpstreamBalance 近期获取的上游余额不足以支付本次采购时返回 ErrUpstreamBalanceInsufficient；余额未知或已过期时放行
+func checkUpstreamBalance(conn *models.SiteConnection, required decimal.Decimal, now time.Time) error {
+	if conn == nil || conn.LastBalance == nil || conn.LastBalanceAt == nil || !required.IsPositive() {
+		return nil
+	}
+	if now.Sub(*conn.LastBalanceAt) > upstreamBalanceFreshness {
+		return nil
+	}
+	if conn.LastBalance.LessThan(required) {
+		return fmt.Errorf("%w: balance %s %s, required %s",
+			ErrUpstreamBalanceInsufficient, conn.LastBalance.StringFixed(2), conn.LastBalanceCurrency, required.StringFixed(2))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestUpstreamBalanceCheckAndSubmitFailFast(t *testing.T) {
+	db := setupProcurementTestDB(t)
+	if err := db.AutoMigrate(&models.UpstreamBalanceSnapshot{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+
+	balance := "30.00"
+	var upstreamOrders int
+	var onPing func()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/upstream/ping":
+			if onPing != nil {
+				onPing()
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "site_name": "up", "balance": balance, "currency": "CNY"})
+		case "/api/v1/upstream/orders":
+			upstreamOrders++
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "order_id": 1, "order_no": "UP-1", "amount": "40.00", "currency": "CNY"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	connRepo := repository.NewSiteConnectionRepository(db)
+	connSvc := NewSiteConnectionService(connRepo, "test-key", t.TempDir())
+	conn, err := connSvc.Create(CreateConnectionInput{
+		Name: "balance", BaseURL: server.URL, ApiKey: "key", ApiSecret: "secret",
+		Protocol: constants.ConnectionProtocolDujiaoNext, BalanceAlertThreshold: 50,
+	})
+	if err != nil {
+		t.Fatalf("create connection failed: %v", err)
+	}
+	if err := db.Model(conn).Update("created_at", time.Now().AddDate(0, 0, -30)).Error; err != nil {
+		t.Fatalf("backdate connection failed: %v", err)
+	}
+
+	// 近 7 天采购支出 70，日均 10
+	spent := createTestProcurementOrder(t, db, conn.ID, 99, "SPENT", constants.ProcurementStatusFulfilled)
+	if err := db.Model(spent).Update("upstream_amount", "70.00").Error; err != nil {
+		t.Fatalf("update spend failed: %v", err)
+	}
+	failed := createTestProcurementOrder(t, db, conn.ID, 98, "FAILED", constants.ProcurementStatusRejected)
+	if err := db.Model(failed).Update("upstream_amount", "500.00").Error; err != nil {
+		t.Fatalf("update failed spend failed: %v", err)
+	}
+
+	balanceSvc := NewUpstreamBalanceService(connRepo, repository.NewUpstreamBalanceSnapshotRepository(db), repository.NewProcurementOrderRepository(db), connSvc, nil)
+	snapshot, err := balanceSvc.Check(conn.ID)
+	if err != nil {
+		t.Fatalf("check balance failed: %v", err)
+	}
+	if !snapshot.Balance.Decimal.Equal(decimal.NewFromInt(30)) || !snapshot.DailySpend.Decimal.Equal(decimal.NewFromInt(10)) ||
+		snapshot.RunwayDays == nil || !snapshot.RunwayDays.Equal(decimal.NewFromInt(3)) || !snapshot.BelowThreshold {
+		t.Fatalf("unexpected snapshot: %+v", snapshot)
+	}
+	reloaded, _ := connRepo.GetByID(conn.ID)
+	if reloaded == nil || !reloaded.BalanceAlerting || reloaded.LastBalance == nil || !reloaded.LastBalance.Equal(decimal.NewFromInt(30)) {
+		t.Fatalf("expected connection balance recorded, got %+v", reloaded)
+	}
+
+	// 已知余额 30 不足以支付单价 40 的采购：不请求上游直接拒绝
+	pm := &models.ProductMapping{ConnectionID: conn.ID, LocalProductID: 1, UpstreamProductID: 10, IsActive: true}
+	if err := db.Create(pm).Error; err != nil {
+		t.Fatalf("create product mapping failed: %v", err)
+	}
+	sm := &models.SKUMapping{ProductMappingID: pm.ID, LocalSKUID: 1, UpstreamSKUID: 100, UpstreamPrice: models.NewMoneyFromDecimal(decimal.NewFromInt(40)), UpstreamIsActive: true}
+	if err := db.Create(sm).Error; err != nil {
+		t.Fatalf("create sku mapping failed: %v", err)
+	}
+	order := createProcTestOrder(t, db, "BAL-001", constants.OrderStatusPaid, constants.FulfillmentTypeUpstream)
+	proc := createTestProcurementOrder(t, db, conn.ID, order.ID, order.OrderNo, constants.ProcurementStatusPending)
+	procSvc := newTestProcurementService(db, connSvc)
+	if err := procSvc.SubmitToUpstream(proc.ID); err == nil {
+		t.Fatalf("expected submit rejected for insufficient balance")
+	}
+	var rejected models.ProcurementOrder
+	if err := db.First(&rejected, proc.ID).Error; err != nil {
+		t.Fatalf("reload procurement failed: %v", err)
+	}
+	if upstreamOrders != 0 || rejected.Status != constants.ProcurementStatusRejected || !strings.Contains(rejected.ErrorMessage, ErrUpstreamBalanceInsufficient.Error()) {
+		t.Fatalf("unexpected procurement after fail fast: calls=%d %+v", upstreamOrders, rejected)
+	}
+
+	// 充值后缓存余额仍为 30：提交前经 Ping 刷新余额，不因过时余额误拒
+	balance = "100.00"
+	topUpOrder := createProcTestOrder(t, db, "BAL-002", constants.OrderStatusPaid, constants.FulfillmentTypeUpstream)
+	topUpProc := createTestProcurementOrder(t, db, conn.ID, topUpOrder.ID, topUpOrder.OrderNo, constants.ProcurementStatusPending)
+	if err := procSvc.SubmitToUpstream(topUpProc.ID); err != nil {
+		t.Fatalf("expected submit after refresh succeed: %v", err)
+	}
+	var submitted models.ProcurementOrder
+	if err := db.First(&submitted, topUpProc.ID).Error; err != nil {
+		t.Fatalf("reload procurement failed: %v", err)
+	}
+	if upstreamOrders != 1 || submitted.Status == constants.ProcurementStatusRejected {
+		t.Fatalf("unexpected procurement after balance refresh: calls=%d %+v", upstreamOrders, submitted)
+	}
+	reloaded, _ = connRepo.GetByID(conn.ID)
+	if reloaded.LastBalance == nil || !reloaded.LastBalance.Equal(decimal.NewFromInt(100)) {
+		t.Fatalf("expected refreshed balance persisted, got %+v", reloaded)
+	}
+
+	// 余额检查仅写回余额字段，不覆盖检查期间管理员修改的配置，告警状态解除
+	onPing = func() {
+		if err := db.Model(&models.SiteConnection{}).Where("id = ?", conn.ID).Update("name", "renamed").Error; err != nil {
+			t.Errorf("rename connection failed: %v", err)
+		}
+	}
+	snapshot, err = balanceSvc.Check(conn.ID)
+	if err != nil {
+		t.Fatalf("recheck balance failed: %v", err)
+	}
+	reloaded, _ = connRepo.GetByID(conn.ID)
+	if snapshot.BelowThreshold || reloaded.BalanceAlerting || reloaded.Name != "renamed" {
+		t.Fatalf("expected balance alert cleared without overwriting config, snapshot=%+v conn=%+v", snapshot, reloaded)
+	}
+}
@@ -25,6 +25,8 @@ const (
 	userTotpDigits            = 6
 	userTotpPeriod            = 30
 	userTotpSkew              = 1
+	userTotpVerifyMaxFailures = 5
+	userTotpVerifyLockTTL     = 15 * time.Minute
 )
 
 // UserChallengePurpose2FA 用户 2FA 挑战 token purpose 常量
@@ -299,6 +301,28 @@ func (s *UserTOTPService) VerifyChallengeRecoveryCode(userID uint, code string)
 	return s.consumeRecoveryCode(user, code)
 }
 
+// VerifySensitiveOperation 敏感操作（如钱包转账）校验动态码或恢复码；
+// 按用户累计失败次数，连续失败达到上限后在锁定期内直接拒绝，成功后清零
+func (s *UserTOTPService) VerifySensitiveOperation(userID uint, code string, isRecoveryCode bool) error {
+	if err := s.checkVerifyFailures(userID); err != nil {
+		return err
+	}
+	var err error
+	if isRecoveryCode {
+		err = s.VerifyChallengeRecoveryCode(userID, code)
+	} else {
+		err = s.VerifyChallengeCode(userID, code)
+	}
+	if errors.Is(err, ErrTOTPCodeInvalid) || errors.Is(err, ErrTOTPRecoveryInvalid) {
+		s.bumpVerifyFailures(userID)
+		return err
+	}
+	if err == nil && s.redis != nil {
+		_ = s.redis.Del(context.Background(), userVerifyFailKey(userID)).Err()
+	}
+	return err
+}
+
 // AdminResetUser2FA 管理员强制清空目标用户 2FA。
 // 使用场景：用户同时丢失 TOTP 设备与所有恢复码，向管理员申诉后由管理员协助解绑。
 // 与用户自助 Disable 不同：不需要 code/recovery code，直接清空。
@@ -383,3 +407,32 @@ func (s *UserTOTPService) bumpEnableFailures(userID uint) {
 		_ = s.redis.Expire(ctx, userEnableFailKey(userID), userTotpPendingTTL).Err()
 	}
 }
+
+func userVerifyFailKey(userID uint) string {
+	return fmt.Sprintf("2fa:user:verify:%d:fails", userID)
+}
+
+func (s *UserTOTPService) checkVerifyFailures(userID uint) error {
+	if s.redis == nil {
+		return nil
+	}
+	v, err := s.redis.Get(context.Background(), userVerifyFailKey(userID)).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if v >= userTotpVerifyMaxFailures {
+		return ErrTOTPTooManyAttempts
+	}
+	return nil
+}
+
+func (s *UserTOTPService) bumpVerifyFailures(userID uint) {
+	if s.redis == nil {
+		return
+	}
+	ctx := context.Background()
+	cnt, err := s.redis.Incr(ctx, userVerifyFailKey(userID)).Result()
+	if err == nil && cnt == 1 {
+		_ = s.redis.Expire(ctx, userVerifyFailKey(userID), userTotpVerifyLockTTL).Err()
+	}
+}
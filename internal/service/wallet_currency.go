@@ -0,0 +1,112 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	walletConfigFieldCurrencies        = "currencies"
+	walletConfigFieldConversionEnabled = "currency_conversion_enabled"
+	walletConfigFieldExchangeRates     = "exchange_rates"
+)
+
+// WalletCurrencyConfig 多币种钱包配置（存储于 wallet_config）
+type WalletCurrencyConfig struct {
+	Currencies        []string             `json:"currencies"`                  // 允许充值的钱包币种，为空表示不限制
+	ConversionEnabled bool                 `json:"currency_conversion_enabled"` // 订单币种余额不足时是否按汇率抵扣其他币种余额
+	ExchangeRates     []WalletExchangeRate `json:"exchange_rates"`              // 兑换汇率
+}
+
+// WalletExchangeRate 钱包兑换汇率：1 单位 From = Rate 单位 To
+type WalletExchangeRate struct {
+	From string          `json:"from"`
+	To   string          `json:"to"`
+	Rate decimal.Decimal `json:"rate"`
+}
+
+// AllowsCurrency 判断币种是否允许充值
+func (c WalletCurrencyConfig) AllowsCurrency(currency string) bool {
+	if len(c.Currencies) == 0 {
+		return true
+	}
+	for _, item := range c.Currencies {
+		if item == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// Rate 查询 from → to 的兑换汇率，未配置正向汇率时使用反向汇率的倒数
+func (c WalletCurrencyConfig) Rate(from, to string) (decimal.Decimal, bool) {
+	if from == to {
+		return decimal.NewFromInt(1), true
+	}
+	for _, item := range c.ExchangeRates {
+		if item.From == from && item.To == to {
+			return item.Rate, true
+		}
+	}
+	for _, item := range c.ExchangeRates {
+		if item.From == to && item.To == from {
+			return decimal.NewFromInt(1).DivRound(item.Rate, 8), true
+		}
+	}
+	return decimal.Zero, false
+}
+
+// walletCurrencyConfigFromJSON 解析 wallet_config 中的多币种配置，忽略非法条目
+func walletCurrencyConfigFromJSON(raw map[string]interface{}) WalletCurrencyConfig {
+	cfg := WalletCurrencyConfig{Currencies: []string{}, ExchangeRates: []WalletExchangeRate{}}
+	if raw == nil {
+		return cfg
+	}
+	if items, ok := raw[walletConfigFieldCurrencies].([]interface{}); ok {
+		seen := make(map[string]struct{}, len(items))
+		for _, item := range items {
+			text, _ := item.(string)
+			currency := strings.ToUpper(strings.TrimSpace(text))
+			if !settingCurrencyCodePattern.MatchString(currency) {
+				continue
+			}
+			if _, dup := seen[currency]; dup {
+				continue
+			}
+			seen[currency] = struct{}{}
+			cfg.Currencies = append(cfg.Currencies, currency)
+		}
+	}
+	cfg.ConversionEnabled = parseSettingBool(raw[walletConfigFieldConversionEnabled])
+	if items, ok := raw[walletConfigFieldExchangeRates].([]interface{}); ok {
+		for _, item := range items {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			from := strings.ToUpper(strings.TrimSpace(normalizeSettingText(entry["from"])))
+			to := strings.ToUpper(strings.TrimSpace(normalizeSettingText(entry["to"])))
+			if !settingCurrencyCodePattern.MatchString(from) || !settingCurrencyCodePattern.MatchString(to) || from == to {
+				continue
+			}
+			rate, err := parseWalletExchangeRate(entry["rate"])
+			if err != nil || !rate.IsPositive() {
+				continue
+			}
+			cfg.ExchangeRates = append(cfg.ExchangeRates, WalletExchangeRate{From: from, To: to, Rate: rate})
+		}
+	}
+	return cfg
+}
+
+func parseWalletExchangeRate(raw interface{}) (decimal.Decimal, error) {
+	if text, ok := raw.(string); ok {
+		return decimal.NewFromString(strings.TrimSpace(text))
+	}
+	value, err := parseSettingFloat(raw)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return decimal.NewFromFloat(value), nil
+}
@@ -0,0 +1,384 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/logger"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// walletLedgerAuditBatchSize 分批读取账户、流水与订单的批大小
+	walletLedgerAuditBatchSize = 500
+	// walletLedgerAuditMaxDiscrepancies 单次核对最多记录的差异数，避免异常数据写爆明细表
+	walletLedgerAuditMaxDiscrepancies = 5000
+)
+
+// WalletLedgerAuditService 钱包账本核对服务：回放账户流水校验余额链连续性，并与订单钱包支付/退款金额交叉核对
+type WalletLedgerAuditService struct {
+	auditRepo        repository.WalletLedgerAuditRepository
+	refundRecordRepo repository.OrderRefundRecordRepository
+	notificationSvc  *NotificationService
+}
+
+// NewWalletLedgerAuditService 创建钱包账本核对服务
+func NewWalletLedgerAuditService(
+	auditRepo repository.WalletLedgerAuditRepository,
+	refundRecordRepo repository.OrderRefundRecordRepository,
+	notificationSvc *NotificationService,
+) *WalletLedgerAuditService {
+	return &WalletLedgerAuditService{
+		auditRepo:        auditRepo,
+		refundRecordRepo: refundRecordRepo,
+		notificationSvc:  notificationSvc,
+	}
+}
+
+// walletLedgerAuditRun 单次核对的累计结果
+type walletLedgerAuditRun struct {
+	audit         *models.WalletLedgerAudit
+	discrepancies []models.WalletLedgerDiscrepancy
+	truncated     bool
+}
+
+func (r *walletLedgerAuditRun) add(item models.WalletLedgerDiscrepancy) {
+	r.audit.DiscrepancyCount++
+	if len(r.discrepancies) >= walletLedgerAuditMaxDiscrepancies {
+		r.truncated = true
+		return
+	}
+	item.AuditID = r.audit.ID
+	item.CreatedAt = time.Now()
+	r.discrepancies = append(r.discrepancies, item)
+}
+
+// RunAll 定时核对全部钱包账本
+func (s *WalletLedgerAuditService) RunAll() {
+	audit, err := s.Run(constants.WalletLedgerAuditSourceScheduled, nil)
+	if err != nil {
+		logger.Warnw("wallet_ledger_audit_failed", "error", err)
+		return
+	}
+	logger.Infow("wallet_ledger_audit_done",
+		"audit_id", audit.ID,
+		"account_count", audit.AccountCount,
+		"order_count", audit.OrderCount,
+		"discrepancy_count", audit.DiscrepancyCount,
+	)
+}
+
+// Run 执行一次账本核对并记录结果，发现差异时发送异常告警
+func (s *WalletLedgerAuditService) Run(source string, adminID *uint) (*models.WalletLedgerAudit, error) {
+	if source != constants.WalletLedgerAuditSourceManual {
+		source = constants.WalletLedgerAuditSourceScheduled
+	}
+	now := time.Now()
+	audit := &models.WalletLedgerAudit{
+		Source:      source,
+		TriggeredBy: adminID,
+		Status:      constants.WalletLedgerAuditStatusRunning,
+		StartedAt:   now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.auditRepo.CreateAudit(audit); err != nil {
+		return nil, err
+	}
+
+	run := &walletLedgerAuditRun{audit: audit}
+	err := s.auditAccounts(run)
+	if err == nil {
+		err = s.auditOrders(run)
+	}
+	if err == nil {
+		err = s.auditRepo.CreateDiscrepancies(run.discrepancies)
+	}
+
+	finished := time.Now()
+	audit.FinishedAt = &finished
+	audit.UpdatedAt = finished
+	if err != nil {
+		audit.Status = constants.WalletLedgerAuditStatusFailed
+		audit.ErrorMessage = err.Error()
+		if updateErr := s.auditRepo.UpdateAudit(audit); updateErr != nil {
+			logger.Warnw("wallet_ledger_audit_update_failed", "audit_id", audit.ID, "error", updateErr)
+		}
+		return audit, err
+	}
+	audit.Status = constants.WalletLedgerAuditStatusCompleted
+	if run.truncated {
+		audit.ErrorMessage = fmt.Sprintf("差异过多，仅记录前 %d 条", walletLedgerAuditMaxDiscrepancies)
+	}
+	if err := s.auditRepo.UpdateAudit(audit); err != nil {
+		return nil, err
+	}
+	if audit.DiscrepancyCount > 0 {
+		s.notifyDiscrepancies(audit)
+	}
+	return audit, nil
+}
+
+// ListAudits 核对记录列表
+func (s *WalletLedgerAuditService) ListAudits(filter repository.WalletLedgerAuditListFilter) ([]models.WalletLedgerAudit, int64, error) {
+	return s.auditRepo.ListAudits(filter)
+}
+
+// GetAudit 核对记录详情
+func (s *WalletLedgerAuditService) GetAudit(id uint) (*models.WalletLedgerAudit, error) {
+	audit, err := s.auditRepo.GetAuditByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if audit == nil {
+		return nil, ErrWalletLedgerAuditNotFound
+	}
+	return audit, nil
+}
+
+// ListDiscrepancies 差异明细列表
+func (s *WalletLedgerAuditService) ListDiscrepancies(filter repository.WalletLedgerDiscrepancyListFilter) ([]models.WalletLedgerDiscrepancy, int64, error) {
+	return s.auditRepo.ListDiscrepancies(filter)
+}
+
+// ResolveDiscrepancy 标记差异已处理
+func (s *WalletLedgerAuditService) ResolveDiscrepancy(id, adminID uint, remark string) (*models.WalletLedgerDiscrepancy, error) {
+	item, err := s.auditRepo.GetDiscrepancyByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, ErrWalletLedgerDiscrepancyNotFound
+	}
+	now := time.Now()
+	item.Resolved = true
+	item.ResolvedBy = &adminID
+	item.ResolvedAt = &now
+	item.Remark = normalizeSettingTextWithRuneLimit(remark, 500)
+	if err := s.auditRepo.UpdateDiscrepancy(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// auditAccounts 逐个账户按流水顺序回放余额：每笔流水的变动前余额须等于上一笔变动后余额，
+// 变动前后之差须等于流水金额，最后一笔变动后余额须等于账户当前余额
+func (s *WalletLedgerAuditService) auditAccounts(run *walletLedgerAuditRun) error {
+	var lastAccountID uint
+	for {
+		accounts, err := s.auditRepo.ListAccountsAfter(lastAccountID, walletLedgerAuditBatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range accounts {
+			if err := s.auditAccount(run, &accounts[i]); err != nil {
+				return err
+			}
+			run.audit.AccountCount++
+		}
+		if len(accounts) < walletLedgerAuditBatchSize {
+			return nil
+		}
+		lastAccountID = accounts[len(accounts)-1].ID
+	}
+}
+
+func (s *WalletLedgerAuditService) auditAccount(run *walletLedgerAuditRun, account *models.WalletAccount) error {
+	running := decimal.Zero
+	var lastTxnID uint
+	for {
+		txns, err := s.auditRepo.ListAccountTransactionsAfter(account.UserID, account.Currency, lastTxnID, walletLedgerAuditBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, txn := range txns {
+			txnID := txn.ID
+			before := txn.BalanceBefore.Decimal.Round(2)
+			after := txn.BalanceAfter.Decimal.Round(2)
+			if !before.Equal(running) {
+				run.add(models.WalletLedgerDiscrepancy{
+					Type:          constants.WalletLedgerDiscrepancyChainBreak,
+					UserID:        account.UserID,
+					Currency:      account.Currency,
+					OrderID:       txn.OrderID,
+					TransactionID: &txnID,
+					Expected:      models.NewMoneyFromDecimal(running),
+					Actual:        models.NewMoneyFromDecimal(before),
+					Detail:        fmt.Sprintf("流水 #%d（%s）变动前余额与上一笔变动后余额不衔接", txn.ID, txn.Type),
+				})
+			}
+			if expected := walletLedgerApplyTxn(before, &txn); !after.Equal(expected) {
+				run.add(models.WalletLedgerDiscrepancy{
+					Type:          constants.WalletLedgerDiscrepancyTxnAmount,
+					UserID:        account.UserID,
+					Currency:      account.Currency,
+					OrderID:       txn.OrderID,
+					TransactionID: &txnID,
+					Expected:      models.NewMoneyFromDecimal(expected),
+					Actual:        models.NewMoneyFromDecimal(after),
+					Detail:        fmt.Sprintf("流水 #%d（%s）变动后余额与变动金额 %s 不符", txn.ID, txn.Type, txn.Amount.Decimal.StringFixed(2)),
+				})
+			}
+			running = after
+			lastTxnID = txn.ID
+			run.audit.TransactionCount++
+		}
+		if len(txns) < walletLedgerAuditBatchSize {
+			break
+		}
+	}
+
+	balance := account.Balance.Decimal.Round(2)
+	if balance.Equal(running) {
+		return nil
+	}
+	// 核对期间账户可能有新的动账，重新读取账户与其后的流水再确认一次
+	latest, err := s.auditRepo.GetAccount(account.UserID, account.Currency)
+	if err != nil {
+		return err
+	}
+	if latest == nil {
+		return nil
+	}
+	tail, err := s.auditRepo.ListAccountTransactionsAfter(account.UserID, account.Currency, lastTxnID, walletLedgerAuditBatchSize)
+	if err != nil {
+		return err
+	}
+	confirmed := running
+	if len(tail) > 0 {
+		confirmed = tail[len(tail)-1].BalanceAfter.Decimal.Round(2)
+	}
+	balance = latest.Balance.Decimal.Round(2)
+	if balance.Equal(confirmed) {
+		return nil
+	}
+	run.add(models.WalletLedgerDiscrepancy{
+		Type:     constants.WalletLedgerDiscrepancyBalance,
+		UserID:   account.UserID,
+		Currency: account.Currency,
+		Expected: models.NewMoneyFromDecimal(confirmed),
+		Actual:   models.NewMoneyFromDecimal(balance),
+		Detail:   "账户余额与流水回放结果不符",
+	})
+	return nil
+}
+
+// auditOrders 核对订单钱包支付与退款金额：
+// 余额支付流水减去退回流水须等于订单钱包支付金额；管理员退回钱包流水须等于订单已退款金额中非手动退款的部分
+func (s *WalletLedgerAuditService) auditOrders(run *walletLedgerAuditRun) error {
+	var lastOrderID uint
+	for {
+		orders, err := s.auditRepo.ListWalletOrdersAfter(lastOrderID, walletLedgerAuditBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(orders) == 0 {
+			return nil
+		}
+		orderIDs := make([]uint, 0, len(orders))
+		for _, order := range orders {
+			orderIDs = append(orderIDs, order.ID)
+		}
+		txns, err := s.auditRepo.ListOrderTransactionsByOrderIDs(orderIDs)
+		if err != nil {
+			return err
+		}
+		records, err := s.refundRecordRepo.ListByOrderIDs(orderIDs)
+		if err != nil {
+			return err
+		}
+		txnsByOrder := make(map[uint]map[string][]models.WalletTransaction, len(orders))
+		for _, txn := range txns {
+			if txn.OrderID == nil {
+				continue
+			}
+			if txnsByOrder[*txn.OrderID] == nil {
+				txnsByOrder[*txn.OrderID] = make(map[string][]models.WalletTransaction)
+			}
+			txnsByOrder[*txn.OrderID][txn.Type] = append(txnsByOrder[*txn.OrderID][txn.Type], txn)
+		}
+		manualRefunds := make(map[uint]decimal.Decimal, len(records))
+		for _, record := range records {
+			if record.Type == constants.OrderRefundTypeManual {
+				manualRefunds[record.OrderID] = manualRefunds[record.OrderID].Add(record.Amount.Decimal)
+			}
+		}
+
+		for i := range orders {
+			s.auditOrder(run, &orders[i], txnsByOrder[orders[i].ID], manualRefunds[orders[i].ID])
+			run.audit.OrderCount++
+		}
+		if len(orders) < walletLedgerAuditBatchSize {
+			return nil
+		}
+		lastOrderID = orders[len(orders)-1].ID
+	}
+}
+
+func (s *WalletLedgerAuditService) auditOrder(run *walletLedgerAuditRun, order *models.Order, txns map[string][]models.WalletTransaction, manualRefund decimal.Decimal) {
+	orderID := order.ID
+	paid := sumWalletSettleAmount(txns[constants.WalletTxnTypeOrderPay]).
+		Sub(sumWalletSettleAmount(txns[constants.WalletTxnTypeOrderRefund])).
+		Round(2)
+	if expected := order.WalletPaidAmount.Decimal.Round(2); !paid.Equal(expected) {
+		run.add(models.WalletLedgerDiscrepancy{
+			Type:     constants.WalletLedgerDiscrepancyOrderPaid,
+			UserID:   order.UserID,
+			Currency: order.Currency,
+			OrderID:  &orderID,
+			Expected: models.NewMoneyFromDecimal(expected),
+			Actual:   models.NewMoneyFromDecimal(paid),
+			Detail:   fmt.Sprintf("订单 %s 钱包支付金额与余额支付流水净额不符", order.OrderNo),
+		})
+	}
+
+	refunded := sumWalletSettleAmount(txns[constants.WalletTxnTypeAdminRefund])
+	if expected := order.RefundedAmount.Decimal.Sub(manualRefund).Round(2); !refunded.Equal(expected) {
+		run.add(models.WalletLedgerDiscrepancy{
+			Type:     constants.WalletLedgerDiscrepancyOrderRefund,
+			UserID:   order.UserID,
+			Currency: order.Currency,
+			OrderID:  &orderID,
+			Expected: models.NewMoneyFromDecimal(expected),
+			Actual:   models.NewMoneyFromDecimal(refunded),
+			Detail:   fmt.Sprintf("订单 %s 已退款金额（不含手动退款）与退回钱包流水不符", order.OrderNo),
+		})
+	}
+}
+
+// notifyDiscrepancies 发送账本差异异常告警
+func (s *WalletLedgerAuditService) notifyDiscrepancies(audit *models.WalletLedgerAudit) {
+	logger.Warnw("wallet_ledger_audit_discrepancies",
+		"audit_id", audit.ID,
+		"discrepancy_count", audit.DiscrepancyCount,
+	)
+	if s.notificationSvc == nil {
+		return
+	}
+	_ = s.notificationSvc.Enqueue(NotificationEnqueueInput{
+		EventType: constants.NotificationEventExceptionAlert,
+		BizType:   constants.NotificationBizTypeWalletLedger,
+		BizID:     audit.ID,
+		Data: map[string]any{
+			"message":           fmt.Sprintf("钱包账本核对 #%d 发现 %d 处差异，请在后台钱包账本核对中查看处理", audit.ID, audit.DiscrepancyCount),
+			"audit_id":          audit.ID,
+			"source":            audit.Source,
+			"account_count":     audit.AccountCount,
+			"order_count":       audit.OrderCount,
+			"discrepancy_count": audit.DiscrepancyCount,
+		},
+	})
+}
+
+// walletLedgerApplyTxn 按流水方向计算变动后余额
+func walletLedgerApplyTxn(before decimal.Decimal, txn *models.WalletTransaction) decimal.Decimal {
+	amount := txn.Amount.Decimal.Round(2)
+	if txn.Direction == constants.WalletTxnDirectionOut {
+		return before.Sub(amount).Round(2)
+	}
+	return before.Add(amount).Round(2)
+}
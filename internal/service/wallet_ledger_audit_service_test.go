@@ -0,0 +1,178 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func setupWalletLedgerAuditTest(t *testing.T) (*WalletLedgerAuditService, *WalletService, *gorm.DB) {
+	t.Helper()
+	walletSvc, db := setupWalletServiceTest(t)
+	if err := db.AutoMigrate(&models.WalletLedgerAudit{}, &models.WalletLedgerDiscrepancy{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	auditSvc := NewWalletLedgerAuditService(
+		repository.NewWalletLedgerAuditRepository(db),
+		repository.NewOrderRefundRecordRepository(db),
+		nil,
+	)
+	return auditSvc, walletSvc, db
+}
+
+// prepareWalletLedgerAuditData 充值、余额支付订单并部分退回钱包，生成一条完整的账本
+func prepareWalletLedgerAuditData(t *testing.T, svc *WalletService, db *gorm.DB, userID uint) *models.Order {
+	t.Helper()
+	createTestUser(t, db, userID)
+	if _, _, err := svc.Recharge(WalletRechargeInput{UserID: userID, Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(100))}); err != nil {
+		t.Fatalf("recharge failed: %v", err)
+	}
+	order := createTestOrder(t, db, userID, "DJTESTLEDGER001", decimal.NewFromInt(40))
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		_, err := svc.ApplyOrderBalance(tx, order, true)
+		return err
+	}); err != nil {
+		t.Fatalf("apply order balance failed: %v", err)
+	}
+	if err := db.Model(&models.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+		"status":  constants.OrderStatusPaid,
+		"paid_at": time.Now(),
+	}).Error; err != nil {
+		t.Fatalf("mark order paid failed: %v", err)
+	}
+	if _, _, _, err := svc.AdminRefundToWallet(AdminRefundToWalletInput{
+		OrderID: order.ID,
+		Amount:  models.NewMoneyFromDecimal(decimal.NewFromInt(15)),
+	}); err != nil {
+		t.Fatalf("admin refund failed: %v", err)
+	}
+	return order
+}
+
+func TestWalletLedgerAuditCleanLedger(t *testing.T) {
+	auditSvc, walletSvc, db := setupWalletLedgerAuditTest(t)
+	prepareWalletLedgerAuditData(t, walletSvc, db, 601)
+
+	audit, err := auditSvc.Run(constants.WalletLedgerAuditSourceManual, nil)
+	if err != nil {
+		t.Fatalf("run audit failed: %v", err)
+	}
+	if audit.Status != constants.WalletLedgerAuditStatusCompleted || audit.Source != constants.WalletLedgerAuditSourceManual {
+		t.Fatalf("unexpected audit: %+v", audit)
+	}
+	if audit.AccountCount != 1 || audit.TransactionCount != 3 || audit.OrderCount != 1 {
+		t.Fatalf("unexpected audit counts: %+v", audit)
+	}
+	if audit.DiscrepancyCount != 0 {
+		items, _, _ := auditSvc.ListDiscrepancies(repository.WalletLedgerDiscrepancyListFilter{AuditID: audit.ID})
+		t.Fatalf("expected clean ledger, got %+v", items)
+	}
+}
+
+func TestWalletLedgerAuditDetectsDiscrepancies(t *testing.T) {
+	auditSvc, walletSvc, db := setupWalletLedgerAuditTest(t)
+	order := prepareWalletLedgerAuditData(t, walletSvc, db, 611)
+
+	// 篡改：账户余额被直接修改、订单钱包支付金额与流水不符、订单退款金额被放大
+	if err := db.Model(&models.WalletAccount{}).Where("user_id = ?", 611).Update("balance", "80.00").Error; err != nil {
+		t.Fatalf("tamper balance failed: %v", err)
+	}
+	if err := db.Model(&models.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+		"wallet_paid_amount": "35.00",
+		"refunded_amount":    "20.00",
+	}).Error; err != nil {
+		t.Fatalf("tamper order failed: %v", err)
+	}
+	var payTxn models.WalletTransaction
+	if err := db.Where("order_id = ? AND type = ?", order.ID, constants.WalletTxnTypeOrderPay).First(&payTxn).Error; err != nil {
+		t.Fatalf("load pay txn failed: %v", err)
+	}
+	if err := db.Model(&payTxn).Update("balance_before", "90.00").Error; err != nil {
+		t.Fatalf("tamper txn failed: %v", err)
+	}
+
+	audit, err := auditSvc.Run(constants.WalletLedgerAuditSourceScheduled, nil)
+	if err != nil {
+		t.Fatalf("run audit failed: %v", err)
+	}
+	items, total, err := auditSvc.ListDiscrepancies(repository.WalletLedgerDiscrepancyListFilter{AuditID: audit.ID})
+	if err != nil {
+		t.Fatalf("list discrepancies failed: %v", err)
+	}
+	if int(total) != audit.DiscrepancyCount {
+		t.Fatalf("discrepancy count mismatch: total=%d audit=%d", total, audit.DiscrepancyCount)
+	}
+	found := make(map[string]models.WalletLedgerDiscrepancy)
+	for _, item := range items {
+		found[item.Type] = item
+	}
+	for _, typ := range []string{
+		constants.WalletLedgerDiscrepancyChainBreak,
+		constants.WalletLedgerDiscrepancyTxnAmount,
+		constants.WalletLedgerDiscrepancyBalance,
+		constants.WalletLedgerDiscrepancyOrderPaid,
+		constants.WalletLedgerDiscrepancyOrderRefund,
+	} {
+		if _, ok := found[typ]; !ok {
+			t.Fatalf("expected discrepancy %s, got %+v", typ, items)
+		}
+	}
+	chain := found[constants.WalletLedgerDiscrepancyChainBreak]
+	if chain.TransactionID == nil || *chain.TransactionID != payTxn.ID ||
+		!chain.Expected.Decimal.Equal(decimal.NewFromInt(100)) || !chain.Actual.Decimal.Equal(decimal.NewFromInt(90)) {
+		t.Fatalf("unexpected chain break: %+v", chain)
+	}
+	balance := found[constants.WalletLedgerDiscrepancyBalance]
+	if !balance.Expected.Decimal.Equal(decimal.NewFromInt(75)) || !balance.Actual.Decimal.Equal(decimal.NewFromInt(80)) {
+		t.Fatalf("unexpected balance mismatch: %+v", balance)
+	}
+	refund := found[constants.WalletLedgerDiscrepancyOrderRefund]
+	if !refund.Expected.Decimal.Equal(decimal.NewFromInt(20)) || !refund.Actual.Decimal.Equal(decimal.NewFromInt(15)) {
+		t.Fatalf("unexpected refund mismatch: %+v", refund)
+	}
+
+	resolved, err := auditSvc.ResolveDiscrepancy(balance.ID, 1, "已人工核实")
+	if err != nil {
+		t.Fatalf("resolve discrepancy failed: %v", err)
+	}
+	if !resolved.Resolved || resolved.ResolvedBy == nil || *resolved.ResolvedBy != 1 {
+		t.Fatalf("unexpected resolved discrepancy: %+v", resolved)
+	}
+	if _, err := auditSvc.ResolveDiscrepancy(99999, 1, ""); err != ErrWalletLedgerDiscrepancyNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestWalletLedgerAuditIgnoresManualRefund(t *testing.T) {
+	auditSvc, walletSvc, db := setupWalletLedgerAuditTest(t)
+	order := prepareWalletLedgerAuditData(t, walletSvc, db, 621)
+
+	// 手动退款只写退款记录与已退款金额，不产生钱包流水
+	if err := db.Create(&models.OrderRefundRecord{
+		UserID:   621,
+		OrderID:  order.ID,
+		Type:     constants.OrderRefundTypeManual,
+		Amount:   models.NewMoneyFromDecimal(decimal.NewFromInt(5)),
+		Currency: "CNY",
+	}).Error; err != nil {
+		t.Fatalf("create manual refund record failed: %v", err)
+	}
+	if err := db.Model(&models.Order{}).Where("id = ?", order.ID).Update("refunded_amount", "20.00").Error; err != nil {
+		t.Fatalf("update refunded amount failed: %v", err)
+	}
+
+	audit, err := auditSvc.Run(constants.WalletLedgerAuditSourceScheduled, nil)
+	if err != nil {
+		t.Fatalf("run audit failed: %v", err)
+	}
+	if audit.DiscrepancyCount != 0 {
+		items, _, _ := auditSvc.ListDiscrepancies(repository.WalletLedgerDiscrepancyListFilter{AuditID: audit.ID})
+		t.Fatalf("expected no discrepancy, got %+v", items)
+	}
+}
@@ -0,0 +1,444 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// WalletRechargeBonusRuleInput 充值赠送活动创建/更新输入
+type WalletRechargeBonusRuleInput struct {
+	Name              string
+	Currency          string
+	Tiers             []models.WalletRechargeBonusTier
+	FirstRechargeOnly bool
+	MemberLevels      []uint
+	PerUserLimit      int
+	PerUserBonusCap   models.Money
+	Locked            bool
+	StartsAt          *time.Time
+	EndsAt            *time.Time
+	IsActive          *bool
+}
+
+// CreateRechargeBonusRule 创建充值赠送活动
+func (s *WalletService) CreateRechargeBonusRule(input WalletRechargeBonusRuleInput) (*models.WalletRechargeBonusRule, error) {
+	rule := &models.WalletRechargeBonusRule{IsActive: true}
+	if err := applyRechargeBonusRuleInput(rule, input); err != nil {
+		return nil, err
+	}
+	if err := s.walletRepo.CreateBonusRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// UpdateRechargeBonusRule 更新充值赠送活动
+func (s *WalletService) UpdateRechargeBonusRule(id uint, input WalletRechargeBonusRuleInput) (*models.WalletRechargeBonusRule, error) {
+	rule, err := s.walletRepo.GetBonusRuleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrWalletRechargeBonusRuleNotFound
+	}
+	if err := applyRechargeBonusRuleInput(rule, input); err != nil {
+		return nil, err
+	}
+	if err := s.walletRepo.UpdateBonusRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeleteRechargeBonusRule 删除充值赠送活动（已发放的赠送记录保留）
+func (s *WalletService) DeleteRechargeBonusRule(id uint) error {
+	rule, err := s.walletRepo.GetBonusRuleByID(id)
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		return ErrWalletRechargeBonusRuleNotFound
+	}
+	return s.walletRepo.DeleteBonusRule(id)
+}
+
+// ListRechargeBonusRules 后台分页查询充值赠送活动
+func (s *WalletService) ListRechargeBonusRules(filter repository.WalletRechargeBonusRuleListFilter) ([]models.WalletRechargeBonusRule, int64, error) {
+	return s.walletRepo.ListBonusRules(filter)
+}
+
+// ListActiveRechargeBonusRules 获取当前生效的充值赠送活动（currency 为空时返回全部币种）
+func (s *WalletService) ListActiveRechargeBonusRules(currency string) ([]models.WalletRechargeBonusRule, error) {
+	rules, err := s.walletRepo.ListActiveBonusRules(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	code := strings.ToUpper(strings.TrimSpace(currency))
+	if code == "" {
+		return rules, nil
+	}
+	result := make([]models.WalletRechargeBonusRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Currency == "" || rule.Currency == code {
+			result = append(result, rule)
+		}
+	}
+	return result, nil
+}
+
+// applyRechargeBonusRuleInput 校验并写入活动字段，档位按门槛升序保存
+func applyRechargeBonusRuleInput(rule *models.WalletRechargeBonusRule, input WalletRechargeBonusRuleInput) error {
+	name := normalizeSettingTextWithRuneLimit(input.Name, 100)
+	if name == "" {
+		return ErrWalletRechargeBonusRuleInvalid
+	}
+	currency := strings.ToUpper(strings.TrimSpace(input.Currency))
+	if currency != "" && !settingCurrencyCodePattern.MatchString(currency) {
+		return ErrWalletRechargeBonusRuleInvalid
+	}
+	if len(input.Tiers) == 0 {
+		return ErrWalletRechargeBonusRuleInvalid
+	}
+	tiers := make(models.WalletRechargeBonusTiers, 0, len(input.Tiers))
+	seen := make(map[string]struct{}, len(input.Tiers))
+	for _, tier := range input.Tiers {
+		minAmount := tier.MinAmount.Decimal.Round(2)
+		value := tier.Value.Decimal.Round(2)
+		maxBonus := tier.MaxBonus.Decimal.Round(2)
+		bonusType := strings.ToLower(strings.TrimSpace(tier.Type))
+		if minAmount.LessThanOrEqual(decimal.Zero) || value.LessThanOrEqual(decimal.Zero) || maxBonus.IsNegative() {
+			return ErrWalletRechargeBonusRuleInvalid
+		}
+		switch bonusType {
+		case constants.WalletRechargeBonusTypeFixed:
+			maxBonus = decimal.Zero
+		case constants.WalletRechargeBonusTypePercent:
+			if value.GreaterThan(decimal.NewFromInt(100)) {
+				return ErrWalletRechargeBonusRuleInvalid
+			}
+		default:
+			return ErrWalletRechargeBonusRuleInvalid
+		}
+		key := minAmount.StringFixed(2)
+		if _, ok := seen[key]; ok {
+			return ErrWalletRechargeBonusRuleInvalid
+		}
+		seen[key] = struct{}{}
+		tiers = append(tiers, models.WalletRechargeBonusTier{
+			MinAmount: models.NewMoneyFromDecimal(minAmount),
+			Type:      bonusType,
+			Value:     models.NewMoneyFromDecimal(value),
+			MaxBonus:  models.NewMoneyFromDecimal(maxBonus),
+		})
+	}
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].MinAmount.Decimal.LessThan(tiers[j].MinAmount.Decimal)
+	})
+	if input.PerUserLimit < 0 || input.PerUserBonusCap.Decimal.IsNegative() {
+		return ErrWalletRechargeBonusRuleInvalid
+	}
+	if input.StartsAt != nil && input.EndsAt != nil && input.EndsAt.Before(*input.StartsAt) {
+		return ErrWalletRechargeBonusRuleInvalid
+	}
+
+	rule.Name = name
+	rule.Currency = currency
+	rule.Tiers = tiers
+	rule.FirstRechargeOnly = input.FirstRechargeOnly
+	rule.MemberLevels = normalizeCouponMemberLevels(input.MemberLevels)
+	rule.PerUserLimit = input.PerUserLimit
+	rule.PerUserBonusCap = models.NewMoneyFromDecimal(input.PerUserBonusCap.Decimal.Round(2))
+	rule.Locked = input.Locked
+	rule.StartsAt = input.StartsAt
+	rule.EndsAt = input.EndsAt
+	if input.IsActive != nil {
+		rule.IsActive = *input.IsActive
+	}
+	return nil
+}
+
+// calculateRechargeBonus 按命中的最高档位计算赠送金额
+func calculateRechargeBonus(rule *models.WalletRechargeBonusRule, amount decimal.Decimal) decimal.Decimal {
+	var matched *models.WalletRechargeBonusTier
+	for i := range rule.Tiers {
+		tier := &rule.Tiers[i]
+		if amount.GreaterThanOrEqual(tier.MinAmount.Decimal) {
+			if matched == nil || tier.MinAmount.Decimal.GreaterThan(matched.MinAmount.Decimal) {
+				matched = tier
+			}
+		}
+	}
+	if matched == nil {
+		return decimal.Zero
+	}
+	if matched.Type == constants.WalletRechargeBonusTypeFixed {
+		return matched.Value.Decimal.Round(2)
+	}
+	bonus := amount.Mul(matched.Value.Decimal).Div(decimal.NewFromInt(100)).Round(2)
+	if matched.MaxBonus.Decimal.IsPositive() && bonus.GreaterThan(matched.MaxBonus.Decimal) {
+		bonus = matched.MaxBonus.Decimal.Round(2)
+	}
+	return bonus
+}
+
+// grantRechargeBonus 在充值到账事务内按活动规则发放赠送余额，多个活动同时命中时取赠送金额最高者
+func (s *WalletService) grantRechargeBonus(repo *repository.GormWalletRepository, account *models.WalletAccount, recharge *models.WalletRechargeOrder, amount decimal.Decimal, firstRecharge bool, now time.Time) error {
+	rules, err := repo.ListActiveBonusRules(now)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var memberLevelID uint
+	memberLevelLoaded := false
+	var best *models.WalletRechargeBonusRule
+	bestBonus := decimal.Zero
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Currency != "" && rule.Currency != account.Currency {
+			continue
+		}
+		if rule.FirstRechargeOnly && !firstRecharge {
+			continue
+		}
+		if len(rule.MemberLevels) > 0 {
+			if !memberLevelLoaded && s.userRepo != nil {
+				user, err := s.userRepo.GetByID(recharge.UserID)
+				if err != nil {
+					return err
+				}
+				if user != nil {
+					memberLevelID = user.MemberLevelID
+				}
+				memberLevelLoaded = true
+			}
+			if !matchesRechargeBonusMemberLevel(rule, memberLevelID) {
+				continue
+			}
+		}
+		bonus := calculateRechargeBonus(rule, amount)
+		if bonus.LessThanOrEqual(decimal.Zero) {
+			continue
+		}
+		if rule.PerUserLimit > 0 || rule.PerUserBonusCap.Decimal.IsPositive() {
+			count, total, err := repo.SumRechargeBonuses(rule.ID, recharge.UserID)
+			if err != nil {
+				return err
+			}
+			if rule.PerUserLimit > 0 && count >= int64(rule.PerUserLimit) {
+				continue
+			}
+			if rule.PerUserBonusCap.Decimal.IsPositive() {
+				remaining := rule.PerUserBonusCap.Decimal.Sub(decimal.NewFromFloat(total)).Round(2)
+				if remaining.LessThanOrEqual(decimal.Zero) {
+					continue
+				}
+				if bonus.GreaterThan(remaining) {
+					bonus = remaining
+				}
+			}
+		}
+		if bonus.GreaterThan(bestBonus) {
+			best = rule
+			bestBonus = bonus
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	grant := &models.WalletRechargeBonus{
+		RuleID:     best.ID,
+		UserID:     recharge.UserID,
+		RechargeID: recharge.ID,
+		Amount:     models.NewMoneyFromDecimal(bestBonus),
+		Currency:   account.Currency,
+		Locked:     best.Locked,
+		Status:     constants.WalletRechargeBonusStatusGranted,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := repo.CreateRechargeBonus(grant); err != nil {
+		return err
+	}
+
+	before := account.Balance.Decimal.Round(2)
+	after := before.Add(bestBonus).Round(2)
+	account.Balance = models.NewMoneyFromDecimal(after)
+	if best.Locked {
+		account.LockedBonus = models.NewMoneyFromDecimal(account.LockedBonus.Decimal.Add(bestBonus).Round(2))
+	}
+	account.UpdatedAt = now
+	if err := repo.UpdateAccount(account); err != nil {
+		return ErrWalletAccountUpdateFailed
+	}
+	txn := &models.WalletTransaction{
+		UserID:        recharge.UserID,
+		Type:          constants.WalletTxnTypeRechargeBonus,
+		Direction:     constants.WalletTxnDirectionIn,
+		Amount:        models.NewMoneyFromDecimal(bestBonus),
+		BalanceBefore: models.NewMoneyFromDecimal(before),
+		BalanceAfter:  models.NewMoneyFromDecimal(after),
+		Currency:      account.Currency,
+		Reference:     fmt.Sprintf("recharge:%d:bonus", recharge.ID),
+		Remark:        fmt.Sprintf("充值赠送（%s）", best.Name),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := repo.CreateTransaction(txn); err != nil {
+		return ErrWalletTransactionCreateFailed
+	}
+	return nil
+}
+
+// RefundRecharge 管理端登记充值退款：扣回充值金额并追回该笔充值的赠送余额（资金需在支付平台另行原路退回）
+func (s *WalletService) RefundRecharge(rechargeID uint, remark string) (*models.WalletRechargeOrder, error) {
+	if rechargeID == 0 {
+		return nil, ErrWalletRechargeNotFound
+	}
+	var refunded *models.WalletRechargeOrder
+	err := s.walletRepo.Transaction(func(tx *gorm.DB) error {
+		repo := s.walletRepo.WithTx(tx)
+		recharge, err := repo.GetRechargeOrderByIDForUpdate(rechargeID)
+		if err != nil {
+			return err
+		}
+		if recharge == nil {
+			return ErrWalletRechargeNotFound
+		}
+		if recharge.Status != constants.WalletRechargeStatusSuccess {
+			return ErrWalletRechargeStatusInvalid
+		}
+
+		now := time.Now()
+		currency := s.resolveCurrency(recharge.Currency)
+		account, err := s.ensureAccountForUpdate(repo, recharge.UserID, currency, now)
+		if err != nil {
+			return err
+		}
+		bonus, err := repo.GetRechargeBonusByRechargeID(recharge.ID)
+		if err != nil {
+			return err
+		}
+		if bonus != nil && bonus.Status != constants.WalletRechargeBonusStatusGranted {
+			bonus = nil
+		}
+
+		amount := recharge.Amount.Decimal.Round(2)
+		total := amount
+		if bonus != nil {
+			total = total.Add(bonus.Amount.Decimal.Round(2))
+		}
+		if account.Balance.Decimal.Round(2).LessThan(total) {
+			return ErrWalletInsufficientBalance
+		}
+
+		entries := []struct {
+			txnType   string
+			amount    decimal.Decimal
+			reference string
+			remark    string
+		}{
+			{constants.WalletTxnTypeRechargeRefund, amount, fmt.Sprintf("recharge:%d:refund", recharge.ID), cleanWalletRemark(remark, fmt.Sprintf("充值退款（%s）", recharge.RechargeNo))},
+		}
+		if bonus != nil {
+			entries = append(entries, struct {
+				txnType   string
+				amount    decimal.Decimal
+				reference string
+				remark    string
+			}{constants.WalletTxnTypeBonusRevoke, bonus.Amount.Decimal.Round(2), fmt.Sprintf("recharge:%d:bonus_revoke", recharge.ID), fmt.Sprintf("充值退款追回赠送（%s）", recharge.RechargeNo)})
+			if bonus.Locked {
+				locked := account.LockedBonus.Decimal.Sub(bonus.Amount.Decimal).Round(2)
+				if locked.IsNegative() {
+					locked = decimal.Zero
+				}
+				account.LockedBonus = models.NewMoneyFromDecimal(locked)
+			}
+		}
+		for _, entry := range entries {
+			before := account.Balance.Decimal.Round(2)
+			after := before.Sub(entry.amount).Round(2)
+			account.Balance = models.NewMoneyFromDecimal(after)
+			account.UpdatedAt = now
+			if err := repo.UpdateAccount(account); err != nil {
+				return ErrWalletAccountUpdateFailed
+			}
+			txn := &models.WalletTransaction{
+				UserID:        recharge.UserID,
+				Type:          entry.txnType,
+				Direction:     constants.WalletTxnDirectionOut,
+				Amount:        models.NewMoneyFromDecimal(entry.amount),
+				BalanceBefore: models.NewMoneyFromDecimal(before),
+				BalanceAfter:  models.NewMoneyFromDecimal(after),
+				Currency:      currency,
+				Reference:     entry.reference,
+				Remark:        entry.remark,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			}
+			if err := repo.CreateTransaction(txn); err != nil {
+				return ErrWalletTransactionCreateFailed
+			}
+		}
+
+		if bonus != nil {
+			bonus.Status = constants.WalletRechargeBonusStatusRevoked
+			bonus.RevokedAt = &now
+			bonus.UpdatedAt = now
+			if err := repo.UpdateRechargeBonus(bonus); err != nil {
+				return err
+			}
+		}
+		recharge.Status = constants.WalletRechargeStatusRefunded
+		recharge.UpdatedAt = now
+		if err := repo.UpdateRechargeOrder(recharge); err != nil {
+			return err
+		}
+		refunded = recharge
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refunded, nil
+}
+
+// matchesRechargeBonusMemberLevel 判断会员等级是否满足充值赠送活动限制；未配置限制时默认允许
+func matchesRechargeBonusMemberLevel(rule *models.WalletRechargeBonusRule, memberLevelID uint) bool {
+	if len(rule.MemberLevels) == 0 {
+		return true
+	}
+	if memberLevelID == 0 {
+		return false
+	}
+	for _, levelID := range rule.MemberLevels {
+		if levelID == memberLevelID {
+			return true
+		}
+	}
+	return false
+}
+
+// walletWithdrawableBalance 可提现/可转出余额：扣除不可提现的赠送部分
+func walletWithdrawableBalance(account *models.WalletAccount) decimal.Decimal {
+	if account == nil {
+		return decimal.Zero
+	}
+	available := account.Balance.Decimal.Sub(account.LockedBonus.Decimal).Round(2)
+	if available.IsNegative() {
+		return decimal.Zero
+	}
+	return available
+}
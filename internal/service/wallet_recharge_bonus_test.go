@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func applyTestRecharge(t *testing.T, svc *WalletService, db *gorm.DB, userID, paymentID uint, amount int64) *models.WalletRechargeOrder {
+	t.Helper()
+	now := time.Now()
+	recharge := &models.WalletRechargeOrder{
+		RechargeNo:      generateSerialNo("WR"),
+		UserID:          userID,
+		PaymentID:       paymentID,
+		ChannelID:       1,
+		ProviderType:    constants.PaymentProviderOfficial,
+		ChannelType:     constants.PaymentChannelTypeAlipay,
+		InteractionMode: constants.PaymentInteractionRedirect,
+		Amount:          models.NewMoneyFromDecimal(decimal.NewFromInt(amount)),
+		PayableAmount:   models.NewMoneyFromDecimal(decimal.NewFromInt(amount)),
+		Currency:        "CNY",
+		Status:          constants.WalletRechargeStatusPending,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := db.Create(recharge).Error; err != nil {
+		t.Fatalf("create recharge order failed: %v", err)
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := svc.ApplyRechargePayment(tx, recharge); err != nil {
+			return err
+		}
+		recharge.Status = constants.WalletRechargeStatusSuccess
+		return tx.Save(recharge).Error
+	}); err != nil {
+		t.Fatalf("apply recharge failed: %v", err)
+	}
+	return recharge
+}
+
+func TestWalletRechargeBonusTiersAndRefundClawback(t *testing.T) {
+	svc, db := setupWalletServiceTest(t)
+	createTestUser(t, db, 501)
+	if _, err := svc.CreateRechargeBonusRule(WalletRechargeBonusRuleInput{
+		Name: "充100送10",
+		Tiers: []models.WalletRechargeBonusTier{
+			{MinAmount: models.NewMoneyFromDecimal(decimal.NewFromInt(500)), Type: constants.WalletRechargeBonusTypePercent, Value: models.NewMoneyFromDecimal(decimal.NewFromInt(20)), MaxBonus: models.NewMoneyFromDecimal(decimal.NewFromInt(80))},
+			{MinAmount: models.NewMoneyFromDecimal(decimal.NewFromInt(100)), Type: constants.WalletRechargeBonusTypeFixed, Value: models.NewMoneyFromDecimal(decimal.NewFromInt(10))},
+		},
+		Locked: true,
+	}); err != nil {
+		t.Fatalf("create bonus rule failed: %v", err)
+	}
+
+	applyTestRecharge(t, svc, db, 501, 9001, 50)
+	big := applyTestRecharge(t, svc, db, 501, 9002, 600)
+	applyTestRecharge(t, svc, db, 501, 9003, 150)
+
+	account, _ := svc.GetAccount(501)
+	// 50 + 600 + 80(20% 封顶) + 150 + 10
+	if !account.Balance.Decimal.Equal(decimal.NewFromInt(890)) || !account.LockedBonus.Decimal.Equal(decimal.NewFromInt(90)) {
+		t.Fatalf("unexpected balance %s locked %s", account.Balance.String(), account.LockedBonus.String())
+	}
+
+	if _, err := svc.RefundRecharge(big.ID, ""); err != nil {
+		t.Fatalf("refund recharge failed: %v", err)
+	}
+	account, _ = svc.GetAccount(501)
+	if !account.Balance.Decimal.Equal(decimal.NewFromInt(210)) || !account.LockedBonus.Decimal.Equal(decimal.NewFromInt(10)) {
+		t.Fatalf("unexpected balance after refund %s locked %s", account.Balance.String(), account.LockedBonus.String())
+	}
+	var bonus models.WalletRechargeBonus
+	if err := db.Where("recharge_id = ?", big.ID).First(&bonus).Error; err != nil {
+		t.Fatalf("load bonus failed: %v", err)
+	}
+	if bonus.Status != constants.WalletRechargeBonusStatusRevoked {
+		t.Fatalf("expected bonus revoked, got %s", bonus.Status)
+	}
+	var revoke models.WalletTransaction
+	if err := db.Where("type = ? AND user_id = ?", constants.WalletTxnTypeBonusRevoke, 501).First(&revoke).Error; err != nil {
+		t.Fatalf("expected bonus revoke transaction: %v", err)
+	}
+	if _, err := svc.RefundRecharge(big.ID, ""); !errors.Is(err, ErrWalletRechargeStatusInvalid) {
+		t.Fatalf("expected status invalid on second refund, got %v", err)
+	}
+}
+
+func TestWalletRechargeBonusEligibility(t *testing.T) {
+	svc, db := setupWalletServiceTest(t)
+	createTestUser(t, db, 511)
+	createTestUser(t, db, 512)
+	if err := db.Model(&models.User{}).Where("id = ?", 512).Update("member_level_id", 3).Error; err != nil {
+		t.Fatalf("set member level failed: %v", err)
+	}
+	tiers := []models.WalletRechargeBonusTier{{MinAmount: models.NewMoneyFromDecimal(decimal.NewFromInt(100)), Type: constants.WalletRechargeBonusTypeFixed, Value: models.NewMoneyFromDecimal(decimal.NewFromInt(10))}}
+	if _, err := svc.CreateRechargeBonusRule(WalletRechargeBonusRuleInput{Name: "首充", Tiers: tiers, FirstRechargeOnly: true}); err != nil {
+		t.Fatalf("create first recharge rule failed: %v", err)
+	}
+	if _, err := svc.CreateRechargeBonusRule(WalletRechargeBonusRuleInput{
+		Name:         "金卡专享",
+		Tiers:        []models.WalletRechargeBonusTier{{MinAmount: models.NewMoneyFromDecimal(decimal.NewFromInt(100)), Type: constants.WalletRechargeBonusTypeFixed, Value: models.NewMoneyFromDecimal(decimal.NewFromInt(30))}},
+		MemberLevels: []uint{3},
+		PerUserLimit: 1,
+	}); err != nil {
+		t.Fatalf("create member level rule failed: %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if _, err := svc.CreateRechargeBonusRule(WalletRechargeBonusRuleInput{Name: "已结束", Tiers: tiers, EndsAt: &past}); err != nil {
+		t.Fatalf("create expired rule failed: %v", err)
+	}
+
+	applyTestRecharge(t, svc, db, 511, 9101, 100)
+	applyTestRecharge(t, svc, db, 511, 9102, 100)
+	account, _ := svc.GetAccount(511)
+	if !account.Balance.Decimal.Equal(decimal.NewFromInt(210)) {
+		t.Fatalf("expected only first recharge bonus, got %s", account.Balance.String())
+	}
+
+	applyTestRecharge(t, svc, db, 512, 9201, 100)
+	applyTestRecharge(t, svc, db, 512, 9202, 100)
+	account, _ = svc.GetAccount(512)
+	// 首次命中金卡 30（取最高），第二次金卡次数用尽且非首充
+	if !account.Balance.Decimal.Equal(decimal.NewFromInt(230)) {
+		t.Fatalf("unexpected member level bonus balance: %s", account.Balance.String())
+	}
+
+	if _, err := svc.CreateRechargeBonusRule(WalletRechargeBonusRuleInput{Name: "bad", Tiers: []models.WalletRechargeBonusTier{{MinAmount: models.NewMoneyFromDecimal(decimal.NewFromInt(100)), Type: constants.WalletRechargeBonusTypePercent, Value: models.NewMoneyFromDecimal(decimal.NewFromInt(120))}}}); !errors.Is(err, ErrWalletRechargeBonusRuleInvalid) {
+		t.Fatalf("expected invalid percent rule, got %v", err)
+	}
+}
+
+func TestWalletLockedBonusCannotBeWithdrawn(t *testing.T) {
+	svc, db := setupWalletServiceTest(t)
+	createTestUser(t, db, 521)
+	enableWalletWithdrawForTest(t, svc)
+	if _, err := svc.CreateRechargeBonusRule(WalletRechargeBonusRuleInput{
+		Name:   "送10",
+		Tiers:  []models.WalletRechargeBonusTier{{MinAmount: models.NewMoneyFromDecimal(decimal.NewFromInt(100)), Type: constants.WalletRechargeBonusTypeFixed, Value: models.NewMoneyFromDecimal(decimal.NewFromInt(10))}},
+		Locked: true,
+	}); err != nil {
+		t.Fatalf("create bonus rule failed: %v", err)
+	}
+	applyTestRecharge(t, svc, db, 521, 9301, 100)
+
+	if _, err := svc.ApplyWithdraw(521, WalletWithdrawApplyInput{Amount: decimal.NewFromInt(105), Channel: "alipay", Account: "user521"}); !errors.Is(err, ErrWalletBonusLocked) {
+		t.Fatalf("expected locked bonus error, got %v", err)
+	}
+	if _, err := svc.ApplyWithdraw(521, WalletWithdrawApplyInput{Amount: decimal.NewFromInt(100), Channel: "alipay", Account: "user521"}); err != nil {
+		t.Fatalf("withdraw cash part failed: %v", err)
+	}
+	account, _ := svc.GetAccount(521)
+	if !account.Balance.Decimal.Equal(decimal.NewFromInt(10)) || !account.LockedBonus.Decimal.Equal(decimal.NewFromInt(10)) {
+		t.Fatalf("unexpected balance %s locked %s", account.Balance.String(), account.LockedBonus.String())
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -78,12 +79,115 @@ func NewWalletService(
 	}
 }
 
-// GetAccount 获取钱包账户（不存在时自动创建）
+// GetAccount 获取站点币种的钱包账户（不存在时自动创建）
 func (s *WalletService) GetAccount(userID uint) (*models.WalletAccount, error) {
+	return s.GetAccountByCurrency(userID, "")
+}
+
+// GetAccountByCurrency 获取指定币种的钱包账户（不存在时自动创建），币种为空时使用站点币种
+func (s *WalletService) GetAccountByCurrency(userID uint, currency string) (*models.WalletAccount, error) {
 	if userID == 0 {
 		return nil, ErrWalletAccountNotFound
 	}
-	return s.getOrCreateAccount(userID)
+	return s.getOrCreateAccount(userID, s.resolveCurrency(currency))
+}
+
+// ListAccounts 获取用户全部币种的钱包账户（始终包含站点币种账户）
+func (s *WalletService) ListAccounts(userID uint) ([]models.WalletAccount, error) {
+	if userID == 0 {
+		return nil, ErrWalletAccountNotFound
+	}
+	if _, err := s.getOrCreateAccount(userID, s.siteCurrency()); err != nil {
+		return nil, err
+	}
+	return s.walletRepo.ListAccountsByUserID(userID)
+}
+
+// SpendableBalance 计算可用于支付指定币种订单的余额（开启兑换时包含其他币种按汇率折算的部分）
+func (s *WalletService) SpendableBalance(userID uint, currency string) (decimal.Decimal, error) {
+	if userID == 0 {
+		return decimal.Zero, ErrWalletAccountNotFound
+	}
+	currency = s.resolveCurrency(currency)
+	accounts, err := s.walletRepo.ListAccountsByUserID(userID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	cfg := s.currencyConfig()
+	total := decimal.Zero
+	for _, account := range accounts {
+		balance := account.Balance.Decimal.Round(2)
+		if !balance.IsPositive() {
+			continue
+		}
+		if account.Currency == currency {
+			total = total.Add(balance)
+			continue
+		}
+		if !cfg.ConversionEnabled {
+			continue
+		}
+		if rate, ok := cfg.Rate(account.Currency, currency); ok {
+			total = total.Add(balance.Mul(rate).RoundDown(2))
+		}
+	}
+	return total.Round(2), nil
+}
+
+// WalletCurrencyStatement 单一币种的钱包对账单
+type WalletCurrencyStatement struct {
+	Currency string
+	Balance  models.Money
+	TotalIn  models.Money
+	TotalOut models.Money
+	InCount  int64
+	OutCount int64
+}
+
+// Statement 按币种汇总用户钱包余额与流水收支（filter.UserID 必填，可按币种与时间范围过滤）
+func (s *WalletService) Statement(filter repository.WalletTransactionListFilter) ([]WalletCurrencyStatement, error) {
+	if filter.UserID == 0 {
+		return nil, ErrWalletAccountNotFound
+	}
+	filter.Currency = strings.ToUpper(strings.TrimSpace(filter.Currency))
+	accounts, err := s.ListAccounts(filter.UserID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.walletRepo.SummarizeTransactions(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	statements := make([]WalletCurrencyStatement, 0, len(accounts))
+	ensure := func(currency string) *WalletCurrencyStatement {
+		if pos, ok := index[currency]; ok {
+			return &statements[pos]
+		}
+		index[currency] = len(statements)
+		statements = append(statements, WalletCurrencyStatement{Currency: currency})
+		return &statements[len(statements)-1]
+	}
+	for _, account := range accounts {
+		if filter.Currency != "" && account.Currency != filter.Currency {
+			continue
+		}
+		ensure(account.Currency).Balance = account.Balance
+	}
+	for _, row := range rows {
+		item := ensure(row.Currency)
+		amount := models.NewMoneyFromDecimal(decimal.NewFromFloat(row.Amount).Round(2))
+		switch row.Direction {
+		case constants.WalletTxnDirectionIn:
+			item.TotalIn = amount
+			item.InCount = row.TxnCount
+		case constants.WalletTxnDirectionOut:
+			item.TotalOut = amount
+			item.OutCount = row.TxnCount
+		}
+	}
+	return statements, nil
 }
 
 // ListTransactions 查询钱包流水
@@ -140,7 +244,7 @@ func (s *WalletService) GetRechargeOrderByPaymentIDAndUser(paymentID uint, userI
 	return order, nil
 }
 
-// GetBalancesByUserIDs 批量查询用户余额
+// GetBalancesByUserIDs 批量查询用户站点币种余额
 func (s *WalletService) GetBalancesByUserIDs(userIDs []uint) (map[uint]models.Money, error) {
 	result := make(map[uint]models.Money, len(userIDs))
 	if len(userIDs) == 0 {
@@ -150,8 +254,11 @@ func (s *WalletService) GetBalancesByUserIDs(userIDs []uint) (map[uint]models.Mo
 	if err != nil {
 		return nil, err
 	}
+	currency := s.siteCurrency()
 	for _, account := range accounts {
-		result[account.UserID] = account.Balance
+		if account.Currency == currency {
+			result[account.UserID] = account.Balance
+		}
 	}
 	return result, nil
 }
@@ -165,9 +272,12 @@ func (s *WalletService) Recharge(input WalletRechargeInput) (*models.WalletAccou
 	if amount.LessThanOrEqual(decimal.Zero) {
 		return nil, nil, ErrWalletInvalidAmount
 	}
+	currency := s.resolveCurrency(input.Currency)
+	if !settingCurrencyCodePattern.MatchString(currency) || !s.currencyConfig().AllowsCurrency(currency) {
+		return nil, nil, ErrWalletCurrencyInvalid
+	}
 	reference := buildWalletReference("recharge", input.UserID)
 	remark := cleanWalletRemark(input.Remark, "用户充值")
-	currency := normalizeWalletCurrency(input.Currency)
 	return s.changeBalance(input.UserID, amount, constants.WalletTxnTypeRecharge, nil, reference, remark, currency)
 }
 
@@ -180,9 +290,12 @@ func (s *WalletService) AdminAdjustBalance(input WalletAdjustInput) (*models.Wal
 	if delta.IsZero() {
 		return nil, nil, ErrWalletInvalidAmount
 	}
+	currency := s.resolveCurrency(input.Currency)
+	if !settingCurrencyCodePattern.MatchString(currency) {
+		return nil, nil, ErrWalletCurrencyInvalid
+	}
 	reference := buildWalletReference("admin_adjust", input.UserID)
 	remark := cleanWalletRemark(input.Remark, "管理员调整余额")
-	currency := normalizeWalletCurrency(input.Currency)
 	return s.changeBalance(input.UserID, delta, constants.WalletTxnTypeAdminAdjust, nil, reference, remark, currency)
 }
 
@@ -239,7 +352,8 @@ func (s *WalletService) AdminRefundToWallet(input AdminRefundToWalletInput) (*mo
 		}
 
 		repo := s.walletRepo.WithTx(tx)
-		account, err := s.ensureAccountForUpdate(repo, order.UserID, time.Now())
+		currency := s.resolveCurrency(order.Currency)
+		account, err := s.ensureAccountForUpdate(repo, order.UserID, currency, time.Now())
 		if err != nil {
 			return err
 		}
@@ -280,6 +394,11 @@ func (s *WalletService) AdminRefundToWallet(input AdminRefundToWalletInput) (*mo
 				return ErrOrderUpdateFailed
 			}
 		}
+		if markRefunded {
+			if err := revokeOrderLicensesTx(tx, order.ID, now); err != nil {
+				return err
+			}
+		}
 		if s.affiliateSvc != nil {
 			if err := s.affiliateSvc.HandleOrderRefundedTx(
 				tx,
@@ -300,7 +419,7 @@ func (s *WalletService) AdminRefundToWallet(input AdminRefundToWalletInput) (*mo
 			Amount:        models.NewMoneyFromDecimal(amount),
 			BalanceBefore: models.NewMoneyFromDecimal(before),
 			BalanceAfter:  models.NewMoneyFromDecimal(after),
-			Currency:      normalizeWalletCurrency(order.Currency),
+			Currency:      currency,
 			Reference:     reference,
 			Remark:        remark,
 			CreatedAt:     time.Now(),
@@ -315,7 +434,7 @@ func (s *WalletService) AdminRefundToWallet(input AdminRefundToWalletInput) (*mo
 			OrderID:    order.ID,
 			Type:       constants.OrderRefundTypeWallet,
 			Amount:     models.NewMoneyFromDecimal(amount),
-			Currency:   normalizeWalletCurrency(order.Currency),
+			Currency:   currency,
 			Remark:     recordRemark,
 			CreatedAt:  now,
 			UpdatedAt:  now,
@@ -340,7 +459,8 @@ func (s *WalletService) AdminRefundToWallet(input AdminRefundToWalletInput) (*mo
 	return order, txnResult, refundRecordResult, nil
 }
 
-// ApplyOrderBalance 在事务内为订单扣减余额并记录流水，返回扣减金额
+// ApplyOrderBalance 在事务内为订单扣减余额并记录流水，返回扣减金额（订单币种）
+// 优先扣减订单币种账户；开启兑换且配置了汇率时，不足部分按汇率抵扣其他币种账户（按币种顺序）。
 func (s *WalletService) ApplyOrderBalance(tx *gorm.DB, order *models.Order, useBalance bool) (decimal.Decimal, error) {
 	if tx == nil {
 		return decimal.Zero, ErrOrderUpdateFailed
@@ -364,60 +484,113 @@ func (s *WalletService) ApplyOrderBalance(tx *gorm.DB, order *models.Order, useB
 
 	now := time.Now()
 	repo := s.walletRepo.WithTx(tx)
-	account, err := s.ensureAccountForUpdate(repo, order.UserID, now)
+	currency := s.resolveCurrency(order.Currency)
+	reference := buildOrderWalletReference(order.ID, constants.WalletTxnTypeOrderPay)
+	paid, err := repo.ListOrderTransactions(order.ID, constants.WalletTxnTypeOrderPay)
 	if err != nil {
 		return decimal.Zero, err
 	}
-
-	available := account.Balance.Decimal.Round(2)
-	if available.LessThanOrEqual(decimal.Zero) {
-		return decimal.Zero, nil
-	}
-	deduct := available
-	if deduct.GreaterThan(order.TotalAmount.Decimal) {
-		deduct = order.TotalAmount.Decimal.Round(2)
-	}
-	if deduct.LessThanOrEqual(decimal.Zero) {
-		return decimal.Zero, nil
+	if len(paid) > 0 {
+		return sumWalletSettleAmount(paid), nil
 	}
 
-	reference := buildOrderWalletReference(order.ID, constants.WalletTxnTypeOrderPay)
-	exists, err := repo.GetTransactionByReference(reference)
+	// 开启兑换时可能扣减多个币种账户，先按账户ID升序统一加锁再计算，避免与其他多账户事务交叉加锁死锁
+	cfg := s.currencyConfig()
+	accounts := make(map[string]*models.WalletAccount, 1)
+	if cfg.ConversionEnabled {
+		accounts, err = s.lockWalletAccounts(repo, order.UserID, currency, now)
+	} else {
+		accounts[currency], err = s.ensureAccountForUpdate(repo, order.UserID, currency, now)
+	}
 	if err != nil {
 		return decimal.Zero, err
 	}
-	if exists != nil {
-		return exists.Amount.Decimal.Round(2), nil
+	account := accounts[currency]
+	remaining := order.TotalAmount.Decimal.Round(2)
+	deductions := make([]walletOrderDeduction, 0, 1)
+	if available := account.Balance.Decimal.Round(2); available.GreaterThan(decimal.Zero) {
+		amount := decimal.Min(available, remaining)
+		deductions = append(deductions, walletOrderDeduction{account: account, amount: amount, settle: amount})
+		remaining = remaining.Sub(amount)
 	}
 
-	before := account.Balance.Decimal.Round(2)
-	after := before.Sub(deduct).Round(2)
-	if after.LessThan(decimal.Zero) {
-		return decimal.Zero, ErrWalletInsufficientBalance
+	if remaining.GreaterThan(decimal.Zero) && cfg.ConversionEnabled {
+		// 其他币种按币种顺序抵扣
+		currencies := make([]string, 0, len(accounts))
+		for code := range accounts {
+			currencies = append(currencies, code)
+		}
+		sort.Strings(currencies)
+		for _, code := range currencies {
+			if remaining.LessThanOrEqual(decimal.Zero) {
+				break
+			}
+			locked := accounts[code]
+			if code == currency || !locked.Balance.Decimal.IsPositive() {
+				continue
+			}
+			rate, ok := cfg.Rate(code, currency)
+			if !ok {
+				continue
+			}
+			balance := locked.Balance.Decimal.Round(2)
+			settle := decimal.Min(balance.Mul(rate).RoundDown(2), remaining)
+			if settle.LessThanOrEqual(decimal.Zero) {
+				continue
+			}
+			amount := decimal.Min(settle.Div(rate).RoundUp(2), balance)
+			if amount.LessThanOrEqual(decimal.Zero) {
+				continue
+			}
+			rateCopy := rate
+			deductions = append(deductions, walletOrderDeduction{account: locked, amount: amount, settle: settle, rate: &rateCopy})
+			remaining = remaining.Sub(settle)
+		}
 	}
-	account.Balance = models.NewMoneyFromDecimal(after)
-	account.UpdatedAt = now
-	if err := repo.UpdateAccount(account); err != nil {
-		return decimal.Zero, ErrWalletAccountUpdateFailed
+	if len(deductions) == 0 {
+		return decimal.Zero, nil
 	}
 
-	txn := &models.WalletTransaction{
-		UserID:        order.UserID,
-		OrderID:       &order.ID,
-		Type:          constants.WalletTxnTypeOrderPay,
-		Direction:     constants.WalletTxnDirectionOut,
-		Amount:        models.NewMoneyFromDecimal(deduct),
-		BalanceBefore: models.NewMoneyFromDecimal(before),
-		BalanceAfter:  models.NewMoneyFromDecimal(after),
-		Currency:      normalizeWalletCurrency(order.Currency),
-		Reference:     reference,
-		Remark:        "订单余额支付",
-		CreatedAt:     now,
-		UpdatedAt:     now,
-	}
-	if err := repo.CreateTransaction(txn); err != nil {
-		return decimal.Zero, ErrWalletTransactionCreateFailed
+	deduct := decimal.Zero
+	for _, item := range deductions {
+		before := item.account.Balance.Decimal.Round(2)
+		after := before.Sub(item.amount).Round(2)
+		if after.LessThan(decimal.Zero) {
+			return decimal.Zero, ErrWalletInsufficientBalance
+		}
+		item.account.Balance = models.NewMoneyFromDecimal(after)
+		item.account.UpdatedAt = now
+		if err := repo.UpdateAccount(item.account); err != nil {
+			return decimal.Zero, ErrWalletAccountUpdateFailed
+		}
+
+		txn := &models.WalletTransaction{
+			UserID:        order.UserID,
+			OrderID:       &order.ID,
+			Type:          constants.WalletTxnTypeOrderPay,
+			Direction:     constants.WalletTxnDirectionOut,
+			Amount:        models.NewMoneyFromDecimal(item.amount),
+			BalanceBefore: models.NewMoneyFromDecimal(before),
+			BalanceAfter:  models.NewMoneyFromDecimal(after),
+			Currency:      item.account.Currency,
+			Reference:     reference,
+			Remark:        "订单余额支付",
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if item.rate != nil {
+			settle := models.NewMoneyFromDecimal(item.settle)
+			txn.ExchangeRate = item.rate
+			txn.SettleAmount = &settle
+			txn.Reference = reference + ":" + item.account.Currency
+			txn.Remark = fmt.Sprintf("订单余额支付（%s 折算 %s %s）", item.account.Currency, item.settle.StringFixed(2), currency)
+		}
+		if err := repo.CreateTransaction(txn); err != nil {
+			return decimal.Zero, ErrWalletTransactionCreateFailed
+		}
+		deduct = deduct.Add(item.settle)
 	}
+	deduct = deduct.Round(2)
 
 	onlineAmount := normalizeOrderAmount(order.TotalAmount.Decimal.Sub(deduct))
 	if err := tx.Model(&models.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
@@ -433,7 +606,7 @@ func (s *WalletService) ApplyOrderBalance(tx *gorm.DB, order *models.Order, useB
 	return deduct, nil
 }
 
-// ReleaseOrderBalance 在事务内将订单已扣余额退回钱包，返回退回金额
+// ReleaseOrderBalance 在事务内将订单已扣余额按原币种退回钱包，返回退回金额（订单币种）
 func (s *WalletService) ReleaseOrderBalance(tx *gorm.DB, order *models.Order, txnType string, remark string) (decimal.Decimal, error) {
 	if tx == nil {
 		return decimal.Zero, ErrOrderUpdateFailed
@@ -454,7 +627,7 @@ func (s *WalletService) ReleaseOrderBalance(tx *gorm.DB, order *models.Order, tx
 		return decimal.Zero, err
 	}
 	if exists != nil {
-		return exists.Amount.Decimal.Round(2), nil
+		return amount, nil
 	}
 
 	result := tx.Model(&models.Order{}).Where("id = ? AND wallet_paid_amount > 0", order.ID).Updates(map[string]interface{}{
@@ -469,34 +642,65 @@ func (s *WalletService) ReleaseOrderBalance(tx *gorm.DB, order *models.Order, tx
 		return decimal.Zero, nil
 	}
 
-	account, err := s.ensureAccountForUpdate(repo, order.UserID, now)
+	// 按支付时的各币种流水原路退回；历史订单无流水时退回订单币种账户
+	paid, err := repo.ListOrderTransactions(order.ID, constants.WalletTxnTypeOrderPay)
 	if err != nil {
 		return decimal.Zero, err
 	}
-	before := account.Balance.Decimal.Round(2)
-	after := before.Add(amount).Round(2)
-	account.Balance = models.NewMoneyFromDecimal(after)
-	account.UpdatedAt = now
-	if err := repo.UpdateAccount(account); err != nil {
-		return decimal.Zero, ErrWalletAccountUpdateFailed
+	if len(paid) == 0 {
+		paid = []models.WalletTransaction{{
+			Amount:   models.NewMoneyFromDecimal(amount),
+			Currency: s.resolveCurrency(order.Currency),
+		}}
 	}
-
-	txn := &models.WalletTransaction{
-		UserID:        order.UserID,
-		OrderID:       &order.ID,
-		Type:          txnType,
-		Direction:     constants.WalletTxnDirectionIn,
-		Amount:        models.NewMoneyFromDecimal(amount),
-		BalanceBefore: models.NewMoneyFromDecimal(before),
-		BalanceAfter:  models.NewMoneyFromDecimal(after),
-		Currency:      normalizeWalletCurrency(order.Currency),
-		Reference:     reference,
-		Remark:        cleanWalletRemark(remark, "订单余额退回"),
-		CreatedAt:     now,
-		UpdatedAt:     now,
+	orderCurrency := s.resolveCurrency(order.Currency)
+	// 可能退回多个币种账户，按账户ID升序统一加锁
+	accounts, err := s.lockWalletAccounts(repo, order.UserID, "", now)
+	if err != nil {
+		return decimal.Zero, err
 	}
-	if err := repo.CreateTransaction(txn); err != nil {
-		return decimal.Zero, ErrWalletTransactionCreateFailed
+	for _, item := range paid {
+		refund := item.Amount.Decimal.Round(2)
+		if refund.LessThanOrEqual(decimal.Zero) {
+			continue
+		}
+		account := accounts[item.Currency]
+		if account == nil {
+			if account, err = s.ensureAccountForUpdate(repo, order.UserID, item.Currency, now); err != nil {
+				return decimal.Zero, err
+			}
+			accounts[item.Currency] = account
+		}
+		before := account.Balance.Decimal.Round(2)
+		after := before.Add(refund).Round(2)
+		account.Balance = models.NewMoneyFromDecimal(after)
+		account.UpdatedAt = now
+		if err := repo.UpdateAccount(account); err != nil {
+			return decimal.Zero, ErrWalletAccountUpdateFailed
+		}
+
+		txn := &models.WalletTransaction{
+			UserID:        order.UserID,
+			OrderID:       &order.ID,
+			Type:          txnType,
+			Direction:     constants.WalletTxnDirectionIn,
+			Amount:        models.NewMoneyFromDecimal(refund),
+			BalanceBefore: models.NewMoneyFromDecimal(before),
+			BalanceAfter:  models.NewMoneyFromDecimal(after),
+			Currency:      account.Currency,
+			ExchangeRate:  item.ExchangeRate,
+			SettleAmount:  item.SettleAmount,
+			Reference:     reference,
+			Remark:        cleanWalletRemark(remark, "订单余额退回"),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if account.Currency != orderCurrency {
+			txn.Reference = reference + ":" + account.Currency
+		}
+		if err := repo.CreateTransaction(txn); err != nil {
+			return decimal.Zero, ErrWalletTransactionCreateFailed
+		}
 	}
 
 	order.WalletPaidAmount = models.NewMoneyFromDecimal(decimal.Zero)
@@ -529,7 +733,12 @@ func (s *WalletService) ApplyRechargePayment(tx *gorm.DB, recharge *models.Walle
 	}
 
 	now := time.Now()
-	account, err := s.ensureAccountForUpdate(repo, recharge.UserID, now)
+	currency := s.resolveCurrency(recharge.Currency)
+	account, err := s.ensureAccountForUpdate(repo, recharge.UserID, currency, now)
+	if err != nil {
+		return nil, err
+	}
+	previousRecharges, err := repo.CountTransactionsByType(recharge.UserID, constants.WalletTxnTypeRecharge)
 	if err != nil {
 		return nil, err
 	}
@@ -549,7 +758,7 @@ func (s *WalletService) ApplyRechargePayment(tx *gorm.DB, recharge *models.Walle
 		Amount:        models.NewMoneyFromDecimal(amount),
 		BalanceBefore: models.NewMoneyFromDecimal(before),
 		BalanceAfter:  models.NewMoneyFromDecimal(after),
-		Currency:      normalizeWalletCurrency(recharge.Currency),
+		Currency:      currency,
 		Reference:     reference,
 		Remark:        cleanWalletRemark(recharge.Remark, "在线充值到账"),
 		CreatedAt:     now,
@@ -558,6 +767,9 @@ func (s *WalletService) ApplyRechargePayment(tx *gorm.DB, recharge *models.Walle
 	if err := repo.CreateTransaction(txn); err != nil {
 		return nil, ErrWalletTransactionCreateFailed
 	}
+	if err := s.grantRechargeBonus(repo, account, recharge, amount, previousRecharges == 0, now); err != nil {
+		return nil, err
+	}
 	return txn, nil
 }
 
@@ -582,6 +794,7 @@ func (s *WalletService) CreditInTx(tx *gorm.DB, input WalletCreditInput) (*model
 		txnType = constants.WalletTxnTypeRecharge
 	}
 	remark := cleanWalletRemark(input.Remark, "钱包入账")
+	currency := s.resolveCurrency(input.Currency)
 	now := time.Now()
 	repo := s.walletRepo.WithTx(tx)
 
@@ -590,12 +803,12 @@ func (s *WalletService) CreditInTx(tx *gorm.DB, input WalletCreditInput) (*model
 		return nil, nil, err
 	}
 	if exists != nil {
-		account, accountErr := repo.GetAccountByUserID(input.UserID)
+		account, accountErr := repo.GetAccount(input.UserID, exists.Currency)
 		if accountErr != nil {
 			return nil, nil, accountErr
 		}
 		if account == nil {
-			account, accountErr = s.ensureAccountForUpdate(repo, input.UserID, now)
+			account, accountErr = s.ensureAccountForUpdate(repo, input.UserID, exists.Currency, now)
 			if accountErr != nil {
 				return nil, nil, accountErr
 			}
@@ -603,7 +816,7 @@ func (s *WalletService) CreditInTx(tx *gorm.DB, input WalletCreditInput) (*model
 		return account, exists, nil
 	}
 
-	account, err := s.ensureAccountForUpdate(repo, input.UserID, now)
+	account, err := s.ensureAccountForUpdate(repo, input.UserID, currency, now)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -623,7 +836,7 @@ func (s *WalletService) CreditInTx(tx *gorm.DB, input WalletCreditInput) (*model
 		Amount:        models.NewMoneyFromDecimal(amount),
 		BalanceBefore: models.NewMoneyFromDecimal(before),
 		BalanceAfter:  models.NewMoneyFromDecimal(after),
-		Currency:      normalizeWalletCurrency(input.Currency),
+		Currency:      currency,
 		Reference:     reference,
 		Remark:        remark,
 		CreatedAt:     now,
@@ -641,7 +854,7 @@ func (s *WalletService) changeBalance(userID uint, delta decimal.Decimal, txnTyp
 	if err := s.walletRepo.Transaction(func(tx *gorm.DB) error {
 		repo := s.walletRepo.WithTx(tx)
 		now := time.Now()
-		account, err := s.ensureAccountForUpdate(repo, userID, now)
+		account, err := s.ensureAccountForUpdate(repo, userID, currency, now)
 		if err != nil {
 			return err
 		}
@@ -672,7 +885,7 @@ func (s *WalletService) changeBalance(userID uint, delta decimal.Decimal, txnTyp
 			Amount:        models.NewMoneyFromDecimal(amount),
 			BalanceBefore: models.NewMoneyFromDecimal(before),
 			BalanceAfter:  models.NewMoneyFromDecimal(after),
-			Currency:      normalizeWalletCurrency(currency),
+			Currency:      currency,
 			Reference:     strings.TrimSpace(reference),
 			Remark:        remark,
 			CreatedAt:     now,
@@ -691,8 +904,8 @@ func (s *WalletService) changeBalance(userID uint, delta decimal.Decimal, txnTyp
 	return accountResult, txnResult, nil
 }
 
-func (s *WalletService) getOrCreateAccount(userID uint) (*models.WalletAccount, error) {
-	account, err := s.walletRepo.GetAccountByUserID(userID)
+func (s *WalletService) getOrCreateAccount(userID uint, currency string) (*models.WalletAccount, error) {
+	account, err := s.walletRepo.GetAccount(userID, currency)
 	if err != nil {
 		return nil, err
 	}
@@ -702,12 +915,13 @@ func (s *WalletService) getOrCreateAccount(userID uint) (*models.WalletAccount,
 	now := time.Now()
 	account = &models.WalletAccount{
 		UserID:    userID,
+		Currency:  currency,
 		Balance:   models.NewMoneyFromDecimal(decimal.Zero),
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 	if err := s.walletRepo.CreateAccount(account); err != nil {
-		created, queryErr := s.walletRepo.GetAccountByUserID(userID)
+		created, queryErr := s.walletRepo.GetAccount(userID, currency)
 		if queryErr == nil && created != nil {
 			return created, nil
 		}
@@ -716,8 +930,8 @@ func (s *WalletService) getOrCreateAccount(userID uint) (*models.WalletAccount,
 	return account, nil
 }
 
-func (s *WalletService) ensureAccountForUpdate(repo *repository.GormWalletRepository, userID uint, now time.Time) (*models.WalletAccount, error) {
-	account, err := repo.GetAccountByUserIDForUpdate(userID)
+func (s *WalletService) ensureAccountForUpdate(repo *repository.GormWalletRepository, userID uint, currency string, now time.Time) (*models.WalletAccount, error) {
+	account, err := repo.GetAccountForUpdate(userID, currency)
 	if err != nil {
 		return nil, err
 	}
@@ -726,12 +940,13 @@ func (s *WalletService) ensureAccountForUpdate(repo *repository.GormWalletReposi
 	}
 	account = &models.WalletAccount{
 		UserID:    userID,
+		Currency:  currency,
 		Balance:   models.NewMoneyFromDecimal(decimal.Zero),
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 	if err := repo.CreateAccount(account); err != nil {
-		created, queryErr := repo.GetAccountByUserIDForUpdate(userID)
+		created, queryErr := repo.GetAccountForUpdate(userID, currency)
 		if queryErr == nil && created != nil {
 			return created, nil
 		}
@@ -740,6 +955,64 @@ func (s *WalletService) ensureAccountForUpdate(repo *repository.GormWalletReposi
 	return account, nil
 }
 
+// lockWalletAccounts 按账户ID升序锁定用户全部钱包账户；指定币种账户不存在时创建（新账户ID最大，不破坏加锁顺序）
+func (s *WalletService) lockWalletAccounts(repo *repository.GormWalletRepository, userID uint, currency string, now time.Time) (map[string]*models.WalletAccount, error) {
+	rows, err := repo.ListAccountsByUserIDForUpdate(userID)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make(map[string]*models.WalletAccount, len(rows)+1)
+	for i := range rows {
+		accounts[rows[i].Currency] = &rows[i]
+	}
+	if currency != "" && accounts[currency] == nil {
+		account, err := s.ensureAccountForUpdate(repo, userID, currency, now)
+		if err != nil {
+			return nil, err
+		}
+		accounts[currency] = account
+	}
+	return accounts, nil
+}
+
+// siteCurrency 站点币种，作为未指定币种时的默认钱包币种
+func (s *WalletService) siteCurrency() string {
+	return normalizeWalletCurrency(resolveServiceSiteCurrency(s.settingService))
+}
+
+// resolveCurrency 归一化币种，为空时使用站点币种
+func (s *WalletService) resolveCurrency(currency string) string {
+	if strings.TrimSpace(currency) == "" {
+		return s.siteCurrency()
+	}
+	return normalizeWalletCurrency(currency)
+}
+
+func (s *WalletService) currencyConfig() WalletCurrencyConfig {
+	return s.settingService.GetWalletCurrencyConfig()
+}
+
+// walletOrderDeduction 订单余额支付的单币种扣减计划
+type walletOrderDeduction struct {
+	account *models.WalletAccount
+	amount  decimal.Decimal  // 扣减金额（账户币种）
+	settle  decimal.Decimal  // 折算金额（订单币种）
+	rate    *decimal.Decimal // 跨币种抵扣汇率，同币种为 nil
+}
+
+// sumWalletSettleAmount 汇总订单支付流水折算为订单币种的金额
+func sumWalletSettleAmount(txns []models.WalletTransaction) decimal.Decimal {
+	total := decimal.Zero
+	for _, txn := range txns {
+		if txn.SettleAmount != nil {
+			total = total.Add(txn.SettleAmount.Decimal)
+			continue
+		}
+		total = total.Add(txn.Amount.Decimal)
+	}
+	return total.Round(2)
+}
+
 func normalizeWalletCurrency(currency string) string {
 	normalized := strings.ToUpper(strings.TrimSpace(currency))
 	if normalized == "" {
@@ -34,7 +34,14 @@ func setupWalletServiceTest(t *testing.T) (*WalletService, *gorm.DB) {
 		&models.AffiliateWithdrawRequest{},
 		&models.WalletAccount{},
 		&models.WalletTransaction{},
+		&models.WalletTransfer{},
+		&models.WalletWithdrawRequest{},
+		&models.WalletRechargeOrder{},
+		&models.WalletRechargeBonusRule{},
+		&models.WalletRechargeBonus{},
+		&models.Admin{},
 		&models.OrderRefundRecord{},
+		&models.License{},
 		&models.Setting{},
 	); err != nil {
 		t.Fatalf("auto migrate failed: %v", err)
@@ -637,3 +644,139 @@ func TestWalletServiceAdminRefundToWalletParentFullMixedChildrenStatus(t *testin
 		t.Fatalf("expected auto child refunded, got: %s", refreshedAuto.Status)
 	}
 }
+
+func TestWalletServiceMultiCurrencyAccounts(t *testing.T) {
+	svc, db := setupWalletServiceTest(t)
+	createTestUser(t, db, 120)
+
+	if _, _, err := svc.Recharge(WalletRechargeInput{UserID: 120, Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(20))}); err != nil {
+		t.Fatalf("recharge CNY failed: %v", err)
+	}
+	usd, _, err := svc.Recharge(WalletRechargeInput{UserID: 120, Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(10)), Currency: "usd"})
+	if err != nil {
+		t.Fatalf("recharge USD failed: %v", err)
+	}
+	if usd.Currency != "USD" || !usd.Balance.Decimal.Equal(decimal.NewFromInt(10)) {
+		t.Fatalf("unexpected USD account: %+v", usd)
+	}
+	primary, err := svc.GetAccount(120)
+	if err != nil || primary.Currency != "CNY" || !primary.Balance.Decimal.Equal(decimal.NewFromInt(20)) {
+		t.Fatalf("unexpected primary account: %+v err=%v", primary, err)
+	}
+	accounts, err := svc.ListAccounts(120)
+	if err != nil || len(accounts) != 2 {
+		t.Fatalf("expected 2 accounts, got %+v err=%v", accounts, err)
+	}
+
+	if _, err := svc.settingService.Update(constants.SettingKeyWalletConfig, map[string]interface{}{
+		"currencies": []interface{}{"CNY"},
+	}); err != nil {
+		t.Fatalf("update wallet config failed: %v", err)
+	}
+	if _, _, err := svc.Recharge(WalletRechargeInput{UserID: 120, Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(1)), Currency: "EUR"}); !errors.Is(err, ErrWalletCurrencyInvalid) {
+		t.Fatalf("expected currency invalid, got %v", err)
+	}
+}
+
+func TestWalletServiceApplyOrderBalanceSpendsOrderCurrencyOnly(t *testing.T) {
+	svc, db := setupWalletServiceTest(t)
+	createTestUser(t, db, 121)
+	order := createTestOrder(t, db, 121, "DJTESTCURRENCY001", decimal.NewFromInt(30))
+
+	if _, _, err := svc.Recharge(WalletRechargeInput{UserID: 121, Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(10))}); err != nil {
+		t.Fatalf("recharge CNY failed: %v", err)
+	}
+	if _, _, err := svc.Recharge(WalletRechargeInput{UserID: 121, Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(100)), Currency: "USD"}); err != nil {
+		t.Fatalf("recharge USD failed: %v", err)
+	}
+
+	var deducted decimal.Decimal
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		deducted, err = svc.ApplyOrderBalance(tx, order, true)
+		return err
+	}); err != nil {
+		t.Fatalf("apply order balance failed: %v", err)
+	}
+	if !deducted.Equal(decimal.NewFromInt(10)) {
+		t.Fatalf("expected deducted 10, got %s", deducted.String())
+	}
+	usd, err := svc.GetAccountByCurrency(121, "USD")
+	if err != nil || !usd.Balance.Decimal.Equal(decimal.NewFromInt(100)) {
+		t.Fatalf("USD balance should be untouched: %+v err=%v", usd, err)
+	}
+}
+
+func TestWalletServiceApplyOrderBalanceWithConversion(t *testing.T) {
+	svc, db := setupWalletServiceTest(t)
+	createTestUser(t, db, 122)
+	order := createTestOrder(t, db, 122, "DJTESTCURRENCY002", decimal.NewFromInt(30))
+
+	if _, err := svc.settingService.Update(constants.SettingKeyWalletConfig, map[string]interface{}{
+		"currency_conversion_enabled": true,
+		"exchange_rates":              []interface{}{map[string]interface{}{"from": "USD", "to": "CNY", "rate": "7"}},
+	}); err != nil {
+		t.Fatalf("update wallet config failed: %v", err)
+	}
+	if _, _, err := svc.Recharge(WalletRechargeInput{UserID: 122, Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(10))}); err != nil {
+		t.Fatalf("recharge CNY failed: %v", err)
+	}
+	if _, _, err := svc.Recharge(WalletRechargeInput{UserID: 122, Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(5)), Currency: "USD"}); err != nil {
+		t.Fatalf("recharge USD failed: %v", err)
+	}
+	spendable, err := svc.SpendableBalance(122, "CNY")
+	if err != nil || !spendable.Equal(decimal.NewFromInt(45)) {
+		t.Fatalf("expected spendable 45, got %s err=%v", spendable.String(), err)
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		deducted, err := svc.ApplyOrderBalance(tx, order, true)
+		if err != nil {
+			return err
+		}
+		if !deducted.Equal(decimal.NewFromInt(30)) {
+			t.Fatalf("expected deducted 30, got %s", deducted.String())
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("apply order balance failed: %v", err)
+	}
+	usd, _ := svc.GetAccountByCurrency(122, "USD")
+	if !usd.Balance.Decimal.Equal(decimal.RequireFromString("2.14")) {
+		t.Fatalf("expected USD balance 2.14, got %s", usd.Balance.String())
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		refunded, err := svc.ReleaseOrderBalance(tx, order, constants.WalletTxnTypeOrderRefund, "测试回退")
+		if err != nil {
+			return err
+		}
+		if !refunded.Equal(decimal.NewFromInt(30)) {
+			t.Fatalf("expected refunded 30, got %s", refunded.String())
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("release order balance failed: %v", err)
+	}
+	cny, _ := svc.GetAccount(122)
+	usd, _ = svc.GetAccountByCurrency(122, "USD")
+	if !cny.Balance.Decimal.Equal(decimal.NewFromInt(10)) || !usd.Balance.Decimal.Equal(decimal.NewFromInt(5)) {
+		t.Fatalf("expected balances restored in original currencies, got CNY=%s USD=%s", cny.Balance.String(), usd.Balance.String())
+	}
+
+	statements, err := svc.Statement(repository.WalletTransactionListFilter{UserID: 122})
+	if err != nil {
+		t.Fatalf("statement failed: %v", err)
+	}
+	if len(statements) != 2 {
+		t.Fatalf("expected 2 currency statements, got %+v", statements)
+	}
+	for _, item := range statements {
+		if item.Currency != "USD" {
+			continue
+		}
+		if item.InCount != 2 || item.OutCount != 1 || !item.TotalOut.Decimal.Equal(decimal.RequireFromString("2.86")) {
+			t.Fatalf("unexpected USD statement: %+v", item)
+		}
+	}
+}
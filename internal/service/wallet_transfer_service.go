@@ -0,0 +1,351 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	walletConfigFieldTransferEnabled          = "transfer_enabled"
+	walletConfigFieldTransferDisabledLevelIDs = "transfer_disabled_member_level_ids"
+	walletConfigFieldTransferMinAmount        = "transfer_min_amount"
+	walletConfigFieldTransferDailyAmountLimit = "transfer_daily_amount_limit"
+	walletConfigFieldTransferDailyCountLimit  = "transfer_daily_count_limit"
+	walletConfigFieldTransferFeeRate          = "transfer_fee_rate"
+	walletConfigFieldTransferFeeFixed         = "transfer_fee_fixed"
+)
+
+// WalletTransferConfig 钱包转账配置（存储于 wallet_config）
+type WalletTransferConfig struct {
+	Enabled                bool            `json:"transfer_enabled"`                   // 是否允许用户间转账（未配置时默认允许）
+	DisabledMemberLevelIDs []uint          `json:"transfer_disabled_member_level_ids"` // 禁止发起转账的会员等级
+	MinAmount              decimal.Decimal `json:"transfer_min_amount"`                // 单笔最低金额（0=不限制）
+	DailyAmountLimit       decimal.Decimal `json:"transfer_daily_amount_limit"`        // 每日转出总额上限（0=不限制）
+	DailyCountLimit        int             `json:"transfer_daily_count_limit"`         // 每日转出笔数上限（0=不限制）
+	FeeRate                decimal.Decimal `json:"transfer_fee_rate"`                  // 手续费比例（百分比）
+	FeeFixed               decimal.Decimal `json:"transfer_fee_fixed"`                 // 每笔固定手续费
+}
+
+// AllowsMemberLevel 判断会员等级是否允许发起转账
+func (c WalletTransferConfig) AllowsMemberLevel(levelID uint) bool {
+	for _, item := range c.DisabledMemberLevelIDs {
+		if item == levelID {
+			return false
+		}
+	}
+	return true
+}
+
+// Fee 计算转账手续费（由转出方承担）
+func (c WalletTransferConfig) Fee(amount decimal.Decimal) decimal.Decimal {
+	fee := amount.Mul(c.FeeRate).Div(decimal.NewFromInt(100)).Add(c.FeeFixed)
+	if fee.LessThan(decimal.Zero) {
+		return decimal.Zero
+	}
+	return fee.Round(2)
+}
+
+// walletTransferConfigFromJSON 解析 wallet_config 中的转账配置，非法值按不限制处理
+func walletTransferConfigFromJSON(raw map[string]interface{}) WalletTransferConfig {
+	cfg := WalletTransferConfig{Enabled: true, DisabledMemberLevelIDs: []uint{}}
+	if raw == nil {
+		return cfg
+	}
+	if value, ok := raw[walletConfigFieldTransferEnabled]; ok {
+		cfg.Enabled = parseSettingBool(value)
+	}
+	if items, ok := raw[walletConfigFieldTransferDisabledLevelIDs].([]interface{}); ok {
+		for _, item := range items {
+			id, err := parseSettingInt(item)
+			if err == nil && id > 0 {
+				cfg.DisabledMemberLevelIDs = append(cfg.DisabledMemberLevelIDs, uint(id))
+			}
+		}
+	}
+	cfg.MinAmount = parseWalletTransferDecimal(raw[walletConfigFieldTransferMinAmount])
+	cfg.DailyAmountLimit = parseWalletTransferDecimal(raw[walletConfigFieldTransferDailyAmountLimit])
+	if count, err := parseSettingInt(raw[walletConfigFieldTransferDailyCountLimit]); err == nil && count > 0 {
+		cfg.DailyCountLimit = count
+	}
+	cfg.FeeRate = parseWalletTransferDecimal(raw[walletConfigFieldTransferFeeRate])
+	if cfg.FeeRate.GreaterThan(decimal.NewFromInt(100)) {
+		cfg.FeeRate = decimal.NewFromInt(100)
+	}
+	cfg.FeeFixed = parseWalletTransferDecimal(raw[walletConfigFieldTransferFeeFixed])
+	return cfg
+}
+
+func parseWalletTransferDecimal(raw interface{}) decimal.Decimal {
+	if raw == nil {
+		return decimal.Zero
+	}
+	value, err := parseWalletExchangeRate(raw)
+	if err != nil || value.IsNegative() {
+		return decimal.Zero
+	}
+	return value.Round(2)
+}
+
+// WalletTransferService 用户间钱包转账服务
+type WalletTransferService struct {
+	walletRepo     repository.WalletRepository
+	userRepo       repository.UserRepository
+	walletService  *WalletService
+	settingService *SettingService
+	totpService    *UserTOTPService
+}
+
+// WalletTransferInput 钱包转账输入
+type WalletTransferInput struct {
+	FromUserID   uint
+	Recipient    string // 收款人邮箱或用户ID
+	Amount       models.Money
+	Currency     string
+	Remark       string
+	TOTPCode     string
+	RecoveryCode string
+	Source       string
+}
+
+// WalletTransferResult 钱包转账结果
+type WalletTransferResult struct {
+	Transfer  *models.WalletTransfer
+	Account   *models.WalletAccount // 转出方转账后的账户
+	Recipient *models.User
+}
+
+// NewWalletTransferService 创建钱包转账服务
+func NewWalletTransferService(walletRepo repository.WalletRepository, userRepo repository.UserRepository, walletService *WalletService, settingService *SettingService, totpService *UserTOTPService) *WalletTransferService {
+	return &WalletTransferService{
+		walletRepo:     walletRepo,
+		userRepo:       userRepo,
+		walletService:  walletService,
+		settingService: settingService,
+		totpService:    totpService,
+	}
+}
+
+// ResolveRecipient 按邮箱或用户ID查找收款人（仅返回状态正常的其他用户）
+func (s *WalletTransferService) ResolveRecipient(fromUserID uint, keyword string) (*models.User, error) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return nil, ErrWalletTransferRecipientNotFound
+	}
+	var user *models.User
+	var err error
+	if id, parseErr := strconv.ParseUint(keyword, 10, 64); parseErr == nil {
+		user, err = s.userRepo.GetByID(uint(id))
+	} else {
+		email, emailErr := normalizeEmail(keyword)
+		if emailErr != nil {
+			return nil, ErrWalletTransferRecipientNotFound
+		}
+		user, err = s.userRepo.GetByEmail(email)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.Status != constants.UserStatusActive {
+		return nil, ErrWalletTransferRecipientNotFound
+	}
+	if user.ID == fromUserID {
+		return nil, ErrWalletTransferSelf
+	}
+	return user, nil
+}
+
+// Transfer 执行用户间转账：校验开关、会员等级、2FA 与每日限额后，在同一事务内写入转出/手续费/转入流水
+func (s *WalletTransferService) Transfer(input WalletTransferInput) (*WalletTransferResult, error) {
+	if input.FromUserID == 0 {
+		return nil, ErrWalletAccountNotFound
+	}
+	amount := input.Amount.Decimal.Round(2)
+	if amount.LessThanOrEqual(decimal.Zero) {
+		return nil, ErrWalletTransferAmountInvalid
+	}
+	cfg := s.settingService.GetWalletTransferConfig()
+	if !cfg.Enabled {
+		return nil, ErrWalletTransferDisabled
+	}
+	if cfg.MinAmount.IsPositive() && amount.LessThan(cfg.MinAmount) {
+		return nil, ErrWalletTransferAmountInvalid
+	}
+
+	sender, err := s.userRepo.GetByID(input.FromUserID)
+	if err != nil {
+		return nil, err
+	}
+	if sender == nil || sender.Status != constants.UserStatusActive {
+		return nil, ErrWalletAccountNotFound
+	}
+	if !cfg.AllowsMemberLevel(sender.MemberLevelID) {
+		return nil, ErrWalletTransferDisabled
+	}
+	recipient, err := s.ResolveRecipient(sender.ID, input.Recipient)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyTOTP(sender, input.TOTPCode, input.RecoveryCode); err != nil {
+		return nil, err
+	}
+
+	currency := s.walletService.resolveCurrency(input.Currency)
+	if !settingCurrencyCodePattern.MatchString(currency) {
+		return nil, ErrWalletCurrencyInvalid
+	}
+	fee := cfg.Fee(amount)
+	source := strings.TrimSpace(input.Source)
+	if source == "" {
+		source = constants.WalletTransferSourceWeb
+	}
+	remark := strings.TrimSpace(input.Remark)
+	if len([]rune(remark)) > 200 {
+		remark = string([]rune(remark)[:200])
+	}
+
+	result := &WalletTransferResult{Recipient: recipient}
+	err = s.walletRepo.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		repo := s.walletRepo.WithTx(tx)
+
+		// 按用户ID升序加锁，避免双向转账死锁
+		lockOrder := []uint{sender.ID, recipient.ID}
+		if recipient.ID < sender.ID {
+			lockOrder = []uint{recipient.ID, sender.ID}
+		}
+		accounts := make(map[uint]*models.WalletAccount, 2)
+		for _, userID := range lockOrder {
+			account, err := s.walletService.ensureAccountForUpdate(repo, userID, currency, now)
+			if err != nil {
+				return err
+			}
+			accounts[userID] = account
+		}
+
+		if cfg.DailyCountLimit > 0 || cfg.DailyAmountLimit.IsPositive() {
+			year, month, day := now.Date()
+			count, sum, err := repo.SumTransfersSince(sender.ID, currency, time.Date(year, month, day, 0, 0, 0, 0, now.Location()))
+			if err != nil {
+				return err
+			}
+			if cfg.DailyCountLimit > 0 && count >= int64(cfg.DailyCountLimit) {
+				return ErrWalletTransferDailyLimitExceeded
+			}
+			if cfg.DailyAmountLimit.IsPositive() && decimal.NewFromFloat(sum).Add(amount).GreaterThan(cfg.DailyAmountLimit) {
+				return ErrWalletTransferDailyLimitExceeded
+			}
+		}
+
+		from := accounts[sender.ID]
+		to := accounts[recipient.ID]
+		if from.Balance.Decimal.Round(2).LessThan(amount.Add(fee)) {
+			return ErrWalletInsufficientBalance
+		}
+		if walletWithdrawableBalance(from).LessThan(amount.Add(fee)) {
+			return ErrWalletBonusLocked
+		}
+
+		transfer := &models.WalletTransfer{
+			TransferNo: generateSerialNo("WT"),
+			FromUserID: sender.ID,
+			ToUserID:   recipient.ID,
+			Amount:     models.NewMoneyFromDecimal(amount),
+			FeeAmount:  models.NewMoneyFromDecimal(fee),
+			Currency:   currency,
+			Source:     source,
+			Remark:     remark,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		if err := repo.CreateTransfer(transfer); err != nil {
+			return err
+		}
+
+		entries := []struct {
+			account   *models.WalletAccount
+			txnType   string
+			direction string
+			amount    decimal.Decimal
+			suffix    string
+			remark    string
+		}{
+			{from, constants.WalletTxnTypeTransferOut, constants.WalletTxnDirectionOut, amount, "out", cleanWalletRemark(remark, fmt.Sprintf("转账给用户 #%d", recipient.ID))},
+			{from, constants.WalletTxnTypeTransferFee, constants.WalletTxnDirectionOut, fee, "fee", "转账手续费"},
+			{to, constants.WalletTxnTypeTransferIn, constants.WalletTxnDirectionIn, amount, "in", cleanWalletRemark(remark, fmt.Sprintf("来自用户 #%d 的转账", sender.ID))},
+		}
+		for _, entry := range entries {
+			if entry.amount.LessThanOrEqual(decimal.Zero) {
+				continue
+			}
+			before := entry.account.Balance.Decimal.Round(2)
+			after := before.Add(entry.amount)
+			if entry.direction == constants.WalletTxnDirectionOut {
+				after = before.Sub(entry.amount)
+			}
+			after = after.Round(2)
+			entry.account.Balance = models.NewMoneyFromDecimal(after)
+			entry.account.UpdatedAt = now
+			if err := repo.UpdateAccount(entry.account); err != nil {
+				return ErrWalletAccountUpdateFailed
+			}
+			txn := &models.WalletTransaction{
+				UserID:        entry.account.UserID,
+				Type:          entry.txnType,
+				Direction:     entry.direction,
+				Amount:        models.NewMoneyFromDecimal(entry.amount),
+				BalanceBefore: models.NewMoneyFromDecimal(before),
+				BalanceAfter:  models.NewMoneyFromDecimal(after),
+				Currency:      currency,
+				Reference:     buildWalletTransferReference(transfer.TransferNo, entry.suffix),
+				Remark:        entry.remark,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			}
+			if err := repo.CreateTransaction(txn); err != nil {
+				return ErrWalletTransactionCreateFailed
+			}
+		}
+		result.Transfer = transfer
+		result.Account = from
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListTransfers 查询钱包转账记录
+func (s *WalletTransferService) ListTransfers(filter repository.WalletTransferListFilter) ([]models.WalletTransfer, int64, error) {
+	return s.walletRepo.ListTransfers(filter)
+}
+
+// verifyTOTP 转出方开启 2FA 时要求提供动态码或恢复码（连续失败达到上限后暂时锁定）
+func (s *WalletTransferService) verifyTOTP(sender *models.User, code, recoveryCode string) error {
+	if sender.TOTPEnabledAt == nil {
+		return nil
+	}
+	code = strings.TrimSpace(code)
+	recoveryCode = strings.TrimSpace(recoveryCode)
+	if s.totpService == nil || (code == "" && recoveryCode == "") {
+		return ErrWalletTransferTOTPRequired
+	}
+	if recoveryCode != "" {
+		return s.totpService.VerifySensitiveOperation(sender.ID, recoveryCode, true)
+	}
+	return s.totpService.VerifySensitiveOperation(sender.ID, code, false)
+}
+
+// buildWalletTransferReference 构造转账流水参考号，同一笔转账的各条流水共享 transfer:{单号} 前缀
+func buildWalletTransferReference(transferNo, suffix string) string {
+	return fmt.Sprintf("transfer:%s:%s", transferNo, suffix)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/config"
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func setupWalletTransferServiceTest(t *testing.T) (*WalletTransferService, *WalletService, *gorm.DB) {
+	t.Helper()
+	walletSvc, db := setupWalletServiceTest(t)
+	userRepo := repository.NewUserRepository(db)
+	cfg := &config.Config{App: config.AppConfig{SecretKey: "test-secret-key-for-wallet-transfer"}}
+	totpSvc := NewUserTOTPService(cfg, userRepo, nil)
+	svc := NewWalletTransferService(repository.NewWalletRepository(db), userRepo, walletSvc, walletSvc.settingService, totpSvc)
+	return svc, walletSvc, db
+}
+
+func TestWalletTransferWritesPairedTransactions(t *testing.T) {
+	svc, walletSvc, db := setupWalletTransferServiceTest(t)
+	createTestUser(t, db, 301)
+	createTestUser(t, db, 302)
+	if _, err := walletSvc.settingService.Update(constants.SettingKeyWalletConfig, map[string]interface{}{
+		"transfer_fee_rate":  1,
+		"transfer_fee_fixed": "0.5",
+	}); err != nil {
+		t.Fatalf("update wallet config failed: %v", err)
+	}
+	if _, _, err := walletSvc.Recharge(WalletRechargeInput{UserID: 301, Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(200))}); err != nil {
+		t.Fatalf("recharge failed: %v", err)
+	}
+
+	result, err := svc.Transfer(WalletTransferInput{
+		FromUserID: 301,
+		Recipient:  "wallet_user_302@example.com",
+		Amount:     models.NewMoneyFromDecimal(decimal.NewFromInt(100)),
+		Remark:     "员工备用金",
+	})
+	if err != nil {
+		t.Fatalf("transfer failed: %v", err)
+	}
+	if result.Recipient.ID != 302 || !result.Transfer.FeeAmount.Decimal.Equal(decimal.RequireFromString("1.5")) {
+		t.Fatalf("unexpected transfer: %+v", result.Transfer)
+	}
+	if !result.Account.Balance.Decimal.Equal(decimal.RequireFromString("98.5")) {
+		t.Fatalf("unexpected sender balance: %s", result.Account.Balance.String())
+	}
+	recipient, _ := walletSvc.GetAccount(302)
+	if !recipient.Balance.Decimal.Equal(decimal.NewFromInt(100)) {
+		t.Fatalf("unexpected recipient balance: %s", recipient.Balance.String())
+	}
+
+	var txns []models.WalletTransaction
+	if err := db.Where("reference LIKE ?", "transfer:"+result.Transfer.TransferNo+":%").Order("id asc").Find(&txns).Error; err != nil {
+		t.Fatalf("load transfer txns failed: %v", err)
+	}
+	if len(txns) != 3 || txns[0].Type != constants.WalletTxnTypeTransferOut || txns[1].Type != constants.WalletTxnTypeTransferFee || txns[2].Type != constants.WalletTxnTypeTransferIn {
+		t.Fatalf("expected out/fee/in transactions, got %+v", txns)
+	}
+	if txns[2].UserID != 302 || txns[2].Remark != "员工备用金" {
+		t.Fatalf("unexpected recipient transaction: %+v", txns[2])
+	}
+}
+
+func TestWalletTransferRejections(t *testing.T) {
+	svc, walletSvc, db := setupWalletTransferServiceTest(t)
+	createTestUser(t, db, 311)
+	createTestUser(t, db, 312)
+	if _, _, err := walletSvc.Recharge(WalletRechargeInput{UserID: 311, Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(100))}); err != nil {
+		t.Fatalf("recharge failed: %v", err)
+	}
+	transfer := func(amount int64) error {
+		_, err := svc.Transfer(WalletTransferInput{FromUserID: 311, Recipient: "312", Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(amount))})
+		return err
+	}
+
+	if _, err := svc.Transfer(WalletTransferInput{FromUserID: 311, Recipient: "311", Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(1))}); !errors.Is(err, ErrWalletTransferSelf) {
+		t.Fatalf("expected self transfer error, got %v", err)
+	}
+	if _, err := svc.Transfer(WalletTransferInput{FromUserID: 311, Recipient: "nobody@example.com", Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(1))}); !errors.Is(err, ErrWalletTransferRecipientNotFound) {
+		t.Fatalf("expected recipient not found, got %v", err)
+	}
+	if err := transfer(500); !errors.Is(err, ErrWalletInsufficientBalance) {
+		t.Fatalf("expected insufficient balance, got %v", err)
+	}
+
+	if _, err := walletSvc.settingService.Update(constants.SettingKeyWalletConfig, map[string]interface{}{
+		"transfer_daily_count_limit":  2,
+		"transfer_daily_amount_limit": 50,
+	}); err != nil {
+		t.Fatalf("update wallet config failed: %v", err)
+	}
+	if err := transfer(30); err != nil {
+		t.Fatalf("first transfer failed: %v", err)
+	}
+	if err := transfer(30); !errors.Is(err, ErrWalletTransferDailyLimitExceeded) {
+		t.Fatalf("expected daily amount limit, got %v", err)
+	}
+	if err := transfer(10); err != nil {
+		t.Fatalf("second transfer failed: %v", err)
+	}
+	if err := transfer(1); !errors.Is(err, ErrWalletTransferDailyLimitExceeded) {
+		t.Fatalf("expected daily count limit, got %v", err)
+	}
+
+	if err := db.Model(&models.User{}).Where("id = ?", 311).Update("member_level_id", 7).Error; err != nil {
+		t.Fatalf("update member level failed: %v", err)
+	}
+	if _, err := walletSvc.settingService.Update(constants.SettingKeyWalletConfig, map[string]interface{}{
+		"transfer_disabled_member_level_ids": []interface{}{7},
+	}); err != nil {
+		t.Fatalf("update wallet config failed: %v", err)
+	}
+	if err := transfer(1); !errors.Is(err, ErrWalletTransferDisabled) {
+		t.Fatalf("expected member level disabled, got %v", err)
+	}
+	if _, err := walletSvc.settingService.Update(constants.SettingKeyWalletConfig, map[string]interface{}{
+		"transfer_enabled": false,
+	}); err != nil {
+		t.Fatalf("update wallet config failed: %v", err)
+	}
+	if err := transfer(1); !errors.Is(err, ErrWalletTransferDisabled) {
+		t.Fatalf("expected transfers disabled, got %v", err)
+	}
+}
+
+func TestWalletTransferRequiresTOTPWhenEnabled(t *testing.T) {
+	svc, walletSvc, db := setupWalletTransferServiceTest(t)
+	createTestUser(t, db, 321)
+	createTestUser(t, db, 322)
+	if _, _, err := walletSvc.Recharge(WalletRechargeInput{UserID: 321, Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(10))}); err != nil {
+		t.Fatalf("recharge failed: %v", err)
+	}
+	setupRes, err := svc.totpService.Setup(321)
+	if err != nil {
+		t.Fatalf("totp setup failed: %v", err)
+	}
+	code, _ := totp.GenerateCode(setupRes.Secret, time.Now())
+	if _, err := svc.totpService.Enable(321, code); err != nil {
+		t.Fatalf("totp enable failed: %v", err)
+	}
+
+	input := WalletTransferInput{FromUserID: 321, Recipient: "322", Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(5))}
+	if _, err := svc.Transfer(input); !errors.Is(err, ErrWalletTransferTOTPRequired) {
+		t.Fatalf("expected totp required, got %v", err)
+	}
+	input.TOTPCode = "000000"
+	if _, err := svc.Transfer(input); !errors.Is(err, ErrTOTPCodeInvalid) {
+		t.Fatalf("expected invalid totp, got %v", err)
+	}
+	input.TOTPCode, _ = totp.GenerateCode(setupRes.Secret, time.Now())
+	if _, err := svc.Transfer(input); err != nil {
+		t.Fatalf("transfer with totp failed: %v", err)
+	}
+}
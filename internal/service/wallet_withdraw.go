@@ -0,0 +1,309 @@
+package service
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	walletConfigFieldWithdrawEnabled   = "withdraw_enabled"
+	walletConfigFieldWithdrawMinAmount = "withdraw_min_amount"
+	walletConfigFieldWithdrawChannels  = "withdraw_channels"
+
+	walletWithdrawExportMaxRows = 5000
+)
+
+// WalletWithdrawConfig 钱包提现配置（存储于 wallet_config）
+type WalletWithdrawConfig struct {
+	Enabled   bool            `json:"withdraw_enabled"`    // 是否开放余额提现（默认关闭）
+	MinAmount decimal.Decimal `json:"withdraw_min_amount"` // 单笔最低提现金额（0=不限制）
+	Channels  []string        `json:"withdraw_channels"`   // 允许的提现渠道，为空表示不限制
+}
+
+// walletWithdrawConfigFromJSON 解析 wallet_config 中的提现配置
+func walletWithdrawConfigFromJSON(raw map[string]interface{}) WalletWithdrawConfig {
+	cfg := WalletWithdrawConfig{Channels: []string{}}
+	if raw == nil {
+		return cfg
+	}
+	cfg.Enabled = parseSettingBool(raw[walletConfigFieldWithdrawEnabled])
+	cfg.MinAmount = parseWalletTransferDecimal(raw[walletConfigFieldWithdrawMinAmount])
+	if items, ok := raw[walletConfigFieldWithdrawChannels].([]interface{}); ok {
+		channels := make([]string, 0, len(items))
+		for _, item := range items {
+			text, _ := item.(string)
+			channels = append(channels, text)
+		}
+		cfg.Channels = normalizeAffiliateWithdrawChannels(channels)
+	}
+	return cfg
+}
+
+// WalletWithdrawApplyInput 钱包提现申请输入
+type WalletWithdrawApplyInput struct {
+	Amount      decimal.Decimal
+	Currency    string
+	Channel     string
+	Account     string
+	AccountName string
+}
+
+// ApplyWithdraw 用户提交余额提现申请，申请金额立即从钱包冻结扣除
+func (s *WalletService) ApplyWithdraw(userID uint, input WalletWithdrawApplyInput) (*models.WalletWithdrawRequest, error) {
+	if userID == 0 {
+		return nil, ErrWalletAccountNotFound
+	}
+	cfg := s.settingService.GetWalletWithdrawConfig()
+	if !cfg.Enabled {
+		return nil, ErrWalletWithdrawDisabled
+	}
+	amount := input.Amount.Round(2)
+	if amount.LessThanOrEqual(decimal.Zero) {
+		return nil, ErrWalletWithdrawAmountInvalid
+	}
+	if cfg.MinAmount.IsPositive() && amount.LessThan(cfg.MinAmount) {
+		return nil, ErrWalletWithdrawAmountInvalid
+	}
+	channel := normalizeSettingTextWithRuneLimit(input.Channel, affiliateWithdrawChannelMaxRune)
+	account := normalizeSettingTextWithRuneLimit(input.Account, 255)
+	if channel == "" || account == "" {
+		return nil, ErrWalletWithdrawChannelInvalid
+	}
+	if len(cfg.Channels) > 0 && !containsWithdrawChannel(cfg.Channels, channel) {
+		return nil, ErrWalletWithdrawChannelInvalid
+	}
+	currency := s.resolveCurrency(input.Currency)
+	if !settingCurrencyCodePattern.MatchString(currency) {
+		return nil, ErrWalletCurrencyInvalid
+	}
+
+	var createdID uint
+	err := s.walletRepo.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		repo := s.walletRepo.WithTx(tx)
+		walletAccount, err := s.ensureAccountForUpdate(repo, userID, currency, now)
+		if err != nil {
+			return err
+		}
+		before := walletAccount.Balance.Decimal.Round(2)
+		if before.LessThan(amount) {
+			return ErrWalletInsufficientBalance
+		}
+		if walletWithdrawableBalance(walletAccount).LessThan(amount) {
+			return ErrWalletBonusLocked
+		}
+
+		req := &models.WalletWithdrawRequest{
+			WithdrawNo:  generateSerialNo("WW"),
+			UserID:      userID,
+			Amount:      models.NewMoneyFromDecimal(amount),
+			Currency:    currency,
+			Channel:     channel,
+			Account:     account,
+			AccountName: normalizeSettingTextWithRuneLimit(input.AccountName, 100),
+			Status:      constants.WalletWithdrawStatusPendingReview,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if err := repo.CreateWithdraw(req); err != nil {
+			return err
+		}
+
+		after := before.Sub(amount).Round(2)
+		walletAccount.Balance = models.NewMoneyFromDecimal(after)
+		walletAccount.UpdatedAt = now
+		if err := repo.UpdateAccount(walletAccount); err != nil {
+			return ErrWalletAccountUpdateFailed
+		}
+		txn := &models.WalletTransaction{
+			UserID:        userID,
+			Type:          constants.WalletTxnTypeWithdrawHold,
+			Direction:     constants.WalletTxnDirectionOut,
+			Amount:        models.NewMoneyFromDecimal(amount),
+			BalanceBefore: models.NewMoneyFromDecimal(before),
+			BalanceAfter:  models.NewMoneyFromDecimal(after),
+			Currency:      currency,
+			Reference:     buildWalletWithdrawReference(req.WithdrawNo, "hold"),
+			Remark:        fmt.Sprintf("提现申请冻结（%s）", req.WithdrawNo),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if err := repo.CreateTransaction(txn); err != nil {
+			return ErrWalletTransactionCreateFailed
+		}
+		createdID = req.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.walletRepo.GetWithdrawByID(createdID)
+}
+
+// ListUserWithdraws 查询用户钱包提现记录
+func (s *WalletService) ListUserWithdraws(userID uint, page, pageSize int, status string) ([]models.WalletWithdrawRequest, int64, error) {
+	if userID == 0 {
+		return []models.WalletWithdrawRequest{}, 0, nil
+	}
+	return s.walletRepo.ListWithdraws(repository.WalletWithdrawListFilter{
+		Page:     page,
+		PageSize: pageSize,
+		UserID:   userID,
+		Status:   strings.TrimSpace(status),
+	})
+}
+
+// ListAdminWithdraws 后台查询钱包提现申请
+func (s *WalletService) ListAdminWithdraws(filter repository.WalletWithdrawListFilter) ([]models.WalletWithdrawRequest, int64, error) {
+	return s.walletRepo.ListWithdraws(filter)
+}
+
+// ReviewWithdraw 管理端审核提现申请：拒绝时将冻结金额退回钱包，打款时记录线下打款流水号
+func (s *WalletService) ReviewWithdraw(adminID, withdrawID uint, action, rejectReason, payoutReference string) (*models.WalletWithdrawRequest, error) {
+	if withdrawID == 0 {
+		return nil, ErrWalletWithdrawNotFound
+	}
+	act := strings.ToLower(strings.TrimSpace(action))
+	if act != constants.WalletWithdrawActionReject && act != constants.WalletWithdrawActionPay {
+		return nil, ErrWalletWithdrawStatusInvalid
+	}
+
+	err := s.walletRepo.Transaction(func(tx *gorm.DB) error {
+		repo := s.walletRepo.WithTx(tx)
+		req, err := repo.GetWithdrawByIDForUpdate(withdrawID)
+		if err != nil {
+			return err
+		}
+		if req == nil {
+			return ErrWalletWithdrawNotFound
+		}
+		if req.Status != constants.WalletWithdrawStatusPendingReview {
+			return ErrWalletWithdrawStatusInvalid
+		}
+
+		now := time.Now()
+		req.ProcessedBy = &adminID
+		req.ProcessedAt = &now
+		req.UpdatedAt = now
+		if act == constants.WalletWithdrawActionPay {
+			req.Status = constants.WalletWithdrawStatusPaid
+			req.RejectReason = ""
+			req.PayoutReference = normalizeSettingTextWithRuneLimit(payoutReference, 120)
+			return repo.UpdateWithdraw(req)
+		}
+
+		req.Status = constants.WalletWithdrawStatusRejected
+		req.RejectReason = normalizeSettingTextWithRuneLimit(rejectReason, 255)
+		if err := repo.UpdateWithdraw(req); err != nil {
+			return err
+		}
+		walletAccount, err := s.ensureAccountForUpdate(repo, req.UserID, req.Currency, now)
+		if err != nil {
+			return err
+		}
+		amount := req.Amount.Decimal.Round(2)
+		before := walletAccount.Balance.Decimal.Round(2)
+		after := before.Add(amount).Round(2)
+		walletAccount.Balance = models.NewMoneyFromDecimal(after)
+		walletAccount.UpdatedAt = now
+		if err := repo.UpdateAccount(walletAccount); err != nil {
+			return ErrWalletAccountUpdateFailed
+		}
+		txn := &models.WalletTransaction{
+			UserID:        req.UserID,
+			Type:          constants.WalletTxnTypeWithdrawRelease,
+			Direction:     constants.WalletTxnDirectionIn,
+			Amount:        models.NewMoneyFromDecimal(amount),
+			BalanceBefore: models.NewMoneyFromDecimal(before),
+			BalanceAfter:  models.NewMoneyFromDecimal(after),
+			Currency:      req.Currency,
+			Reference:     buildWalletWithdrawReference(req.WithdrawNo, "release"),
+			Remark:        cleanWalletRemark(req.RejectReason, fmt.Sprintf("提现申请被拒绝（%s）", req.WithdrawNo)),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if err := repo.CreateTransaction(txn); err != nil {
+			return ErrWalletTransactionCreateFailed
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.walletRepo.GetWithdrawByID(withdrawID)
+}
+
+// ExportWithdraws 导出提现申请 CSV 用于批量打款（未指定 ID 与状态时导出全部待审核申请）
+func (s *WalletService) ExportWithdraws(filter repository.WalletWithdrawListFilter) ([]byte, string, error) {
+	if len(filter.IDs) == 0 && strings.TrimSpace(filter.Status) == "" {
+		filter.Status = constants.WalletWithdrawStatusPendingReview
+	}
+	filter.Page = 1
+	filter.PageSize = walletWithdrawExportMaxRows
+	rows, _, err := s.walletRepo.ListWithdraws(filter)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(rows) == 0 {
+		return nil, "", ErrWalletWithdrawNotFound
+	}
+
+	builder := &strings.Builder{}
+	writer := csv.NewWriter(builder)
+	if err := writer.Write([]string{
+		"withdraw_no",
+		"user_id",
+		"email",
+		"amount",
+		"currency",
+		"channel",
+		"account",
+		"account_name",
+		"status",
+		"payout_reference",
+		"created_at",
+	}); err != nil {
+		return nil, "", err
+	}
+	for _, row := range rows {
+		email := ""
+		if row.User != nil {
+			email = row.User.Email
+		}
+		if err := writer.Write([]string{
+			row.WithdrawNo,
+			strconv.FormatUint(uint64(row.UserID), 10),
+			sanitizeCSVCell(email),
+			row.Amount.StringFixed(2),
+			row.Currency,
+			row.Channel,
+			sanitizeCSVCell(row.Account),
+			sanitizeCSVCell(row.AccountName),
+			row.Status,
+			sanitizeCSVCell(row.PayoutReference),
+			row.CreatedAt.Format(time.RFC3339),
+		}); err != nil {
+			return nil, "", err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, "", err
+	}
+	return []byte(builder.String()), "text/csv; charset=utf-8", nil
+}
+
+// buildWalletWithdrawReference 构造提现流水参考号
+func buildWalletWithdrawReference(withdrawNo, suffix string) string {
+	return fmt.Sprintf("withdraw:%s:%s", withdrawNo, suffix)
+}
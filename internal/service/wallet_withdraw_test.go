@@ -0,0 +1,150 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/repository"
+
+	"github.com/shopspring/decimal"
+)
+
+func enableWalletWithdrawForTest(t *testing.T, svc *WalletService) {
+	t.Helper()
+	if _, err := svc.settingService.Update(constants.SettingKeyWalletConfig, map[string]interface{}{
+		"withdraw_enabled":    true,
+		"withdraw_min_amount": "10",
+		"withdraw_channels":   []interface{}{"alipay", "usdt"},
+	}); err != nil {
+		t.Fatalf("update wallet config failed: %v", err)
+	}
+}
+
+func TestWalletWithdrawHoldAndReject(t *testing.T) {
+	svc, db := setupWalletServiceTest(t)
+	createTestUser(t, db, 401)
+	enableWalletWithdrawForTest(t, svc)
+	if _, _, err := svc.Recharge(WalletRechargeInput{UserID: 401, Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(100))}); err != nil {
+		t.Fatalf("recharge failed: %v", err)
+	}
+
+	row, err := svc.ApplyWithdraw(401, WalletWithdrawApplyInput{
+		Amount:  decimal.NewFromInt(60),
+		Channel: "alipay",
+		Account: "user401@alipay",
+	})
+	if err != nil {
+		t.Fatalf("apply withdraw failed: %v", err)
+	}
+	if row.Status != constants.WalletWithdrawStatusPendingReview {
+		t.Fatalf("unexpected status: %s", row.Status)
+	}
+	account, _ := svc.GetAccount(401)
+	if !account.Balance.Decimal.Equal(decimal.NewFromInt(40)) {
+		t.Fatalf("expected held balance 40, got %s", account.Balance.String())
+	}
+
+	rejected, err := svc.ReviewWithdraw(1, row.ID, constants.WalletWithdrawActionReject, "账号信息有误", "")
+	if err != nil {
+		t.Fatalf("reject withdraw failed: %v", err)
+	}
+	if rejected.Status != constants.WalletWithdrawStatusRejected || rejected.ProcessedBy == nil || *rejected.ProcessedBy != 1 {
+		t.Fatalf("unexpected rejected row: %+v", rejected)
+	}
+	account, _ = svc.GetAccount(401)
+	if !account.Balance.Decimal.Equal(decimal.NewFromInt(100)) {
+		t.Fatalf("expected released balance 100, got %s", account.Balance.String())
+	}
+
+	var txns []models.WalletTransaction
+	if err := db.Where("reference LIKE ?", "withdraw:"+row.WithdrawNo+":%").Order("id asc").Find(&txns).Error; err != nil {
+		t.Fatalf("load withdraw txns failed: %v", err)
+	}
+	if len(txns) != 2 || txns[0].Type != constants.WalletTxnTypeWithdrawHold || txns[1].Type != constants.WalletTxnTypeWithdrawRelease {
+		t.Fatalf("expected hold/release transactions, got %+v", txns)
+	}
+
+	if _, err := svc.ReviewWithdraw(1, row.ID, constants.WalletWithdrawActionPay, "", "TX-1"); !errors.Is(err, ErrWalletWithdrawStatusInvalid) {
+		t.Fatalf("expected status invalid after reject, got %v", err)
+	}
+}
+
+func TestWalletWithdrawPayAndExport(t *testing.T) {
+	svc, db := setupWalletServiceTest(t)
+	createTestUser(t, db, 411)
+	enableWalletWithdrawForTest(t, svc)
+	if _, _, err := svc.Recharge(WalletRechargeInput{UserID: 411, Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(100))}); err != nil {
+		t.Fatalf("recharge failed: %v", err)
+	}
+	first, err := svc.ApplyWithdraw(411, WalletWithdrawApplyInput{Amount: decimal.NewFromInt(30), Channel: "usdt", Account: "TAddr411"})
+	if err != nil {
+		t.Fatalf("apply first withdraw failed: %v", err)
+	}
+	if _, err := svc.ApplyWithdraw(411, WalletWithdrawApplyInput{Amount: decimal.NewFromInt(20), Channel: "alipay", Account: "=HYPERLINK(\"http://evil\")", AccountName: "@SUM(A1)"}); err != nil {
+		t.Fatalf("apply second withdraw failed: %v", err)
+	}
+
+	content, contentType, err := svc.ExportWithdraws(repository.WalletWithdrawListFilter{})
+	if err != nil {
+		t.Fatalf("export withdraws failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if !strings.HasPrefix(contentType, "text/csv") || len(lines) != 3 || !strings.Contains(string(content), "wallet_user_411@example.com") {
+		t.Fatalf("unexpected export: %s", content)
+	}
+	// 公式字符开头的单元格需加单引号前缀
+	if !strings.Contains(string(content), `"'=HYPERLINK(""http://evil"")",'@SUM(A1),`) {
+		t.Fatalf("expected formula cells escaped: %s", content)
+	}
+
+	paid, err := svc.ReviewWithdraw(2, first.ID, constants.WalletWithdrawActionPay, "", "CHAIN-TX-001")
+	if err != nil {
+		t.Fatalf("pay withdraw failed: %v", err)
+	}
+	if paid.Status != constants.WalletWithdrawStatusPaid || paid.PayoutReference != "CHAIN-TX-001" {
+		t.Fatalf("unexpected paid row: %+v", paid)
+	}
+	account, _ := svc.GetAccount(411)
+	if !account.Balance.Decimal.Equal(decimal.NewFromInt(50)) {
+		t.Fatalf("paying must not change balance, got %s", account.Balance.String())
+	}
+
+	content, _, err = svc.ExportWithdraws(repository.WalletWithdrawListFilter{})
+	if err != nil {
+		t.Fatalf("export pending withdraws failed: %v", err)
+	}
+	if lines = strings.Split(strings.TrimSpace(string(content)), "\n"); len(lines) != 2 {
+		t.Fatalf("expected only pending withdraw in export, got %d lines", len(lines))
+	}
+}
+
+func TestWalletWithdrawRejections(t *testing.T) {
+	svc, db := setupWalletServiceTest(t)
+	createTestUser(t, db, 421)
+	if _, _, err := svc.Recharge(WalletRechargeInput{UserID: 421, Amount: models.NewMoneyFromDecimal(decimal.NewFromInt(50))}); err != nil {
+		t.Fatalf("recharge failed: %v", err)
+	}
+	input := WalletWithdrawApplyInput{Amount: decimal.NewFromInt(20), Channel: "alipay", Account: "user421"}
+	if _, err := svc.ApplyWithdraw(421, input); !errors.Is(err, ErrWalletWithdrawDisabled) {
+		t.Fatalf("expected disabled by default, got %v", err)
+	}
+
+	enableWalletWithdrawForTest(t, svc)
+	cases := []struct {
+		input WalletWithdrawApplyInput
+		want  error
+	}{
+		{WalletWithdrawApplyInput{Amount: decimal.NewFromInt(5), Channel: "alipay", Account: "user421"}, ErrWalletWithdrawAmountInvalid},
+		{WalletWithdrawApplyInput{Amount: decimal.NewFromInt(20), Channel: "bank", Account: "6222"}, ErrWalletWithdrawChannelInvalid},
+		{WalletWithdrawApplyInput{Amount: decimal.NewFromInt(20), Channel: "alipay"}, ErrWalletWithdrawChannelInvalid},
+		{WalletWithdrawApplyInput{Amount: decimal.NewFromInt(80), Channel: "alipay", Account: "user421"}, ErrWalletInsufficientBalance},
+	}
+	for _, tc := range cases {
+		if _, err := svc.ApplyWithdraw(421, tc.input); !errors.Is(err, tc.want) {
+			t.Fatalf("input %+v: expected %v, got %v", tc.input, tc.want, err)
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 type PingResult struct {
 	SiteName        string                 `json:"site_name"`
 	ProtocolVersion string                 `json:"protocol_version"`
+	Features        []string               `json:"features,omitempty"` // 上游支持的协议能力（1.1 起返回）
 	UserID          uint                   `json:"user_id"`
 	Balance         string                 `json:"balance"`
 	Currency        string                 `json:"currency"`
@@ -35,6 +36,7 @@ type ProductListResult struct {
 // UpstreamProduct 上游商品信息
 type UpstreamProduct struct {
 	ID               uint          `json:"id"`
+	Slug             string        `json:"slug,omitempty"`
 	SeoMeta          models.JSON   `json:"seo_meta"`
 	Title            models.JSON   `json:"title"`
 	Description      models.JSON   `json:"description"`
@@ -44,12 +46,13 @@ type UpstreamProduct struct {
 	PriceAmount      string        `json:"price_amount"`
 	OriginalPrice    string        `json:"original_price,omitempty"`
 	MemberPrice      string        `json:"member_price,omitempty"`
-	Currency         string        `json:"currency"`
+	Currency         string        `json:"currency,omitempty"`
 	FulfillmentType  string        `json:"fulfillment_type"`
 	ManualFormSchema models.JSON   `json:"manual_form_schema"`
 	IsActive         bool          `json:"is_active"`
 	CategoryID       uint          `json:"category_id"`
 	SKUs             []UpstreamSKU `json:"skus"`
+	CreatedAt        *time.Time    `json:"created_at,omitempty"`
 	UpdatedAt        time.Time     `json:"updated_at"`
 }
 
@@ -123,7 +126,19 @@ type UpstreamOrderDetail struct {
 	Currency       string               `json:"currency"`
 	Fulfillment    *UpstreamFulfillment `json:"fulfillment,omitempty"`
 	// RefundRecords 上游退款记录（协议兼容字段）
-	RefundRecords []models.JSON `json:"refund_records,omitempty"`
+	RefundRecords []models.JSON       `json:"refund_records,omitempty"`
+	Items         []UpstreamOrderItem `json:"items,omitempty"`
+}
+
+// UpstreamOrderItem 上游订单项
+type UpstreamOrderItem struct {
+	ProductID       uint        `json:"product_id"`
+	SKUID           uint        `json:"sku_id"`
+	Title           models.JSON `json:"title"`
+	Quantity        int         `json:"quantity"`
+	UnitPrice       string      `json:"unit_price"`
+	TotalPrice      string      `json:"total_price"`
+	FulfillmentType string      `json:"fulfillment_type"`
 }
 
 // Adapter 上游站点适配器接口
@@ -153,11 +168,19 @@ type Adapter interface {
 	DownloadImage(ctx context.Context, imageURL string) (localPath string, err error)
 }
 
+// CatalogWebhookRegistrar 支持商品目录变更推送的适配器（可选能力）
+type CatalogWebhookRegistrar interface {
+	// RegisterCatalogWebhook 向上游注册目录变更推送地址
+	RegisterCatalogWebhook(ctx context.Context, url string) error
+}
+
 // NewAdapter 根据协议类型创建适配器
 func NewAdapter(conn *models.SiteConnection, uploadsDir string) (Adapter, error) {
 	switch conn.Protocol {
 	case constants.ConnectionProtocolDujiaoNext:
 		return NewDujiaoNextAdapter(conn, uploadsDir), nil
+	case constants.ConnectionProtocolGenericREST:
+		return NewGenericRESTAdapter(conn, uploadsDir)
 	default:
 		return nil, fmt.Errorf("unsupported protocol: %s", conn.Protocol)
 	}
@@ -0,0 +1,146 @@
+package conformance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/upstream"
+)
+
+const (
+	standInKey    = "conformance-key"
+	standInSecret = "conformance-secret"
+)
+
+func TestStandInConformance(t *testing.T) {
+	server := httptest.NewServer(NewStandIn(standInKey, standInSecret))
+	defer server.Close()
+
+	Run(t, Target{
+		BaseURL:   server.URL,
+		ApiKey:    standInKey,
+		ApiSecret: standInSecret,
+		SKUID:     StandInSKUID,
+	})
+}
+
+// TestExternalConformance 对外部上游运行一致性测试，例如：
+// DUJIAO_CONFORMANCE_BASE_URL=https://shop.example.com DUJIAO_CONFORMANCE_API_KEY=... DUJIAO_CONFORMANCE_API_SECRET=... go test ./internal/upstream/conformance -run External
+func TestExternalConformance(t *testing.T) {
+	baseURL := os.Getenv("DUJIAO_CONFORMANCE_BASE_URL")
+	if baseURL == "" {
+		t.Skip("DUJIAO_CONFORMANCE_BASE_URL not set")
+	}
+	target := Target{
+		BaseURL:    baseURL,
+		ApiKey:     os.Getenv("DUJIAO_CONFORMANCE_API_KEY"),
+		ApiSecret:  os.Getenv("DUJIAO_CONFORMANCE_API_SECRET"),
+		WebhookURL: os.Getenv("DUJIAO_CONFORMANCE_WEBHOOK_URL"),
+	}
+	if raw := os.Getenv("DUJIAO_CONFORMANCE_SKU_ID"); raw != "" {
+		skuID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			t.Fatalf("invalid DUJIAO_CONFORMANCE_SKU_ID: %v", err)
+		}
+		target.SKUID = uint(skuID)
+	}
+	Run(t, target)
+}
+
+func TestStandInServesOpenAPIDocument(t *testing.T) {
+	server := httptest.NewServer(NewStandIn(standInKey, standInSecret))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + upstream.PathOpenAPI)
+	if err != nil {
+		t.Fatalf("get openapi: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestDujiaoNextAdapterAgainstStandIn(t *testing.T) {
+	var mu sync.Mutex
+	var idempotencyKeys []string
+	standIn := NewStandIn(standInKey, standInSecret)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == upstream.PathOrders {
+			mu.Lock()
+			idempotencyKeys = append(idempotencyKeys, r.Header.Get(upstream.HeaderIdempotencyKey))
+			mu.Unlock()
+		}
+		standIn.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	conn := &models.SiteConnection{BaseURL: server.URL, ApiKey: standInKey, ApiSecret: standInSecret}
+	ctx := context.Background()
+
+	// 未知协议版本时不发送 Idempotency-Key
+	legacy := upstream.NewDujiaoNextAdapter(conn, t.TempDir())
+	if _, err := legacy.CreateOrder(ctx, upstream.CreateUpstreamOrderReq{SKUID: StandInSKUID, Quantity: 1, DownstreamOrderNo: "DO-legacy"}); err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+
+	adapter := upstream.NewDujiaoNextAdapter(conn, t.TempDir())
+	ping, err := adapter.Ping(ctx)
+	if err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	if ping.ProtocolVersion != upstream.ProtocolVersion {
+		t.Fatalf("expected protocol version %s, got %s", upstream.ProtocolVersion, ping.ProtocolVersion)
+	}
+	products, err := adapter.ListProducts(ctx, upstream.ListProductsOpts{Page: 1, PageSize: 20})
+	if err != nil || len(products.Items) != 1 || products.Items[0].SKUs[0].ID != StandInSKUID {
+		t.Fatalf("unexpected products: %+v err=%v", products, err)
+	}
+	created, err := adapter.CreateOrder(ctx, upstream.CreateUpstreamOrderReq{SKUID: StandInSKUID, Quantity: 2, DownstreamOrderNo: "DO-1"})
+	if err != nil || !created.OK {
+		t.Fatalf("create order: %+v err=%v", created, err)
+	}
+	detail, err := adapter.GetOrder(ctx, created.OrderID)
+	if err != nil {
+		t.Fatalf("get order: %v", err)
+	}
+	if detail.Amount != "20.00" || len(detail.Items) != 1 || detail.Items[0].Quantity != 2 {
+		t.Fatalf("unexpected order detail: %+v", detail)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(idempotencyKeys) != 2 || idempotencyKeys[0] != "" || idempotencyKeys[1] != "DO-1" {
+		t.Fatalf("expected Idempotency-Key only after protocol 1.1 ping, got %q", idempotencyKeys)
+	}
+}
+
+func TestValidatorRejectsMismatchedResponses(t *testing.T) {
+	validator := NewValidator(upstream.OpenAPIDocument())
+	cases := []struct {
+		name string
+		op   string
+		code int
+		body string
+	}{
+		{name: "missing required field", op: "ping", code: 200, body: `{"ok":true,"site_name":"x","user_id":1,"balance":"0","currency":"CNY"}`},
+		{name: "wrong type", op: "listProducts", code: 200, body: `{"ok":true,"items":[],"total":"1","page":1,"page_size":20}`},
+		{name: "unknown error code", op: "getOrder", code: 404, body: `{"ok":false,"error_code":"nope","error_message":"x"}`},
+		{name: "not json", op: "ping", code: 200, body: `ok`},
+	}
+	for _, tc := range cases {
+		if err := validator.ValidateResponse(tc.op, tc.code, []byte(tc.body)); err == nil {
+			t.Fatalf("%s: expected validation error", tc.name)
+		}
+	}
+	ok := `{"ok":true,"site_name":"x","protocol_version":"1.1","user_id":1,"balance":"0","currency":"CNY","extra":1}`
+	if err := validator.ValidateResponse("ping", 200, []byte(ok)); err != nil {
+		t.Fatalf("expected additional fields to be allowed: %v", err)
+	}
+}
@@ -0,0 +1,383 @@
+package conformance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+	"github.com/dujiao-next/internal/upstream"
+	"github.com/shopspring/decimal"
+)
+
+// 替身上游内置的可下单商品
+const (
+	StandInProductID uint = 1
+	StandInSKUID     uint = 11
+	standInPrice          = "10.00"
+	standInCurrency       = "CNY"
+)
+
+// StandIn 内存实现的 Dujiao-Next 上游协议替身，用于本地运行一致性测试与下游对接测试
+type StandIn struct {
+	apiKey    string
+	apiSecret string
+
+	mu           sync.Mutex
+	nextOrderID  uint
+	orders       map[uint]*upstream.UpstreamOrderDetail
+	byDownstream map[string]uint
+	idempotency  map[string]standInReplay
+	webhook      *models.CatalogWebhook
+}
+
+type standInReplay struct {
+	fingerprint string
+	body        []byte
+}
+
+// NewStandIn 创建协议替身
+func NewStandIn(apiKey, apiSecret string) *StandIn {
+	return &StandIn{
+		apiKey:       apiKey,
+		apiSecret:    apiSecret,
+		nextOrderID:  1000,
+		orders:       map[uint]*upstream.UpstreamOrderDetail{},
+		byDownstream: map[string]uint{},
+		idempotency:  map[string]standInReplay{},
+	}
+}
+
+// ServeHTTP 按协议路径分发请求
+func (s *StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && r.URL.Path == upstream.PathOpenAPI {
+		writeJSON(w, http.StatusOK, upstream.OpenAPIDocument())
+		return
+	}
+	body, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	path := r.URL.Path
+	switch {
+	case r.Method == http.MethodPost && path == upstream.PathPing:
+		s.ping(w)
+	case r.Method == http.MethodGet && path == upstream.PathCategories:
+		s.categories(w)
+	case r.Method == http.MethodGet && path == upstream.PathProducts:
+		s.products(w, r)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, upstream.PathProducts+"/"):
+		s.product(w, strings.TrimPrefix(path, upstream.PathProducts+"/"))
+	case r.Method == http.MethodPost && path == upstream.PathOrders:
+		s.createOrder(w, r, body)
+	case r.Method == http.MethodPost && strings.HasPrefix(path, upstream.PathOrders+"/") && strings.HasSuffix(path, "/cancel"):
+		s.cancelOrder(w, strings.TrimSuffix(strings.TrimPrefix(path, upstream.PathOrders+"/"), "/cancel"))
+	case r.Method == http.MethodGet && strings.HasPrefix(path, upstream.PathOrders+"/"):
+		s.getOrder(w, strings.TrimPrefix(path, upstream.PathOrders+"/"))
+	case path == upstream.PathWebhooks:
+		s.webhooks(w, r, body)
+	default:
+		writeError(w, http.StatusNotFound, upstream.ErrorCodeBadRequest, "route not found")
+	}
+}
+
+// authenticate 按协议顺序校验请求头、时间戳、密钥与签名
+func (s *StandIn) authenticate(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	apiKey := r.Header.Get(upstream.HeaderApiKey)
+	timestampRaw := r.Header.Get(upstream.HeaderTimestamp)
+	signature := r.Header.Get(upstream.HeaderSignature)
+	if apiKey == "" || timestampRaw == "" || signature == "" {
+		writeError(w, http.StatusUnauthorized, upstream.ErrorCodeMissingAuthHeaders, "missing authentication headers")
+		return nil, false
+	}
+	timestamp, err := upstream.ParseTimestamp(timestampRaw)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, upstream.ErrorCodeInvalidTimestamp, "invalid timestamp format")
+		return nil, false
+	}
+	if !upstream.IsTimestampValid(timestamp) {
+		writeError(w, http.StatusUnauthorized, upstream.ErrorCodeTimestampExpired, "timestamp expired or too far in the future")
+		return nil, false
+	}
+	if apiKey != s.apiKey {
+		writeError(w, http.StatusForbidden, upstream.ErrorCodeInvalidApiKey, "invalid or inactive api key")
+		return nil, false
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, upstream.ErrorCodeBadRequest, "failed to read request body")
+		return nil, false
+	}
+	if !upstream.Verify(s.apiSecret, r.Method, r.URL.Path, signature, timestamp, body) {
+		writeError(w, http.StatusUnauthorized, upstream.ErrorCodeInvalidSignature, "signature verification failed")
+		return nil, false
+	}
+	return body, true
+}
+
+func (s *StandIn) ping(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, upstream.PingResponse{
+		OK: true,
+		PingResult: upstream.PingResult{
+			SiteName:        "Dujiao-Next Stand-in",
+			ProtocolVersion: upstream.ProtocolVersion,
+			Features:        upstream.ProtocolFeatures(upstream.ProtocolVersion),
+			UserID:          1,
+			Balance:         "1000.00",
+			Currency:        standInCurrency,
+		},
+	})
+}
+
+func (s *StandIn) categories(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, upstream.CategoryListResponse{
+		OK: true,
+		Categories: []upstream.UpstreamCategory{
+			{ID: 1, Slug: "default", Name: models.JSON{"zh-CN": "默认分类", "en-US": "Default"}},
+		},
+	})
+}
+
+func (s *StandIn) products(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	items := []upstream.UpstreamProduct{}
+	if page == 1 {
+		items = append(items, standInProduct())
+	}
+	writeJSON(w, http.StatusOK, upstream.ProductListResponse{
+		OK: true, Items: items, Total: 1, Page: page, PageSize: pageSize,
+	})
+}
+
+func (s *StandIn) product(w http.ResponseWriter, rawID string) {
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, upstream.ErrorCodeBadRequest, "invalid product id")
+		return
+	}
+	if uint(id) != StandInProductID {
+		writeError(w, http.StatusNotFound, upstream.ErrorCodeProductNotFound, "product not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, upstream.ProductResponse{OK: true, Product: standInProduct()})
+}
+
+func (s *StandIn) createOrder(w http.ResponseWriter, r *http.Request, body []byte) {
+	var req upstream.CreateUpstreamOrderReq
+	if err := json.Unmarshal(body, &req); err != nil || req.SKUID == 0 || req.Quantity < 1 {
+		writeError(w, http.StatusBadRequest, upstream.ErrorCodeBadRequest, "invalid request body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.Header.Get(upstream.HeaderIdempotencyKey)
+	fingerprint := requestFingerprint(r.Method, r.URL.Path, body)
+	if key != "" {
+		if len(key) > 128 {
+			writeError(w, http.StatusBadRequest, upstream.ErrorCodeIdempotencyInvalid, "Idempotency-Key is too long")
+			return
+		}
+		if replay, ok := s.idempotency[key]; ok {
+			if replay.fingerprint != fingerprint {
+				writeError(w, http.StatusConflict, upstream.ErrorCodeIdempotencyReuse, "Idempotency-Key was used with a different request")
+				return
+			}
+			writeRaw(w, http.StatusOK, replay.body)
+			return
+		}
+	}
+
+	if req.SKUID != StandInSKUID {
+		writeError(w, http.StatusBadRequest, upstream.ErrorCodeSKUUnavailable, "sku is invalid or not available")
+		return
+	}
+
+	var order *upstream.UpstreamOrderDetail
+	if id, ok := s.byDownstream[req.DownstreamOrderNo]; ok && req.DownstreamOrderNo != "" {
+		order = s.orders[id]
+	} else {
+		s.nextOrderID++
+		price := decimal.RequireFromString(standInPrice)
+		total := price.Mul(decimal.NewFromInt(int64(req.Quantity)))
+		product := standInProduct()
+		order = &upstream.UpstreamOrderDetail{
+			OrderID:  s.nextOrderID,
+			OrderNo:  fmt.Sprintf("SI%d", s.nextOrderID),
+			Status:   constants.OrderStatusPaid,
+			Amount:   total.StringFixed(2),
+			Currency: standInCurrency,
+			Items: []upstream.UpstreamOrderItem{{
+				ProductID:       product.ID,
+				SKUID:           req.SKUID,
+				Title:           product.Title,
+				Quantity:        req.Quantity,
+				UnitPrice:       price.StringFixed(2),
+				TotalPrice:      total.StringFixed(2),
+				FulfillmentType: product.FulfillmentType,
+			}},
+		}
+		s.orders[order.OrderID] = order
+		if req.DownstreamOrderNo != "" {
+			s.byDownstream[req.DownstreamOrderNo] = order.OrderID
+		}
+	}
+
+	payload, _ := json.Marshal(upstream.CreateUpstreamOrderResp{
+		OK:       true,
+		OrderID:  order.OrderID,
+		OrderNo:  order.OrderNo,
+		Status:   order.Status,
+		Amount:   order.Amount,
+		Currency: order.Currency,
+	})
+	if key != "" {
+		s.idempotency[key] = standInReplay{fingerprint: fingerprint, body: payload}
+	}
+	writeRaw(w, http.StatusOK, payload)
+}
+
+func (s *StandIn) getOrder(w http.ResponseWriter, rawID string) {
+	order, ok := s.lookupOrder(w, rawID)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, upstream.OrderDetailResponse{OK: true, UpstreamOrderDetail: order})
+}
+
+func (s *StandIn) cancelOrder(w http.ResponseWriter, rawID string) {
+	order, ok := s.lookupOrder(w, rawID)
+	if !ok {
+		return
+	}
+	if order.Status != constants.OrderStatusPendingPayment {
+		writeError(w, http.StatusConflict, upstream.ErrorCodeCancelNotAllowed, "order cannot be canceled")
+		return
+	}
+	writeJSON(w, http.StatusOK, upstream.CancelOrderResponse{OK: true, OrderID: order.OrderID, OrderNo: order.OrderNo, Status: constants.OrderStatusCanceled})
+}
+
+// lookupOrder 返回订单副本，避免响应序列化时与并发请求竞争
+func (s *StandIn) lookupOrder(w http.ResponseWriter, rawID string) (upstream.UpstreamOrderDetail, bool) {
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, upstream.ErrorCodeBadRequest, "invalid order id")
+		return upstream.UpstreamOrderDetail{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.orders[uint(id)]
+	if !ok {
+		writeError(w, http.StatusNotFound, upstream.ErrorCodeOrderNotFound, "order not found")
+		return upstream.UpstreamOrderDetail{}, false
+	}
+	return *order, true
+}
+
+func (s *StandIn) webhooks(w http.ResponseWriter, r *http.Request, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case http.MethodPost:
+		var req upstream.CatalogWebhookRequest
+		if err := json.Unmarshal(body, &req); err != nil || strings.TrimSpace(req.URL) == "" {
+			writeError(w, http.StatusBadRequest, upstream.ErrorCodeBadRequest, "invalid request body")
+			return
+		}
+		if !strings.HasPrefix(req.URL, "http://") && !strings.HasPrefix(req.URL, "https://") {
+			writeError(w, http.StatusBadRequest, upstream.ErrorCodeInvalidCallbackURL, "invalid webhook url")
+			return
+		}
+		now := time.Now()
+		s.webhook = &models.CatalogWebhook{ID: 1, ApiCredentialID: 1, UserID: 1, URL: req.URL, IsActive: true, CreatedAt: now, UpdatedAt: now}
+		writeJSON(w, http.StatusOK, upstream.CatalogWebhookResponse{OK: true, Webhook: *s.webhook})
+	case http.MethodGet:
+		if s.webhook == nil {
+			writeError(w, http.StatusNotFound, upstream.ErrorCodeWebhookNotFound, "webhook not registered")
+			return
+		}
+		writeJSON(w, http.StatusOK, upstream.CatalogWebhookResponse{OK: true, Webhook: *s.webhook})
+	case http.MethodDelete:
+		if s.webhook == nil {
+			writeError(w, http.StatusNotFound, upstream.ErrorCodeWebhookNotFound, "webhook not registered")
+			return
+		}
+		s.webhook = nil
+		writeJSON(w, http.StatusOK, upstream.OKResponse{OK: true})
+	default:
+		writeError(w, http.StatusNotFound, upstream.ErrorCodeBadRequest, "route not found")
+	}
+}
+
+func standInProduct() upstream.UpstreamProduct {
+	updatedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return upstream.UpstreamProduct{
+		ID:               StandInProductID,
+		Slug:             "stand-in-product",
+		SeoMeta:          models.JSON{},
+		Title:            models.JSON{"zh-CN": "替身商品", "en-US": "Stand-in product"},
+		Description:      models.JSON{},
+		Content:          models.JSON{},
+		Images:           []string{},
+		Tags:             []string{},
+		PriceAmount:      standInPrice,
+		Currency:         standInCurrency,
+		FulfillmentType:  constants.FulfillmentTypeAuto,
+		ManualFormSchema: models.JSON{},
+		IsActive:         true,
+		CategoryID:       1,
+		SKUs: []upstream.UpstreamSKU{{
+			ID:            StandInSKUID,
+			SKUCode:       "DEFAULT",
+			SpecValues:    models.JSON{},
+			PriceAmount:   standInPrice,
+			StockStatus:   constants.ProductStockStatusUnlimited,
+			StockQuantity: -1,
+			IsActive:      true,
+		}},
+		CreatedAt: &updatedAt,
+		UpdatedAt: updatedAt,
+	}
+}
+
+func requestFingerprint(method, path string, body []byte) string {
+	sum := sha256.Sum256(append([]byte(method+"\n"+path+"\n"), body...))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, upstream.ErrorCodeInternal, "failed to encode response")
+		return
+	}
+	writeRaw(w, status, body)
+}
+
+func writeRaw(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	body, _ := json.Marshal(upstream.ErrorResponse{OK: false, ErrorCode: code, ErrorMessage: message})
+	writeRaw(w, status, body)
+}
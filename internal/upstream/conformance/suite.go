@@ -0,0 +1,401 @@
+// Package conformance Dujiao-Next 上游协议一致性测试套件。
+//
+// 任何声称实现该协议的上游（本站、第三方实现或本包的 StandIn 替身）都可以通过 Run 校验：
+// 鉴权错误码、响应结构（按 upstream.OpenAPIDocument 生成的 schema）、下单幂等与按协议版本开放的能力。
+// 针对真实站点运行时请使用专用测试密钥：下单用例会产生真实订单，webhook 用例会覆盖并删除该密钥的推送注册。
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dujiao-next/internal/upstream"
+)
+
+// missingID 一致性测试用于“不存在”用例的资源 ID
+const missingID = 999999999
+
+// Target 被测上游
+type Target struct {
+	BaseURL   string
+	ApiKey    string
+	ApiSecret string
+	// SKUID 可下单的 SKU（需钱包余额充足），为 0 时跳过下单用例
+	SKUID uint
+	// WebhookURL 注册目录推送使用的地址，为空时使用 https://example.com/dujiao-conformance
+	WebhookURL string
+	Client     *http.Client
+}
+
+type suite struct {
+	target    Target
+	client    *http.Client
+	validator *Validator
+	version   string
+}
+
+// Run 对目标上游运行一致性测试
+func Run(t *testing.T, target Target) {
+	t.Helper()
+	s := &suite{
+		target:    target,
+		client:    target.Client,
+		validator: NewValidator(upstream.OpenAPIDocument()),
+	}
+	s.target.BaseURL = strings.TrimRight(target.BaseURL, "/")
+	if s.client == nil {
+		s.client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if s.target.WebhookURL == "" {
+		s.target.WebhookURL = "https://example.com/dujiao-conformance"
+	}
+
+	if !t.Run("ping", s.testPing) {
+		t.Fatalf("ping failed, skipping remaining conformance checks")
+	}
+	t.Run("auth", s.testAuth)
+	t.Run("catalog", s.testCatalog)
+	t.Run("orders", s.testOrders)
+	t.Run("webhooks", s.testWebhooks)
+}
+
+// response 协议响应
+type response struct {
+	status int
+	body   []byte
+}
+
+// request 发送签名请求；mutate 可在签名后修改请求（用于构造鉴权失败用例）
+func (s *suite) request(t *testing.T, method, path string, body []byte, headers map[string]string, mutate func(*http.Request)) response {
+	t.Helper()
+	signPath := path
+	if idx := strings.Index(path, "?"); idx > 0 {
+		signPath = path[:idx]
+	}
+	timestamp := time.Now().Unix()
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, s.target.BaseURL+path, reader)
+	if err != nil {
+		t.Fatalf("build request %s %s: %v", method, path, err)
+	}
+	req.Header.Set(upstream.HeaderApiKey, s.target.ApiKey)
+	req.Header.Set(upstream.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(upstream.HeaderSignature, upstream.Sign(s.target.ApiSecret, method, signPath, timestamp, body))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if mutate != nil {
+		mutate(req)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		t.Fatalf("send %s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read %s %s: %v", method, path, err)
+	}
+	return response{status: resp.StatusCode, body: respBody}
+}
+
+// expectOK 断言 200 且响应符合接口 schema，并解析到 out
+func (s *suite) expectOK(t *testing.T, operationID string, resp response, out interface{}) {
+	t.Helper()
+	if resp.status != http.StatusOK {
+		t.Fatalf("%s: expected status 200, got %d: %s", operationID, resp.status, resp.body)
+	}
+	if err := s.validator.ValidateResponse(operationID, resp.status, resp.body); err != nil {
+		t.Fatalf("%s: response does not match schema: %v\n%s", operationID, err, resp.body)
+	}
+	if out != nil {
+		if err := json.Unmarshal(resp.body, out); err != nil {
+			t.Fatalf("%s: decode response: %v", operationID, err)
+		}
+	}
+}
+
+// expectError 断言错误响应结构、错误码以及协议错误码表约定的 HTTP 状态码
+func (s *suite) expectError(t *testing.T, operationID string, resp response, codes ...string) {
+	t.Helper()
+	if err := s.validator.ValidateResponse(operationID, resp.status, resp.body); err != nil {
+		t.Fatalf("%s: error response does not match schema: %v\n%s", operationID, err, resp.body)
+	}
+	var payload upstream.ErrorResponse
+	if err := json.Unmarshal(resp.body, &payload); err != nil {
+		t.Fatalf("%s: decode error response: %v", operationID, err)
+	}
+	if payload.OK {
+		t.Fatalf("%s: error response must have ok=false: %s", operationID, resp.body)
+	}
+	matched := false
+	for _, code := range codes {
+		if payload.ErrorCode == code {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		t.Fatalf("%s: expected error_code in %v, got %q (status %d)", operationID, codes, payload.ErrorCode, resp.status)
+	}
+	if status := protocolErrorStatus(payload.ErrorCode); status != 0 && status != resp.status &&
+		!(payload.ErrorCode == upstream.ErrorCodeProductUnavailable && resp.status == http.StatusBadRequest) {
+		t.Fatalf("%s: error_code %s must be returned with status %d, got %d", operationID, payload.ErrorCode, status, resp.status)
+	}
+}
+
+func protocolErrorStatus(code string) int {
+	for _, item := range upstream.ProtocolErrors {
+		if item.Code == code {
+			return item.Status
+		}
+	}
+	return 0
+}
+
+func (s *suite) supports(feature string) bool {
+	return upstream.SupportsFeature(s.version, feature)
+}
+
+func (s *suite) testPing(t *testing.T) {
+	var payload upstream.PingResponse
+	s.expectOK(t, "ping", s.request(t, http.MethodPost, upstream.PathPing, nil, nil, nil), &payload)
+	if !payload.OK {
+		t.Fatalf("ping must return ok=true")
+	}
+	if upstream.CompareProtocolVersion(payload.ProtocolVersion, "1.0") < 0 {
+		t.Fatalf("protocol_version %q is not a valid major.minor version >= 1.0", payload.ProtocolVersion)
+	}
+	s.version = payload.ProtocolVersion
+	if upstream.CompareProtocolVersion(s.version, "1.1") >= 0 {
+		for _, feature := range upstream.ProtocolFeatures(s.version) {
+			if !containsString(payload.Features, feature) {
+				t.Errorf("protocol %s must advertise feature %q, got %v", s.version, feature, payload.Features)
+			}
+		}
+	}
+}
+
+func (s *suite) testAuth(t *testing.T) {
+	t.Run("missing_headers", func(t *testing.T) {
+		resp := s.request(t, http.MethodPost, upstream.PathPing, nil, nil, func(r *http.Request) {
+			r.Header.Del(upstream.HeaderSignature)
+		})
+		s.expectError(t, "ping", resp, upstream.ErrorCodeMissingAuthHeaders)
+	})
+	t.Run("invalid_timestamp", func(t *testing.T) {
+		resp := s.request(t, http.MethodPost, upstream.PathPing, nil, nil, func(r *http.Request) {
+			r.Header.Set(upstream.HeaderTimestamp, "not-a-number")
+		})
+		s.expectError(t, "ping", resp, upstream.ErrorCodeInvalidTimestamp)
+	})
+	t.Run("timestamp_expired", func(t *testing.T) {
+		resp := s.request(t, http.MethodPost, upstream.PathPing, nil, nil, func(r *http.Request) {
+			stale := time.Now().Unix() - 10*upstream.MaxTimestampSkew
+			r.Header.Set(upstream.HeaderTimestamp, strconv.FormatInt(stale, 10))
+			r.Header.Set(upstream.HeaderSignature, upstream.Sign(s.target.ApiSecret, r.Method, upstream.PathPing, stale, nil))
+		})
+		s.expectError(t, "ping", resp, upstream.ErrorCodeTimestampExpired)
+	})
+	t.Run("invalid_signature", func(t *testing.T) {
+		resp := s.request(t, http.MethodPost, upstream.PathPing, nil, nil, func(r *http.Request) {
+			r.Header.Set(upstream.HeaderSignature, strings.Repeat("0", 64))
+		})
+		s.expectError(t, "ping", resp, upstream.ErrorCodeInvalidSignature)
+	})
+	t.Run("signature_covers_body", func(t *testing.T) {
+		body := []byte(`{"sku_id":0,"quantity":1}`)
+		resp := s.request(t, http.MethodPost, upstream.PathOrders, body, nil, func(r *http.Request) {
+			tampered := []byte(`{"sku_id":1,"quantity":1}`)
+			r.Body = io.NopCloser(bytes.NewReader(tampered))
+			r.ContentLength = int64(len(tampered))
+		})
+		s.expectError(t, "createOrder", resp, upstream.ErrorCodeInvalidSignature)
+	})
+	t.Run("invalid_api_key", func(t *testing.T) {
+		resp := s.request(t, http.MethodPost, upstream.PathPing, nil, nil, func(r *http.Request) {
+			r.Header.Set(upstream.HeaderApiKey, "conformance-unknown-key")
+		})
+		s.expectError(t, "ping", resp, upstream.ErrorCodeInvalidApiKey)
+	})
+}
+
+func (s *suite) testCatalog(t *testing.T) {
+	t.Run("categories", func(t *testing.T) {
+		resp := s.request(t, http.MethodGet, upstream.PathCategories, nil, nil, nil)
+		if resp.status == http.StatusNotFound && upstream.CompareProtocolVersion(s.version, "1.1") < 0 {
+			t.Skip("categories endpoint is optional before protocol 1.1")
+		}
+		var payload upstream.CategoryListResponse
+		s.expectOK(t, "listCategories", resp, &payload)
+	})
+
+	var first *upstream.UpstreamProduct
+	t.Run("products", func(t *testing.T) {
+		var payload upstream.ProductListResponse
+		s.expectOK(t, "listProducts", s.request(t, http.MethodGet, upstream.PathProducts+"?page=1&page_size=5", nil, nil, nil), &payload)
+		if payload.Page != 1 || payload.PageSize != 5 {
+			t.Fatalf("expected page=1 page_size=5 echoed back, got page=%d page_size=%d", payload.Page, payload.PageSize)
+		}
+		if int64(len(payload.Items)) > payload.Total {
+			t.Fatalf("items (%d) exceed total (%d)", len(payload.Items), payload.Total)
+		}
+		if len(payload.Items) > 0 {
+			first = &payload.Items[0]
+		}
+	})
+	t.Run("product", func(t *testing.T) {
+		if first == nil {
+			t.Skip("catalog is empty")
+		}
+		var payload upstream.ProductResponse
+		s.expectOK(t, "getProduct", s.request(t, http.MethodGet, productPath(first.ID), nil, nil, nil), &payload)
+		if payload.Product.ID != first.ID {
+			t.Fatalf("expected product %d, got %d", first.ID, payload.Product.ID)
+		}
+	})
+	t.Run("product_not_found", func(t *testing.T) {
+		resp := s.request(t, http.MethodGet, productPath(missingID), nil, nil, nil)
+		s.expectError(t, "getProduct", resp, upstream.ErrorCodeProductNotFound, upstream.ErrorCodeProductUnavailable)
+	})
+}
+
+func (s *suite) testOrders(t *testing.T) {
+	t.Run("order_not_found", func(t *testing.T) {
+		s.expectError(t, "getOrder", s.request(t, http.MethodGet, orderPath(missingID), nil, nil, nil), upstream.ErrorCodeOrderNotFound)
+	})
+	t.Run("invalid_order_id", func(t *testing.T) {
+		s.expectError(t, "getOrder", s.request(t, http.MethodGet, upstream.PathOrders+"/abc", nil, nil, nil), upstream.ErrorCodeBadRequest)
+	})
+	t.Run("invalid_body", func(t *testing.T) {
+		s.expectError(t, "createOrder", s.request(t, http.MethodPost, upstream.PathOrders, []byte(`{"sku_id":0,"quantity":0}`), nil, nil), upstream.ErrorCodeBadRequest)
+	})
+	if s.target.SKUID == 0 {
+		t.Run("create", func(t *testing.T) { t.Skip("no orderable sku configured") })
+		return
+	}
+
+	nonce := strconv.FormatInt(time.Now().UnixNano(), 36)
+	var created upstream.CreateUpstreamOrderResp
+	t.Run("create", func(t *testing.T) {
+		body := s.orderBody(t, "CONF-"+nonce)
+		s.expectOK(t, "createOrder", s.request(t, http.MethodPost, upstream.PathOrders, body, nil, nil), &created)
+		if !created.OK || created.OrderID == 0 || created.OrderNo == "" {
+			t.Fatalf("expected a created order, got %+v", created)
+		}
+
+		var repeated upstream.CreateUpstreamOrderResp
+		s.expectOK(t, "createOrder", s.request(t, http.MethodPost, upstream.PathOrders, body, nil, nil), &repeated)
+		if repeated.OrderID != created.OrderID {
+			t.Fatalf("repeating downstream_order_no must return order %d, got %d", created.OrderID, repeated.OrderID)
+		}
+	})
+	t.Run("idempotency_key", func(t *testing.T) {
+		if !s.supports(upstream.FeatureIdempotencyKey) {
+			t.Skipf("protocol %s does not support %s", s.version, upstream.FeatureIdempotencyKey)
+		}
+		key := "conf-" + nonce
+		headers := map[string]string{upstream.HeaderIdempotencyKey: key}
+		body := s.orderBody(t, "CONF-IK-"+nonce)
+		var first, replay upstream.CreateUpstreamOrderResp
+		s.expectOK(t, "createOrder", s.request(t, http.MethodPost, upstream.PathOrders, body, headers, nil), &first)
+		s.expectOK(t, "createOrder", s.request(t, http.MethodPost, upstream.PathOrders, body, headers, nil), &replay)
+		if first.OrderID == 0 || replay.OrderID != first.OrderID {
+			t.Fatalf("replaying Idempotency-Key must return order %d, got %d", first.OrderID, replay.OrderID)
+		}
+		conflict := s.request(t, http.MethodPost, upstream.PathOrders, s.orderBody(t, "CONF-IK2-"+nonce), headers, nil)
+		s.expectError(t, "createOrder", conflict, upstream.ErrorCodeIdempotencyReuse)
+	})
+	t.Run("get", func(t *testing.T) {
+		if created.OrderID == 0 {
+			t.Skip("order was not created")
+		}
+		var detail upstream.OrderDetailResponse
+		s.expectOK(t, "getOrder", s.request(t, http.MethodGet, orderPath(created.OrderID), nil, nil, nil), &detail)
+		if detail.OrderID != created.OrderID || detail.OrderNo != created.OrderNo {
+			t.Fatalf("expected order %d/%s, got %d/%s", created.OrderID, created.OrderNo, detail.OrderID, detail.OrderNo)
+		}
+	})
+	t.Run("cancel", func(t *testing.T) {
+		if created.OrderID == 0 {
+			t.Skip("order was not created")
+		}
+		resp := s.request(t, http.MethodPost, orderPath(created.OrderID)+"/cancel", nil, nil, nil)
+		if resp.status == http.StatusOK {
+			var payload upstream.CancelOrderResponse
+			s.expectOK(t, "cancelOrder", resp, &payload)
+			return
+		}
+		s.expectError(t, "cancelOrder", resp, upstream.ErrorCodeCancelNotAllowed)
+	})
+}
+
+func (s *suite) testWebhooks(t *testing.T) {
+	if !s.supports(upstream.FeatureCatalogWebhooks) {
+		t.Skipf("protocol %s does not support %s", s.version, upstream.FeatureCatalogWebhooks)
+	}
+	body, _ := json.Marshal(upstream.CatalogWebhookRequest{URL: s.target.WebhookURL})
+	var registered upstream.CatalogWebhookResponse
+	s.expectOK(t, "registerCatalogWebhook", s.request(t, http.MethodPost, upstream.PathWebhooks, body, nil, nil), &registered)
+	if registered.Webhook.URL != s.target.WebhookURL {
+		t.Fatalf("expected webhook url %s, got %s", s.target.WebhookURL, registered.Webhook.URL)
+	}
+
+	var current upstream.CatalogWebhookResponse
+	s.expectOK(t, "getCatalogWebhook", s.request(t, http.MethodGet, upstream.PathWebhooks, nil, nil, nil), &current)
+	if current.Webhook.URL != s.target.WebhookURL {
+		t.Fatalf("expected registered webhook url %s, got %s", s.target.WebhookURL, current.Webhook.URL)
+	}
+
+	invalid, _ := json.Marshal(upstream.CatalogWebhookRequest{URL: "ftp://example.com/hook"})
+	s.expectError(t, "registerCatalogWebhook", s.request(t, http.MethodPost, upstream.PathWebhooks, invalid, nil, nil),
+		upstream.ErrorCodeInvalidCallbackURL)
+
+	s.expectOK(t, "deleteCatalogWebhook", s.request(t, http.MethodDelete, upstream.PathWebhooks, nil, nil, nil), nil)
+	s.expectError(t, "getCatalogWebhook", s.request(t, http.MethodGet, upstream.PathWebhooks, nil, nil, nil), upstream.ErrorCodeWebhookNotFound)
+	s.expectError(t, "deleteCatalogWebhook", s.request(t, http.MethodDelete, upstream.PathWebhooks, nil, nil, nil), upstream.ErrorCodeWebhookNotFound)
+}
+
+func (s *suite) orderBody(t *testing.T, downstreamOrderNo string) []byte {
+	t.Helper()
+	body, err := json.Marshal(upstream.CreateUpstreamOrderReq{
+		SKUID:             s.target.SKUID,
+		Quantity:          1,
+		DownstreamOrderNo: downstreamOrderNo,
+		TraceID:           downstreamOrderNo,
+	})
+	if err != nil {
+		t.Fatalf("marshal order body: %v", err)
+	}
+	return body
+}
+
+func productPath(id uint) string {
+	return fmt.Sprintf("%s/%d", upstream.PathProducts, id)
+}
+
+func orderPath(id uint) string {
+	return fmt.Sprintf("%s/%d", upstream.PathOrders, id)
+}
+
+func containsString(items []string, target string) bool {
+	for _, item := range items {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Validator 按 OpenAPI 文档校验响应体。只校验文档声明的字段与必填项，允许实现返回额外字段。
+type Validator struct {
+	doc        map[string]interface{}
+	schemas    map[string]interface{}
+	operations map[string]map[string]interface{}
+}
+
+// NewValidator 基于 OpenAPI 文档创建校验器
+func NewValidator(doc map[string]interface{}) *Validator {
+	v := &Validator{doc: doc, operations: map[string]map[string]interface{}{}}
+	if components, ok := doc["components"].(map[string]interface{}); ok {
+		v.schemas, _ = components["schemas"].(map[string]interface{})
+	}
+	paths, _ := doc["paths"].(map[string]interface{})
+	for _, rawItem := range paths {
+		item, _ := rawItem.(map[string]interface{})
+		for _, rawOp := range item {
+			op, _ := rawOp.(map[string]interface{})
+			if id, ok := op["operationId"].(string); ok {
+				v.operations[id] = op
+			}
+		}
+	}
+	return v
+}
+
+// ValidateResponse 按接口与状态码校验响应体（200 使用成功响应 schema，其余使用错误响应 schema）
+func (v *Validator) ValidateResponse(operationID string, status int, body []byte) error {
+	op, ok := v.operations[operationID]
+	if !ok {
+		return fmt.Errorf("operation %s is not documented", operationID)
+	}
+	key := "default"
+	if status == 200 {
+		key = "200"
+	}
+	responses, _ := op["responses"].(map[string]interface{})
+	response, _ := responses[key].(map[string]interface{})
+	content, _ := response["content"].(map[string]interface{})
+	media, _ := content["application/json"].(map[string]interface{})
+	schema, _ := media["schema"].(map[string]interface{})
+	if schema == nil {
+		return fmt.Errorf("operation %s has no %s response schema", operationID, key)
+	}
+	return v.ValidateSchema(schema, body)
+}
+
+// ValidateComponent 按 components/schemas 中的具名 schema 校验
+func (v *Validator) ValidateComponent(name string, body []byte) error {
+	schema, ok := v.schemas[name].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("schema %s is not documented", name)
+	}
+	return v.ValidateSchema(schema, body)
+}
+
+// ValidateSchema 解析 JSON 并按 schema 校验
+func (v *Validator) ValidateSchema(schema map[string]interface{}, body []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("response is not valid json: %w", err)
+	}
+	return v.validate(schema, value, "$")
+}
+
+func (v *Validator) validate(schema map[string]interface{}, value interface{}, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		resolved, ok := v.schemas[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: unresolved schema %s", path, ref)
+		}
+		return v.validate(resolved, value, path)
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		var errs []string
+		for _, raw := range anyOf {
+			sub, _ := raw.(map[string]interface{})
+			err := v.validate(sub, value, path)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return fmt.Errorf("%s: no schema matched (%s)", path, strings.Join(errs, "; "))
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		actual := jsonType(value)
+		if !typeAllowed(types, actual) {
+			return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, "|"), actual)
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, item := range enum {
+			if fmt.Sprint(item) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of the documented values", path, value)
+		}
+	}
+	if format, _ := schema["format"].(string); format == "date-time" {
+		if text, ok := value.(string); ok {
+			if _, err := time.Parse(time.RFC3339, text); err != nil {
+				return fmt.Errorf("%s: %q is not an RFC3339 date-time", path, text)
+			}
+		}
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for _, raw := range asSlice(schema["required"]) {
+			name, _ := raw.(string)
+			if _, ok := typed[name]; !ok {
+				return fmt.Errorf("%s: missing required field %q", path, name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fieldValue, ok := typed[name]
+			if !ok {
+				continue
+			}
+			sub, _ := properties[name].(map[string]interface{})
+			if err := v.validate(sub, fieldValue, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+		if items != nil {
+			for i, item := range typed {
+				if err := v.validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func schemaTypes(raw interface{}) []string {
+	switch typed := raw.(type) {
+	case string:
+		return []string{typed}
+	case []interface{}:
+		types := make([]string, 0, len(typed))
+		for _, item := range typed {
+			if text, ok := item.(string); ok {
+				types = append(types, text)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func asSlice(raw interface{}) []interface{} {
+	switch typed := raw.(type) {
+	case []interface{}:
+		return typed
+	case []string:
+		items := make([]interface{}, 0, len(typed))
+		for _, item := range typed {
+			items = append(items, item)
+		}
+		return items
+	}
+	return nil
+}
+
+func jsonType(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if strings.ContainsAny(typed.String(), ".eE") {
+			return "number"
+		}
+		return "integer"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+func typeAllowed(types []string, actual string) bool {
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
@@ -24,16 +24,19 @@ type DujiaoNextAdapter struct {
 	apiKey     string
 	apiSecret  string
 	uploadsDir string
-	client     *http.Client
+	// protocolVersion 最近一次 ping 得到的上游协议版本，用于判断可选能力
+	protocolVersion string
+	client          *http.Client
 }
 
 // NewDujiaoNextAdapter 创建 Dujiao-Next 适配器
 func NewDujiaoNextAdapter(conn *models.SiteConnection, uploadsDir string) *DujiaoNextAdapter {
 	return &DujiaoNextAdapter{
-		baseURL:    strings.TrimRight(conn.BaseURL, "/"),
-		apiKey:     conn.ApiKey,
-		apiSecret:  conn.ApiSecret,
-		uploadsDir: uploadsDir,
+		baseURL:         strings.TrimRight(conn.BaseURL, "/"),
+		apiKey:          conn.ApiKey,
+		apiSecret:       conn.ApiSecret,
+		uploadsDir:      uploadsDir,
+		protocolVersion: conn.ProtocolVersion,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -42,16 +45,14 @@ func NewDujiaoNextAdapter(conn *models.SiteConnection, uploadsDir string) *Dujia
 
 // Ping 连接测试
 func (a *DujiaoNextAdapter) Ping(ctx context.Context) (*PingResult, error) {
-	var result struct {
-		OK bool `json:"ok"`
-		PingResult
-	}
-	if err := a.doRequest(ctx, http.MethodPost, "/api/v1/upstream/ping", nil, &result); err != nil {
+	var result PingResponse
+	if err := a.doRequest(ctx, http.MethodPost, PathPing, nil, &result); err != nil {
 		return nil, err
 	}
 	if !result.OK {
 		return nil, fmt.Errorf("ping failed")
 	}
+	a.protocolVersion = result.ProtocolVersion
 	return &result.PingResult, nil
 }
 
@@ -97,10 +98,14 @@ func (a *DujiaoNextAdapter) GetProduct(ctx context.Context, productID uint) (*Up
 	return &result.Product, nil
 }
 
-// CreateOrder 发起采购单
+// CreateOrder 发起采购单（上游支持时以下游订单号作为 Idempotency-Key，网络重试不会重复下单）
 func (a *DujiaoNextAdapter) CreateOrder(ctx context.Context, req CreateUpstreamOrderReq) (*CreateUpstreamOrderResp, error) {
+	var headers map[string]string
+	if req.DownstreamOrderNo != "" && SupportsFeature(a.protocolVersion, FeatureIdempotencyKey) {
+		headers = map[string]string{HeaderIdempotencyKey: req.DownstreamOrderNo}
+	}
 	var result CreateUpstreamOrderResp
-	if err := a.doRequest(ctx, http.MethodPost, "/api/v1/upstream/orders", req, &result); err != nil {
+	if err := a.doRequestWithHeaders(ctx, http.MethodPost, PathOrders, headers, req, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
@@ -131,62 +136,32 @@ func (a *DujiaoNextAdapter) CancelOrder(ctx context.Context, orderID uint) error
 	return nil
 }
 
-// DownloadImage 下载图片到本地
-func (a *DujiaoNextAdapter) DownloadImage(ctx context.Context, imageURL string) (string, error) {
-	// 相对路径转绝对 URL
-	fullURL := imageURL
-	if strings.HasPrefix(imageURL, "/") {
-		fullURL = a.baseURL + imageURL
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("create download request: %w", err)
-	}
-
-	resp, err := a.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("download image: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("download image: status %d", resp.StatusCode)
-	}
-
-	// 确定文件扩展名
-	ext := filepath.Ext(imageURL)
-	if ext == "" || len(ext) > 6 {
-		ext = ".jpg"
-	}
-	// 去除 query string
-	if idx := strings.Index(ext, "?"); idx > 0 {
-		ext = ext[:idx]
-	}
-
-	filename := uuid.New().String() + ext
-	dir := filepath.Join(a.uploadsDir, "upstream")
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", fmt.Errorf("create uploads dir: %w", err)
+// RegisterCatalogWebhook 向上游注册商品目录变更推送地址
+func (a *DujiaoNextAdapter) RegisterCatalogWebhook(ctx context.Context, url string) error {
+	var result struct {
+		OK bool `json:"ok"`
 	}
-
-	filePath := filepath.Join(dir, filename)
-	f, err := os.Create(filePath)
-	if err != nil {
-		return "", fmt.Errorf("create file: %w", err)
+	if err := a.doRequest(ctx, http.MethodPost, PathWebhooks, CatalogWebhookRequest{URL: url}, &result); err != nil {
+		return err
 	}
-	defer f.Close()
-
-	if _, err := io.Copy(f, resp.Body); err != nil {
-		return "", fmt.Errorf("write file: %w", err)
+	if !result.OK {
+		return fmt.Errorf("register catalog webhook failed")
 	}
+	return nil
+}
 
-	// 返回相对路径
-	return "/uploads/upstream/" + filename, nil
+// DownloadImage 下载图片到本地
+func (a *DujiaoNextAdapter) DownloadImage(ctx context.Context, imageURL string) (string, error) {
+	return downloadUpstreamImage(ctx, a.client, a.baseURL, a.uploadsDir, imageURL)
 }
 
 // doRequest 发送签名请求
 func (a *DujiaoNextAdapter) doRequest(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	return a.doRequestWithHeaders(ctx, method, path, nil, body, result)
+}
+
+// doRequestWithHeaders 发送带附加请求头的签名请求
+func (a *DujiaoNextAdapter) doRequestWithHeaders(ctx context.Context, method, path string, headers map[string]string, body interface{}, result interface{}) error {
 	var bodyBytes []byte
 	if body != nil {
 		var err error
@@ -222,6 +197,9 @@ func (a *DujiaoNextAdapter) doRequest(ctx context.Context, method, path string,
 	if bodyBytes != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
 
 	resp, err := a.client.Do(req)
 	if err != nil {
@@ -238,7 +216,7 @@ func (a *DujiaoNextAdapter) doRequest(ctx context.Context, method, path string,
 		logger.Warnw("upstream_request_error",
 			"method", method, "path", path,
 			"status", resp.StatusCode, "body", string(respBody))
-		return fmt.Errorf("upstream responded with status %d: %s", resp.StatusCode, string(respBody))
+		return &ResponseError{StatusCode: resp.StatusCode, Body: string(respBody)}
 	}
 
 	if result != nil {
@@ -249,3 +227,78 @@ func (a *DujiaoNextAdapter) doRequest(ctx context.Context, method, path string,
 
 	return nil
 }
+
+// ResponseError 上游返回非 200 状态码（响应体为协议错误结构时可解析出错误码）
+type ResponseError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("upstream responded with status %d: %s", e.StatusCode, e.Body)
+}
+
+// ErrorCode 解析响应体中的协议错误码，无法解析时返回空字符串
+func (e *ResponseError) ErrorCode() string {
+	var body struct {
+		ErrorCode string `json:"error_code"`
+	}
+	if err := json.Unmarshal([]byte(e.Body), &body); err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(body.ErrorCode))
+}
+
+// downloadUpstreamImage 下载上游图片到本地 uploads/upstream 目录
+func downloadUpstreamImage(ctx context.Context, client *http.Client, baseURL, uploadsDir, imageURL string) (string, error) {
+	// 相对路径转绝对 URL
+	fullURL := imageURL
+	if strings.HasPrefix(imageURL, "/") {
+		fullURL = baseURL + imageURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("create download request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("download image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download image: status %d", resp.StatusCode)
+	}
+
+	// 确定文件扩展名
+	ext := filepath.Ext(imageURL)
+	if ext == "" || len(ext) > 6 {
+		ext = ".jpg"
+	}
+	// 去除 query string
+	if idx := strings.Index(ext, "?"); idx > 0 {
+		ext = ext[:idx]
+	}
+
+	filename := uuid.New().String() + ext
+	dir := filepath.Join(uploadsDir, "upstream")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("create uploads dir: %w", err)
+	}
+
+	filePath := filepath.Join(dir, filename)
+	f, err := os.Create(filePath)
+	if err != nil {
+		return "", fmt.Errorf("create file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		return "", fmt.Errorf("write file: %w", err)
+	}
+
+	// 返回相对路径
+	return "/uploads/upstream/" + filename, nil
+}
@@ -0,0 +1,707 @@
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/logger"
+	"github.com/dujiao-next/internal/models"
+)
+
+// restDryRunRawLimit 试运行时返回的原始响应最大长度
+const restDryRunRawLimit = 4096
+
+// restMaxResponseBytes 上游响应体最大读取长度，防止异常响应耗尽内存
+const restMaxResponseBytes = 8 << 20
+
+// GenericRESTAdapter 模板驱动的通用 REST 适配器
+type GenericRESTAdapter struct {
+	baseURL    string
+	apiKey     string
+	apiSecret  string
+	uploadsDir string
+	template   *RESTTemplate
+	client     *http.Client
+}
+
+// NewGenericRESTAdapter 根据连接上的模板创建通用 REST 适配器
+func NewGenericRESTAdapter(conn *models.SiteConnection, uploadsDir string) (*GenericRESTAdapter, error) {
+	tpl, err := ParseRESTTemplate(conn.RestTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return &GenericRESTAdapter{
+		baseURL:    strings.TrimRight(conn.BaseURL, "/"),
+		apiKey:     conn.ApiKey,
+		apiSecret:  conn.ApiSecret,
+		uploadsDir: uploadsDir,
+		template:   tpl,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}, nil
+}
+
+// restExchange 一次模板请求的记录
+type restExchange struct {
+	Method      string
+	URL         string
+	RequestBody string
+	StatusCode  int
+	RawResponse string
+	data        interface{}
+}
+
+// Ping 连接测试（未配置 ping 端点时以拉取一页商品代替）
+func (a *GenericRESTAdapter) Ping(ctx context.Context) (*PingResult, error) {
+	if _, ok := a.template.Endpoints[RESTEndpointPing]; !ok {
+		if _, err := a.ListProducts(ctx, ListProductsOpts{Page: 1, PageSize: 1}); err != nil {
+			return nil, err
+		}
+		return &PingResult{ProtocolVersion: "generic-rest"}, nil
+	}
+	ex, err := a.call(ctx, RESTEndpointPing, nil)
+	if err != nil {
+		return nil, err
+	}
+	return a.mapPing(ex.data), nil
+}
+
+// ListCategories 拉取上游分类列表
+func (a *GenericRESTAdapter) ListCategories(ctx context.Context) (*CategoryListResult, error) {
+	if _, ok := a.template.Endpoints[RESTEndpointListCategories]; !ok {
+		return &CategoryListResult{Supported: false}, nil
+	}
+	ex, err := a.call(ctx, RESTEndpointListCategories, nil)
+	if err != nil {
+		return nil, err
+	}
+	return a.mapCategories(ex.data)
+}
+
+// ListProducts 拉取上游商品列表
+func (a *GenericRESTAdapter) ListProducts(ctx context.Context, opts ListProductsOpts) (*ProductListResult, error) {
+	ex, err := a.call(ctx, RESTEndpointListProducts, listProductsVars(opts))
+	if err != nil {
+		return nil, err
+	}
+	return a.mapProductList(ex.data)
+}
+
+// GetProduct 获取单个商品详情
+func (a *GenericRESTAdapter) GetProduct(ctx context.Context, productID uint) (*UpstreamProduct, error) {
+	ex, err := a.call(ctx, RESTEndpointGetProduct, map[string]interface{}{"product_id": productID})
+	if err != nil {
+		return nil, err
+	}
+	return a.mapProduct(ex.data)
+}
+
+// CreateOrder 发起采购单
+func (a *GenericRESTAdapter) CreateOrder(ctx context.Context, req CreateUpstreamOrderReq) (*CreateUpstreamOrderResp, error) {
+	ex, err := a.call(ctx, RESTEndpointCreateOrder, createOrderVars(req))
+	if err != nil {
+		return nil, err
+	}
+	return a.mapCreateOrder(ex.data), nil
+}
+
+// GetOrder 查询上游订单状态
+func (a *GenericRESTAdapter) GetOrder(ctx context.Context, orderID uint) (*UpstreamOrderDetail, error) {
+	ex, err := a.call(ctx, RESTEndpointGetOrder, map[string]interface{}{"order_id": orderID})
+	if err != nil {
+		return nil, err
+	}
+	return a.mapOrderDetail(ex.data)
+}
+
+// CancelOrder 取消采购单
+func (a *GenericRESTAdapter) CancelOrder(ctx context.Context, orderID uint) error {
+	if _, ok := a.template.Endpoints[RESTEndpointCancelOrder]; !ok {
+		return fmt.Errorf("cancel_order endpoint is not configured")
+	}
+	ex, err := a.call(ctx, RESTEndpointCancelOrder, map[string]interface{}{"order_id": orderID})
+	if err != nil {
+		return err
+	}
+	if val, ok := a.field(RESTEndpointCancelOrder, ex.data, "ok"); ok && !valueToBool(val) {
+		return fmt.Errorf("cancel order failed")
+	}
+	return nil
+}
+
+// DownloadImage 下载图片到本地
+func (a *GenericRESTAdapter) DownloadImage(ctx context.Context, imageURL string) (string, error) {
+	return downloadUpstreamImage(ctx, a.client, a.baseURL, a.uploadsDir, imageURL)
+}
+
+// RESTDryRunInput 模板试运行参数
+type RESTDryRunInput struct {
+	ProductID          uint `json:"product_id"`           // 为空时取商品列表第一项
+	SKUID              uint `json:"sku_id"`               // 为空时取商品第一个 SKU
+	OrderID            uint `json:"order_id"`             // 查询订单使用的上游订单ID
+	Quantity           int  `json:"quantity"`             // 下单数量，默认 1
+	ExecuteCreateOrder bool `json:"execute_create_order"` // 是否真实调用下单端点（否则仅预览请求）
+}
+
+// RESTDryRunStep 单个端点的试运行结果
+type RESTDryRunStep struct {
+	Endpoint    string      `json:"endpoint"`
+	Method      string      `json:"method,omitempty"`
+	URL         string      `json:"url,omitempty"`
+	RequestBody string      `json:"request_body,omitempty"`
+	StatusCode  int         `json:"status_code,omitempty"`
+	RawResponse string      `json:"raw_response,omitempty"`
+	Mapped      interface{} `json:"mapped,omitempty"`
+	Error       string      `json:"error,omitempty"`
+	Skipped     bool        `json:"skipped"`
+	SkipReason  string      `json:"skip_reason,omitempty"`
+}
+
+// DryRun 依次调用模板中的端点并返回请求、原始响应与映射结果
+// 下单端点默认只预览请求，取消端点始终只预览，避免对上游产生副作用
+func (a *GenericRESTAdapter) DryRun(ctx context.Context, input RESTDryRunInput) []RESTDryRunStep {
+	steps := make([]RESTDryRunStep, 0, 7)
+	run := func(name string, vars map[string]interface{}, mapper func(interface{}) (interface{}, error)) interface{} {
+		step := RESTDryRunStep{Endpoint: name}
+		if _, ok := a.template.Endpoints[name]; !ok {
+			step.Skipped = true
+			step.SkipReason = "not_configured"
+			steps = append(steps, step)
+			return nil
+		}
+		ex, err := a.call(ctx, name, vars)
+		if ex != nil {
+			step.Method, step.URL, step.RequestBody = ex.Method, ex.URL, ex.RequestBody
+			step.StatusCode = ex.StatusCode
+			step.RawResponse = truncateRaw(ex.RawResponse)
+		}
+		var mapped interface{}
+		if err == nil {
+			mapped, err = mapper(ex.data)
+		}
+		if err != nil {
+			step.Error = err.Error()
+			mapped = nil
+		}
+		step.Mapped = mapped
+		steps = append(steps, step)
+		return mapped
+	}
+	preview := func(name string, vars map[string]interface{}, reason string) {
+		step := RESTDryRunStep{Endpoint: name, Skipped: true, SkipReason: reason}
+		if _, ok := a.template.Endpoints[name]; !ok {
+			step.SkipReason = "not_configured"
+		} else if ex, err := a.buildExchange(name, vars); err != nil {
+			step.Error = err.Error()
+		} else {
+			step.Method, step.URL, step.RequestBody = ex.Method, ex.URL, ex.RequestBody
+		}
+		steps = append(steps, step)
+	}
+
+	run(RESTEndpointPing, nil, func(data interface{}) (interface{}, error) {
+		return a.mapPing(data), nil
+	})
+	run(RESTEndpointListCategories, nil, func(data interface{}) (interface{}, error) {
+		return a.mapCategories(data)
+	})
+	productID := input.ProductID
+	listed := run(RESTEndpointListProducts, listProductsVars(ListProductsOpts{Page: 1, PageSize: 5}), func(data interface{}) (interface{}, error) {
+		return a.mapProductList(data)
+	})
+	if list, ok := listed.(*ProductListResult); ok && productID == 0 && len(list.Items) > 0 {
+		productID = list.Items[0].ID
+	}
+
+	skuID := input.SKUID
+	if productID == 0 {
+		preview(RESTEndpointGetProduct, map[string]interface{}{"product_id": productID}, "missing_product_id")
+	} else {
+		fetched := run(RESTEndpointGetProduct, map[string]interface{}{"product_id": productID}, func(data interface{}) (interface{}, error) {
+			return a.mapProduct(data)
+		})
+		if product, ok := fetched.(*UpstreamProduct); ok && skuID == 0 && len(product.SKUs) > 0 {
+			skuID = product.SKUs[0].ID
+		}
+	}
+
+	quantity := input.Quantity
+	if quantity <= 0 {
+		quantity = 1
+	}
+	orderVars := createOrderVars(CreateUpstreamOrderReq{
+		SKUID:             skuID,
+		Quantity:          quantity,
+		DownstreamOrderNo: fmt.Sprintf("DRYRUN%d", time.Now().Unix()),
+		TraceID:           fmt.Sprintf("dry-run-%d", time.Now().UnixNano()),
+	})
+	orderID := input.OrderID
+	if input.ExecuteCreateOrder {
+		created := run(RESTEndpointCreateOrder, orderVars, func(data interface{}) (interface{}, error) {
+			return a.mapCreateOrder(data), nil
+		})
+		if resp, ok := created.(*CreateUpstreamOrderResp); ok && orderID == 0 {
+			orderID = resp.OrderID
+		}
+	} else {
+		preview(RESTEndpointCreateOrder, orderVars, "preview_only")
+	}
+
+	if orderID == 0 {
+		preview(RESTEndpointGetOrder, map[string]interface{}{"order_id": orderID}, "missing_order_id")
+	} else {
+		run(RESTEndpointGetOrder, map[string]interface{}{"order_id": orderID}, func(data interface{}) (interface{}, error) {
+			return a.mapOrderDetail(data)
+		})
+	}
+	preview(RESTEndpointCancelOrder, map[string]interface{}{"order_id": orderID}, "preview_only")
+	return steps
+}
+
+func listProductsVars(opts ListProductsOpts) map[string]interface{} {
+	vars := map[string]interface{}{
+		"page":          opts.Page,
+		"page_size":     opts.PageSize,
+		"updated_after": "",
+	}
+	if opts.UpdatedAfter != nil {
+		vars["updated_after"] = opts.UpdatedAfter.UTC().Format(time.RFC3339)
+	}
+	return vars
+}
+
+func createOrderVars(req CreateUpstreamOrderReq) map[string]interface{} {
+	formData := map[string]interface{}{}
+	if req.ManualFormData != nil {
+		formData = req.ManualFormData
+	}
+	return map[string]interface{}{
+		"sku_id":              req.SKUID,
+		"quantity":            req.Quantity,
+		"downstream_order_no": req.DownstreamOrderNo,
+		"trace_id":            req.TraceID,
+		"callback_url":        req.CallbackURL,
+		"manual_form_data":    formData,
+	}
+}
+
+// buildExchange 渲染端点请求（不发送）
+func (a *GenericRESTAdapter) buildExchange(name string, vars map[string]interface{}) (*restExchange, error) {
+	ep, ok := a.template.Endpoints[name]
+	if !ok {
+		return nil, fmt.Errorf("%s endpoint is not configured", name)
+	}
+	allVars := map[string]interface{}{
+		"api_key":   a.apiKey,
+		"timestamp": time.Now().Unix(),
+	}
+	for key, val := range vars {
+		allVars[key] = val
+	}
+
+	path := renderTemplateString(ep.Path, allVars)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	fullURL := a.baseURL + path
+	if len(ep.Query) > 0 {
+		query := url.Values{}
+		for key, val := range ep.Query {
+			if rendered := renderTemplateString(val, allVars); rendered != "" {
+				query.Set(key, rendered)
+			}
+		}
+		if encoded := query.Encode(); encoded != "" {
+			separator := "?"
+			if strings.Contains(fullURL, "?") {
+				separator = "&"
+			}
+			fullURL += separator + encoded
+		}
+	}
+
+	ex := &restExchange{Method: ep.method(), URL: fullURL}
+	if len(ep.Body) > 0 && ex.Method != http.MethodGet {
+		bodyBytes, err := json.Marshal(renderTemplateValue(ep.Body, allVars))
+		if err != nil {
+			return nil, fmt.Errorf("marshal request body: %w", err)
+		}
+		ex.RequestBody = string(bodyBytes)
+	}
+	return ex, nil
+}
+
+// call 渲染并发送端点请求，解析 JSON 响应
+func (a *GenericRESTAdapter) call(ctx context.Context, name string, vars map[string]interface{}) (*restExchange, error) {
+	ex, err := a.buildExchange(name, vars)
+	if err != nil {
+		return nil, err
+	}
+	ep := a.template.Endpoints[name]
+
+	var bodyReader io.Reader
+	if ex.RequestBody != "" {
+		bodyReader = strings.NewReader(ex.RequestBody)
+	}
+	req, err := http.NewRequestWithContext(ctx, ex.Method, ex.URL, bodyReader)
+	if err != nil {
+		return ex, fmt.Errorf("create request: %w", err)
+	}
+	if ex.RequestBody != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	headerVars := map[string]interface{}{"api_key": a.apiKey}
+	for key, val := range ep.Headers {
+		req.Header.Set(key, renderTemplateString(val, headerVars))
+	}
+	a.applyAuth(req, []byte(ex.RequestBody))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return ex, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, restMaxResponseBytes+1))
+	if err != nil {
+		return ex, fmt.Errorf("read response: %w", err)
+	}
+	if len(respBody) > restMaxResponseBytes {
+		return ex, fmt.Errorf("upstream response exceeds %d bytes", restMaxResponseBytes)
+	}
+	ex.StatusCode = resp.StatusCode
+	ex.RawResponse = string(respBody)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		logger.Warnw("upstream_generic_rest_request_error",
+			"endpoint", name, "method", ex.Method, "url", ex.URL,
+			"status", resp.StatusCode, "body", truncateRaw(ex.RawResponse))
+		return ex, fmt.Errorf("upstream responded with status %d: %s", resp.StatusCode, truncateRaw(ex.RawResponse))
+	}
+	if len(bytes.TrimSpace(respBody)) > 0 {
+		if err := json.Unmarshal(respBody, &ex.data); err != nil {
+			return ex, fmt.Errorf("unmarshal response: %w", err)
+		}
+	}
+	return ex, nil
+}
+
+// applyAuth 按模板鉴权方式设置请求头
+func (a *GenericRESTAdapter) applyAuth(req *http.Request, body []byte) {
+	auth := a.template.Auth
+	switch strings.TrimSpace(auth.Type) {
+	case RESTAuthHeader:
+		req.Header.Set(defaultString(auth.KeyHeader, "X-Api-Key"), a.apiKey)
+		if auth.SecretHeader != "" {
+			req.Header.Set(auth.SecretHeader, a.apiSecret)
+		}
+	case RESTAuthHMAC:
+		timestamp := time.Now().Unix()
+		signature := Sign(a.apiSecret, req.Method, req.URL.Path, timestamp, body)
+		req.Header.Set(defaultString(auth.KeyHeader, HeaderApiKey), a.apiKey)
+		req.Header.Set(defaultString(auth.TimestampHeader, HeaderTimestamp), fmt.Sprintf("%d", timestamp))
+		req.Header.Set(defaultString(auth.SignatureHeader, HeaderSignature), signature)
+	case RESTAuthBasic:
+		req.SetBasicAuth(a.apiKey, a.apiSecret)
+	}
+}
+
+// field 读取端点 fields 中映射的响应字段
+func (a *GenericRESTAdapter) field(endpoint string, item interface{}, target string) (interface{}, bool) {
+	path := strings.TrimSpace(a.template.Endpoints[endpoint].Fields[target])
+	if path == "" {
+		return nil, false
+	}
+	return lookupPath(item, path)
+}
+
+func (a *GenericRESTAdapter) fieldString(endpoint string, item interface{}, target string) string {
+	val, _ := a.field(endpoint, item, target)
+	return strings.TrimSpace(stringifyValue(val))
+}
+
+// mapStatus 将上游状态按 status_map 转为本地标准状态
+func (a *GenericRESTAdapter) mapStatus(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if mapped, ok := a.template.StatusMap[raw]; ok {
+		return mapped
+	}
+	for key, mapped := range a.template.StatusMap {
+		if strings.EqualFold(key, raw) {
+			return mapped
+		}
+	}
+	return strings.ToLower(raw)
+}
+
+func (a *GenericRESTAdapter) mapPing(data interface{}) *PingResult {
+	root, _ := lookupPath(data, a.template.Endpoints[RESTEndpointPing].ItemsPath)
+	return &PingResult{
+		SiteName:        a.fieldString(RESTEndpointPing, root, "site_name"),
+		ProtocolVersion: "generic-rest",
+		Balance:         a.fieldString(RESTEndpointPing, root, "balance"),
+		Currency:        a.fieldString(RESTEndpointPing, root, "currency"),
+	}
+}
+
+func (a *GenericRESTAdapter) mapCategories(data interface{}) (*CategoryListResult, error) {
+	items, err := a.listItems(RESTEndpointListCategories, data)
+	if err != nil {
+		return nil, err
+	}
+	result := &CategoryListResult{Supported: true, Categories: make([]UpstreamCategory, 0, len(items))}
+	for _, item := range items {
+		name, _ := a.field(RESTEndpointListCategories, item, "name")
+		id, _ := a.field(RESTEndpointListCategories, item, "id")
+		parentID, _ := a.field(RESTEndpointListCategories, item, "parent_id")
+		sortOrder, _ := a.field(RESTEndpointListCategories, item, "sort_order")
+		sort, _ := valueToInt(sortOrder)
+		result.Categories = append(result.Categories, UpstreamCategory{
+			ID:        valueToUint(id),
+			ParentID:  valueToUint(parentID),
+			Slug:      a.fieldString(RESTEndpointListCategories, item, "slug"),
+			Name:      valueToLocalized(name),
+			Icon:      a.fieldString(RESTEndpointListCategories, item, "icon"),
+			SortOrder: sort,
+		})
+	}
+	return result, nil
+}
+
+func (a *GenericRESTAdapter) mapProductList(data interface{}) (*ProductListResult, error) {
+	items, err := a.listItems(RESTEndpointListProducts, data)
+	if err != nil {
+		return nil, err
+	}
+	result := &ProductListResult{Total: len(items), Items: make([]UpstreamProduct, 0, len(items))}
+	if totalPath := a.template.Endpoints[RESTEndpointListProducts].TotalPath; totalPath != "" {
+		if val, ok := lookupPath(data, totalPath); ok {
+			if total, ok := valueToInt(val); ok {
+				result.Total = total
+			}
+		}
+	}
+	for _, item := range items {
+		product, err := a.productFromItem(RESTEndpointListProducts, item)
+		if err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, *product)
+	}
+	return result, nil
+}
+
+func (a *GenericRESTAdapter) mapProduct(data interface{}) (*UpstreamProduct, error) {
+	root, ok := lookupPath(data, a.template.Endpoints[RESTEndpointGetProduct].ItemsPath)
+	if !ok {
+		return nil, fmt.Errorf("product not found in response")
+	}
+	return a.productFromItem(RESTEndpointGetProduct, root)
+}
+
+// listItems 读取列表端点的数组
+func (a *GenericRESTAdapter) listItems(endpoint string, data interface{}) ([]interface{}, error) {
+	val, ok := lookupPath(data, a.template.Endpoints[endpoint].ItemsPath)
+	if !ok {
+		return []interface{}{}, nil
+	}
+	items, ok := val.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s items_path does not point to an array", endpoint)
+	}
+	return items, nil
+}
+
+// productFromItem 将单个商品对象映射为 UpstreamProduct
+// 未配置 skus_path 时按单 SKU 商品处理，SKU ID 与商品 ID 相同
+func (a *GenericRESTAdapter) productFromItem(endpoint string, item interface{}) (*UpstreamProduct, error) {
+	ep := a.template.Endpoints[endpoint]
+	idVal, _ := a.field(endpoint, item, "id")
+	product := &UpstreamProduct{
+		ID:              valueToUint(idVal),
+		PriceAmount:     a.fieldString(endpoint, item, "price_amount"),
+		Currency:        a.fieldString(endpoint, item, "currency"),
+		FulfillmentType: defaultString(a.fieldString(endpoint, item, "fulfillment_type"), "auto"),
+		IsActive:        true,
+	}
+	if product.ID == 0 {
+		return nil, fmt.Errorf("product id not found in response")
+	}
+	if val, ok := a.field(endpoint, item, "title"); ok {
+		product.Title = valueToLocalized(val)
+	}
+	if val, ok := a.field(endpoint, item, "description"); ok {
+		product.Description = valueToLocalized(val)
+	}
+	if val, ok := a.field(endpoint, item, "images"); ok {
+		product.Images = valueToStrings(val)
+	}
+	if val, ok := a.field(endpoint, item, "tags"); ok {
+		product.Tags = valueToStrings(val)
+	}
+	if val, ok := a.field(endpoint, item, "is_active"); ok {
+		product.IsActive = valueToBool(val)
+	}
+	if val, ok := a.field(endpoint, item, "category_id"); ok {
+		product.CategoryID = valueToUint(val)
+	}
+	if val, ok := a.field(endpoint, item, "updated_at"); ok {
+		if parsed, err := time.Parse(time.RFC3339, stringifyValue(val)); err == nil {
+			product.UpdatedAt = parsed
+		}
+	}
+
+	if ep.SKUsPath == "" {
+		stock := -1
+		if val, ok := a.field(endpoint, item, "stock_quantity"); ok {
+			stock, _ = valueToInt(val)
+		}
+		product.SKUs = []UpstreamSKU{{
+			ID:            product.ID,
+			SKUCode:       "DEFAULT",
+			PriceAmount:   product.PriceAmount,
+			StockQuantity: stock,
+			StockStatus:   stockStatusFor(stock),
+			IsActive:      product.IsActive,
+		}}
+		return product, nil
+	}
+
+	skusVal, _ := lookupPath(item, ep.SKUsPath)
+	skus, _ := skusVal.([]interface{})
+	product.SKUs = make([]UpstreamSKU, 0, len(skus))
+	for _, skuItem := range skus {
+		skuField := func(target string) (interface{}, bool) {
+			path := strings.TrimSpace(ep.SKUFields[target])
+			if path == "" {
+				return nil, false
+			}
+			return lookupPath(skuItem, path)
+		}
+		sku := UpstreamSKU{StockQuantity: -1, IsActive: true}
+		if val, ok := skuField("id"); ok {
+			sku.ID = valueToUint(val)
+		}
+		if val, ok := skuField("sku_code"); ok {
+			sku.SKUCode = stringifyValue(val)
+		}
+		if val, ok := skuField("price_amount"); ok {
+			sku.PriceAmount = stringifyValue(val)
+		}
+		if val, ok := skuField("stock_quantity"); ok {
+			sku.StockQuantity, _ = valueToInt(val)
+		}
+		if val, ok := skuField("is_active"); ok {
+			sku.IsActive = valueToBool(val)
+		}
+		if val, ok := skuField("spec_values"); ok {
+			sku.SpecValues = valueToLocalized(val)
+		}
+		if sku.ID == 0 {
+			return nil, fmt.Errorf("sku id not found in response")
+		}
+		if sku.SKUCode == "" {
+			sku.SKUCode = fmt.Sprintf("SKU-%d", sku.ID)
+		}
+		if sku.PriceAmount == "" {
+			sku.PriceAmount = product.PriceAmount
+		}
+		sku.StockStatus = stockStatusFor(sku.StockQuantity)
+		product.SKUs = append(product.SKUs, sku)
+	}
+	return product, nil
+}
+
+// mapCreateOrder 映射下单响应；未配置 ok 字段时以返回了订单ID或订单号视为成功。
+// 非数字的订单ID（如 UUID）无法作为 OrderID，未配置订单号时作为 OrderNo 保留。
+func (a *GenericRESTAdapter) mapCreateOrder(data interface{}) *CreateUpstreamOrderResp {
+	root, _ := lookupPath(data, a.template.Endpoints[RESTEndpointCreateOrder].ItemsPath)
+	orderID, _ := a.field(RESTEndpointCreateOrder, root, "order_id")
+	resp := &CreateUpstreamOrderResp{
+		OrderID:      valueToUint(orderID),
+		OrderNo:      a.fieldString(RESTEndpointCreateOrder, root, "order_no"),
+		Status:       a.mapStatus(a.fieldString(RESTEndpointCreateOrder, root, "status")),
+		Amount:       a.fieldString(RESTEndpointCreateOrder, root, "amount"),
+		Currency:     a.fieldString(RESTEndpointCreateOrder, root, "currency"),
+		ErrorCode:    a.fieldString(RESTEndpointCreateOrder, root, "error_code"),
+		ErrorMessage: a.fieldString(RESTEndpointCreateOrder, root, "error_message"),
+	}
+	if resp.OrderID == 0 && resp.OrderNo == "" {
+		resp.OrderNo = strings.TrimSpace(stringifyValue(orderID))
+	}
+	hasID := resp.OrderID > 0 || resp.OrderNo != ""
+	if val, ok := a.field(RESTEndpointCreateOrder, root, "ok"); ok {
+		resp.OK = valueToBool(val)
+	} else {
+		resp.OK = hasID
+	}
+	if resp.OK && !hasID {
+		resp.OK = false
+		resp.ErrorCode = "missing_order_id"
+		resp.ErrorMessage = "order_id not found in response"
+	}
+	return resp
+}
+
+func (a *GenericRESTAdapter) mapOrderDetail(data interface{}) (*UpstreamOrderDetail, error) {
+	root, ok := lookupPath(data, a.template.Endpoints[RESTEndpointGetOrder].ItemsPath)
+	if !ok {
+		return nil, fmt.Errorf("order not found in response")
+	}
+	orderID, _ := a.field(RESTEndpointGetOrder, root, "order_id")
+	detail := &UpstreamOrderDetail{
+		OrderID:        valueToUint(orderID),
+		OrderNo:        a.fieldString(RESTEndpointGetOrder, root, "order_no"),
+		Status:         a.mapStatus(a.fieldString(RESTEndpointGetOrder, root, "status")),
+		Amount:         a.fieldString(RESTEndpointGetOrder, root, "amount"),
+		RefundedAmount: a.fieldString(RESTEndpointGetOrder, root, "refunded_amount"),
+		Currency:       a.fieldString(RESTEndpointGetOrder, root, "currency"),
+	}
+	if payload := a.fieldString(RESTEndpointGetOrder, root, "fulfillment_payload"); payload != "" {
+		fulfillment := &UpstreamFulfillment{
+			Type:    defaultString(a.fieldString(RESTEndpointGetOrder, root, "fulfillment_type"), "auto"),
+			Status:  detail.Status,
+			Payload: payload,
+		}
+		if deliveredAt, err := time.Parse(time.RFC3339, a.fieldString(RESTEndpointGetOrder, root, "delivered_at")); err == nil {
+			fulfillment.DeliveredAt = &deliveredAt
+		}
+		detail.Fulfillment = fulfillment
+	}
+	return detail, nil
+}
+
+func stockStatusFor(quantity int) string {
+	switch {
+	case quantity < 0:
+		return constants.ProductStockStatusUnlimited
+	case quantity == 0:
+		return constants.ProductStockStatusOutOfStock
+	default:
+		return constants.ProductStockStatusInStock
+	}
+}
+
+func defaultString(value, fallback string) string {
+	if strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	return value
+}
+
+func truncateRaw(raw string) string {
+	if len(raw) <= restDryRunRawLimit {
+		return raw
+	}
+	return raw[:restDryRunRawLimit] + "...(truncated)"
+}
@@ -0,0 +1,224 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dujiao-next/internal/constants"
+	"github.com/dujiao-next/internal/models"
+)
+
+func genericRESTTestTemplate() models.JSON {
+	raw := `{
+		"auth": {"type": "header", "key_header": "X-Token"},
+		"status_map": {"SUCCESS": "delivered", "PROCESSING": "paid"},
+		"endpoints": {
+			"list_products": {
+				"path": "/goods", "query": {"p": "{{page}}", "size": "{{page_size}}"},
+				"items_path": "data.list", "total_path": "data.total",
+				"fields": {"id": "goods_id", "title": "name", "price_amount": "price", "stock_quantity": "stock"}
+			},
+			"get_product": {
+				"path": "/goods/{{product_id}}", "items_path": "$.data",
+				"fields": {"id": "goods_id", "title": "name", "price_amount": "price", "is_active": "on_sale"},
+				"skus_path": "specs",
+				"sku_fields": {"id": "spec_id", "price_amount": "price", "stock_quantity": "stock"}
+			},
+			"create_order": {
+				"method": "POST", "path": "/orders",
+				"body": {"spec": "{{sku_id}}", "num": "{{quantity}}", "out_no": "{{downstream_order_no}}"},
+				"fields": {"ok": "code", "order_id": "data.id", "status": "data.state", "amount": "data.total"}
+			},
+			"get_order": {
+				"path": "/orders/{{order_id}}", "items_path": "data",
+				"fields": {"order_id": "id", "status": "state", "fulfillment_payload": "cards"}
+			}
+		}
+	}`
+	var tpl models.JSON
+	if err := json.Unmarshal([]byte(raw), &tpl); err != nil {
+		panic(err)
+	}
+	return tpl
+}
+
+func TestGenericRESTAdapterMapsTemplate(t *testing.T) {
+	var createBody map[string]interface{}
+	orderCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/goods":
+			if r.URL.Query().Get("p") != "1" || r.URL.Query().Get("size") != "5" {
+				t.Errorf("unexpected list query: %s", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(`{"data":{"total":12,"list":[{"goods_id":"31","name":"月卡","price":9.9,"stock":3}]}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/goods/31":
+			_, _ = w.Write([]byte(`{"data":{"goods_id":31,"name":"月卡","price":"9.90","on_sale":1,"specs":[{"spec_id":311,"price":"8.50","stock":0},{"spec_id":312,"stock":5}]}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/orders":
+			orderCalls++
+			_ = json.NewDecoder(r.Body).Decode(&createBody)
+			_, _ = w.Write([]byte(`{"code":1,"data":{"id":5001,"state":"PROCESSING","total":"17.00"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/orders/5001":
+			_, _ = w.Write([]byte(`{"data":{"id":5001,"state":"SUCCESS","cards":["AAA","BBB"]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	adapter, err := NewAdapter(&models.SiteConnection{
+		BaseURL:      server.URL,
+		ApiKey:       "key",
+		Protocol:     constants.ConnectionProtocolGenericREST,
+		RestTemplate: genericRESTTestTemplate(),
+	}, t.TempDir())
+	if err != nil {
+		t.Fatalf("create adapter failed: %v", err)
+	}
+	ctx := context.Background()
+
+	list, err := adapter.ListProducts(ctx, ListProductsOpts{Page: 1, PageSize: 5})
+	if err != nil {
+		t.Fatalf("list products failed: %v", err)
+	}
+	if list.Total != 12 || len(list.Items) != 1 || list.Items[0].ID != 31 || list.Items[0].PriceAmount != "9.9" {
+		t.Fatalf("unexpected product list: %+v", list)
+	}
+	if sku := list.Items[0].SKUs; len(sku) != 1 || sku[0].ID != 31 || sku[0].StockQuantity != 3 {
+		t.Fatalf("expected synthesized single sku, got %+v", sku)
+	}
+
+	product, err := adapter.GetProduct(ctx, 31)
+	if err != nil {
+		t.Fatalf("get product failed: %v", err)
+	}
+	if product.Title["zh-CN"] != "月卡" || !product.IsActive || len(product.SKUs) != 2 {
+		t.Fatalf("unexpected product: %+v", product)
+	}
+	if product.SKUs[0].StockStatus != constants.ProductStockStatusOutOfStock || product.SKUs[1].PriceAmount != "9.90" || product.SKUs[1].StockQuantity != 5 {
+		t.Fatalf("unexpected skus: %+v", product.SKUs)
+	}
+
+	resp, err := adapter.CreateOrder(ctx, CreateUpstreamOrderReq{SKUID: 312, Quantity: 2, DownstreamOrderNo: "PO-1"})
+	if err != nil {
+		t.Fatalf("create order failed: %v", err)
+	}
+	if !resp.OK || resp.OrderID != 5001 || resp.Status != "paid" || resp.Amount != "17.00" {
+		t.Fatalf("unexpected create order resp: %+v", resp)
+	}
+	if createBody["spec"] != float64(312) || createBody["num"] != float64(2) || createBody["out_no"] != "PO-1" {
+		t.Fatalf("unexpected create order body: %+v", createBody)
+	}
+
+	detail, err := adapter.GetOrder(ctx, 5001)
+	if err != nil {
+		t.Fatalf("get order failed: %v", err)
+	}
+	if detail.Status != "delivered" || detail.Fulfillment == nil || detail.Fulfillment.Payload != "AAA\nBBB" {
+		t.Fatalf("unexpected order detail: %+v", detail)
+	}
+	if err := adapter.CancelOrder(ctx, 5001); err == nil {
+		t.Fatalf("expected cancel error without cancel_order endpoint")
+	}
+
+	// 试运行默认不真实下单，仅预览请求
+	steps := adapter.(*GenericRESTAdapter).DryRun(ctx, RESTDryRunInput{OrderID: 5001})
+	byName := make(map[string]RESTDryRunStep, len(steps))
+	for _, step := range steps {
+		byName[step.Endpoint] = step
+	}
+	if orderCalls != 1 {
+		t.Fatalf("dry run should not create orders, calls=%d", orderCalls)
+	}
+	if step := byName[RESTEndpointCreateOrder]; !step.Skipped || !strings.Contains(step.RequestBody, `"spec":311`) {
+		t.Fatalf("unexpected create_order preview: %+v", step)
+	}
+	if step := byName[RESTEndpointGetProduct]; step.Error != "" || step.StatusCode != http.StatusOK || step.Mapped == nil {
+		t.Fatalf("unexpected get_product step: %+v", step)
+	}
+	if step := byName[RESTEndpointGetOrder]; step.Error != "" || step.Mapped.(*UpstreamOrderDetail).Status != "delivered" {
+		t.Fatalf("unexpected get_order step: %+v", step)
+	}
+	if step := byName[RESTEndpointPing]; !step.Skipped || step.SkipReason != "not_configured" {
+		t.Fatalf("unexpected ping step: %+v", step)
+	}
+}
+
+func TestParseRESTTemplateValidation(t *testing.T) {
+	tpl := genericRESTTestTemplate()
+	endpoints := tpl["endpoints"].(map[string]interface{})
+	delete(endpoints, RESTEndpointGetOrder)
+	if _, err := ParseRESTTemplate(tpl); err == nil || !strings.Contains(err.Error(), RESTEndpointGetOrder) {
+		t.Fatalf("expected missing get_order error, got %v", err)
+	}
+
+	tpl = genericRESTTestTemplate()
+	tpl["auth"] = map[string]interface{}{"type": "oauth"}
+	if _, err := ParseRESTTemplate(tpl); err == nil {
+		t.Fatalf("expected unsupported auth error")
+	}
+	if _, err := ParseRESTTemplate(nil); err == nil {
+		t.Fatalf("expected empty template error")
+	}
+}
+
+func TestGenericRESTAdapterAcceptsStringOrderID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Header.Get("X-Case") {
+		case "missing":
+			_, _ = w.Write([]byte(`{"code":1,"data":{"state":"PROCESSING"}}`))
+		case "oversized":
+			_, _ = w.Write([]byte(`{"code":1,"data":{"id":"` + strings.Repeat("x", restMaxResponseBytes) + `"}}`))
+		default:
+			_, _ = w.Write([]byte(`{"code":1,"data":{"id":"7f3c9a2e-uuid","state":"PROCESSING"}}`))
+		}
+	}))
+	defer server.Close()
+
+	adapter, err := NewAdapter(&models.SiteConnection{
+		BaseURL:      server.URL,
+		ApiKey:       "key",
+		Protocol:     constants.ConnectionProtocolGenericREST,
+		RestTemplate: genericRESTTestTemplate(),
+	}, t.TempDir())
+	if err != nil {
+		t.Fatalf("create adapter failed: %v", err)
+	}
+	rest := adapter.(*GenericRESTAdapter)
+	ctx := context.Background()
+
+	resp, err := adapter.CreateOrder(ctx, CreateUpstreamOrderReq{SKUID: 312, Quantity: 1, DownstreamOrderNo: "PO-2"})
+	if err != nil {
+		t.Fatalf("create order failed: %v", err)
+	}
+	if !resp.OK || resp.OrderID != 0 || resp.OrderNo != "7f3c9a2e-uuid" {
+		t.Fatalf("expected string order id kept as order no, got %+v", resp)
+	}
+
+	create := rest.template.Endpoints[RESTEndpointCreateOrder]
+	for _, tc := range []string{"missing", "oversized"} {
+		create.Headers = map[string]string{"X-Case": tc}
+		rest.template.Endpoints[RESTEndpointCreateOrder] = create
+		resp, err := adapter.CreateOrder(ctx, CreateUpstreamOrderReq{SKUID: 312, Quantity: 1, DownstreamOrderNo: "PO-3"})
+		switch tc {
+		case "missing":
+			if err != nil || resp.OK || resp.ErrorCode != "missing_order_id" {
+				t.Fatalf("expected missing order id failure, got %+v err=%v", resp, err)
+			}
+		case "oversized":
+			if err == nil || !strings.Contains(err.Error(), "exceeds") {
+				t.Fatalf("expected oversized response error, got %+v err=%v", resp, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package upstream
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dujiao-next/internal/constants"
+)
+
+// openAPIOperation 协议接口描述，请求/响应体由 Go 类型反射生成 schema
+type openAPIOperation struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Scope       string
+	Feature     string
+	Params      []openAPIParam
+	Request     interface{}
+	Response    interface{}
+	Errors      []string
+}
+
+type openAPIParam struct {
+	Name     string
+	In       string
+	Type     string
+	Format   string
+	Required bool
+	Desc     string
+}
+
+var orderIDParam = openAPIParam{Name: "id", In: "path", Type: "integer", Required: true, Desc: "upstream order id"}
+
+var authErrors = []string{
+	ErrorCodeMissingAuthHeaders, ErrorCodeInvalidTimestamp, ErrorCodeTimestampExpired, ErrorCodeInvalidApiKey,
+	ErrorCodeUserDisabled, ErrorCodeInvalidSignature, ErrorCodeIPNotAllowed, ErrorCodeRateLimitExceeded,
+}
+
+// openAPIOperations 协议接口表
+var openAPIOperations = []openAPIOperation{
+	{
+		Method: "post", Path: PathPing, OperationID: "ping",
+		Summary:  "Verify credentials and read protocol version, wallet balance and member level",
+		Response: PingResponse{},
+	},
+	{
+		Method: "get", Path: PathCategories, OperationID: "listCategories",
+		Summary: "List categories", Scope: constants.ApiScopeCatalogRead,
+		Response: CategoryListResponse{}, Errors: []string{ErrorCodeInsufficientScope},
+	},
+	{
+		Method: "get", Path: PathProducts, OperationID: "listProducts",
+		Summary: "List products on sale with the caller's effective prices", Scope: constants.ApiScopeCatalogRead,
+		Params: []openAPIParam{
+			{Name: "page", In: "query", Type: "integer", Desc: "page number, default 1"},
+			{Name: "page_size", In: "query", Type: "integer", Desc: "page size, 1-50, default 20"},
+			{Name: "updated_after", In: "query", Type: "string", Format: "date-time", Desc: "RFC3339; only products updated after this time"},
+		},
+		Response: ProductListResponse{}, Errors: []string{ErrorCodeInsufficientScope},
+	},
+	{
+		Method: "get", Path: PathProduct, OperationID: "getProduct",
+		Summary: "Get a product", Scope: constants.ApiScopeCatalogRead,
+		Params:   []openAPIParam{{Name: "id", In: "path", Type: "integer", Required: true, Desc: "product id"}},
+		Response: ProductResponse{}, Errors: []string{ErrorCodeInsufficientScope, ErrorCodeProductNotFound, ErrorCodeProductUnavailable},
+	},
+	{
+		Method: "post", Path: PathOrders, OperationID: "createOrder",
+		Summary: "Create an order paid from the wallet; repeated downstream_order_no returns the existing order",
+		Scope:   constants.ApiScopeOrderCreate,
+		Params: []openAPIParam{
+			{Name: HeaderIdempotencyKey, In: "header", Type: "string", Desc: "optional, 1-128 characters; retries with the same key replay the first response (protocol 1.1+)"},
+		},
+		Request: CreateUpstreamOrderReq{}, Response: CreateUpstreamOrderResp{},
+		Errors: []string{
+			ErrorCodeInsufficientScope, ErrorCodeQuotaExceeded, ErrorCodeBadRequest, ErrorCodeInvalidCallbackURL,
+			ErrorCodeSKUUnavailable, ErrorCodeProductUnavailable, ErrorCodeInsufficientStock, ErrorCodeInsufficientFunds,
+			ErrorCodePaymentFailed, ErrorCodeIdempotencyInvalid, ErrorCodeIdempotencyReuse, ErrorCodeIdempotencyBusy,
+		},
+	},
+	{
+		Method: "get", Path: PathOrder, OperationID: "getOrder",
+		Summary: "Get an order with fulfillment and refund records", Scope: constants.ApiScopeOrderRead,
+		Params:   []openAPIParam{orderIDParam},
+		Response: OrderDetailResponse{}, Errors: []string{ErrorCodeInsufficientScope, ErrorCodeBadRequest, ErrorCodeOrderNotFound},
+	},
+	{
+		Method: "post", Path: PathOrderCancel, OperationID: "cancelOrder",
+		Summary: "Cancel an unpaid order", Scope: constants.ApiScopeOrderCreate,
+		Params:   []openAPIParam{orderIDParam},
+		Response: CancelOrderResponse{}, Errors: []string{ErrorCodeInsufficientScope, ErrorCodeBadRequest, ErrorCodeOrderNotFound, ErrorCodeCancelNotAllowed},
+	},
+	{
+		Method: "post", Path: PathWebhooks, OperationID: "registerCatalogWebhook",
+		Summary: "Register the catalog change webhook url for this api key", Scope: constants.ApiScopeCatalogRead,
+		Feature: FeatureCatalogWebhooks,
+		Request: CatalogWebhookRequest{}, Response: CatalogWebhookResponse{},
+		Errors: []string{ErrorCodeInsufficientScope, ErrorCodeBadRequest, ErrorCodeInvalidCallbackURL},
+	},
+	{
+		Method: "get", Path: PathWebhooks, OperationID: "getCatalogWebhook",
+		Summary: "Get the registered catalog change webhook", Scope: constants.ApiScopeCatalogRead,
+		Feature:  FeatureCatalogWebhooks,
+		Response: CatalogWebhookResponse{}, Errors: []string{ErrorCodeInsufficientScope, ErrorCodeWebhookNotFound},
+	},
+	{
+		Method: "delete", Path: PathWebhooks, OperationID: "deleteCatalogWebhook",
+		Summary: "Unregister the catalog change webhook", Scope: constants.ApiScopeCatalogRead,
+		Feature:  FeatureCatalogWebhooks,
+		Response: OKResponse{}, Errors: []string{ErrorCodeInsufficientScope, ErrorCodeWebhookNotFound},
+	},
+}
+
+const openAPIDescription = "Server-to-server API a Dujiao-Next site exposes to downstream sites.\n\n" +
+	"Every request carries Dujiao-Next-Api-Key, Dujiao-Next-Timestamp (unix seconds, within 60s of server time) " +
+	"and Dujiao-Next-Signature = hex(HMAC-SHA256(api_secret, METHOD + \"\\n\" + PATH + \"\\n\" + TIMESTAMP + \"\\n\" + hex(MD5(BODY)))). " +
+	"PATH excludes the query string; BODY is the raw request body (empty for GET, MD5 of empty input).\n\n" +
+	"Errors use {ok:false, error_code, error_message}; see x-error-codes. " +
+	"Callbacks to the downstream site are signed the same way with PATH " + PathCallback + "."
+
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  map[string]interface{}
+)
+
+// OpenAPIDocument 返回上游协议的 OpenAPI 3.1 文档（由协议 DTO 类型反射生成，版本号为协议版本）
+func OpenAPIDocument() map[string]interface{} {
+	openAPIOnce.Do(func() {
+		openAPIDoc = buildOpenAPIDocument()
+	})
+	return openAPIDoc
+}
+
+func buildOpenAPIDocument() map[string]interface{} {
+	gen := &schemaGenerator{components: map[string]interface{}{}}
+
+	paths := map[string]interface{}{}
+	for _, op := range openAPIOperations {
+		item, _ := paths[op.Path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[op.Path] = item
+		}
+		item[op.Method] = gen.operation(op)
+	}
+
+	errorCodes := make([]interface{}, 0, len(ProtocolErrors))
+	codes := make([]interface{}, 0, len(ProtocolErrors))
+	for _, item := range ProtocolErrors {
+		errorCodes = append(errorCodes, map[string]interface{}{
+			"code":        item.Code,
+			"status":      item.Status,
+			"description": item.Description,
+		})
+		codes = append(codes, item.Code)
+	}
+	features := map[string]interface{}{}
+	for feature, minVersion := range protocolFeatures {
+		features[feature] = minVersion
+	}
+
+	callbackSchema := func(event interface{}, events ...string) map[string]interface{} {
+		values := make([]interface{}, 0, len(events))
+		for _, e := range events {
+			values = append(values, e)
+		}
+		return map[string]interface{}{
+			"post": map[string]interface{}{
+				"summary":  "Signed POST to " + PathCallback + " on the downstream site (events: " + strings.Join(events, ", ") + ")",
+				"security": []interface{}{map[string]interface{}{"ApiKey": []interface{}{}, "Timestamp": []interface{}{}, "Signature": []interface{}{}}},
+				"requestBody": map[string]interface{}{
+					"required": true,
+					"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": gen.schema(reflect.TypeOf(event))}},
+				},
+				"responses": map[string]interface{}{"200": map[string]interface{}{"description": "acknowledged with {ok:true}"}},
+				"x-events":  values,
+			},
+		}
+	}
+	webhooks := map[string]interface{}{
+		"orderCallback":   callbackSchema(OrderCallbackEvent{}, constants.CallbackEventOrderStatusChanged, constants.CallbackEventOrderFulfilled),
+		"catalogCallback": callbackSchema(CatalogCallbackEvent{}, constants.CatalogEventProductChanged),
+	}
+
+	// ErrorResponse 的 error_code 限定为协议错误码
+	gen.schema(reflect.TypeOf(ErrorResponse{}))
+	if errSchema, ok := gen.components["ErrorResponse"].(map[string]interface{}); ok {
+		if props, ok := errSchema["properties"].(map[string]interface{}); ok {
+			props["error_code"] = map[string]interface{}{"type": "string", "enum": codes}
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":       "Dujiao-Next Upstream API",
+			"version":     ProtocolVersion,
+			"description": openAPIDescription,
+		},
+		"paths":    paths,
+		"webhooks": webhooks,
+		"components": map[string]interface{}{
+			"schemas": gen.components,
+			"securitySchemes": map[string]interface{}{
+				"ApiKey":    map[string]interface{}{"type": "apiKey", "in": "header", "name": HeaderApiKey},
+				"Timestamp": map[string]interface{}{"type": "apiKey", "in": "header", "name": HeaderTimestamp},
+				"Signature": map[string]interface{}{"type": "apiKey", "in": "header", "name": HeaderSignature},
+			},
+		},
+		"security":              []interface{}{map[string]interface{}{"ApiKey": []interface{}{}, "Timestamp": []interface{}{}, "Signature": []interface{}{}}},
+		"x-error-codes":         errorCodes,
+		"x-protocol-features":   features,
+		"x-max-timestamp-skew":  MaxTimestampSkew,
+		"x-signature-algorithm": "HMAC-SHA256(secret, METHOD\\nPATH\\nTIMESTAMP\\nMD5(BODY))",
+	}
+}
+
+func (g *schemaGenerator) operation(op openAPIOperation) map[string]interface{} {
+	result := map[string]interface{}{
+		"operationId": op.OperationID,
+		"summary":     op.Summary,
+	}
+	if op.Scope != "" {
+		result["x-required-scope"] = op.Scope
+	}
+	if op.Feature != "" {
+		result["x-protocol-feature"] = op.Feature
+		result["x-min-protocol-version"] = protocolFeatures[op.Feature]
+	}
+	if len(op.Params) > 0 {
+		params := make([]interface{}, 0, len(op.Params))
+		for _, p := range op.Params {
+			schema := map[string]interface{}{"type": p.Type}
+			if p.Format != "" {
+				schema["format"] = p.Format
+			}
+			params = append(params, map[string]interface{}{
+				"name":        p.Name,
+				"in":          p.In,
+				"required":    p.Required,
+				"description": p.Desc,
+				"schema":      schema,
+			})
+		}
+		result["parameters"] = params
+	}
+	if op.Request != nil {
+		result["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(op.Request))}},
+		}
+	}
+
+	errs := append(append([]string{}, authErrors...), op.Errors...)
+	sort.Strings(errs)
+	errValues := make([]interface{}, 0, len(errs))
+	for _, code := range errs {
+		errValues = append(errValues, code)
+	}
+	result["x-error-codes"] = errValues
+	result["responses"] = map[string]interface{}{
+		"200": map[string]interface{}{
+			"description": "success",
+			"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(op.Response))}},
+		},
+		"default": map[string]interface{}{
+			"description": "error",
+			"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(ErrorResponse{}))}},
+		},
+	}
+	return result
+}
+
+// schemaGenerator 将 Go 类型转换为 JSON Schema，具名结构体登记为 components
+type schemaGenerator struct {
+	components map[string]interface{}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		inner := g.schema(t.Elem())
+		return map[string]interface{}{"anyOf": []interface{}{inner, map[string]interface{}{"type": "null"}}}
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": []interface{}{"array", "null"}, "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": []interface{}{"object", "null"}}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Struct:
+		name := t.Name()
+		if name == "" {
+			return g.structSchema(t)
+		}
+		if _, ok := g.components[name]; !ok {
+			g.components[name] = map[string]interface{}{} // 占位，防止递归
+			g.components[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := make([]string, 0)
+	g.collectFields(t, properties, &required)
+	sort.Strings(required)
+	result := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		values := make([]interface{}, 0, len(required))
+		for _, name := range required {
+			values = append(values, name)
+		}
+		result["required"] = values
+	}
+	return result
+}
+
+// collectFields 按 encoding/json 规则收集字段：匿名结构体展开，未标记 omitempty 的字段视为必填
+func (g *schemaGenerator) collectFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.collectFields(field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schema(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
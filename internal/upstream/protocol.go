@@ -0,0 +1,254 @@
+package upstream
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dujiao-next/internal/models"
+)
+
+// ProtocolVersion 本站实现的 Dujiao-Next 上游协议版本（major.minor），通过 ping 返回给下游
+const ProtocolVersion = "1.1"
+
+// 协议能力（下游根据 ping 返回的 protocol_version 判断上游是否支持）
+const (
+	FeatureCatalogWebhooks = "catalog_webhooks" // 商品目录变更推送（/webhooks）
+	FeatureIdempotencyKey  = "idempotency_key"  // 下单支持 Idempotency-Key 请求头
+	FeatureKeyScopes       = "key_scopes"       // 密钥权限范围、IP 白名单与每日配额
+)
+
+// protocolFeatures 各能力的最低协议版本
+var protocolFeatures = map[string]string{
+	FeatureCatalogWebhooks: "1.1",
+	FeatureIdempotencyKey:  "1.1",
+	FeatureKeyScopes:       "1.1",
+}
+
+// 协议路径（签名使用不含 query string 的路径）
+const (
+	PathPing        = "/api/v1/upstream/ping"
+	PathCategories  = "/api/v1/upstream/categories"
+	PathProducts    = "/api/v1/upstream/products"
+	PathProduct     = "/api/v1/upstream/products/{id}"
+	PathOrders      = "/api/v1/upstream/orders"
+	PathOrder       = "/api/v1/upstream/orders/{id}"
+	PathOrderCancel = "/api/v1/upstream/orders/{id}/cancel"
+	PathWebhooks    = "/api/v1/upstream/webhooks"
+	PathCallback    = "/api/v1/upstream/callback"
+	PathOpenAPI     = "/api/v1/upstream/openapi.json"
+)
+
+// HeaderIdempotencyKey 下单幂等键请求头
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// 协议错误码（响应体 error_code）
+const (
+	ErrorCodeMissingAuthHeaders = "missing_auth_headers"
+	ErrorCodeInvalidTimestamp   = "invalid_timestamp"
+	ErrorCodeTimestampExpired   = "timestamp_expired"
+	ErrorCodeInvalidApiKey      = "invalid_api_key"
+	ErrorCodeUserDisabled       = "user_disabled"
+	ErrorCodeInvalidSignature   = "invalid_signature"
+	ErrorCodeIPNotAllowed       = "ip_not_allowed"
+	ErrorCodeInsufficientScope  = "insufficient_scope"
+	ErrorCodeRateLimitExceeded  = "rate_limit_exceeded"
+	ErrorCodeQuotaExceeded      = "quota_exceeded"
+	ErrorCodeUnauthorized       = "unauthorized"
+	ErrorCodeBadRequest         = "bad_request"
+	ErrorCodeInternal           = "internal_error"
+	ErrorCodeProductNotFound    = "product_not_found"
+	ErrorCodeProductUnavailable = "product_unavailable"
+	ErrorCodeSKUUnavailable     = "sku_unavailable"
+	ErrorCodeInvalidCallbackURL = "invalid_callback_url"
+	ErrorCodeInsufficientStock  = "insufficient_stock"
+	ErrorCodeInsufficientFunds  = "insufficient_balance"
+	ErrorCodePaymentFailed      = "payment_failed"
+	ErrorCodeOrderNotFound      = "order_not_found"
+	ErrorCodeCancelNotAllowed   = "cancel_not_allowed"
+	ErrorCodeWebhookNotFound    = "webhook_not_found"
+	ErrorCodeIdempotencyInvalid = "idempotency_key_invalid"
+	ErrorCodeIdempotencyReuse   = "idempotency_key_conflict"
+	ErrorCodeIdempotencyBusy    = "idempotency_in_progress"
+)
+
+// ProtocolError 错误码说明
+type ProtocolError struct {
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Description string `json:"description"`
+}
+
+// ProtocolErrors 协议错误码表（写入 OpenAPI 文档并由一致性测试校验）
+var ProtocolErrors = []ProtocolError{
+	{ErrorCodeMissingAuthHeaders, 401, "Dujiao-Next-Api-Key, Dujiao-Next-Timestamp or Dujiao-Next-Signature header is missing"},
+	{ErrorCodeInvalidTimestamp, 401, "Dujiao-Next-Timestamp is not a unix timestamp in seconds"},
+	{ErrorCodeTimestampExpired, 401, "timestamp differs from server time by more than 60 seconds"},
+	{ErrorCodeInvalidApiKey, 403, "api key is unknown, not approved or disabled"},
+	{ErrorCodeUserDisabled, 403, "the account owning the api key is disabled"},
+	{ErrorCodeInvalidSignature, 401, "signature does not match HMAC-SHA256(secret, method\\npath\\ntimestamp\\nmd5(body))"},
+	{ErrorCodeIPNotAllowed, 403, "client ip is not in the api key allowlist"},
+	{ErrorCodeInsufficientScope, 403, "api key lacks the scope required by the operation"},
+	{ErrorCodeRateLimitExceeded, 429, "per-key request rate limit exceeded, see Retry-After"},
+	{ErrorCodeQuotaExceeded, 429, "per-key daily order count or amount quota exceeded"},
+	{ErrorCodeUnauthorized, 401, "credentials could not be resolved"},
+	{ErrorCodeBadRequest, 400, "request parameters or body are invalid"},
+	{ErrorCodeInternal, 500, "unexpected server error"},
+	{ErrorCodeProductNotFound, 404, "product does not exist"},
+	{ErrorCodeProductUnavailable, 404, "product is not on sale (400 when ordering)"},
+	{ErrorCodeSKUUnavailable, 400, "sku does not exist or is not on sale"},
+	{ErrorCodeInvalidCallbackURL, 400, "callback_url is not a public http(s) url"},
+	{ErrorCodeInsufficientStock, 409, "stock is insufficient"},
+	{ErrorCodeInsufficientFunds, 402, "wallet balance is insufficient"},
+	{ErrorCodePaymentFailed, 200, "order was created but wallet payment failed; the order is canceled (ok=false)"},
+	{ErrorCodeOrderNotFound, 404, "order does not exist or belongs to another account"},
+	{ErrorCodeCancelNotAllowed, 409, "order cannot be canceled in its current status"},
+	{ErrorCodeWebhookNotFound, 404, "no catalog webhook registered for the api key"},
+	{ErrorCodeIdempotencyInvalid, 400, "Idempotency-Key is empty or longer than 128 characters"},
+	{ErrorCodeIdempotencyReuse, 409, "Idempotency-Key was already used with a different request"},
+	{ErrorCodeIdempotencyBusy, 409, "a request with the same Idempotency-Key is still being processed"},
+}
+
+// SupportsFeature 判断协议版本是否支持指定能力；无法解析的版本（如 generic-rest）视为不支持
+func SupportsFeature(version, feature string) bool {
+	minVersion, ok := protocolFeatures[feature]
+	if !ok {
+		return false
+	}
+	return CompareProtocolVersion(version, minVersion) >= 0
+}
+
+// ProtocolFeatures 返回协议版本支持的能力列表
+func ProtocolFeatures(version string) []string {
+	features := make([]string, 0, len(protocolFeatures))
+	for _, feature := range []string{FeatureCatalogWebhooks, FeatureIdempotencyKey, FeatureKeyScopes} {
+		if SupportsFeature(version, feature) {
+			features = append(features, feature)
+		}
+	}
+	return features
+}
+
+// CompareProtocolVersion 比较 major.minor 版本号；无法解析的版本视为低于任何有效版本
+func CompareProtocolVersion(a, b string) int {
+	aMajor, aMinor, aOK := parseProtocolVersion(a)
+	bMajor, bMinor, bOK := parseProtocolVersion(b)
+	switch {
+	case !aOK && !bOK:
+		return 0
+	case !aOK:
+		return -1
+	case !bOK:
+		return 1
+	case aMajor != bMajor:
+		if aMajor < bMajor {
+			return -1
+		}
+		return 1
+	case aMinor != bMinor:
+		if aMinor < bMinor {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+func parseProtocolVersion(version string) (int, int, bool) {
+	parts := strings.SplitN(strings.TrimSpace(version), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil || major < 0 {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil || minor < 0 {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// ---- 协议响应体 ----
+
+// ErrorResponse 错误响应
+type ErrorResponse struct {
+	OK           bool   `json:"ok"`
+	ErrorCode    string `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+}
+
+// PingResponse ping 响应
+type PingResponse struct {
+	OK bool `json:"ok"`
+	PingResult
+}
+
+// CategoryListResponse 分类列表响应
+type CategoryListResponse struct {
+	OK         bool               `json:"ok"`
+	Categories []UpstreamCategory `json:"categories"`
+}
+
+// ProductListResponse 商品列表响应
+type ProductListResponse struct {
+	OK       bool              `json:"ok"`
+	Items    []UpstreamProduct `json:"items"`
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+}
+
+// ProductResponse 商品详情响应
+type ProductResponse struct {
+	OK      bool            `json:"ok"`
+	Product UpstreamProduct `json:"product"`
+}
+
+// OrderDetailResponse 订单详情响应
+type OrderDetailResponse struct {
+	OK bool `json:"ok"`
+	UpstreamOrderDetail
+}
+
+// CancelOrderResponse 取消订单响应
+type CancelOrderResponse struct {
+	OK      bool   `json:"ok"`
+	OrderID uint   `json:"order_id"`
+	OrderNo string `json:"order_no"`
+	Status  string `json:"status"`
+}
+
+// CatalogWebhookRequest 注册目录变更推送请求
+type CatalogWebhookRequest struct {
+	URL string `json:"url"`
+}
+
+// CatalogWebhookResponse 目录变更推送注册信息响应
+type CatalogWebhookResponse struct {
+	OK      bool                  `json:"ok"`
+	Webhook models.CatalogWebhook `json:"webhook"`
+}
+
+// OKResponse 仅含 ok 的响应
+type OKResponse struct {
+	OK bool `json:"ok"`
+}
+
+// OrderCallbackEvent 订单状态回调（上游 POST 到下单时的 callback_url，使用同一密钥签名）
+type OrderCallbackEvent struct {
+	Event             string               `json:"event"`
+	OrderID           uint                 `json:"order_id"`
+	OrderNo           string               `json:"order_no"`
+	DownstreamOrderNo string               `json:"downstream_order_no"`
+	Status            string               `json:"status"`
+	Fulfillment       *UpstreamFulfillment `json:"fulfillment,omitempty"`
+	Timestamp         int64                `json:"timestamp"`
+}
+
+// CatalogCallbackEvent 商品目录变更推送（上游 POST 到注册的 webhook 地址）
+type CatalogCallbackEvent struct {
+	Event     string           `json:"event"`
+	Product   *UpstreamProduct `json:"product"`
+	Timestamp int64            `json:"timestamp"`
+}
@@ -0,0 +1,39 @@
+package upstream
+
+import "testing"
+
+func TestCompareProtocolVersion(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		expect int
+	}{
+		{"1.1", "1.0", 1},
+		{"1.0", "1.1", -1},
+		{"1.10", "1.9", 1},
+		{"2.0", "1.9", 1},
+		{" 1.1 ", "1.1", 0},
+		{"1.1.3", "1.1", 0},
+		{"generic-rest", "1.0", -1},
+		{"", "", 0},
+	}
+	for _, tc := range tests {
+		if got := CompareProtocolVersion(tc.a, tc.b); got != tc.expect {
+			t.Fatalf("CompareProtocolVersion(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.expect)
+		}
+	}
+}
+
+func TestSupportsFeature(t *testing.T) {
+	if SupportsFeature("1.0", FeatureIdempotencyKey) {
+		t.Fatalf("1.0 must not support idempotency key")
+	}
+	if !SupportsFeature(ProtocolVersion, FeatureCatalogWebhooks) {
+		t.Fatalf("current protocol must support catalog webhooks")
+	}
+	if SupportsFeature("", FeatureCatalogWebhooks) || SupportsFeature(ProtocolVersion, "unknown") {
+		t.Fatalf("unknown version or feature must not be supported")
+	}
+	if got := ProtocolFeatures("1.0"); len(got) != 0 {
+		t.Fatalf("expected no features for 1.0, got %v", got)
+	}
+}
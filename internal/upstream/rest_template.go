@@ -0,0 +1,329 @@
+package upstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dujiao-next/internal/models"
+)
+
+// 通用 REST 模板的端点名称
+const (
+	RESTEndpointPing           = "ping"
+	RESTEndpointListCategories = "list_categories"
+	RESTEndpointListProducts   = "list_products"
+	RESTEndpointGetProduct     = "get_product"
+	RESTEndpointCreateOrder    = "create_order"
+	RESTEndpointGetOrder       = "get_order"
+	RESTEndpointCancelOrder    = "cancel_order"
+)
+
+// 通用 REST 模板的鉴权方式
+const (
+	RESTAuthNone   = "none"   // 不鉴权
+	RESTAuthHeader = "header" // 固定 header 传 API Key（可选同时传 Secret）
+	RESTAuthHMAC   = "hmac"   // 与 Dujiao-Next 相同的 HMAC-SHA256 签名，header 名可自定义
+	RESTAuthBasic  = "basic"  // HTTP Basic，用户名为 API Key，密码为 Secret
+)
+
+// restRequiredEndpoints 采购链路必须配置的端点
+var restRequiredEndpoints = []string{
+	RESTEndpointListProducts,
+	RESTEndpointGetProduct,
+	RESTEndpointCreateOrder,
+	RESTEndpointGetOrder,
+}
+
+// RESTTemplate 通用 REST 适配器模板
+type RESTTemplate struct {
+	Auth      RESTAuthConfig          `json:"auth"`
+	Endpoints map[string]RESTEndpoint `json:"endpoints"`
+	StatusMap map[string]string       `json:"status_map,omitempty"` // 上游订单状态 → 本地标准状态（paid/delivered/canceled 等）
+}
+
+// RESTAuthConfig 通用 REST 鉴权配置
+type RESTAuthConfig struct {
+	Type            string `json:"type"`                       // none / header / hmac / basic
+	KeyHeader       string `json:"key_header,omitempty"`       // 放置 API Key 的 header
+	SecretHeader    string `json:"secret_header,omitempty"`    // header 模式下放置 Secret 的 header（为空则不发送）
+	TimestampHeader string `json:"timestamp_header,omitempty"` // hmac 模式时间戳 header
+	SignatureHeader string `json:"signature_header,omitempty"` // hmac 模式签名 header
+}
+
+// RESTEndpoint 单个端点的请求与响应映射
+//
+// Path/Query/Headers/Body 中的 {{var}} 会被替换为请求变量；
+// Body 中值恰好为 "{{var}}" 的字段保留变量原始类型（数字仍为数字）。
+// 响应路径使用 a.b[0].c 形式，可带 $. 前缀。
+type RESTEndpoint struct {
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	Query     map[string]string `json:"query,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      models.JSON       `json:"body,omitempty"`
+	ItemsPath string            `json:"items_path,omitempty"` // 列表数组路径；单对象端点表示数据根路径
+	TotalPath string            `json:"total_path,omitempty"` // 列表总数路径
+	SKUsPath  string            `json:"skus_path,omitempty"`  // 商品内 SKU 数组路径（为空按单 SKU 商品处理）
+	Fields    map[string]string `json:"fields,omitempty"`     // 目标字段 → 响应路径
+	SKUFields map[string]string `json:"sku_fields,omitempty"` // SKU 目标字段 → 相对 SKU 元素的路径
+}
+
+// ParseRESTTemplate 从连接配置解析并校验模板
+func ParseRESTTemplate(raw models.JSON) (*RESTTemplate, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("rest template is empty")
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("marshal rest template: %w", err)
+	}
+	var tpl RESTTemplate
+	if err := json.Unmarshal(data, &tpl); err != nil {
+		return nil, fmt.Errorf("parse rest template: %w", err)
+	}
+	if err := tpl.Validate(); err != nil {
+		return nil, err
+	}
+	return &tpl, nil
+}
+
+// Validate 校验模板完整性
+func (t *RESTTemplate) Validate() error {
+	switch strings.TrimSpace(t.Auth.Type) {
+	case "", RESTAuthNone, RESTAuthHeader, RESTAuthHMAC, RESTAuthBasic:
+	default:
+		return fmt.Errorf("unsupported auth type: %s", t.Auth.Type)
+	}
+	for _, name := range restRequiredEndpoints {
+		if _, ok := t.Endpoints[name]; !ok {
+			return fmt.Errorf("endpoint %s is required", name)
+		}
+	}
+	for name, ep := range t.Endpoints {
+		switch name {
+		case RESTEndpointPing, RESTEndpointListCategories, RESTEndpointListProducts, RESTEndpointGetProduct,
+			RESTEndpointCreateOrder, RESTEndpointGetOrder, RESTEndpointCancelOrder:
+		default:
+			return fmt.Errorf("unknown endpoint: %s", name)
+		}
+		if strings.TrimSpace(ep.Path) == "" {
+			return fmt.Errorf("endpoint %s path is required", name)
+		}
+		switch ep.method() {
+		case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			return fmt.Errorf("endpoint %s method %s is not supported", name, ep.Method)
+		}
+	}
+	return nil
+}
+
+func (e RESTEndpoint) method() string {
+	method := strings.ToUpper(strings.TrimSpace(e.Method))
+	if method == "" {
+		return http.MethodGet
+	}
+	return method
+}
+
+// renderTemplateString 替换字符串中的 {{var}} 占位符
+func renderTemplateString(tpl string, vars map[string]interface{}) string {
+	if !strings.Contains(tpl, "{{") {
+		return tpl
+	}
+	for key, val := range vars {
+		tpl = strings.ReplaceAll(tpl, "{{"+key+"}}", stringifyValue(val))
+	}
+	return tpl
+}
+
+// renderTemplateValue 递归替换请求体中的占位符
+func renderTemplateValue(value interface{}, vars map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		trimmed := strings.TrimSpace(v)
+		if strings.HasPrefix(trimmed, "{{") && strings.HasSuffix(trimmed, "}}") && strings.Count(trimmed, "{{") == 1 {
+			if val, ok := vars[strings.TrimSpace(trimmed[2:len(trimmed)-2])]; ok {
+				return val
+			}
+		}
+		return renderTemplateString(v, vars)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = renderTemplateValue(item, vars)
+		}
+		return out
+	case models.JSON:
+		return renderTemplateValue(map[string]interface{}(v), vars)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = renderTemplateValue(item, vars)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// lookupPath 按 a.b[0].c 形式读取 JSON 值
+func lookupPath(data interface{}, path string) (interface{}, bool) {
+	path = strings.TrimSpace(path)
+	path = strings.TrimPrefix(path, "$")
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return data, data != nil
+	}
+	current := data
+	for _, segment := range splitPath(path) {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			val, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			current = val
+		case []interface{}:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil, false
+			}
+			current = node[idx]
+		default:
+			return nil, false
+		}
+	}
+	return current, current != nil
+}
+
+// splitPath 将 a.b[0].c 拆为 [a b 0 c]
+func splitPath(path string) []string {
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+	parts := strings.Split(path, ".")
+	segments := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			segments = append(segments, part)
+		}
+	}
+	return segments
+}
+
+// stringifyValue 将 JSON 值转为字符串（数字不使用科学计数法）
+func stringifyValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, stringifyValue(item))
+		}
+		return strings.Join(items, "\n")
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	}
+}
+
+func valueToUint(value interface{}) uint {
+	switch v := value.(type) {
+	case float64:
+		if v < 0 {
+			return 0
+		}
+		return uint(v)
+	case string:
+		n, _ := strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+		return uint(n)
+	}
+	return 0
+}
+
+func valueToInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		return n, err == nil
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func valueToBool(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "1", "true", "yes", "y", "on", "ok", "success", "active", "enabled":
+			return true
+		}
+	}
+	return false
+}
+
+// valueToLocalized 将字符串或多语言对象转为多语言 JSON
+func valueToLocalized(value interface{}) models.JSON {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return models.JSON(v)
+	case nil:
+		return nil
+	default:
+		text := stringifyValue(v)
+		if text == "" {
+			return nil
+		}
+		return models.JSON{"zh-CN": text, "zh-TW": text, "en": text}
+	}
+}
+
+func valueToStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if text := strings.TrimSpace(stringifyValue(item)); text != "" {
+				items = append(items, text)
+			}
+		}
+		return items
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil
+		}
+		return []string{strings.TrimSpace(v)}
+	}
+	return nil
+}
@@ -45,6 +45,9 @@ func (c *Consumer) Register(mux *asynq.ServeMux) {
 	}
 	mux.HandleFunc(queue.TaskOrderStatusEmail, c.handleOrderStatusEmail)
 	mux.HandleFunc(queue.TaskOrderAutoFulfill, c.handleOrderAutoFulfill)
+	mux.HandleFunc(queue.TaskOrderWebhookFulfill, c.handleOrderWebhookFulfill)
+	mux.HandleFunc(queue.TaskOrderLicenseIssue, c.handleOrderLicenseIssue)
+	mux.HandleFunc(queue.TaskOrderFileDeliver, c.handleOrderFileDeliver)
 	mux.HandleFunc(queue.TaskOrderTimeoutCancel, c.handleOrderTimeoutCancel)
 	mux.HandleFunc(queue.TaskWalletRechargeExpire, c.handleWalletRechargeExpire)
 	mux.HandleFunc(queue.TaskNotificationDispatch, c.handleNotificationDispatch)
@@ -53,10 +56,15 @@ func (c *Consumer) Register(mux *asynq.ServeMux) {
 	mux.HandleFunc(queue.TaskProcurementSubmit, c.handleProcurementSubmit)
 	mux.HandleFunc(queue.TaskProcurementPollStatus, c.handleProcurementPollStatus)
 	mux.HandleFunc(queue.TaskProcurementSyncAccepted, c.handleProcurementSyncAccepted)
+	mux.HandleFunc(queue.TaskCardReplenishRun, c.handleCardReplenishRun)
+	mux.HandleFunc(queue.TaskUpstreamBalanceCheck, c.handleUpstreamBalanceCheck)
+	mux.HandleFunc(queue.TaskWalletLedgerAudit, c.handleWalletLedgerAudit)
 	mux.HandleFunc(queue.TaskDownstreamCallback, c.handleDownstreamCallback)
+	mux.HandleFunc(queue.TaskCatalogWebhookDispatch, c.handleCatalogWebhookDispatch)
 	mux.HandleFunc(queue.TaskReconciliationRun, c.handleReconciliationRun)
 	mux.HandleFunc(queue.TaskBotNotify, c.handleBotNotify)
 	mux.HandleFunc(queue.TaskTelegramBroadcast, c.handleTelegramBroadcast)
+	mux.HandleFunc(queue.TaskIdempotencyPurge, c.handleIdempotencyPurge)
 }
 
 // handleOrderStatusEmail 处理订单状态邮件发送任务。
@@ -124,6 +132,10 @@ func (c *Consumer) handleOrderStatusEmail(_ context.Context, task *asynq.Task) e
 	if status == "" {
 		status = order.Status
 	}
+	if err := c.DeliveryLinkService.MaskOrderPayloads(order); err != nil {
+		logger.Warnw("worker_order_status_email_mask_payload_failed", "order_id", order.ID, "error", err)
+		return fmt.Errorf("mask delivery payload: %w", err)
+	}
 	payloadText := buildOrderFulfillmentEmailPayload(order)
 	siteBrand := service.SiteBrand{}
 	if c.SettingService != nil {
@@ -242,6 +254,102 @@ func (c *Consumer) handleOrderAutoFulfill(_ context.Context, task *asynq.Task) e
 	return nil
 }
 
+// handleOrderWebhookFulfill 处理 Webhook 交付推送任务。
+func (c *Consumer) handleOrderWebhookFulfill(_ context.Context, task *asynq.Task) error {
+	if c == nil || task == nil {
+		logger.Debugw("worker_order_webhook_fulfill_skip_nil", "consumer_nil", c == nil, "task_nil", task == nil)
+		return nil
+	}
+	var payload queue.OrderWebhookFulfillPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		logger.Warnw("worker_order_webhook_fulfill_unmarshal_failed", "error", err)
+		return err
+	}
+	if payload.OrderID == 0 {
+		logger.Debugw("worker_order_webhook_fulfill_skip_invalid_payload", "order_id", payload.OrderID)
+		return nil
+	}
+	if err := c.FulfillmentWebhookService.Execute(payload.OrderID); err != nil {
+		logger.Warnw("worker_order_webhook_fulfill_failed", "order_id", payload.OrderID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// handleOrderLicenseIssue 处理授权码签发交付任务。
+func (c *Consumer) handleOrderLicenseIssue(_ context.Context, task *asynq.Task) error {
+	if c == nil || task == nil {
+		logger.Debugw("worker_order_license_issue_skip_nil", "consumer_nil", c == nil, "task_nil", task == nil)
+		return nil
+	}
+	var payload queue.OrderLicenseIssuePayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		logger.Warnw("worker_order_license_issue_unmarshal_failed", "error", err)
+		return err
+	}
+	if payload.OrderID == 0 {
+		logger.Debugw("worker_order_license_issue_skip_invalid_payload", "order_id", payload.OrderID)
+		return nil
+	}
+	if _, err := c.LicenseService.IssueForOrder(payload.OrderID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrFulfillmentExists):
+			logger.Debugw("worker_order_license_issue_skip_exists", "order_id", payload.OrderID)
+			return nil
+		case errors.Is(err, service.ErrFulfillmentInvalid):
+			logger.Debugw("worker_order_license_issue_skip_not_license", "order_id", payload.OrderID)
+			return nil
+		case errors.Is(err, service.ErrOrderStatusInvalid):
+			logger.Debugw("worker_order_license_issue_skip_invalid_status", "order_id", payload.OrderID)
+			return nil
+		case errors.Is(err, service.ErrOrderNotFound):
+			logger.Debugw("worker_order_license_issue_skip_order_not_found", "order_id", payload.OrderID)
+			return nil
+		default:
+			logger.Warnw("worker_order_license_issue_failed", "order_id", payload.OrderID, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// handleOrderFileDeliver 处理文件下载授权签发交付任务。
+func (c *Consumer) handleOrderFileDeliver(_ context.Context, task *asynq.Task) error {
+	if c == nil || task == nil {
+		logger.Debugw("worker_order_file_deliver_skip_nil", "consumer_nil", c == nil, "task_nil", task == nil)
+		return nil
+	}
+	var payload queue.OrderFileDeliverPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		logger.Warnw("worker_order_file_deliver_unmarshal_failed", "error", err)
+		return err
+	}
+	if payload.OrderID == 0 {
+		logger.Debugw("worker_order_file_deliver_skip_invalid_payload", "order_id", payload.OrderID)
+		return nil
+	}
+	if _, err := c.FileDeliveryService.DeliverForOrder(payload.OrderID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrFulfillmentExists):
+			logger.Debugw("worker_order_file_deliver_skip_exists", "order_id", payload.OrderID)
+			return nil
+		case errors.Is(err, service.ErrFulfillmentInvalid):
+			logger.Debugw("worker_order_file_deliver_skip_not_file", "order_id", payload.OrderID)
+			return nil
+		case errors.Is(err, service.ErrOrderStatusInvalid):
+			logger.Debugw("worker_order_file_deliver_skip_invalid_status", "order_id", payload.OrderID)
+			return nil
+		case errors.Is(err, service.ErrOrderNotFound):
+			logger.Debugw("worker_order_file_deliver_skip_order_not_found", "order_id", payload.OrderID)
+			return nil
+		default:
+			logger.Warnw("worker_order_file_deliver_failed", "order_id", payload.OrderID, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
 // handleOrderTimeoutCancel 处理超时未支付订单自动取消任务。
 func (c *Consumer) handleOrderTimeoutCancel(_ context.Context, task *asynq.Task) error {
 	if c == nil || task == nil {
@@ -434,6 +542,46 @@ func (c *Consumer) handleProcurementSyncAccepted(_ context.Context, _ *asynq.Tas
 	return nil
 }
 
+// handleCardReplenishRun 处理卡密自动补货巡检任务。
+func (c *Consumer) handleCardReplenishRun(_ context.Context, _ *asynq.Task) error {
+	if c == nil || c.CardReplenishService == nil {
+		logger.Debugw("worker_card_replenish_skip_nil")
+		return nil
+	}
+	c.CardReplenishService.RunAll()
+	return nil
+}
+
+// handleUpstreamBalanceCheck 处理上游余额巡检任务。
+func (c *Consumer) handleUpstreamBalanceCheck(_ context.Context, _ *asynq.Task) error {
+	if c == nil || c.UpstreamBalanceService == nil {
+		logger.Debugw("worker_upstream_balance_check_skip_nil")
+		return nil
+	}
+	c.UpstreamBalanceService.RunAll()
+	return nil
+}
+
+// handleWalletLedgerAudit 处理钱包账本核对任务。
+func (c *Consumer) handleWalletLedgerAudit(_ context.Context, _ *asynq.Task) error {
+	if c == nil || c.WalletLedgerAuditService == nil {
+		logger.Debugw("worker_wallet_ledger_audit_skip_nil")
+		return nil
+	}
+	c.WalletLedgerAuditService.RunAll()
+	return nil
+}
+
+// handleIdempotencyPurge 处理过期幂等键清理任务。
+func (c *Consumer) handleIdempotencyPurge(_ context.Context, _ *asynq.Task) error {
+	if c == nil || c.IdempotencyService == nil {
+		logger.Debugw("worker_idempotency_purge_skip_nil")
+		return nil
+	}
+	c.IdempotencyService.PurgeExpired()
+	return nil
+}
+
 // handleDownstreamCallback 处理下游回调发送任务。
 func (c *Consumer) handleDownstreamCallback(_ context.Context, task *asynq.Task) error {
 	if c == nil || task == nil || c.DownstreamCallbackService == nil {
@@ -458,6 +606,30 @@ func (c *Consumer) handleDownstreamCallback(_ context.Context, task *asynq.Task)
 	return nil
 }
 
+// handleCatalogWebhookDispatch 处理商品目录变更推送任务。
+func (c *Consumer) handleCatalogWebhookDispatch(_ context.Context, task *asynq.Task) error {
+	if c == nil || task == nil || c.CatalogWebhookService == nil {
+		logger.Debugw("worker_catalog_webhook_skip_nil")
+		return nil
+	}
+	var payload queue.CatalogWebhookDispatchPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		logger.Warnw("worker_catalog_webhook_unmarshal_failed", "error", err)
+		return err
+	}
+	if payload.ProductID == 0 {
+		return nil
+	}
+	// 投递失败已记录在 Webhook 上，由下游轮询兜底，不重试整个任务
+	if err := c.CatalogWebhookService.Dispatch(payload.ProductID); err != nil {
+		logger.Warnw("worker_catalog_webhook_dispatch_failed",
+			"product_id", payload.ProductID,
+			"error", err,
+		)
+	}
+	return nil
+}
+
 // handleReconciliationRun 处理对账任务执行。
 func (c *Consumer) handleReconciliationRun(ctx context.Context, task *asynq.Task) error {
 	if c == nil || task == nil || c.ReconciliationService == nil {
@@ -85,6 +85,42 @@ func registerPeriodicTasks(scheduler *asynq.Scheduler, consumer *Consumer, cfg *
 			}
 		}
 	}
+	if consumer.CardReplenishService != nil {
+		task := queue.NewCardReplenishRunTask()
+		entryID, err := scheduler.Register("@every 5m", task, asynq.Queue(queue.DefaultQueue))
+		if err != nil {
+			logger.Warnw("scheduler_register_card_replenish_failed", "error", err)
+		} else {
+			logger.Infow("scheduler_register_card_replenish_ok", "entry_id", entryID)
+		}
+	}
+	if consumer.UpstreamBalanceService != nil {
+		task := queue.NewUpstreamBalanceCheckTask()
+		entryID, err := scheduler.Register("@every 10m", task, asynq.Queue(queue.DefaultQueue))
+		if err != nil {
+			logger.Warnw("scheduler_register_upstream_balance_check_failed", "error", err)
+		} else {
+			logger.Infow("scheduler_register_upstream_balance_check_ok", "entry_id", entryID)
+		}
+	}
+	if consumer.WalletLedgerAuditService != nil {
+		task := queue.NewWalletLedgerAuditTask()
+		entryID, err := scheduler.Register("@every 6h", task, asynq.Queue(queue.DefaultQueue))
+		if err != nil {
+			logger.Warnw("scheduler_register_wallet_ledger_audit_failed", "error", err)
+		} else {
+			logger.Infow("scheduler_register_wallet_ledger_audit_ok", "entry_id", entryID)
+		}
+	}
+	if consumer.IdempotencyService != nil {
+		task := queue.NewIdempotencyPurgeTask()
+		entryID, err := scheduler.Register("@every 1h", task, asynq.Queue(queue.DefaultQueue))
+		if err != nil {
+			logger.Warnw("scheduler_register_idempotency_purge_failed", "error", err)
+		} else {
+			logger.Infow("scheduler_register_idempotency_purge_ok", "entry_id", entryID)
+		}
+	}
 	if consumer.ProcurementOrderService != nil {
 		task := queue.NewProcurementSyncAcceptedTask()
 		entryID, err := scheduler.Register("@every 30m", task, asynq.Queue(queue.DefaultQueue))